@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+	"github.com/Askeban/llm-router-go/internal/services"
+)
+
+// Budget caps each benchmark's per-operation cost; a zero limit is unchecked
+type Budget struct {
+	Benchmarks map[string]Limit `json:"benchmarks"`
+}
+
+// Limit is the most one operation may cost before the run fails
+type Limit struct {
+	MaxNsPerOp     int64 `json:"max_ns_per_op"`
+	MaxAllocsPerOp int64 `json:"max_allocs_per_op"`
+	MaxBytesPerOp  int64 `json:"max_bytes_per_op"`
+}
+
+// BenchResult is one benchmark's measurement and any budget it exceeded
+type BenchResult struct {
+	Name        string   `json:"name"`
+	Iterations  int      `json:"iterations"`
+	NsPerOp     int64    `json:"ns_per_op"`
+	AllocsPerOp int64    `json:"allocs_per_op"`
+	BytesPerOp  int64    `json:"bytes_per_op"`
+	Violations  []string `json:"violations,omitempty"`
+}
+
+// benchmark is a named workload run through testing.Benchmark
+type benchmark struct {
+	name string
+	fn   func(b *testing.B)
+}
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	modelPath := fs.String("models", defaultModelPath(), "catalog file to score against")
+	budgetPath := fs.String("budget", "", "performance budget to enforce (JSON)")
+	writeBudget := fs.String("write-budget", "", "write a budget with -headroom over this run's results")
+	headroom := fs.Float64("headroom", 3, "multiplier applied to results by -write-budget")
+	tolerance := fs.Float64("tolerance", 0, "extra fraction allowed over the budget, e.g. 0.1 for 10%")
+	ignoreTime := fs.Bool("ignore-time", false, "enforce only allocs/op and B/op, for hosts with noisy timings")
+	only := fs.String("run", "", "comma-separated benchmarks to run (default: all)")
+	corpusPath := fs.String("corpus", "", "file with one prompt per line (default: synthetic corpus)")
+	corpusSize := fs.Int("corpus-size", 200, "synthetic prompts to generate")
+	seed := fs.Int64("seed", 1, "synthetic corpus seed")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+
+	quietLogs(*verbose)
+
+	prompts, err := loadCorpus(*corpusPath, *corpusSize, *seed)
+	if err != nil {
+		return err
+	}
+	// The smart benchmark measures scoring every prompt, so its service never
+	// reuses a ranking; memo hits are measured on a second service
+	memoOff := services.DefaultMemoConfig()
+	memoOff.Enabled = false
+	memoOn := services.DefaultMemoConfig()
+	memoOn.Enabled = true
+	routerService, err := services.NewReplicatedRouterService(*modelPath)
+	if err != nil {
+		return fmt.Errorf("failed to load catalog %s: %w", *modelPath, err)
+	}
+	routerService.SetMemoConfig(memoOff)
+	memoService, err := services.NewReplicatedRouterService(*modelPath)
+	if err != nil {
+		return fmt.Errorf("failed to load catalog %s: %w", *modelPath, err)
+	}
+	memoService.SetMemoConfig(memoOn)
+
+	var budget *Budget
+	if *budgetPath != "" {
+		if budget, err = loadBudget(*budgetPath); err != nil {
+			return err
+		}
+	}
+
+	selected := map[string]bool{}
+	for _, name := range strings.Split(*only, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			selected[name] = true
+		}
+	}
+
+	var results []BenchResult
+	for _, bm := range benchmarks(routerService, memoService, prompts) {
+		if len(selected) > 0 && !selected[bm.name] {
+			continue
+		}
+		r := testing.Benchmark(bm.fn)
+		result := BenchResult{
+			Name:        bm.name,
+			Iterations:  r.N,
+			NsPerOp:     r.NsPerOp(),
+			AllocsPerOp: r.AllocsPerOp(),
+			BytesPerOp:  r.AllocedBytesPerOp(),
+		}
+		if budget != nil {
+			result.Violations = checkBudget(result, budget.Benchmarks[bm.name], *tolerance, *ignoreTime)
+		}
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else {
+		printBenchResults(results)
+	}
+
+	if *writeBudget != "" {
+		if err := saveBudget(*writeBudget, results, *headroom); err != nil {
+			return err
+		}
+	}
+
+	var failed []string
+	for _, r := range results {
+		if len(r.Violations) > 0 {
+			failed = append(failed, r.Name)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("performance budget exceeded by %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// benchmarks covers the classifier, the scoring engine alone, the full smart
+// path that chains them, and the smart path answered from the memo
+func benchmarks(routerService, memoService *services.EnhancedRouterService, prompts []string) []benchmark {
+	classifier := classification.NewTaskClassifier()
+	requests := make([]recommendation.RecommendationRequest, len(prompts))
+	for i, prompt := range prompts {
+		result := classifier.ClassifyPrompt(prompt)
+		requests[i] = recommendation.RecommendationRequest{
+			TaskType:        result.TaskType,
+			Category:        result.Category,
+			Subcategory:     result.Subcategory,
+			Complexity:      result.Complexity,
+			ComplexityScore: result.ComplexityScore,
+			Priority:        result.Priority,
+			Requirements:    result.Requirements,
+		}
+	}
+	ctx := context.Background()
+
+	return []benchmark{
+		{"classification", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				classifier.ClassifyPrompt(prompts[i%len(prompts)])
+			}
+		}},
+		{"scoring", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				routerService.GetDirectRecommendations(ctx, requests[i%len(requests)])
+			}
+		}},
+		{"smart", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				routerService.GetSmartRecommendations(ctx, services.SmartRecommendationRequest{Prompt: prompts[i%len(prompts)]})
+			}
+		}},
+		{"smart-memo", func(b *testing.B) {
+			// Route every prompt once so each timed request is a memo hit
+			for _, prompt := range prompts {
+				memoService.GetSmartRecommendations(ctx, services.SmartRecommendationRequest{Prompt: prompt})
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				memoService.GetSmartRecommendations(ctx, services.SmartRecommendationRequest{Prompt: prompts[i%len(prompts)]})
+			}
+		}},
+	}
+}
+
+// checkBudget lists every limit the result exceeds after tolerance. Wall-clock
+// time is skipped with ignoreTime; allocations are stable across hosts.
+func checkBudget(r BenchResult, limit Limit, tolerance float64, ignoreTime bool) []string {
+	var violations []string
+	check := func(metric string, got, max int64) {
+		if max <= 0 {
+			return
+		}
+		allowed := int64(math.Ceil(float64(max) * (1 + tolerance)))
+		if got > allowed {
+			violations = append(violations, fmt.Sprintf("%s %d > %d", metric, got, allowed))
+		}
+	}
+	if !ignoreTime {
+		check("ns/op", r.NsPerOp, limit.MaxNsPerOp)
+	}
+	check("allocs/op", r.AllocsPerOp, limit.MaxAllocsPerOp)
+	check("B/op", r.BytesPerOp, limit.MaxBytesPerOp)
+	return violations
+}
+
+func loadBudget(path string) (*Budget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budget: %w", err)
+	}
+	var budget Budget
+	if err := json.Unmarshal(data, &budget); err != nil {
+		return nil, fmt.Errorf("failed to parse budget %s: %w", path, err)
+	}
+	return &budget, nil
+}
+
+func saveBudget(path string, results []BenchResult, headroom float64) error {
+	budget := Budget{Benchmarks: map[string]Limit{}}
+	scale := func(v int64) int64 { return int64(math.Ceil(float64(v) * headroom)) }
+	for _, r := range results {
+		budget.Benchmarks[r.Name] = Limit{
+			MaxNsPerOp:     scale(r.NsPerOp),
+			MaxAllocsPerOp: scale(r.AllocsPerOp),
+			MaxBytesPerOp:  scale(r.BytesPerOp),
+		}
+	}
+
+	data, err := json.MarshalIndent(budget, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode budget: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write budget: %w", err)
+	}
+	return nil
+}
+
+func printBenchResults(results []BenchResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "BENCHMARK\tITERATIONS\tNS/OP\tALLOCS/OP\tB/OP\tBUDGET")
+	for _, r := range results {
+		status := "ok"
+		if len(r.Violations) > 0 {
+			status = "FAIL: " + strings.Join(r.Violations, "; ")
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", r.Name, r.Iterations, r.NsPerOp, r.AllocsPerOp, r.BytesPerOp, status)
+	}
+	w.Flush()
+}
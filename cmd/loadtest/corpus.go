@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// corpusTemplates cover every classifier category at several complexities so
+// load exercises the same branches production traffic does
+var corpusTemplates = []string{
+	"Write a %s function that parses %s and returns an error on invalid input",
+	"Fix this bug in my %s service: requests to %s time out under load",
+	"Write a SQL query that joins orders and customers and groups revenue by %s for %s",
+	"Build a React component that renders %s with pagination and sorting by %s",
+	"Solve the equation for x and explain each step: %s = %s",
+	"Calculate the probability that %s given %s, showing the formula",
+	"Analyze the trade-offs between %s and %s for a team of ten engineers",
+	"Evaluate the argument that %s leads to %s and point out any logical flaws",
+	"Write a blog post about %s for readers interested in %s",
+	"Draft a professional email to a customer explaining a delay with %s and offering %s",
+	"Summarize the key findings of this report on %s and its impact on %s",
+	"Review these metrics and identify trends in %s across %s",
+	"Generate a photorealistic image of %s at %s",
+	"Design a creative, colorful poster about %s in the style of %s",
+	"Explain %s to a beginner, then give an expert-level comparison with %s",
+}
+
+var corpusTopics = []string{
+	"Go", "Python", "Rust", "TypeScript", "JSON", "CSV files", "a payment API", "Kubernetes",
+	"quarterly revenue", "user churn", "climate policy", "remote work", "supply chains",
+	"3x + 7", "2x^2 - 8", "matrix inversion", "a mountain lake", "sunset over a city",
+	"microservices", "a monolith", "vector databases", "full-text search", "art deco",
+}
+
+// loadCorpus reads one prompt per line from path, or generates size synthetic
+// prompts from the templates when path is empty
+func loadCorpus(path string, size int, seed int64) ([]string, error) {
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open corpus: %w", err)
+		}
+		defer f.Close()
+
+		var prompts []string
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				prompts = append(prompts, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read corpus: %w", err)
+		}
+		if len(prompts) == 0 {
+			return nil, fmt.Errorf("corpus %s has no prompts", path)
+		}
+		return prompts, nil
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	prompts := make([]string, size)
+	for i := range prompts {
+		template := corpusTemplates[r.Intn(len(corpusTemplates))]
+		prompts[i] = fmt.Sprintf(template, corpusTopics[r.Intn(len(corpusTopics))], corpusTopics[r.Intn(len(corpusTopics))])
+	}
+	return prompts, nil
+}
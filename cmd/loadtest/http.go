@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	httpHandlers "github.com/Askeban/llm-router-go/internal/http"
+	"github.com/Askeban/llm-router-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// endpoints maps the -endpoints names to the paths they drive
+var endpoints = map[string]string{
+	"smart":    "/api/v2/recommend/smart",
+	"direct":   "/api/v2/recommend/direct",
+	"classify": "/api/v2/classify",
+}
+
+// EndpointReport summarizes one endpoint's latency distribution
+type EndpointReport struct {
+	Endpoint   string         `json:"endpoint"`
+	Requests   int            `json:"requests"`
+	Errors     int            `json:"errors"`
+	Statuses   map[int]int    `json:"statuses"`
+	Throughput float64        `json:"throughput_rps"`
+	Latency    LatencySummary `json:"latency_ms"`
+}
+
+// LatencySummary holds latency percentiles in milliseconds
+type LatencySummary struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// AllocReport is the process's allocation activity per request. In-process
+// runs include the server; remote runs measure the load generator only.
+type AllocReport struct {
+	InProcess        bool    `json:"in_process"`
+	AllocsPerRequest float64 `json:"allocs_per_request"`
+	BytesPerRequest  float64 `json:"bytes_per_request"`
+	GCCycles         uint32  `json:"gc_cycles"`
+	GCPauseTotalMs   float64 `json:"gc_pause_total_ms"`
+}
+
+// HTTPReport is the result of one load run
+type HTTPReport struct {
+	Target      string           `json:"target"`
+	Concurrency int              `json:"concurrency"`
+	Duration    float64          `json:"duration_seconds"`
+	Endpoints   []EndpointReport `json:"endpoints"`
+	Allocations AllocReport      `json:"allocations"`
+}
+
+type sample struct {
+	endpoint string
+	latency  time.Duration
+	status   int
+	err      bool
+}
+
+func runHTTP(args []string) error {
+	fs := flag.NewFlagSet("http", flag.ExitOnError)
+	baseURL := fs.String("url", "", "router base URL; empty serves the local catalog in-process")
+	modelPath := fs.String("models", defaultModelPath(), "catalog file for the in-process server")
+	apiKey := fs.String("api-key", os.Getenv("ROUTER_API_KEY"), "bearer credential for a remote router")
+	concurrency := fs.Int("c", 8, "concurrent workers")
+	duration := fs.Duration("duration", 10*time.Second, "how long to run")
+	requests := fs.Int("n", 0, "stop after this many requests (0 runs for -duration)")
+	endpointList := fs.String("endpoints", "smart,classify", "comma-separated endpoints: smart, direct, classify")
+	corpusPath := fs.String("corpus", "", "file with one prompt per line (default: synthetic corpus)")
+	corpusSize := fs.Int("corpus-size", 500, "synthetic prompts to generate")
+	seed := fs.Int64("seed", 1, "synthetic corpus seed")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+
+	if *concurrency < 1 {
+		return fmt.Errorf("-c must be at least 1")
+	}
+	var names []string
+	for _, name := range strings.Split(*endpointList, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := endpoints[name]; !ok {
+			return fmt.Errorf("unknown endpoint %q (valid: smart, direct, classify)", name)
+		}
+		names = append(names, name)
+	}
+	quietLogs(*verbose)
+
+	prompts, err := loadCorpus(*corpusPath, *corpusSize, *seed)
+	if err != nil {
+		return err
+	}
+	bodies := buildBodies(names, prompts)
+
+	target := *baseURL
+	inProcess := target == ""
+	if inProcess {
+		server, err := startLocalServer(*modelPath)
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+		target = server.URL
+	}
+	target = strings.TrimRight(target, "/")
+
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	results := make(chan sample, *concurrency*4)
+	var issued int64
+	deadline := time.Now().Add(*duration)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := worker; ; i += *concurrency {
+				if *requests > 0 {
+					if atomic.AddInt64(&issued, 1) > int64(*requests) {
+						return
+					}
+				} else if time.Now().After(deadline) {
+					return
+				}
+				name := names[i%len(names)]
+				body := bodies[name][i%len(prompts)]
+				results <- send(client, target+endpoints[name], *apiKey, name, body)
+			}
+		}(w)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	byEndpoint := make(map[string][]sample)
+	for s := range results {
+		byEndpoint[s.endpoint] = append(byEndpoint[s.endpoint], s)
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	report := HTTPReport{Target: target, Concurrency: *concurrency, Duration: elapsed.Seconds()}
+	total := 0
+	for _, name := range names {
+		samples := byEndpoint[name]
+		total += len(samples)
+		report.Endpoints = append(report.Endpoints, summarize(endpoints[name], samples, elapsed))
+	}
+	report.Allocations = AllocReport{InProcess: inProcess, GCCycles: after.NumGC - before.NumGC,
+		GCPauseTotalMs: float64(after.PauseTotalNs-before.PauseTotalNs) / 1e6}
+	if total > 0 {
+		report.Allocations.AllocsPerRequest = float64(after.Mallocs-before.Mallocs) / float64(total)
+		report.Allocations.BytesPerRequest = float64(after.TotalAlloc-before.TotalAlloc) / float64(total)
+	}
+
+	if *asJSON {
+		return printJSON(report)
+	}
+	printHTTPReport(report)
+	return nil
+}
+
+// buildBodies prepares every request body up front so encoding is not measured
+func buildBodies(names []string, prompts []string) map[string][][]byte {
+	classifier := classification.NewTaskClassifier()
+	bodies := make(map[string][][]byte, len(names))
+	for _, name := range names {
+		list := make([][]byte, len(prompts))
+		for i, prompt := range prompts {
+			var payload interface{} = map[string]string{"prompt": prompt}
+			if name == "direct" {
+				result := classifier.ClassifyPrompt(prompt)
+				payload = map[string]string{
+					"task_type":  result.TaskType,
+					"category":   result.Category,
+					"complexity": result.Complexity,
+					"priority":   result.Priority,
+				}
+			}
+			list[i], _ = json.Marshal(payload)
+		}
+		bodies[name] = list
+	}
+	return bodies
+}
+
+// startLocalServer serves the enhanced endpoints over the local catalog
+func startLocalServer(modelPath string) (*httptest.Server, error) {
+	routerService, err := services.NewReplicatedRouterService(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog %s: %w", modelPath, err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	httpHandlers.NewEnhancedHandlers(routerService).SetupEnhancedRoutes(r)
+	return httptest.NewServer(r), nil
+}
+
+func send(client *http.Client, url, apiKey, endpoint string, body []byte) sample {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return sample{endpoint: endpoint, err: true}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return sample{endpoint: endpoint, latency: time.Since(start), err: true}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	latency := time.Since(start)
+	return sample{endpoint: endpoint, latency: latency, status: resp.StatusCode, err: resp.StatusCode >= 400}
+}
+
+func summarize(endpoint string, samples []sample, elapsed time.Duration) EndpointReport {
+	report := EndpointReport{Endpoint: endpoint, Requests: len(samples), Statuses: map[int]int{}}
+	if len(samples) == 0 {
+		return report
+	}
+
+	latencies := make([]float64, 0, len(samples))
+	sum := 0.0
+	for _, s := range samples {
+		if s.err {
+			report.Errors++
+		}
+		if s.status != 0 {
+			report.Statuses[s.status]++
+		}
+		ms := float64(s.latency) / float64(time.Millisecond)
+		latencies = append(latencies, ms)
+		sum += ms
+	}
+	sort.Float64s(latencies)
+
+	report.Throughput = float64(len(samples)) / elapsed.Seconds()
+	report.Latency = LatencySummary{
+		Mean: sum / float64(len(latencies)),
+		P50:  percentile(latencies, 0.50),
+		P95:  percentile(latencies, 0.95),
+		P99:  percentile(latencies, 0.99),
+		Max:  latencies[len(latencies)-1],
+	}
+	return report
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func printHTTPReport(report HTTPReport) {
+	fmt.Printf("target=%s concurrency=%d duration=%.1fs\n\n", report.Target, report.Concurrency, report.Duration)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ENDPOINT\tREQUESTS\tERRORS\tRPS\tP50_MS\tP95_MS\tP99_MS\tMAX_MS")
+	for _, e := range report.Endpoints {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\n",
+			e.Endpoint, e.Requests, e.Errors, e.Throughput, e.Latency.P50, e.Latency.P95, e.Latency.P99, e.Latency.Max)
+	}
+	w.Flush()
+
+	scope := "load generator only"
+	if report.Allocations.InProcess {
+		scope = "server and load generator"
+	}
+	a := report.Allocations
+	fmt.Printf("\nallocations (%s): %.0f allocs/request, %.0f bytes/request, %d GC cycles, %.1fms GC pause\n",
+		scope, a.AllocsPerRequest, a.BytesPerRequest, a.GCCycles, a.GCPauseTotalMs)
+}
@@ -0,0 +1,82 @@
+// Command loadtest drives the recommendation and classification endpoints
+// under concurrent load, and benchmarks the scoring engine in-process against
+// a performance budget so regressions fail CI.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+const usage = `loadtest - load and performance budget checks
+
+Usage:
+  loadtest http [flags]    drive /api/v2 endpoints and report latency percentiles
+  loadtest bench [flags]   benchmark scoring and classification in-process,
+                           failing when a result exceeds the performance budget
+
+Run "loadtest <command> -h" for command flags. CI enforces the budget with:
+
+  loadtest bench -models configs/perf_catalog.json -budget configs/perf_budget.json -ignore-time
+
+CI gates on allocs/op and B/op only, since wall-clock time varies between build
+hosts; run without -ignore-time (optionally with -tolerance) on a quiet machine
+to check ns/op as well. configs/perf_catalog.json is a fixed catalog sample so
+budgets do not move as the production catalog grows; regenerate the budget
+with -write-budget.
+
+Environment:
+  MODEL_PATH      catalog file (default ./configs/model_1.json)
+  ROUTER_API_KEY  bearer credential sent to a remote router
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "http":
+		err = runHTTP(args)
+	case "bench":
+		err = runBench(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadtest: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// defaultModelPath mirrors the servers' MODEL_PATH default
+func defaultModelPath() string {
+	if path := os.Getenv("MODEL_PATH"); path != "" {
+		return path
+	}
+	return "./configs/model_1.json"
+}
+
+// quietLogs hides service logging unless -v is given
+func quietLogs(verbose bool) {
+	if !verbose {
+		log.SetOutput(io.Discard)
+	}
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/services"
+)
+
+// setupLegacyRoutes registers the routes the standalone enhanced server
+// served besides /api/v2: the v1 /recommend shape and development shortcuts
+func setupLegacyRoutes(r *gin.Engine) {
+	// Legacy compatibility endpoint
+	r.POST("/recommend", func(c *gin.Context) {
+		var legacyReq struct {
+			Category   string `json:"category"`
+			Difficulty string `json:"difficulty"`
+		}
+
+		if err := c.ShouldBindJSON(&legacyReq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request format",
+			})
+			return
+		}
+
+		// Convert legacy request to new format
+		prompt := "I need help with " + legacyReq.Category + " task"
+		if legacyReq.Difficulty != "" {
+			prompt += " with " + legacyReq.Difficulty + " complexity"
+		}
+
+		smartReq := services.SmartRecommendationRequest{
+			Prompt: prompt,
+		}
+
+		response := routerService.GetSmartRecommendations(c.Request.Context(), smartReq)
+		ranked := response.Recommendations.Recommendations
+		if len(ranked) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":          "No models match the request",
+				"classification": response.Classification,
+			})
+			return
+		}
+
+		// Return in legacy format for backward compatibility
+		c.JSON(http.StatusOK, gin.H{
+			"top_model":      ranked[0].Model,
+			"ranked_models":  ranked,
+			"classification": response.Classification,
+		})
+	})
+
+	// Quick test endpoints for development
+	for path, prompt := range map[string]string{
+		"/test/text":  "Write a Python function to calculate fibonacci numbers with optimizations",
+		"/test/image": "Generate a photorealistic image of a sunset over mountains",
+		"/test/video": "Create a 30-second marketing video with professional quality",
+	} {
+		prompt := prompt
+		r.GET(path, func(c *gin.Context) {
+			req := services.SmartRecommendationRequest{
+				Prompt: prompt,
+			}
+			response := routerService.GetSmartRecommendations(c.Request.Context(), req)
+			c.JSON(http.StatusOK, response)
+		})
+	}
+}
@@ -0,0 +1,1649 @@
+// Command router is the RouteLLM API server. ROUTER_PROFILE selects which
+// features it serves: production (auth, tenants and the v2 engine over
+// Postgres), enhanced (the v2 engine alone, without a database) or auth.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/anomaly"
+	"github.com/Askeban/llm-router-go/internal/apischema"
+	"github.com/Askeban/llm-router-go/internal/archive"
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/auth"
+	"github.com/Askeban/llm-router-go/internal/availability"
+	"github.com/Askeban/llm-router-go/internal/calibration"
+	"github.com/Askeban/llm-router-go/internal/catalog"
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/config"
+	"github.com/Askeban/llm-router-go/internal/costguard"
+	"github.com/Askeban/llm-router-go/internal/drift"
+	"github.com/Askeban/llm-router-go/internal/encryption"
+	"github.com/Askeban/llm-router-go/internal/eval"
+	"github.com/Askeban/llm-router-go/internal/export"
+	"github.com/Askeban/llm-router-go/internal/fallback"
+	"github.com/Askeban/llm-router-go/internal/feedback"
+	"github.com/Askeban/llm-router-go/internal/finetune"
+	"github.com/Askeban/llm-router-go/internal/fingerprint"
+	"github.com/Askeban/llm-router-go/internal/flags"
+	"github.com/Askeban/llm-router-go/internal/generate"
+	"github.com/Askeban/llm-router-go/internal/graphql"
+	"github.com/Askeban/llm-router-go/internal/health"
+	httpHandlers "github.com/Askeban/llm-router-go/internal/http"
+	"github.com/Askeban/llm-router-go/internal/ingest"
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/migration"
+	"github.com/Askeban/llm-router-go/internal/mirror"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/overlay"
+	"github.com/Askeban/llm-router-go/internal/pricing"
+	"github.com/Askeban/llm-router-go/internal/privacy"
+	"github.com/Askeban/llm-router-go/internal/prompttemplate"
+	"github.com/Askeban/llm-router-go/internal/providers"
+	"github.com/Askeban/llm-router-go/internal/quality"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+	"github.com/Askeban/llm-router-go/internal/replication"
+	"github.com/Askeban/llm-router-go/internal/ruleoverlay"
+	"github.com/Askeban/llm-router-go/internal/rulestore"
+	"github.com/Askeban/llm-router-go/internal/safety"
+	"github.com/Askeban/llm-router-go/internal/secrets"
+	"github.com/Askeban/llm-router-go/internal/services"
+	"github.com/Askeban/llm-router-go/internal/signing"
+	"github.com/Askeban/llm-router-go/internal/sso"
+	"github.com/Askeban/llm-router-go/internal/statshistory"
+	"github.com/Askeban/llm-router-go/internal/status"
+	"github.com/Askeban/llm-router-go/internal/trace"
+	"github.com/Askeban/llm-router-go/internal/training"
+	"github.com/Askeban/llm-router-go/internal/trust"
+	"github.com/Askeban/llm-router-go/internal/tuning"
+	"github.com/Askeban/llm-router-go/internal/twofactor"
+	"github.com/Askeban/llm-router-go/internal/usage"
+	"github.com/Askeban/llm-router-go/internal/vault"
+	"github.com/Askeban/llm-router-go/internal/warmup"
+)
+
+var (
+	db            *sql.DB
+	alertManager  *alerts.Manager
+	alertHandlers *alerts.Handlers
+	dbDSN         string
+	routerService *services.EnhancedRouterService
+	authHandlers  *auth.Handlers
+
+	twoFactorStore    *twofactor.Store
+	twoFactorHandlers *twofactor.Handlers
+
+	ssoStore    *sso.Store
+	ssoHandlers *sso.Handlers
+
+	concurrencyLimiter *auth.ConcurrencyLimiter
+	rateLimiter        *auth.RateLimiter
+	tokenQuota         *auth.TokenQuota
+	promptCap          *limits.PromptCap
+	loadShedder        *limits.Shedder
+
+	catalogHandlers *catalog.Handlers
+
+	providerRegistry    *providers.Registry
+	providerKeyHandlers *providers.Handlers
+	generateHandlers    *generate.Handlers
+	generator           *generate.Generator
+
+	promptTemplateHandlers *prompttemplate.Handlers
+
+	signingVerifier *signing.Verifier
+	signingHandlers *signing.Handlers
+
+	auditLogger    *audit.Logger
+	auditHandlers  *audit.Handlers
+	safetyHandlers *safety.Handlers
+
+	promptRetention *privacy.Retention
+	privacyHandlers *privacy.Handlers
+
+	secretGuard    *secrets.Guard
+	secretHandlers *secrets.Handlers
+
+	costGuardHandlers *costguard.Handlers
+
+	featureHandlers *flags.Handlers
+
+	dataKeyHandlers *encryption.Handlers
+
+	usageTracker  *usage.Tracker
+	usageHandlers *usage.Handlers
+
+	anomalyDetector *anomaly.Detector
+	anomalyHandlers *anomaly.Handlers
+	promptGuard     *fingerprint.Guard
+
+	driftHandlers *drift.Handlers
+
+	feedbackHandlers *feedback.Handlers
+
+	calibrationHandlers *calibration.Handlers
+
+	tuningHandlers *tuning.Handlers
+
+	trainingHandlers *training.Handlers
+
+	tenantModelHandlers *finetune.Handlers
+
+	overlayHandlers *overlay.Handlers
+
+	ruleOverlayHandlers    *ruleoverlay.Handlers
+	classifierRuleStore    *rulestore.Store
+	classifierRuleHandlers *rulestore.Handlers
+	classifierCorpusDir    string
+
+	ingestHandlers *ingest.Handlers
+
+	evalHandlers *eval.Handlers
+
+	qualityHandlers *quality.Handlers
+
+	archiveHandlers *archive.Handlers
+
+	exportHandlers *export.Handlers
+
+	statusMonitor *status.Monitor
+	hubIngester   *ingest.HubIngester
+
+	configHandlers *config.Handlers
+
+	graphqlHandlers *graphql.Handlers
+
+	requestMirror  *mirror.Mirror
+	mirrorHandlers *mirror.Handlers
+
+	pricingHandlers *pricing.Handlers
+
+	fallbackHandlers *fallback.Handlers
+
+	migrationHandlers *migration.Handlers
+
+	trustHandlers *trust.Handlers
+
+	availabilityHandlers *availability.Handlers
+
+	warmupHandlers *warmup.Handlers
+
+	statsHistoryHandlers *statshistory.Handlers
+
+	healthChecker *health.Checker
+	// backgroundJobs collects the heartbeats of the periodic loops
+	backgroundJobs = health.NewJobs()
+)
+
+func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("[ROUTER] Invalid configuration: %v", err)
+	}
+	log.Printf("[ROUTER] Starting RouteLLM server (%s)", cfg)
+	configHandlers = config.NewHandlers(cfg)
+
+	// Initialize database connection
+	if cfg.Server.Storage == config.StoragePostgres {
+		if err := initDatabase(cfg.Database); err != nil {
+			log.Fatalf("[ROUTER] Failed to initialize database: %v", err)
+		}
+		defer db.Close()
+	}
+
+	// Post operational events to Slack/Teams webhooks; without a database
+	// only env-configured webhooks apply
+	alertManager = alerts.NewManager(db)
+	alertManager.WatchCircuitBreakers()
+	alertHandlers = alerts.NewHandlers(alertManager)
+
+	if cfg.Server.EnableV2 {
+		// Initialize enhanced router service
+		if err := initRouterService(cfg.Catalog); err != nil {
+			log.Fatalf("[ROUTER] Failed to initialize router service: %v", err)
+		}
+
+		// Downrank or exclude models during provider incidents
+		if err := initStatusMonitor(cfg.Status.PollInterval); err != nil {
+			log.Fatalf("[STATUS] Failed to initialize status monitor: %v", err)
+		}
+
+		// Enrich open models with Hugging Face Hub popularity and metadata
+		if cfg.HuggingFace.PollInterval > 0 {
+			hubIngester = ingest.NewHubIngester(routerService.FusionService(), cfg.HuggingFace.Token.Value())
+		}
+
+		// Persist stats snapshots for trend dashboards
+		if db != nil {
+			statsRecorder := statshistory.NewRecorder(db, routerService)
+			statsRecorder.Start(backgroundJobs.Context(context.Background(), "stats_history", 5*time.Minute), 5*time.Minute)
+			statsHistoryHandlers = statshistory.NewHandlers(statsRecorder)
+		}
+	}
+
+	// Initialize auth handlers
+	if cfg.Server.EnableAuth {
+		if err := initAuthHandlers(cfg.Auth, cfg.Redis); err != nil {
+			log.Fatalf("[ROUTER] Failed to initialize auth handlers: %v", err)
+		}
+	}
+
+	if cfg.Tenants() {
+		initTenantServices(cfg)
+	}
+
+	// Started after tenant services, which persist its metrics when they run
+	if hubIngester != nil {
+		interval := cfg.HuggingFace.PollInterval
+		hubIngester.Start(backgroundJobs.Context(context.Background(), "huggingface", interval), interval)
+	}
+
+	// Check every dependency for /readyz
+	initHealth(cfg)
+
+	// Setup Gin router
+	r := setupRouter(cfg)
+
+	// Start server with graceful shutdown
+	startServer(r, cfg)
+}
+
+// initTenantServices starts the features that act on behalf of tenants and
+// so need both auth and the engine
+func initTenantServices(cfg *config.Config) {
+	// Initialize provider registry and BYOK key vault
+	initProviderRegistry()
+
+	// Keep self-hosted models loaded ahead of their traffic
+	initWarmup()
+
+	// Initialize audit log and content safety gate
+	initSafetyGate()
+
+	// Catch API keys, private keys and tokens before prompts reach providers
+	initSecretGuard()
+
+	// Hold back generations that could cost more than the key's threshold until confirmed
+	costThresholds := costguard.NewStore(db, cfg.Limits.HighCostUSD)
+	generateHandlers.SetCostThresholds(costThresholds)
+	costGuardHandlers = costguard.NewHandlers(costThresholds)
+
+	// Gate race mode, webhooks and custom models per plan, organization and user
+	featureFlags := flags.NewService(db, auditLogger)
+	generateHandlers.SetFeatures(featureFlags)
+	featureHandlers = flags.NewHandlers(featureFlags)
+
+	// Audit two-factor enrollments, backup code use and policy changes
+	if twoFactorStore != nil {
+		twoFactorStore.SetAuditLog(auditLogger)
+	}
+	// Audit SSO connection changes and logins
+	if ssoStore != nil {
+		ssoStore.SetAuditLog(auditLogger)
+	}
+
+	// Enforce tenant prompt logging policies and retention
+	policies := initPrivacy()
+
+	// Compare a staging router's recommendations against production's
+	initMirror(cfg.Mirror, policies)
+
+	// Encrypt stored prompts with per-tenant data keys
+	initPromptEncryption(cfg.Encryption.DataKeyMaxAge)
+
+	// Initialize usage tracking and dashboard analytics
+	usageTracker = usage.NewTracker(db)
+	usageHandlers = usage.NewHandlers(usageTracker)
+
+	// Learn how long generations run per category for cost and latency estimates
+	outputLengths := usage.NewOutputLengths(db, 7*24*time.Hour, 20)
+	outputLengths.Start(backgroundJobs.Context(context.Background(), "output_lengths", 15*time.Minute), 15*time.Minute)
+	routerService.SetOutputHistory(outputLengths)
+	generateHandlers.SetCategorizer(routerService)
+
+	// Roll up, export or drop usage and metrics rows past their retention
+	if err := initArchive(); err != nil {
+		log.Printf("[ARCHIVE] Archival disabled: %v", err)
+	}
+
+	// Ship tenants' usage and audit records to their own warehouses
+	initExports()
+
+	// Flag (and optionally throttle) keys whose usage suggests they leaked
+	anomalyDetector = anomaly.NewDetector(db, anomaly.DefaultConfig())
+	anomalyDetector.SetAlerts(alertManager)
+	anomalyHandlers = anomaly.NewHandlers(anomalyDetector)
+	anomalyHandlers.SetSecrets(secretGuard)
+
+	// Fingerprint prompts to catch duplicate traffic per key
+	promptGuard = fingerprint.NewGuard(fingerprint.DefaultConfig())
+	promptGuard.SetAlerts(alertManager)
+
+	// Watch the classifier's category mix per tenant for blind spots
+	driftMonitor := drift.NewMonitor(db, drift.DefaultConfig())
+	driftMonitor.SetAlerts(alertManager)
+	driftMonitor.Start(backgroundJobs.Context(context.Background(), "drift", time.Minute), time.Minute)
+	routerService.SetClassificationObserver(driftMonitor)
+	driftHandlers = drift.NewHandlers(driftMonitor)
+
+	// Learn per-tenant model affinities from feedback
+	feedbackStore := feedback.NewStore(db)
+	routerService.SetFeedbackStore(feedbackStore)
+	feedbackHandlers = feedback.NewHandlers(feedbackStore)
+
+	// Calibrate confidence against rated recommendations
+	initCalibration(cfg.Calibration.RefreshInterval)
+
+	// Fit component weights to feedback and A/B test them before promotion
+	initTuning(cfg.Tuning.RefreshInterval)
+
+	// Keep anonymized recommendation decisions for learning-to-rank exports
+	initTraining(policies)
+
+	// Route tenants to their own fine-tuned models
+	tenantModels := finetune.NewStore(db)
+	routerService.SetTenantModels(tenantModels)
+	tenantModelHandlers = finetune.NewHandlers(tenantModels, routerService.GetModelByID)
+
+	// Let tenants narrow and annotate the shared catalog
+	catalogOverlays := overlay.NewStore(db)
+	routerService.SetCatalogOverlays(catalogOverlays)
+	overlayHandlers = overlay.NewHandlers(catalogOverlays, routerService.GetAllModels)
+
+	// Warn operators when models tenants pinned leave the catalog
+	pins := overlay.NewPinValidator(catalogOverlays)
+	pins.SetAlerts(alertManager)
+	routerService.InvalidationBus().Subscribe("overlay_pins", pins.Validate)
+
+	// Let tenants teach the classifier their own jargon
+	ruleOverlays := ruleoverlay.NewStore(db, routerService.Classifier())
+	routerService.SetClassifierOverlays(ruleOverlays)
+	ruleOverlayHandlers = ruleoverlay.NewHandlers(ruleOverlays)
+
+	// Admins edit the classifier rules in the database; every replica follows
+	classifierRuleStore = rulestore.NewStore(db, routerService.Classifier(), auditLogger)
+	classifierRuleStore.Start(backgroundJobs.Context(context.Background(), "classifier_rules", 30*time.Second), 30*time.Second)
+	routerService.SetClassifierRuleStore(classifierRuleStore)
+	classifierRuleHandlers = rulestore.NewHandlers(classifierRuleStore)
+
+	// Persist Analytics AI metrics with a dead-letter queue for failed rows
+	ingester := ingest.NewIngester(db)
+	ingester.SetAlerts(alertManager)
+	routerService.FusionService().SetMetricsSink(ingester)
+	ingester.Start(backgroundJobs.Context(context.Background(), "ingest_retry", time.Minute), time.Minute)
+	ingestHandlers = ingest.NewHandlers(ingester)
+	if hubIngester != nil {
+		hubIngester.SetIngester(ingester)
+		ingestHandlers.SetHubIngester(hubIngester)
+	}
+
+	// Operators override per-category fallback models; every replica follows
+	fallbackStore := fallback.NewStore(db, routerService.Fallbacks())
+	fallbackStore.Start(backgroundJobs.Context(context.Background(), "fallbacks", time.Minute), time.Minute)
+	fallbackHandlers = fallback.NewHandlers(fallbackStore, routerService.GetModelByID)
+
+	// Record provider price changes and tell the tenants depending on the model
+	priceTracker := pricing.NewTracker(db, pricing.DefaultConfig())
+	priceTracker.SetAlerts(alertManager)
+	priceTracker.SetCatalog(routerService.FusionService())
+	routerService.FusionService().SetPricingObserver(priceTracker)
+	go priceTracker.Start(context.Background(), routerService.GetAllModels())
+	pricingHandlers = pricing.NewHandlers(priceTracker)
+
+	// Suggest replacements for deprecated models tenants depend on
+	initMigrations(catalogOverlays)
+
+	// Start newly ingested models as experimental until verified
+	trustStore := trust.NewStore(db, routerService.FusionService(), auditLogger, trust.DefaultConfig())
+	trustStore.Start(backgroundJobs.Context(context.Background(), "trust", time.Minute), time.Minute)
+	trustHandlers = trust.NewHandlers(trustStore)
+
+	// Let operators disable models and schedule maintenance windows
+	availabilityStore := availability.NewStore(db, routerService.FusionService(), auditLogger)
+	availabilityStore.Start(backgroundJobs.Context(context.Background(), "availability", time.Minute), time.Minute)
+	availabilityHandlers = availability.NewHandlers(availabilityStore)
+
+	// Query the catalog, stored metrics and usage together over GraphQL
+	if err := initGraphQL(cfg.GraphQL, ingester); err != nil {
+		log.Fatalf("[ROUTER] Failed to initialize GraphQL: %v", err)
+	}
+
+	// Measure model quality with eval suites and route on the results
+	if err := initEvals(ingester, cfg.Evals.SuitesDir); err != nil {
+		log.Fatalf("[ROUTER] Failed to initialize eval suites: %v", err)
+	}
+
+	// Judge a sample of live answers and route on the scores too
+	if err := initQuality(ingester, policies); err != nil {
+		log.Fatalf("[ROUTER] Failed to initialize quality judging: %v", err)
+	}
+}
+
+func initMigrations(catalogOverlays *overlay.Store) {
+	deprecations := migration.NewDeprecations(db, routerService.FusionService())
+	deprecations.Start(backgroundJobs.Context(context.Background(), "deprecations", time.Minute), time.Minute)
+
+	assistant := migration.NewAssistant(db, routerService, catalogOverlays, auditLogger, migration.DefaultConfig())
+	assistant.Start(context.Background())
+	migrationHandlers = migration.NewHandlers(assistant, deprecations)
+}
+
+func initQuality(ingester *ingest.Ingester, policies *privacy.PolicyStore) error {
+	cfg := quality.DefaultConfig()
+	rubrics, err := quality.LoadRubrics(os.Getenv("QUALITY_RUBRICS_PATH"))
+	if err != nil {
+		return err
+	}
+	cfg.Rubrics = rubrics
+	if cfg.JudgeModel != "" {
+		if _, ok := routerService.GetModelByID(cfg.JudgeModel); !ok {
+			return fmt.Errorf("judge model %s is not in the catalog", cfg.JudgeModel)
+		}
+	}
+
+	// Scores judged on other replicas are loaded even where sampling is off
+	judge := quality.NewJudge(db, generator, ingester, cfg)
+	judge.SetPolicies(policies)
+	judge.SetQualitySink(routerService.FusionService())
+	judge.Start(backgroundJobs.Context(context.Background(), "quality", 10*time.Minute), 10*time.Minute)
+	generateHandlers.SetQualitySampler(judge)
+	qualityHandlers = quality.NewHandlers(judge)
+
+	if cfg.Enabled() {
+		log.Printf("[QUALITY] Judging %.1f%% of answers with %s", cfg.SampleRate*100, cfg.JudgeModel)
+	}
+	return nil
+}
+
+func initGraphQL(cfg config.GraphQLConfig, ingester *ingest.Ingester) error {
+	graphqlHandlers = graphql.NewHandlers(graphql.NewSchema(routerService.FusionService(), usageTracker, ingester))
+	graphqlHandlers.SetAdminChecker(authHandlers)
+
+	if cfg.PersistedQueriesDir != "" {
+		persisted, err := graphql.LoadPersistedQueries(cfg.PersistedQueriesDir, cfg.PersistedOnly)
+		if err != nil {
+			return err
+		}
+		graphqlHandlers.SetPersistedQueries(persisted)
+		log.Printf("[GRAPHQL] Loaded %d persisted queries from %s (persisted only: %t)", persisted.Len(), cfg.PersistedQueriesDir, cfg.PersistedOnly)
+	}
+	return nil
+}
+
+func initDatabase(cfg config.DatabaseConfig) error {
+	var dsn string
+	if cfg.InstanceConnectionName != "" {
+		// Cloud SQL connection via Unix socket
+		dsn = fmt.Sprintf("host=/cloudsql/%s user=%s password=%s dbname=%s sslmode=disable",
+			cfg.InstanceConnectionName, cfg.User, cfg.Password.Value(), cfg.Name)
+	} else {
+		// Direct connection
+		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=require",
+			cfg.Host, cfg.User, cfg.Password.Value(), cfg.Name)
+	}
+
+	log.Printf("[DATABASE] Connecting to PostgreSQL database: %s", cfg.Name)
+
+	dbDSN = dsn
+
+	var err error
+	db, err = sql.Open("postgres", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Configure connection pool
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	// Test connection
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	log.Println("[DATABASE] Successfully connected to PostgreSQL")
+
+	// Apply schema if needed
+	if err := applySchema(cfg.SchemaPath); err != nil {
+		return fmt.Errorf("failed to apply schema: %w", err)
+	}
+
+	return nil
+}
+
+func applySchema(schemaPath string) error {
+	// Check if schema is already applied
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'users')").Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check schema: %w", err)
+	}
+
+	if exists {
+		log.Println("[DATABASE] Schema already exists")
+		return nil
+	}
+
+	log.Println("[DATABASE] Applying database schema...")
+
+	// Read and execute schema file
+	schemaSQL, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	if _, err := db.Exec(string(schemaSQL)); err != nil {
+		return fmt.Errorf("failed to execute schema: %w", err)
+	}
+
+	log.Println("[DATABASE] Schema applied successfully")
+	return nil
+}
+
+func initRouterService(cfg config.CatalogConfig) error {
+	modelPath := cfg.ModelPath
+	log.Printf("[ROUTER] Initializing model service with path: %s", modelPath)
+
+	// With replication enabled only the elected leader fetches Analytics AI;
+	// every other replica receives the fused catalog over Postgres
+	replicate := cfg.Replication
+
+	var err error
+	if replicate {
+		routerService, err = services.NewReplicatedRouterService(modelPath)
+	} else {
+		routerService, err = services.NewEnhancedRouterService(modelPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize router service: %w", err)
+	}
+	routerService.FusionService().SetAlerts(alertManager)
+	catalogHandlers = catalog.NewHandlers(routerService.FusionService())
+
+	if replicate {
+		replicator := replication.NewCatalogReplicator(db, dbDSN, routerService.FusionService(), cfg.RefreshInterval)
+		replicator.SetAlerts(alertManager)
+		replicator.Start(backgroundJobs.Context(context.Background(), "catalog_replication", cfg.RefreshInterval))
+		routerService.SetCatalogReplicator(replicator)
+		catalogHandlers.SetPublisher(replicator)
+	}
+
+	if err := routerService.ConfigureBenchmarkMappings(cfg.BenchmarkMappingsPath); err != nil {
+		return fmt.Errorf("failed to load benchmark mappings: %w", err)
+	}
+
+	if cfg.FeaturesPath != "" {
+		features, err := models.LoadFeatureConfig(cfg.FeaturesPath)
+		if err != nil {
+			return fmt.Errorf("failed to load model features: %w", err)
+		}
+		routerService.FusionService().SetFeatureConfig(features)
+	}
+
+	if cfg.LocalesPath != "" {
+		locales, err := models.LoadLocaleScores(cfg.LocalesPath)
+		if err != nil {
+			return fmt.Errorf("failed to load locale scores: %w", err)
+		}
+		routerService.FusionService().SetLocaleScores(locales)
+	}
+
+	if cfg.FallbacksPath != "" {
+		if err := routerService.ConfigureFallbacks(cfg.FallbacksPath); err != nil {
+			return fmt.Errorf("failed to load category fallbacks: %w", err)
+		}
+	}
+
+	if cfg.ClassifierRulesPath != "" {
+		if err := routerService.ConfigureClassifierRules(cfg.ClassifierRulesPath); err != nil {
+			return fmt.Errorf("failed to load classifier rules: %w", err)
+		}
+	}
+	classifierCorpusDir = cfg.ClassifierCorpusDir
+
+	procurement, err := recommendation.ProcurementScorerFromEnv()
+	if err != nil {
+		return err
+	}
+	if procurement != nil {
+		if err := routerService.RegisterScorer(procurement, 0); err != nil {
+			return fmt.Errorf("failed to register procurement scorer: %w", err)
+		}
+	}
+
+	stats := routerService.GetStats()
+	log.Printf("[ROUTER] Service initialized:")
+	log.Printf("  - Total models: %v", stats["total_models"])
+	log.Printf("  - Data sources: %v", stats["data_sources"])
+
+	return nil
+}
+
+func initStatusMonitor(interval time.Duration) error {
+	feeds, err := status.FeedsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	monitor := status.NewMonitor(feeds, routerService.FusionService())
+	monitor.SetAlerts(alertManager)
+	if len(feeds) > 0 {
+		monitor.Start(backgroundJobs.Context(context.Background(), "status_pages", interval), interval)
+	}
+	statusMonitor = monitor
+
+	log.Printf("[STATUS] Polling %d provider status pages every %s", len(feeds), interval)
+	return nil
+}
+
+func initAuthHandlers(cfg config.AuthConfig, redisCfg config.RedisConfig) error {
+	log.Println("[AUTH] Initializing authentication handlers...")
+
+	// Create JWT manager
+	jwtManager := auth.NewJWTManager(cfg.JWTSecret.Value(), 24*time.Hour)
+
+	// Create auth service
+	authService := auth.NewService(db)
+
+	// Create auth handlers
+	authHandlers = auth.NewHandlers(authService, jwtManager)
+
+	// TOTP second factors for dashboard users, sealed by the key vault
+	if v, err := vault.NewVaultFromEnv(); err != nil {
+		log.Printf("[2FA] Two-factor authentication disabled: %v", err)
+	} else {
+		twoFactorStore = twofactor.NewStore(db, v)
+		authHandlers.SetTwoFactor(twoFactorStore)
+		twoFactorHandlers = twofactor.NewHandlers(twoFactorStore, jwtManager, cfg.TwoFactorMaxAge)
+		log.Printf("[2FA] Two-factor authentication enabled (challenges valid for %s)", cfg.TwoFactorMaxAge)
+
+		// OIDC single sign-on per organization, client secrets sealed by the same vault
+		if cfg.SSORedirectURL == "" {
+			log.Println("[SSO] auth.sso_redirect_url not set, single sign-on disabled")
+		} else {
+			ssoStore = sso.NewStore(db, v)
+			authHandlers.SetSSOPolicy(ssoStore)
+			ssoHandlers = sso.NewHandlers(ssoStore, authService, jwtManager, cfg.SSORedirectURL)
+			log.Printf("[SSO] Single sign-on enabled, returning to %s", cfg.SSORedirectURL)
+		}
+	}
+
+	// Cap in-flight requests per API key across replicas
+	concurrencyLimits, err := authService.PlanConcurrencyLimits()
+	if err != nil || len(concurrencyLimits) == 0 {
+		log.Printf("[AUTH] Using default concurrency limits: %v", err)
+		concurrencyLimits = auth.DefaultConcurrencyLimits
+	}
+	if redisCfg.Host == "" {
+		log.Println("[AUTH] redis.host not set, concurrency limits apply per replica")
+	}
+	concurrencyLimiter = auth.NewConcurrencyLimiter(redisCfg.Addr(), redisCfg.Password.Value(), 0, concurrencyLimits)
+	authHandlers.SetConcurrencyLimiter(concurrencyLimiter)
+
+	// Enforce hourly and daily plan limits, with a token bucket per key for bursts
+	planRates, err := authService.PlanRateLimits()
+	if err != nil || len(planRates) == 0 {
+		log.Printf("[AUTH] Using default rate limits: %v", err)
+		planRates = auth.DefaultPlanRates
+	}
+	rateLimiter = auth.NewRateLimiter(redisCfg.Addr(), redisCfg.Password.Value(), 0, planRates)
+	rateLimiter.SetService(authService)
+	authHandlers.SetRateLimiter(rateLimiter)
+
+	// Hold generations' worst-case tokens against each plan's monthly token ceiling
+	tokenLimits, err := authService.PlanTokenLimits()
+	if err != nil || len(tokenLimits) == 0 {
+		log.Printf("[AUTH] Using default token limits: %v", err)
+		tokenLimits = auth.DefaultTokenLimits
+	}
+	tokenQuota = auth.NewTokenQuota(redisCfg.Addr(), redisCfg.Password.Value(), 0, tokenLimits, authService)
+	authHandlers.SetTokenQuota(tokenQuota)
+
+	// Cap prompt length per plan before classification
+	promptLimits, err := authService.PlanPromptLimits()
+	if err != nil || len(promptLimits) == 0 {
+		log.Printf("[AUTH] Using default prompt limits: %v", err)
+		promptLimits = limits.DefaultPromptTokens
+	}
+	promptCap = limits.NewPromptCap(promptLimits)
+
+	log.Println("[AUTH] Authentication handlers initialized")
+	return nil
+}
+
+func initProviderRegistry() {
+	var keyStore *providers.KeyStore
+
+	v, err := vault.NewVaultFromEnv()
+	if err != nil {
+		log.Printf("[VAULT] Provider key vault disabled: %v", err)
+	} else {
+		keyStore = providers.NewKeyStore(db, v)
+		providerKeyHandlers = providers.NewHandlers(keyStore)
+		log.Println("[VAULT] Provider key vault initialized")
+
+		// Signing secrets are sealed by the same vault
+		signingStore := signing.NewStore(db, v)
+		signingVerifier = signing.NewVerifier(signingStore)
+		signingHandlers = signing.NewHandlers(signingStore, signingVerifier)
+		log.Println("[SIGNING] Request signing enabled")
+	}
+
+	providerRegistry = providers.NewRegistry(keyStore)
+	log.Printf("[PROVIDERS] Platform keys configured for: %v", providerRegistry.PlatformProviders())
+
+	// One generator, so generate mode and eval runs share the provider worker pools
+	generator = generate.NewGenerator(providerRegistry, routerService)
+	generator.SetQueues(generate.NewQueues(generate.DefaultQueueConfig()))
+	// Size max_tokens from the expected answer length when callers leave it unset
+	generator.SetOutputEstimator(routerService)
+	if tokenQuota != nil {
+		generator.SetTokenQuota(tokenQuota)
+	}
+	generateHandlers = generate.NewHandlers(generator)
+
+	// Tenants override the catalog's per-model prompt templates
+	promptTemplates := prompttemplate.NewStore(db)
+	generator.SetPromptTemplates(promptTemplates)
+	promptTemplateHandlers = prompttemplate.NewHandlers(promptTemplates, routerService.GetModelByID)
+}
+
+func initWarmup() {
+	scheduler := warmup.NewScheduler(db, generator, routerService.GetAllModels, warmup.DefaultConfig())
+	generator.SetActivityObserver(scheduler)
+	routerService.SetColdStarts(scheduler)
+	scheduler.Start(context.Background())
+	warmupHandlers = warmup.NewHandlers(scheduler)
+
+	cfg := scheduler.Config()
+	log.Printf("[WARMUP] Self-hosted models kept loaded (keep-alive %s, lead %s, always %t)", cfg.KeepAlive, cfg.Lead, cfg.Always)
+}
+
+func initEvals(ingester *ingest.Ingester, suitesDir string) error {
+	suites, err := eval.LoadSuites(suitesDir)
+	if err != nil {
+		return err
+	}
+
+	runner := eval.NewRunner(db, generator, ingester, suites)
+	runner.SetBenchmarkSink(routerService.FusionService())
+	if err := runner.Restore(context.Background()); err != nil {
+		log.Printf("[EVAL] Routing without stored eval scores: %v", err)
+	}
+	runner.Watch(context.Background(), 10*time.Minute)
+	evalHandlers = eval.NewHandlers(runner)
+
+	log.Printf("[EVAL] Loaded %d eval suites from %s", len(suites), suitesDir)
+	return nil
+}
+
+func initSafetyGate() {
+	auditLogger = audit.NewLogger(db)
+	auditHandlers = audit.NewHandlers(auditLogger)
+
+	gate := safety.NewGate(db, auditLogger)
+	routerService.SetSafetyGate(gate)
+	safetyHandlers = safety.NewHandlers(gate)
+
+	log.Println("[SAFETY] Content safety gate enabled")
+}
+
+func initSecretGuard() {
+	secretGuard = secrets.NewGuard(db, auditLogger, secrets.DefaultDetectorConfig())
+	generateHandlers.SetSecretGuard(secretGuard)
+	secretHandlers = secrets.NewHandlers(secretGuard)
+
+	log.Printf("[SECRETS] Secret leakage guard enabled (default action: %s)", secrets.DefaultPolicy().Action)
+}
+
+func initPrivacy() *privacy.PolicyStore {
+	policies := privacy.NewPolicyStore(db)
+	auditLogger.SetPromptScrubber(policies)
+
+	// Every subsystem that retains prompts registers here for TTL and purge
+	promptRetention = privacy.NewRetention(auditLogger)
+	promptRetention.SetAlerts(alertManager)
+	promptRetention.Start(backgroundJobs.Context(context.Background(), "prompt_retention", time.Hour), time.Hour)
+
+	privacyHandlers = privacy.NewHandlers(policies, promptRetention)
+
+	log.Printf("[PRIVACY] Prompt logging default: %s, retention %d days",
+		privacy.DefaultPolicy().Mode, privacy.DefaultPolicy().RetentionDays)
+	return policies
+}
+
+func initMirror(cfg config.MirrorConfig, policies *privacy.PolicyStore) {
+	if cfg.StagingURL == "" {
+		return
+	}
+	requestMirror = mirror.NewMirror(mirror.Config{
+		StagingURL:    cfg.StagingURL,
+		Percent:       cfg.Percent,
+		RedactPrompts: cfg.RedactPrompts,
+		Timeout:       cfg.Timeout,
+	})
+	requestMirror.SetPromptScrubber(policies)
+	requestMirror.Start(context.Background())
+	mirrorHandlers = mirror.NewHandlers(requestMirror)
+
+	log.Printf("[MIRROR] Mirroring %g%% of recommend requests to %s (redact prompts: %t)",
+		cfg.Percent, cfg.StagingURL, cfg.RedactPrompts)
+}
+
+func initPromptEncryption(maxAge time.Duration) {
+	v, err := vault.NewVaultFromEnv()
+	if err != nil {
+		log.Printf("[ENCRYPTION] Stored prompts are not encrypted: %v", err)
+		return
+	}
+
+	keys := encryption.NewKeyStore(db, v)
+	if maxAge > 0 {
+		keys.SetMaxAge(maxAge)
+	}
+	auditLogger.SetEncryptor(encryption.NewEncryptor(keys))
+	dataKeyHandlers = encryption.NewHandlers(keys, auditLogger)
+
+	log.Println("[ENCRYPTION] Stored prompts encrypted with per-tenant data keys")
+}
+
+func initArchive() error {
+	store, err := archive.NewObjectStoreFromEnv()
+	if err != nil {
+		return err
+	}
+
+	policies := archive.NewPolicyStore(db)
+	archiver := archive.NewArchiver(db, policies, store)
+	archiver.SetAlerts(alertManager)
+	archiver.Start(backgroundJobs.Context(context.Background(), "archive", 6*time.Hour), 6*time.Hour)
+	archiveHandlers = archive.NewHandlers(policies, archiver)
+
+	if store != nil {
+		log.Printf("[ARCHIVE] Exports go to %s", store.Location())
+	}
+	return nil
+}
+
+func initExports() {
+	v, err := vault.NewVaultFromEnv()
+	if err != nil {
+		log.Printf("[EXPORT] Data export disabled: %v", err)
+		return
+	}
+
+	store := export.NewStore(db, v)
+	exporter := export.NewExporter(db, store)
+	exporter.Start(backgroundJobs.Context(context.Background(), "exports", time.Minute), time.Minute)
+	exportHandlers = export.NewHandlers(store)
+
+	log.Println("[EXPORT] Data export to BigQuery, Snowflake and S3 enabled")
+}
+
+func initCalibration(interval time.Duration) {
+	calibrator := calibration.NewCalibrator(db)
+	calibrator.Start(backgroundJobs.Context(context.Background(), "calibration", interval), interval)
+	routerService.SetCalibrator(calibrator)
+	calibrationHandlers = calibration.NewHandlers(calibrator)
+}
+
+func initTraining(policies *privacy.PolicyStore) {
+	decisions := training.NewRecorder(db, policies)
+	routerService.SetDecisionRecorder(decisions)
+	promptRetention.Register(decisions)
+	trainingHandlers = training.NewHandlers(training.NewExporter(db))
+}
+
+func initTuning(interval time.Duration) {
+	weightTuner := tuning.NewTuner(db)
+	weightTuner.Start(backgroundJobs.Context(context.Background(), "weight_tuning", interval), interval)
+	routerService.SetWeightSource(weightTuner)
+	tuningHandlers = tuning.NewHandlers(weightTuner)
+}
+
+// setupRouter registers the routes of every enabled feature. Each route keeps
+// the path and middleware it had in the binary that used to serve it.
+func setupRouter(cfg *config.Config) *gin.Engine {
+	// Set Gin mode
+	if os.Getenv("GIN_MODE") == "" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	r := gin.New()
+	// Assign the request ID first, so access logs, errors and every later handler carry it
+	r.Use(trace.Middleware())
+	r.Use(gin.LoggerWithFormatter(trace.LogFormatter))
+	r.Use(gin.Recovery())
+	r.Use(corsMiddleware())
+
+	// Refuse oversized bodies before anything reads them; limits were validated at load
+	routeBodyBytes, _ := cfg.Limits.Routes()
+	r.Use(limits.BodyLimit(int64(cfg.Limits.MaxBodyBytes), routeBodyBytes))
+	if promptCap == nil {
+		promptCap = limits.NewPromptCap(limits.DefaultPromptTokens)
+	}
+	if cfg.Limits.ShedMaxInFlight > 0 || cfg.Limits.ShedLatencyTarget > 0 {
+		loadShedder = limits.NewShedder(cfg.Limits.ShedMaxInFlight, cfg.Limits.ShedLatencyTarget)
+	}
+
+	// Check bodies against the OpenAPI spec before any handler binds them
+	if cfg.Validation.Requests != config.ValidationOff {
+		if validator, err := apischema.NewValidator(cfg.Validation.Requests == config.ValidationEnforce, cfg.Validation.Responses); err != nil {
+			log.Printf("[SCHEMA] Request validation disabled: %v", err)
+		} else {
+			log.Printf("[SCHEMA] Validating %d operations (%s)", validator.Operations(), cfg.Validation.Requests)
+			r.Use(validator.Middleware())
+			r.GET("/openapi.yaml", validator.ServeSpec)
+		}
+	}
+
+	// Health check endpoint
+	r.GET("/health", healthCheck)
+	r.GET("/healthz", healthCheck)
+	r.GET("/livez", livenessCheck)
+	r.GET("/readyz", readinessCheck)
+
+	// Root endpoint
+	r.GET("/", rootHandler(cfg))
+
+	if cfg.Server.EnableV2 {
+		// Read-only catalog for marketing sites and docs, cacheable by CDNs
+		if publicCatalog, err := catalog.NewPublisher(routerService.FusionService()); err != nil {
+			log.Printf("[CATALOG] Public catalog disabled: %v", err)
+		} else {
+			r.GET("/public/catalog", publicCatalog.Handle)
+			r.GET("/public/catalog/key", publicCatalog.HandleKey)
+		}
+	}
+
+	if cfg.Tenants() {
+		// Identify tenants on public endpoints when a token is supplied
+		r.Use(authHandlers.OptionalAuthMiddleware())
+
+		// Verify HMAC-signed server-to-server calls and reject replays
+		if signingVerifier != nil {
+			r.Use(signingVerifier.Middleware())
+		}
+
+		// Shed low-priority traffic as the replica nears capacity, once callers
+		// are identified but before their request costs any work
+		if loadShedder != nil {
+			r.Use(loadShedder.Middleware())
+		}
+
+		// Record per-tenant usage for dashboard analytics
+		r.Use(usageTracker.Middleware())
+
+		// Watch per-key usage for spikes and throttle suspicious keys
+		r.Use(anomalyDetector.Middleware())
+
+		// Refuse prompts over the plan's token cap before they are fingerprinted or classified
+		r.Use(promptCap.Middleware())
+
+		// Record prompt fingerprints and throttle runaway duplicate traffic
+		r.Use(promptGuard.Middleware())
+
+		// Refuse requests over the plan's hourly, daily or burst limit
+		r.Use(rateLimiter.Middleware())
+
+		// Limit concurrent in-flight requests per key, streams included
+		r.Use(concurrencyLimiter.Middleware())
+
+		// Copy sampled recommend traffic to staging once production has answered
+		if requestMirror != nil {
+			r.Use(requestMirror.Middleware())
+		}
+	} else {
+		// Without tenants every caller is anonymous, so all traffic sheds as low priority
+		if loadShedder != nil {
+			r.Use(loadShedder.Middleware())
+		}
+
+		// Without tenants every caller gets the anonymous prompt cap
+		r.Use(promptCap.Middleware())
+	}
+
+	if cfg.Server.EnableV2 {
+		// Setup enhanced handlers (model recommendations)
+		enhancedHandlers := httpHandlers.NewEnhancedHandlers(routerService)
+		enhancedHandlers.SetStatusMonitor(statusMonitor)
+		enhancedHandlers.SetupEnhancedRoutes(r)
+
+		// Trends of /api/v2/stats from the persisted snapshots
+		if statsHistoryHandlers != nil {
+			r.GET("/api/v2/stats/history", statsHistoryHandlers.History)
+		}
+	}
+
+	if cfg.Server.LegacyRoutes {
+		setupLegacyRoutes(r)
+	}
+
+	if cfg.Tenants() {
+		// Feedback on recommendations drives personalized routing
+		r.POST("/api/v2/feedback", authHandlers.AuthMiddleware(), feedbackHandlers.Submit)
+
+		// Generation bills the caller's provider keys, so it always requires a tenant
+		r.POST("/api/v2/generate", authHandlers.AuthMiddleware(), generateHandlers.Generate)
+
+		// Remaining quotas, so SDKs can throttle themselves before a 429
+		r.GET("/v1/limits", authHandlers.AuthMiddleware(), authHandlers.GetLimits)
+
+		// Catalog, metrics and usage in one query; fields check the caller themselves
+		r.GET("/graphql", graphqlHandlers.Query)
+		r.POST("/graphql", graphqlHandlers.Query)
+		r.GET("/graphql/schema", graphqlHandlers.Schema)
+	}
+
+	if cfg.Server.EnableAuth {
+		// Setup authentication handlers
+		setupAuthRoutes(r)
+	}
+
+	if cfg.Tenants() {
+		// Setup dashboard handlers
+		setupDashboardRoutes(r)
+
+		// Setup operator handlers
+		setupAdminRoutes(r)
+	}
+
+	return r
+}
+
+func corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.Request.Header.Get("Origin")
+		if origin == "" {
+			origin = "*"
+		}
+
+		c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, X-Admin-Token, X-Request-ID, X-Usage-Tags")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// initHealth registers a check for every dependency this configuration uses
+func initHealth(cfg *config.Config) {
+	healthChecker = health.NewChecker()
+	if db != nil {
+		healthChecker.Register(health.Postgres(db))
+	}
+	if concurrencyLimiter != nil {
+		healthChecker.Register(health.Redis(concurrencyLimiter, concurrencyLimiter.Shared()))
+	}
+	if routerService != nil {
+		// Only the leader fetches Analytics AI when replicating, but every
+		// replica reports it so a dead leader's outage is visible anywhere
+		healthChecker.Register(health.AnalyticsAI(routerService.FusionService().PingAnalytics))
+		healthChecker.Register(routerService.Classifier().HealthCheck())
+		healthChecker.Register(health.CatalogFreshness(routerService.FusionService(), catalogMaxAge(cfg.Catalog)))
+	}
+	if generator != nil {
+		healthChecker.Register(generator.HealthCheck())
+	}
+	healthChecker.Register(backgroundJobs.Check())
+}
+
+// catalogMaxAge is how old the fused catalog may get before the replica
+// reports degraded: three missed refreshes, and never less than a day
+func catalogMaxAge(cfg config.CatalogConfig) time.Duration {
+	maxAge := 3 * cfg.RefreshInterval
+	if maxAge < 24*time.Hour {
+		maxAge = 24 * time.Hour
+	}
+	return maxAge
+}
+
+// livenessCheck answers as long as the process serves HTTP; restarting the
+// replica cannot fix a dependency, so dependencies are left to readinessCheck
+func livenessCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    health.StatusOK,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// readinessCheck runs every dependency check and answers 503 while a critical
+// dependency is down, so load balancers stop sending traffic
+func readinessCheck(c *gin.Context) {
+	report := healthChecker.Run(c.Request.Context())
+	code := http.StatusOK
+	if !report.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+func healthCheck(c *gin.Context) {
+	// Check database connection
+	dbStatus := "disabled"
+	if db != nil {
+		dbStatus = "healthy"
+		if err := db.Ping(); err != nil {
+			dbStatus = "unhealthy: " + err.Error()
+		}
+	}
+
+	var modelCount interface{}
+	if routerService != nil {
+		modelCount = routerService.GetStats()["total_models"]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "healthy",
+		"service":    "llm-router-go",
+		"version":    "4.0.0",
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"database":   dbStatus,
+		"models":     modelCount,
+		"domain":     "routellm.dev",
+		"categories": []string{"text", "image-generation", "video-generation", "voice-generation", "multimodal"},
+		"sources":    []string{"HuggingFace", "Commercial APIs", "Latest 2025 Models", "Analytics"},
+		"analytics":  "enabled",
+		"multimodal": "enabled",
+	})
+}
+
+func listBenchmarkMappings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    routerService.BenchmarkMappings(),
+	})
+}
+
+// getLoadShedding shows the replica's load, whether it is shedding and the
+// requests it admitted and shed by priority
+func getLoadShedding(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    loadShedder.Stats(),
+	})
+}
+
+// listCapabilityTaxonomy shows the canonical capability names, the aliases resolved to each
+// and the subcategories of each category that has them
+func listCapabilityTaxonomy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"data":          models.CapabilityTaxonomy(),
+		"subcategories": models.SubcategoryTaxonomy(),
+	})
+}
+
+// getClassifierRules shows the generation and source of the classifier rules;
+// classifications report the generation that served them as rules_generation
+func getClassifierRules(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    routerService.ClassifierRules(),
+	})
+}
+
+// reloadClassifierRules re-reads the classifier rules without waiting for the file watcher
+func reloadClassifierRules(c *gin.Context) {
+	rules, err := routerService.ReloadClassifierRules()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to reload classifier rules; previous rules remain active",
+			"details": err.Error(),
+			"rules":   rules,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// runClassifierCorpus classifies the labelled prompt corpus and reports a
+// confusion matrix per dimension. ?rules=draft measures the draft rules
+// before they are activated; the corpus is re-read on every run.
+func runClassifierCorpus(c *gin.Context) {
+	cases, err := classification.LoadCorpus(classifierCorpusDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load classifier corpus",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(cases) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Classifier corpus is empty",
+			"details": "no corpus files in " + classifierCorpusDir,
+		})
+		return
+	}
+
+	var rules *classification.RuleConfig
+	switch c.DefaultQuery("rules", "active") {
+	case "active":
+	case "draft":
+		draft, err := classifierRuleStore.Validate(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Draft classifier rules are invalid",
+				"details": err.Error(),
+			})
+			return
+		}
+		rules = &draft
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid rules",
+			"details": "rules must be active or draft",
+		})
+		return
+	}
+
+	report, err := routerService.Classifier().EvaluateCorpus(c.Request.Context(), cases, rules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to run classifier corpus",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// listScorers shows the registered plugin scorers in run order with their metrics
+func listScorers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    routerService.ScorerStats(),
+	})
+}
+
+// rootHandler describes the service and lists the endpoints this
+// configuration serves
+func rootHandler(cfg *config.Config) gin.HandlerFunc {
+	var features []string
+	endpoints := gin.H{"health": "GET /health", "liveness": "GET /livez", "readiness": "GET /readyz"}
+	if cfg.Server.EnableV2 {
+		features = append(features, "Smart model recommendations", "Multi-modal support", "Analytics integration")
+	}
+	if cfg.Server.EnableAuth {
+		features = append(features, "User authentication & API keys", "Rate limiting & usage tracking", "GitHub OAuth")
+	}
+	if cfg.Server.EnableAuth {
+		endpoints["auth_signup"] = "POST /api/v1/auth/signup"
+		endpoints["auth_login"] = "POST /api/v1/auth/login"
+		endpoints["auth_me"] = "GET /api/v1/auth/me"
+		endpoints["waitlist"] = "POST /api/v1/auth/waitlist"
+	}
+	if cfg.Server.EnableV2 {
+		endpoints["smart_recommendations"] = "POST /api/v2/recommend/smart"
+		endpoints["direct_recommendations"] = "POST /api/v2/recommend/direct"
+		endpoints["prompt_classification"] = "POST /api/v2/classify"
+		endpoints["models"] = "GET /api/v2/models"
+		endpoints["provider_status"] = "GET /api/v2/status"
+	}
+	if cfg.Tenants() {
+		endpoints["provider_keys"] = "GET|POST /api/v1/dashboard/provider-keys"
+		endpoints["usage_daily"] = "GET /api/v1/dashboard/usage/daily"
+		endpoints["usage_by_model"] = "GET /api/v1/dashboard/usage/by-model"
+		endpoints["security"] = "GET /api/v1/dashboard/security"
+		endpoints["tenant_models"] = "GET|POST /api/v1/dashboard/models"
+		endpoints["generate"] = "POST /api/v2/generate"
+		endpoints["graphql"] = "GET|POST /graphql"
+	}
+	if cfg.Server.LegacyRoutes {
+		endpoints["legacy_recommend"] = "POST /recommend"
+	}
+	if cfg.Validation.Requests != config.ValidationOff {
+		endpoints["openapi"] = "GET /openapi.yaml"
+	}
+
+	return func(c *gin.Context) {
+		response := gin.H{
+			"service":     "RouteLLM - AI Model Router",
+			"version":     "1.0",
+			"description": "Production-ready LLM routing with authentication",
+			"profile":     cfg.Profile,
+			"features":    features,
+			"endpoints":   endpoints,
+		}
+		if routerService != nil {
+			response["stats"] = routerService.GetStats()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func setupAuthRoutes(r *gin.Engine) {
+	authGroup := r.Group("/api/v1/auth")
+	{
+		// Public endpoints
+		authGroup.POST("/signup", authHandlers.Register)
+		authGroup.POST("/login", authHandlers.Login)
+		authGroup.POST("/waitlist", authHandlers.Waitlist)
+		authGroup.POST("/oauth/github", authHandlers.GitHubOAuth)
+		authGroup.POST("/refresh", authHandlers.RefreshToken)
+		if ssoHandlers != nil {
+			authGroup.POST("/sso/start", ssoHandlers.Start)
+			authGroup.POST("/sso/callback", ssoHandlers.Callback)
+		}
+
+		// Protected endpoints (require JWT)
+		protected := authGroup.Group("")
+		protected.Use(authHandlers.AuthMiddleware())
+		{
+			protected.GET("/me", authHandlers.GetProfile)
+			protected.POST("/logout", authHandlers.Logout)
+			protected.GET("/usage", authHandlers.GetUsage)
+			protected.GET("/api-keys", authHandlers.ListAPIKeys)
+			protected.POST("/api-keys", twoFactorHandlers.RequireRecent(), authHandlers.CreateAPIKey)
+
+			if twoFactorHandlers != nil {
+				protected.GET("/2fa", twoFactorHandlers.Status)
+				protected.POST("/2fa/enroll", twoFactorHandlers.Enroll)
+				protected.POST("/2fa/confirm", twoFactorHandlers.Confirm)
+				protected.POST("/2fa/challenge", twoFactorHandlers.Challenge)
+				protected.POST("/2fa/backup-codes", twoFactorHandlers.RequireRecent(), twoFactorHandlers.RegenerateBackupCodes)
+				protected.DELETE("/2fa", twoFactorHandlers.RequireRecent(), twoFactorHandlers.Disable)
+			}
+		}
+	}
+}
+
+func setupDashboardRoutes(r *gin.Engine) {
+	dashboard := r.Group("/api/v1/dashboard")
+	dashboard.Use(authHandlers.AuthMiddleware(), twoFactorHandlers.Enforce())
+	{
+		if providerKeyHandlers != nil {
+			dashboard.GET("/provider-keys", providerKeyHandlers.ListKeys)
+			dashboard.POST("/provider-keys", twoFactorHandlers.RequireRecent(), providerKeyHandlers.PutKey)
+			dashboard.PATCH("/provider-keys/:id", twoFactorHandlers.RequireRecent(), providerKeyHandlers.UpdateKey)
+			dashboard.DELETE("/provider-keys/:id", providerKeyHandlers.DeleteKey)
+		}
+
+		if signingHandlers != nil {
+			dashboard.GET("/signing-secrets", signingHandlers.List)
+			dashboard.POST("/signing-secrets", twoFactorHandlers.RequireRecent(), signingHandlers.Create)
+			dashboard.PATCH("/signing-secrets/:id", twoFactorHandlers.RequireRecent(), signingHandlers.Update)
+			dashboard.DELETE("/signing-secrets/:id", signingHandlers.Revoke)
+		}
+
+		dashboard.GET("/safety-policy", safetyHandlers.GetPolicy)
+		dashboard.PUT("/safety-policy", safetyHandlers.PutPolicy)
+
+		dashboard.GET("/logging-policy", privacyHandlers.GetPolicy)
+		dashboard.PUT("/logging-policy", privacyHandlers.PutPolicy)
+		dashboard.DELETE("/data", privacyHandlers.PurgeData)
+
+		dashboard.GET("/secret-policy", secretHandlers.GetPolicy)
+		dashboard.PUT("/secret-policy", secretHandlers.PutPolicy)
+
+		dashboard.GET("/features", featureHandlers.Features)
+
+		dashboard.GET("/cost-guard", costGuardHandlers.List)
+		dashboard.PUT("/cost-guard", costGuardHandlers.Put)
+		dashboard.DELETE("/cost-guard", costGuardHandlers.Delete)
+
+		dashboard.GET("/feedback/affinities", feedbackHandlers.ListAffinities)
+
+		dashboard.GET("/usage/daily", usageHandlers.Daily)
+		dashboard.GET("/usage/by-model", usageHandlers.ByModel)
+		dashboard.GET("/usage/by-tag", usageHandlers.ByTag)
+		dashboard.GET("/usage/duplicates", usageHandlers.Duplicates)
+
+		dashboard.GET("/models", tenantModelHandlers.List)
+		dashboard.POST("/models", featureHandlers.Require(flags.CustomModels), tenantModelHandlers.Create)
+		dashboard.PUT("/models/:id", tenantModelHandlers.Update)
+		dashboard.DELETE("/models/:id", tenantModelHandlers.Delete)
+
+		dashboard.GET("/catalog-overlay", overlayHandlers.Get)
+		dashboard.PUT("/catalog-overlay", overlayHandlers.Put)
+		dashboard.DELETE("/catalog-overlay", overlayHandlers.Delete)
+
+		dashboard.GET("/classifier/rules", ruleOverlayHandlers.Get)
+		dashboard.PUT("/classifier/rules", ruleOverlayHandlers.Put)
+		dashboard.DELETE("/classifier/rules", ruleOverlayHandlers.Delete)
+		dashboard.POST("/classifier/rules/test", ruleOverlayHandlers.Test)
+
+		dashboard.GET("/prompt-templates", promptTemplateHandlers.List)
+		dashboard.GET("/prompt-templates/:model_id", promptTemplateHandlers.Get)
+		dashboard.PUT("/prompt-templates/:model_id", promptTemplateHandlers.Put)
+		dashboard.DELETE("/prompt-templates/:model_id", promptTemplateHandlers.Delete)
+
+		dashboard.GET("/pricing/changes", pricingHandlers.TenantChanges)
+		dashboard.GET("/pricing/webhook", pricingHandlers.GetWebhook)
+		dashboard.PUT("/pricing/webhook", featureHandlers.Require(flags.Webhooks), pricingHandlers.PutWebhook)
+		dashboard.DELETE("/pricing/webhook", pricingHandlers.DeleteWebhook)
+
+		dashboard.GET("/migrations", migrationHandlers.Migrations)
+		dashboard.PUT("/migrations/settings", migrationHandlers.UpdateSettings)
+		dashboard.POST("/migrations/:model_id/apply", migrationHandlers.Apply)
+
+		dashboard.GET("/security", anomalyHandlers.Overview)
+		dashboard.POST("/security/flags/:subject/clear", anomalyHandlers.ClearFlag)
+
+		if exportHandlers != nil {
+			dashboard.GET("/exports", exportHandlers.List)
+			dashboard.POST("/exports", exportHandlers.Create)
+			dashboard.PUT("/exports/:id", exportHandlers.Update)
+			dashboard.DELETE("/exports/:id", exportHandlers.Delete)
+			dashboard.POST("/exports/:id/run", exportHandlers.Run)
+		}
+	}
+}
+
+func setupAdminRoutes(r *gin.Engine) {
+	admin := r.Group("/api/v1/admin")
+	admin.Use(authHandlers.AdminMiddleware())
+	{
+		admin.GET("/config", configHandlers.Get)
+
+		if twoFactorHandlers != nil {
+			admin.GET("/2fa/policies", twoFactorHandlers.ListPolicies)
+			admin.PUT("/2fa/policies/:organization", twoFactorHandlers.PutPolicy)
+			admin.DELETE("/2fa/policies/:organization", twoFactorHandlers.DeletePolicy)
+		}
+		if ssoHandlers != nil {
+			admin.GET("/sso", ssoHandlers.List)
+			admin.PUT("/sso/:organization", ssoHandlers.Put)
+			admin.DELETE("/sso/:organization", ssoHandlers.Delete)
+		}
+
+		admin.PUT("/api-keys/:id/burst", authHandlers.PutKeyBurst)
+		admin.DELETE("/api-keys/:id/burst", authHandlers.DeleteKeyBurst)
+
+		admin.GET("/audit", auditHandlers.List)
+		admin.GET("/safety/flagged", safetyHandlers.ListFlagged)
+		admin.POST("/safety/flagged/:id/review", safetyHandlers.ReviewFlagged)
+
+		if loadShedder != nil {
+			admin.GET("/load-shedding", getLoadShedding)
+		}
+
+		admin.GET("/benchmark-mappings", listBenchmarkMappings)
+		admin.GET("/capability-taxonomy", listCapabilityTaxonomy)
+		admin.GET("/scorers", listScorers)
+		admin.GET("/classifier/rules", getClassifierRules)
+		admin.POST("/classifier/rules/reload", reloadClassifierRules)
+		admin.GET("/classifier/rules/draft", classifierRuleHandlers.ListDraft)
+		admin.GET("/classifier/rules/draft/:group/:label", classifierRuleHandlers.GetRule)
+		admin.PUT("/classifier/rules/draft/:group/:label", classifierRuleHandlers.PutRule)
+		admin.DELETE("/classifier/rules/draft/:group/:label", classifierRuleHandlers.DeleteRule)
+		admin.POST("/classifier/rules/validate", classifierRuleHandlers.ValidateDraft)
+		admin.POST("/classifier/rules/activate", classifierRuleHandlers.Activate)
+		admin.GET("/classifier/rules/versions", classifierRuleHandlers.ListVersions)
+		admin.GET("/classifier/rules/versions/:version", classifierRuleHandlers.GetVersion)
+		admin.POST("/classifier/rules/versions/:version/activate", classifierRuleHandlers.ActivateVersion)
+		admin.POST("/classifier/corpus/run", runClassifierCorpus)
+		admin.GET("/classifier/drift", driftHandlers.Report)
+
+		admin.GET("/fallbacks", fallbackHandlers.List)
+		admin.PUT("/fallbacks/:category", fallbackHandlers.Put)
+		admin.DELETE("/fallbacks/:category", fallbackHandlers.Delete)
+
+		admin.GET("/deprecations", migrationHandlers.ListDeprecations)
+		admin.PUT("/deprecations/:model_id", migrationHandlers.PutDeprecation)
+		admin.DELETE("/deprecations/:model_id", migrationHandlers.DeleteDeprecation)
+		admin.GET("/trust", trustHandlers.List)
+		admin.POST("/trust/:model_id/verify", trustHandlers.Verify)
+		admin.PUT("/trust/:model_id", trustHandlers.SetTier)
+		admin.GET("/availability", availabilityHandlers.List)
+		admin.PUT("/availability/:model_id/disabled", availabilityHandlers.Disable)
+		admin.DELETE("/availability/:model_id/disabled", availabilityHandlers.Enable)
+		admin.POST("/availability/:model_id/maintenance", availabilityHandlers.ScheduleMaintenance)
+		admin.DELETE("/availability/:model_id/maintenance/:id", availabilityHandlers.CancelMaintenance)
+
+		admin.GET("/features", featureHandlers.List)
+		admin.PUT("/features/plans/:plan/:flag", featureHandlers.PutPlan)
+		admin.PUT("/features/overrides/:scope/:subject/:flag", featureHandlers.PutOverride)
+		admin.DELETE("/features/overrides/:scope/:subject/:flag", featureHandlers.DeleteOverride)
+
+		admin.GET("/catalog/export", catalogHandlers.Export)
+		admin.POST("/catalog/import", catalogHandlers.Import)
+		admin.PUT("/catalog/models/:id", catalogHandlers.PutModel)
+		admin.DELETE("/catalog/models/:id", catalogHandlers.DeleteModel)
+
+		admin.GET("/calibration", calibrationHandlers.List)
+		admin.POST("/calibration/refresh", calibrationHandlers.Refresh)
+
+		admin.GET("/exports/training", trainingHandlers.List)
+		admin.POST("/exports/training", trainingHandlers.Create)
+		admin.GET("/exports/training/:id", trainingHandlers.Get)
+		admin.GET("/exports/training/:id/download", trainingHandlers.Download)
+		admin.DELETE("/exports/training/:id", trainingHandlers.Delete)
+
+		admin.GET("/weights", tuningHandlers.List)
+		admin.POST("/weights/refit", tuningHandlers.Refit)
+		admin.PUT("/weights/:priority/experiment", tuningHandlers.StartExperiment)
+		admin.DELETE("/weights/:priority/experiment", tuningHandlers.StopExperiment)
+		admin.POST("/weights/:priority/promote", tuningHandlers.Promote)
+		admin.DELETE("/weights/:priority/promoted", tuningHandlers.Revert)
+
+		admin.GET("/alerts", alertHandlers.ListChannels)
+		admin.POST("/alerts", alertHandlers.CreateChannel)
+		admin.PUT("/alerts/:id", alertHandlers.UpdateChannel)
+		admin.DELETE("/alerts/:id", alertHandlers.DeleteChannel)
+		admin.POST("/alerts/:id/test", alertHandlers.TestChannel)
+
+		admin.GET("/ingest/failures", ingestHandlers.ListFailures)
+		admin.POST("/ingest/failures/requeue", ingestHandlers.Requeue)
+		admin.POST("/ingest/failures/:id/requeue", ingestHandlers.Requeue)
+		admin.GET("/ingest/huggingface", ingestHandlers.HubRepos)
+
+		admin.GET("/evals", evalHandlers.List)
+		admin.POST("/evals/:id/runs", evalHandlers.StartRun)
+		admin.GET("/evals/runs/:id", evalHandlers.GetRun)
+
+		admin.GET("/quality", qualityHandlers.Scores)
+
+		admin.GET("/pricing/changes", pricingHandlers.Changes)
+
+		admin.GET("/generate/queues", generateHandlers.Queues)
+		admin.GET("/generate/endpoints", generateHandlers.Endpoints)
+		admin.GET("/generate/races", generateHandlers.Races)
+		admin.GET("/generate/warmup", warmupHandlers.Report)
+		admin.POST("/generate/warmup/run", warmupHandlers.Run)
+
+		if dataKeyHandlers != nil {
+			admin.GET("/data-keys/:tenant", dataKeyHandlers.List)
+			admin.POST("/data-keys/:tenant/rotate", dataKeyHandlers.Rotate)
+		}
+
+		if mirrorHandlers != nil {
+			admin.GET("/mirror/report", mirrorHandlers.Report)
+			admin.POST("/mirror/reset", mirrorHandlers.Reset)
+		}
+
+		if archiveHandlers != nil {
+			admin.GET("/retention", archiveHandlers.List)
+			admin.PUT("/retention", archiveHandlers.PutPolicy)
+			admin.DELETE("/retention/:id", archiveHandlers.DeletePolicy)
+			admin.POST("/retention/run", archiveHandlers.Run)
+		}
+
+		if exportHandlers != nil {
+			admin.GET("/exports", exportHandlers.ListAll)
+		}
+	}
+}
+
+func startServer(r *gin.Engine, cfg *config.Config) {
+	port := cfg.Server.Port
+
+	server := &http.Server{
+		Addr:         ":" + port,
+		Handler:      r,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+
+	// Start server in goroutine
+	go func() {
+		log.Printf("[SERVER] Starting on port %s over %s", port, cfg.Server.Transport)
+		log.Println("[SERVER] Endpoints:")
+		if cfg.Server.EnableAuth {
+			log.Println("  Auth:   POST /api/v1/auth/signup, /login, /waitlist")
+		}
+		if cfg.Server.EnableV2 {
+			log.Println("  Router: POST /api/v2/recommend/smart")
+			log.Println("  Models: GET /api/v2/models")
+		}
+		log.Println("  Health: GET /health, /livez, /readyz")
+
+		var err error
+		if cfg.Server.Transport == config.TransportTLS {
+			err = server.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("[SERVER] Failed to start: %v", err)
+		}
+	}()
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("[SERVER] Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("[SERVER] Forced shutdown: %v", err)
+	}
+
+	log.Println("[SERVER] Exited gracefully")
+}
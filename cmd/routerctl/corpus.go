@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+)
+
+// defaultCorpusDir mirrors the servers' CLASSIFIER_CORPUS_DIR default
+func defaultCorpusDir() string {
+	if dir := os.Getenv("CLASSIFIER_CORPUS_DIR"); dir != "" {
+		return dir
+	}
+	return "./configs/classifier_corpus"
+}
+
+func runCorpus(args []string) error {
+	fs := flag.NewFlagSet("corpus", flag.ExitOnError)
+	dir := fs.String("dir", defaultCorpusDir(), "classifier corpus directory")
+	rulesPath := fs.String("rules", "", "classifier rules file to measure instead of the built-in rules")
+	minAccuracy := fs.Float64("min-accuracy", 0, "fail when any dimension's accuracy is below this, between 0 and 1")
+	showMisses := fs.Bool("misses", false, "list the misclassified prompts")
+	asJSON := fs.Bool("json", false, "print the full report")
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+	quietLogs(*verbose)
+
+	cases, err := classification.LoadCorpus(*dir)
+	if err != nil {
+		return err
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("no corpus cases in %s", *dir)
+	}
+
+	var rules *classification.RuleConfig
+	if *rulesPath != "" {
+		cfg, err := classification.ReadRuleFile(*rulesPath)
+		if err != nil {
+			return err
+		}
+		rules = &cfg
+	}
+
+	report, err := classification.NewTaskClassifier().EvaluateCorpus(context.Background(), cases, rules)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else if err := printCorpusReport(report, *showMisses); err != nil {
+		return err
+	}
+
+	for _, dim := range []string{classification.DimensionTaskType, classification.DimensionCategory, classification.DimensionComplexity} {
+		if accuracy := report.Dimensions[dim].Accuracy; accuracy < *minAccuracy {
+			return fmt.Errorf("%s accuracy %.3f is below %.3f", dim, accuracy, *minAccuracy)
+		}
+	}
+	return nil
+}
+
+// printCorpusReport prints each dimension's confusion matrix, expected labels
+// down and predicted labels across
+func printCorpusReport(report classification.CorpusReport, showMisses bool) error {
+	fmt.Printf("%d cases, %d misclassified (rules: %s)\n", report.Cases, len(report.Misses), report.RulesSource)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, dim := range []string{classification.DimensionTaskType, classification.DimensionCategory, classification.DimensionComplexity} {
+		d := report.Dimensions[dim]
+		fmt.Fprintf(w, "\n%s: accuracy %.3f (%d of %d)\n", dim, d.Accuracy, d.Correct, d.Checked)
+
+		fmt.Fprint(w, "EXPECTED \\ PREDICTED")
+		for _, label := range d.Labels {
+			fmt.Fprintf(w, "\t%s", label)
+		}
+		fmt.Fprintln(w, "\tRECALL\tPRECISION")
+		for _, want := range d.Labels {
+			fmt.Fprint(w, want)
+			for _, got := range d.Labels {
+				fmt.Fprintf(w, "\t%d", d.Confusion[want][got])
+			}
+			stats := d.PerLabel[want]
+			fmt.Fprintf(w, "\t%.3f\t%.3f\n", stats.Recall, stats.Precision)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if showMisses && len(report.Misses) > 0 {
+		fmt.Println("\nMisclassified:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CASE\tDIMENSION\tEXPECTED\tPREDICTED")
+		for _, miss := range report.Misses {
+			for _, dim := range []string{classification.DimensionTaskType, classification.DimensionCategory, classification.DimensionComplexity} {
+				if want := miss.Expected[dim]; want != "" && want != miss.Predicted[dim] {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", miss.ID, dim, want, miss.Predicted[dim])
+				}
+			}
+		}
+		return w.Flush()
+	}
+	return nil
+}
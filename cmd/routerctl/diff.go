@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// catalogEntry is a model as raw JSON, so diffs work across schema versions
+type catalogEntry map[string]interface{}
+
+type modelChange struct {
+	ID     string                 `json:"id"`
+	Fields map[string]fieldChange `json:"fields"`
+}
+
+type fieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type catalogDiff struct {
+	Added   []string      `json:"added"`
+	Removed []string      `json:"removed"`
+	Changed []modelChange `json:"changed"`
+}
+
+func runCatalogDiff(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("catalog diff requires two catalog files")
+	}
+
+	oldCatalog, err := readCatalogFile(args[0])
+	if err != nil {
+		return err
+	}
+	newCatalog, err := readCatalogFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	diff := catalogDiff{Added: []string{}, Removed: []string{}, Changed: []modelChange{}}
+	for id := range newCatalog {
+		if _, ok := oldCatalog[id]; !ok {
+			diff.Added = append(diff.Added, id)
+		}
+	}
+	for id, oldModel := range oldCatalog {
+		newModel, ok := newCatalog[id]
+		if !ok {
+			diff.Removed = append(diff.Removed, id)
+			continue
+		}
+		if fields := diffFields("", oldModel, newModel); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, modelChange{ID: id, Fields: fields})
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ID < diff.Changed[j].ID })
+
+	return printJSON(diff)
+}
+
+// readCatalogFile accepts either {"models": [...]} or a bare array of models
+func readCatalogFile(path string) (map[string]catalogEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var entries []catalogEntry
+	var wrapped struct {
+		Models []catalogEntry `json:"models"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Models != nil {
+		entries = wrapped.Models
+	} else if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: expected {\"models\": [...]} or an array", path)
+	}
+
+	byID := make(map[string]catalogEntry, len(entries))
+	for i, entry := range entries {
+		id, _ := entry["id"].(string)
+		if id == "" {
+			return nil, fmt.Errorf("%s: model %d has no id", path, i)
+		}
+		byID[id] = entry
+	}
+	return byID, nil
+}
+
+// diffFields walks nested objects and reports leaf differences by dotted path
+func diffFields(prefix string, oldValue, newValue map[string]interface{}) map[string]fieldChange {
+	changes := make(map[string]fieldChange)
+
+	keys := make(map[string]bool)
+	for k := range oldValue {
+		keys[k] = true
+	}
+	for k := range newValue {
+		keys[k] = true
+	}
+
+	for k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		o, n := oldValue[k], newValue[k]
+		oMap, oIsMap := o.(map[string]interface{})
+		nMap, nIsMap := n.(map[string]interface{})
+		if oIsMap && nIsMap {
+			for p, c := range diffFields(path, oMap, nMap) {
+				changes[p] = c
+			}
+			continue
+		}
+		if !reflect.DeepEqual(o, n) {
+			changes[path] = fieldChange{Old: o, New: n}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Askeban/llm-router-go/internal/catalog"
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+	"github.com/Askeban/llm-router-go/internal/services"
+)
+
+// quietLogs hides service logging unless -v is given
+func quietLogs(verbose bool) {
+	if !verbose {
+		log.SetOutput(io.Discard)
+	}
+}
+
+// loadRouter builds a router over the local catalog. Analytics AI is only
+// contacted when fuse is set, so commands work offline by default.
+func loadRouter(modelPath string, fuse bool) (*services.EnhancedRouterService, error) {
+	routerService, err := services.NewReplicatedRouterService(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog %s: %w", modelPath, err)
+	}
+	if fuse {
+		if err := routerService.FusionService().PerformFusion(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to fuse catalog: %w", err)
+		}
+	}
+	return routerService, nil
+}
+
+func runClassify(args []string) error {
+	fs := flag.NewFlagSet("classify", flag.ExitOnError)
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+
+	prompt := strings.Join(fs.Args(), " ")
+	if prompt == "" {
+		return fmt.Errorf("classify requires a prompt")
+	}
+	quietLogs(*verbose)
+
+	// Classification does not depend on the catalog
+	return printJSON(classification.NewTaskClassifier().ClassifyPrompt(prompt))
+}
+
+func runRank(args []string) error {
+	fs := flag.NewFlagSet("rank", flag.ExitOnError)
+	modelPath := fs.String("models", defaultModelPath(), "catalog file")
+	priority := fs.String("priority", "", "override the inferred priority (quality, speed, cost, balanced)")
+	maxLatency := fs.Int("max-latency-ms", 0, "hard latency SLO in milliseconds")
+	limit := fs.Int("n", 5, "number of models to show")
+	minScore := fs.Float64("min-score", recommendation.DefaultMinScore, "lowest overall score to show")
+	ragTopK := fs.Int("rag-top-k", 0, "chunks retrieved per request, for RAG prompts")
+	ragChunkTokens := fs.Int("rag-chunk-tokens", 500, "expected tokens per retrieved chunk")
+	images := fs.Int("images", 0, "images sent with the prompt; only models that read images rank")
+	tolerance := fs.String("latency-tolerance", "", "realtime or batch; batch prices models on their batch tier")
+	fuse := fs.Bool("fuse", false, "fuse Analytics AI data before ranking")
+	asJSON := fs.Bool("json", false, "print the full recommendation response")
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+
+	prompt := strings.Join(fs.Args(), " ")
+	if prompt == "" {
+		return fmt.Errorf("rank requires a prompt")
+	}
+	quietLogs(*verbose)
+
+	routerService, err := loadRouter(*modelPath, *fuse)
+	if err != nil {
+		return err
+	}
+
+	if err := recommendation.ValidateImageInputs(*images); err != nil {
+		return err
+	}
+	if err := recommendation.ValidateLatencyTolerance(*tolerance, *maxLatency); err != nil {
+		return err
+	}
+	result := routerService.TestClassification(prompt)
+	if *images > 0 {
+		result = classification.WithImageInput(result, prompt)
+	}
+	req := recommendation.RecommendationRequest{
+		TaskType:         result.TaskType,
+		Category:         result.Category,
+		Subcategory:      result.Subcategory,
+		Complexity:       result.Complexity,
+		ComplexityScore:  result.ComplexityScore,
+		Priority:         result.Priority,
+		Requirements:     result.Requirements,
+		MaxLatencyMs:     *maxLatency,
+		ReasoningEffort:  result.ReasoningDepth,
+		MinScore:         minScore,
+		ImageInputs:      *images,
+		LatencyTolerance: *tolerance,
+	}
+	// Local ranking is not bound by a plan
+	if *limit > 0 {
+		req.MaxResults, req.PlanMaxResults = *limit, *limit
+	}
+	if *priority != "" {
+		req.Priority = *priority
+	}
+	if *ragTopK > 0 {
+		req.RAG = &recommendation.RAGHint{TopK: *ragTopK, ChunkTokens: *ragChunkTokens, PromptTokens: len(prompt) / 4}
+		if err := recommendation.ValidateRAG(req.RAG); err != nil {
+			return err
+		}
+	}
+
+	response := routerService.GetDirectRecommendations(context.Background(), req)
+	if *asJSON {
+		return printJSON(response)
+	}
+
+	fmt.Printf("task_type=%s category=%s complexity=%s priority=%s (%d of %d models eligible)\n\n",
+		req.TaskType, req.Category, req.Complexity, req.Priority, response.FilteredModels, response.TotalModels)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tMODEL\tPROVIDER\tSCORE\tCONFIDENCE\tCOST\tTIER\tLATENCY_MS\tEFFORT")
+	for i, rec := range response.Recommendations {
+		if i >= *limit {
+			break
+		}
+		latency := "-"
+		if rec.LatencyEstimate != nil {
+			latency = fmt.Sprintf("%.0f", rec.LatencyEstimate.TotalMs)
+		}
+		effort := "-"
+		if rec.ReasoningEffort != nil {
+			effort = rec.ReasoningEffort.Effort
+		}
+		tier := "-"
+		if rec.PriceTier != nil {
+			tier = rec.PriceTier.Name
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%.3f\t%.2f\t$%.4f\t%s\t%s\t%s\n",
+			i+1, rec.Model.ID, rec.Model.Provider, rec.OverallScore, rec.Confidence, rec.CostEstimate, tier, latency, effort)
+	}
+	return w.Flush()
+}
+
+func runIngest(args []string) error {
+	fs := flag.NewFlagSet("ingest", flag.ExitOnError)
+	modelPath := fs.String("models", defaultModelPath(), "base catalog file")
+	out := fs.String("out", "", "write the fused catalog to this file instead of stdout")
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+	quietLogs(*verbose)
+
+	routerService, err := loadRouter(*modelPath, true)
+	if err != nil {
+		return err
+	}
+
+	stats := routerService.GetStats()
+	if n, _ := stats["analytics_success_count"].(int64); n == 0 {
+		return fmt.Errorf("Analytics AI ingestion failed; rerun with -v for details")
+	}
+
+	fused := models.ModelData{Models: sortedModels(routerService.GetAllModels())}
+	if *out == "" {
+		return printJSON(fused)
+	}
+
+	data, err := json.MarshalIndent(fused, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d models to %s\n", len(fused.Models), *out)
+	return nil
+}
+
+func runCatalog(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("catalog requires a subcommand: dump, diff, export, import or validate")
+	}
+
+	switch args[0] {
+	case "dump":
+		return runCatalogDump(args[1:])
+	case "diff":
+		return runCatalogDiff(args[1:])
+	case "export":
+		return runCatalogExport(args[1:])
+	case "import":
+		return runCatalogImport(args[1:])
+	case "validate":
+		return runCatalogValidate(args[1:])
+	}
+	return fmt.Errorf("unknown catalog subcommand %q", args[0])
+}
+
+func runCatalogDump(args []string) error {
+	fs := flag.NewFlagSet("catalog dump", flag.ExitOnError)
+	modelPath := fs.String("models", defaultModelPath(), "catalog file")
+	query := fs.String("query", "", `catalog query, e.g. "open_source = true AND coding > 0.8"`)
+	idsOnly := fs.Bool("ids", false, "print model IDs only")
+	fuse := fs.Bool("fuse", false, "fuse Analytics AI data before dumping")
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+	quietLogs(*verbose)
+
+	routerService, err := loadRouter(*modelPath, *fuse)
+	if err != nil {
+		return err
+	}
+
+	all := routerService.GetAllModels()
+	if *query != "" {
+		q, err := catalog.Parse(*query)
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+		all = q.Filter(all)
+	}
+	all = sortedModels(all)
+
+	if *idsOnly {
+		for _, m := range all {
+			fmt.Println(m.ID)
+		}
+		return nil
+	}
+	return printJSON(models.ModelData{Models: all})
+}
+
+func sortedModels(all []models.EnhancedModel) []models.EnhancedModel {
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	return all
+}
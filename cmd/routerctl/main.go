@@ -0,0 +1,85 @@
+// Command routerctl classifies prompts, ranks models and inspects catalogs
+// locally, and calls a remote router instance for operators and scripts.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const usage = `routerctl - local routing and catalog inspection
+
+Usage:
+  routerctl classify [flags] <prompt>          classify a prompt with the local classifier
+  routerctl corpus [flags]                     score the classifier against the labelled prompt corpus
+  routerctl rank [flags] <prompt>              rank models for a prompt against a local catalog
+  routerctl rankdiff [flags]                   compare two catalogs, weight sets or routers over a prompt corpus
+  routerctl catalog dump [flags]               print the catalog, optionally filtered with -query
+  routerctl catalog diff <old.json> <new.json> compare two catalog files
+  routerctl catalog export [flags]             write a portable catalog (JSON or YAML), local or -remote
+  routerctl catalog import [flags] <file>      validate and import a portable catalog into a remote router
+  routerctl catalog validate <file>            check a portable catalog offline
+  routerctl ingest [flags]                     run the Analytics AI ingester and write the fused catalog
+  routerctl remote [flags] <METHOD> <path> [body|-]
+                                               call a remote router instance
+
+Run "routerctl <command> -h" for command flags.
+
+Environment:
+  MODEL_PATH             catalog file (default ./configs/model_1.json)
+  CLASSIFIER_CORPUS_DIR  labelled prompts for corpus (default ./configs/classifier_corpus)
+  ROUTER_URL             remote router base URL (default http://localhost:8080)
+  ROUTER_API_KEY         bearer credential sent to the remote router
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "classify":
+		err = runClassify(args)
+	case "corpus":
+		err = runCorpus(args)
+	case "rank":
+		err = runRank(args)
+	case "rankdiff":
+		err = runRankDiff(args)
+	case "catalog":
+		err = runCatalog(args)
+	case "ingest":
+		err = runIngest(args)
+	case "remote":
+		err = runRemote(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "routerctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// defaultModelPath mirrors the servers' MODEL_PATH default
+func defaultModelPath() string {
+	if path := os.Getenv("MODEL_PATH"); path != "" {
+		return path
+	}
+	return "./configs/model_1.json"
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,608 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+	"github.com/Askeban/llm-router-go/internal/services"
+)
+
+// promptRanking is one engine's answer to a prompt, reduced to what is compared
+type promptRanking struct {
+	Category string        `json:"category"`
+	Models   []rankedModel `json:"models"` // Best first
+}
+
+type rankedModel struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+	Cost  float64 `json:"cost"`
+}
+
+// ranker ranks models for a prompt: a local engine or a remote router
+type ranker interface {
+	rank(ctx context.Context, prompt string, depth int) (promptRanking, error)
+}
+
+// localRanker ranks with a router built in this process over a catalog file,
+// optionally with component weights from a file
+type localRanker struct {
+	router   *services.EnhancedRouterService
+	priority string
+}
+
+// fileWeights serves component weights read from a file, keyed by priority
+type fileWeights map[string]map[string]float64
+
+func (w fileWeights) Weights(priority, subject string) (map[string]float64, string) {
+	if weights, ok := w[priority]; ok {
+		return weights, "file"
+	}
+	return nil, ""
+}
+
+func (l localRanker) rank(ctx context.Context, prompt string, depth int) (promptRanking, error) {
+	result := l.router.TestClassification(prompt)
+	minScore := 0.0
+	req := recommendation.RecommendationRequest{
+		TaskType:        result.TaskType,
+		Category:        result.Category,
+		Subcategory:     result.Subcategory,
+		Complexity:      result.Complexity,
+		ComplexityScore: result.ComplexityScore,
+		Priority:        result.Priority,
+		Requirements:    result.Requirements,
+		ReasoningEffort: result.ReasoningDepth,
+		MinScore:        &minScore,
+		// Not bound by a plan, so both sides rank to the same depth
+		MaxResults:     depth,
+		PlanMaxResults: depth,
+	}
+	if l.priority != "" {
+		req.Priority = l.priority
+	}
+
+	response := l.router.GetDirectRecommendations(ctx, req)
+	r := promptRanking{Category: result.Category, Models: []rankedModel{}}
+	for _, rec := range response.Recommendations {
+		r.Models = append(r.Models, rankedModel{ID: rec.Model.ID, Score: rec.OverallScore, Cost: rec.CostEstimate})
+	}
+	return r, nil
+}
+
+// remoteRanker asks a running router, e.g. one still on the previous engine
+// version, through its smart recommendation endpoint
+type remoteRanker struct {
+	client *remoteClient
+}
+
+func (r remoteRanker) rank(ctx context.Context, prompt string, depth int) (promptRanking, error) {
+	// Feedback adjustments are per caller, so they would not be compared like for like
+	payload, _ := json.Marshal(map[string]interface{}{
+		"prompt":                  prompt,
+		"max_results":             depth,
+		"min_score":               0,
+		"disable_personalization": true,
+	})
+	data, err := r.client.do("POST", "/api/v2/recommend/smart", bytes.NewReader(payload), "application/json")
+	if err != nil {
+		return promptRanking{}, err
+	}
+
+	var resp struct {
+		Data struct {
+			Classification struct {
+				Category string `json:"category"`
+			} `json:"classification"`
+			Recommendations struct {
+				Recommendations []struct {
+					Model struct {
+						ID string `json:"id"`
+					} `json:"model"`
+					OverallScore float64 `json:"overall_score"`
+					CostEstimate float64 `json:"cost_estimate"`
+				} `json:"recommendations"`
+			} `json:"recommendations"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return promptRanking{}, fmt.Errorf("failed to parse recommend response: %w", err)
+	}
+	ranking := promptRanking{Category: resp.Data.Classification.Category, Models: []rankedModel{}}
+	for _, rec := range resp.Data.Recommendations.Recommendations {
+		ranking.Models = append(ranking.Models, rankedModel{ID: rec.Model.ID, Score: rec.OverallScore, Cost: rec.CostEstimate})
+	}
+	return ranking, nil
+}
+
+// promptDiff is how the candidate's ranking of one prompt differed from the base's
+type promptDiff struct {
+	Prompt            string   `json:"prompt"`
+	Category          string   `json:"category"`
+	CandidateCategory string   `json:"candidate_category,omitempty"` // When the candidate classified it differently
+	Base              []string `json:"base"`
+	Candidate         []string `json:"candidate"`
+	TopMatch          bool     `json:"top_match"`
+	RankCorrelation   float64  `json:"rank_correlation"`
+	BaseCost          float64  `json:"base_cost"`      // Top model's estimated cost
+	CandidateCost     float64  `json:"candidate_cost"` // Top model's estimated cost
+}
+
+type categoryDiff struct {
+	Category            string  `json:"category"`
+	Prompts             int     `json:"prompts"`
+	Top1Agreement       float64 `json:"top1_agreement"`
+	MeanRankCorrelation float64 `json:"mean_rank_correlation"`
+	CostDelta           float64 `json:"cost_delta"` // Mean per prompt, candidate minus base
+}
+
+type topSwap struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// rankDiffReport summarizes how the candidate engine ranks a corpus
+// differently from the base
+type rankDiffReport struct {
+	Base                string         `json:"base"`
+	Candidate           string         `json:"candidate"`
+	Depth               int            `json:"depth"`
+	Prompts             int            `json:"prompts"`
+	Compared            int            `json:"compared"`
+	Failed              int            `json:"failed"`                // Either side errored
+	Top1Agreement       float64        `json:"top1_agreement"`        // Share of prompts with the same top model
+	MeanRankCorrelation float64        `json:"mean_rank_correlation"` // Spearman over each prompt's two top-depth lists
+	MeanOverlap         float64        `json:"mean_overlap"`          // Share of the base's top models the candidate also ranked
+	CategoryChanges     int            `json:"category_changes"`      // Prompts classified into another category
+	BaseMeanCost        float64        `json:"base_mean_cost"`        // Top model's estimated cost per prompt
+	CandidateMeanCost   float64        `json:"candidate_mean_cost"`
+	CostDelta           float64        `json:"cost_delta"`
+	CostDeltaPercent    float64        `json:"cost_delta_percent"`
+	ByCategory          []categoryDiff `json:"by_category"`
+	Swaps               []topSwap      `json:"swaps"`       // Most common top-model changes
+	Divergences         []promptDiff   `json:"divergences"` // Least correlated prompts first
+}
+
+func runRankDiff(args []string) error {
+	fs := flag.NewFlagSet("rankdiff", flag.ExitOnError)
+	corpus := fs.String("corpus", defaultCorpusDir(), "classifier corpus directory, or a file with one prompt per line")
+	baseModels := fs.String("base-models", defaultModelPath(), "base catalog file (model file or portable export)")
+	baseWeights := fs.String("base-weights", "", "base component weights file, keyed by priority")
+	baseURL := fs.String("base-url", "", "rank the base on a running router instead of a local catalog")
+	candidateModels := fs.String("candidate-models", "", "candidate catalog file (default: the base catalog)")
+	candidateWeights := fs.String("candidate-weights", "", "candidate component weights file, keyed by priority")
+	candidateURL := fs.String("candidate-url", "", "rank the candidate on a running router instead of a local catalog")
+	apiKey := fs.String("api-key", os.Getenv("ROUTER_API_KEY"), "bearer credential for remote routers")
+	timeout := fs.Duration("timeout", 30*time.Second, "remote request timeout")
+	priority := fs.String("priority", "", "rank every prompt with this priority instead of the inferred one (local sides only)")
+	depth := fs.Int("depth", 5, "top models compared per prompt")
+	divergences := fs.Int("divergences", 10, "least correlated prompts to list")
+	minAgreement := fs.Float64("min-agreement", 0, "fail when top-1 agreement is below this, between 0 and 1")
+	asJSON := fs.Bool("json", false, "print the full report")
+	verbose := fs.Bool("v", false, "show service logs")
+	fs.Parse(args)
+	quietLogs(*verbose)
+
+	if *depth < 1 {
+		return fmt.Errorf("depth must be at least 1")
+	}
+	if *candidateModels == "" && *candidateWeights == "" && *candidateURL == "" {
+		return fmt.Errorf("rankdiff needs a candidate: -candidate-models, -candidate-weights or -candidate-url")
+	}
+	if *candidateModels == "" {
+		*candidateModels = *baseModels
+	}
+
+	prompts, err := loadDiffPrompts(*corpus)
+	if err != nil {
+		return err
+	}
+	if len(prompts) == 0 {
+		return fmt.Errorf("no prompts in %s", *corpus)
+	}
+
+	base, baseName, err := newRanker(*baseModels, *baseWeights, *baseURL, *apiKey, *timeout, *priority)
+	if err != nil {
+		return fmt.Errorf("base: %w", err)
+	}
+	candidate, candidateName, err := newRanker(*candidateModels, *candidateWeights, *candidateURL, *apiKey, *timeout, *priority)
+	if err != nil {
+		return fmt.Errorf("candidate: %w", err)
+	}
+
+	report := diffRankings(context.Background(), prompts, base, candidate, *depth)
+	report.Base, report.Candidate = baseName, candidateName
+	if len(report.Divergences) > *divergences {
+		report.Divergences = report.Divergences[:*divergences]
+	}
+
+	if *asJSON {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else if err := printRankDiff(report); err != nil {
+		return err
+	}
+
+	if report.Compared == 0 {
+		return fmt.Errorf("no prompt was ranked by both engines; rerun with -v for details")
+	}
+	if report.Top1Agreement < *minAgreement {
+		return fmt.Errorf("top-1 agreement %.3f is below %.3f", report.Top1Agreement, *minAgreement)
+	}
+	return nil
+}
+
+// newRanker builds one side of the comparison and describes it
+func newRanker(modelPath, weightsPath, url, apiKey string, timeout time.Duration, priority string) (ranker, string, error) {
+	if url != "" {
+		if weightsPath != "" || priority != "" {
+			return nil, "", fmt.Errorf("weights and -priority apply to local catalogs only, not to %s", url)
+		}
+		client := &remoteClient{baseURL: &url, apiKey: &apiKey, adminToken: new(string), timeout: &timeout}
+		return remoteRanker{client: client}, url, nil
+	}
+
+	router, err := loadRouter(modelPath, false)
+	if err != nil {
+		return nil, "", err
+	}
+	name := modelPath
+	if weightsPath != "" {
+		data, err := os.ReadFile(weightsPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", weightsPath, err)
+		}
+		var weights fileWeights
+		if err := json.Unmarshal(data, &weights); err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s: expected {\"<priority>\": {\"<component>\": weight}}", weightsPath)
+		}
+		if err := checkWeights(weights); err != nil {
+			return nil, "", fmt.Errorf("%s: %w", weightsPath, err)
+		}
+		router.SetWeightSource(weights)
+		name += " with weights " + weightsPath
+	}
+	return localRanker{router: router, priority: priority}, name, nil
+}
+
+// checkWeights rejects priorities and components the engine does not know,
+// which would otherwise leave the built-in weights silently in place
+func checkWeights(weights fileWeights) error {
+	components := make(map[string]bool)
+	for _, priority := range recommendation.Priorities {
+		for component := range recommendation.DefaultWeights(priority) {
+			components[component] = true
+		}
+	}
+	for priority, values := range weights {
+		known := false
+		for _, p := range recommendation.Priorities {
+			known = known || p == priority
+		}
+		if !known {
+			return fmt.Errorf("unknown priority %q, expected one of %s", priority, strings.Join(recommendation.Priorities, ", "))
+		}
+		for component := range values {
+			if !components[component] {
+				return fmt.Errorf("unknown component %q in %s weights", component, priority)
+			}
+		}
+	}
+	return nil
+}
+
+// loadDiffPrompts reads the prompts of a classifier corpus directory, or one
+// prompt per line of a file
+func loadDiffPrompts(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus %s: %w", path, err)
+	}
+	if info.IsDir() {
+		cases, err := classification.LoadCorpus(path)
+		if err != nil {
+			return nil, err
+		}
+		prompts := make([]string, len(cases))
+		for i, c := range cases {
+			prompts[i] = c.Prompt
+		}
+		return prompts, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var prompts []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			prompts = append(prompts, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read corpus %s: %w", path, err)
+	}
+	return prompts, nil
+}
+
+// diffRankings ranks every prompt on both sides and compares the rankings
+func diffRankings(ctx context.Context, prompts []string, base, candidate ranker, depth int) rankDiffReport {
+	report := rankDiffReport{Depth: depth, Prompts: len(prompts), ByCategory: []categoryDiff{}, Swaps: []topSwap{}, Divergences: []promptDiff{}}
+
+	type categoryTotals struct {
+		prompts, topMatches int
+		correlation, cost   float64
+	}
+	categories := make(map[string]*categoryTotals)
+	swaps := make(map[[2]string]int)
+	var agreements, costed int
+	var correlation, overlap float64
+
+	for _, prompt := range prompts {
+		b, err := base.rank(ctx, prompt, depth)
+		if err == nil {
+			var c promptRanking
+			if c, err = candidate.rank(ctx, prompt, depth); err == nil {
+				d := comparePrompt(prompt, b, c)
+				report.Compared++
+				correlation += d.RankCorrelation
+				overlap += topOverlap(d.Base, d.Candidate)
+				if d.TopMatch {
+					agreements++
+				} else if len(d.Base) > 0 && len(d.Candidate) > 0 {
+					swaps[[2]string{d.Base[0], d.Candidate[0]}]++
+				}
+				if d.CandidateCategory != "" {
+					report.CategoryChanges++
+				}
+
+				totals := categories[d.Category]
+				if totals == nil {
+					totals = &categoryTotals{}
+					categories[d.Category] = totals
+				}
+				totals.prompts++
+				totals.correlation += d.RankCorrelation
+				if d.TopMatch {
+					totals.topMatches++
+				}
+				// Costs are only comparable when both sides recommended a model
+				if len(d.Base) > 0 && len(d.Candidate) > 0 {
+					costed++
+					report.BaseMeanCost += d.BaseCost
+					report.CandidateMeanCost += d.CandidateCost
+					totals.cost += d.CandidateCost - d.BaseCost
+				}
+				if !d.TopMatch || d.RankCorrelation < 1 || d.CandidateCategory != "" {
+					report.Divergences = append(report.Divergences, d)
+				}
+				continue
+			}
+		}
+		report.Failed++
+		fmt.Fprintf(os.Stderr, "rankdiff: %q: %v\n", truncatePrompt(prompt), err)
+	}
+
+	if report.Compared > 0 {
+		n := float64(report.Compared)
+		report.Top1Agreement = float64(agreements) / n
+		report.MeanRankCorrelation = correlation / n
+		report.MeanOverlap = overlap / n
+	}
+	if costed > 0 {
+		report.BaseMeanCost /= float64(costed)
+		report.CandidateMeanCost /= float64(costed)
+		report.CostDelta = report.CandidateMeanCost - report.BaseMeanCost
+		if report.BaseMeanCost > 0 {
+			report.CostDeltaPercent = 100 * report.CostDelta / report.BaseMeanCost
+		}
+	}
+
+	for category, totals := range categories {
+		n := float64(totals.prompts)
+		report.ByCategory = append(report.ByCategory, categoryDiff{
+			Category:            category,
+			Prompts:             totals.prompts,
+			Top1Agreement:       float64(totals.topMatches) / n,
+			MeanRankCorrelation: totals.correlation / n,
+			CostDelta:           totals.cost / n,
+		})
+	}
+	sort.Slice(report.ByCategory, func(i, j int) bool {
+		if report.ByCategory[i].Top1Agreement != report.ByCategory[j].Top1Agreement {
+			return report.ByCategory[i].Top1Agreement < report.ByCategory[j].Top1Agreement
+		}
+		return report.ByCategory[i].Category < report.ByCategory[j].Category
+	})
+
+	for pair, count := range swaps {
+		report.Swaps = append(report.Swaps, topSwap{From: pair[0], To: pair[1], Count: count})
+	}
+	sort.Slice(report.Swaps, func(i, j int) bool {
+		if report.Swaps[i].Count != report.Swaps[j].Count {
+			return report.Swaps[i].Count > report.Swaps[j].Count
+		}
+		return report.Swaps[i].From+report.Swaps[i].To < report.Swaps[j].From+report.Swaps[j].To
+	})
+	if len(report.Swaps) > 10 {
+		report.Swaps = report.Swaps[:10]
+	}
+
+	sort.SliceStable(report.Divergences, func(i, j int) bool {
+		return report.Divergences[i].RankCorrelation < report.Divergences[j].RankCorrelation
+	})
+	return report
+}
+
+func comparePrompt(prompt string, base, candidate promptRanking) promptDiff {
+	d := promptDiff{
+		Prompt:    prompt,
+		Category:  base.Category,
+		Base:      modelIDs(base.Models),
+		Candidate: modelIDs(candidate.Models),
+	}
+	if candidate.Category != base.Category {
+		d.CandidateCategory = candidate.Category
+	}
+	switch {
+	case len(d.Base) == 0 && len(d.Candidate) == 0:
+		d.TopMatch = true
+	case len(d.Base) > 0 && len(d.Candidate) > 0:
+		d.TopMatch = d.Base[0] == d.Candidate[0]
+		d.BaseCost, d.CandidateCost = base.Models[0].Cost, candidate.Models[0].Cost
+	}
+	d.RankCorrelation = rankCorrelation(d.Base, d.Candidate)
+	return d
+}
+
+func modelIDs(ranked []rankedModel) []string {
+	ids := make([]string, len(ranked))
+	for i, m := range ranked {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+// rankCorrelation is Spearman's correlation over the models either list
+// ranks. A model missing from a list counts as ranked just below its end.
+func rankCorrelation(base, candidate []string) float64 {
+	union := make([]string, 0, len(base)+len(candidate))
+	seen := make(map[string]bool, len(base)+len(candidate))
+	for _, id := range append(append([]string{}, base...), candidate...) {
+		if !seen[id] {
+			seen[id] = true
+			union = append(union, id)
+		}
+	}
+	if len(union) < 2 {
+		if len(base) == len(candidate) {
+			return 1
+		}
+		return 0
+	}
+
+	ranks := func(list []string) []float64 {
+		position := make(map[string]int, len(list))
+		for i, id := range list {
+			position[id] = i + 1
+		}
+		values := make([]float64, len(union))
+		for i, id := range union {
+			if p, ok := position[id]; ok {
+				values[i] = float64(p)
+			} else {
+				values[i] = float64(len(list) + 1)
+			}
+		}
+		return values
+	}
+	return pearson(ranks(base), ranks(candidate))
+}
+
+// pearson correlates two equally long samples; a constant sample correlates
+// with nothing
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX, meanY = meanX/n, meanY/n
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// topOverlap is the share of the base's models the candidate also ranked
+func topOverlap(base, candidate []string) float64 {
+	if len(base) == 0 {
+		if len(candidate) == 0 {
+			return 1
+		}
+		return 0
+	}
+	inCandidate := make(map[string]bool, len(candidate))
+	for _, id := range candidate {
+		inCandidate[id] = true
+	}
+	shared := 0
+	for _, id := range base {
+		if inCandidate[id] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(base))
+}
+
+func truncatePrompt(prompt string) string {
+	if len(prompt) > 60 {
+		return prompt[:57] + "..."
+	}
+	return prompt
+}
+
+func printRankDiff(report rankDiffReport) error {
+	fmt.Printf("base:      %s\ncandidate: %s\n\n", report.Base, report.Candidate)
+	fmt.Printf("%d prompts, %d compared, %d failed, top %d models each\n", report.Prompts, report.Compared, report.Failed, report.Depth)
+	fmt.Printf("top-1 agreement        %.3f\n", report.Top1Agreement)
+	fmt.Printf("mean rank correlation  %.3f\n", report.MeanRankCorrelation)
+	fmt.Printf("mean top-%d overlap     %.3f\n", report.Depth, report.MeanOverlap)
+	fmt.Printf("category changes       %d\n", report.CategoryChanges)
+	fmt.Printf("cost per prompt        $%.5f -> $%.5f (%+.5f, %+.1f%%)\n",
+		report.BaseMeanCost, report.CandidateMeanCost, report.CostDelta, report.CostDeltaPercent)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\nCATEGORY\tPROMPTS\tTOP1_AGREEMENT\tRANK_CORRELATION\tCOST_DELTA")
+	for _, c := range report.ByCategory {
+		fmt.Fprintf(w, "%s\t%d\t%.3f\t%.3f\t%+.5f\n", c.Category, c.Prompts, c.Top1Agreement, c.MeanRankCorrelation, c.CostDelta)
+	}
+	if len(report.Swaps) > 0 {
+		fmt.Fprintln(w, "\nBASE_TOP\tCANDIDATE_TOP\tPROMPTS")
+		for _, s := range report.Swaps {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", s.From, s.To, s.Count)
+		}
+	}
+	if len(report.Divergences) > 0 {
+		fmt.Fprintln(w, "\nRANK_CORRELATION\tBASE_TOP\tCANDIDATE_TOP\tPROMPT")
+		for _, d := range report.Divergences {
+			fmt.Fprintf(w, "%.3f\t%s\t%s\t%s\n", d.RankCorrelation, first(d.Base), first(d.Candidate), truncatePrompt(d.Prompt))
+		}
+	}
+	return w.Flush()
+}
+
+func first(ids []string) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+	return ids[0]
+}
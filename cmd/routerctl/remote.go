@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// remoteClient calls a router instance with the operator's credentials
+type remoteClient struct {
+	baseURL    *string
+	apiKey     *string
+	adminToken *string
+	timeout    *time.Duration
+}
+
+// remoteFlags registers the connection flags shared by remote commands
+func remoteFlags(fs *flag.FlagSet) *remoteClient {
+	return &remoteClient{
+		baseURL:    fs.String("url", envOr("ROUTER_URL", "http://localhost:8080"), "router base URL"),
+		apiKey:     fs.String("api-key", os.Getenv("ROUTER_API_KEY"), "bearer credential for the router"),
+		adminToken: fs.String("admin-token", os.Getenv("ADMIN_TOKEN"), "X-Admin-Token for /api/v1/admin endpoints"),
+		timeout:    fs.Duration("timeout", 30*time.Second, "request timeout"),
+	}
+}
+
+// do sends a request and returns the response body, failing on error statuses
+// after the body has been read so callers can still show it
+func (rc *remoteClient) do(method, path string, body io.Reader, contentType string) ([]byte, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(*rc.baseURL, "/")+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if *rc.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+*rc.apiKey)
+	}
+	if *rc.adminToken != "" {
+		req.Header.Set("X-Admin-Token", *rc.adminToken)
+	}
+
+	client := &http.Client{Timeout: *rc.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return data, fmt.Errorf("%s %s returned %s", method, path, resp.Status)
+	}
+	return data, nil
+}
+
+func runRemote(args []string) error {
+	fs := flag.NewFlagSet("remote", flag.ExitOnError)
+	rc := remoteFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: routerctl remote [flags] <METHOD> <path> [body|-]")
+		fmt.Fprintln(os.Stderr, `Example: routerctl remote POST /api/v2/recommend/smart '{"prompt":"write a sql query"}'`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return fmt.Errorf("remote requires a method and a path")
+	}
+	method := strings.ToUpper(fs.Arg(0))
+	path := fs.Arg(1)
+
+	var body io.Reader
+	if fs.NArg() > 2 {
+		if fs.Arg(2) == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read body from stdin: %w", err)
+			}
+			body = bytes.NewReader(data)
+		} else {
+			body = strings.NewReader(fs.Arg(2))
+		}
+	}
+
+	data, err := rc.do(method, path, body, "application/json")
+	printResponse(data)
+	return err
+}
+
+// printResponse pretty-prints JSON responses and passes anything else through
+func printResponse(data []byte) {
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, data, "", "  ") == nil {
+		data = append(pretty.Bytes(), '\n')
+	}
+	os.Stdout.Write(data)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
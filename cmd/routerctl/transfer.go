@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/catalog"
+)
+
+func runCatalogExport(args []string) error {
+	fs := flag.NewFlagSet("catalog export", flag.ExitOnError)
+	modelPath := fs.String("models", defaultModelPath(), "catalog file, when exporting locally")
+	fuse := fs.Bool("fuse", false, "fuse Analytics AI data before exporting locally")
+	remote := fs.Bool("remote", false, "export the live catalog of the router at -url")
+	format := fs.String("format", catalog.FormatJSON, "json or yaml")
+	out := fs.String("out", "", "write to this file instead of stdout; its extension sets the format")
+	verbose := fs.Bool("v", false, "show service logs")
+	rc := remoteFlags(fs)
+	fs.Parse(args)
+	quietLogs(*verbose)
+
+	if *out != "" && !flagSet(fs, "format") {
+		*format = strings.TrimPrefix(filepath.Ext(*out), ".")
+	}
+	resolved, err := catalog.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if *remote {
+		data, err = rc.do("GET", "/api/v1/admin/catalog/export?format="+resolved, nil, "")
+		if err != nil {
+			printResponse(data)
+			return err
+		}
+	} else {
+		routerService, err := loadRouter(*modelPath, *fuse)
+		if err != nil {
+			return err
+		}
+		doc, err := catalog.Export(routerService.FusionService())
+		if err != nil {
+			return err
+		}
+		if data, err = doc.Encode(resolved); err != nil {
+			return err
+		}
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", *out)
+	return nil
+}
+
+func runCatalogImport(args []string) error {
+	fs := flag.NewFlagSet("catalog import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "validate on the router without replacing its catalog")
+	rc := remoteFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("catalog import requires a catalog file")
+	}
+
+	// Catch schema problems before uploading
+	data, format, err := readPortableFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/json"
+	if format == catalog.FormatYAML {
+		contentType = "application/yaml"
+	}
+	query := url.Values{"format": {format}}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+	resp, err := rc.do("POST", "/api/v1/admin/catalog/import?"+query.Encode(), bytes.NewReader(data), contentType)
+	printResponse(resp)
+	return err
+}
+
+func runCatalogValidate(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("catalog validate requires a catalog file")
+	}
+	if _, _, err := readPortableFile(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("%s is a valid catalog\n", args[0])
+	return nil
+}
+
+// readPortableFile reads and validates a portable catalog, printing any
+// warnings, and returns its contents and format
+func readPortableFile(path string) ([]byte, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	format, err := catalog.ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, "", err
+	}
+
+	doc, err := catalog.DecodePortable(data, format)
+	if err != nil {
+		return nil, "", err
+	}
+	problems, warnings := doc.Validate()
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "error: %s\n", p)
+		}
+		return nil, "", fmt.Errorf("%s is not a valid catalog (%d errors)", path, len(problems))
+	}
+	return data, format, nil
+}
+
+// flagSet reports whether a flag was given on the command line
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -26,10 +26,19 @@ CREATE TABLE IF NOT EXISTS users (
     oauth_provider VARCHAR(50),
     avatar_url TEXT,
 
+    -- SSO fields
+    oidc_issuer VARCHAR(255),
+    oidc_subject VARCHAR(255),
+    org_role VARCHAR(20),            -- admin, member or viewer, mapped from the identity provider
+
     -- Metadata
     metadata JSONB DEFAULT '{}'::jsonb
 );
 
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS org_role VARCHAR(20);
+
 -- Waitlist table
 CREATE TABLE IF NOT EXISTS waitlist (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -58,9 +67,12 @@ CREATE TABLE IF NOT EXISTS api_keys (
     expires_at TIMESTAMP WITH TIME ZONE,
     permissions TEXT[] DEFAULT ARRAY['read', 'recommend'],
     rate_limit_override INTEGER,
+    rate_limit_burst INTEGER,         -- Token bucket size; NULL uses the plan's
     metadata JSONB DEFAULT '{}'::jsonb
 );
 
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rate_limit_burst INTEGER;
+
 -- Usage tracking table
 CREATE TABLE IF NOT EXISTS api_usage (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -71,14 +83,22 @@ CREATE TABLE IF NOT EXISTS api_usage (
     prompt_category VARCHAR(100),
     recommended_model VARCHAR(255),
     tokens_estimated INTEGER,
+    cost_usd NUMERIC(12, 6) DEFAULT 0,
     response_time_ms INTEGER,
     status_code INTEGER,
     error_message TEXT,
     timestamp TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     date_bucket DATE NOT NULL DEFAULT CURRENT_DATE,
     hour_bucket TIMESTAMP NOT NULL DEFAULT date_trunc('hour', CURRENT_TIMESTAMP),
-    metadata JSONB DEFAULT '{}'::jsonb
+    metadata JSONB DEFAULT '{}'::jsonb,
+    prompt_fingerprint BIGINT,        -- Hash of the normalized prompt; the prompt itself is not stored
+    duplicate_kind VARCHAR(10),       -- exact or near when the key sent the prompt recently
+    tags JSONB NOT NULL DEFAULT '{}'::jsonb  -- Caller-chosen attribution such as {"team": "search"}
 );
+ALTER TABLE api_usage ADD COLUMN IF NOT EXISTS cost_usd NUMERIC(12, 6) DEFAULT 0;
+ALTER TABLE api_usage ADD COLUMN IF NOT EXISTS prompt_fingerprint BIGINT;
+ALTER TABLE api_usage ADD COLUMN IF NOT EXISTS duplicate_kind VARCHAR(10);
+ALTER TABLE api_usage ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}'::jsonb;
 
 -- Monthly usage summary for faster rate limit checks
 CREATE TABLE IF NOT EXISTS monthly_usage_summary (
@@ -102,10 +122,15 @@ CREATE TABLE IF NOT EXISTS plan_limits (
     priority_support BOOLEAN DEFAULT FALSE,
     rate_limit_burst INTEGER DEFAULT 10,
     max_tokens_per_request INTEGER DEFAULT 4000,
+    max_concurrent_requests INTEGER DEFAULT 2,
+    tokens_per_month BIGINT,  -- Generation tokens per calendar month; NULL for no ceiling
     features JSONB DEFAULT '{}'::jsonb,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
+ALTER TABLE plan_limits ADD COLUMN IF NOT EXISTS max_concurrent_requests INTEGER DEFAULT 2;
+
+ALTER TABLE plan_limits ADD COLUMN IF NOT EXISTS tokens_per_month BIGINT;
 
 -- Sessions table for JWT refresh tokens
 CREATE TABLE IF NOT EXISTS sessions (
@@ -121,6 +146,540 @@ CREATE TABLE IF NOT EXISTS sessions (
     last_accessed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Tenant-supplied provider API keys (BYOK), sealed by the key vault
+CREATE TABLE IF NOT EXISTS provider_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    label VARCHAR(255),
+    sealed_key BYTEA NOT NULL,        -- sealed box ciphertext, never stored in plaintext
+    key_hint VARCHAR(20),             -- last 4 chars for display
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, provider)
+);
+
+-- Append-only audit log for security-relevant decisions
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    event_type VARCHAR(100) NOT NULL,   -- e.g. safety.block, safety.flag
+    action VARCHAR(50) NOT NULL,
+    resource VARCHAR(255),
+    prompt TEXT,                        -- subject to the tenant's logging policy; encrypted with a vault key set
+    details JSONB DEFAULT '{}'::jsonb,
+    prompt_details JSONB,               -- prompt-derived details, expired with the prompt
+    request_id VARCHAR(128),            -- X-Request-ID of the request that caused the event
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);
+
+-- Per-tenant content safety policies (category -> action)
+CREATE TABLE IF NOT EXISTS safety_policies (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    actions JSONB NOT NULL DEFAULT '{}'::jsonb,
+    safe_models JSONB NOT NULL DEFAULT '[]'::jsonb,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Admin reviews of flagged safety decisions
+CREATE TABLE IF NOT EXISTS safety_reviews (
+    audit_id UUID PRIMARY KEY REFERENCES audit_log(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK(status IN ('approved', 'rejected', 'escalated')),
+    reviewed_by VARCHAR(255),
+    note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-tenant prompt logging policies (full, hashed, none) and retention
+CREATE TABLE IF NOT EXISTS logging_policies (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    mode VARCHAR(20) NOT NULL DEFAULT 'full' CHECK(mode IN ('full', 'hashed', 'none')),
+    retention_days INTEGER NOT NULL DEFAULT 90 CHECK(retention_days > 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-tenant action on prompts carrying API keys, private keys or tokens
+CREATE TABLE IF NOT EXISTS secret_policies (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL DEFAULT 'mask' CHECK(action IN ('warn', 'mask', 'block')),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tenant ratings of recommended models, used for personalized routing
+CREATE TABLE IF NOT EXISTS model_feedback (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    model_id VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    rating SMALLINT NOT NULL CHECK(rating BETWEEN 1 AND 5),
+    comment TEXT,
+    predicted_confidence NUMERIC(5, 4) CHECK(predicted_confidence BETWEEN 0 AND 1),
+    priority VARCHAR(20),          -- Scoring inputs of the rated recommendation, for weight tuning
+    component_scores JSONB,
+    weights_variant VARCHAR(20),
+    decision_id UUID,              -- recommendation_decisions row the rating labels
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE model_feedback ADD COLUMN IF NOT EXISTS priority VARCHAR(20);
+ALTER TABLE model_feedback ADD COLUMN IF NOT EXISTS component_scores JSONB;
+ALTER TABLE model_feedback ADD COLUMN IF NOT EXISTS weights_variant VARCHAR(20);
+ALTER TABLE model_feedback ADD COLUMN IF NOT EXISTS decision_id UUID;
+
+-- Fused model catalogs published by the replication leader
+CREATE TABLE IF NOT EXISTS catalog_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    content_hash VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    model_count INTEGER NOT NULL DEFAULT 0,
+    fused_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_by VARCHAR(255) NOT NULL,
+    imported BOOLEAN NOT NULL DEFAULT FALSE, -- Curated catalog imported by an admin; followers adopt it as their base
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Latest ingested model metrics (Analytics AI indices, pricing, speed)
+CREATE TABLE IF NOT EXISTS model_metrics (
+    model_id VARCHAR(255) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    metric VARCHAR(100) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    observed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (model_id, source, metric)
+);
+
+-- Ingest rows that failed to upsert, retried by the ingest worker
+CREATE TABLE IF NOT EXISTS ingest_failures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    batch_id VARCHAR(64) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    model_id VARCHAR(255),
+    payload JSONB NOT NULL,
+    error TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'resolved', 'abandoned')),
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Slack/Teams webhook destinations for operational alerts
+CREATE TABLE IF NOT EXISTS alert_channels (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK(kind IN ('slack', 'teams')),
+    webhook_url TEXT NOT NULL,
+    min_severity VARCHAR(20) NOT NULL DEFAULT 'warning' CHECK(min_severity IN ('info', 'warning', 'critical')),
+    event_types JSONB NOT NULL DEFAULT '[]'::jsonb,
+    dedupe_window_seconds INTEGER NOT NULL DEFAULT 900 CHECK(dedupe_window_seconds >= 0),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subject VARCHAR(100) NOT NULL,    -- key:<api_key_id> or user:<user_id>
+    kind VARCHAR(50) NOT NULL,        -- request_spike, category_shift, off_hours_burst
+    severity VARCHAR(20) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}'::jsonb,
+    throttled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tenant_models (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    model_id VARCHAR(128) NOT NULL,
+    display_name VARCHAR(255) NOT NULL,
+    provider VARCHAR(100) NOT NULL,
+    base_model VARCHAR(255) NOT NULL,
+    endpoint TEXT NOT NULL DEFAULT '',
+    cost_in_per_1k NUMERIC(12, 6),
+    cost_out_per_1k NUMERIC(12, 6),
+    capability_deltas JSONB NOT NULL DEFAULT '{}'::jsonb,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, model_id)
+);
+
+CREATE TABLE IF NOT EXISTS signing_secrets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE,
+    label VARCHAR(255),
+    sealed_secret BYTEA NOT NULL,     -- sealed box ciphertext, never stored in plaintext
+    secret_hint VARCHAR(20),          -- last 4 chars for display
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Signatures already verified, shared by every replica to reject replays
+CREATE TABLE IF NOT EXISTS signature_nonces (
+    signature_hash VARCHAR(64) PRIMARY KEY,  -- SHA-256 of the key ID and signature
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Retention policies for growing tables; user_id NULL is the table default
+CREATE TABLE IF NOT EXISTS retention_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    table_name VARCHAR(100) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    retain_days INTEGER NOT NULL CHECK(retain_days > 0),
+    action VARCHAR(20) NOT NULL CHECK(action IN ('summarize', 'export', 'delete')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Daily usage aggregates kept after raw api_usage rows are archived
+CREATE TABLE IF NOT EXISTS api_usage_archive (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    prompt_category VARCHAR(100) NOT NULL DEFAULT '',
+    recommended_model VARCHAR(255) NOT NULL DEFAULT '',
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    tokens BIGINT NOT NULL DEFAULT 0,
+    cost_usd NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    total_response_time_ms BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, endpoint, prompt_category, recommended_model)
+);
+
+-- Per-tenant view of the shared catalog: hidden models, notes and negotiated prices
+CREATE TABLE IF NOT EXISTS catalog_overlays (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    include JSONB NOT NULL DEFAULT '[]'::jsonb,
+    exclude JSONB NOT NULL DEFAULT '[]'::jsonb,
+    annotations JSONB NOT NULL DEFAULT '{}'::jsonb,
+    min_trust_tier VARCHAR(20),  -- NULL routes to every tier
+    compliance JSONB,            -- License, data usage and certification policy; NULL allows any model
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE catalog_overlays ADD COLUMN IF NOT EXISTS min_trust_tier VARCHAR(20);
+ALTER TABLE catalog_overlays ADD COLUMN IF NOT EXISTS compliance JSONB;
+
+-- Per-tenant classifier rules merged over the base rules at classify time
+CREATE TABLE IF NOT EXISTS classifier_rule_overlays (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    rules JSONB NOT NULL DEFAULT '{}'::jsonb,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-tenant prompt templates that replace a model's catalog template in generate mode
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    model_id VARCHAR(255) NOT NULL,
+    template JSONB NOT NULL,            -- system and user parts, or {"disabled": true}
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, model_id)
+);
+
+-- Per-tenant data keys for envelope encryption of stored prompts
+CREATE TABLE IF NOT EXISTS data_keys (
+    tenant_id VARCHAR(64) NOT NULL,     -- user ID, or 'shared' for records without one
+    version INTEGER NOT NULL,
+    wrapped_key BYTEA NOT NULL,         -- sealed by the vault key, never stored in plaintext
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, version)
+);
+
+-- Hourly classifier output per tenant, for category drift reports
+CREATE TABLE IF NOT EXISTS classification_distribution (
+    tenant_id VARCHAR(64) NOT NULL,     -- user ID, or '' for unauthenticated requests
+    hour TIMESTAMP WITH TIME ZONE NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    complexity VARCHAR(50) NOT NULL,
+    fallback BOOLEAN NOT NULL DEFAULT FALSE,  -- no category rule matched
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, hour, category, complexity, fallback)
+);
+
+-- Last recorded list price per model, the baseline price changes are measured against
+CREATE TABLE IF NOT EXISTS model_prices (
+    model_id VARCHAR(255) PRIMARY KEY,
+    cost_in_per_1k DOUBLE PRECISION,
+    cost_out_per_1k DOUBLE PRECISION,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- List price changes beyond the detection threshold
+CREATE TABLE IF NOT EXISTS pricing_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    model_id VARCHAR(255) NOT NULL,
+    provider VARCHAR(100) NOT NULL DEFAULT '',
+    previous_cost_in_per_1k DOUBLE PRECISION,
+    previous_cost_out_per_1k DOUBLE PRECISION,
+    cost_in_per_1k DOUBLE PRECISION,
+    cost_out_per_1k DOUBLE PRECISION,
+    change_percent DOUBLE PRECISION NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Where tenants receive price changes for models they pinned or use heavily
+CREATE TABLE IF NOT EXISTS pricing_webhooks (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    last_delivered_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Admin-set fallback models per category, overriding the fallbacks config file
+CREATE TABLE IF NOT EXISTS category_fallbacks (
+    category VARCHAR(100) PRIMARY KEY,  -- canonical capability, or '*' for every category
+    model_id VARCHAR(255) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK(threshold >= 0 AND threshold <= 1),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-tenant warehouse and bucket destinations for usage and audit exports
+CREATE TABLE IF NOT EXISTS export_destinations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,          -- bigquery, snowflake or s3_parquet
+    datasets TEXT[] NOT NULL DEFAULT '{usage}',
+    settings JSONB NOT NULL DEFAULT '{}'::jsonb,
+    sealed_credentials BYTEA NOT NULL,  -- encrypted with the vault key
+    interval_seconds INTEGER NOT NULL DEFAULT 3600 CHECK(interval_seconds >= 300),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    schema_version INTEGER NOT NULL DEFAULT 0,  -- export schema the destination's tables were last ensured for
+    last_run_at TIMESTAMP WITH TIME ZONE,       -- NULL when a run is due now
+    last_error TEXT,
+    lease_until TIMESTAMP WITH TIME ZONE,       -- set while a replica runs the destination
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- How far each dataset has been exported to a destination
+CREATE TABLE IF NOT EXISTS export_cursors (
+    destination_id UUID NOT NULL REFERENCES export_destinations(id) ON DELETE CASCADE,
+    dataset VARCHAR(20) NOT NULL,
+    last_ts TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_id UUID NOT NULL,
+    rows_exported BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (destination_id, dataset)
+);
+
+-- Operator-recorded model deprecations, shown on the catalog
+CREATE TABLE IF NOT EXISTS model_deprecations (
+    model_id VARCHAR(255) PRIMARY KEY,
+    announced_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    shutdown_date TIMESTAMP WITH TIME ZONE,  -- NULL when the provider has not set one
+    replacement VARCHAR(255),                -- the provider's recommended successor
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tenants opted in to automatic rewrites of pinned deprecated models
+CREATE TABLE IF NOT EXISTS migration_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    auto_rewrite BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Model trust tiers; new models start experimental until verified
+CREATE TABLE IF NOT EXISTS model_trust (
+    model_id VARCHAR(255) PRIMARY KEY,
+    tier VARCHAR(20) NOT NULL DEFAULT 'experimental' CHECK (tier IN ('verified', 'community', 'experimental')),
+    manual BOOLEAN NOT NULL DEFAULT FALSE,  -- set by an admin; automated checks leave it alone
+    checks JSONB NOT NULL DEFAULT '{}'::jsonb,
+    verified_by VARCHAR(255),               -- admin user ID, or 'automated'
+    verified_at TIMESTAMP WITH TIME ZONE,
+    note TEXT,
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Draft classifier rules, one row per label, edited by admins
+CREATE TABLE IF NOT EXISTS classifier_rules (
+    group_name VARCHAR(50) NOT NULL,     -- task_type, category, coding_subcategory or complexity
+    label VARCHAR(100) NOT NULL,
+    patterns JSONB NOT NULL DEFAULT '[]'::jsonb,
+    terms JSONB NOT NULL DEFAULT '[]'::jsonb,
+    weight NUMERIC(6,3),
+    threshold NUMERIC(6,3),
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_name, label)
+);
+
+-- Activated classifier rule sets; exactly one is active
+CREATE TABLE IF NOT EXISTS classifier_rule_versions (
+    version SERIAL PRIMARY KEY,
+    schema_version INTEGER NOT NULL,     -- layout of rules; newer than a replica reads is not applied
+    rules JSONB NOT NULL,
+    note TEXT,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    activated_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Judge model scores of sampled generate answers; prompts and answers are not stored
+CREATE TABLE IF NOT EXISTS quality_scores (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    model_id VARCHAR(255) NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    score DOUBLE PRECISION NOT NULL,    -- 0 to 1
+    judge_model VARCHAR(255) NOT NULL,
+    judge_cost_usd DECIMAL(10, 6) DEFAULT 0,
+    judged_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- TOTP second factors of dashboard accounts, sealed by the key vault
+CREATE TABLE IF NOT EXISTS two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    sealed_secret BYTEA NOT NULL,
+    backup_codes JSONB NOT NULL DEFAULT '[]'::jsonb,  -- SHA-256 hashes of unused backup codes
+    last_step BIGINT NOT NULL DEFAULT 0,              -- last accepted TOTP time step; codes cannot be replayed
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    confirmed_at TIMESTAMP WITH TIME ZONE,            -- NULL while enrollment is pending
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Organizations whose dashboard users must enroll a second factor
+CREATE TABLE IF NOT EXISTS two_factor_policies (
+    organization VARCHAR(255) PRIMARY KEY,  -- users.company_name, lower-cased
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Worst-case generation cost above which requests need confirm_high_cost;
+-- api_key_id NULL is the tenant's default
+CREATE TABLE IF NOT EXISTS cost_thresholds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID REFERENCES api_keys(id) ON DELETE CASCADE,
+    max_request_cost_usd NUMERIC(12, 6) NOT NULL CHECK(max_request_cost_usd > 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Component weights fitted to feedback: the promoted set and any A/B
+-- experiment, per priority mode; no row means the built-in weights
+CREATE TABLE IF NOT EXISTS scoring_weights (
+    priority VARCHAR(20) PRIMARY KEY,
+    promoted JSONB,
+    promoted_at TIMESTAMP WITH TIME ZONE,
+    candidate JSONB,
+    candidate_percent SMALLINT NOT NULL DEFAULT 0 CHECK(candidate_percent BETWEEN 0 AND 100),
+    experiment_started_at TIMESTAMP WITH TIME ZONE,
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Smart recommendation decisions for learning-to-rank: prompt features (never
+-- the prompt), classification and ranked candidates
+CREATE TABLE IF NOT EXISTS recommendation_decisions (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,  -- NULL for anonymous callers
+    features JSONB NOT NULL,
+    classification JSONB NOT NULL,
+    priority VARCHAR(20),
+    weights_variant VARCHAR(20),
+    candidates JSONB NOT NULL,
+    chosen_model VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Training datasets built from recommendation_decisions, gzipped JSON lines
+CREATE TABLE IF NOT EXISTS training_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, running, completed, failed, expired
+    schema_version INTEGER NOT NULL,
+    since TIMESTAMP WITH TIME ZONE NOT NULL,
+    until TIMESTAMP WITH TIME ZONE NOT NULL,
+    rated_only BOOLEAN NOT NULL DEFAULT FALSE,
+    include_comments BOOLEAN NOT NULL DEFAULT FALSE,
+    max_records INTEGER NOT NULL,
+    records INTEGER NOT NULL DEFAULT 0,
+    size_bytes BIGINT,
+    data BYTEA,
+    error TEXT,
+    requested_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Models an operator switched out of rotation
+CREATE TABLE IF NOT EXISTS model_disables (
+    model_id VARCHAR(255) PRIMARY KEY,
+    reason TEXT,
+    disabled_by VARCHAR(255),               -- admin user ID
+    disabled_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    until TIMESTAMP WITH TIME ZONE           -- re-enabled automatically when set
+);
+
+-- Scheduled periods models are out of rotation
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    model_id VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL CHECK (ends_at > starts_at),
+    reason TEXT,
+    created_by VARCHAR(255),                -- admin user ID
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- OIDC identity providers organizations sign in with
+CREATE TABLE IF NOT EXISTS sso_connections (
+    organization VARCHAR(255) PRIMARY KEY,  -- users.company_name, lower-cased
+    issuer TEXT NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret BYTEA NOT NULL,           -- sealed by the key vault
+    domains TEXT[] NOT NULL,                -- email domains the provider may sign in
+    role_claim VARCHAR(100) NOT NULL DEFAULT 'groups',
+    role_mapping JSONB NOT NULL DEFAULT '{}'::jsonb,  -- claim value -> organization role
+    default_role VARCHAR(20) NOT NULL DEFAULT 'member',
+    password_login BOOLEAN NOT NULL DEFAULT TRUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Pending SSO logins between the redirect to the provider and the callback
+CREATE TABLE IF NOT EXISTS sso_login_states (
+    state VARCHAR(64) PRIMARY KEY,
+    organization VARCHAR(255) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Periodic snapshots of each replica's catalog size, source freshness, fusion and cache counters
+CREATE TABLE IF NOT EXISTS stats_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    instance_id VARCHAR(255) NOT NULL,
+    captured_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    snapshot JSONB NOT NULL
+);
+
+-- Feature flags turned on or off for an organization or user, over their plan's features
+CREATE TABLE IF NOT EXISTS feature_flag_overrides (
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('org', 'user')),
+    subject VARCHAR(255) NOT NULL,          -- users.company_name, lower-cased, or a user ID
+    flag VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_by VARCHAR(255),                -- admin user ID
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (scope, subject, flag)
+);
+
 -- Indexes for performance
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 CREATE INDEX IF NOT EXISTS idx_users_plan ON users(plan_type, status);
@@ -141,6 +700,9 @@ CREATE INDEX IF NOT EXISTS idx_usage_user_hour ON api_usage(user_id, hour_bucket
 CREATE INDEX IF NOT EXISTS idx_usage_key ON api_usage(api_key_id);
 CREATE INDEX IF NOT EXISTS idx_usage_timestamp ON api_usage(timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_usage_endpoint ON api_usage(endpoint, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_usage_user_model ON api_usage(user_id, date_bucket, recommended_model);
+CREATE INDEX IF NOT EXISTS idx_usage_user_category ON api_usage(user_id, date_bucket, prompt_category);
+CREATE INDEX IF NOT EXISTS idx_usage_user_fingerprint ON api_usage(user_id, date_bucket, prompt_fingerprint) WHERE prompt_fingerprint IS NOT NULL;
 
 CREATE INDEX IF NOT EXISTS idx_monthly_summary_user ON monthly_usage_summary(user_id, year_month);
 
@@ -148,6 +710,46 @@ CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id, is_active);
 CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
 CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(refresh_token_hash);
 
+CREATE INDEX IF NOT EXISTS idx_provider_keys_user ON provider_keys(user_id, is_active);
+
+CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_log(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_event ON audit_log(event_type, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_feedback_user_category ON model_feedback(user_id, category, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_ingest_failures_due ON ingest_failures(status, next_attempt_at);
+
+CREATE INDEX IF NOT EXISTS idx_security_events_user ON security_events(user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_signing_secrets_user ON signing_secrets(user_id);
+CREATE INDEX IF NOT EXISTS idx_signing_secrets_key ON signing_secrets(api_key_id) WHERE api_key_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_signature_nonces_expires ON signature_nonces(expires_at);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_policies_default ON retention_policies(table_name) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_policies_tenant ON retention_policies(table_name, user_id) WHERE user_id IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cost_thresholds_default ON cost_thresholds(user_id) WHERE api_key_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cost_thresholds_key ON cost_thresholds(user_id, api_key_id) WHERE api_key_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_security_events_created ON security_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_classification_distribution_hour ON classification_distribution(hour);
+CREATE INDEX IF NOT EXISTS idx_ingest_failures_created ON ingest_failures(created_at);
+CREATE INDEX IF NOT EXISTS idx_usage_archive_user_day ON api_usage_archive(user_id, day);
+CREATE INDEX IF NOT EXISTS idx_pricing_history_model ON pricing_history(model_id, detected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_pricing_history_detected ON pricing_history(detected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_usage_user_export ON api_usage(user_id, timestamp, id);
+CREATE INDEX IF NOT EXISTS idx_export_destinations_user ON export_destinations(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_classifier_rule_versions_active ON classifier_rule_versions(active) WHERE active;
+CREATE INDEX IF NOT EXISTS idx_quality_scores_judged ON quality_scores(judged_at);
+CREATE INDEX IF NOT EXISTS idx_recommendation_decisions_created ON recommendation_decisions(created_at);
+CREATE INDEX IF NOT EXISTS idx_recommendation_decisions_user ON recommendation_decisions(user_id);
+CREATE INDEX IF NOT EXISTS idx_model_feedback_decision ON model_feedback(decision_id) WHERE decision_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_ends ON maintenance_windows(ends_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_identity ON users(oidc_issuer, oidc_subject) WHERE oidc_subject IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_sso_login_states_created ON sso_login_states(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_request ON audit_log(request_id) WHERE request_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_stats_snapshots_captured ON stats_snapshots(captured_at);
+
 -- Function to get next waitlist position
 CREATE OR REPLACE FUNCTION get_next_waitlist_position() RETURNS INTEGER AS $$
 BEGIN
@@ -171,23 +773,29 @@ CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
 CREATE TRIGGER update_plan_limits_updated_at BEFORE UPDATE ON plan_limits
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+CREATE TRIGGER update_provider_keys_updated_at BEFORE UPDATE ON provider_keys
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
 CREATE TRIGGER update_sessions_last_accessed BEFORE UPDATE ON sessions
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
 -- Initial plan data
-INSERT INTO plan_limits (plan_type, requests_per_hour, requests_per_day, requests_per_month, max_api_keys, can_generate, max_tokens_per_request, features) VALUES
-('free', 10, 100, 500, 1, FALSE, 2000, '{"support": "community", "analytics": false}'::jsonb),
-('beta', 100, 1000, 1000, 3, TRUE, 4000, '{"support": "email", "analytics": true, "early_access": true}'::jsonb),
-('starter', 1000, 10000, 100000, 5, TRUE, 8000, '{"support": "email", "analytics": true, "custom_models": false}'::jsonb),
-('pro', 5000, 50000, 500000, 10, TRUE, 16000, '{"support": "priority", "analytics": true, "custom_models": true, "webhooks": true}'::jsonb),
-('enterprise', 20000, 200000, 2000000, 50, TRUE, 32000, '{"support": "dedicated", "analytics": true, "custom_models": true, "webhooks": true, "sla": true}'::jsonb)
+INSERT INTO plan_limits (plan_type, requests_per_hour, requests_per_day, requests_per_month, max_api_keys, can_generate, rate_limit_burst, max_tokens_per_request, max_concurrent_requests, tokens_per_month, features) VALUES
+('free', 10, 100, 500, 1, FALSE, 5, 2000, 2, 200000, '{"support": "community", "analytics": false}'::jsonb),
+('beta', 100, 1000, 1000, 3, TRUE, 10, 4000, 5, 2000000, '{"support": "email", "analytics": true, "early_access": true}'::jsonb),
+('starter', 1000, 10000, 100000, 5, TRUE, 30, 8000, 10, 20000000, '{"support": "email", "analytics": true, "custom_models": false}'::jsonb),
+('pro', 5000, 50000, 500000, 10, TRUE, 100, 16000, 25, 100000000, '{"support": "priority", "analytics": true, "custom_models": true, "webhooks": true, "race_mode": true, "batch_api": true, "shadow_routing": false}'::jsonb),
+('enterprise', 20000, 200000, 2000000, 50, TRUE, 300, 32000, 100, 500000000, '{"support": "dedicated", "analytics": true, "custom_models": true, "webhooks": true, "sla": true, "race_mode": true, "batch_api": true, "shadow_routing": true}'::jsonb)
 ON CONFLICT (plan_type) DO UPDATE SET
     requests_per_hour = EXCLUDED.requests_per_hour,
     requests_per_day = EXCLUDED.requests_per_day,
     requests_per_month = EXCLUDED.requests_per_month,
     max_api_keys = EXCLUDED.max_api_keys,
     can_generate = EXCLUDED.can_generate,
+    rate_limit_burst = EXCLUDED.rate_limit_burst,
     max_tokens_per_request = EXCLUDED.max_tokens_per_request,
+    max_concurrent_requests = EXCLUDED.max_concurrent_requests,
+    tokens_per_month = EXCLUDED.tokens_per_month,
     features = EXCLUDED.features,
     updated_at = CURRENT_TIMESTAMP;
 
@@ -227,3 +835,44 @@ COMMENT ON TABLE api_usage IS 'Detailed API usage logs for analytics and rate li
 COMMENT ON TABLE monthly_usage_summary IS 'Aggregated monthly usage for fast rate limit checks';
 COMMENT ON TABLE plan_limits IS 'Configuration for different subscription plans';
 COMMENT ON TABLE sessions IS 'User sessions for JWT refresh token management';
+COMMENT ON TABLE provider_keys IS 'Per-tenant provider API keys (BYOK), encrypted at rest';
+COMMENT ON TABLE audit_log IS 'Append-only audit trail of security-relevant decisions';
+COMMENT ON TABLE safety_policies IS 'Per-tenant content safety actions by category';
+COMMENT ON TABLE safety_reviews IS 'Admin review status of flagged safety decisions';
+COMMENT ON TABLE logging_policies IS 'Per-tenant prompt logging mode and retention TTL';
+COMMENT ON TABLE secret_policies IS 'Per-tenant warn, mask or block action for secrets detected in generation prompts';
+COMMENT ON TABLE model_feedback IS 'Tenant ratings of recommended models for personalized routing';
+COMMENT ON TABLE catalog_snapshots IS 'Fused model catalogs published by the replication leader, and imported catalogs, for follower replicas';
+COMMENT ON TABLE model_metrics IS 'Latest ingested per-model metrics by source';
+COMMENT ON TABLE ingest_failures IS 'Dead-letter queue of metric rows that failed to ingest';
+COMMENT ON TABLE alert_channels IS 'Slack/Teams webhooks receiving operational alerts';
+COMMENT ON TABLE tenant_models IS 'Tenant fine-tuned models that route only for their owner';
+COMMENT ON TABLE security_events IS 'Usage anomalies detected per API key';
+COMMENT ON TABLE signing_secrets IS 'HMAC secrets for signed server-to-server requests, encrypted at rest';
+COMMENT ON TABLE signature_nonces IS 'Verified request signatures kept for the replay window';
+COMMENT ON TABLE retention_policies IS 'Per-table and per-tenant retention with summarize, export or delete on expiry';
+COMMENT ON TABLE api_usage_archive IS 'Daily usage aggregates of archived api_usage rows';
+COMMENT ON TABLE catalog_overlays IS 'Per-tenant include/exclude lists, model notes and negotiated pricing over the shared catalog';
+COMMENT ON TABLE classifier_rule_overlays IS 'Per-tenant classifier patterns that replace base rule labels for that tenant';
+COMMENT ON TABLE prompt_templates IS 'Per-tenant overrides of the catalog prompt templates applied to generation requests';
+COMMENT ON TABLE data_keys IS 'Versioned per-tenant AES data keys wrapped by the vault key, for prompts encrypted at rest';
+COMMENT ON TABLE export_destinations IS 'Per-tenant BigQuery, Snowflake and S3 Parquet destinations for scheduled usage and audit exports';
+COMMENT ON TABLE export_cursors IS 'Keyset position of the last record exported per destination and dataset';
+COMMENT ON TABLE model_deprecations IS 'Announced model deprecations with shutdown dates and provider replacements';
+COMMENT ON TABLE classifier_rules IS 'Draft classifier patterns, terms, weights and thresholds per label, applied on activation';
+COMMENT ON TABLE classifier_rule_versions IS 'Versioned classifier rule sets; the active one is served by every replica';
+COMMENT ON TABLE model_trust IS 'Model trust tiers with automated check results and admin verifications';
+COMMENT ON TABLE migration_settings IS 'Per-tenant opt-in to rewriting pinned deprecated models before shutdown';
+COMMENT ON TABLE classification_distribution IS 'Hourly counts of classified categories and complexities per tenant, for drift monitoring';
+COMMENT ON TABLE quality_scores IS 'Judge model scores of sampled generate answers, averaged per model and category for routing';
+COMMENT ON TABLE two_factor IS 'TOTP secrets, backup code hashes and lockout state of dashboard accounts';
+COMMENT ON TABLE two_factor_policies IS 'Per-organization enforcement of two-factor authentication for dashboard users';
+COMMENT ON TABLE cost_thresholds IS 'Per-tenant and per-key worst-case generation cost that requires explicit confirmation';
+COMMENT ON TABLE scoring_weights IS 'Promoted and candidate recommendation component weights per priority, tuned on feedback';
+COMMENT ON TABLE recommendation_decisions IS 'Prompt features, classification and ranked candidates of smart recommendations, for learning-to-rank exports';
+COMMENT ON TABLE training_exports IS 'Anonymized JSONL training datasets built from recommendation decisions and their feedback';
+COMMENT ON TABLE model_disables IS 'Models manually taken out of rotation by an admin, with the reason shown in catalog listings';
+COMMENT ON TABLE maintenance_windows IS 'Scheduled per-model maintenance windows during which the model is not routed to';
+COMMENT ON TABLE sso_connections IS 'Per-organization OIDC identity providers with role mapping and whether password login is allowed';
+COMMENT ON TABLE sso_login_states IS 'State, nonce and PKCE verifier of SSO logins awaiting the provider callback';
+COMMENT ON TABLE stats_snapshots IS 'Snapshots of /api/v2/stats taken every five minutes per replica, served as trends by /api/v2/stats/history';
@@ -0,0 +1,411 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// Operational event types
+const (
+	EventCatalogFetchFailed = "catalog_fetch_failed"
+	EventCircuitBreakerOpen = "circuit_breaker_open"
+	EventClassifierDrift    = "classifier_drift"
+	EventIngestAnomaly      = "ingest_anomaly"
+	EventJobFailed          = "job_failed"
+	EventPinnedModelRemoved = "pinned_model_removed"
+	EventPricingChanged     = "pricing_changed"
+	EventProviderIncident   = "provider_incident"
+	EventUsageAnomaly       = "usage_anomaly"
+)
+
+// EventTypes lists every event a channel can subscribe to
+var EventTypes = []string{EventCatalogFetchFailed, EventCircuitBreakerOpen, EventClassifierDrift, EventIngestAnomaly, EventJobFailed, EventPinnedModelRemoved, EventPricingChanged, EventProviderIncident, EventUsageAnomaly}
+
+// Severities in increasing order
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+var severityRank = map[string]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// Channel kinds
+const (
+	KindSlack = "slack"
+	KindTeams = "teams"
+)
+
+const (
+	defaultDedupeWindow = 15 * time.Minute
+	channelCacheTTL     = time.Minute
+)
+
+// Event is an operational occurrence worth telling a human about
+type Event struct {
+	Type     string            `json:"type"`
+	Severity string            `json:"severity"`
+	Source   string            `json:"source"`
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	// Key identifies repeats of the same problem for deduplication; defaults to Source
+	Key  string    `json:"-"`
+	Time time.Time `json:"time"`
+}
+
+// Channel is a webhook destination with its routing thresholds
+type Channel struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name" binding:"required"`
+	Kind                string    `json:"kind" binding:"required"` // slack, teams
+	WebhookURL          string    `json:"webhook_url,omitempty"`
+	MinSeverity         string    `json:"min_severity"`
+	EventTypes          []string  `json:"event_types"` // Empty subscribes to all events
+	DedupeWindowSeconds int       `json:"dedupe_window_seconds"`
+	Enabled             bool      `json:"enabled"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (ch Channel) accepts(e Event) bool {
+	if !ch.Enabled || severityRank[e.Severity] < severityRank[ch.MinSeverity] {
+		return false
+	}
+	if len(ch.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range ch.EventTypes {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+func (ch Channel) dedupeWindow() time.Duration {
+	if ch.DedupeWindowSeconds > 0 {
+		return time.Duration(ch.DedupeWindowSeconds) * time.Second
+	}
+	return defaultDedupeWindow
+}
+
+// Validate normalizes defaults and rejects unusable channel configs
+func (ch *Channel) Validate() error {
+	if ch.Kind != KindSlack && ch.Kind != KindTeams {
+		return fmt.Errorf("kind must be %q or %q", KindSlack, KindTeams)
+	}
+	if ch.MinSeverity == "" {
+		ch.MinSeverity = SeverityWarning
+	}
+	if _, ok := severityRank[ch.MinSeverity]; !ok {
+		return fmt.Errorf("unknown severity %q", ch.MinSeverity)
+	}
+	for _, t := range ch.EventTypes {
+		if !validEventType(t) {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	if ch.DedupeWindowSeconds < 0 {
+		return fmt.Errorf("dedupe_window_seconds must not be negative")
+	}
+	return nil
+}
+
+func validEventType(t string) bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+type dedupeState struct {
+	lastSent   time.Time
+	suppressed int
+}
+
+// Manager routes events to configured channels. A nil *Manager is valid and
+// drops every event, so subsystems can alert without checking configuration.
+type Manager struct {
+	db     *sql.DB
+	sender sender
+
+	mu        sync.Mutex
+	channels  []Channel
+	loadedAt  time.Time
+	envConfig []Channel
+	dedupe    map[string]*dedupeState
+}
+
+// NewManager creates a manager over the alert_channels table. db may be nil,
+// in which case only channels from ALERT_SLACK_WEBHOOK_URL / ALERT_TEAMS_WEBHOOK_URL apply.
+func NewManager(db *sql.DB) *Manager {
+	m := &Manager{
+		db:     db,
+		sender: newWebhookSender(),
+		dedupe: make(map[string]*dedupeState),
+	}
+
+	minSeverity := os.Getenv("ALERT_MIN_SEVERITY")
+	if minSeverity == "" {
+		minSeverity = SeverityWarning
+	}
+	for kind, env := range map[string]string{KindSlack: "ALERT_SLACK_WEBHOOK_URL", KindTeams: "ALERT_TEAMS_WEBHOOK_URL"} {
+		if url := os.Getenv(env); url != "" {
+			m.envConfig = append(m.envConfig, Channel{
+				ID:          "env-" + kind,
+				Name:        env,
+				Kind:        kind,
+				WebhookURL:  url,
+				MinSeverity: minSeverity,
+				Enabled:     true,
+			})
+		}
+	}
+	return m
+}
+
+// Notify delivers an event asynchronously to every matching channel
+func (m *Manager) Notify(e Event) {
+	if m == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Severity == "" {
+		e.Severity = SeverityWarning
+	}
+	if e.Key == "" {
+		e.Key = e.Source
+	}
+
+	go m.deliver(context.Background(), e)
+}
+
+// WatchCircuitBreakers alerts whenever an outbound HTTP client's circuit
+// breaker opens. The webhook client is skipped since it could not deliver.
+func (m *Manager) WatchCircuitBreakers() {
+	transport.SetBreakerHook(func(name string, failures int, cooldown time.Duration) {
+		if name == "alerts" {
+			return
+		}
+		m.Notify(Event{
+			Type:     EventCircuitBreakerOpen,
+			Severity: SeverityCritical,
+			Source:   "transport",
+			Title:    fmt.Sprintf("Circuit breaker open for %s", name),
+			Message:  fmt.Sprintf("%d consecutive failed requests; calls fail fast for %s", failures, cooldown),
+			Fields:   map[string]string{"client": name},
+			Key:      "transport:" + name,
+		})
+	})
+}
+
+func (m *Manager) deliver(ctx context.Context, e Event) {
+	channels, err := m.activeChannels(ctx)
+	if err != nil {
+		log.Printf("[ALERTS] Failed to load alert channels: %v", err)
+		return
+	}
+
+	for _, ch := range channels {
+		if !ch.accepts(e) {
+			continue
+		}
+		suppressed, send := m.admit(ch, e)
+		if !send {
+			continue
+		}
+		if err := m.sender.send(ctx, ch, e, suppressed); err != nil {
+			log.Printf("[ALERTS] Failed to post %s to %s: %v", e.Type, ch.Name, err)
+		}
+	}
+}
+
+// admit applies the channel's dedupe window and returns how many repeats were suppressed
+func (m *Manager) admit(ch Channel, e Event) (int, bool) {
+	key := ch.ID + "|" + e.Type + "|" + e.Key
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.dedupe[key]
+	if !ok {
+		state = &dedupeState{}
+		m.dedupe[key] = state
+	}
+	if !state.lastSent.IsZero() && e.Time.Sub(state.lastSent) < ch.dedupeWindow() {
+		state.suppressed++
+		return 0, false
+	}
+
+	suppressed := state.suppressed
+	state.lastSent = e.Time
+	state.suppressed = 0
+	return suppressed, true
+}
+
+func (m *Manager) activeChannels(ctx context.Context) ([]Channel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.db != nil && time.Since(m.loadedAt) > channelCacheTTL {
+		channels, err := m.listChannels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.channels = channels
+		m.loadedAt = time.Now()
+	}
+
+	return append(append([]Channel{}, m.envConfig...), m.channels...), nil
+}
+
+func (m *Manager) invalidate() {
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+// Channels returns the configured channels with webhook URLs masked
+func (m *Manager) Channels(ctx context.Context) ([]Channel, error) {
+	channels, err := m.activeChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range channels {
+		channels[i].WebhookURL = maskURL(channels[i].WebhookURL)
+	}
+	return channels, nil
+}
+
+func (m *Manager) listChannels(ctx context.Context) ([]Channel, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT id, name, kind, webhook_url, min_severity, event_types, dedupe_window_seconds, enabled, updated_at
+		FROM alert_channels
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert channels: %w", err)
+	}
+	defer rows.Close()
+
+	channels := []Channel{}
+	for rows.Next() {
+		var ch Channel
+		var eventTypes []byte
+		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Kind, &ch.WebhookURL, &ch.MinSeverity, &eventTypes,
+			&ch.DedupeWindowSeconds, &ch.Enabled, &ch.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert channel: %w", err)
+		}
+		json.Unmarshal(eventTypes, &ch.EventTypes)
+		channels = append(channels, ch)
+	}
+	return channels, rows.Err()
+}
+
+// PutChannel creates a channel, or updates it when ch.ID is set. An empty
+// webhook URL on update keeps the stored one, since reads never return it.
+func (m *Manager) PutChannel(ctx context.Context, ch Channel) (string, error) {
+	if m.db == nil {
+		return "", fmt.Errorf("alert channels require a database")
+	}
+	if err := ch.Validate(); err != nil {
+		return "", err
+	}
+	if ch.EventTypes == nil {
+		ch.EventTypes = []string{}
+	}
+	eventTypes, _ := json.Marshal(ch.EventTypes)
+	if ch.DedupeWindowSeconds == 0 {
+		ch.DedupeWindowSeconds = int(defaultDedupeWindow.Seconds())
+	}
+
+	var id string
+	var err error
+	if ch.ID == "" {
+		if ch.WebhookURL == "" {
+			return "", fmt.Errorf("webhook_url is required")
+		}
+		err = m.db.QueryRowContext(ctx, `
+			INSERT INTO alert_channels (name, kind, webhook_url, min_severity, event_types, dedupe_window_seconds, enabled)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id`,
+			ch.Name, ch.Kind, ch.WebhookURL, ch.MinSeverity, eventTypes, ch.DedupeWindowSeconds, ch.Enabled).Scan(&id)
+	} else {
+		err = m.db.QueryRowContext(ctx, `
+			UPDATE alert_channels
+			SET name = $2, kind = $3, webhook_url = COALESCE(NULLIF($4, ''), webhook_url), min_severity = $5,
+			    event_types = $6, dedupe_window_seconds = $7, enabled = $8, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+			RETURNING id`,
+			ch.ID, ch.Name, ch.Kind, ch.WebhookURL, ch.MinSeverity, eventTypes, ch.DedupeWindowSeconds, ch.Enabled).Scan(&id)
+	}
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("alert channel not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save alert channel: %w", err)
+	}
+
+	m.invalidate()
+	return id, nil
+}
+
+// DeleteChannel removes a channel
+func (m *Manager) DeleteChannel(ctx context.Context, id string) error {
+	if m.db == nil {
+		return fmt.Errorf("alert channels require a database")
+	}
+	res, err := m.db.ExecContext(ctx, `DELETE FROM alert_channels WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert channel: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("alert channel not found")
+	}
+
+	m.invalidate()
+	return nil
+}
+
+// Test posts a sample event to one channel synchronously, bypassing thresholds and dedupe
+func (m *Manager) Test(ctx context.Context, id string) error {
+	channels, err := m.activeChannels(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		if ch.ID == id {
+			return m.sender.send(ctx, ch, Event{
+				Type:     EventJobFailed,
+				Severity: SeverityInfo,
+				Source:   "alerts",
+				Title:    "Test alert",
+				Message:  fmt.Sprintf("Alert channel %q is configured correctly", ch.Name),
+				Time:     time.Now(),
+			}, 0)
+		}
+	}
+	return fmt.Errorf("alert channel not found")
+}
+
+func maskURL(url string) string {
+	if len(url) <= 12 {
+		return "****"
+	}
+	return url[:8] + "****" + url[len(url)-4:]
+}
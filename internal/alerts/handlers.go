@@ -0,0 +1,104 @@
+package alerts
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes alert channel configuration to admins
+type Handlers struct {
+	manager *Manager
+}
+
+func NewHandlers(manager *Manager) *Handlers {
+	return &Handlers{manager: manager}
+}
+
+// ListChannels returns configured channels and the events they can subscribe to
+func (h *Handlers) ListChannels(c *gin.Context) {
+	channels, err := h.manager.Channels(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list alert channels",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"channels":    channels,
+			"event_types": EventTypes,
+			"severities":  []string{SeverityInfo, SeverityWarning, SeverityCritical},
+		},
+	})
+}
+
+// CreateChannel adds a webhook channel
+func (h *Handlers) CreateChannel(c *gin.Context) {
+	h.saveChannel(c, "")
+}
+
+// UpdateChannel replaces a channel's settings
+func (h *Handlers) UpdateChannel(c *gin.Context) {
+	h.saveChannel(c, c.Param("id"))
+}
+
+func (h *Handlers) saveChannel(c *gin.Context, id string) {
+	// Channels are enabled unless the request says otherwise
+	req := Channel{Enabled: true}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.ID = id
+
+	savedID, err := h.manager.PutChannel(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid alert channel",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"id":      savedID,
+	})
+}
+
+// DeleteChannel removes a channel
+func (h *Handlers) DeleteChannel(c *gin.Context) {
+	if err := h.manager.DeleteChannel(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to delete alert channel",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// TestChannel posts a sample alert so admins can verify the webhook
+func (h *Handlers) TestChannel(c *gin.Context) {
+	if err := h.manager.Test(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Test alert failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,115 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+type sender interface {
+	send(ctx context.Context, ch Channel, e Event, suppressed int) error
+}
+
+type webhookSender struct {
+	client *http.Client
+}
+
+func newWebhookSender() *webhookSender {
+	return &webhookSender{client: transport.Client("alerts")}
+}
+
+var severityColor = map[string]string{
+	SeverityInfo:     "2EB886",
+	SeverityWarning:  "DAA038",
+	SeverityCritical: "A30200",
+}
+
+func (s *webhookSender) send(ctx context.Context, ch Channel, e Event, suppressed int) error {
+	var payload interface{}
+	switch ch.Kind {
+	case KindSlack:
+		payload = slackPayload(e, suppressed)
+	case KindTeams:
+		payload = teamsPayload(e, suppressed)
+	default:
+		return fmt.Errorf("unsupported channel kind %q", ch.Kind)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func headline(e Event) string {
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(e.Severity), e.Title)
+}
+
+func details(e Event, suppressed int) []string {
+	lines := []string{}
+	if e.Message != "" {
+		lines = append(lines, e.Message)
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, e.Fields[k]))
+	}
+
+	lines = append(lines, fmt.Sprintf("event: %s · source: %s · %s", e.Type, e.Source, e.Time.UTC().Format(time.RFC3339)))
+	if suppressed > 0 {
+		lines = append(lines, fmt.Sprintf("%d similar alerts suppressed since the last notification", suppressed))
+	}
+	return lines
+}
+
+func slackPayload(e Event, suppressed int) map[string]interface{} {
+	return map[string]interface{}{
+		"text": headline(e),
+		"attachments": []map[string]interface{}{{
+			"color": "#" + severityColor[e.Severity],
+			"text":  strings.Join(details(e, suppressed), "\n"),
+		}},
+	}
+}
+
+func teamsPayload(e Event, suppressed int) map[string]interface{} {
+	return map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    headline(e),
+		"themeColor": severityColor[e.Severity],
+		"title":      headline(e),
+		"text":       strings.Join(details(e, suppressed), "\n\n"),
+	}
+}
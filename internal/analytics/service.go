@@ -1,12 +1,15 @@
 package analytics
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
 )
 
 type AnalyticsAPIResponse struct {
@@ -101,22 +104,20 @@ func NewService() *Service {
 	}
 
 	return &Service{
-		apiKey:  apiKey,
-		baseURL: "https://artificialanalysis.ai/api/v2",
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		apiKey:     apiKey,
+		baseURL:    "https://artificialanalysis.ai/api/v2",
+		httpClient: transport.Client("analytics"),
 	}
 }
 
-func (s *Service) FetchModels() ([]ModelData, error) {
-	return s.FetchModelsWithETag("")
+func (s *Service) FetchModels(ctx context.Context) ([]ModelData, error) {
+	return s.FetchModelsWithETag(ctx, "")
 }
 
-func (s *Service) FetchModelsWithETag(etag string) ([]ModelData, error) {
+func (s *Service) FetchModelsWithETag(ctx context.Context, etag string) ([]ModelData, error) {
 	url := fmt.Sprintf("%s/data/llms/models", s.baseURL)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -153,10 +154,10 @@ func (s *Service) FetchModelsWithETag(etag string) ([]ModelData, error) {
 	return apiResp.Data, nil
 }
 
-func (s *Service) GetResponseETag(etag string) (string, []ModelData, error) {
+func (s *Service) GetResponseETag(ctx context.Context, etag string) (string, []ModelData, error) {
 	url := fmt.Sprintf("%s/data/llms/models", s.baseURL)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", nil, fmt.Errorf("create request: %w", err)
 	}
@@ -196,6 +197,29 @@ func (s *Service) GetResponseETag(etag string) (string, []ModelData, error) {
 	return responseETag, apiResp.Data, nil
 }
 
+// Ping checks that the Analytics AI API is reachable and accepts the key,
+// without downloading the model list
+func (s *Service) Ping(ctx context.Context) error {
+	url := fmt.Sprintf("%s/data/llms/models", s.baseURL)
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("x-api-key", s.apiKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("analytics API error %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // ConvertToInternalModel converts Analytics AI model data to our internal format
 func (s *Service) ConvertToInternalModel(data ModelData) Model {
 	// Map creator slug to our provider format
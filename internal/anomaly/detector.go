@@ -0,0 +1,475 @@
+package anomaly
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+)
+
+// Anomaly kinds
+const (
+	KindRequestSpike  = "request_spike"
+	KindCategoryShift = "category_shift"
+	KindOffHoursBurst = "off_hours_burst"
+)
+
+// Config holds detection thresholds
+type Config struct {
+	SpikeFactor        float64       // Recent rate over baseline that counts as a spike
+	MinSpikeRequests   int           // Requests in the spike window before a spike can fire
+	MinBaselineMinutes int           // History required before spikes are judged
+	CategoryShift      float64       // Total variation distance between recent and usual categories
+	MinCategoryHistory float64       // Historical requests required before category shifts are judged
+	MinCategoryRecent  int           // Recent requests required before category shifts are judged
+	OffHoursShare      float64       // Hour-of-day share below which an hour is off-hours for the key
+	MinHourlyHistory   float64       // Historical requests required before off-hours bursts are judged
+	MinOffHoursBurst   int           // Requests in the spike window that make an off-hours burst
+	FlagTTL            time.Duration // How long a key stays suspicious after its last anomaly
+	Throttle           bool          // Rate limit suspicious keys
+	ThrottleRPM        int           // Requests per minute allowed for a suspicious key
+}
+
+// DefaultConfig returns the built-in thresholds; ANOMALY_THROTTLE=true and
+// ANOMALY_THROTTLE_RPM enable and size throttling of suspicious keys
+func DefaultConfig() Config {
+	cfg := Config{
+		SpikeFactor:        10,
+		MinSpikeRequests:   50,
+		MinBaselineMinutes: 60,
+		CategoryShift:      0.6,
+		MinCategoryHistory: 200,
+		MinCategoryRecent:  30,
+		OffHoursShare:      0.01,
+		MinHourlyHistory:   500,
+		MinOffHoursBurst:   20,
+		FlagTTL:            time.Hour,
+		Throttle:           os.Getenv("ANOMALY_THROTTLE") == "true",
+		ThrottleRPM:        10,
+	}
+	if rpm, err := strconv.Atoi(os.Getenv("ANOMALY_THROTTLE_RPM")); err == nil && rpm > 0 {
+		cfg.ThrottleRPM = rpm
+	}
+	return cfg
+}
+
+const (
+	spikeWindowMinutes    = 5
+	categoryWindowMinutes = 15
+	// Baseline and category history decay with a one-day time constant
+	baselineAlpha = 1.0 / 1440
+	// Keys idle this long are forgotten
+	idleExpiry = 7 * 24 * time.Hour
+)
+
+// Flag marks a key as suspicious
+type Flag struct {
+	Subject   string    `json:"subject"`
+	UserID    string    `json:"-"`
+	Reasons   []string  `json:"reasons"`
+	FlaggedAt time.Time `json:"flagged_at"`
+	Until     time.Time `json:"until"`
+	Throttled bool      `json:"throttled"`
+}
+
+type minuteBucket struct {
+	minute     int64
+	requests   int
+	categories map[string]int
+}
+
+// keyStats is the usage profile of one API key (or JWT user without a key)
+type keyStats struct {
+	userID string
+
+	// Recent per-minute buckets, indexed by minute % len
+	buckets    [categoryWindowMinutes]minuteBucket
+	lastMinute int64
+	firstSeen  int64
+
+	// Long-term profile folded from completed minutes
+	baseline   float64            // Decayed average requests per minute
+	categories map[string]float64 // Decayed request counts per category
+	hours      [24]float64        // Request counts per UTC hour of day
+
+	flag      *Flag
+	lastEvent map[string]time.Time
+}
+
+// Detector watches per-key usage for spikes, category shifts and off-hours bursts
+type Detector struct {
+	db     *sql.DB
+	alerts *alerts.Manager
+	cfg    Config
+
+	mu     sync.Mutex
+	keys   map[string]*keyStats
+	lastGC time.Time
+}
+
+func NewDetector(db *sql.DB, cfg Config) *Detector {
+	return &Detector{
+		db:   db,
+		cfg:  cfg,
+		keys: make(map[string]*keyStats),
+	}
+}
+
+// SetAlerts posts detected anomalies to the operational alert webhooks
+func (d *Detector) SetAlerts(manager *alerts.Manager) {
+	d.alerts = manager
+}
+
+// Subject identifies the credential a request used: the API key when present,
+// otherwise the JWT user
+func Subject(apiKeyID, userID string) string {
+	if apiKeyID != "" {
+		return "key:" + apiKeyID
+	}
+	return "user:" + userID
+}
+
+// Observe records one request and returns any anomalies it triggered
+func (d *Detector) Observe(subject, userID, category string) []Event {
+	now := time.Now()
+	minute := now.Unix() / 60
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.gc(now)
+
+	ks, ok := d.keys[subject]
+	if !ok {
+		ks = &keyStats{
+			userID:     userID,
+			firstSeen:  minute,
+			lastMinute: minute,
+			categories: make(map[string]float64),
+			lastEvent:  make(map[string]time.Time),
+		}
+		d.keys[subject] = ks
+	}
+	ks.advance(minute)
+
+	bucket := &ks.buckets[minute%categoryWindowMinutes]
+	if bucket.minute != minute {
+		*bucket = minuteBucket{minute: minute, categories: make(map[string]int)}
+	}
+	bucket.requests++
+	if category != "" {
+		bucket.categories[category]++
+	}
+
+	var events []Event
+	for _, e := range d.evaluate(ks, now, minute) {
+		// Report each kind once per flag period; the flag itself keeps extending
+		if last, ok := ks.lastEvent[e.Kind]; ok && now.Sub(last) < d.cfg.FlagTTL {
+			ks.flag.Until = now.Add(d.cfg.FlagTTL)
+			continue
+		}
+		ks.lastEvent[e.Kind] = now
+		e.Subject = subject
+		e.UserID = ks.userID
+		e.Throttled = d.cfg.Throttle
+		e.CreatedAt = now
+		d.flag(ks, subject, e, now)
+		events = append(events, e)
+	}
+	return events
+}
+
+// advance folds completed minutes into the long-term profile
+func (ks *keyStats) advance(minute int64) {
+	if minute <= ks.lastMinute {
+		return
+	}
+
+	prev := ks.buckets[ks.lastMinute%categoryWindowMinutes]
+	count := 0
+	if prev.minute == ks.lastMinute {
+		count = prev.requests
+		decay := 1 - baselineAlpha
+		for category, v := range ks.categories {
+			ks.categories[category] = v * decay
+		}
+		for category, n := range prev.categories {
+			ks.categories[category] += float64(n)
+		}
+		ks.hours[time.Unix(ks.lastMinute*60, 0).UTC().Hour()] += float64(count)
+	}
+	ks.baseline = ks.baseline*(1-baselineAlpha) + baselineAlpha*float64(count)
+
+	// Idle minutes in between count as zero requests
+	if gap := minute - ks.lastMinute - 1; gap > 0 {
+		ks.baseline *= math.Pow(1-baselineAlpha, float64(gap))
+	}
+	ks.lastMinute = minute
+}
+
+func (ks *keyStats) recent(minute int64, window int) (int, map[string]int) {
+	requests := 0
+	categories := make(map[string]int)
+	for i := 0; i < window; i++ {
+		b := ks.buckets[(minute-int64(i))%categoryWindowMinutes]
+		if b.minute != minute-int64(i) {
+			continue
+		}
+		requests += b.requests
+		for category, n := range b.categories {
+			categories[category] += n
+		}
+	}
+	return requests, categories
+}
+
+func (d *Detector) evaluate(ks *keyStats, now time.Time, minute int64) []Event {
+	var events []Event
+	spikeRequests, _ := ks.recent(minute, spikeWindowMinutes)
+
+	// Sudden request spikes against the key's own baseline. The baseline is
+	// floored so a nearly idle key still needs a real burst to trip it.
+	if minute-ks.firstSeen >= int64(d.cfg.MinBaselineMinutes) && spikeRequests >= d.cfg.MinSpikeRequests {
+		baseline := math.Max(ks.baseline, 0.5)
+		rate := float64(spikeRequests) / spikeWindowMinutes
+		if rate >= d.cfg.SpikeFactor*baseline {
+			events = append(events, Event{
+				Kind:     KindRequestSpike,
+				Severity: alerts.SeverityCritical,
+				Details: map[string]interface{}{
+					"requests_per_minute": round(rate),
+					"baseline_per_minute": round(ks.baseline),
+					"factor":              round(rate / baseline),
+					"window_minutes":      spikeWindowMinutes,
+				},
+			})
+		}
+	}
+
+	// Unusual category mix compared to what the key normally asks for
+	_, recentCategories := ks.recent(minute, categoryWindowMinutes)
+	historyTotal := 0.0
+	for _, v := range ks.categories {
+		historyTotal += v
+	}
+	recentTotal := 0
+	for _, n := range recentCategories {
+		recentTotal += n
+	}
+	if historyTotal >= d.cfg.MinCategoryHistory && recentTotal >= d.cfg.MinCategoryRecent {
+		distance, dominant := categoryDistance(ks.categories, historyTotal, recentCategories, recentTotal)
+		if distance >= d.cfg.CategoryShift {
+			events = append(events, Event{
+				Kind:     KindCategoryShift,
+				Severity: alerts.SeverityWarning,
+				Details: map[string]interface{}{
+					"distance":          round(distance),
+					"recent_categories": recentCategories,
+					"usual_categories":  topCategories(ks.categories, 3),
+					"dominant_category": dominant,
+					"window_minutes":    categoryWindowMinutes,
+				},
+			})
+		}
+	}
+
+	// Bursts at hours the key is normally silent
+	hoursTotal := 0.0
+	for _, v := range ks.hours {
+		hoursTotal += v
+	}
+	hour := now.UTC().Hour()
+	if hoursTotal >= d.cfg.MinHourlyHistory && spikeRequests >= d.cfg.MinOffHoursBurst {
+		if share := ks.hours[hour] / hoursTotal; share < d.cfg.OffHoursShare {
+			events = append(events, Event{
+				Kind:     KindOffHoursBurst,
+				Severity: alerts.SeverityWarning,
+				Details: map[string]interface{}{
+					"hour_utc":       hour,
+					"usual_share":    round(share),
+					"requests":       spikeRequests,
+					"window_minutes": spikeWindowMinutes,
+				},
+			})
+		}
+	}
+
+	return events
+}
+
+// categoryDistance is the total variation distance between the usual and recent
+// category distributions, plus the recent category that gained the most share
+func categoryDistance(history map[string]float64, historyTotal float64, recent map[string]int, recentTotal int) (float64, string) {
+	seen := make(map[string]bool, len(history)+len(recent))
+	for c := range history {
+		seen[c] = true
+	}
+	for c := range recent {
+		seen[c] = true
+	}
+
+	distance := 0.0
+	dominant, maxGain := "", 0.0
+	for c := range seen {
+		p := history[c] / historyTotal
+		q := float64(recent[c]) / float64(recentTotal)
+		distance += math.Abs(p - q)
+		if q-p > maxGain {
+			dominant, maxGain = c, q-p
+		}
+	}
+	return distance / 2, dominant
+}
+
+func topCategories(categories map[string]float64, n int) []string {
+	names := make([]string, 0, len(categories))
+	for c := range categories {
+		names = append(names, c)
+	}
+	sort.Slice(names, func(i, j int) bool { return categories[names[i]] > categories[names[j]] })
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+func (d *Detector) flag(ks *keyStats, subject string, e Event, now time.Time) {
+	if ks.flag == nil || now.After(ks.flag.Until) {
+		ks.flag = &Flag{Subject: subject, UserID: ks.userID, FlaggedAt: now}
+	}
+	if !containsString(ks.flag.Reasons, e.Kind) {
+		ks.flag.Reasons = append(ks.flag.Reasons, e.Kind)
+	}
+	ks.flag.Until = now.Add(d.cfg.FlagTTL)
+	ks.flag.Throttled = d.cfg.Throttle
+}
+
+// Allow reports whether a request from subject may proceed. Only suspicious
+// keys are limited, and only when throttling is enabled.
+func (d *Detector) Allow(subject string) (bool, time.Duration) {
+	if !d.cfg.Throttle {
+		return true, 0
+	}
+	now := time.Now()
+	minute := now.Unix() / 60
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ks, ok := d.keys[subject]
+	if !ok || ks.flag == nil || now.After(ks.flag.Until) {
+		return true, 0
+	}
+	if b := ks.buckets[minute%categoryWindowMinutes]; b.minute == minute && b.requests >= d.cfg.ThrottleRPM {
+		return false, time.Duration(60-now.Unix()%60) * time.Second
+	}
+	return true, 0
+}
+
+// Flags returns the user's currently suspicious keys
+func (d *Detector) Flags(userID string) []Flag {
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	flags := []Flag{}
+	for _, ks := range d.keys {
+		if ks.flag != nil && ks.userID == userID && now.Before(ks.flag.Until) {
+			flags = append(flags, *ks.flag)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].FlaggedAt.After(flags[j].FlaggedAt) })
+	return flags
+}
+
+// ClearFlag lifts the suspicious flag from one of the user's keys, e.g. after rotating it
+func (d *Detector) ClearFlag(userID, subject string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ks, ok := d.keys[subject]
+	if !ok || ks.userID != userID || ks.flag == nil {
+		return fmt.Errorf("no active flag for %s", subject)
+	}
+	ks.flag = nil
+	ks.lastEvent = make(map[string]time.Time)
+	return nil
+}
+
+func (d *Detector) gc(now time.Time) {
+	if now.Sub(d.lastGC) < time.Hour {
+		return
+	}
+	d.lastGC = now
+	cutoff := now.Add(-idleExpiry).Unix() / 60
+	for subject, ks := range d.keys {
+		if ks.lastMinute < cutoff {
+			delete(d.keys, subject)
+		}
+	}
+}
+
+// report persists and announces anomalies off the request path
+func (d *Detector) report(events []Event) {
+	for _, e := range events {
+		log.Printf("[SECURITY] %s on %s (user %s): %v", e.Kind, e.Subject, e.UserID, e.Details)
+
+		d.alerts.Notify(alerts.Event{
+			Type:     alerts.EventUsageAnomaly,
+			Severity: e.Severity,
+			Source:   "anomaly",
+			Title:    fmt.Sprintf("Suspicious usage on %s: %s", e.Subject, strings.ReplaceAll(e.Kind, "_", " ")),
+			Message:  "The key's usage deviates sharply from its history and may have leaked.",
+			Fields:   alertFields(e),
+			Key:      e.Subject + "|" + e.Kind,
+		})
+	}
+
+	if d.db == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, e := range events {
+			if err := d.saveEvent(ctx, e); err != nil {
+				log.Printf("[SECURITY] %v", err)
+			}
+		}
+	}()
+}
+
+func alertFields(e Event) map[string]string {
+	fields := map[string]string{
+		"subject":   e.Subject,
+		"user_id":   e.UserID,
+		"throttled": strconv.FormatBool(e.Throttled),
+	}
+	for k, v := range e.Details {
+		fields[k] = fmt.Sprint(v)
+	}
+	return fields
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,66 @@
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Event is one detected anomaly on a key
+type Event struct {
+	ID        string                 `json:"id,omitempty"`
+	Subject   string                 `json:"subject"`
+	UserID    string                 `json:"-"`
+	Kind      string                 `json:"kind"`
+	Severity  string                 `json:"severity"`
+	Details   map[string]interface{} `json:"details"`
+	Throttled bool                   `json:"throttled"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+func (d *Detector) saveEvent(ctx context.Context, e Event) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode security event: %w", err)
+	}
+
+	_, err = d.db.ExecContext(ctx, `
+		INSERT INTO security_events (user_id, subject, kind, severity, details, throttled, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		e.UserID, e.Subject, e.Kind, e.Severity, details, e.Throttled, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+	return nil
+}
+
+// ListEvents returns the user's most recent anomalies, newest first
+func (d *Detector) ListEvents(ctx context.Context, userID string, limit int) ([]Event, error) {
+	if d.db == nil {
+		return []Event{}, nil
+	}
+
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT id, subject, kind, severity, details, throttled, created_at
+		FROM security_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var e Event
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.Subject, &e.Kind, &e.Severity, &details, &e.Throttled, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan security event: %w", err)
+		}
+		json.Unmarshal(details, &e.Details)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,129 @@
+package anomaly
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/secrets"
+	"github.com/Askeban/llm-router-go/internal/usage"
+)
+
+// Middleware throttles suspicious keys before the handler runs and feeds every
+// authenticated request into the detector afterwards. It must run after the
+// auth middleware so the caller is known.
+func (d *Detector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" || c.Request.Method == "OPTIONS" {
+			c.Next()
+			return
+		}
+		subject := Subject(c.GetString("api_key_id"), userID)
+
+		if ok, retryAfter := d.Allow(subject); !ok {
+			limits.TooManyRequests(c, limits.RetryHint{
+				RetryAfter: retryAfter,
+				Window:     limits.WindowThrottle,
+				Backoff:    limits.BackoffFixed,
+			}, gin.H{
+				"error":   "Key throttled due to suspicious usage",
+				"code":    "key_throttled",
+				"details": "Review recent activity on /api/v1/dashboard/security and rotate the key if it has leaked",
+			})
+			return
+		}
+
+		c.Next()
+
+		if events := d.Observe(subject, userID, c.GetString(usage.ContextCategory)); len(events) > 0 {
+			d.report(events)
+		}
+	}
+}
+
+// secretWindow is how far back the overview counts secrets caught in prompts
+const secretWindow = 30 * 24 * time.Hour
+
+// SecretSummarizer counts the secrets caught in a tenant's prompts
+type SecretSummarizer interface {
+	Summarize(ctx context.Context, userID string, since time.Time) (secrets.Summary, error)
+}
+
+// Handlers exposes suspicious key flags and anomaly history on the dashboard
+type Handlers struct {
+	detector *Detector
+	secrets  SecretSummarizer
+}
+
+func NewHandlers(detector *Detector) *Handlers {
+	return &Handlers{detector: detector}
+}
+
+// SetSecrets adds counts of secrets caught in prompts to the overview
+func (h *Handlers) SetSecrets(summarizer SecretSummarizer) {
+	h.secrets = summarizer
+}
+
+// Overview returns the caller's flagged keys and recent anomalies
+func (h *Handlers) Overview(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid limit",
+			"details": "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	events, err := h.detector.ListEvents(c.Request.Context(), userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load security events",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	data := gin.H{
+		"flagged_keys":       h.detector.Flags(userID),
+		"events":             events,
+		"throttling_enabled": h.detector.cfg.Throttle,
+	}
+	if h.secrets != nil {
+		summary, err := h.secrets.Summarize(c.Request.Context(), userID, time.Now().Add(-secretWindow))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to load secret detections",
+				"details": err.Error(),
+			})
+			return
+		}
+		data["secret_detections"] = summary
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// ClearFlag lets the owner lift a flag once the key is rotated or the traffic explained
+func (h *Handlers) ClearFlag(c *gin.Context) {
+	if err := h.detector.ClearFlag(c.GetString("user_id"), c.Param("subject")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to clear flag",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
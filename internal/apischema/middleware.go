@@ -0,0 +1,213 @@
+package apischema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/jsonschema"
+)
+
+const (
+	// maxFieldErrors bounds the field errors returned for one request
+	maxFieldErrors = 20
+	// maxResponseBytes is the largest response body checked against its schema
+	maxResponseBytes = 1 << 20
+)
+
+// FieldError is one field of a request body that does not match the spec
+type FieldError struct {
+	Field   string `json:"field"` // e.g. "priority" or "attachments[0].type"; "body" for the whole body
+	Message string `json:"message"`
+}
+
+// Validator checks request bodies, and optionally response bodies, against
+// the JSON Schema generated from the embedded OpenAPI spec for their route.
+// Routes the spec does not describe pass through unchecked.
+type Validator struct {
+	operations map[string]operation
+	enforce    bool // Reject invalid requests; otherwise only log them
+	responses  bool // Log responses that do not match the spec
+}
+
+// NewValidator generates the schemas from the embedded spec. With enforce
+// unset invalid requests are logged and still served, for rolling out a
+// stricter spec.
+func NewValidator(enforce, responses bool) (*Validator, error) {
+	operations, err := loadOperations(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{operations: operations, enforce: enforce, responses: responses}, nil
+}
+
+// Operations is the number of routes the spec describes
+func (v *Validator) Operations() int {
+	return len(v.operations)
+}
+
+// Middleware validates the request body before the handler binds it. It
+// must run after the body limit, which buffers the body, and reads the route
+// pattern so it must be registered with Use on the engine.
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := v.operations[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if op.request != nil {
+			if !v.checkRequest(c, op) {
+				return
+			}
+		}
+
+		if !v.responses || len(op.responses) == 0 {
+			c.Next()
+			return
+		}
+		capture := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = capture
+		c.Next()
+		v.checkResponse(c, op, capture)
+	}
+}
+
+// ServeSpec returns the OpenAPI document the validator enforces
+func (v *Validator) ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml", spec)
+}
+
+// checkRequest validates the body and restores it for the handler. It
+// reports whether the request may continue.
+func (v *Validator) checkRequest(c *gin.Context, op operation) bool {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to read request body",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if !op.requestRequired {
+			return true
+		}
+		return v.reject(c, "malformed_json", "request body is empty", nil)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return v.reject(c, "malformed_json", err.Error(), nil)
+	}
+
+	errs := jsonschema.Validate(op.request, value)
+	if len(errs) == 0 {
+		return true
+	}
+	if len(errs) > maxFieldErrors {
+		errs = errs[:maxFieldErrors]
+	}
+	fields := make([]FieldError, len(errs))
+	details := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = FieldError{Field: fieldName(e.Path), Message: e.Message}
+		details[i] = fields[i].Field + ": " + e.Message
+	}
+	return v.reject(c, "schema_validation_failed", strings.Join(details, "; "), fields)
+}
+
+// reject answers 400 in enforce mode; in report mode it logs the problem and
+// lets the request through
+func (v *Validator) reject(c *gin.Context, code, details string, fields []FieldError) bool {
+	if !v.enforce {
+		log.Printf("[SCHEMA] %s %s would be rejected (%s): %s", c.Request.Method, c.FullPath(), code, details)
+		return true
+	}
+
+	resp := gin.H{
+		"error":   "Invalid request format",
+		"code":    code,
+		"details": details,
+	}
+	if len(fields) > 0 {
+		resp["fields"] = fields
+	}
+	c.JSON(http.StatusBadRequest, resp)
+	c.Abort()
+	return false
+}
+
+// checkResponse logs a JSON response that does not match the spec for its
+// status. Responses are never changed: a mismatch is a bug in the spec or the
+// handler, not in the caller's request.
+func (v *Validator) checkResponse(c *gin.Context, op operation, capture *capturingWriter) {
+	schema, ok := op.responses[c.Writer.Status()]
+	if !ok || capture.truncated || capture.body.Len() == 0 {
+		return
+	}
+	if !strings.HasPrefix(c.Writer.Header().Get("Content-Type"), "application/json") {
+		return // Event streams and other formats are not described
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(capture.body.Bytes(), &value); err != nil {
+		log.Printf("[SCHEMA] %s %s returned invalid JSON: %v", c.Request.Method, c.FullPath(), err)
+		return
+	}
+	if errs := jsonschema.Validate(schema, value); len(errs) > 0 {
+		log.Printf("[SCHEMA] %s %s response %d does not match the spec: %v", c.Request.Method, c.FullPath(), c.Writer.Status(), errs[0])
+	}
+}
+
+// fieldName turns a schema path such as "$.rag.top_k" into "rag.top_k"
+func fieldName(path string) string {
+	field := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if field == "" {
+		return "body"
+	}
+	return field
+}
+
+// capturingWriter copies what the handler writes, up to maxResponseBytes,
+// while passing it through to the client
+type capturingWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *capturingWriter) capture(data []byte) {
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(data) > maxResponseBytes {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,154 @@
+package apischema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spec is the OpenAPI document the request schemas are generated from
+//
+//go:embed openapi.yaml
+var spec []byte
+
+// refPrefix is the only $ref form the spec uses
+const refPrefix = "#/components/schemas/"
+
+// maxRefDepth stops resolution of a schema that refers back to itself
+const maxRefDepth = 32
+
+// operation is the JSON Schema for one method and route
+type operation struct {
+	request         interface{}
+	requestRequired bool
+	responses       map[int]interface{} // By status code
+}
+
+// loadOperations generates a JSON Schema per operation, keyed by method and
+// gin route pattern, e.g. "POST /api/v2/recommend/smart"
+func loadOperations(raw []byte) (map[string]operation, error) {
+	var parsed interface{}
+	if err := yaml.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	// Round-trip through JSON so numbers and maps have the types
+	// encoding/json decodes request bodies into
+	encoded, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert OpenAPI spec: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return nil, fmt.Errorf("failed to convert OpenAPI spec: %w", err)
+	}
+
+	components, _ := doc["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	resolve := func(node interface{}) (interface{}, error) {
+		return resolveRefs(node, schemas, 0)
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	operations := make(map[string]operation)
+	for path, item := range paths {
+		methods, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for method, op := range methods {
+			details, ok := op.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			key := strings.ToUpper(method) + " " + ginPath(path)
+
+			var compiled operation
+			if body, ok := details["requestBody"].(map[string]interface{}); ok {
+				if schema := jsonSchema(body); schema != nil {
+					if compiled.request, err = resolve(schema); err != nil {
+						return nil, fmt.Errorf("%s request: %w", key, err)
+					}
+					compiled.requestRequired, _ = body["required"].(bool)
+				}
+			}
+			if responses, ok := details["responses"].(map[string]interface{}); ok {
+				compiled.responses = make(map[int]interface{})
+				for status, response := range responses {
+					var code int
+					if _, err := fmt.Sscanf(status, "%d", &code); err != nil {
+						continue // "default" and ranges such as "4XX" are not checked
+					}
+					r, _ := response.(map[string]interface{})
+					if schema := jsonSchema(r); schema != nil {
+						if compiled.responses[code], err = resolve(schema); err != nil {
+							return nil, fmt.Errorf("%s response %d: %w", key, code, err)
+						}
+					}
+				}
+			}
+			operations[key] = compiled
+		}
+	}
+	return operations, nil
+}
+
+// jsonSchema returns the application/json schema of a request body or
+// response, if it has one
+func jsonSchema(body map[string]interface{}) interface{} {
+	content, _ := body["content"].(map[string]interface{})
+	media, _ := content["application/json"].(map[string]interface{})
+	return media["schema"]
+}
+
+// resolveRefs replaces every $ref with a copy of the component it names
+func resolveRefs(node interface{}, schemas map[string]interface{}, depth int) (interface{}, error) {
+	if depth > maxRefDepth {
+		return nil, fmt.Errorf("$ref nesting deeper than %d", maxRefDepth)
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			name := strings.TrimPrefix(ref, refPrefix)
+			target, found := schemas[name]
+			if name == ref || !found {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+			return resolveRefs(target, schemas, depth+1)
+		}
+		resolved := make(map[string]interface{}, len(n))
+		for key, value := range n {
+			r, err := resolveRefs(value, schemas, depth)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(n))
+		for i, value := range n {
+			r, err := resolveRefs(value, schemas, depth)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	}
+	return node, nil
+}
+
+// ginPath converts OpenAPI path parameters to gin's, "/models/{id}" to
+// "/models/:id"
+func ginPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			segments[i] = ":" + s[1:len(s)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
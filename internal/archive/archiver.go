@@ -0,0 +1,322 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// batchSize bounds the rows moved per statement, keeping transactions and
+// export files small
+const batchSize = 5000
+
+// TableReport is the outcome of archiving one table under one policy
+type TableReport struct {
+	Table   string   `json:"table"`
+	UserID  string   `json:"user_id,omitempty"`
+	Action  string   `json:"action"`
+	Rows    int64    `json:"rows"`
+	Objects []string `json:"objects,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Run is one archival pass over every policy
+type Run struct {
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	Trigger    string        `json:"trigger"` // "schedule" or "manual"
+	Tables     []TableReport `json:"tables"`
+}
+
+// Archiver applies retention policies to the growing tables
+type Archiver struct {
+	db       *sql.DB
+	policies *PolicyStore
+	store    ObjectStore
+	alerts   *alerts.Manager
+
+	running sync.Mutex
+
+	mu      sync.RWMutex
+	lastRun *Run
+}
+
+func NewArchiver(db *sql.DB, policies *PolicyStore, store ObjectStore) *Archiver {
+	return &Archiver{db: db, policies: policies, store: store}
+}
+
+// SetAlerts reports failed archival passes to operators
+func (a *Archiver) SetAlerts(manager *alerts.Manager) {
+	a.alerts = manager
+}
+
+// Exportable reports whether an object store is configured for exports
+func (a *Archiver) Exportable() bool {
+	return a.store != nil
+}
+
+// Location describes the export destination, if any
+func (a *Archiver) Location() string {
+	if a.store == nil {
+		return ""
+	}
+	return a.store.Location()
+}
+
+// LastRun returns the most recent archival pass
+func (a *Archiver) LastRun() *Run {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.lastRun
+}
+
+// Start runs an archival pass on the given interval until ctx is cancelled
+func (a *Archiver) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.Run(ctx, "schedule")
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Trigger starts a pass in the background unless one is already running
+func (a *Archiver) Trigger() bool {
+	if !a.running.TryLock() {
+		return false
+	}
+	a.running.Unlock()
+	go a.Run(context.Background(), "manual")
+	return true
+}
+
+// Run applies every policy once. Tenant overrides are applied first, and the
+// table's default covers every tenant without an override.
+func (a *Archiver) Run(ctx context.Context, trigger string) *Run {
+	if !a.running.TryLock() {
+		return nil
+	}
+	defer a.running.Unlock()
+
+	run := &Run{StartedAt: time.Now(), Trigger: trigger, Tables: []TableReport{}}
+	a.publish(run)
+
+	policies, err := a.policies.List(ctx)
+	if err != nil {
+		log.Printf("[ARCHIVE] %v", err)
+		a.alert("Retention policies could not be loaded", err)
+		run.Tables = append(run.Tables, TableReport{Error: err.Error()})
+		a.finish(run)
+		return run
+	}
+
+	overrides := make(map[string][]string)
+	for _, p := range policies {
+		if p.UserID != "" {
+			overrides[p.Table] = append(overrides[p.Table], p.UserID)
+		}
+	}
+
+	for _, p := range policies {
+		report := a.apply(ctx, p, overrides[p.Table])
+		if report.Error != "" {
+			log.Printf("[ARCHIVE] %s (%s) failed after %d rows: %s", p.Table, p.Action, report.Rows, report.Error)
+			a.alert("Archival pass failed for "+p.Table, fmt.Errorf("%s", report.Error))
+		} else if report.Rows > 0 {
+			log.Printf("[ARCHIVE] %s: %s %d rows", p.Table, p.Action, report.Rows)
+		}
+		run.Tables = append(run.Tables, report)
+		a.publish(run)
+	}
+
+	a.finish(run)
+	return run
+}
+
+func (a *Archiver) publish(run *Run) {
+	snapshot := *run
+	snapshot.Tables = append([]TableReport(nil), run.Tables...)
+	a.mu.Lock()
+	a.lastRun = &snapshot
+	a.mu.Unlock()
+}
+
+func (a *Archiver) finish(run *Run) {
+	now := time.Now()
+	run.FinishedAt = &now
+	a.publish(run)
+}
+
+func (a *Archiver) alert(title string, err error) {
+	a.alerts.Notify(alerts.Event{
+		Type:     alerts.EventJobFailed,
+		Severity: alerts.SeverityCritical,
+		Source:   "archive",
+		Title:    title,
+		Message:  err.Error(),
+	})
+}
+
+// apply moves a policy's expired rows batch by batch until none are left
+func (a *Archiver) apply(ctx context.Context, p Policy, overridden []string) TableReport {
+	report := TableReport{Table: p.Table, UserID: p.UserID, Action: p.Action}
+	table := tables[p.Table]
+	cutoff := time.Now().AddDate(0, 0, -p.RetainDays)
+
+	// Rows covered by this policy: the tenant's, or every tenant without an override
+	where := fmt.Sprintf("%s < $1", table.TimeColumn)
+	args := []interface{}{cutoff}
+	if p.UserID != "" {
+		where += fmt.Sprintf(" AND %s = $2", table.TenantColumn)
+		args = append(args, p.UserID)
+	} else if len(overridden) > 0 {
+		where += fmt.Sprintf(" AND (%s IS NULL OR NOT (%s = ANY($2::uuid[])))", table.TenantColumn, table.TenantColumn)
+		args = append(args, pq.Array(overridden))
+	}
+	batch := fmt.Sprintf("SELECT id FROM %s WHERE %s ORDER BY %s LIMIT %d", table.Name, where, table.TimeColumn, batchSize)
+
+	for ctx.Err() == nil {
+		var n int64
+		var err error
+		switch p.Action {
+		case ActionDelete:
+			n, err = a.delete(ctx, table, batch, args)
+		case ActionSummarize:
+			n, err = a.summarizeUsage(ctx, batch, args)
+		case ActionExport:
+			var key string
+			n, key, err = a.export(ctx, table, p, batch, args)
+			if key != "" {
+				report.Objects = append(report.Objects, key)
+			}
+		}
+		report.Rows += n
+		if err != nil {
+			report.Error = err.Error()
+			return report
+		}
+		if n < batchSize {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+func (a *Archiver) delete(ctx context.Context, table Table, batch string, args []interface{}) (int64, error) {
+	result, err := a.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", table.Name, batch), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired rows: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// summarizeUsage folds a batch of usage rows into daily aggregates and deletes
+// them in one statement, so rows are never counted twice or lost
+func (a *Archiver) summarizeUsage(ctx context.Context, batch string, args []interface{}) (int64, error) {
+	var n int64
+	err := a.db.QueryRowContext(ctx, fmt.Sprintf(`
+		WITH moved AS (
+			DELETE FROM api_usage WHERE id IN (%s)
+			RETURNING user_id, date_bucket, endpoint, prompt_category, recommended_model,
+				tokens_estimated, cost_usd, response_time_ms, status_code
+		), summarized AS (
+			INSERT INTO api_usage_archive (user_id, day, endpoint, prompt_category, recommended_model,
+				requests, errors, tokens, cost_usd, total_response_time_ms)
+			SELECT user_id, date_bucket, endpoint, COALESCE(prompt_category, ''), COALESCE(recommended_model, ''),
+				COUNT(*), COUNT(*) FILTER (WHERE status_code >= 400),
+				COALESCE(SUM(tokens_estimated), 0), COALESCE(SUM(cost_usd), 0), COALESCE(SUM(response_time_ms), 0)
+			FROM moved
+			GROUP BY 1, 2, 3, 4, 5
+			ON CONFLICT (user_id, day, endpoint, prompt_category, recommended_model) DO UPDATE SET
+				requests = api_usage_archive.requests + EXCLUDED.requests,
+				errors = api_usage_archive.errors + EXCLUDED.errors,
+				tokens = api_usage_archive.tokens + EXCLUDED.tokens,
+				cost_usd = api_usage_archive.cost_usd + EXCLUDED.cost_usd,
+				total_response_time_ms = api_usage_archive.total_response_time_ms + EXCLUDED.total_response_time_ms
+			RETURNING 1
+		)
+		SELECT COUNT(*) FROM moved`, batch), args...).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to summarize usage rows: %w", err)
+	}
+	return n, nil
+}
+
+// export writes a batch as gzipped JSON lines, then deletes exactly the rows
+// written. A failed upload leaves the rows in place for the next pass.
+func (a *Archiver) export(ctx context.Context, table Table, p Policy, batch string, args []interface{}) (int64, string, error) {
+	if a.store == nil {
+		return 0, "", fmt.Errorf("no object store configured for export")
+	}
+
+	rows, err := a.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT t.id::text, row_to_json(t) FROM %s t WHERE t.id IN (%s)`, table.Name, batch), args...)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read expired rows: %w", err)
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	var ids []string
+	for rows.Next() {
+		var id string
+		var row json.RawMessage
+		if err := rows.Scan(&id, &row); err != nil {
+			return 0, "", fmt.Errorf("failed to scan expired row: %w", err)
+		}
+		gz.Write(row)
+		gz.Write([]byte("\n"))
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("failed to read expired rows: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, "", nil
+	}
+	if err := gz.Close(); err != nil {
+		return 0, "", fmt.Errorf("failed to compress export: %w", err)
+	}
+
+	scope := "all"
+	if p.UserID != "" {
+		scope = p.UserID
+	}
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s/%s/%s/%s.jsonl.gz", table.Name, scope, now.Format("2006/01/02"), now.Format("150405.000000"))
+	if err := a.store.Put(ctx, key, buf.Bytes()); err != nil {
+		return 0, "", err
+	}
+
+	result, err := a.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE id = ANY($1::uuid[])", table.Name), pq.Array(ids))
+	if err != nil {
+		return 0, key, fmt.Errorf("exported to %s but failed to delete rows: %w", key, err)
+	}
+	n, _ := result.RowsAffected()
+	return n, key, nil
+}
@@ -0,0 +1,120 @@
+package archive
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes retention policies and archival runs to admins
+type Handlers struct {
+	policies *PolicyStore
+	archiver *Archiver
+}
+
+type PutPolicyRequest struct {
+	Table      string `json:"table" binding:"required"`
+	UserID     string `json:"user_id"` // Empty sets the table's default
+	RetainDays int    `json:"retain_days" binding:"required"`
+	Action     string `json:"action" binding:"required"`
+}
+
+func NewHandlers(policies *PolicyStore, archiver *Archiver) *Handlers {
+	return &Handlers{policies: policies, archiver: archiver}
+}
+
+// List returns the policies, archivable tables and the last run
+func (h *Handlers) List(c *gin.Context) {
+	policies, err := h.policies.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list retention policies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"policies":     policies,
+			"tables":       Tables(),
+			"export_store": h.archiver.Location(),
+			"last_run":     h.archiver.LastRun(),
+		},
+	})
+}
+
+// PutPolicy creates or replaces the policy for a table and tenant
+func (h *Handlers) PutPolicy(c *gin.Context) {
+	var req PutPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	policy := Policy{Table: req.Table, UserID: req.UserID, RetainDays: req.RetainDays, Action: req.Action}
+	if err := policy.Validate(h.archiver.Exportable()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid retention policy",
+			"details":       err.Error(),
+			"valid_actions": []string{ActionSummarize, ActionExport, ActionDelete},
+		})
+		return
+	}
+
+	policy, err := h.policies.Put(c.Request.Context(), policy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to store retention policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// DeletePolicy removes a policy; its rows are kept until another policy covers them
+func (h *Handlers) DeletePolicy(c *gin.Context) {
+	deleted, err := h.policies.Delete(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete retention policy",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Retention policy not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Run starts an archival pass now
+func (h *Handlers) Run(c *gin.Context) {
+	if !h.archiver.Trigger() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "An archival pass is already running",
+			"last_run": h.archiver.LastRun(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Archival pass started",
+	})
+}
@@ -0,0 +1,309 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// ObjectStore receives exported archive files
+type ObjectStore interface {
+	// Put writes body under key, replacing any existing object
+	Put(ctx context.Context, key string, body []byte) error
+	// Location describes where objects are written, for reports
+	Location() string
+}
+
+// NewObjectStoreFromEnv opens the store named by ARCHIVE_STORE:
+// file:///dir, gs://bucket/prefix or s3://bucket/prefix. It returns nil
+// when ARCHIVE_STORE is unset.
+func NewObjectStoreFromEnv() (ObjectStore, error) {
+	raw := os.Getenv("ARCHIVE_STORE")
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARCHIVE_STORE: %w", err)
+	}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "file":
+		return &fileStore{dir: u.Path}, nil
+	case "gs":
+		return &gcsStore{bucket: u.Host, prefix: prefix, client: transport.Client("archive")}, nil
+	case "s3":
+		store, err := NewS3Store(S3Config{
+			Bucket:          u.Host,
+			Prefix:          prefix,
+			Region:          os.Getenv("AWS_REGION"),
+			Endpoint:        os.Getenv("ARCHIVE_S3_ENDPOINT"),
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, "archive")
+		if err != nil {
+			return nil, fmt.Errorf("s3 archive store: %w", err)
+		}
+		return store, nil
+	}
+	return nil, fmt.Errorf("unsupported ARCHIVE_STORE scheme %q", u.Scheme)
+}
+
+// fileStore writes objects under a local (or mounted bucket) directory
+type fileStore struct {
+	dir string
+}
+
+func (s *fileStore) Put(ctx context.Context, key string, body []byte) error {
+	target := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.WriteFile(target, body, 0o640); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	return nil
+}
+
+func (s *fileStore) Location() string {
+	return "file://" + s.dir
+}
+
+// gcsStore uploads to Google Cloud Storage with the instance service account
+type gcsStore struct {
+	bucket string
+	prefix string
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+const gcsTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+func (s *gcsStore) Put(ctx context.Context, key string, body []byte) error {
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		url.PathEscape(s.bucket), url.QueryEscape(path.Join(s.prefix, key)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/gzip")
+
+	return expectOK(s.client.Do(req))
+}
+
+// accessToken fetches and caches a token from the metadata server
+func (s *gcsStore) accessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("ARCHIVE_GCS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcsTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch gcs access token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch gcs access token: status %d", resp.StatusCode)
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to decode gcs access token: %w", err)
+	}
+	s.token = tok.AccessToken
+	// Refresh a minute early so uploads never race expiry
+	s.expires = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+func (s *gcsStore) Location() string {
+	return "gs://" + path.Join(s.bucket, s.prefix)
+}
+
+// S3Config locates an S3 (or S3-compatible) bucket and the keys that write to it
+type S3Config struct {
+	Bucket          string
+	Prefix          string
+	Region          string // us-east-1 when empty
+	Endpoint        string // The region's AWS endpoint when empty
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	ContentType     string // Of every object written; application/gzip when empty
+}
+
+// NewS3Store opens an S3 store whose uploads go through the named shared
+// HTTP client
+func NewS3Store(cfg S3Config, client string) (ObjectStore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("an access key ID and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	if cfg.ContentType == "" {
+		cfg.ContentType = "application/gzip"
+	}
+	return &s3Store{
+		bucket:       cfg.Bucket,
+		prefix:       strings.Trim(cfg.Prefix, "/"),
+		endpoint:     strings.TrimRight(cfg.Endpoint, "/"),
+		region:       cfg.Region,
+		accessKey:    cfg.AccessKeyID,
+		secretKey:    cfg.SecretAccessKey,
+		sessionToken: cfg.SessionToken,
+		contentType:  cfg.ContentType,
+		client:       transport.Client(client),
+	}, nil
+}
+
+// s3Store uploads to S3 or an S3-compatible endpoint with SigV4 signing
+type s3Store struct {
+	bucket       string
+	prefix       string
+	endpoint     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	contentType  string
+	client       *http.Client
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, body []byte) error {
+	objectPath := "/" + s.bucket + "/" + escapeS3Path(path.Join(s.prefix, key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.endpoint+objectPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", s.contentType)
+	s.sign(req, body, time.Now().UTC())
+
+	return expectOK(s.client.Do(req))
+}
+
+// sign adds an AWS Signature Version 4 Authorization header
+func (s *s3Store) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	signed := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if s.sessionToken != "" {
+		signed = append(signed, "x-amz-security-token")
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range signed {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, req.URL.EscapedPath(), req.URL.RawQuery,
+		canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func (s *s3Store) Location() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+// escapeS3Path escapes each key segment as SigV4 expects
+func escapeS3Path(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func expectOK(resp *http.Response, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to upload archive: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
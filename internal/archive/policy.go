@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Archival actions for rows past their retention
+const (
+	ActionSummarize = "summarize" // roll rows into daily aggregates, then delete them
+	ActionExport    = "export"    // write rows to object storage, then delete them
+	ActionDelete    = "delete"    // delete rows outright
+)
+
+// maxRetainDays caps configured retention
+const maxRetainDays = 3650
+
+// Table describes a table the archiver can prune
+type Table struct {
+	Name         string `json:"name"`
+	TimeColumn   string `json:"time_column"`
+	TenantColumn string `json:"tenant_column,omitempty"` // Empty for tables without per-tenant rows
+	Summarizable bool   `json:"summarizable"`
+}
+
+// tables are the growing tables retention policies may target. Names and
+// columns are interpolated into SQL, so only these are accepted.
+var tables = map[string]Table{
+	"api_usage":       {Name: "api_usage", TimeColumn: "timestamp", TenantColumn: "user_id", Summarizable: true},
+	"security_events": {Name: "security_events", TimeColumn: "created_at", TenantColumn: "user_id"},
+	"ingest_failures": {Name: "ingest_failures", TimeColumn: "created_at"},
+	"stats_snapshots": {Name: "stats_snapshots", TimeColumn: "captured_at"},
+}
+
+// Tables returns the archivable tables sorted by name
+func Tables() []Table {
+	list := make([]Table, 0, len(tables))
+	for _, t := range tables {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Policy keeps a table's rows for RetainDays, then applies Action. A policy
+// with a UserID overrides the table's default for that tenant.
+type Policy struct {
+	ID         string    `json:"id"`
+	Table      string    `json:"table"`
+	UserID     string    `json:"user_id,omitempty"`
+	RetainDays int       `json:"retain_days"`
+	Action     string    `json:"action"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Validate checks the policy against its table and the configured object store
+func (p Policy) Validate(exportable bool) error {
+	table, ok := tables[p.Table]
+	if !ok {
+		return fmt.Errorf("table %s is not archivable", p.Table)
+	}
+	if p.RetainDays <= 0 || p.RetainDays > maxRetainDays {
+		return fmt.Errorf("retain_days must be between 1 and %d", maxRetainDays)
+	}
+	if p.UserID != "" && table.TenantColumn == "" {
+		return fmt.Errorf("table %s has no per-tenant rows", p.Table)
+	}
+	switch p.Action {
+	case ActionDelete:
+	case ActionSummarize:
+		if !table.Summarizable {
+			return fmt.Errorf("table %s cannot be summarized", p.Table)
+		}
+	case ActionExport:
+		if !exportable {
+			return fmt.Errorf("export requires ARCHIVE_STORE to be configured")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", p.Action)
+	}
+	return nil
+}
+
+// PolicyStore persists retention policies
+type PolicyStore struct {
+	db *sql.DB
+}
+
+func NewPolicyStore(db *sql.DB) *PolicyStore {
+	return &PolicyStore{db: db}
+}
+
+// List returns every policy, defaults before tenant overrides
+func (s *PolicyStore) List(ctx context.Context) ([]Policy, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, table_name, COALESCE(user_id::text, ''), retain_days, action, updated_at
+		FROM retention_policies
+		ORDER BY table_name, user_id NULLS FIRST`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []Policy{}
+	for rows.Next() {
+		var p Policy
+		if err := rows.Scan(&p.ID, &p.Table, &p.UserID, &p.RetainDays, &p.Action, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan retention policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// Put creates or replaces the policy for its table and tenant
+func (s *PolicyStore) Put(ctx context.Context, p Policy) (Policy, error) {
+	var userID interface{}
+	if p.UserID != "" {
+		userID = p.UserID
+	}
+
+	// Defaults and overrides have separate unique indexes, so upsert by hand
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE retention_policies SET retain_days = $3, action = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE table_name = $1 AND user_id IS NOT DISTINCT FROM $2
+		RETURNING id, updated_at`,
+		p.Table, userID, p.RetainDays, p.Action,
+	).Scan(&p.ID, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		err = s.db.QueryRowContext(ctx, `
+			INSERT INTO retention_policies (table_name, user_id, retain_days, action)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, updated_at`,
+			p.Table, userID, p.RetainDays, p.Action,
+		).Scan(&p.ID, &p.UpdatedAt)
+	}
+	if err != nil {
+		return p, fmt.Errorf("failed to store retention policy: %w", err)
+	}
+	return p, nil
+}
+
+// Delete removes a policy, reporting whether it existed
+func (s *PolicyStore) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM retention_policies WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
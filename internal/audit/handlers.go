@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers lets admins search the audit log, for example for every event of
+// a request a user reported by its request ID
+type Handlers struct {
+	logger *Logger
+}
+
+func NewHandlers(logger *Logger) *Handlers {
+	return &Handlers{logger: logger}
+}
+
+// List returns audit entries filtered by user_id, event prefix, request_id and
+// comma-separated actions, newest first
+func (h *Handlers) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	f := Filter{
+		UserID:      c.Query("user_id"),
+		EventPrefix: c.Query("event"),
+		RequestID:   c.Query("request_id"),
+		Limit:       limit,
+		Offset:      offset,
+	}
+	if actions := c.Query("actions"); actions != "" {
+		f.Actions = strings.Split(actions, ",")
+	}
+
+	entries, err := h.logger.List(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load audit log",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
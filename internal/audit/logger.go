@@ -0,0 +1,361 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// Entry is a single audit log record
+type Entry struct {
+	ID        string                 `json:"id"`
+	UserID    string                 `json:"user_id,omitempty"`
+	EventType string                 `json:"event_type"` // e.g. "safety.block", "safety.flag"
+	Action    string                 `json:"action"`
+	Resource  string                 `json:"resource,omitempty"`
+	Prompt    string                 `json:"prompt,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"` // Taken from the context when empty
+	CreatedAt time.Time              `json:"created_at"`
+
+	// PromptDetails holds detail fields derived from prompt text (e.g. matched
+	// fragments). They follow the prompt logging policy and retention, not Details'.
+	PromptDetails map[string]interface{} `json:"-"`
+}
+
+// Filter narrows audit log queries
+type Filter struct {
+	UserID      string
+	EventPrefix string
+	RequestID   string
+	Actions     []string
+	Limit       int
+	Offset      int
+}
+
+// PromptScrubber applies a tenant's prompt logging policy before storage.
+// verbatim is false when the returned value is not the original prompt.
+type PromptScrubber interface {
+	ScrubPrompt(ctx context.Context, userID, prompt string) (stored string, verbatim bool)
+}
+
+// FieldEncryptor seals stored prompt fields under the tenant's data key.
+// Decrypt returns values stored before encryption was enabled unchanged.
+type FieldEncryptor interface {
+	Encrypt(ctx context.Context, tenant, field, plaintext string) (string, error)
+	Decrypt(ctx context.Context, tenant, field, stored string) (string, error)
+}
+
+const (
+	promptField        = "audit_log.prompt"
+	promptDetailsField = "audit_log.prompt_details"
+)
+
+// Logger writes and reads the append-only audit log
+type Logger struct {
+	db        *sql.DB
+	scrubber  PromptScrubber
+	encryptor FieldEncryptor
+}
+
+func NewLogger(db *sql.DB) *Logger {
+	return &Logger{db: db}
+}
+
+// SetPromptScrubber enforces prompt logging policies on recorded entries
+func (l *Logger) SetPromptScrubber(scrubber PromptScrubber) {
+	l.scrubber = scrubber
+}
+
+// SetEncryptor encrypts prompts and prompt-derived details at rest; reads
+// through the logger decrypt them
+func (l *Logger) SetEncryptor(encryptor FieldEncryptor) {
+	l.encryptor = encryptor
+}
+
+// Record appends an entry to the audit log and returns its ID
+func (l *Logger) Record(ctx context.Context, entry Entry) (string, error) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = trace.ID(ctx)
+	}
+
+	// Prompt-derived details are only kept when the prompt itself is kept verbatim
+	if l.scrubber != nil {
+		var verbatim bool
+		entry.Prompt, verbatim = l.scrubber.ScrubPrompt(ctx, entry.UserID, entry.Prompt)
+		if !verbatim {
+			entry.PromptDetails = nil
+		}
+	}
+
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit details: %w", err)
+	}
+
+	var promptDetails interface{}
+	if len(entry.PromptDetails) > 0 {
+		encoded, err := json.Marshal(entry.PromptDetails)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode audit prompt details: %w", err)
+		}
+		promptDetails = string(encoded)
+	}
+
+	// Nothing prompt-derived is written in plaintext once encryption is on
+	if l.encryptor != nil {
+		if entry.Prompt, err = l.sealPrompt(ctx, entry.UserID, entry.Prompt); err != nil {
+			return "", err
+		}
+		if promptDetails != nil {
+			if promptDetails, err = l.sealPromptDetails(ctx, entry.UserID, promptDetails.(string)); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var userID interface{}
+	if entry.UserID != "" {
+		userID = entry.UserID
+	}
+
+	_, err = l.db.ExecContext(ctx, `
+		INSERT INTO audit_log (id, user_id, event_type, action, resource, prompt, details, prompt_details, request_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NULLIF($9, ''))`,
+		entry.ID, userID, entry.EventType, entry.Action, entry.Resource, entry.Prompt, string(details), promptDetails, entry.RequestID)
+	if err != nil {
+		return "", fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return entry.ID, nil
+}
+
+// List returns audit entries matching the filter, newest first
+func (l *Logger) List(ctx context.Context, f Filter) ([]Entry, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	if f.Actions == nil {
+		f.Actions = []string{}
+	}
+
+	query := `
+		SELECT id, COALESCE(user_id::text, ''), event_type, action, COALESCE(resource, ''),
+		       COALESCE(prompt, ''), COALESCE(details, '{}'::jsonb), prompt_details, COALESCE(request_id, ''), created_at
+		FROM audit_log
+		WHERE ($1 = '' OR user_id::text = $1)
+		  AND ($2 = '' OR event_type LIKE $2 || '%')
+		  AND (cardinality($3::text[]) = 0 OR action = ANY($3::text[]))
+		  AND ($6 = '' OR request_id = $6)
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5`
+
+	rows, err := l.db.QueryContext(ctx, query, f.UserID, f.EventPrefix, pq.Array(f.Actions), f.Limit, f.Offset, f.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		var details, promptDetails []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.EventType, &e.Action, &e.Resource,
+			&e.Prompt, &details, &promptDetails, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		_ = json.Unmarshal(details, &e.Details)
+
+		// Prompt-derived details are merged into Details for readers
+		if e.Prompt, err = l.OpenPrompt(ctx, e.UserID, e.Prompt); err != nil {
+			return nil, err
+		}
+		opened, err := l.openPromptDetails(ctx, e.UserID, promptDetails)
+		if err != nil {
+			return nil, err
+		}
+		if len(opened) > 0 && e.Details == nil {
+			e.Details = make(map[string]interface{}, len(opened))
+		}
+		for k, v := range opened {
+			e.Details[k] = v
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Name identifies the audit log in retention and purge reports
+func (l *Logger) Name() string {
+	return "audit_log"
+}
+
+// ExpirePrompts clears prompt text and prompt-derived details older than each
+// tenant's retention. Audit events themselves are kept.
+func (l *Logger) ExpirePrompts(ctx context.Context, defaultDays int) (int64, error) {
+	res, err := l.db.ExecContext(ctx, `
+		UPDATE audit_log a SET prompt = NULL, prompt_details = NULL
+		WHERE (a.prompt IS NOT NULL OR a.prompt_details IS NOT NULL)
+		  AND a.created_at < CURRENT_TIMESTAMP - make_interval(days => COALESCE(
+		      (SELECT p.retention_days FROM logging_policies p WHERE p.user_id = a.user_id), $1))`,
+		defaultDays)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire audit prompts: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PurgeTenant clears all prompt data recorded for a tenant
+func (l *Logger) PurgeTenant(ctx context.Context, userID string) (int64, error) {
+	res, err := l.db.ExecContext(ctx, `
+		UPDATE audit_log SET prompt = NULL, prompt_details = NULL
+		WHERE user_id = $1 AND (prompt IS NOT NULL OR prompt_details IS NOT NULL)`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge audit prompts: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// OpenPrompt decrypts a prompt read from the audit log for an authorized reader
+func (l *Logger) OpenPrompt(ctx context.Context, userID, stored string) (string, error) {
+	if l.encryptor == nil || stored == "" {
+		return stored, nil
+	}
+	prompt, err := l.encryptor.Decrypt(ctx, userID, promptField, stored)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt audit prompt: %w", err)
+	}
+	return prompt, nil
+}
+
+// ReencryptTenant moves a tenant's stored prompts onto the active data key,
+// encrypting any still in plaintext. An empty userID selects entries without a tenant.
+func (l *Logger) ReencryptTenant(ctx context.Context, userID string) (int64, error) {
+	if l.encryptor == nil {
+		return 0, fmt.Errorf("audit log encryption is not enabled")
+	}
+
+	type stored struct {
+		id            string
+		prompt        string
+		promptDetails []byte
+	}
+	rows, err := l.db.QueryContext(ctx, `
+		SELECT id, COALESCE(prompt, ''), prompt_details
+		FROM audit_log
+		WHERE (user_id::text = $1 OR ($1 = '' AND user_id IS NULL))
+		  AND (prompt IS NOT NULL OR prompt_details IS NOT NULL)`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load audit prompts: %w", err)
+	}
+	var pending []stored
+	for rows.Next() {
+		var s stored
+		if err := rows.Scan(&s.id, &s.prompt, &s.promptDetails); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan audit prompt: %w", err)
+		}
+		pending = append(pending, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to load audit prompts: %w", err)
+	}
+
+	var updated int64
+	for _, s := range pending {
+		prompt, err := l.OpenPrompt(ctx, userID, s.prompt)
+		if err != nil {
+			return updated, err
+		}
+		if prompt, err = l.sealPrompt(ctx, userID, prompt); err != nil {
+			return updated, err
+		}
+
+		var promptDetails interface{}
+		if len(s.promptDetails) > 0 {
+			plaintext, err := l.openPromptDetailsJSON(ctx, userID, s.promptDetails)
+			if err != nil {
+				return updated, err
+			}
+			if promptDetails, err = l.sealPromptDetails(ctx, userID, plaintext); err != nil {
+				return updated, err
+			}
+		}
+
+		if _, err := l.db.ExecContext(ctx, `
+			UPDATE audit_log SET prompt = NULLIF($2, ''), prompt_details = $3 WHERE id = $1`,
+			s.id, prompt, promptDetails); err != nil {
+			return updated, fmt.Errorf("failed to re-encrypt audit prompt: %w", err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+func (l *Logger) sealPrompt(ctx context.Context, userID, prompt string) (string, error) {
+	if prompt == "" {
+		return "", nil
+	}
+	sealed, err := l.encryptor.Encrypt(ctx, userID, promptField, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt audit prompt: %w", err)
+	}
+	return sealed, nil
+}
+
+// sealPromptDetails encrypts the details' JSON and stores the ciphertext as a
+// JSON string, so the column stays valid JSONB
+func (l *Logger) sealPromptDetails(ctx context.Context, userID, plaintext string) (string, error) {
+	sealed, err := l.encryptor.Encrypt(ctx, userID, promptDetailsField, plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt audit prompt details: %w", err)
+	}
+	encoded, err := json.Marshal(sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit prompt details: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// openPromptDetailsJSON returns the details' JSON object, decrypting it when
+// stored as a ciphertext string
+func (l *Logger) openPromptDetailsJSON(ctx context.Context, userID string, stored []byte) (string, error) {
+	var sealed string
+	if json.Unmarshal(stored, &sealed) != nil {
+		return string(stored), nil
+	}
+	if l.encryptor == nil {
+		return "", fmt.Errorf("audit prompt details are encrypted but no encryptor is configured")
+	}
+	plaintext, err := l.encryptor.Decrypt(ctx, userID, promptDetailsField, sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt audit prompt details: %w", err)
+	}
+	return plaintext, nil
+}
+
+func (l *Logger) openPromptDetails(ctx context.Context, userID string, stored []byte) (map[string]interface{}, error) {
+	if len(stored) == 0 {
+		return nil, nil
+	}
+	plaintext, err := l.openPromptDetailsJSON(ctx, userID, stored)
+	if err != nil {
+		return nil, err
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(plaintext), &details); err != nil {
+		return nil, fmt.Errorf("failed to decode audit prompt details: %w", err)
+	}
+	return details, nil
+}
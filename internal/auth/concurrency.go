@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+const (
+	// concurrencyLease is how long a slot survives without a heartbeat, so a
+	// replica that dies mid-request frees its slots
+	concurrencyLease = 60 * time.Second
+	// concurrencyHeartbeat renews the lease of long generations and streams
+	concurrencyHeartbeat = 20 * time.Second
+)
+
+// DefaultConcurrencyLimits are the in-flight request limits per plan, used
+// when plan_limits cannot be read
+var DefaultConcurrencyLimits = map[string]int{
+	"free":       2,
+	"beta":       5,
+	"starter":    10,
+	"pro":        25,
+	"enterprise": 100,
+}
+
+// acquireScript takes a slot when fewer than the limit are held. Slots are
+// sorted set members scored by their lease expiry, so expired ones are dropped
+// before counting.
+var acquireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local held = redis.call('ZCARD', KEYS[1])
+if held >= tonumber(ARGV[2]) then
+	return {0, held}
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return {1, held + 1}
+`)
+
+// ConcurrencyStatus is a caller's in-flight requests against its plan limit
+type ConcurrencyStatus struct {
+	InFlight int `json:"in_flight"`
+	Limit    int `json:"limit"`
+}
+
+// ConcurrencyLimiter caps each API key's in-flight requests with a semaphore
+// in Redis, shared by every router replica. Without Redis it counts in process.
+type ConcurrencyLimiter struct {
+	client *redis.Client
+	limits map[string]int
+
+	mu    sync.Mutex
+	local map[string]int
+}
+
+func NewConcurrencyLimiter(redisAddr string, password string, db int, limits map[string]int) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{limits: limits, local: make(map[string]int)}
+	if redisAddr != "" {
+		l.client = redis.NewClient(&redis.Options{Addr: redisAddr, Password: password, DB: db})
+	}
+	return l
+}
+
+// PlanConcurrencyLimits reads max_concurrent_requests for every plan
+func (s *Service) PlanConcurrencyLimits() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT plan_type, max_concurrent_requests FROM plan_limits`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := make(map[string]int)
+	for rows.Next() {
+		var plan string
+		var limit sql.NullInt64
+		if err := rows.Scan(&plan, &limit); err != nil {
+			return nil, fmt.Errorf("failed to scan plan limits: %w", err)
+		}
+		if limit.Valid {
+			limits[plan] = int(limit.Int64)
+		}
+	}
+	return limits, rows.Err()
+}
+
+// PlanPromptLimits reads max_tokens_per_request, the prompt token cap, for
+// every plan
+func (s *Service) PlanPromptLimits() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT plan_type, max_tokens_per_request FROM plan_limits`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := make(map[string]int)
+	for rows.Next() {
+		var plan string
+		var limit sql.NullInt64
+		if err := rows.Scan(&plan, &limit); err != nil {
+			return nil, fmt.Errorf("failed to scan plan limits: %w", err)
+		}
+		if limit.Valid {
+			limits[plan] = int(limit.Int64)
+		}
+	}
+	return limits, rows.Err()
+}
+
+// Limit returns a plan's in-flight limit; unknown plans get the free limit
+func (l *ConcurrencyLimiter) Limit(plan string) int {
+	if limit, ok := l.limits[plan]; ok {
+		return limit
+	}
+	if limit, ok := l.limits["free"]; ok {
+		return limit
+	}
+	return DefaultConcurrencyLimits["free"]
+}
+
+// Acquire takes a slot for subject, returning a release function, or false
+// with the number of slots held when the subject is at its limit
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, subject string, limit int) (func(), bool, int, error) {
+	if l.client == nil {
+		return l.acquireLocal(subject, limit)
+	}
+
+	key := concurrencyKey(subject)
+	token := uuid.NewString()
+	now := time.Now()
+	res, err := acquireScript.Run(ctx, l.client, []string{key},
+		now.UnixMilli(), limit, now.Add(concurrencyLease).UnixMilli(), token, concurrencyLease.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, false, 0, fmt.Errorf("failed to acquire concurrency slot: %w", err)
+	}
+	if res[0] == 0 {
+		return nil, false, int(res[1]), nil
+	}
+
+	// Renew the lease until the request finishes
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(concurrencyHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				expires := time.Now().Add(concurrencyLease).UnixMilli()
+				pipe := l.client.TxPipeline()
+				pipe.ZAddXX(context.Background(), key, redis.Z{Score: float64(expires), Member: token})
+				pipe.PExpire(context.Background(), key, concurrencyLease)
+				if _, err := pipe.Exec(context.Background()); err != nil {
+					log.Printf("[CONCURRENCY] Failed to renew slot for %s: %v", subject, err)
+				}
+			}
+		}
+	}()
+
+	release := func() {
+		close(done)
+		if err := l.client.ZRem(context.Background(), key, token).Err(); err != nil {
+			log.Printf("[CONCURRENCY] Failed to release slot for %s: %v", subject, err)
+		}
+	}
+	return release, true, int(res[1]), nil
+}
+
+func (l *ConcurrencyLimiter) acquireLocal(subject string, limit int) (func(), bool, int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	held := l.local[subject]
+	if held >= limit {
+		return nil, false, held, nil
+	}
+	l.local[subject] = held + 1
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.local[subject] <= 1 {
+				delete(l.local, subject)
+			} else {
+				l.local[subject]--
+			}
+		})
+	}
+	return release, true, held + 1, nil
+}
+
+// InFlight returns how many slots subject currently holds
+func (l *ConcurrencyLimiter) InFlight(ctx context.Context, subject string) (int, error) {
+	if l.client == nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.local[subject], nil
+	}
+
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	held, err := l.client.ZCount(ctx, concurrencyKey(subject), "("+now, "+inf").Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count concurrency slots: %w", err)
+	}
+	return int(held), nil
+}
+
+// Shared reports whether slots are counted in Redis rather than per replica
+func (l *ConcurrencyLimiter) Shared() bool {
+	return l.client != nil
+}
+
+// Ping checks the Redis connection; without Redis there is nothing to check
+func (l *ConcurrencyLimiter) Ping(ctx context.Context) error {
+	if l.client == nil {
+		return nil
+	}
+	if err := l.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Status returns the caller's in-flight requests and limit
+func (l *ConcurrencyLimiter) Status(c *gin.Context) (ConcurrencyStatus, error) {
+	held, err := l.InFlight(c.Request.Context(), concurrencySubject(c))
+	if err != nil {
+		return ConcurrencyStatus{}, err
+	}
+	return ConcurrencyStatus{InFlight: held, Limit: l.Limit(c.GetString("user_plan"))}, nil
+}
+
+// Middleware holds a slot for the whole of each authenticated request that
+// does work, streams included, and rejects requests over the plan's limit with
+// 429. Reads are not limited, so callers at their limit can still check usage.
+// It must run after the auth middleware so the caller is known. If Redis is
+// unreachable requests are let through rather than failing.
+func (l *ConcurrencyLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if c.GetString("user_id") == "" {
+			c.Next()
+			return
+		}
+
+		subject := concurrencySubject(c)
+		limit := l.Limit(c.GetString("user_plan"))
+		release, ok, held, err := l.Acquire(c.Request.Context(), subject, limit)
+		if err != nil {
+			trace.Logf(c.Request.Context(), "[CONCURRENCY] Not enforcing limit for %s: %v", subject, err)
+			c.Next()
+			return
+		}
+		if !ok {
+			limits.TooManyRequests(c, limits.RetryHint{
+				RetryAfter: time.Second,
+				Window:     limits.WindowConcurrency,
+				Backoff:    limits.BackoffExponential,
+			}, gin.H{
+				"error":   "Too many concurrent requests",
+				"code":    "concurrency_exceeded",
+				"details": fmt.Sprintf("%d requests in flight; your plan allows %d at a time", held, limit),
+			})
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
+
+// concurrencySubject keys slots by API key, or by user for JWT sessions
+func concurrencySubject(c *gin.Context) string {
+	if keyID := c.GetString("api_key_id"); keyID != "" {
+		return "key:" + keyID
+	}
+	return "user:" + c.GetString("user_id")
+}
+
+func concurrencyKey(subject string) string {
+	return "llm-router:concurrency:" + subject
+}
@@ -2,12 +2,14 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
@@ -18,6 +20,23 @@ type Handlers struct {
 	service       *Service
 	jwtManager    *JWTManager
 	githubOAuth   *oauth2.Config
+	adminToken    string
+	concurrency   *ConcurrencyLimiter
+	rateLimiter   *RateLimiter
+	tokenQuota    *TokenQuota
+	twoFactor     TwoFactor
+	sso           SSOPolicy
+}
+
+// TwoFactor checks the second factor of accounts that enabled one
+type TwoFactor interface {
+	Enrolled(ctx context.Context, userID string) (bool, error)
+	Verify(ctx context.Context, userID, code string) error
+}
+
+// SSOPolicy reports organizations that sign in through SSO only
+type SSOPolicy interface {
+	PasswordLoginDisabled(ctx context.Context, organization string) (bool, error)
 }
 
 type RegisterRequest struct {
@@ -27,8 +46,9 @@ type RegisterRequest struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email         string `json:"email" binding:"required,email"`
+	Password      string `json:"password" binding:"required"`
+	TwoFactorCode string `json:"two_factor_code"` // Authenticator or backup code; required once two-factor is enabled
 }
 
 type WaitlistRequest struct {
@@ -56,9 +76,38 @@ func NewHandlers(service *Service, jwtManager *JWTManager) *Handlers {
 		service:     service,
 		jwtManager:  jwtManager,
 		githubOAuth: githubOAuth,
+		adminToken:  os.Getenv("ADMIN_TOKEN"),
 	}
 }
 
+// SetConcurrencyLimiter reports in-flight requests in usage statistics
+func (h *Handlers) SetConcurrencyLimiter(limiter *ConcurrencyLimiter) {
+	h.concurrency = limiter
+}
+
+// SetRateLimiter reports each caller's token bucket in its limits and
+// refreshes cached burst overrides as admins change them
+func (h *Handlers) SetRateLimiter(limiter *RateLimiter) {
+	h.rateLimiter = limiter
+}
+
+// SetTokenQuota reports each caller's generation tokens this month in its
+// limits
+func (h *Handlers) SetTokenQuota(quota *TokenQuota) {
+	h.tokenQuota = quota
+}
+
+// SetTwoFactor requires the second factor at password login for accounts
+// that enabled one
+func (h *Handlers) SetTwoFactor(twoFactor TwoFactor) {
+	h.twoFactor = twoFactor
+}
+
+// SetSSOPolicy refuses password login to organizations that disabled it
+func (h *Handlers) SetSSOPolicy(policy SSOPolicy) {
+	h.sso = policy
+}
+
 // Register handles user registration
 func (h *Handlers) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -171,8 +220,57 @@ func (h *Handlers) Login(c *gin.Context) {
 		return
 	}
 
+	// Organizations may require their users to sign in through SSO
+	if h.sso != nil {
+		ssoOnly, err := h.sso.PasswordLoginDisabled(c.Request.Context(), user.CompanyName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check single sign-on policy",
+			})
+			return
+		}
+		if ssoOnly {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":        "Your organization requires single sign-on",
+				"details":      "Sign in through POST /api/v1/auth/sso/start",
+				"sso_required": true,
+			})
+			return
+		}
+	}
+
+	// Accounts with two-factor authentication confirm the login with a code
+	var twoFactorAt time.Time
+	if h.twoFactor != nil {
+		enrolled, err := h.twoFactor.Enrolled(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check two-factor authentication",
+			})
+			return
+		}
+		if enrolled {
+			if req.TwoFactorCode == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":               "Two-factor code required",
+					"two_factor_required": true,
+				})
+				return
+			}
+			if err := h.twoFactor.Verify(c.Request.Context(), user.ID, req.TwoFactorCode); err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":               "Invalid two-factor code",
+					"details":             err.Error(),
+					"two_factor_required": true,
+				})
+				return
+			}
+			twoFactorAt = time.Now()
+		}
+	}
+
 	// Generate JWT token
-	token, err := h.jwtManager.Generate(user.ID, user.Email, user.PlanType)
+	token, err := h.jwtManager.GenerateTwoFactor(user.ID, user.Email, user.PlanType, twoFactorAt)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -393,11 +491,72 @@ func (h *Handlers) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_plan", claims.Plan)
+		if claims.TwoFactorAt != nil {
+			c.Set("two_factor_at", claims.TwoFactorAt.Time)
+		}
+
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware sets user info in context when a valid JWT is present,
+// but lets anonymous requests through
+func (h *Handlers) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := h.jwtManager.Verify(parts[1]); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_email", claims.Email)
+				c.Set("user_plan", claims.Plan)
+				if claims.TwoFactorAt != nil {
+					c.Set("two_factor_at", claims.TwoFactorAt.Time)
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// AdminMiddleware restricts operator endpoints to callers presenting ADMIN_TOKEN
+// in the X-Admin-Token header
+func (h *Handlers) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.adminToken == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Admin API is not configured",
+			})
+			c.Abort()
+			return
+		}
+
+		token := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Invalid admin token",
+			})
+			c.Abort()
+			return
+		}
+
+		adminID := c.GetHeader("X-Admin-User")
+		if adminID == "" {
+			adminID = "admin"
+		}
+		c.Set("admin_id", adminID)
 
 		c.Next()
 	}
 }
 
+// IsAdmin reports whether the request presents ADMIN_TOKEN, for endpoints
+// that serve both tenants and operators
+func (h *Handlers) IsAdmin(c *gin.Context) bool {
+	token := c.GetHeader("X-Admin-Token")
+	return h.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
 // GetProfile returns the current user's profile
 func (h *Handlers) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -437,6 +596,12 @@ func (h *Handlers) GetUsage(c *gin.Context) {
 		return
 	}
 
+	if h.concurrency != nil {
+		if status, err := h.concurrency.Status(c); err == nil {
+			usage["concurrency"] = status
+		}
+	}
+
 	c.JSON(http.StatusOK, usage)
 }
 
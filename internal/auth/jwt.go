@@ -16,6 +16,8 @@ type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	Plan   string `json:"plan"`
+	// TwoFactorAt is when the holder last passed a two-factor challenge
+	TwoFactorAt *jwt.NumericDate `json:"two_factor_at,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -27,6 +29,12 @@ func NewJWTManager(secretKey string, duration time.Duration) *JWTManager {
 }
 
 func (m *JWTManager) Generate(userID, email, plan string) (string, error) {
+	return m.GenerateTwoFactor(userID, email, plan, time.Time{})
+}
+
+// GenerateTwoFactor issues a token recording when the holder passed a
+// two-factor challenge; a zero time records none
+func (m *JWTManager) GenerateTwoFactor(userID, email, plan string, twoFactorAt time.Time) (string, error) {
 	claims := Claims{
 		UserID: userID,
 		Email:  email,
@@ -37,6 +45,9 @@ func (m *JWTManager) Generate(userID, email, plan string) (string, error) {
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
+	if !twoFactorAt.IsZero() {
+		claims.TwoFactorAt = jwt.NewNumericDate(twoFactorAt)
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(m.secretKey))
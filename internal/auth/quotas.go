@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/limits"
+)
+
+// Quota is a caller's requests in the current hour, day or month, or its
+// generation tokens this month, against its plan limit
+type Quota struct {
+	Window    string    `json:"window"`
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Reserved  int       `json:"reserved,omitempty"` // Tokens held by generations still running
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	ResetInMs int64     `json:"reset_in_ms"`
+}
+
+// Limits is everything a client needs to throttle itself
+type Limits struct {
+	Plan        string             `json:"plan"`
+	Quotas      []Quota            `json:"quotas"`
+	Concurrency *ConcurrencyStatus `json:"concurrency,omitempty"`
+	Burst       *BurstStatus       `json:"burst,omitempty"`
+}
+
+// Quotas returns the user's remaining requests in the current calendar hour,
+// day and month. Hours and days are counted from recorded usage, the month
+// from the monthly summary.
+func (s *Service) Quotas(userID string) (Limits, error) {
+	var l Limits
+	var perHour, perDay, perMonth int
+	err := s.db.QueryRow(`
+		SELECT u.plan_type, pl.requests_per_hour, pl.requests_per_day, pl.requests_per_month
+		FROM users u
+		JOIN plan_limits pl ON u.plan_type = pl.plan_type
+		WHERE u.id = $1
+	`, userID).Scan(&l.Plan, &perHour, &perDay, &perMonth)
+	if err != nil {
+		return l, fmt.Errorf("failed to get plan limits: %w", err)
+	}
+
+	now := time.Now()
+	hour := now.Truncate(time.Hour)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var usedHour, usedDay, usedMonth int
+	err = s.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE timestamp >= $2), COUNT(*)
+		FROM api_usage
+		WHERE user_id = $1 AND timestamp >= $3
+	`, userID, hour, day).Scan(&usedHour, &usedDay)
+	if err != nil {
+		return l, fmt.Errorf("failed to count usage: %w", err)
+	}
+	err = s.db.QueryRow(`
+		SELECT COALESCE(total_requests, 0)
+		FROM monthly_usage_summary
+		WHERE user_id = $1 AND year_month = $2
+	`, userID, now.Format("2006-01")).Scan(&usedMonth)
+	if err != nil && err != sql.ErrNoRows {
+		return l, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	l.Quotas = []Quota{
+		newQuota(limits.WindowHour, perHour, usedHour, hour.Add(time.Hour), now),
+		newQuota(limits.WindowDay, perDay, usedDay, day.AddDate(0, 0, 1), now),
+		newQuota(limits.WindowMonth, perMonth, usedMonth, month.AddDate(0, 1, 0), now),
+	}
+	return l, nil
+}
+
+func newQuota(window string, limit, used int, resetAt, now time.Time) Quota {
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Quota{
+		Window:    window,
+		Limit:     limit,
+		Used:      used,
+		Remaining: remaining,
+		ResetAt:   resetAt,
+		ResetInMs: resetAt.Sub(now).Milliseconds(),
+	}
+}
+
+// GetLimits returns the caller's remaining hourly, daily and monthly quotas,
+// monthly generation tokens and in-flight requests, so SDKs can throttle
+// before they hit a 429
+func (h *Handlers) GetLimits(c *gin.Context) {
+	l, err := h.service.Quotas(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get limits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if h.tokenQuota != nil {
+		if status, err := h.tokenQuota.Status(c.Request.Context(), c.GetString("user_id"), l.Plan); err == nil && status.Limit > 0 {
+			q := newQuota(limits.WindowTokens, int(status.Limit), int(status.Used+status.Reserved), status.ResetAt, time.Now())
+			q.Used, q.Reserved = int(status.Used), int(status.Reserved)
+			l.Quotas = append(l.Quotas, q)
+		}
+	}
+
+	if h.concurrency != nil {
+		if status, err := h.concurrency.Status(c); err == nil {
+			l.Concurrency = &status
+		}
+	}
+	if h.rateLimiter != nil {
+		if status, err := h.rateLimiter.Status(c); err == nil {
+			l.Burst = &status
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    l,
+	})
+}
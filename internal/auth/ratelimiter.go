@@ -1,15 +1,498 @@
 package auth
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
-type RateLimiter struct{}
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 
-func NewRateLimiter(redisAddr string, password string, db int) *RateLimiter {
-	return &RateLimiter{}
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// keyBurstTTL is how long a replica keeps an API key's burst override before
+// reading it again, so admin changes reach every replica within a minute
+const keyBurstTTL = time.Minute
+
+// Reasons a request is refused, as returned by rateLimitScript
+const (
+	rateAllowed = iota
+	rateBurst
+	rateHour
+	rateDay
+)
+
+// PlanRate is a plan's sustained hourly and daily limits and the burst its
+// token bucket absorbs on top of them
+type PlanRate struct {
+	PerHour int `json:"requests_per_hour"`
+	PerDay  int `json:"requests_per_day"`
+	Burst   int `json:"burst"`
+}
+
+// DefaultPlanRates are the request limits per plan, used when plan_limits
+// cannot be read
+var DefaultPlanRates = map[string]PlanRate{
+	"free":       {PerHour: 10, PerDay: 100, Burst: 5},
+	"beta":       {PerHour: 100, PerDay: 1000, Burst: 10},
+	"starter":    {PerHour: 1000, PerDay: 10000, Burst: 30},
+	"pro":        {PerHour: 5000, PerDay: 50000, Burst: 100},
+	"enterprise": {PerHour: 20000, PerDay: 200000, Burst: 300},
+}
+
+// rateLimitScript refills the caller's token bucket at the hourly rate, then
+// takes a token and counts the request in the hour and day unless the bucket
+// is empty or either count is at its limit. Tokens are returned in
+// thousandths, since Redis truncates Lua numbers to integers.
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local rate = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or capacity
+local at = tonumber(bucket[2]) or now
+if now > at then
+	tokens = math.min(capacity, tokens + (now - at) * rate)
+end
+local hour = tonumber(redis.call('GET', KEYS[2]) or '0')
+local day = tonumber(redis.call('GET', KEYS[3]) or '0')
+local denied = 0
+if hour >= tonumber(ARGV[4]) then
+	denied = 2
+elseif day >= tonumber(ARGV[5]) then
+	denied = 3
+elseif tokens < 1 then
+	denied = 1
+else
+	tokens = tokens - 1
+	hour = redis.call('INCR', KEYS[2])
+	redis.call('PEXPIRE', KEYS[2], ARGV[6])
+	day = redis.call('INCR', KEYS[3])
+	redis.call('PEXPIRE', KEYS[3], ARGV[7])
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[8])
+return {denied, math.floor(tokens * 1000), hour, day}
+`)
+
+// RateDecision is the outcome of taking a request from a caller's limits
+type RateDecision struct {
+	Allowed        bool
+	Reason         int
+	Rate           PlanRate
+	BurstRemaining float64
+	HourUsed       int
+	DayUsed        int
+	HourReset      time.Time
+	DayReset       time.Time
+	NextTokenIn    time.Duration
+}
+
+// BurstStatus is a caller's token bucket, reported by GET /v1/limits
+type BurstStatus struct {
+	Size      int     `json:"size"`
+	Remaining int     `json:"remaining"`
+	RefillPer float64 `json:"refill_per_second"`
+}
+
+type localBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+type localCount struct {
+	window time.Time
+	n      int
+}
+
+type keyBurst struct {
+	burst   int // Zero when the key uses its plan's burst
+	expires time.Time
+}
+
+// RateLimiter enforces each plan's hourly and daily request limits and
+// smooths traffic with a token bucket per API key, so a caller can burst up
+// to its plan's rate_limit_burst and then continues at the hourly rate. The
+// bucket and counters live in Redis, shared by every router replica. Without
+// Redis they are kept in process.
+type RateLimiter struct {
+	client  *redis.Client
+	plans   map[string]PlanRate
+	service *Service
+
+	mu      sync.Mutex
+	buckets map[string]*localBucket
+	counts  map[string]*localCount
+	bursts  map[string]keyBurst
+}
+
+func NewRateLimiter(redisAddr string, password string, db int, plans map[string]PlanRate) *RateLimiter {
+	r := &RateLimiter{
+		plans:   plans,
+		buckets: make(map[string]*localBucket),
+		counts:  make(map[string]*localCount),
+		bursts:  make(map[string]keyBurst),
+	}
+	if redisAddr != "" {
+		r.client = redis.NewClient(&redis.Options{Addr: redisAddr, Password: password, DB: db})
+	}
+	return r
+}
+
+// PlanRateLimits reads the hourly, daily and burst limits of every plan
+func (s *Service) PlanRateLimits() (map[string]PlanRate, error) {
+	rows, err := s.db.Query(`SELECT plan_type, requests_per_hour, requests_per_day, rate_limit_burst FROM plan_limits`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan limits: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make(map[string]PlanRate)
+	for rows.Next() {
+		var plan string
+		var rate PlanRate
+		var burst sql.NullInt64
+		if err := rows.Scan(&plan, &rate.PerHour, &rate.PerDay, &burst); err != nil {
+			return nil, fmt.Errorf("failed to scan plan limits: %w", err)
+		}
+		rate.Burst = int(burst.Int64)
+		rates[plan] = rate
+	}
+	return rates, rows.Err()
+}
+
+// KeyBurst returns an API key's burst override, or zero when it uses its
+// plan's
+func (s *Service) KeyBurst(ctx context.Context, keyID string) (int, error) {
+	var burst sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `SELECT rate_limit_burst FROM api_keys WHERE id = $1`, keyID).Scan(&burst)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get key burst: %w", err)
+	}
+	return int(burst.Int64), nil
+}
+
+// SetKeyBurst overrides an API key's burst; zero returns it to the plan's.
+// It reports whether the key exists.
+func (s *Service) SetKeyBurst(ctx context.Context, keyID string, burst int) (bool, error) {
+	var value interface{}
+	if burst > 0 {
+		value = burst
+	}
+	result, err := s.db.ExecContext(ctx, `UPDATE api_keys SET rate_limit_burst = $2 WHERE id = $1`, keyID, value)
+	if err != nil {
+		return false, fmt.Errorf("failed to set key burst: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// SetService reads per-key burst overrides from api_keys
+func (r *RateLimiter) SetService(service *Service) {
+	r.service = service
+}
+
+// Rate returns a plan's limits; unknown plans get the free limits. A plan
+// without a burst can burst to one minute of its hourly rate.
+func (r *RateLimiter) Rate(plan string) PlanRate {
+	rate, ok := r.plans[plan]
+	if !ok {
+		if rate, ok = r.plans["free"]; !ok {
+			rate = DefaultPlanRates["free"]
+		}
+	}
+	if rate.Burst <= 0 {
+		rate.Burst = rate.PerHour / 60
+		if rate.Burst < 1 {
+			rate.Burst = 1
+		}
+	}
+	return rate
+}
+
+// Take counts one request against subject's limits, taking a token from its
+// bucket. userID's hourly and daily counts are shared by all of its keys.
+func (r *RateLimiter) Take(ctx context.Context, subject, userID string, rate PlanRate) (RateDecision, error) {
+	now := time.Now()
+	hour := now.Truncate(time.Hour)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	d := RateDecision{Rate: rate, HourReset: hour.Add(time.Hour), DayReset: day.AddDate(0, 0, 1)}
+	perMs := float64(rate.PerHour) / float64(time.Hour.Milliseconds())
+
+	if r.client == nil {
+		r.takeLocal(&d, subject, userID, now, hour, day, perMs)
+	} else {
+		refill := 24 * time.Hour // Time for an empty bucket to fill, after which it can be dropped
+		if perMs > 0 {
+			refill = time.Duration(float64(rate.Burst)/perMs)*time.Millisecond + time.Second
+		}
+		res, err := rateLimitScript.Run(ctx, r.client,
+			[]string{rateKey("bucket", subject), rateKey(hour.Format("2006010215"), userID), rateKey(day.Format("20060102"), userID)},
+			now.UnixMilli(), rate.Burst, perMs, rate.PerHour, rate.PerDay,
+			time.Until(d.HourReset).Milliseconds()+1000, time.Until(d.DayReset).Milliseconds()+1000, refill.Milliseconds()).Int64Slice()
+		if err != nil {
+			return d, fmt.Errorf("failed to take rate limit token: %w", err)
+		}
+		d.Reason, d.BurstRemaining, d.HourUsed, d.DayUsed = int(res[0]), float64(res[1])/1000, int(res[2]), int(res[3])
+	}
+
+	d.Allowed = d.Reason == rateAllowed
+	if d.BurstRemaining < 1 && perMs > 0 {
+		d.NextTokenIn = time.Duration((1-d.BurstRemaining)/perMs) * time.Millisecond
+	}
+	return d, nil
+}
+
+func (r *RateLimiter) takeLocal(d *RateDecision, subject, userID string, now, hour, day time.Time, perMs float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[subject]
+	if !ok {
+		b = &localBucket{tokens: float64(d.Rate.Burst), at: now}
+		r.buckets[subject] = b
+	}
+	b.tokens = math.Min(float64(d.Rate.Burst), b.tokens+float64(now.Sub(b.at).Milliseconds())*perMs)
+	b.at = now
+
+	hourCount := r.localCount("hour:"+userID, hour)
+	dayCount := r.localCount("day:"+userID, day)
+	switch {
+	case hourCount.n >= d.Rate.PerHour:
+		d.Reason = rateHour
+	case dayCount.n >= d.Rate.PerDay:
+		d.Reason = rateDay
+	case b.tokens < 1:
+		d.Reason = rateBurst
+	default:
+		b.tokens--
+		hourCount.n++
+		dayCount.n++
+	}
+	d.BurstRemaining, d.HourUsed, d.DayUsed = b.tokens, hourCount.n, dayCount.n
+}
+
+// localCount returns the count of the current window, starting it afresh
+// when the window has rolled over. Callers hold the mutex.
+func (r *RateLimiter) localCount(key string, window time.Time) *localCount {
+	count, ok := r.counts[key]
+	if !ok || !count.window.Equal(window) {
+		count = &localCount{window: window}
+		r.counts[key] = count
+	}
+	return count
+}
+
+// burst returns the caller's bucket size: its API key's override, if any,
+// else its plan's
+func (r *RateLimiter) burst(ctx context.Context, keyID string, planBurst int) (int, bool) {
+	if keyID == "" || r.service == nil {
+		return planBurst, false
+	}
+
+	r.mu.Lock()
+	cached, ok := r.bursts[keyID]
+	r.mu.Unlock()
+	if !ok || time.Now().After(cached.expires) {
+		burst, err := r.service.KeyBurst(ctx, keyID)
+		if err != nil {
+			trace.Logf(ctx, "[RATELIMIT] Using plan burst for key %s: %v", keyID, err)
+			return planBurst, false
+		}
+		cached = keyBurst{burst: burst, expires: time.Now().Add(keyBurstTTL)}
+		r.mu.Lock()
+		r.bursts[keyID] = cached
+		r.mu.Unlock()
+	}
+	if cached.burst > 0 {
+		return cached.burst, true
+	}
+	return planBurst, false
+}
+
+// forgetBurst drops a key's cached override after it changes
+func (r *RateLimiter) forgetBurst(keyID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.bursts, keyID)
+}
+
+// Status returns the caller's bucket without taking a token
+func (r *RateLimiter) Status(c *gin.Context) (BurstStatus, error) {
+	rate := r.Rate(c.GetString("user_plan"))
+	rate.Burst, _ = r.burst(c.Request.Context(), c.GetString("api_key_id"), rate.Burst)
+	perMs := float64(rate.PerHour) / float64(time.Hour.Milliseconds())
+	status := BurstStatus{Size: rate.Burst, RefillPer: math.Round(perMs*1000*1e4) / 1e4}
+	subject := concurrencySubject(c)
+
+	var tokens float64
+	var at time.Time
+	if r.client == nil {
+		r.mu.Lock()
+		b, ok := r.buckets[subject]
+		if !ok {
+			r.mu.Unlock()
+			status.Remaining = rate.Burst
+			return status, nil
+		}
+		tokens, at = b.tokens, b.at
+		r.mu.Unlock()
+	} else {
+		fields, err := r.client.HMGet(c.Request.Context(), rateKey("bucket", subject), "tokens", "at").Result()
+		if err != nil {
+			return status, fmt.Errorf("failed to read token bucket: %w", err)
+		}
+		if fields[0] == nil || fields[1] == nil {
+			status.Remaining = rate.Burst
+			return status, nil
+		}
+		tokenField, _ := fields[0].(string)
+		atField, _ := fields[1].(string)
+		tokens, _ = strconv.ParseFloat(tokenField, 64)
+		ms, _ := strconv.ParseInt(atField, 10, 64)
+		at = time.UnixMilli(ms)
+	}
+
+	tokens = math.Min(float64(rate.Burst), tokens+float64(time.Since(at).Milliseconds())*perMs)
+	status.Remaining = int(tokens)
+	return status, nil
 }
 
-func (r *RateLimiter) RateLimitMiddleware(authService *Service) gin.HandlerFunc {
+// Middleware enforces the caller's hourly and daily limits and token
+// bucket on each authenticated request that does work, and reports them in
+// X-RateLimit-* headers. Reads are not limited, so callers at their limit can
+// still check usage. It must run after the auth and signing middleware so the
+// caller and its key are known. If Redis is unreachable requests are let
+// through rather than failing.
+func (r *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		userID := c.GetString("user_id")
+		if userID == "" {
+			c.Next()
+			return
+		}
+
+		subject := concurrencySubject(c)
+		rate := r.Rate(c.GetString("user_plan"))
+		rate.Burst, _ = r.burst(c.Request.Context(), c.GetString("api_key_id"), rate.Burst)
+		d, err := r.Take(c.Request.Context(), subject, userID, rate)
+		if err != nil {
+			trace.Logf(c.Request.Context(), "[RATELIMIT] Not enforcing limits for %s: %v", subject, err)
+			c.Next()
+			return
+		}
+		setRateHeaders(c, d)
+
+		switch d.Reason {
+		case rateHour:
+			limits.TooManyRequests(c, limits.RetryHint{RetryAfter: time.Until(d.HourReset), Window: limits.WindowHour}, gin.H{
+				"error":   "Hourly request limit reached",
+				"code":    "rate_limit_exceeded",
+				"details": fmt.Sprintf("your plan allows %d requests per hour", rate.PerHour),
+			})
+			return
+		case rateDay:
+			limits.TooManyRequests(c, limits.RetryHint{RetryAfter: time.Until(d.DayReset), Window: limits.WindowDay}, gin.H{
+				"error":   "Daily request limit reached",
+				"code":    "rate_limit_exceeded",
+				"details": fmt.Sprintf("your plan allows %d requests per day", rate.PerDay),
+			})
+			return
+		case rateBurst:
+			limits.TooManyRequests(c, limits.RetryHint{RetryAfter: d.NextTokenIn, Window: limits.WindowBurst}, gin.H{
+				"error":   "Request burst limit reached",
+				"code":    "burst_exceeded",
+				"details": fmt.Sprintf("%d requests may be sent at once, then %d per hour", rate.Burst, rate.PerHour),
+			})
+			return
+		}
+
 		c.Next()
 	}
 }
+
+// setRateHeaders reports the hourly limit and the token bucket on the
+// response
+func setRateHeaders(c *gin.Context, d RateDecision) {
+	remaining := d.Rate.PerHour - d.HourUsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(d.Rate.PerHour))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(d.HourReset.Unix(), 10))
+	c.Header("X-RateLimit-Burst", strconv.Itoa(d.Rate.Burst))
+	c.Header("X-RateLimit-Burst-Remaining", strconv.Itoa(int(d.BurstRemaining)))
+}
+
+// Shared reports whether limits are counted in Redis rather than per replica
+func (r *RateLimiter) Shared() bool {
+	return r.client != nil
+}
+
+func rateKey(window, subject string) string {
+	return "llm-router:ratelimit:" + window + ":" + subject
+}
+
+type KeyBurstRequest struct {
+	Burst int `json:"burst" binding:"required,min=1,max=100000"`
+}
+
+// PutKeyBurst overrides an API key's burst size
+func (h *Handlers) PutKeyBurst(c *gin.Context) {
+	var req KeyBurstRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	h.setKeyBurst(c, req.Burst)
+}
+
+// DeleteKeyBurst returns an API key to its plan's burst size
+func (h *Handlers) DeleteKeyBurst(c *gin.Context) {
+	h.setKeyBurst(c, 0)
+}
+
+func (h *Handlers) setKeyBurst(c *gin.Context, burst int) {
+	keyID := c.Param("id")
+	found, err := h.service.SetKeyBurst(c.Request.Context(), keyID, burst)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to set burst",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "API key not found",
+		})
+		return
+	}
+	if h.rateLimiter != nil {
+		h.rateLimiter.forgetBurst(keyID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"api_key_id": keyID,
+			"burst":      burst,
+		},
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,6 +29,7 @@ type User struct {
 	LastLoginAt    *time.Time `json:"last_login_at,omitempty"`
 	GitHubID       *string   `json:"github_id,omitempty"`
 	AvatarURL      *string   `json:"avatar_url,omitempty"`
+	OrgRole        string    `json:"org_role,omitempty"` // Role in the organization, set by SSO
 }
 
 type WaitlistEntry struct {
@@ -128,7 +130,7 @@ func (s *Service) GetUserByID(id string) (*User, error) {
 	query := `
 		SELECT id, email, full_name, company_name, plan_type, status,
 		       beta_access, is_active, created_at, email_verified_at,
-		       last_login_at, github_id, avatar_url
+		       last_login_at, github_id, avatar_url, COALESCE(org_role, '')
 		FROM users
 		WHERE id = $1 AND is_active = TRUE`
 
@@ -136,7 +138,7 @@ func (s *Service) GetUserByID(id string) (*User, error) {
 		&user.ID, &user.Email, &user.FullName, &user.CompanyName,
 		&user.PlanType, &user.Status, &user.BetaAccess, &user.IsActive,
 		&user.CreatedAt, &user.EmailVerifiedAt, &user.LastLoginAt,
-		&user.GitHubID, &user.AvatarURL,
+		&user.GitHubID, &user.AvatarURL, &user.OrgRole,
 	)
 
 	if err == sql.ErrNoRows {
@@ -341,3 +343,110 @@ func (s *Service) CreateOrGetUserByGitHub(githubID, email, fullName, avatarURL s
 
 	return user, nil
 }
+
+// ErrOtherOrganization is returned when an SSO login's email belongs to an
+// account of another organization
+var ErrOtherOrganization = errors.New("account belongs to another organization")
+
+// SSOIdentity is a user as their organization's identity provider asserts them
+type SSOIdentity struct {
+	Issuer       string
+	Subject      string
+	Email        string
+	FullName     string
+	Organization string // users.company_name of provisioned accounts
+	Role         string // Organization role mapped from the provider's claims
+}
+
+// CreateOrGetUserBySSO returns the account linked to the identity, links an
+// existing account of the organization with the same email on first SSO
+// login, or provisions a new one. The organization role is refreshed on
+// every login, so changes at the provider apply at the next sign-in.
+func (s *Service) CreateOrGetUserBySSO(identity SSOIdentity) (*User, error) {
+	user := &User{}
+	var organization sql.NullString
+	var issuer, subject sql.NullString
+	err := s.db.QueryRow(`
+		SELECT id, email, full_name, company_name, plan_type, status, beta_access, is_active, created_at,
+		       oidc_issuer, oidc_subject
+		FROM users
+		WHERE ((oidc_issuer = $1 AND oidc_subject = $2) OR email = $3) AND is_active = TRUE
+		ORDER BY (oidc_issuer = $1 AND oidc_subject = $2) DESC NULLS LAST
+		LIMIT 1`,
+		identity.Issuer, identity.Subject, identity.Email,
+	).Scan(
+		&user.ID, &user.Email, &user.FullName, &organization, &user.PlanType,
+		&user.Status, &user.BetaAccess, &user.IsActive, &user.CreatedAt,
+		&issuer, &subject,
+	)
+
+	if err == nil {
+		linked := issuer.String == identity.Issuer && subject.String == identity.Subject
+		if !linked {
+			// Only accounts of the same organization, or of none, are linked by email
+			if org := strings.ToLower(strings.TrimSpace(organization.String)); org != "" && org != identity.Organization {
+				return nil, ErrOtherOrganization
+			}
+			if issuer.Valid {
+				return nil, fmt.Errorf("account is linked to another identity provider")
+			}
+		}
+		_, err = s.db.Exec(`
+			UPDATE users SET oidc_issuer = $2, oidc_subject = $3, org_role = $4,
+			       company_name = COALESCE(NULLIF(company_name, ''), $5), last_login_at = $6
+			WHERE id = $1`,
+			user.ID, identity.Issuer, identity.Subject, identity.Role, identity.Organization, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to link SSO user: %w", err)
+		}
+		user.CompanyName = organization.String
+		if user.CompanyName == "" {
+			user.CompanyName = identity.Organization
+		}
+		user.OrgRole = identity.Role
+		return user, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check SSO user: %w", err)
+	}
+
+	// Provision the account just in time
+	betaFull, err := s.IsBetaFull()
+	if err != nil {
+		return nil, err
+	}
+
+	planType := "free"
+	betaAccess := false
+	if !betaFull {
+		planType = "beta"
+		betaAccess = true
+	}
+
+	user = &User{
+		ID:          uuid.New().String(),
+		Email:       identity.Email,
+		FullName:    identity.FullName,
+		CompanyName: identity.Organization,
+		OrgRole:     identity.Role,
+		PlanType:    planType,
+		Status:      "active",
+		BetaAccess:  betaAccess,
+		IsActive:    true,
+	}
+
+	err = s.db.QueryRow(`
+		INSERT INTO users (id, email, full_name, company_name, org_role, plan_type, status, beta_access,
+		                   is_active, oidc_issuer, oidc_subject, oauth_provider, password_hash, email_verified_at, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 'oidc', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING created_at`,
+		user.ID, user.Email, user.FullName, user.CompanyName, user.OrgRole, user.PlanType, user.Status,
+		user.BetaAccess, user.IsActive, identity.Issuer, identity.Subject,
+	).Scan(&user.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSO user: %w", err)
+	}
+
+	return user, nil
+}
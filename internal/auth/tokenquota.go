@@ -0,0 +1,335 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Askeban/llm-router-go/internal/limits"
+)
+
+// tokenUsageTTL is how long the month's recorded tokens are cached before
+// monthly_usage_summary is read again, so usage recorded by other endpoints
+// and replicas catches up
+const tokenUsageTTL = time.Minute
+
+// DefaultTokenLimits are the generation tokens per calendar month per plan,
+// used when plan_limits cannot be read
+var DefaultTokenLimits = map[string]int64{
+	"free":       200000,
+	"beta":       2000000,
+	"starter":    20000000,
+	"pro":        100000000,
+	"enterprise": 500000000,
+}
+
+// reserveTokensScript holds tokens when the month's recorded usage plus
+// what running generations hold leaves room for them. Reservations are sorted
+// set members "<token>:<tokens>" scored by their lease expiry, like
+// concurrency slots, so expired ones are dropped before summing.
+var reserveTokensScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local reserved = 0
+for _, member in ipairs(redis.call('ZRANGE', KEYS[2], 0, -1)) do
+	reserved = reserved + tonumber(string.match(member, ':(%d+)$'))
+end
+if used + reserved + tonumber(ARGV[3]) > tonumber(ARGV[2]) then
+	return {0, used, reserved}
+end
+redis.call('ZADD', KEYS[2], ARGV[4], ARGV[5])
+redis.call('PEXPIRE', KEYS[2], ARGV[6])
+return {1, used, reserved}
+`)
+
+// settleTokensScript releases a reservation and counts the tokens actually
+// used. A usage count that has expired is left to be read afresh.
+var settleTokensScript = redis.NewScript(`
+redis.call('ZREM', KEYS[2], ARGV[1])
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('INCRBY', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// TokenStatus is a caller's generation tokens this month against its plan's
+// ceiling
+type TokenStatus struct {
+	Limit    int64 // 0 for no ceiling
+	Used     int64
+	Reserved int64
+	ResetAt  time.Time
+}
+
+type localTokens struct {
+	month   string
+	used    int64
+	fetched time.Time
+	held    map[string]int64
+}
+
+// TokenQuota enforces each plan's monthly generation token ceiling. A
+// generation reserves the most tokens it can use before it starts, which a
+// long stream holds until it ends, and settles with the tokens it used.
+// Reservations live in Redis, shared by every router replica. Without Redis
+// they are kept in process.
+type TokenQuota struct {
+	client  *redis.Client
+	limits  map[string]int64
+	service *Service
+
+	mu    sync.Mutex
+	local map[string]*localTokens
+}
+
+func NewTokenQuota(redisAddr string, password string, db int, limits map[string]int64, service *Service) *TokenQuota {
+	q := &TokenQuota{limits: limits, service: service, local: make(map[string]*localTokens)}
+	if redisAddr != "" {
+		q.client = redis.NewClient(&redis.Options{Addr: redisAddr, Password: password, DB: db})
+	}
+	return q
+}
+
+// PlanTokenLimits reads tokens_per_month for every plan; plans without one
+// map to 0, no ceiling
+func (s *Service) PlanTokenLimits() (map[string]int64, error) {
+	rows, err := s.db.Query(`SELECT plan_type, tokens_per_month FROM plan_limits`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := make(map[string]int64)
+	for rows.Next() {
+		var plan string
+		var limit sql.NullInt64
+		if err := rows.Scan(&plan, &limit); err != nil {
+			return nil, fmt.Errorf("failed to scan plan limits: %w", err)
+		}
+		limits[plan] = limit.Int64
+	}
+	return limits, rows.Err()
+}
+
+// MonthTokens returns the tokens recorded for the user in a month (YYYY-MM)
+func (s *Service) MonthTokens(ctx context.Context, userID, month string) (int64, error) {
+	var tokens int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(total_tokens, 0) FROM monthly_usage_summary
+		WHERE user_id = $1 AND year_month = $2`, userID, month).Scan(&tokens)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get token usage: %w", err)
+	}
+	return tokens, nil
+}
+
+// Limit returns a plan's monthly token ceiling, 0 for none; unknown plans
+// get the free ceiling
+func (q *TokenQuota) Limit(plan string) int64 {
+	if limit, ok := q.limits[plan]; ok {
+		return limit
+	}
+	if limit, ok := q.limits["free"]; ok {
+		return limit
+	}
+	return DefaultTokenLimits["free"]
+}
+
+// Reserve holds tokens for a generation against the user's monthly ceiling,
+// returning a settle function to call with the tokens actually used. Over
+// the ceiling it returns a *limits.TokenQuotaError.
+func (q *TokenQuota) Reserve(ctx context.Context, userID, plan string, tokens int) (func(used int), error) {
+	limit := q.Limit(plan)
+	if limit <= 0 {
+		return func(int) {}, nil
+	}
+	now := time.Now()
+	month := now.Format("2006-01")
+	resetAt := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1, 0)
+	if q.client == nil {
+		return q.reserveLocal(ctx, userID, month, resetAt, limit, int64(tokens))
+	}
+
+	usedKey, heldKey := tokenKeys(userID, month)
+	if err := q.seedUsed(ctx, usedKey, userID, month); err != nil {
+		return nil, err
+	}
+	token := uuid.NewString()
+	member := token + ":" + strconv.Itoa(tokens)
+	res, err := reserveTokensScript.Run(ctx, q.client, []string{usedKey, heldKey},
+		now.UnixMilli(), limit, tokens, now.Add(concurrencyLease).UnixMilli(), member, time.Until(resetAt).Milliseconds()+concurrencyLease.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve tokens: %w", err)
+	}
+	if res[0] == 0 {
+		return nil, &limits.TokenQuotaError{Limit: limit, Used: res[1], Reserved: res[2], Requested: int64(tokens), ResetAt: resetAt}
+	}
+
+	// Renew the lease until the generation settles, however long it streams
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(concurrencyHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				expires := time.Now().Add(concurrencyLease).UnixMilli()
+				if err := q.client.ZAddXX(context.Background(), heldKey, redis.Z{Score: float64(expires), Member: member}).Err(); err != nil {
+					log.Printf("[TOKENS] Failed to renew reservation for %s: %v", userID, err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	settle := func(used int) {
+		once.Do(func() {
+			close(done)
+			if err := settleTokensScript.Run(context.Background(), q.client, []string{usedKey, heldKey}, member, used).Err(); err != nil {
+				log.Printf("[TOKENS] Failed to settle reservation for %s: %v", userID, err)
+			}
+		})
+	}
+	return settle, nil
+}
+
+// seedUsed caches the month's recorded tokens unless a fresh count is cached
+func (q *TokenQuota) seedUsed(ctx context.Context, usedKey, userID, month string) error {
+	n, err := q.client.Exists(ctx, usedKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read token usage: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	used, err := q.service.MonthTokens(ctx, userID, month)
+	if err != nil {
+		return err
+	}
+	if err := q.client.SetNX(ctx, usedKey, used, tokenUsageTTL).Err(); err != nil {
+		return fmt.Errorf("failed to cache token usage: %w", err)
+	}
+	return nil
+}
+
+func (q *TokenQuota) reserveLocal(ctx context.Context, userID, month string, resetAt time.Time, limit, tokens int64) (func(used int), error) {
+	if err := q.refreshLocal(ctx, userID, month); err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := q.local[userID]
+	var reserved int64
+	for _, held := range t.held {
+		reserved += held
+	}
+	if t.used+reserved+tokens > limit {
+		return nil, &limits.TokenQuotaError{Limit: limit, Used: t.used, Reserved: reserved, Requested: tokens, ResetAt: resetAt}
+	}
+	token := uuid.NewString()
+	t.held[token] = tokens
+
+	var once sync.Once
+	settle := func(used int) {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			delete(t.held, token)
+			t.used += int64(used)
+		})
+	}
+	return settle, nil
+}
+
+// refreshLocal reads the month's recorded tokens once the cached count is
+// older than tokenUsageTTL or from another month
+func (q *TokenQuota) refreshLocal(ctx context.Context, userID, month string) error {
+	q.mu.Lock()
+	t, ok := q.local[userID]
+	fresh := ok && t.month == month && time.Since(t.fetched) < tokenUsageTTL
+	q.mu.Unlock()
+	if fresh {
+		return nil
+	}
+
+	used, err := q.service.MonthTokens(ctx, userID, month)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if t, ok = q.local[userID]; !ok || t.month != month {
+		t = &localTokens{month: month, held: make(map[string]int64)}
+		q.local[userID] = t
+	}
+	t.used, t.fetched = used, time.Now()
+	return nil
+}
+
+// Status returns the user's tokens this month without reserving any
+func (q *TokenQuota) Status(ctx context.Context, userID, plan string) (TokenStatus, error) {
+	now := time.Now()
+	month := now.Format("2006-01")
+	status := TokenStatus{
+		Limit:   q.Limit(plan),
+		ResetAt: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1, 0),
+	}
+
+	if q.client == nil {
+		if err := q.refreshLocal(ctx, userID, month); err != nil {
+			return status, err
+		}
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		t := q.local[userID]
+		status.Used = t.used
+		for _, held := range t.held {
+			status.Reserved += held
+		}
+		return status, nil
+	}
+
+	usedKey, heldKey := tokenKeys(userID, month)
+	if err := q.seedUsed(ctx, usedKey, userID, month); err != nil {
+		return status, err
+	}
+	used, err := q.client.Get(ctx, usedKey).Int64()
+	if err != nil && err != redis.Nil {
+		return status, fmt.Errorf("failed to read token usage: %w", err)
+	}
+	status.Used = used
+	members, err := q.client.ZRangeByScore(ctx, heldKey, &redis.ZRangeBy{Min: "(" + strconv.FormatInt(now.UnixMilli(), 10), Max: "+inf"}).Result()
+	if err != nil {
+		return status, fmt.Errorf("failed to read token reservations: %w", err)
+	}
+	for _, member := range members {
+		status.Reserved += reservedTokens(member)
+	}
+	return status, nil
+}
+
+// reservedTokens parses the tokens of a "<token>:<tokens>" reservation
+func reservedTokens(member string) int64 {
+	for i := len(member) - 1; i >= 0; i-- {
+		if member[i] == ':' {
+			n, _ := strconv.ParseInt(member[i+1:], 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+func tokenKeys(userID, month string) (used, held string) {
+	prefix := "llm-router:tokens:" + month + ":" + userID
+	return prefix + ":used", prefix + ":held"
+}
@@ -0,0 +1,125 @@
+package availability
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers lets admins take models out of rotation and schedule maintenance
+type Handlers struct {
+	store *Store
+}
+
+func NewHandlers(store *Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// List returns the disabled models and current or upcoming maintenance
+// windows, models out of rotation first
+func (h *Handlers) List(c *gin.Context) {
+	records, err := h.store.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load model availability",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    records,
+	})
+}
+
+// Disable takes a model out of rotation until it is enabled again or the
+// optional until time passes
+func (h *Handlers) Disable(c *gin.Context) {
+	var req DisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := h.store.Disable(c.Request.Context(), c.Param("model_id"), req, c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Model disabled",
+	})
+}
+
+// Enable returns a disabled model to rotation
+func (h *Handlers) Enable(c *gin.Context) {
+	err := h.store.Enable(c.Request.Context(), c.Param("model_id"), c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Model enabled",
+	})
+}
+
+// ScheduleMaintenance adds a maintenance window for a model
+func (h *Handlers) ScheduleMaintenance(c *gin.Context) {
+	var req WindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	window, err := h.store.ScheduleMaintenance(c.Request.Context(), c.Param("model_id"), req, c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    window,
+	})
+}
+
+// CancelMaintenance removes a maintenance window, ending it early when it is
+// under way
+func (h *Handlers) CancelMaintenance(c *gin.Context) {
+	err := h.store.CancelMaintenance(c.Request.Context(), c.Param("model_id"), c.Param("id"), c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Maintenance window cancelled",
+	})
+}
+
+// respond writes the error response for err, reporting whether there was none
+func (h *Handlers) respond(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrUnknownModel):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Model not found",
+		})
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Nothing to remove",
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update model availability",
+			"details": err.Error(),
+		})
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package availability
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// maxReasonLength bounds an admin's reason for a disable or maintenance window
+const maxReasonLength = 2000
+
+var (
+	// ErrUnknownModel is returned for models not in the catalog
+	ErrUnknownModel = errors.New("model not found in catalog")
+	// ErrNotFound is returned when there is no disable or window to remove
+	ErrNotFound = errors.New("not found")
+)
+
+// Catalog is the served model catalog operator holds are enforced on
+type Catalog interface {
+	GetModelByID(id string) (models.EnhancedModel, bool)
+	SetAvailability(availability map[string]models.Availability)
+}
+
+// Record is one model's operator hold and whether it keeps the model out of
+// rotation right now
+type Record struct {
+	ModelID string `json:"model_id"`
+	models.Availability
+	InRotation bool   `json:"in_rotation"`
+	HeldReason string `json:"held_reason,omitempty"` // Why the model is out of rotation now
+}
+
+// DisableRequest takes a model out of rotation
+type DisableRequest struct {
+	Reason string     `json:"reason" binding:"required"`
+	Until  *time.Time `json:"until"` // Re-enable automatically at this time
+}
+
+// WindowRequest schedules a maintenance window
+type WindowRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+// Validate rejects windows that cannot be scheduled
+func (r WindowRequest) Validate(now time.Time) error {
+	if !r.EndsAt.After(r.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if !r.EndsAt.After(now) {
+		return errors.New("ends_at must be in the future")
+	}
+	if len(r.Reason) > maxReasonLength {
+		return fmt.Errorf("reason exceeds %d characters", maxReasonLength)
+	}
+	return nil
+}
+
+// Store persists manual disables and maintenance windows and keeps every
+// replica's catalog in step with them
+type Store struct {
+	db       *sql.DB
+	catalog  Catalog
+	auditLog *audit.Logger
+}
+
+func NewStore(db *sql.DB, catalog Catalog, auditLog *audit.Logger) *Store {
+	return &Store{db: db, catalog: catalog, auditLog: auditLog}
+}
+
+// Start syncs operator holds and resyncs them every interval, so changes
+// made through another replica take effect here and expired holds drop off
+// the catalog. Windows open and close on time between syncs, as the filter
+// checks them against the clock.
+func (s *Store) Start(ctx context.Context, interval time.Duration) {
+	if err := s.Sync(ctx); err != nil {
+		log.Printf("[AVAILABILITY] %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Sync(ctx); err != nil {
+					log.Printf("[AVAILABILITY] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Sync replaces the catalog's operator holds with the stored ones that have
+// not expired
+func (s *Store) Sync(ctx context.Context) error {
+	holds, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	s.catalog.SetAvailability(holds)
+	return nil
+}
+
+// List returns the models with a disable or a current or upcoming window
+func (s *Store) List(ctx context.Context) ([]Record, error) {
+	holds, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list := make([]Record, 0, len(holds))
+	for modelID, a := range holds {
+		held, reason := models.EnhancedModel{Availability: &a}.Unavailable(now)
+		list = append(list, Record{
+			ModelID:      modelID,
+			Availability: a,
+			InRotation:   !held,
+			HeldReason:   reason,
+		})
+	}
+	// Models out of rotation first, then by ID
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].InRotation != list[j].InRotation {
+			return !list[i].InRotation
+		}
+		return list[i].ModelID < list[j].ModelID
+	})
+	return list, nil
+}
+
+// load reads the disables still in force and the windows not yet over
+func (s *Store) load(ctx context.Context) (map[string]models.Availability, error) {
+	holds := make(map[string]models.Availability)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT model_id, COALESCE(reason, ''), COALESCE(disabled_by, ''), disabled_at, until
+		FROM model_disables
+		WHERE until IS NULL OR until > CURRENT_TIMESTAMP`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model disables: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var modelID string
+		var disabledAt time.Time
+		var until sql.NullTime
+		a := models.Availability{Disabled: true}
+		if err := rows.Scan(&modelID, &a.Reason, &a.DisabledBy, &disabledAt, &until); err != nil {
+			return nil, fmt.Errorf("failed to scan model disable: %w", err)
+		}
+		a.DisabledAt = &disabledAt
+		if until.Valid {
+			t := until.Time
+			a.Until = &t
+		}
+		holds[modelID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	windows, err := s.db.QueryContext(ctx, `
+		SELECT id, model_id, starts_at, ends_at, COALESCE(reason, '')
+		FROM maintenance_windows
+		WHERE ends_at > CURRENT_TIMESTAMP
+		ORDER BY starts_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load maintenance windows: %w", err)
+	}
+	defer windows.Close()
+	for windows.Next() {
+		var modelID string
+		var w models.MaintenanceWindow
+		if err := windows.Scan(&w.ID, &modelID, &w.StartsAt, &w.EndsAt, &w.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		a := holds[modelID]
+		a.Maintenance = append(a.Maintenance, w)
+		holds[modelID] = a
+	}
+	return holds, windows.Err()
+}
+
+// Disable takes a model out of rotation on an admin's behalf, replacing any
+// earlier disable
+func (s *Store) Disable(ctx context.Context, modelID string, req DisableRequest, adminID string) error {
+	if _, ok := s.catalog.GetModelByID(modelID); !ok {
+		return ErrUnknownModel
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > maxReasonLength {
+		return fmt.Errorf("reason exceeds %d characters", maxReasonLength)
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return errors.New("until must be in the future")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO model_disables (model_id, reason, disabled_by, until)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+		ON CONFLICT (model_id) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			disabled_by = EXCLUDED.disabled_by,
+			disabled_at = CURRENT_TIMESTAMP,
+			until = EXCLUDED.until`,
+		modelID, reason, adminID, req.Until)
+	if err != nil {
+		return fmt.Errorf("failed to store model disable: %w", err)
+	}
+
+	log.Printf("[AVAILABILITY] %s disabled by %s: %s", modelID, adminID, reason)
+	s.record(ctx, adminID, "availability.disabled", modelID, map[string]interface{}{
+		"reason": reason,
+		"until":  req.Until,
+	})
+	return s.Sync(ctx)
+}
+
+// Enable returns a disabled model to rotation. Maintenance windows still apply.
+func (s *Store) Enable(ctx context.Context, modelID, adminID string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM model_disables WHERE model_id = $1`, modelID)
+	if err != nil {
+		return fmt.Errorf("failed to delete model disable: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	log.Printf("[AVAILABILITY] %s enabled by %s", modelID, adminID)
+	s.record(ctx, adminID, "availability.enabled", modelID, nil)
+	return s.Sync(ctx)
+}
+
+// ScheduleMaintenance adds a maintenance window for a model
+func (s *Store) ScheduleMaintenance(ctx context.Context, modelID string, req WindowRequest, adminID string) (models.MaintenanceWindow, error) {
+	if _, ok := s.catalog.GetModelByID(modelID); !ok {
+		return models.MaintenanceWindow{}, ErrUnknownModel
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		return models.MaintenanceWindow{}, err
+	}
+
+	w := models.MaintenanceWindow{StartsAt: req.StartsAt, EndsAt: req.EndsAt, Reason: strings.TrimSpace(req.Reason)}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO maintenance_windows (model_id, starts_at, ends_at, reason, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+		RETURNING id`,
+		modelID, w.StartsAt, w.EndsAt, w.Reason, adminID).Scan(&w.ID)
+	if err != nil {
+		return models.MaintenanceWindow{}, fmt.Errorf("failed to store maintenance window: %w", err)
+	}
+
+	log.Printf("[AVAILABILITY] %s maintenance scheduled by %s from %s to %s", modelID, adminID,
+		w.StartsAt.UTC().Format(time.RFC3339), w.EndsAt.UTC().Format(time.RFC3339))
+	s.record(ctx, adminID, "availability.maintenance_scheduled", modelID, map[string]interface{}{
+		"window_id": w.ID,
+		"starts_at": w.StartsAt,
+		"ends_at":   w.EndsAt,
+		"reason":    w.Reason,
+	})
+	return w, s.Sync(ctx)
+}
+
+// CancelMaintenance removes one of a model's maintenance windows, ending it
+// early when it is under way
+func (s *Store) CancelMaintenance(ctx context.Context, modelID, windowID, adminID string) error {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM maintenance_windows WHERE model_id = $1 AND id::text = $2`, modelID, windowID)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	log.Printf("[AVAILABILITY] %s maintenance %s cancelled by %s", modelID, windowID, adminID)
+	s.record(ctx, adminID, "availability.maintenance_cancelled", modelID, map[string]interface{}{
+		"window_id": windowID,
+	})
+	return s.Sync(ctx)
+}
+
+func (s *Store) record(ctx context.Context, userID, eventType, modelID string, details map[string]interface{}) {
+	if s.auditLog == nil {
+		return
+	}
+	if _, err := s.auditLog.Record(ctx, audit.Entry{
+		UserID:    userID,
+		EventType: eventType,
+		Action:    strings.TrimPrefix(eventType, "availability."),
+		Resource:  modelID,
+		Details:   details,
+	}); err != nil {
+		log.Printf("[AVAILABILITY] Failed to record audit entry: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package calibration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+const (
+	// minSamples is the feedback needed before a category gets its own curve;
+	// sparser categories use the curve fitted over all categories
+	minSamples = 30
+	// minIsotonicSamples is where isotonic regression replaces Platt scaling;
+	// below it the step function overfits
+	minIsotonicSamples = 300
+	// successRating is the lowest feedback rating counted as a success
+	successRating = 4
+	// lookback limits fitting to recent feedback
+	lookback = 180 * 24 * time.Hour
+	// maxSamples bounds the rows read per refresh
+	maxSamples = 200000
+)
+
+// allCategories keys the curve fitted over every category's feedback
+const allCategories = "*"
+
+// Fit describes one fitted curve and how it changed in-sample calibration
+type Fit struct {
+	Category    string    `json:"category"`
+	Method      string    `json:"method"`
+	Samples     int       `json:"samples"`
+	SuccessRate float64   `json:"success_rate"`
+	BrierBefore float64   `json:"brier_before"`
+	BrierAfter  float64   `json:"brier_after"`
+	ECEBefore   float64   `json:"ece_before"`
+	ECEAfter    float64   `json:"ece_after"`
+	Curve       Curve     `json:"curve"`
+	FittedAt    time.Time `json:"fitted_at"`
+}
+
+// Calibrator fits per-category curves from recommendation feedback and maps
+// heuristic confidence to the observed success probability
+type Calibrator struct {
+	db *sql.DB
+
+	mu   sync.RWMutex
+	fits map[string]Fit
+}
+
+func NewCalibrator(db *sql.DB) *Calibrator {
+	return &Calibrator{db: db, fits: map[string]Fit{}}
+}
+
+// Calibrate returns the calibrated confidence and the method used. Categories
+// without enough feedback fall back to the all-category curve; with no curve
+// at all the confidence is returned unchanged and the method is empty.
+func (c *Calibrator) Calibrate(category string, confidence float64) (float64, string) {
+	c.mu.RLock()
+	fit, ok := c.fits[category]
+	if !ok {
+		fit, ok = c.fits[allCategories]
+	}
+	c.mu.RUnlock()
+	if !ok {
+		return confidence, ""
+	}
+	return math.Max(0, math.Min(1, fit.Curve.Predict(confidence))), fit.Method
+}
+
+// Start refits now and then on the given interval until ctx is cancelled
+func (c *Calibrator) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		if err := c.Refresh(ctx); err != nil {
+			log.Printf("[CALIBRATION] Refresh failed: %v", err)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Refresh(ctx); err != nil {
+					log.Printf("[CALIBRATION] Refresh failed: %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh refits every curve from recent feedback that recorded a confidence
+func (c *Calibrator) Refresh(ctx context.Context) error {
+	byCategory, err := c.samples(ctx)
+	if err != nil {
+		return err
+	}
+
+	var all []Sample
+	fits := make(map[string]Fit)
+	for category, samples := range byCategory {
+		all = append(all, samples...)
+		if len(samples) >= minSamples {
+			fits[category] = fit(category, samples)
+		}
+	}
+	if len(all) >= minSamples {
+		fits[allCategories] = fit(allCategories, all)
+	}
+
+	c.mu.Lock()
+	c.fits = fits
+	c.mu.Unlock()
+
+	log.Printf("[CALIBRATION] Fitted %d curves from %d feedback samples", len(fits), len(all))
+	return nil
+}
+
+// Fits returns the active curves sorted by category
+func (c *Calibrator) Fits() []Fit {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	fits := make([]Fit, 0, len(c.fits))
+	for _, f := range c.fits {
+		fits = append(fits, f)
+	}
+	sort.Slice(fits, func(i, j int) bool { return fits[i].Category < fits[j].Category })
+	return fits
+}
+
+func (c *Calibrator) samples(ctx context.Context) (map[string][]Sample, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT category, predicted_confidence, rating
+		FROM model_feedback
+		WHERE predicted_confidence IS NOT NULL AND created_at >= $1
+		ORDER BY created_at DESC
+		LIMIT $2`, time.Now().Add(-lookback), maxSamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load calibration samples: %w", err)
+	}
+	defer rows.Close()
+
+	byCategory := make(map[string][]Sample)
+	for rows.Next() {
+		var category string
+		var confidence float64
+		var rating int
+		if err := rows.Scan(&category, &confidence, &rating); err != nil {
+			return nil, fmt.Errorf("failed to scan calibration sample: %w", err)
+		}
+		byCategory[category] = append(byCategory[category], Sample{Confidence: confidence, Success: rating >= successRating})
+	}
+	return byCategory, rows.Err()
+}
+
+// fit picks Platt scaling for moderate samples and isotonic regression once
+// there is enough data for it
+func fit(category string, samples []Sample) Fit {
+	var curve Curve
+	if len(samples) >= minIsotonicSamples {
+		curve = fitIsotonic(samples)
+	} else {
+		curve = fitPlatt(samples)
+	}
+
+	successes := 0
+	for _, s := range samples {
+		if s.Success {
+			successes++
+		}
+	}
+	identity := func(x float64) float64 { return x }
+	return Fit{
+		Category:    category,
+		Method:      curve.Method(),
+		Samples:     len(samples),
+		SuccessRate: float64(successes) / float64(len(samples)),
+		BrierBefore: brierScore(samples, identity),
+		BrierAfter:  brierScore(samples, curve.Predict),
+		ECEBefore:   expectedCalibrationError(samples, identity),
+		ECEAfter:    expectedCalibrationError(samples, curve.Predict),
+		Curve:       curve,
+		FittedAt:    time.Now(),
+	}
+}
@@ -0,0 +1,193 @@
+package calibration
+
+import (
+	"math"
+	"sort"
+)
+
+// Calibration methods
+const (
+	MethodPlatt    = "platt"
+	MethodIsotonic = "isotonic"
+)
+
+// Sample pairs the confidence returned with a recommendation with whether it worked out
+type Sample struct {
+	Confidence float64
+	Success    bool
+}
+
+// Curve maps a heuristic confidence to a success probability
+type Curve interface {
+	Predict(confidence float64) float64
+	Method() string
+}
+
+// plattCurve is a logistic fit p = 1 / (1 + exp(-(a*x + b)))
+type plattCurve struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+func (c plattCurve) Predict(x float64) float64 { return sigmoid(c.A*x + c.B) }
+func (c plattCurve) Method() string            { return MethodPlatt }
+
+// fitPlatt fits a logistic curve with Newton's method, using Platt's smoothed
+// targets so a small sample cannot produce 0 or 1 probabilities
+func fitPlatt(samples []Sample) plattCurve {
+	positives := 0
+	for _, s := range samples {
+		if s.Success {
+			positives++
+		}
+	}
+	negatives := len(samples) - positives
+	hi := (float64(positives) + 1) / (float64(positives) + 2)
+	lo := 1 / (float64(negatives) + 2)
+
+	curve := plattCurve{A: 0, B: math.Log((float64(positives) + 1) / (float64(negatives) + 1))}
+	for iter := 0; iter < 100; iter++ {
+		var ga, gb, haa, hab, hbb float64
+		for _, s := range samples {
+			t := lo
+			if s.Success {
+				t = hi
+			}
+			p := curve.Predict(s.Confidence)
+			d := p - t
+			w := math.Max(p*(1-p), 1e-12)
+			ga += d * s.Confidence
+			gb += d
+			haa += w * s.Confidence * s.Confidence
+			hab += w * s.Confidence
+			hbb += w
+		}
+		// A small ridge keeps the Hessian invertible when every confidence is equal
+		haa += 1e-6
+		hbb += 1e-6
+		det := haa*hbb - hab*hab
+		if det <= 0 {
+			break
+		}
+		da := (hbb*ga - hab*gb) / det
+		db := (haa*gb - hab*ga) / det
+		curve.A -= da
+		curve.B -= db
+		if math.Abs(da) < 1e-9 && math.Abs(db) < 1e-9 {
+			break
+		}
+	}
+	return curve
+}
+
+// isotonicCurve is a non-decreasing piecewise linear fit through the means of
+// the blocks found by pool-adjacent-violators
+type isotonicCurve struct {
+	X []float64 `json:"x"`
+	Y []float64 `json:"y"`
+}
+
+func (c isotonicCurve) Method() string { return MethodIsotonic }
+
+func (c isotonicCurve) Predict(x float64) float64 {
+	n := len(c.X)
+	if n == 0 {
+		return x
+	}
+	if x <= c.X[0] {
+		return c.Y[0]
+	}
+	if x >= c.X[n-1] {
+		return c.Y[n-1]
+	}
+	i := sort.SearchFloat64s(c.X, x)
+	x0, x1, y0, y1 := c.X[i-1], c.X[i], c.Y[i-1], c.Y[i]
+	if x1 == x0 {
+		return y1
+	}
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
+
+func fitIsotonic(samples []Sample) isotonicCurve {
+	sorted := append([]Sample(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Confidence < sorted[j].Confidence })
+
+	type block struct{ sumX, sumY, weight float64 }
+	var blocks []block
+	for _, s := range sorted {
+		y := 0.0
+		if s.Success {
+			y = 1
+		}
+		blocks = append(blocks, block{sumX: s.Confidence, sumY: y, weight: 1})
+		// Pool while the previous block's rate is not below the new one's
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sumY/prev.weight < last.sumY/last.weight {
+				break
+			}
+			blocks = blocks[:len(blocks)-2]
+			blocks = append(blocks, block{sumX: prev.sumX + last.sumX, sumY: prev.sumY + last.sumY, weight: prev.weight + last.weight})
+		}
+	}
+
+	curve := isotonicCurve{X: make([]float64, len(blocks)), Y: make([]float64, len(blocks))}
+	for i, b := range blocks {
+		curve.X[i] = b.sumX / b.weight
+		curve.Y[i] = b.sumY / b.weight
+	}
+	return curve
+}
+
+// brierScore is the mean squared error between predicted and realized outcomes
+func brierScore(samples []Sample, predict func(float64) float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, s := range samples {
+		y := 0.0
+		if s.Success {
+			y = 1
+		}
+		d := predict(s.Confidence) - y
+		total += d * d
+	}
+	return total / float64(len(samples))
+}
+
+// expectedCalibrationError is the sample-weighted gap between mean predicted
+// confidence and success rate over ten equal-width bins
+func expectedCalibrationError(samples []Sample, predict func(float64) float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	const bins = 10
+	var predicted, realized, counts [bins]float64
+	for _, s := range samples {
+		p := predict(s.Confidence)
+		i := int(p * bins)
+		if i >= bins {
+			i = bins - 1
+		}
+		if i < 0 {
+			i = 0
+		}
+		predicted[i] += p
+		if s.Success {
+			realized[i]++
+		}
+		counts[i]++
+	}
+	ece := 0.0
+	for i := range counts {
+		if counts[i] > 0 {
+			ece += math.Abs(predicted[i]-realized[i]) / float64(len(samples))
+		}
+	}
+	return ece
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
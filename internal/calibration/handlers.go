@@ -0,0 +1,45 @@
+package calibration
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes the fitted calibration curves to admins
+type Handlers struct {
+	calibrator *Calibrator
+}
+
+func NewHandlers(calibrator *Calibrator) *Handlers {
+	return &Handlers{calibrator: calibrator}
+}
+
+// List returns the active curves with their in-sample Brier score and ECE
+func (h *Handlers) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"fits":                 h.calibrator.Fits(),
+			"min_samples":          minSamples,
+			"min_isotonic_samples": minIsotonicSamples,
+			"success_rating":       successRating,
+		},
+	})
+}
+
+// Refresh refits the curves now
+func (h *Handlers) Refresh(c *gin.Context) {
+	if err := h.calibrator.Refresh(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to refit calibration curves",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.calibrator.Fits(),
+	})
+}
@@ -0,0 +1,207 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// scalarFields lists the model attributes addressable from a query
+var scalarFields = map[string]func(m models.EnhancedModel) (literal, bool){
+	"id":             func(m models.EnhancedModel) (literal, bool) { return stringValue(m.ID) },
+	"provider":       func(m models.EnhancedModel) (literal, bool) { return stringValue(m.Provider) },
+	"name":           func(m models.EnhancedModel) (literal, bool) { return stringValue(m.DisplayName) },
+	"model_type":     func(m models.EnhancedModel) (literal, bool) { return stringValue(m.ModelType) },
+	"release_date":   func(m models.EnhancedModel) (literal, bool) { return stringValue(m.ReleaseDate) },
+	"open_source":    func(m models.EnhancedModel) (literal, bool) { return boolValue(m.OpenSource) },
+	"free_tier":      func(m models.EnhancedModel) (literal, bool) { return boolValue(m.Pricing.FreeTier) },
+	"context_window": func(m models.EnhancedModel) (literal, bool) { return intValue(m.TechnicalSpecs.ContextWindow) },
+	"confidence":     func(m models.EnhancedModel) (literal, bool) { return numberValue(m.ConfidenceScore) },
+	"image_input":    func(m models.EnhancedModel) (literal, bool) { return boolValue(m.AcceptsImageInput()) },
+	"cost_in": func(m models.EnhancedModel) (literal, bool) {
+		return costValue(m.Pricing.Text.CostInPer1K, m.Pricing.CostInPer1K)
+	},
+	"cost_out": func(m models.EnhancedModel) (literal, bool) {
+		return costValue(m.Pricing.Text.CostOutPer1K, m.Pricing.CostOutPer1K)
+	},
+	"latency_ms": func(m models.EnhancedModel) (literal, bool) {
+		if m.Performance.Latency.AvgLatencyMs != nil {
+			return intValue(*m.Performance.Latency.AvgLatencyMs)
+		}
+		return intValue(m.Performance.AvgLatencyMs)
+	},
+	"ttft_ms": func(m models.EnhancedModel) (literal, bool) {
+		if m.Performance.Latency.TimeToFirstTokenMs == nil {
+			return literal{}, false
+		}
+		return intValue(*m.Performance.Latency.TimeToFirstTokenMs)
+	},
+	"tool_use":              func(m models.EnhancedModel) (literal, bool) { return featureValue(m, models.FeatureToolUse) },
+	"prompt_caching":        func(m models.EnhancedModel) (literal, bool) { return featureValue(m, models.FeaturePromptCaching) },
+	"system_prompt_support": func(m models.EnhancedModel) (literal, bool) { return featureValue(m, models.FeatureSystemPrompt) },
+	"max_parallel_tool_calls": func(m models.EnhancedModel) (literal, bool) {
+		if m.Features == nil {
+			return literal{}, false
+		}
+		return intValue(m.Features.MaxParallelToolCalls)
+	},
+	"license": func(m models.EnhancedModel) (literal, bool) {
+		if m.Compliance == nil {
+			return literal{}, false
+		}
+		return stringValue(m.Compliance.License)
+	},
+	"trains_on_inputs": func(m models.EnhancedModel) (literal, bool) {
+		if m.Compliance == nil || m.Compliance.TrainsOnInputs == nil {
+			return literal{}, false
+		}
+		return boolValue(*m.Compliance.TrainsOnInputs)
+	},
+	"throughput": func(m models.EnhancedModel) (literal, bool) {
+		if m.Performance.Latency.ThroughputTokensSec != nil {
+			return numberValue(*m.Performance.Latency.ThroughputTokensSec)
+		}
+		if m.Performance.Throughput > 0 {
+			return numberValue(m.Performance.Throughput)
+		}
+		return literal{}, false
+	},
+}
+
+// listFields are matched with HAS
+var listFields = map[string]func(m models.EnhancedModel) []string{
+	"tags":             func(m models.EnhancedModel) []string { return m.Tags },
+	"sources":          func(m models.EnhancedModel) []string { return m.Sources },
+	"specializations":  func(m models.EnhancedModel) []string { return m.ComplexityRecommendations.Specializations },
+	"strengths":        func(m models.EnhancedModel) []string { return m.CommunityFeedback.Strengths },
+	"input_modalities": func(m models.EnhancedModel) []string { return m.InputModalities },
+	"certifications": func(m models.EnhancedModel) []string {
+		if m.Compliance == nil {
+			return nil
+		}
+		return m.Compliance.Certifications
+	},
+	"price_tiers": func(m models.EnhancedModel) []string {
+		var names []string
+		for _, tier := range m.PriceTiers() {
+			names = append(names, tier.Name)
+		}
+		return names
+	},
+}
+
+// capabilityFields are the capability names accepted without the capability.
+// prefix; their taxonomy aliases are accepted too
+var capabilityFields = map[string]bool{
+	models.CapabilityCoding: true, models.CapabilityMath: true, models.CapabilityReasoning: true,
+	models.CapabilityWriting: true, models.CapabilityCreative: true, models.CapabilityAnalysis: true,
+	models.CapabilityResearch: true, models.CapabilityConversation: true, models.CapabilityTranslation: true,
+	models.CapabilitySummarization: true, models.CapabilityVisionInput: true, models.CapabilityImageGeneration: true,
+	models.CapabilityVideoGeneration: true, models.CapabilityAudioGeneration: true,
+}
+
+// Fields returns the names accepted by the query language
+func Fields() map[string][]string {
+	fields := map[string][]string{
+		"scalar":     {},
+		"list":       {},
+		"capability": {},
+	}
+	for name := range scalarFields {
+		fields["scalar"] = append(fields["scalar"], name)
+	}
+	for name := range listFields {
+		fields["list"] = append(fields["list"], name)
+	}
+	for name := range capabilityFields {
+		fields["capability"] = append(fields["capability"], name)
+	}
+	for _, names := range fields {
+		sort.Strings(names)
+	}
+	return fields
+}
+
+func isKnownField(field string) bool {
+	if _, ok := scalarFields[field]; ok {
+		return true
+	}
+	if _, ok := listFields[field]; ok {
+		return true
+	}
+	return capabilityFields[models.CanonicalCapability(field)] || strings.HasPrefix(field, "capability.")
+}
+
+func isListField(field string) bool {
+	_, ok := listFields[field]
+	return ok
+}
+
+func resolveField(m models.EnhancedModel, field string) (literal, bool) {
+	if resolve, ok := scalarFields[field]; ok {
+		return resolve(m)
+	}
+	return capabilityScore(m, models.CanonicalCapability(strings.TrimPrefix(field, "capability.")))
+}
+
+func resolveList(m models.EnhancedModel, field string) ([]string, bool) {
+	resolve, ok := listFields[field]
+	if !ok {
+		return nil, false
+	}
+	return resolve(m), true
+}
+
+// capabilityScore looks the category up in text tasks first, then generative tasks
+func capabilityScore(m models.EnhancedModel, name string) (literal, bool) {
+	if cap, ok := m.TaskCapabilities.TextTasks[name]; ok {
+		return numberValue(cap.Score)
+	}
+	if cap, ok := m.TaskCapabilities.GenerativeTasks[name]; ok {
+		return numberValue(cap.Score)
+	}
+	return literal{}, false
+}
+
+func stringValue(s string) (literal, bool) {
+	if s == "" {
+		return literal{}, false
+	}
+	return literal{kind: kindString, text: s}, true
+}
+
+func boolValue(b bool) (literal, bool) {
+	return literal{kind: kindBool, flag: b}, true
+}
+
+func numberValue(f float64) (literal, bool) {
+	return literal{kind: kindNumber, number: f}, true
+}
+
+func intValue(i int) (literal, bool) {
+	if i == 0 {
+		return literal{}, false
+	}
+	return numberValue(float64(i))
+}
+
+// featureValue is unset while the model's support for the feature is unknown
+func featureValue(m models.EnhancedModel, feature string) (literal, bool) {
+	supported, known := m.Features.Supports(feature)
+	if !known {
+		return literal{}, false
+	}
+	return boolValue(supported)
+}
+
+// costValue prefers the structured text pricing over the legacy flat fields
+func costValue(primary, legacy *float64) (literal, bool) {
+	if primary != nil {
+		return numberValue(*primary)
+	}
+	if legacy != nil {
+		return numberValue(*legacy)
+	}
+	return literal{}, false
+}
@@ -0,0 +1,250 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// maxImportBytes bounds an uploaded catalog
+const maxImportBytes = 32 << 20
+
+// Store is the live catalog that exports read, imports replace and model
+// edits change one model at a time
+type Store interface {
+	Source
+	ImportCatalog(catalog []models.EnhancedModel, importedAt time.Time)
+	GetModelByID(id string) (models.EnhancedModel, bool)
+	BaseModels() []models.EnhancedModel
+	UpsertModel(model models.EnhancedModel) models.EnhancedModel
+	RemoveModel(id string) bool
+}
+
+// ImportPublisher shares an imported catalog with the other replicas
+type ImportPublisher interface {
+	PublishImport(ctx context.Context, catalog []models.EnhancedModel, importedAt time.Time) error
+}
+
+// Handlers exports and imports the catalog for admins
+type Handlers struct {
+	store     Store
+	publisher ImportPublisher
+}
+
+func NewHandlers(store Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// SetPublisher replicates imports to the other router replicas
+func (h *Handlers) SetPublisher(publisher ImportPublisher) {
+	h.publisher = publisher
+}
+
+// Export downloads the live catalog as a portable document; ?format=yaml
+// selects YAML
+func (h *Handlers) Export(c *gin.Context) {
+	format, err := ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	doc, err := Export(h.store)
+	if err == nil {
+		var data []byte
+		if data, err = doc.Encode(format); err == nil {
+			contentType := "application/json"
+			if format == FormatYAML {
+				contentType = "application/yaml"
+			}
+			filename := fmt.Sprintf("catalog-%s.%s", doc.ExportedAt.Format("20060102T150405Z"), format)
+			c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+			c.Data(http.StatusOK, contentType, data)
+			return
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Failed to export catalog",
+		"details": err.Error(),
+	})
+}
+
+// Import validates a portable document and replaces the live catalog with
+// it. The format follows ?format or the Content-Type; ?dry_run=true only
+// validates.
+func (h *Handlers) Import(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = c.ContentType()
+	}
+	format, err := ParseFormat(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxImportBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read catalog",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(data) > maxImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Catalog exceeds %d bytes", maxImportBytes),
+		})
+		return
+	}
+
+	doc, err := DecodePortable(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid catalog",
+			"details": err.Error(),
+		})
+		return
+	}
+	problems, warnings := doc.Validate()
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Invalid catalog",
+			"details":  problems,
+			"warnings": warnings,
+		})
+		return
+	}
+
+	previous := len(h.store.GetAllModels())
+	result := gin.H{
+		"models":          len(doc.Models),
+		"previous_models": previous,
+		"schema_version":  doc.SchemaVersion,
+		"dry_run":         c.Query("dry_run") == "true",
+	}
+	if len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    result,
+		})
+		return
+	}
+
+	importedAt := time.Now()
+	h.store.ImportCatalog(doc.Models, importedAt)
+	log.Printf("[CATALOG] Imported %d models (was %d)", len(doc.Models), previous)
+
+	if h.publisher != nil {
+		if err := h.publisher.PublishImport(c.Request.Context(), doc.Models, importedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Catalog imported on this replica but not published to the others",
+				"details": err.Error(),
+			})
+			return
+		}
+		result["replicated"] = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// PutModel adds or replaces one model of the base catalog without rebuilding
+// the catalog. The body is a catalog entry, as in an export; its id may be
+// left out.
+func (h *Handlers) PutModel(c *gin.Context) {
+	id := c.Param("id")
+	var model models.EnhancedModel
+	if err := c.ShouldBindJSON(&model); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid model",
+			"details": err.Error(),
+		})
+		return
+	}
+	if model.ID != "" && model.ID != id {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid model",
+			"details": fmt.Sprintf("id %q does not match the path's %q", model.ID, id),
+		})
+		return
+	}
+	model.ID = id
+	if problems := validateModel(id, model); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid model",
+			"details": problems,
+		})
+		return
+	}
+
+	_, existed := h.store.GetModelByID(id)
+	served := h.store.UpsertModel(model)
+	log.Printf("[CATALOG] Upserted model %s", id)
+
+	result := gin.H{"model": served, "created": !existed}
+	if !h.replicate(c, result) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// DeleteModel removes one model from the base and the served catalog. A model
+// Analytics AI also lists returns at the next fusion.
+func (h *Handlers) DeleteModel(c *gin.Context) {
+	id := c.Param("id")
+	if !h.store.RemoveModel(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Model not found"})
+		return
+	}
+	log.Printf("[CATALOG] Removed model %s", id)
+
+	result := gin.H{"model_id": id, "removed": true}
+	if !h.replicate(c, result) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// replicate publishes the edited base catalog to the other replicas, as an
+// import would, answering 500 when that fails; it reports whether the
+// request may continue
+func (h *Handlers) replicate(c *gin.Context, result gin.H) bool {
+	if h.publisher == nil {
+		return true
+	}
+	if err := h.publisher.PublishImport(c.Request.Context(), h.store.BaseModels(), time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Model changed on this replica but not published to the others",
+			"details": err.Error(),
+		})
+		return false
+	}
+	result["replicated"] = true
+	return true
+}
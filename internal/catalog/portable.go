@@ -0,0 +1,249 @@
+package catalog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+const (
+	// PortableKind identifies a portable catalog document
+	PortableKind = "llm-router/catalog"
+	// PortableVersion is the schema version written by Export. Bump it when a
+	// change to the model schema would be misread by an older importer.
+	PortableVersion = 1
+
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// validModelTypes are the model types the router can route
+var validModelTypes = map[string]bool{
+	"text": true, "image": true, "video": true, "audio": true, "multimodal": true,
+}
+
+// PortableCatalog is the fused catalog as a versioned document, for promoting
+// a curated catalog between environments and editing it offline
+type PortableCatalog struct {
+	Kind          string                 `json:"kind"`
+	SchemaVersion int                    `json:"schema_version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	FusedAt       *time.Time             `json:"fused_at,omitempty"` // Unset for catalogs never fused with Analytics AI
+	ModelCount    int                    `json:"model_count"`
+	ContentHash   string                 `json:"content_hash,omitempty"` // sha256 of the models as exported; drop it after editing
+	Models        []models.EnhancedModel `json:"models"`
+}
+
+// Export builds a portable document from the live catalog. Incident status
+// and tenant annotations are runtime state and are left out.
+func Export(source Source) (PortableCatalog, error) {
+	all := source.GetAllModels()
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	for i := range all {
+		all[i].Status = nil
+		all[i].TenantAnnotation = nil
+	}
+
+	hash, err := contentHash(all)
+	if err != nil {
+		return PortableCatalog{}, err
+	}
+	doc := PortableCatalog{
+		Kind:          PortableKind,
+		SchemaVersion: PortableVersion,
+		ExportedAt:    time.Now().UTC(),
+		ModelCount:    len(all),
+		ContentHash:   hash,
+		Models:        all,
+	}
+	if fusedAt := source.LastFusion(); !fusedAt.IsZero() {
+		doc.FusedAt = &fusedAt
+	}
+	return doc, nil
+}
+
+// ParseFormat resolves a format name or content type to FormatJSON or FormatYAML
+func ParseFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch {
+	case format == "" || format == FormatJSON || strings.Contains(format, "json"):
+		return FormatJSON, nil
+	case format == FormatYAML || format == "yml" || strings.Contains(format, "yaml"):
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("unsupported catalog format %q, expected json or yaml", format)
+}
+
+// Encode writes the document as indented JSON or YAML
+func (c PortableCatalog) Encode(format string) ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode catalog: %w", err)
+	}
+	if format != FormatYAML {
+		return append(data, '\n'), nil
+	}
+
+	// Models carry JSON tags only, so YAML goes through the JSON form to keep
+	// the same field names and order in both formats
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to encode catalog: %w", err)
+	}
+	blockStyle(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode catalog as yaml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle drops the JSON flow and quoting styles so the encoder writes
+// plain block YAML, quoting only where a value would otherwise change type
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// DecodePortable reads a document in either format. Unknown fields are
+// rejected so that typos in hand-edited catalogs are not silently dropped.
+func DecodePortable(data []byte, format string) (*PortableCatalog, error) {
+	if format == FormatYAML {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse yaml catalog: %w", err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse yaml catalog: %w", err)
+		}
+		data = converted
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var c PortableCatalog
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %w", err)
+	}
+	return &c, nil
+}
+
+// Validate checks the document against the schema. Problems block an import;
+// warnings do not.
+func (c *PortableCatalog) Validate() (problems []string, warnings []string) {
+	if c.Kind != PortableKind {
+		problems = append(problems, fmt.Sprintf("kind must be %q, got %q", PortableKind, c.Kind))
+	}
+	if c.SchemaVersion < 1 || c.SchemaVersion > PortableVersion {
+		problems = append(problems, fmt.Sprintf("schema_version %d is not supported; this router reads versions 1 to %d", c.SchemaVersion, PortableVersion))
+	}
+	if len(c.Models) == 0 {
+		problems = append(problems, "catalog has no models")
+	}
+	if c.ModelCount != 0 && c.ModelCount != len(c.Models) {
+		warnings = append(warnings, fmt.Sprintf("model_count is %d but the catalog has %d models", c.ModelCount, len(c.Models)))
+	}
+	if c.ContentHash != "" {
+		if hash, err := contentHash(c.Models); err == nil && hash != c.ContentHash {
+			warnings = append(warnings, "content_hash does not match; the catalog was edited since export")
+		}
+	}
+
+	seen := make(map[string]bool, len(c.Models))
+	for i, m := range c.Models {
+		where := fmt.Sprintf("models[%d]", i)
+		if m.ID == "" {
+			problems = append(problems, where+": id is required")
+		} else {
+			where = fmt.Sprintf("models[%d] (%s)", i, m.ID)
+			if seen[m.ID] {
+				problems = append(problems, where+": duplicate id")
+			}
+			seen[m.ID] = true
+		}
+		problems = append(problems, validateModel(where, m)...)
+	}
+	return problems, warnings
+}
+
+// validateModel checks one model's required fields and values
+func validateModel(where string, m models.EnhancedModel) []string {
+	var problems []string
+	if m.Provider == "" {
+		problems = append(problems, where+": provider is required")
+	}
+	if !validModelTypes[m.ModelType] {
+		problems = append(problems, fmt.Sprintf("%s: model_type %q must be one of text, image, video, audio, multimodal", where, m.ModelType))
+	}
+	return append(problems, validateModelValues(where, m)...)
+}
+
+// validateModelValues checks ranges the engine relies on
+func validateModelValues(where string, m models.EnhancedModel) []string {
+	var problems []string
+	unit := func(field string, v float64) {
+		if math.IsNaN(v) || v < 0 || v > 1 {
+			problems = append(problems, fmt.Sprintf("%s: %s %v must be in [0, 1]", where, field, v))
+		}
+	}
+	price := func(field string, v *float64) {
+		if v != nil && (math.IsNaN(*v) || *v < 0) {
+			problems = append(problems, fmt.Sprintf("%s: %s %v must not be negative", where, field, *v))
+		}
+	}
+
+	unit("confidence_score", m.ConfidenceScore)
+	for category, tc := range m.TaskCapabilities.TextTasks {
+		unit("task_capabilities.text_tasks."+category+".score", tc.Score)
+	}
+	if m.TechnicalSpecs.ContextWindow < 0 {
+		problems = append(problems, fmt.Sprintf("%s: technical_specs.context_window must not be negative", where))
+	}
+	if m.TechnicalSpecs.MaxOutputTokens < 0 {
+		problems = append(problems, fmt.Sprintf("%s: technical_specs.max_output_tokens must not be negative", where))
+	}
+	price("pricing.text.cost_in_per_1k", m.Pricing.Text.CostInPer1K)
+	price("pricing.text.cost_out_per_1k", m.Pricing.Text.CostOutPer1K)
+	for i, tier := range m.Pricing.Tiers {
+		field := fmt.Sprintf("pricing.tiers[%d]", i)
+		if tier.Name != models.PriceTierBatch && tier.Name != models.PriceTierOffPeak {
+			problems = append(problems, fmt.Sprintf("%s: %s.name %q must be %s or %s", where, field, tier.Name, models.PriceTierBatch, models.PriceTierOffPeak))
+		}
+		unit(field+".discount", tier.Discount)
+		price(field+".cost_in_per_1k", tier.CostInPer1K)
+		price(field+".cost_out_per_1k", tier.CostOutPer1K)
+		if tier.Window != nil && !tier.Window.Valid() {
+			problems = append(problems, fmt.Sprintf("%s: %s.window bounds must be HH:MM", where, field))
+		}
+	}
+	if m.Compliance != nil {
+		if err := m.Compliance.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: compliance: %v", where, err))
+		}
+	}
+	return problems
+}
+
+func contentHash(all []models.EnhancedModel) (string, error) {
+	payload, err := json.Marshal(all)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash catalog: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
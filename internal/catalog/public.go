@@ -0,0 +1,242 @@
+package catalog
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+const (
+	// publicCacheControl lets CDNs serve the catalog for five minutes and
+	// revalidate in the background for ten more
+	publicCacheControl = "public, max-age=300, stale-while-revalidate=600"
+	// publicRebuildInterval bounds how often the catalog is re-rendered
+	publicRebuildInterval = 30 * time.Second
+)
+
+// Source supplies the live catalog
+type Source interface {
+	GetAllModels() []models.EnhancedModel
+	LastFusion() time.Time
+}
+
+// PublicModel is the subset of a model safe to publish: no provenance,
+// community weaknesses, internal eval scores or tenant endpoints
+type PublicModel struct {
+	ID               string                  `json:"id"`
+	Provider         string                  `json:"provider"`
+	DisplayName      string                  `json:"display_name"`
+	ModelType        string                  `json:"model_type"`
+	ReleaseDate      string                  `json:"release_date,omitempty"`
+	ContextWindow    int                     `json:"context_window,omitempty"`
+	Pricing          models.PricingStructure `json:"pricing"`
+	Capabilities     map[string]float64      `json:"capabilities,omitempty"`
+	Benchmarks       map[string]float64      `json:"benchmarks,omitempty"`
+	ReasoningEfforts []string                `json:"reasoning_efforts,omitempty"`
+	OpenSource       bool                    `json:"open_source"`
+	Tags             []string                `json:"tags,omitempty"`
+	Compliance       *models.Compliance      `json:"compliance,omitempty"`
+	LastUpdated      string                  `json:"last_updated,omitempty"`
+}
+
+// PublicCatalog is the published catalog document
+type PublicCatalog struct {
+	Models      []PublicModel `json:"models"`
+	Count       int           `json:"count"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// toPublic copies the publishable fields of a model
+func toPublic(m models.EnhancedModel) PublicModel {
+	pm := PublicModel{
+		ID:            m.ID,
+		Provider:      m.Provider,
+		DisplayName:   m.DisplayName,
+		ModelType:     m.ModelType,
+		ReleaseDate:   m.ReleaseDate,
+		ContextWindow: m.TechnicalSpecs.ContextWindow,
+		Pricing:       m.Pricing,
+		OpenSource:    m.OpenSource,
+		Tags:          m.Tags,
+		Compliance:    m.Compliance,
+		LastUpdated:   m.LastUpdated,
+	}
+
+	caps := make(map[string]float64)
+	for category, tc := range m.TaskCapabilities.TextTasks {
+		caps[category] = tc.Score
+	}
+	for task, gc := range m.TaskCapabilities.GenerativeTasks {
+		caps[task] = gc.Score
+	}
+	if len(caps) > 0 {
+		pm.Capabilities = caps
+	}
+
+	// Measured benchmarks come from private eval suites, judged ones from
+	// tenants' traffic
+	bench := make(map[string]float64)
+	for name, value := range m.Benchmarks.Text {
+		if !models.IsMeasuredBenchmark(name) && !models.IsJudgedBenchmark(name) {
+			bench[name] = value
+		}
+	}
+	if len(bench) > 0 {
+		pm.Benchmarks = bench
+	}
+
+	for _, v := range m.ReasoningEfforts {
+		pm.ReasoningEfforts = append(pm.ReasoningEfforts, v.Effort)
+	}
+	return pm
+}
+
+// Publisher renders the public catalog, tracks its ETag and signs it when
+// CATALOG_SIGNING_KEY holds a base64 Ed25519 seed
+type Publisher struct {
+	source Source
+	key    ed25519.PrivateKey
+	keyID  string
+
+	mu           sync.Mutex
+	body         []byte
+	etag         string
+	signature    string
+	lastModified time.Time
+	builtAt      time.Time
+}
+
+func NewPublisher(source Source) (*Publisher, error) {
+	p := &Publisher{source: source}
+
+	if encoded := os.Getenv("CATALOG_SIGNING_KEY"); encoded != "" {
+		seed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("CATALOG_SIGNING_KEY must be a base64 %d-byte Ed25519 seed", ed25519.SeedSize)
+		}
+		p.key = ed25519.NewKeyFromSeed(seed)
+		sum := sha256.Sum256(p.key.Public().(ed25519.PublicKey))
+		p.keyID = hex.EncodeToString(sum[:8])
+	}
+	return p, nil
+}
+
+// snapshot returns the current rendering, rebuilding it when stale. Last-Modified
+// only moves when the content, and so the ETag, changes.
+func (p *Publisher) snapshot() ([]byte, string, string, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.body != nil && time.Since(p.builtAt) < publicRebuildInterval {
+		return p.body, p.etag, p.signature, p.lastModified, nil
+	}
+
+	all := p.source.GetAllModels()
+	published := make([]PublicModel, 0, len(all))
+	for _, m := range all {
+		published = append(published, toPublic(m))
+	}
+	sort.Slice(published, func(i, j int) bool { return published[i].ID < published[j].ID })
+
+	// GeneratedAt is the fusion time so identical catalogs render identically
+	body, err := json.Marshal(PublicCatalog{
+		Models:      published,
+		Count:       len(published),
+		GeneratedAt: p.source.LastFusion().UTC(),
+	})
+	if err != nil {
+		return nil, "", "", time.Time{}, fmt.Errorf("failed to render public catalog: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if etag != p.etag {
+		p.lastModified = time.Now().UTC().Truncate(time.Second)
+		if fused := p.source.LastFusion(); p.etag == "" && !fused.IsZero() {
+			p.lastModified = fused.UTC().Truncate(time.Second)
+		}
+		p.signature = ""
+		if p.key != nil {
+			p.signature = base64.StdEncoding.EncodeToString(ed25519.Sign(p.key, body))
+		}
+		log.Printf("[CATALOG] Public catalog rendered: %d models, etag %s", len(published), etag)
+	}
+	p.body, p.etag, p.builtAt = body, etag, time.Now()
+	return p.body, p.etag, p.signature, p.lastModified, nil
+}
+
+// Handle serves the catalog with validators for conditional requests
+func (p *Publisher) Handle(c *gin.Context) {
+	body, etag, signature, lastModified, err := p.snapshot()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to render catalog",
+		})
+		return
+	}
+
+	h := c.Writer.Header()
+	h.Set("ETag", etag)
+	h.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	h.Set("Cache-Control", publicCacheControl)
+	h.Set("Access-Control-Allow-Origin", "*")
+	h.Del("Access-Control-Allow-Credentials")
+	if signature != "" {
+		h.Set("X-Catalog-Signature", "ed25519="+signature)
+		h.Set("X-Catalog-Key-Id", p.keyID)
+	}
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// HandleKey serves the public key that verifies X-Catalog-Signature
+func (p *Publisher) HandleKey(c *gin.Context) {
+	if p.key == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Catalog signing is not configured",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", publicCacheControl)
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm":  "ed25519",
+		"key_id":     p.keyID,
+		"public_key": base64.StdEncoding.EncodeToString(p.key.Public().(ed25519.PublicKey)),
+	})
+}
+
+// notModified applies If-None-Match, falling back to If-Modified-Since
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !lastModified.After(since)
+	}
+	return false
+}
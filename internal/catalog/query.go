@@ -0,0 +1,455 @@
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Query is a compiled catalog filter expression.
+//
+// Grammar:
+//
+//	expr       := and_expr { OR and_expr }
+//	and_expr   := unary { AND unary }
+//	unary      := NOT unary | "(" expr ")" | comparison
+//	comparison := field op value | field HAS value | field IN "(" value { "," value } ")"
+//	op         := = | != | > | >= | < | <=
+//
+// Fields are scalar model attributes (provider, open_source, cost_out, ...),
+// the tags list, or capability scores addressed by category name (coding, math)
+// or as capability.<name>. Example:
+//
+//	open_source = true AND coding > 0.8 AND cost_out < 0.01
+type Query struct {
+	source string
+	root   node
+}
+
+// Parse compiles a query expression
+func Parse(source string) (*Query, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	return &Query{source: source, root: root}, nil
+}
+
+// String returns the original expression
+func (q *Query) String() string {
+	return q.source
+}
+
+// Match reports whether a model satisfies the query
+func (q *Query) Match(model models.EnhancedModel) bool {
+	return q.root.eval(model)
+}
+
+// Filter returns the models that satisfy the query, preserving order
+func (q *Query) Filter(all []models.EnhancedModel) []models.EnhancedModel {
+	matched := []models.EnhancedModel{}
+	for _, model := range all {
+		if q.Match(model) {
+			matched = append(matched, model)
+		}
+	}
+	return matched
+}
+
+// AST
+
+type node interface {
+	eval(model models.EnhancedModel) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+
+func (n andNode) eval(m models.EnhancedModel) bool { return n.left.eval(m) && n.right.eval(m) }
+func (n orNode) eval(m models.EnhancedModel) bool  { return n.left.eval(m) || n.right.eval(m) }
+func (n notNode) eval(m models.EnhancedModel) bool { return !n.inner.eval(m) }
+
+type compareNode struct {
+	field string
+	op    string
+	value literal
+}
+
+type hasNode struct {
+	field string
+	value literal
+}
+
+type inNode struct {
+	field  string
+	values []literal
+}
+
+// A comparison against a field the model does not have is false
+func (n compareNode) eval(m models.EnhancedModel) bool {
+	v, ok := resolveField(m, n.field)
+	if !ok {
+		return false
+	}
+	return compare(v, n.op, n.value)
+}
+
+func (n hasNode) eval(m models.EnhancedModel) bool {
+	list, ok := resolveList(m, n.field)
+	if !ok {
+		return false
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, n.value.text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n inNode) eval(m models.EnhancedModel) bool {
+	v, ok := resolveField(m, n.field)
+	if !ok {
+		return false
+	}
+	for _, candidate := range n.values {
+		if compare(v, "=", candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// Values
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+)
+
+type literal struct {
+	kind   valueKind
+	text   string
+	number float64
+	flag   bool
+}
+
+func compare(v literal, op string, want literal) bool {
+	switch v.kind {
+	case kindNumber:
+		if want.kind != kindNumber {
+			return false
+		}
+		switch op {
+		case "=":
+			return v.number == want.number
+		case "!=":
+			return v.number != want.number
+		case ">":
+			return v.number > want.number
+		case ">=":
+			return v.number >= want.number
+		case "<":
+			return v.number < want.number
+		case "<=":
+			return v.number <= want.number
+		}
+	case kindBool:
+		if want.kind != kindBool {
+			return false
+		}
+		switch op {
+		case "=":
+			return v.flag == want.flag
+		case "!=":
+			return v.flag != want.flag
+		}
+	case kindString:
+		a, b := strings.ToLower(v.text), strings.ToLower(want.text)
+		switch op {
+		case "=":
+			return a == b
+		case "!=":
+			return a != b
+		case ">":
+			return a > b
+		case ">=":
+			return a >= b
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		}
+	}
+	return false
+}
+
+// Tokenizer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], byte(c))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{tokString, src[i+1 : i+1+end], i})
+			i += end + 2
+		case strings.ContainsRune("=!<>", c):
+			op := string(c)
+			if i+1 < len(src) && src[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected '!' at position %d", i)
+			}
+			if op == "==" {
+				op = "="
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+			if op == "=" && i < len(src) && src[i] == '=' {
+				i++
+			}
+		case c == '-' || c == '.' || unicode.IsDigit(c):
+			start := i
+			i++
+			for i < len(src) {
+				d := src[i]
+				exponentSign := (d == '-' || d == '+') && (src[i-1] == 'e' || src[i-1] == 'E')
+				if !unicode.IsDigit(rune(d)) && d != '.' && d != 'e' && d != 'E' && !exponentSign {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, src[start:i], start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_' || src[i] == '.' || src[i] == '-') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, src[start:i], start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	tokens = append(tokens, token{tokEOF, "end of query", len(src)})
+	return tokens, nil
+}
+
+// Parser
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokIdent && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.keyword("not") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+
+	if p.peek().kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, fmt.Errorf("expected ')' at position %d, got %q", tok.pos, tok.text)
+		}
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != tokIdent {
+		return nil, fmt.Errorf("expected field name at position %d, got %q", fieldTok.pos, fieldTok.text)
+	}
+	field := strings.ToLower(fieldTok.text)
+	if !isKnownField(field) {
+		return nil, fmt.Errorf("unknown field %q at position %d", fieldTok.text, fieldTok.pos)
+	}
+
+	if p.keyword("has") {
+		if !isListField(field) {
+			return nil, fmt.Errorf("field %q does not support HAS", fieldTok.text)
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return hasNode{field: field, value: value}, nil
+	}
+
+	if p.keyword("in") {
+		if tok := p.next(); tok.kind != tokLParen {
+			return nil, fmt.Errorf("expected '(' after IN at position %d", tok.pos)
+		}
+		var values []literal
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			tok := p.next()
+			if tok.kind == tokRParen {
+				break
+			}
+			if tok.kind != tokComma {
+				return nil, fmt.Errorf("expected ',' or ')' at position %d, got %q", tok.pos, tok.text)
+			}
+		}
+		return inNode{field: field, values: values}, nil
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokOp {
+		return nil, fmt.Errorf("expected operator after %q at position %d, got %q", fieldTok.text, opTok.pos, opTok.text)
+	}
+	if isListField(field) {
+		return nil, fmt.Errorf("field %q only supports HAS", fieldTok.text)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{field: field, op: opTok.text, value: value}, nil
+}
+
+func (p *parser) parseValue() (literal, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString:
+		return literal{kind: kindString, text: tok.text}, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return literal{}, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return literal{kind: kindNumber, number: n, text: tok.text}, nil
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return literal{kind: kindBool, flag: true, text: tok.text}, nil
+		case "false":
+			return literal{kind: kindBool, flag: false, text: tok.text}, nil
+		}
+		// Bare words are accepted as strings: provider = openai
+		return literal{kind: kindString, text: tok.text}, nil
+	}
+	return literal{}, fmt.Errorf("expected value at position %d, got %q", tok.pos, tok.text)
+}
@@ -0,0 +1,240 @@
+package classification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Dimensions of a classification a corpus checks
+const (
+	DimensionTaskType   = "task_type"
+	DimensionCategory   = "category"
+	DimensionComplexity = "complexity"
+)
+
+var corpusDimensions = []string{DimensionTaskType, DimensionCategory, DimensionComplexity}
+
+// CorpusFile is a set of prompts labelled by hand for one category
+type CorpusFile struct {
+	Category    string       `json:"category"` // Expected category of cases that do not set one
+	Description string       `json:"description,omitempty"`
+	Cases       []CorpusCase `json:"cases"`
+}
+
+// CorpusCase is one prompt and the labels the classifier should give it. An
+// empty label is not checked.
+type CorpusCase struct {
+	ID         string `json:"id"`
+	Prompt     string `json:"prompt"`
+	TaskType   string `json:"task_type,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Complexity string `json:"complexity,omitempty"`
+}
+
+// CorpusReport compares a classifier's labels with the corpus
+type CorpusReport struct {
+	Cases           int                        `json:"cases"`
+	RulesGeneration int64                      `json:"rules_generation"`
+	RulesSource     string                     `json:"rules_source"`
+	Dimensions      map[string]DimensionReport `json:"dimensions"` // By task_type, category and complexity
+	Misses          []CorpusMiss               `json:"misses"`
+}
+
+// DimensionReport is the confusion matrix of one dimension
+type DimensionReport struct {
+	Checked   int                       `json:"checked"`
+	Correct   int                       `json:"correct"`
+	Accuracy  float64                   `json:"accuracy"`
+	Labels    []string                  `json:"labels"`    // Expected and predicted labels, sorted
+	Confusion map[string]map[string]int `json:"confusion"` // Expected label to predicted label to count
+	PerLabel  map[string]LabelStats     `json:"per_label"`
+}
+
+// LabelStats are the precision and recall of one label
+type LabelStats struct {
+	Support   int     `json:"support"` // Cases expecting the label
+	Predicted int     `json:"predicted"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+// CorpusMiss is a case classified differently than labelled
+type CorpusMiss struct {
+	ID        string            `json:"id"`
+	Prompt    string            `json:"prompt"`
+	Expected  map[string]string `json:"expected"`
+	Predicted map[string]string `json:"predicted"`
+}
+
+// LoadCorpus reads every *.json corpus file in dir, in name order. A missing
+// directory yields no cases.
+func LoadCorpus(dir string) ([]CorpusCase, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classifier corpus: %w", err)
+	}
+	sort.Strings(files)
+
+	var cases []CorpusCase
+	seen := make(map[string]bool)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read classifier corpus %s: %w", file, err)
+		}
+		var corpus CorpusFile
+		if err := json.Unmarshal(data, &corpus); err != nil {
+			return nil, fmt.Errorf("failed to parse classifier corpus %s: %w", file, err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		for i, c := range corpus.Cases {
+			if c.ID == "" {
+				c.ID = fmt.Sprintf("case_%d", i+1)
+			}
+			c.ID = name + "/" + c.ID
+			if seen[c.ID] {
+				return nil, fmt.Errorf("classifier corpus %s: duplicate case id %s", file, c.ID)
+			}
+			seen[c.ID] = true
+			if strings.TrimSpace(c.Prompt) == "" {
+				return nil, fmt.Errorf("classifier corpus %s case %s: prompt is required", file, c.ID)
+			}
+			if c.Category == "" {
+				c.Category = corpus.Category
+			}
+			c.Category = models.CanonicalCapability(c.Category)
+			cases = append(cases, c)
+		}
+	}
+	return cases, nil
+}
+
+// EvaluateCorpus classifies every case and reports a confusion matrix per
+// dimension. With rules nil the active rules are used; otherwise rules are
+// merged over the built-in ones and compiled, so a draft can be measured
+// before it is activated.
+func (tc *TaskClassifier) EvaluateCorpus(ctx context.Context, cases []CorpusCase, rules *RuleConfig) (CorpusReport, error) {
+	set := tc.rules.Load()
+	if rules != nil {
+		compiled, err := WithDefaults(*rules).compile()
+		if err != nil {
+			return CorpusReport{}, fmt.Errorf("invalid classifier rules: %w", err)
+		}
+		compiled.status.Source = "draft"
+		set = compiled
+	}
+
+	report := CorpusReport{
+		Cases:           len(cases),
+		RulesGeneration: set.status.Generation,
+		RulesSource:     set.status.Source,
+		Dimensions:      make(map[string]DimensionReport, len(corpusDimensions)),
+		Misses:          []CorpusMiss{},
+	}
+	confusion := make(map[string]map[string]map[string]int, len(corpusDimensions))
+	for _, dim := range corpusDimensions {
+		confusion[dim] = make(map[string]map[string]int)
+	}
+
+	for _, c := range cases {
+		result, err := tc.classifyWith(ctx, set, c.Prompt)
+		if err != nil {
+			return report, err
+		}
+
+		expected := map[string]string{
+			DimensionTaskType:   c.TaskType,
+			DimensionCategory:   c.Category,
+			DimensionComplexity: c.Complexity,
+		}
+		predicted := map[string]string{
+			DimensionTaskType:   result.TaskType,
+			DimensionCategory:   result.Category,
+			DimensionComplexity: result.Complexity,
+		}
+
+		missed := false
+		for _, dim := range corpusDimensions {
+			want := expected[dim]
+			if want == "" {
+				continue
+			}
+			if confusion[dim][want] == nil {
+				confusion[dim][want] = make(map[string]int)
+			}
+			confusion[dim][want][predicted[dim]]++
+			if predicted[dim] != want {
+				missed = true
+			}
+		}
+		if missed {
+			report.Misses = append(report.Misses, CorpusMiss{
+				ID:        c.ID,
+				Prompt:    c.Prompt,
+				Expected:  expected,
+				Predicted: predicted,
+			})
+		}
+	}
+
+	for _, dim := range corpusDimensions {
+		report.Dimensions[dim] = summarizeConfusion(confusion[dim])
+	}
+	return report, nil
+}
+
+// summarizeConfusion derives accuracy, precision and recall from a matrix
+func summarizeConfusion(confusion map[string]map[string]int) DimensionReport {
+	report := DimensionReport{
+		Confusion: confusion,
+		PerLabel:  make(map[string]LabelStats),
+	}
+
+	labels := make(map[string]bool)
+	for want, row := range confusion {
+		labels[want] = true
+		for got, n := range row {
+			labels[got] = true
+			report.Checked += n
+			if got == want {
+				report.Correct += n
+			}
+
+			expected := report.PerLabel[want]
+			expected.Support += n
+			report.PerLabel[want] = expected
+			predicted := report.PerLabel[got]
+			predicted.Predicted += n
+			report.PerLabel[got] = predicted
+		}
+	}
+	for label := range labels {
+		report.Labels = append(report.Labels, label)
+	}
+	sort.Strings(report.Labels)
+
+	for label, stats := range report.PerLabel {
+		correct := confusion[label][label]
+		stats.Precision = ratio(correct, stats.Predicted)
+		stats.Recall = ratio(correct, stats.Support)
+		report.PerLabel[label] = stats
+	}
+	report.Accuracy = ratio(report.Correct, report.Checked)
+	return report
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(d)*1000) / 1000
+}
@@ -0,0 +1,39 @@
+package classification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// healthProbe is classified by the health check
+const healthProbe = "Write a function that reverses a string"
+
+// HealthCheck reports whether the classifier has rules loaded and classifies
+// a probe prompt in time; routing cannot work without it
+func (tc *TaskClassifier) HealthCheck() health.Check {
+	return health.Check{
+		Name:     "classifier",
+		Critical: true,
+		Timeout:  500 * time.Millisecond,
+		Run: func(ctx context.Context) health.Result {
+			rules := tc.Rules()
+			result, err := tc.ClassifyPromptContext(ctx, healthProbe)
+			if err != nil {
+				return health.Down(fmt.Sprintf("failed to classify probe: %v", err))
+			}
+			if result.TaskType == "" || result.Category == "" {
+				return health.Down("probe was not classified")
+			}
+			check := health.OK(fmt.Sprintf("rules generation %d", rules.Generation))
+			check.Details = map[string]interface{}{
+				"generation": rules.Generation,
+				"source":     rules.Source,
+				"loaded_at":  rules.LoadedAt,
+			}
+			return check
+		},
+	}
+}
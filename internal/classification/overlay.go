@@ -0,0 +1,104 @@
+package classification
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Bounds on a tenant rule overlay, which is compiled on the request path
+const (
+	maxOverlayPatterns      = 200
+	maxOverlayPatternLength = 500
+)
+
+// TenantRules are a tenant's rule overlay compiled over one generation of
+// the base rules. Compile again once Rules reports a newer generation.
+type TenantRules struct {
+	rules          *ruleSet
+	BaseGeneration int64
+}
+
+// ValidateOverlay checks a tenant overlay against the active rules. An
+// overlay may only re-pattern labels the base rules already have, so every
+// category it assigns is one the catalog is scored on.
+func (tc *TaskClassifier) ValidateOverlay(overlay RuleConfig) error {
+	base := tc.rules.Load().config
+
+	patterns := 0
+	for group, labels := range overlay.Patterns {
+		if !ruleGroups[group] {
+			return fmt.Errorf("unknown rule group %q", group)
+		}
+		for label, expressions := range labels {
+			if _, ok := base.Patterns[group][label]; !ok {
+				if _, ok := base.Terms[group][label]; !ok {
+					return fmt.Errorf("%s has no label %q", group, label)
+				}
+			}
+			for i, expr := range expressions {
+				if len(expr) > maxOverlayPatternLength {
+					return fmt.Errorf("%s.%s[%d] is longer than %d characters", group, label, i, maxOverlayPatternLength)
+				}
+			}
+			patterns += len(expressions)
+		}
+	}
+	for group, labels := range overlay.Terms {
+		if !ruleGroups[group] {
+			return fmt.Errorf("unknown rule group %q", group)
+		}
+		for label, terms := range labels {
+			if _, ok := base.Patterns[group][label]; !ok {
+				if _, ok := base.Terms[group][label]; !ok {
+					return fmt.Errorf("%s has no label %q", group, label)
+				}
+			}
+			for i, term := range terms {
+				if len(term) > maxOverlayPatternLength {
+					return fmt.Errorf("terms.%s.%s[%d] is longer than %d characters", group, label, i, maxOverlayPatternLength)
+				}
+			}
+			patterns += len(terms)
+		}
+	}
+	for level, indicators := range overlay.ComplexityIndicators {
+		if _, ok := base.ComplexityIndicators[level]; !ok {
+			return fmt.Errorf("unknown complexity level %q", level)
+		}
+		patterns += len(indicators)
+	}
+	if patterns > maxOverlayPatterns {
+		return fmt.Errorf("overlay has %d patterns; at most %d are allowed", patterns, maxOverlayPatterns)
+	}
+	return nil
+}
+
+// CompileOverlay merges overlay over the active rules, as a rules file is
+// merged over the built-in ones: a label the overlay lists replaces that
+// label's patterns
+func (tc *TaskClassifier) CompileOverlay(overlay RuleConfig) (*TenantRules, error) {
+	if err := tc.ValidateOverlay(overlay); err != nil {
+		return nil, err
+	}
+	base := tc.rules.Load()
+	rules, err := mergeRules(base.config, overlay).compile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid classifier rules: %w", err)
+	}
+	rules.status.Generation = base.status.Generation
+	rules.status.Source = base.status.Source + " with tenant overlay"
+	rules.status.LoadedAt = time.Now()
+	return &TenantRules{rules: rules, BaseGeneration: base.status.Generation}, nil
+}
+
+// ClassifyPromptWith classifies with a tenant's compiled rules, or with the
+// active rules when tenant is nil
+func (tc *TaskClassifier) ClassifyPromptWith(ctx context.Context, prompt string, tenant *TenantRules) (ClassificationResult, error) {
+	if tenant == nil {
+		return tc.ClassifyPromptContext(ctx, prompt)
+	}
+	result, err := tc.classifyWith(ctx, tenant.rules, prompt)
+	result.RulesOverlay = true
+	return result, err
+}
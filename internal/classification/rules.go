@@ -0,0 +1,369 @@
+package classification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// ruleGroups are the pattern groups the classifier reads: task types,
+// categories and a subcategory group for each category that has subcategories
+var ruleGroups = func() map[string]bool {
+	groups := map[string]bool{"task_type": true, "category": true}
+	for category := range models.SubcategoryTaxonomy() {
+		groups[SubcategoryGroup(category)] = true
+	}
+	return groups
+}()
+
+// SubcategoryGroup names the rule group holding a category's subcategory
+// patterns, e.g. "coding_subcategory"
+func SubcategoryGroup(category string) string {
+	return category + "_subcategory"
+}
+
+// IsRuleGroup reports whether the classifier reads the pattern group
+func IsRuleGroup(group string) bool {
+	return ruleGroups[group]
+}
+
+// RuleConfig is the classifier's rules in editable form: regular expressions
+// and plain terms by group and label, per-label weights and thresholds, and
+// complexity indicator phrases by level
+type RuleConfig struct {
+	Patterns             map[string]map[string][]string `json:"patterns"`
+	Terms                map[string]map[string][]string `json:"terms,omitempty"`      // Phrases matched case-insensitively as whole words
+	Weights              map[string]map[string]float64  `json:"weights,omitempty"`    // Multiplies a label's match score; 1 when unset
+	Thresholds           map[string]map[string]float64  `json:"thresholds,omitempty"` // Score a label needs to be chosen; 0 when unset
+	ComplexityIndicators map[string][]string            `json:"complexity_indicators"`
+}
+
+// maxRuleWeight bounds label weights and thresholds
+const maxRuleWeight = 10
+
+// RulesStatus identifies the rules a classification used
+type RulesStatus struct {
+	Generation int64          `json:"generation"` // Increases with every reload
+	Source     string         `json:"source"`     // "builtin" or the rules file
+	LoadedAt   time.Time      `json:"loaded_at"`
+	Labels     map[string]int `json:"labels"` // Patterns per group
+}
+
+// ruleSet is a compiled RuleConfig. It is never modified once published;
+// reloads build a new one and swap the pointer, so a classification reads one
+// consistent rule set however reloads interleave with it.
+type ruleSet struct {
+	config               RuleConfig
+	patterns             map[string]map[string][]*regexp.Regexp
+	weights              map[string]map[string]float64
+	thresholds           map[string]map[string]float64
+	complexityIndicators map[string][]string
+	status               RulesStatus
+}
+
+// score applies a label's weight and threshold to its raw match score
+func (rules *ruleSet) score(group, label string, raw float64) float64 {
+	if weight, ok := rules.weights[group][label]; ok {
+		raw *= weight
+	}
+	if raw < rules.thresholds[group][label] {
+		return 0
+	}
+	return raw
+}
+
+// compile validates the config and compiles its patterns into a rule set that
+// shares nothing with cfg
+func (cfg RuleConfig) compile() (*ruleSet, error) {
+	cfg = mergeRules(RuleConfig{}, cfg)
+	rules := &ruleSet{
+		config:               cfg,
+		patterns:             make(map[string]map[string][]*regexp.Regexp, len(cfg.Patterns)),
+		weights:              cfg.Weights,
+		thresholds:           cfg.Thresholds,
+		complexityIndicators: cfg.ComplexityIndicators,
+		status:               RulesStatus{Labels: make(map[string]int, len(cfg.Patterns))},
+	}
+	for group, labels := range cfg.Patterns {
+		if !ruleGroups[group] {
+			return nil, fmt.Errorf("unknown rule group %q", group)
+		}
+		rules.patterns[group] = make(map[string][]*regexp.Regexp, len(labels))
+		for label, expressions := range labels {
+			compiled := make([]*regexp.Regexp, 0, len(expressions))
+			for i, expr := range expressions {
+				re, err := regexp.Compile(expr)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s[%d]: %w", group, label, i, err)
+				}
+				compiled = append(compiled, re)
+			}
+			rules.patterns[group][label] = compiled
+			rules.status.Labels[group] += len(compiled)
+		}
+	}
+	for group, labels := range cfg.Terms {
+		if !ruleGroups[group] {
+			return nil, fmt.Errorf("unknown rule group %q", group)
+		}
+		if rules.patterns[group] == nil {
+			rules.patterns[group] = make(map[string][]*regexp.Regexp, len(labels))
+		}
+		for label, terms := range labels {
+			for i, term := range terms {
+				if strings.TrimSpace(term) == "" {
+					return nil, fmt.Errorf("terms.%s.%s[%d] is empty", group, label, i)
+				}
+				rules.patterns[group][label] = append(rules.patterns[group][label], termPattern(term))
+			}
+			rules.status.Labels[group] += len(terms)
+		}
+	}
+	for name, values := range map[string]map[string]map[string]float64{"weights": cfg.Weights, "thresholds": cfg.Thresholds} {
+		for group, labels := range values {
+			for label, v := range labels {
+				if _, ok := rules.patterns[group][label]; !ok {
+					return nil, fmt.Errorf("%s.%s.%s: %s has no label %q", name, group, label, group, label)
+				}
+				if v < 0 || v > maxRuleWeight {
+					return nil, fmt.Errorf("%s.%s.%s must be between 0 and %d", name, group, label, maxRuleWeight)
+				}
+			}
+		}
+	}
+	for _, group := range []string{"task_type", "category"} {
+		if len(rules.patterns[group]) == 0 {
+			return nil, fmt.Errorf("rule group %q has no labels", group)
+		}
+	}
+	return rules, nil
+}
+
+// termPattern matches a plain term case-insensitively, as a whole word where
+// it starts or ends with a word character
+func termPattern(term string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(strings.TrimSpace(term))
+	if wordChar.MatchString(expr[:1]) {
+		expr = `\b` + expr
+	}
+	if wordChar.MatchString(expr[len(expr)-1:]) {
+		expr += `\b`
+	}
+	return regexp.MustCompile("(?i)" + expr)
+}
+
+var wordChar = regexp.MustCompile(`\w`)
+
+// Validate compiles the rules, reporting the first invalid pattern, term,
+// weight or threshold
+func (cfg RuleConfig) Validate() error {
+	_, err := cfg.compile()
+	return err
+}
+
+// WithDefaults returns cfg merged over the built-in rules, as a rules file is
+func WithDefaults(cfg RuleConfig) RuleConfig {
+	return mergeRules(DefaultRuleConfig(), cfg)
+}
+
+// mergeRules returns a copy of base overlaid with cfg: a label listed in cfg
+// replaces that label's patterns, terms, weight or threshold, and labels cfg
+// does not mention keep the base ones
+func mergeRules(base, cfg RuleConfig) RuleConfig {
+	merged := RuleConfig{
+		Patterns:             make(map[string]map[string][]string, len(base.Patterns)),
+		Terms:                make(map[string]map[string][]string),
+		Weights:              make(map[string]map[string]float64),
+		Thresholds:           make(map[string]map[string]float64),
+		ComplexityIndicators: make(map[string][]string, len(base.ComplexityIndicators)),
+	}
+	for _, source := range []RuleConfig{base, cfg} {
+		for group, labels := range source.Patterns {
+			if merged.Patterns[group] == nil {
+				merged.Patterns[group] = make(map[string][]string, len(labels))
+			}
+			for label, expressions := range labels {
+				merged.Patterns[group][label] = append([]string(nil), expressions...)
+			}
+		}
+		for group, labels := range source.Terms {
+			if merged.Terms[group] == nil {
+				merged.Terms[group] = make(map[string][]string, len(labels))
+			}
+			for label, terms := range labels {
+				merged.Terms[group][label] = append([]string(nil), terms...)
+			}
+		}
+		for _, pair := range [][2]map[string]map[string]float64{{merged.Weights, source.Weights}, {merged.Thresholds, source.Thresholds}} {
+			into, from := pair[0], pair[1]
+			for group, labels := range from {
+				if into[group] == nil {
+					into[group] = make(map[string]float64, len(labels))
+				}
+				for label, v := range labels {
+					into[group][label] = v
+				}
+			}
+		}
+		for level, indicators := range source.ComplexityIndicators {
+			merged.ComplexityIndicators[level] = append([]string(nil), indicators...)
+		}
+	}
+	return merged
+}
+
+// LoadRules reads rules from a JSON file, merged over the built-in rules, and
+// reloads it whenever it changes
+func (tc *TaskClassifier) LoadRules(ctx context.Context, path string, interval time.Duration) error {
+	tc.path = path
+	if err := tc.Reload(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	tc.reloadMu.Lock()
+	tc.stopWatch = cancel
+	tc.reloadMu.Unlock()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				tc.reloadMu.Lock()
+				changed := !info.ModTime().Equal(tc.modTime)
+				tc.reloadMu.Unlock()
+				if changed {
+					if err := tc.Reload(); err != nil {
+						log.Printf("[CLASSIFIER] Keeping rules generation %d: %v", tc.Rules().Generation, err)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Reload re-reads the rules file. Without one it rebuilds the built-in rules.
+func (tc *TaskClassifier) Reload() error {
+	// Held throughout so concurrent reloads cannot publish older file contents
+	// under a newer generation
+	tc.reloadMu.Lock()
+	defer tc.reloadMu.Unlock()
+
+	if tc.path == "" {
+		_, err := tc.swap(DefaultRuleConfig(), "builtin")
+		return err
+	}
+
+	info, err := os.Stat(tc.path)
+	if err != nil {
+		return fmt.Errorf("failed to read classifier rules: %w", err)
+	}
+	cfg, err := ReadRuleFile(tc.path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tc.swap(mergeRules(DefaultRuleConfig(), cfg), tc.path); err != nil {
+		return err
+	}
+	tc.modTime = info.ModTime()
+	return nil
+}
+
+// ReadRuleFile parses a rules file without applying it
+func ReadRuleFile(path string) (RuleConfig, error) {
+	var cfg RuleConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read classifier rules: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse classifier rules: %w", err)
+	}
+	return cfg, nil
+}
+
+// StopWatching stops reloading the rules file, once rules are managed
+// elsewhere such as in the database
+func (tc *TaskClassifier) StopWatching() {
+	tc.reloadMu.Lock()
+	defer tc.reloadMu.Unlock()
+	if tc.stopWatch != nil {
+		tc.stopWatch()
+		tc.stopWatch = nil
+	}
+}
+
+// ReplaceRules compiles cfg and swaps it in for subsequent classifications,
+// returning its generation. Invalid rules leave the current rules in place.
+func (tc *TaskClassifier) ReplaceRules(cfg RuleConfig, source string) (int64, error) {
+	tc.reloadMu.Lock()
+	defer tc.reloadMu.Unlock()
+	return tc.swap(cfg, source)
+}
+
+// swap publishes a new rule set; callers hold reloadMu so generations
+// increase in publication order
+func (tc *TaskClassifier) swap(cfg RuleConfig, source string) (int64, error) {
+	rules, err := cfg.compile()
+	if err != nil {
+		return 0, fmt.Errorf("invalid classifier rules: %w", err)
+	}
+
+	tc.generation++
+	rules.status.Generation = tc.generation
+	rules.status.Source = source
+	rules.status.LoadedAt = time.Now()
+	tc.rules.Store(rules)
+
+	log.Printf("[CLASSIFIER] Loaded rules generation %d from %s", rules.status.Generation, source)
+	return rules.status.Generation, nil
+}
+
+// Rules returns the generation and source of the active rules
+func (tc *TaskClassifier) Rules() RulesStatus {
+	status := tc.rules.Load().status
+	labels := make(map[string]int, len(status.Labels))
+	for group, n := range status.Labels {
+		labels[group] = n
+	}
+	status.Labels = labels
+	return status
+}
+
+// Generation returns the generation of the active rules
+func (tc *TaskClassifier) Generation() int64 {
+	return tc.rules.Load().status.Generation
+}
+
+// RuleConfig returns the active rules in editable form
+func (tc *TaskClassifier) RuleConfig() RuleConfig {
+	return mergeRules(RuleConfig{}, tc.rules.Load().config)
+}
+
+// labels returns a group's labels in a fixed order
+func (rules *ruleSet) labels(group string) []string {
+	labels := make([]string, 0, len(rules.patterns[group]))
+	for label := range rules.patterns[group] {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
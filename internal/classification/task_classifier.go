@@ -1,171 +1,283 @@
 package classification
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Askeban/llm-router-go/internal/models"
 	"github.com/Askeban/llm-router-go/internal/recommendation"
 )
 
 // TaskClassifier analyzes user prompts to determine task type, category, and complexity
 type TaskClassifier struct {
-	// Pattern matchers for different task types and categories
-	patterns map[string]map[string][]*regexp.Regexp
-	
-	// Complexity indicators
-	complexityIndicators map[string][]string
+	// Active rules, swapped whole on reload
+	rules atomic.Pointer[ruleSet]
+	
+	// Serializes reloads and guards the rules file state
+	reloadMu   sync.Mutex
+	generation int64
+	path       string
+	modTime    time.Time
+	stopWatch  context.CancelFunc
 }
 
 // ClassificationResult represents the analysis of a user prompt
 type ClassificationResult struct {
 	TaskType           string                 `json:"task_type"`
 	Category           string                 `json:"category"`
+	Subcategory        string                 `json:"subcategory,omitempty"` // Narrower task within the category, e.g. "marketing_copy" for writing
 	Complexity         string                 `json:"complexity"`
+	ComplexityScore    float64                `json:"complexity_score"` // 0–1, within the complexity level's band
 	Priority           string                 `json:"priority"`
 	Requirements       map[string]interface{} `json:"requirements"`
 	Confidence         float64                `json:"confidence"`
 	DetectedKeywords   []string               `json:"detected_keywords"`
 	ReasoningSteps     []string               `json:"reasoning_steps"`
+	ReasoningDepth     string                 `json:"reasoning_depth"` // "none", "low", "medium", "high"
+	RulesGeneration    int64                  `json:"rules_generation"` // Generation of the rules that classified the prompt
+	CategoryFallback   bool                   `json:"category_fallback,omitempty"` // No category rule matched, so the task type's default was used
+	RulesOverlay       bool                   `json:"rules_overlay,omitempty"` // The tenant's rule overlay was merged over the rules
+	RunnerUpCategory   string                 `json:"runner_up_category,omitempty"` // Next best matching category
 }
 
 func NewTaskClassifier() *TaskClassifier {
-	tc := &TaskClassifier{
-		patterns:             make(map[string]map[string][]*regexp.Regexp),
-		complexityIndicators: make(map[string][]string),
+	tc := &TaskClassifier{}
+	if _, err := tc.ReplaceRules(DefaultRuleConfig(), "builtin"); err != nil {
+		panic(err) // The built-in rules are compiled at every start
 	}
-	
-	tc.initializePatterns()
-	tc.initializeComplexityIndicators()
-	
 	return tc
 }
 
-func (tc *TaskClassifier) initializePatterns() {
+// DefaultRuleConfig returns the built-in classifier rules
+func DefaultRuleConfig() RuleConfig {
+	cfg := RuleConfig{
+		Patterns:             make(map[string]map[string][]string),
+		ComplexityIndicators: make(map[string][]string),
+	}
+
 	// Initialize task type patterns
-	tc.patterns["task_type"] = make(map[string][]*regexp.Regexp)
+	cfg.Patterns["task_type"] = make(map[string][]string)
 	
 	// Image generation patterns (put first to prioritize over text)
-	tc.patterns["task_type"]["image"] = []*regexp.Regexp{
+	cfg.Patterns["task_type"]["image"] = []string{
 		// High priority - explicit image generation phrases
-		regexp.MustCompile(`(?i)\b(generate|create|make|draw|paint|sketch|render|design|produce)\s+.*\b(image|picture|photo|visual|graphic|artwork|illustration)\b`),
-		regexp.MustCompile(`(?i)\b(create|generate|make)\s+.*\b(marketing|promotional|advertising)\s+.*\b(image|visual|graphic)\b`),
+		`(?i)\b(generate|create|make|draw|paint|sketch|render|design|produce)\s+.*\b(image|picture|photo|visual|graphic|artwork|illustration)\b`,
+		`(?i)\b(create|generate|make)\s+.*\b(marketing|promotional|advertising)\s+.*\b(image|visual|graphic)\b`,
 		// Medium priority - image-related nouns
-		regexp.MustCompile(`(?i)\b(image|picture|photo|drawing|illustration|artwork|visual|graphic|design)\b`),
-		regexp.MustCompile(`(?i)\b(logo|poster|banner|icon|avatar|thumbnail|wallpaper|background)\b`),
-		regexp.MustCompile(`(?i)\b(photorealistic|artistic|cartoon|3d|digital\s+art|concept\s+art)\b`),
+		`(?i)\b(image|picture|photo|drawing|illustration|artwork|visual|graphic|design)\b`,
+		`(?i)\b(logo|poster|banner|icon|avatar|thumbnail|wallpaper|background)\b`,
+		`(?i)\b(photorealistic|artistic|cartoon|3d|digital\s+art|concept\s+art)\b`,
 		// Specific image tasks
-		regexp.MustCompile(`(?i)\b(professional|marketing|social\s+media|instagram|facebook)\s+.*\b(image|visual|photo|graphic)\b`),
+		`(?i)\b(professional|marketing|social\s+media|instagram|facebook)\s+.*\b(image|visual|photo|graphic)\b`,
 	}
 	
 	// Text task patterns (refined to avoid conflict with image generation)
-	tc.patterns["task_type"]["text"] = []*regexp.Regexp{
+	cfg.Patterns["task_type"]["text"] = []string{
 		// Pure text tasks without visual components
-		regexp.MustCompile(`(?i)\b(write|compose|draft|explain|analyze|summarize|translate|answer|help|assist)\b`),
-		regexp.MustCompile(`(?i)\b(code|program|script|function|algorithm|debug|fix|review)\b`),
-		regexp.MustCompile(`(?i)\b(math|calculate|solve|equation|formula|compute|statistics)\b`),
-		regexp.MustCompile(`(?i)\b(essay|article|blog|story|report|email|letter|documentation|content)\b`),
+		`(?i)\b(write|compose|draft|explain|analyze|summarize|translate|answer|help|assist)\b`,
+		`(?i)\b(code|program|script|function|algorithm|debug|fix|review)\b`,
+		`(?i)\b(math|calculate|solve|equation|formula|compute|statistics)\b`,
+		`(?i)\b(essay|article|blog|story|report|email|letter|documentation|content)\b`,
 		// Text-specific generation
-		regexp.MustCompile(`(?i)\b(generate|create)\s+.*\b(text|content|copy|description|caption)\b`),
+		`(?i)\b(generate|create)\s+.*\b(text|content|copy|description|caption)\b`,
 	}
 	
 	// Video generation patterns
-	tc.patterns["task_type"]["video"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(video|movie|clip|animation|film|commercial|trailer)\b`),
-		regexp.MustCompile(`(?i)\b(generate|create|make|produce)\s+.*\b(video|animation)\b`),
-		regexp.MustCompile(`(?i)\b(cinematic|motion|sequence|scene|footage)\b`),
+	cfg.Patterns["task_type"]["video"] = []string{
+		`(?i)\b(video|movie|clip|animation|film|commercial|trailer)\b`,
+		`(?i)\b(generate|create|make|produce)\s+.*\b(video|animation)\b`,
+		`(?i)\b(cinematic|motion|sequence|scene|footage)\b`,
 	}
 	
 	// Audio generation patterns
-	tc.patterns["task_type"]["audio"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(audio|sound|voice|speech|music|song|narration)\b`),
-		regexp.MustCompile(`(?i)\b(generate|create|make|synthesize)\s+.*\b(audio|voice|music)\b`),
-		regexp.MustCompile(`(?i)\b(voiceover|podcast|jingle|soundbite|tts|text.to.speech)\b`),
+	cfg.Patterns["task_type"]["audio"] = []string{
+		`(?i)\b(audio|sound|voice|speech|music|song|narration)\b`,
+		`(?i)\b(generate|create|make|synthesize)\s+.*\b(audio|voice|music)\b`,
+		`(?i)\b(voiceover|podcast|jingle|soundbite|tts|text.to.speech)\b`,
 	}
 	
 	// Initialize category patterns
-	tc.patterns["category"] = make(map[string][]*regexp.Regexp)
+	cfg.Patterns["category"] = make(map[string][]string)
 	
 	// Coding category
-	tc.patterns["category"]["coding"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(code|program|script|function|class|method|algorithm|api|framework|library)\b`),
-		regexp.MustCompile(`(?i)\b(python|javascript|java|go|rust|c\+\+|typescript|react|node|django)\b`),
-		regexp.MustCompile(`(?i)\b(debug|fix|optimize|refactor|implement|develop|build|deploy)\b`),
-		regexp.MustCompile(`(?i)\b(database|sql|rest|graphql|docker|kubernetes|git)\b`),
+	cfg.Patterns["category"]["coding"] = []string{
+		`(?i)\b(code|program|script|function|class|method|algorithm|api|framework|library)\b`,
+		`(?i)\b(python|javascript|java|go|rust|c\+\+|typescript|react|node|django)\b`,
+		`(?i)\b(debug|fix|optimize|refactor|implement|develop|build|deploy)\b`,
+		`(?i)\b(database|sql|rest|graphql|docker|kubernetes|git)\b`,
+		`(?i)\b(golang|goroutines?|pandas|numpy|dataframe|jupyter)\b`,
+		// Everyday words like "query" and "join" only count in a code context
+		`(?i)\b((sql|database|graphql)\s+quer(y|ies)|(inner|left|right|full|outer|cross)\s+join|html\s+(page|element|tag|form|template|markup)|css\s+(class|selector|grid|flexbox|file|rule|styles?))\b`,
 	}
 	
 	// Math category  
-	tc.patterns["category"]["math"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(math|mathematics|calculate|solve|equation|formula|algebra|calculus|statistics)\b`),
-		regexp.MustCompile(`(?i)\b(integral|derivative|matrix|vector|probability|geometry|trigonometry)\b`),
-		regexp.MustCompile(`(?i)\b(compute|numerical|analytical|mathematical|quantitative)\b`),
+	cfg.Patterns["category"]["math"] = []string{
+		`(?i)\b(math|mathematics|calculate|solve|equation|formula|algebra|calculus|statistics)\b`,
+		`(?i)\b(integral|derivative|matrix|vector|probability|geometry|trigonometry)\b`,
+		`(?i)\b(compute|numerical|analytical|mathematical|quantitative)\b`,
 	}
 	
 	// Reasoning category
-	tc.patterns["category"]["reasoning"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(analyze|reason|logic|evaluate|assess|compare|contrast|examine)\b`),
-		regexp.MustCompile(`(?i)\b(argument|evidence|conclusion|inference|deduction|critical thinking)\b`),
-		regexp.MustCompile(`(?i)\b(problem solving|decision|strategy|planning|optimization)\b`),
+	cfg.Patterns["category"]["reasoning"] = []string{
+		`(?i)\b(analyze|reason|logic|evaluate|assess|compare|contrast|examine)\b`,
+		`(?i)\b(argument|evidence|conclusion|inference|deduction|critical thinking)\b`,
+		`(?i)\b(problem solving|decision|strategy|planning|optimization)\b`,
 	}
 	
 	// Writing category
-	tc.patterns["category"]["writing"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(write|compose|draft|create|author|edit|proofread|rewrite)\b`),
-		regexp.MustCompile(`(?i)\b(essay|article|blog|story|report|email|letter|content|copy)\b`),
-		regexp.MustCompile(`(?i)\b(creative writing|technical writing|copywriting|journalism)\b`),
+	cfg.Patterns["category"]["writing"] = []string{
+		`(?i)\b(write|compose|draft|create|author|edit|proofread|rewrite)\b`,
+		`(?i)\b(essay|article|blog|story|report|email|letter|content|copy)\b`,
+		`(?i)\b(creative writing|technical writing|copywriting|journalism)\b`,
 	}
 	
 	// Analysis category
-	tc.patterns["category"]["analysis"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(analyze|analysis|examine|evaluate|assess|review|investigate)\b`),
-		regexp.MustCompile(`(?i)\b(data|trend|pattern|insight|interpretation|conclusion)\b`),
-		regexp.MustCompile(`(?i)\b(research|study|survey|findings|results|metrics)\b`),
+	cfg.Patterns["category"]["analysis"] = []string{
+		`(?i)\b(analyze|analysis|examine|evaluate|assess|review|investigate)\b`,
+		`(?i)\b(data|trend|pattern|insight|interpretation|conclusion)\b`,
+		`(?i)\b(research|study|survey|findings|results|metrics)\b`,
 	}
 	
 	// Creative category (for generative tasks)
-	tc.patterns["category"]["creative"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(creative|artistic|imaginative|original|unique|innovative)\b`),
-		regexp.MustCompile(`(?i)\b(art|design|style|aesthetic|beautiful|colorful|abstract)\b`),
+	cfg.Patterns["category"]["creative"] = []string{
+		`(?i)\b(creative|artistic|imaginative|original|unique|innovative)\b`,
+		`(?i)\b(art|design|style|aesthetic|beautiful|colorful|abstract)\b`,
+	}
+	
+	// Subcategories, matched only once a prompt is classified into their category
+	cfg.Patterns[SubcategoryGroup(models.CapabilityCoding)] = map[string][]string{
+		models.SubcategorySQL: {
+			`(?i)\b(sql|postgres(ql)?|mysql|sqlite|t-sql|pl/pgsql|stored procedure|cte)\b`,
+			`(?i)\b(select\s+.+\s+from|inner join|left join|group by|order by|where clause)\b`,
+			`(?i)\b(query|queries|table|schema|index|migration)\b`,
+		},
+		models.SubcategoryFrontend: {
+			`(?i)\b(react|vue|angular|svelte|next\.js|nextjs|tailwind|jsx|tsx)\b`,
+			`(?i)\b(html|css|dom|browser|component|responsive|ui|ux|frontend|front-end)\b`,
+			`(?i)\b(typescript|javascript)\b`,
+		},
+		models.SubcategoryBackend: {
+			`(?i)\b(django|flask|fastapi|express|spring|rails|laravel|gin|node\.?js)\b`,
+			`(?i)\b(rest|graphql|grpc|endpoint|middleware|microservices?|backend|back-end|server)\b`,
+			`(?i)\b(auth|oauth|jwt|session|webhook|queue)\b`,
+		},
+		models.SubcategorySystems: {
+			`(?i)\b(golang|goroutines?|rust|cargo|c\+\+|embedded|kernel|firmware)\b`,
+			`(?i)\b(go\s+(code|program|function|module|service|package)|in go)\b`,
+			`(?i)\b(memory|pointer|mutex|lock-free|concurrency|allocator|syscall|assembly)\b`,
+		},
+		models.SubcategoryDataScience: {
+			`(?i)\b(pandas|numpy|scipy|matplotlib|seaborn|scikit-learn|sklearn|jupyter|notebook)\b`,
+			`(?i)\b(pytorch|tensorflow|keras|dataframe|dataset|feature engineering)\b`,
+			`(?i)\b(regression|classification model|clustering|training data|plot)\b`,
+		},
+		models.SubcategoryDevOps: {
+			`(?i)\b(docker(file)?|kubernetes|k8s|helm|terraform|ansible|pulumi)\b`,
+			`(?i)\b(ci/cd|ci pipeline|github actions|gitlab ci|jenkins|deployment|devops)\b`,
+			`(?i)\b(nginx|bash script|shell script|yaml manifest|infrastructure as code)\b`,
+		},
+	}
+	cfg.Patterns[SubcategoryGroup(models.CapabilityWriting)] = map[string][]string{
+		models.SubcategoryMarketingCopy: {
+			`(?i)\b(marketing|ad copy|advert|tagline|slogan|landing page|product description)\b`,
+			`(?i)\b(campaign|brand|call to action|cta|seo|newsletter|social media post)\b`,
+		},
+		models.SubcategoryTechnicalWriting: {
+			`(?i)\b(documentation|readme|user guide|manual|api docs?|tutorial|how-to guide)\b`,
+			`(?i)\b(release notes|changelog|runbook|specification|technical writing)\b`,
+		},
+		models.SubcategoryAcademicWriting: {
+			`(?i)\b(essay|thesis|dissertation|abstract|literature review|research paper)\b`,
+			`(?i)\b(citations?|references|bibliography|academic|apa|mla)\b`,
+		},
+		models.SubcategoryBusinessEmail: {
+			`(?i)\b(e-?mail|cover letter|memo|follow-up|reply to|out of office)\b`,
+			`(?i)\b(dear|regards|colleague|client|manager|meeting request)\b`,
+		},
+	}
+	cfg.Patterns[SubcategoryGroup(models.CapabilityMath)] = map[string][]string{
+		models.SubcategoryStatistics: {
+			`(?i)\b(statistics|statistical|probability|distribution|variance|standard deviation)\b`,
+			`(?i)\b(mean|median|hypothesis test|p-value|confidence interval|bayes(ian)?)\b`,
+		},
+		models.SubcategoryProofs: {
+			`(?i)\b(prove|proof|theorem|lemma|induction|contradiction|q\.e\.d)\b`,
+			`(?i)\b(show that|if and only if|axiom|corollary)\b`,
+		},
+	}
+	cfg.Patterns[SubcategoryGroup(models.CapabilityAnalysis)] = map[string][]string{
+		models.SubcategoryFinancial: {
+			`(?i)\b(financial|revenue|profit|balance sheet|cash flow|valuation|earnings)\b`,
+			`(?i)\b(portfolio|stock|investment|budget|forecast|roi|ebitda)\b`,
+		},
+		models.SubcategoryLegal: {
+			`(?i)\b(legal|contract|clause|agreement|liability|compliance|gdpr)\b`,
+			`(?i)\b(lawsuit|statute|regulation|terms of service|indemnif(y|ication))\b`,
+		},
+	}
+	cfg.Patterns[SubcategoryGroup(models.CapabilityConversation)] = map[string][]string{
+		models.SubcategoryCustomerSupport: {
+			`(?i)\b(customer|support ticket|refund|complaint|order status|help desk)\b`,
+			`(?i)\b(troubleshoot|my account|cancel my|subscription|faq)\b`,
+		},
 	}
 	
 	// Photorealistic category (for images)
-	tc.patterns["category"]["photorealistic"] = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(photorealistic|realistic|photo.realistic|lifelike|natural)\b`),
-		regexp.MustCompile(`(?i)\b(professional|high.quality|detailed|sharp|clear)\b`),
+	cfg.Patterns["category"]["photorealistic"] = []string{
+		`(?i)\b(photorealistic|realistic|photo.realistic|lifelike|natural)\b`,
+		`(?i)\b(professional|high.quality|detailed|sharp|clear)\b`,
 	}
-}
 
-func (tc *TaskClassifier) initializeComplexityIndicators() {
 	// Simple complexity indicators
-	tc.complexityIndicators["simple"] = []string{
+	cfg.ComplexityIndicators["simple"] = []string{
 		"simple", "basic", "easy", "quick", "short", "brief", "straightforward",
 		"beginner", "intro", "getting started", "hello world", "tutorial",
 	}
 	
 	// Medium complexity indicators
-	tc.complexityIndicators["medium"] = []string{
+	cfg.ComplexityIndicators["medium"] = []string{
 		"medium", "intermediate", "moderate", "standard", "typical", "regular",
 		"multi-step", "detailed", "comprehensive", "thorough",
 	}
 	
 	// Hard complexity indicators
-	tc.complexityIndicators["hard"] = []string{
+	cfg.ComplexityIndicators["hard"] = []string{
 		"hard", "difficult", "complex", "advanced", "challenging", "sophisticated",
 		"enterprise", "production", "scalable", "optimized", "performance",
 		"multi-threaded", "distributed", "microservices", "machine learning",
 	}
 	
 	// Expert complexity indicators
-	tc.complexityIndicators["expert"] = []string{
+	cfg.ComplexityIndicators["expert"] = []string{
 		"expert", "professional", "enterprise-grade", "research-level", "cutting-edge",
 		"state-of-the-art", "highly optimized", "custom", "specialized",
 		"architectural", "system design", "distributed systems", "high-performance",
 	}
+
+	return cfg
 }
 
 // ClassifyPrompt analyzes a user prompt and returns classification results
 func (tc *TaskClassifier) ClassifyPrompt(prompt string) ClassificationResult {
+	result, _ := tc.ClassifyPromptContext(context.Background(), prompt)
+	return result
+}
+
+// ClassifyPromptContext classifies a prompt, stopping between steps once ctx is
+// done. On cancellation the steps completed so far are returned with ctx's error.
+func (tc *TaskClassifier) ClassifyPromptContext(ctx context.Context, prompt string) (ClassificationResult, error) {
+	// Read the rules once so a reload mid-classification cannot mix generations
+	return tc.classifyWith(ctx, tc.rules.Load(), prompt)
+}
+
+func (tc *TaskClassifier) classifyWith(ctx context.Context, rules *ruleSet, prompt string) (ClassificationResult, error) {
 	result := ClassificationResult{
 		Requirements:     make(map[string]interface{}),
 		DetectedKeywords: []string{},
@@ -174,23 +286,56 @@ func (tc *TaskClassifier) ClassifyPrompt(prompt string) ClassificationResult {
 	
 	promptLower := strings.ToLower(prompt)
 	
+	result.RulesGeneration = rules.status.Generation
+	
 	// Step 1: Determine task type
-	taskType, taskTypeConfidence := tc.classifyTaskType(prompt, promptLower)
+	taskType, taskTypeConfidence := tc.classifyTaskType(rules, prompt, promptLower)
+	
+	// Step 1b: Prompts about an image they carry want text back, though they mention images
+	imageInput := detectsImageInput(prompt)
+	if imageInput && taskType != "text" && !asksForImageOutput(prompt) {
+		taskType = "text"
+	}
 	result.TaskType = taskType
 	result.ReasoningSteps = append(result.ReasoningSteps, 
 		fmt.Sprintf("Identified task type '%s' with %.2f confidence", taskType, taskTypeConfidence))
 	
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	
 	// Step 2: Determine category
-	category, categoryConfidence := tc.classifyCategory(prompt, promptLower, taskType)
-	result.Category = category
+	category, categoryConfidence, matched, runnerUp := tc.classifyCategory(rules, prompt, promptLower, taskType)
+	result.Category = models.CanonicalCapability(category)
+	result.CategoryFallback = !matched
 	result.ReasoningSteps = append(result.ReasoningSteps, 
 		fmt.Sprintf("Identified category '%s' with %.2f confidence", category, categoryConfidence))
+	if runnerUp != "" {
+		result.RunnerUpCategory = models.CanonicalCapability(runnerUp)
+		result.ReasoningSteps = append(result.ReasoningSteps, 
+			fmt.Sprintf("Runner-up category '%s'", runnerUp))
+	}
+	
+	// Step 2b: Narrow the category to the kind of task the prompt asks for
+	if result.Subcategory = tc.classifySubcategory(rules, result.Category, prompt); result.Subcategory != "" {
+			result.ReasoningSteps = append(result.ReasoningSteps, 
+			fmt.Sprintf("Identified %s subcategory '%s'", result.Category, result.Subcategory))
+	}
+	
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
 	
 	// Step 3: Determine complexity
-	complexity, complexityConfidence := tc.classifyComplexity(prompt, promptLower)
+	complexity, complexityScore, complexityConfidence := tc.classifyComplexity(rules, prompt, promptLower)
 	result.Complexity = complexity
+	result.ComplexityScore = complexityScore
 	result.ReasoningSteps = append(result.ReasoningSteps, 
-		fmt.Sprintf("Identified complexity '%s' with %.2f confidence", complexity, complexityConfidence))
+		fmt.Sprintf("Identified complexity '%s' (score %.2f) with %.2f confidence", complexity, complexityScore, complexityConfidence))
+	
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
 	
 	// Step 4: Determine priority from context
 	priority := tc.inferPriority(prompt, promptLower)
@@ -202,32 +347,41 @@ func (tc *TaskClassifier) ClassifyPrompt(prompt string) ClassificationResult {
 	
 	// Step 5: Extract special requirements
 	requirements := tc.extractRequirements(prompt, promptLower)
+	if imageInput {
+		requirements[recommendation.RequirementImageInput] = true
+		result.ReasoningSteps = append(result.ReasoningSteps, "Detected an image sent with the prompt")
+	}
 	result.Requirements = requirements
 	if len(requirements) > 0 {
 		result.ReasoningSteps = append(result.ReasoningSteps, 
 			fmt.Sprintf("Extracted %d special requirements", len(requirements)))
 	}
 	
-	// Step 6: Calculate overall confidence
+	// Step 6: Estimate how much deliberate reasoning the task needs
+	result.ReasoningDepth = tc.estimateReasoningDepth(promptLower, category, complexity)
+	result.ReasoningSteps = append(result.ReasoningSteps, 
+		fmt.Sprintf("Estimated reasoning depth '%s'", result.ReasoningDepth))
+	
+	// Step 7: Calculate overall confidence
 	result.Confidence = (taskTypeConfidence + categoryConfidence + complexityConfidence) / 3.0
 	
-	// Step 7: Extract detected keywords
+	// Step 8: Extract detected keywords
 	result.DetectedKeywords = tc.extractKeywords(prompt, promptLower)
 	
-	return result
+	return result, nil
 }
 
-func (tc *TaskClassifier) classifyTaskType(prompt, promptLower string) (string, float64) {
+func (tc *TaskClassifier) classifyTaskType(rules *ruleSet, prompt, promptLower string) (string, float64) {
 	scores := make(map[string]float64)
 	
 	// Check patterns for each task type
-	for taskType, patterns := range tc.patterns["task_type"] {
+	for taskType, patterns := range rules.patterns["task_type"] {
 		score := 0.0
 		for _, pattern := range patterns {
 			matches := pattern.FindAllString(prompt, -1)
 			score += float64(len(matches)) * 0.2
 		}
-		scores[taskType] = score
+		scores[taskType] = rules.score("task_type", taskType, score)
 	}
 	
 	// Handle visual-conflicting text patterns: if image patterns found, reduce text scores for conflicting patterns
@@ -280,17 +434,21 @@ func (tc *TaskClassifier) classifyTaskType(prompt, promptLower string) (string,
 	return selectedType, confidence
 }
 
-func (tc *TaskClassifier) classifyCategory(prompt, promptLower, taskType string) (string, float64) {
+// classifyCategory returns the best matching category, falling back to the task
+// type's default when no rule matched
+// classifyCategory returns the best matching category, its confidence, whether
+// any rule matched, and the next best matching category, if any
+func (tc *TaskClassifier) classifyCategory(rules *ruleSet, prompt, promptLower, taskType string) (string, float64, bool, string) {
 	scores := make(map[string]float64)
 	
 	// Check patterns for each category
-	for category, patterns := range tc.patterns["category"] {
+	for category, patterns := range rules.patterns["category"] {
 		score := 0.0
 		for _, pattern := range patterns {
 			matches := pattern.FindAllString(prompt, -1)
 			score += float64(len(matches)) * 0.3
 		}
-		scores[category] = score
+		scores[category] = rules.score("category", category, score)
 	}
 	
 	// Apply task type specific logic
@@ -320,12 +478,54 @@ func (tc *TaskClassifier) classifyCategory(prompt, promptLower, taskType string)
 		}
 	}
 	
+	// The runner-up is the next best match, in label order on ties
+	runnerUp, runnerUpScore := "", 0.0
+	labels := make([]string, 0, len(scores))
+	for category := range scores {
+		labels = append(labels, category)
+	}
+	sort.Strings(labels)
+	for _, category := range labels {
+		if category != selectedCategory && scores[category] > runnerUpScore {
+			runnerUp, runnerUpScore = category, scores[category]
+		}
+	}
+	
 	confidence := math.Min(maxScore, 1.0)
 	if confidence == 0 {
 		confidence = 0.4 // Default confidence for category
 	}
 	
-	return selectedCategory, confidence
+	return selectedCategory, confidence, maxScore > 0, runnerUp
+}
+
+// Categories returns the text and generative categories the classifier can assign
+func (tc *TaskClassifier) Categories() []string {
+	return tc.rules.Load().labels("category")
+}
+
+// classifySubcategory returns the category's best matching subcategory, or ""
+// when the category has none, the prompt gives no signal or subcategories tie
+func (tc *TaskClassifier) classifySubcategory(rules *ruleSet, category, prompt string) string {
+	group := SubcategoryGroup(category)
+	best, bestScore, tied := "", 0.0, false
+	for subcategory, patterns := range rules.patterns[group] {
+		score := 0.0
+		for _, pattern := range patterns {
+			score += float64(len(pattern.FindAllString(prompt, -1)))
+		}
+		score = rules.score(group, subcategory, score)
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = subcategory, score, false
+		case score == bestScore && score > 0:
+			tied = true
+		}
+	}
+	if tied {
+		return ""
+	}
+	return best
 }
 
 func (tc *TaskClassifier) getDefaultCategory(taskType string) string {
@@ -343,11 +543,13 @@ func (tc *TaskClassifier) getDefaultCategory(taskType string) string {
 	}
 }
 
-func (tc *TaskClassifier) classifyComplexity(prompt, promptLower string) (string, float64) {
+// classifyComplexity picks the complexity level with the most evidence and
+// places the prompt on the continuous 0–1 scale within that level's band
+func (tc *TaskClassifier) classifyComplexity(rules *ruleSet, prompt, promptLower string) (string, float64, float64) {
 	scores := make(map[string]int)
 	
 	// Count indicators for each complexity level
-	for complexity, indicators := range tc.complexityIndicators {
+	for complexity, indicators := range rules.complexityIndicators {
 		count := 0
 		for _, indicator := range indicators {
 			if strings.Contains(promptLower, indicator) {
@@ -405,7 +607,64 @@ func (tc *TaskClassifier) classifyComplexity(prompt, promptLower string) (string
 		confidence = math.Min(0.3 + float64(maxScore)*0.2, 1.0)
 	}
 	
-	return selectedComplexity, confidence
+	return selectedComplexity, continuousComplexity(scores, selectedComplexity, wordCount), confidence
+}
+
+// continuousComplexity averages the level midpoints weighted by the evidence
+// for each level, nudges longer prompts up, and keeps the result within the
+// selected level's band so the score and label always agree. A hard prompt
+// with some expert evidence lands near the top of hard.
+func continuousComplexity(scores map[string]int, level string, wordCount int) float64 {
+	weighted, total := 0.0, 0
+	for l, n := range scores {
+		weighted += recommendation.ComplexityMidpoint(l) * float64(n)
+		total += n
+	}
+	score := recommendation.ComplexityMidpoint(level)
+	if total > 0 {
+		score = weighted / float64(total)
+	}
+	score += 0.1 * (math.Min(float64(wordCount)/400.0, 1.0) - 0.5)
+	
+	lo, hi := recommendation.ComplexityBand(level)
+	score = math.Max(score, math.Max(lo, 0.01))
+	score = math.Min(score, hi-0.01)
+	return math.Round(score*100) / 100
+}
+
+// reasoningDepths orders the depth levels estimated by the classifier
+var reasoningDepths = []string{"none", "low", "medium", "high"}
+
+// estimateReasoningDepth starts from the complexity level and shifts it one step
+// for reasoning-heavy categories and explicit cues in the prompt
+func (tc *TaskClassifier) estimateReasoningDepth(promptLower, category, complexity string) string {
+	level := map[string]int{"simple": 0, "medium": 1, "hard": 2, "expert": 3}[complexity]
+	
+	if category == "math" || category == "reasoning" {
+		level++
+	}
+	
+	deepCues := []string{
+		"step by step", "step-by-step", "prove", "proof", "derive", "think carefully",
+		"rigorous", "reason through", "edge cases", "trade-off", "tradeoff",
+	}
+	for _, cue := range deepCues {
+		if strings.Contains(promptLower, cue) {
+			level++
+			break
+		}
+	}
+	
+	shallowCues := []string{"quick", "briefly", "just tell me", "one word", "short answer", "tl;dr"}
+	for _, cue := range shallowCues {
+		if strings.Contains(promptLower, cue) {
+			level--
+			break
+		}
+	}
+	
+	level = int(math.Max(0, math.Min(float64(level), float64(len(reasoningDepths)-1))))
+	return reasoningDepths[level]
 }
 
 func (tc *TaskClassifier) inferPriority(prompt, promptLower string) string {
@@ -504,9 +763,12 @@ func (tc *TaskClassifier) ConvertToRecommendationRequest(classification Classifi
 	return recommendation.RecommendationRequest{
 		TaskType:     classification.TaskType,
 		Category:     classification.Category,
+		Subcategory:  classification.Subcategory,
 		Complexity:   classification.Complexity,
+		ComplexityScore: classification.ComplexityScore,
 		Priority:     classification.Priority,
 		Requirements: classification.Requirements,
 		Context:      context,
+		ReasoningEffort: classification.ReasoningDepth,
 	}
 }
\ No newline at end of file
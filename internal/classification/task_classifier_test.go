@@ -0,0 +1,51 @@
+package classification
+
+import "testing"
+
+func TestClassifyCodingSubcategoryPrompts(t *testing.T) {
+	tests := []struct {
+		prompt          string
+		wantCategory    string
+		wantSubcategory string
+	}{
+		{"Write a SQL query with a left join between orders and customers", "coding", "sql"},
+		{"Make this HTML form and its CSS grid work on mobile", "coding", "frontend"},
+		{"Why do my goroutines deadlock on this mutex?", "coding", "systems"},
+		{"Load the csv into a pandas dataframe and plot it", "coding", "data_science"},
+	}
+
+	tc := NewTaskClassifier()
+	for _, tt := range tests {
+		t.Run(tt.prompt, func(t *testing.T) {
+			result := tc.ClassifyPrompt(tt.prompt)
+			if result.Category != tt.wantCategory {
+				t.Fatalf("category = %q, want %q", result.Category, tt.wantCategory)
+			}
+			if result.Subcategory != tt.wantSubcategory {
+				t.Errorf("subcategory = %q, want %q", result.Subcategory, tt.wantSubcategory)
+			}
+		})
+	}
+}
+
+func TestClassifyEverydayWordsAreNotCoding(t *testing.T) {
+	prompts := []string{
+		"Can you join the meeting at 3pm and take notes?",
+		"Query my bank about the duplicate charge on my statement",
+		"Write a short poem about friends who join us for dinner",
+		"I have a query about my insurance claim",
+	}
+
+	tc := NewTaskClassifier()
+	for _, prompt := range prompts {
+		t.Run(prompt, func(t *testing.T) {
+			result := tc.ClassifyPrompt(prompt)
+			if result.Category == "coding" {
+				t.Errorf("category = coding, want a non-coding category")
+			}
+			if result.Subcategory != "" {
+				t.Errorf("subcategory = %q, want none", result.Subcategory)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package classification
+
+import (
+	"regexp"
+
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// imageInputPatterns match prompts about an image the caller is sending,
+// as opposed to one they want generated
+var imageInputPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(this|these|attached|uploaded|enclosed|above|following)\s+(images?|pictures?|photos?|photographs?|screenshots?|scans?|diagrams?|charts?|figures?|receipts?|whiteboards?)\b`),
+	regexp.MustCompile(`(?i)\bscreen\s?shots?\b`),
+	regexp.MustCompile(`(?i)\b(images?|pictures?|photos?|files?)\s+(i|we)('ve|\s+have)?\s+(attached|uploaded|sent|shared|pasted)\b`),
+	regexp.MustCompile(`(?i)\b(what'?s|what\s+is|who\s+is|what\s+does|describe|caption|transcribe|read|extract|identify)\b.*\b(in|on|from)\s+(the|this|my)\s+(image|picture|photo|screenshot|scan|chart|diagram)\b`),
+	regexp.MustCompile(`(?i)\b(ocr|image\s+to\s+text)\b`),
+}
+
+// imageOutputPattern matches requests to create or edit an image, which stay
+// generative even when they refer to an input image
+var imageOutputPattern = regexp.MustCompile(`(?i)\b((generate|create|draw|paint|sketch|render|produce)\s+(an?\s+|the\s+|another\s+|some\s+)?(new\s+)?(images?|pictures?|illustrations?|artwork|variations?|versions?)|edit|retouch|restyle|recolou?r|upscale|inpaint|outpaint|remove\s+the\s+background|make\s+.+\s+look\s+like|turn\s+.+\s+into)\b`)
+
+// detectsImageInput reports whether the prompt refers to an image it carries
+func detectsImageInput(prompt string) bool {
+	for _, pattern := range imageInputPatterns {
+		if pattern.MatchString(prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+// asksForImageOutput reports whether the prompt wants an image back
+func asksForImageOutput(prompt string) bool {
+	return imageOutputPattern.MatchString(prompt)
+}
+
+// WithImageInput marks a result whose prompt was sent with images. Unless the
+// prompt asks for an image back, the answer is text, whatever the classifier
+// made of the prompt's mentions of images.
+func WithImageInput(result ClassificationResult, prompt string) ClassificationResult {
+	requirements := make(map[string]interface{}, len(result.Requirements)+1)
+	for k, v := range result.Requirements {
+		requirements[k] = v
+	}
+	requirements[recommendation.RequirementImageInput] = true
+	result.Requirements = requirements
+
+	if result.TaskType != "text" && !asksForImageOutput(prompt) {
+		result.TaskType = "text"
+		result.ReasoningSteps = append(result.ReasoningSteps, "Prompt carries images to read; answering in text")
+	}
+	return result
+}
@@ -0,0 +1,382 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Storage backends
+const (
+	StoragePostgres = "postgres"
+	StorageNone     = "none"
+)
+
+// Listener transports
+const (
+	TransportHTTP = "http"
+	TransportTLS  = "tls"
+)
+
+// Request validation modes
+const (
+	ValidationEnforce = "enforce" // Reject requests that do not match the OpenAPI spec
+	ValidationReport  = "report"  // Log them and serve them anyway
+	ValidationOff     = "off"
+)
+
+// Secret is a setting that must never be printed. It formats and marshals as
+// a placeholder; Value returns the real string.
+type Secret string
+
+const redacted = "[redacted]"
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.String())), nil
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// Config is every setting the router server reads at startup. Each leaf field
+// is named by its yaml path in the config file, its env variable and a flag of
+// the same dotted name (--server.port).
+type Config struct {
+	Profile     string            `yaml:"profile" env:"ROUTER_PROFILE"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Catalog     CatalogConfig     `yaml:"catalog"`
+	Status      StatusConfig      `yaml:"status"`
+	Calibration CalibrationConfig `yaml:"calibration"`
+	Tuning      TuningConfig      `yaml:"tuning"`
+	Evals       EvalsConfig       `yaml:"evals"`
+	Encryption  EncryptionConfig  `yaml:"encryption"`
+	GraphQL     GraphQLConfig     `yaml:"graphql"`
+	Mirror      MirrorConfig      `yaml:"mirror"`
+	Limits      LimitsConfig      `yaml:"limits"`
+	HuggingFace HuggingFaceConfig `yaml:"huggingface"`
+	Validation  ValidationConfig  `yaml:"validation"`
+
+	// sources records which layer set each setting, by key
+	sources map[string]string
+}
+
+// ServerConfig selects which parts of the router this process serves. The
+// profile sets every field; other layers override individual fields.
+type ServerConfig struct {
+	EnableAuth   bool   `yaml:"enable_auth" env:"ROUTER_ENABLE_AUTH"` // Users, JWT auth and the tenant dashboard; requires postgres storage
+	EnableV2     bool   `yaml:"enable_v2" env:"ROUTER_ENABLE_V2"`     // The recommendation engine and /api/v2 routes
+	LegacyRoutes bool   `yaml:"legacy_routes" env:"ROUTER_LEGACY_ROUTES"`
+	Storage      string `yaml:"storage" env:"ROUTER_STORAGE"`     // StoragePostgres or StorageNone
+	Transport    string `yaml:"transport" env:"ROUTER_TRANSPORT"` // TransportHTTP or TransportTLS
+	TLSCertFile  string `yaml:"tls_cert_file" env:"ROUTER_TLS_CERT_FILE"`
+	TLSKeyFile   string `yaml:"tls_key_file" env:"ROUTER_TLS_KEY_FILE"`
+	Port         string `yaml:"port" env:"PORT"`
+}
+
+// DatabaseConfig locates Postgres, over TCP or a Cloud SQL socket
+type DatabaseConfig struct {
+	Host                   string `yaml:"host" env:"DB_HOST"`
+	InstanceConnectionName string `yaml:"instance_connection_name" env:"INSTANCE_CONNECTION_NAME"`
+	User                   string `yaml:"user" env:"DB_USER"`
+	Password               Secret `yaml:"password" env:"DB_PASSWORD"`
+	Name                   string `yaml:"name" env:"DB_NAME"`
+	SchemaPath             string `yaml:"schema_path" env:"SCHEMA_PATH"`
+}
+
+// RedisConfig locates the Redis shared by replicas; without a host limits are per replica
+type RedisConfig struct {
+	Host     string `yaml:"host" env:"REDIS_HOST"`
+	Port     string `yaml:"port" env:"REDIS_PORT"`
+	Password Secret `yaml:"password" env:"REDIS_PASSWORD"`
+}
+
+// Addr returns host:port, or "" when no host is configured
+func (r RedisConfig) Addr() string {
+	if r.Host == "" {
+		return ""
+	}
+	return r.Host + ":" + r.Port
+}
+
+type AuthConfig struct {
+	JWTSecret       Secret        `yaml:"jwt_secret" env:"JWT_SECRET"`
+	TwoFactorMaxAge time.Duration `yaml:"two_factor_max_age" env:"TWO_FACTOR_MAX_AGE"` // How recent a challenge sensitive dashboard operations need
+	SSORedirectURL  string        `yaml:"sso_redirect_url" env:"SSO_REDIRECT_URL"`     // Dashboard page identity providers return SSO logins to
+}
+
+type CatalogConfig struct {
+	ModelPath             string        `yaml:"model_path" env:"MODEL_PATH"`
+	Replication           bool          `yaml:"replication" env:"CATALOG_REPLICATION"` // Only the elected leader fetches Analytics AI
+	RefreshInterval       time.Duration `yaml:"refresh_interval" env:"CATALOG_REFRESH_INTERVAL"`
+	BenchmarkMappingsPath string        `yaml:"benchmark_mappings_path" env:"BENCHMARK_MAPPINGS_PATH"`
+	ClassifierRulesPath   string        `yaml:"classifier_rules_path" env:"CLASSIFIER_RULES_PATH"` // Built-in rules when empty
+	ClassifierCorpusDir   string        `yaml:"classifier_corpus_dir" env:"CLASSIFIER_CORPUS_DIR"` // Labelled prompts the classifier is scored on
+	FeaturesPath          string        `yaml:"features_path" env:"MODEL_FEATURES_PATH"`           // Declared tool use, vision and caching support
+	LocalesPath           string        `yaml:"locales_path" env:"MODEL_LOCALES_PATH"`             // Per-model locale scores; none when empty
+	FallbacksPath         string        `yaml:"fallbacks_path" env:"CATEGORY_FALLBACKS_PATH"`      // Models served when nothing scores well enough; none when empty
+}
+
+type StatusConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"STATUS_POLL_INTERVAL"`
+}
+
+// HuggingFaceConfig enriches open models with Hugging Face Hub data
+type HuggingFaceConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"HF_POLL_INTERVAL"` // Zero disables enrichment
+	Token        Secret        `yaml:"token" env:"HF_TOKEN"`                 // Only needed for gated repositories
+}
+
+type CalibrationConfig struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"CALIBRATION_REFRESH_INTERVAL"`
+}
+
+type TuningConfig struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"WEIGHT_TUNING_INTERVAL"` // How often weights are refitted to feedback
+}
+
+type EvalsConfig struct {
+	SuitesDir string `yaml:"suites_dir" env:"EVAL_SUITES_DIR"`
+}
+
+type EncryptionConfig struct {
+	DataKeyMaxAge time.Duration `yaml:"data_key_max_age" env:"DATA_KEY_MAX_AGE"` // Zero rotates on demand only
+}
+
+type GraphQLConfig struct {
+	PersistedQueriesDir string `yaml:"persisted_queries_dir" env:"GRAPHQL_PERSISTED_QUERIES_DIR"` // Preloaded *.graphql documents
+	PersistedOnly       bool   `yaml:"persisted_only" env:"GRAPHQL_PERSISTED_ONLY"`               // Refuse queries not preloaded
+}
+
+// MirrorConfig copies a sample of recommend traffic to a staging router
+type MirrorConfig struct {
+	StagingURL    string        `yaml:"staging_url" env:"MIRROR_STAGING_URL"` // Base URL of the staging router; empty disables mirroring
+	Percent       float64       `yaml:"percent" env:"MIRROR_PERCENT"`         // Share of recommend requests mirrored, 0-100
+	RedactPrompts bool          `yaml:"redact_prompts" env:"MIRROR_REDACT_PROMPTS"`
+	Timeout       time.Duration `yaml:"timeout" env:"MIRROR_TIMEOUT"`
+}
+
+// LimitsConfig bounds request bodies before any handler or middleware reads them
+type LimitsConfig struct {
+	MaxBodyBytes   int    `yaml:"max_body_bytes" env:"MAX_BODY_BYTES"`     // Routes without an override
+	RouteBodyBytes string `yaml:"route_body_bytes" env:"ROUTE_BODY_BYTES"` // Overrides by route pattern, "/api/v2/generate=4194304,/graphql=65536"
+
+	// HighCostUSD is the worst-case cost of one generation above which it
+	// needs confirm_high_cost, for tenants and keys without their own; 0 disables
+	HighCostUSD float64 `yaml:"high_cost_usd" env:"HIGH_COST_USD"`
+
+	// Capacity for load shedding: requests in flight on one replica and the
+	// p99 latency of requests it answers itself; 0 disables each signal
+	ShedMaxInFlight   int           `yaml:"shed_max_in_flight" env:"SHED_MAX_IN_FLIGHT"`
+	ShedLatencyTarget time.Duration `yaml:"shed_latency_target" env:"SHED_LATENCY_TARGET"`
+}
+
+// Routes parses RouteBodyBytes into byte limits by route pattern
+func (l LimitsConfig) Routes() (map[string]int64, error) {
+	routes := make(map[string]int64)
+	for _, entry := range strings.Split(l.RouteBodyBytes, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, size, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("%q is not route=bytes", entry)
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q: bytes must be a positive integer", entry)
+		}
+		routes[route] = n
+	}
+	return routes, nil
+}
+
+// ValidationConfig checks bodies against the embedded OpenAPI spec
+type ValidationConfig struct {
+	Requests  string `yaml:"requests" env:"SCHEMA_VALIDATION"`          // ValidationEnforce, ValidationReport or ValidationOff
+	Responses bool   `yaml:"responses" env:"SCHEMA_VALIDATE_RESPONSES"` // Log responses that do not match the spec
+}
+
+// profiles reproduce the servers that used to be separate binaries:
+// production was the root main.go, enhanced was cmd/enhanced-server and auth
+// was the auth-only API
+var profiles = map[string]ServerConfig{
+	"production": {EnableAuth: true, EnableV2: true, Storage: StoragePostgres, Port: "8080"},
+	"enhanced":   {EnableV2: true, Storage: StorageNone, LegacyRoutes: true, Port: "8083"},
+	"auth":       {EnableAuth: true, Storage: StoragePostgres, Port: "8080"},
+}
+
+// defaults returns the built-in settings for a profile
+func defaults(profile string) (Config, error) {
+	server, ok := profiles[profile]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown profile %q, expected production, enhanced or auth", profile)
+	}
+	server.Transport = TransportHTTP
+
+	return Config{
+		Profile: profile,
+		Server:  server,
+		Database: DatabaseConfig{
+			User:       "postgres",
+			Name:       "routellm",
+			SchemaPath: "./database/schema_postgres.sql",
+		},
+		Redis: RedisConfig{Port: "6379"},
+		Auth: AuthConfig{
+			JWTSecret:       "kIQuPaMIDulFsCJmB6iolLF0yhE5pCnN", // Default from GCloud secret
+			TwoFactorMaxAge: 15 * time.Minute,
+		},
+		Catalog: CatalogConfig{
+			ModelPath:             "./configs/model_1.json",
+			RefreshInterval:       time.Hour,
+			BenchmarkMappingsPath: "./configs/benchmark_mappings.json",
+			FeaturesPath:          "./configs/model_features.json",
+			ClassifierCorpusDir:   "./configs/classifier_corpus",
+		},
+		Status:      StatusConfig{PollInterval: 2 * time.Minute},
+		Calibration: CalibrationConfig{RefreshInterval: 6 * time.Hour},
+		Tuning:      TuningConfig{RefreshInterval: 24 * time.Hour},
+		Evals:       EvalsConfig{SuitesDir: "./configs/evals"},
+		Mirror:      MirrorConfig{Percent: 1, RedactPrompts: true, Timeout: 5 * time.Second},
+		Limits: LimitsConfig{
+			MaxBodyBytes:      1 << 20,
+			RouteBodyBytes:    "/api/v2/generate=8388608", // Generation accepts inline images
+			HighCostUSD:       10,
+			ShedMaxInFlight:   512,
+			ShedLatencyTarget: 2 * time.Second,
+		},
+		HuggingFace: HuggingFaceConfig{PollInterval: 12 * time.Hour},
+		Validation:  ValidationConfig{Requests: ValidationEnforce},
+	}, nil
+}
+
+// Validate checks the settings against each other and reports every problem
+func (cfg *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	s := cfg.Server
+	if s.Storage != StoragePostgres && s.Storage != StorageNone {
+		fail("server.storage: unknown storage %q, expected postgres or none", s.Storage)
+	}
+	if s.Transport != TransportHTTP && s.Transport != TransportTLS {
+		fail("server.transport: unknown transport %q, expected http or tls", s.Transport)
+	}
+	if s.Transport == TransportTLS && (s.TLSCertFile == "" || s.TLSKeyFile == "") {
+		fail("server.transport: tls requires server.tls_cert_file and server.tls_key_file")
+	}
+	if port, err := strconv.Atoi(s.Port); err != nil || port < 1 || port > 65535 {
+		fail("server.port: %q is not a port number", s.Port)
+	}
+	if !s.EnableAuth && !s.EnableV2 {
+		fail("server: neither auth nor the v2 engine is enabled, nothing to serve")
+	}
+	if s.EnableAuth && s.Storage != StoragePostgres {
+		fail("server.enable_auth: auth requires postgres storage")
+	}
+	if s.LegacyRoutes && !s.EnableV2 {
+		fail("server.legacy_routes: legacy routes require the v2 engine")
+	}
+
+	if s.Storage == StoragePostgres && cfg.Database.Host == "" && cfg.Database.InstanceConnectionName == "" {
+		fail("database: postgres storage requires database.host or database.instance_connection_name")
+	}
+	if cfg.Catalog.Replication && s.Storage != StoragePostgres {
+		fail("catalog.replication: catalog replication requires postgres storage")
+	}
+	if cfg.Redis.Host != "" {
+		if port, err := strconv.Atoi(cfg.Redis.Port); err != nil || port < 1 || port > 65535 {
+			fail("redis.port: %q is not a port number", cfg.Redis.Port)
+		}
+	}
+	if s.EnableAuth && cfg.Auth.JWTSecret == "" {
+		fail("auth.jwt_secret: required when auth is enabled")
+	}
+
+	for key, d := range map[string]time.Duration{
+		"catalog.refresh_interval":     cfg.Catalog.RefreshInterval,
+		"status.poll_interval":         cfg.Status.PollInterval,
+		"calibration.refresh_interval": cfg.Calibration.RefreshInterval,
+		"tuning.refresh_interval":      cfg.Tuning.RefreshInterval,
+		"auth.two_factor_max_age":      cfg.Auth.TwoFactorMaxAge,
+	} {
+		if d <= 0 {
+			fail("%s: must be a positive duration", key)
+		}
+	}
+	if cfg.HuggingFace.PollInterval < 0 {
+		fail("huggingface.poll_interval: must not be negative")
+	}
+	if cfg.Encryption.DataKeyMaxAge < 0 {
+		fail("encryption.data_key_max_age: must not be negative")
+	}
+	if cfg.GraphQL.PersistedOnly && cfg.GraphQL.PersistedQueriesDir == "" {
+		fail("graphql.persisted_only: requires graphql.persisted_queries_dir")
+	}
+	if m := cfg.Mirror; m.StagingURL != "" {
+		if u, err := url.Parse(m.StagingURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("mirror.staging_url: %q is not an http(s) URL", m.StagingURL)
+		}
+		if m.Percent <= 0 || m.Percent > 100 {
+			fail("mirror.percent: must be above 0 and at most 100")
+		}
+		if m.Timeout <= 0 {
+			fail("mirror.timeout: must be a positive duration")
+		}
+	}
+	if cfg.Limits.MaxBodyBytes <= 0 {
+		fail("limits.max_body_bytes: must be positive")
+	}
+	if cfg.Limits.HighCostUSD < 0 {
+		fail("limits.high_cost_usd: must not be negative")
+	}
+	if cfg.Limits.ShedMaxInFlight < 0 {
+		fail("limits.shed_max_in_flight: must not be negative")
+	}
+	if cfg.Limits.ShedLatencyTarget < 0 {
+		fail("limits.shed_latency_target: must not be negative")
+	}
+	if _, err := cfg.Limits.Routes(); err != nil {
+		fail("limits.route_body_bytes: %v", err)
+	}
+	switch cfg.Validation.Requests {
+	case ValidationEnforce, ValidationReport, ValidationOff:
+	default:
+		fail("validation.requests: unknown mode %q, expected enforce, report or off", cfg.Validation.Requests)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Tenants reports whether the per-tenant features run: usage, feedback,
+// generation, dashboards and the admin API all need both auth and the engine
+func (cfg *Config) Tenants() bool {
+	return cfg.Server.EnableAuth && cfg.Server.EnableV2
+}
+
+func (cfg *Config) String() string {
+	return fmt.Sprintf("profile=%s auth=%t v2=%t storage=%s transport=%s legacy_routes=%t",
+		cfg.Profile, cfg.Server.EnableAuth, cfg.Server.EnableV2, cfg.Server.Storage, cfg.Server.Transport, cfg.Server.LegacyRoutes)
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes the running configuration to admins
+type Handlers struct {
+	cfg *Config
+}
+
+func NewHandlers(cfg *Config) *Handlers {
+	return &Handlers{cfg: cfg}
+}
+
+// Get returns every setting with the layer that set it; secrets are redacted
+func (h *Handlers) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"profile":  h.cfg.Profile,
+			"settings": h.cfg.Sanitized(),
+		},
+	})
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of a setting, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// field is one leaf setting reached through the Config struct
+type field struct {
+	key   string // Dotted yaml path
+	env   string
+	value reflect.Value
+}
+
+// fields lists every leaf setting of cfg, addressable for writes
+func (cfg *Config) fields() []field {
+	var out []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if !f.IsExported() || name == "" {
+				continue
+			}
+			key := prefix + name
+			if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Duration(0)) {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			out = append(out, field{key: key, env: f.Tag.Get("env"), value: v.Field(i)})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return out
+}
+
+// set parses s into the field's type
+func (f field) set(s string) error {
+	v := f.value
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration %q", f.key, s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", f.key, s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%s: invalid integer %q", f.key, s)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", f.key, s)
+		}
+		v.SetFloat(x)
+	default:
+		return fmt.Errorf("%s: unsupported setting type %s", f.key, v.Type())
+	}
+	return nil
+}
+
+// flagValue collects a flag's raw value for the flag layer
+type flagValue struct {
+	isBool bool
+	value  *string
+}
+
+func (f flagValue) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return *f.value
+}
+
+func (f flagValue) Set(s string) error {
+	*f.value = s
+	return nil
+}
+
+func (f flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// Load builds the configuration from, in increasing precedence, the profile's
+// defaults, a YAML or JSON file (--config or ROUTER_CONFIG_FILE), environment
+// variables and flags named after the setting's dotted path. Unknown file
+// keys and unparseable values are errors, as is a configuration that fails
+// Validate.
+func Load(args []string) (*Config, error) {
+	var probe Config
+	fs := flag.NewFlagSet("router", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("ROUTER_CONFIG_FILE"), "YAML or JSON config file")
+	flagValues := make(map[string]*string)
+	for _, f := range probe.fields() {
+		raw := new(string)
+		flagValues[f.key] = raw
+		usage := "overrides " + f.key
+		if f.env != "" {
+			usage += " and $" + f.env
+		}
+		fs.Var(flagValue{isBool: f.value.Kind() == reflect.Bool, value: raw}, f.key, usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	flags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		if raw, ok := flagValues[f.Name]; ok {
+			flags[f.Name] = *raw
+		}
+	})
+
+	fileValues := map[string]string{}
+	if *file != "" {
+		var err error
+		if fileValues, err = readFile(*file); err != nil {
+			return nil, err
+		}
+	}
+
+	// The profile picks the defaults every other layer applies over
+	profile := "production"
+	for _, v := range []string{fileValues["profile"], os.Getenv("ROUTER_PROFILE"), flags["profile"]} {
+		if v != "" {
+			profile = v
+		}
+	}
+	cfg, err := defaults(profile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.sources = make(map[string]string)
+
+	fields := cfg.fields()
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.key] = true
+		cfg.sources[f.key] = SourceDefault
+
+		if v, ok := fileValues[f.key]; ok {
+			if err := f.set(v); err != nil {
+				return nil, fmt.Errorf("%s: %w", *file, err)
+			}
+			cfg.sources[f.key] = SourceFile
+		}
+		if v := os.Getenv(f.env); f.env != "" && v != "" {
+			if err := f.set(v); err != nil {
+				return nil, fmt.Errorf("$%s: %w", f.env, err)
+			}
+			cfg.sources[f.key] = SourceEnv
+		}
+		if v, ok := flags[f.key]; ok {
+			if err := f.set(v); err != nil {
+				return nil, fmt.Errorf("--%s: %w", f.key, err)
+			}
+			cfg.sources[f.key] = SourceFlag
+		}
+	}
+	for key := range fileValues {
+		if !known[key] {
+			return nil, fmt.Errorf("%s: unknown setting %q", *file, key)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// readFile flattens a YAML (or JSON, which YAML parses) file to dotted keys
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var tree map[string]interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	var flatten func(node map[string]interface{}, prefix string) error
+	flatten = func(node map[string]interface{}, prefix string) error {
+		for k, v := range node {
+			key := prefix + k
+			switch v := v.(type) {
+			case map[string]interface{}:
+				if err := flatten(v, key+"."); err != nil {
+					return err
+				}
+			case []interface{}:
+				return fmt.Errorf("%s: %s must not be a list", path, key)
+			case nil:
+				// An empty value leaves the setting alone
+			default:
+				values[key] = fmt.Sprint(v)
+			}
+		}
+		return nil
+	}
+	return values, flatten(tree, "")
+}
+
+// Setting is one setting as reported by the admin API
+type Setting struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"` // SourceDefault, SourceFile, SourceEnv or SourceFlag
+	Env    string      `json:"env,omitempty"`
+	Secret bool        `json:"secret,omitempty"`
+}
+
+// Sanitized lists every setting with its source, secrets redacted
+func (cfg *Config) Sanitized() []Setting {
+	settings := []Setting{}
+	for _, f := range cfg.fields() {
+		s := Setting{Key: f.key, Source: cfg.sources[f.key], Env: f.env}
+		switch v := f.value.Interface().(type) {
+		case Secret:
+			s.Value, s.Secret = v.String(), true
+		case time.Duration:
+			s.Value = v.String()
+		default:
+			s.Value = v
+		}
+		if s.Source == "" {
+			s.Source = SourceDefault
+		}
+		settings = append(settings, s)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
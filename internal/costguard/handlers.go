@@ -0,0 +1,97 @@
+package costguard
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes tenant management of high-cost thresholds
+type Handlers struct {
+	store *Store
+}
+
+type PutThresholdRequest struct {
+	APIKeyID          string  `json:"api_key_id"` // Optional; without it the tenant's default is set
+	MaxRequestCostUSD float64 `json:"max_request_cost_usd" binding:"required"`
+}
+
+func NewHandlers(store *Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// List returns the caller's thresholds and the platform default
+func (h *Handlers) List(c *gin.Context) {
+	thresholds, err := h.store.List(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load cost thresholds",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"thresholds":           thresholds,
+			"default_max_cost_usd": h.store.Default(),
+		},
+	})
+}
+
+// Put sets the caller's default threshold or one key's
+func (h *Handlers) Put(c *gin.Context) {
+	var req PutThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	threshold, err := h.store.Put(c.Request.Context(), c.GetString("user_id"), req.APIKeyID, req.MaxRequestCostUSD)
+	if errors.Is(err, ErrUnknownKey) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "API key not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid cost threshold",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    threshold,
+	})
+}
+
+// Delete removes the caller's default threshold, or the one for the
+// api_key_id query parameter
+func (h *Handlers) Delete(c *gin.Context) {
+	deleted, err := h.store.Delete(c.Request.Context(), c.GetString("user_id"), c.Query("api_key_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete cost threshold",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No cost threshold to delete",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
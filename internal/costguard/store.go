@@ -0,0 +1,146 @@
+package costguard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnknownKey is returned for a threshold on an API key the tenant does not own
+var ErrUnknownKey = errors.New("unknown API key")
+
+// Threshold is the worst-case cost of one generation above which the caller
+// must confirm it
+type Threshold struct {
+	APIKeyID          string    `json:"api_key_id,omitempty"` // Empty for the tenant's default
+	MaxRequestCostUSD float64   `json:"max_request_cost_usd"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Store persists per-tenant and per-key thresholds over a platform default
+type Store struct {
+	db         *sql.DB
+	defaultUSD float64
+}
+
+// NewStore falls back to defaultUSD for tenants without a threshold; 0
+// leaves them without one
+func NewStore(db *sql.DB, defaultUSD float64) *Store {
+	return &Store{db: db, defaultUSD: defaultUSD}
+}
+
+// Default is the platform threshold
+func (s *Store) Default() float64 {
+	return s.defaultUSD
+}
+
+// MaxRequestCost returns the threshold for a call: the key's, then the
+// tenant's, then the platform default. On error it returns the default.
+func (s *Store) MaxRequestCost(ctx context.Context, userID, apiKeyID string) (float64, error) {
+	if userID == "" {
+		return s.defaultUSD, nil
+	}
+
+	var usd float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT max_request_cost_usd FROM cost_thresholds
+		WHERE user_id = $1 AND (api_key_id IS NULL OR api_key_id::text = $2)
+		ORDER BY api_key_id IS NULL
+		LIMIT 1`,
+		userID, apiKeyID,
+	).Scan(&usd)
+	if err == sql.ErrNoRows {
+		return s.defaultUSD, nil
+	}
+	if err != nil {
+		return s.defaultUSD, fmt.Errorf("failed to load cost threshold: %w", err)
+	}
+	return usd, nil
+}
+
+// List returns the tenant's thresholds, its default first
+func (s *Store) List(ctx context.Context, userID string) ([]Threshold, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COALESCE(api_key_id::text, ''), max_request_cost_usd, updated_at
+		FROM cost_thresholds
+		WHERE user_id = $1
+		ORDER BY api_key_id NULLS FIRST`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cost thresholds: %w", err)
+	}
+	defer rows.Close()
+
+	thresholds := []Threshold{}
+	for rows.Next() {
+		var t Threshold
+		if err := rows.Scan(&t.APIKeyID, &t.MaxRequestCostUSD, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cost threshold: %w", err)
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, rows.Err()
+}
+
+// Put sets the tenant's default threshold, or a key's when apiKeyID is set
+func (s *Store) Put(ctx context.Context, userID, apiKeyID string, usd float64) (Threshold, error) {
+	t := Threshold{APIKeyID: apiKeyID, MaxRequestCostUSD: usd}
+	if usd <= 0 {
+		return t, errors.New("max_request_cost_usd must be positive")
+	}
+
+	var keyID interface{}
+	if apiKeyID != "" {
+		var owned bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM api_keys WHERE id::text = $1 AND user_id = $2)`,
+			apiKeyID, userID,
+		).Scan(&owned)
+		if err != nil {
+			return t, fmt.Errorf("failed to check API key: %w", err)
+		}
+		if !owned {
+			return t, ErrUnknownKey
+		}
+		keyID = apiKeyID
+	}
+
+	// Defaults and key thresholds have separate unique indexes, so upsert by hand
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE cost_thresholds SET max_request_cost_usd = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND api_key_id IS NOT DISTINCT FROM $2
+		RETURNING updated_at`,
+		userID, keyID, usd,
+	).Scan(&t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		err = s.db.QueryRowContext(ctx, `
+			INSERT INTO cost_thresholds (user_id, api_key_id, max_request_cost_usd)
+			VALUES ($1, $2, $3)
+			RETURNING updated_at`,
+			userID, keyID, usd,
+		).Scan(&t.UpdatedAt)
+	}
+	if err != nil {
+		return t, fmt.Errorf("failed to store cost threshold: %w", err)
+	}
+	return t, nil
+}
+
+// Delete removes the tenant's default threshold, or a key's when apiKeyID is
+// set, reporting whether it existed
+func (s *Store) Delete(ctx context.Context, userID, apiKeyID string) (bool, error) {
+	var keyID interface{}
+	if apiKeyID != "" {
+		keyID = apiKeyID
+	}
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM cost_thresholds
+		WHERE user_id = $1 AND api_key_id::text IS NOT DISTINCT FROM $2`,
+		userID, keyID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete cost threshold: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
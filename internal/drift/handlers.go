@@ -0,0 +1,62 @@
+package drift
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes classification drift reports to admins
+type Handlers struct {
+	monitor *Monitor
+}
+
+func NewHandlers(monitor *Monitor) *Handlers {
+	return &Handlers{monitor: monitor}
+}
+
+// Report compares each tenant's recent classifications with its baseline.
+// ?window (default 24h) is the recent period, ?baseline (default 168h) the
+// period before it, ?tenant narrows the report to one tenant.
+func (h *Handlers) Report(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "24h"))
+	if err != nil || window < time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid window",
+			"details": "window must be a duration of at least 1h",
+		})
+		return
+	}
+	baseline, err := time.ParseDuration(c.DefaultQuery("baseline", "168h"))
+	if err != nil || baseline < time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid baseline",
+			"details": "baseline must be a duration of at least 1h",
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid limit",
+			"details": "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	report, err := h.monitor.Report(c.Request.Context(), c.Query("tenant"), window, baseline, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to build drift report",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,489 @@
+package drift
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// AllTenants aggregates every tenant's classifications
+const AllTenants = "*"
+
+// anonymousTenant records classifications of unauthenticated requests
+const anonymousTenant = ""
+
+// Config holds drift thresholds
+type Config struct {
+	BlindSpotShare      float64  // Share of blind-spot classifications in an hour that raises an alert
+	MinRequests         int      // Classifications in the hour before the share is judged
+	DriftThreshold      float64  // Total variation distance from the baseline that marks a tenant as drifting
+	BlindSpotCategories []string // Categories that signal the classifier did not recognize a prompt
+}
+
+// DefaultConfig returns the built-in thresholds; DRIFT_BLIND_SPOT_SHARE and
+// DRIFT_MIN_REQUESTS override the alerting threshold
+func DefaultConfig() Config {
+	cfg := Config{
+		BlindSpotShare:      0.35,
+		MinRequests:         100,
+		DriftThreshold:      0.2,
+		BlindSpotCategories: []string{"general", "unknown"},
+	}
+	if share, err := strconv.ParseFloat(os.Getenv("DRIFT_BLIND_SPOT_SHARE"), 64); err == nil && share > 0 && share <= 1 {
+		cfg.BlindSpotShare = share
+	}
+	if n, err := strconv.Atoi(os.Getenv("DRIFT_MIN_REQUESTS")); err == nil && n > 0 {
+		cfg.MinRequests = n
+	}
+	return cfg
+}
+
+// bucketKey is one row of classification_distribution
+type bucketKey struct {
+	tenant     string
+	hour       int64
+	category   string
+	complexity string
+	fallback   bool
+}
+
+// hourWindow counts a tenant's classifications in the current hour for alerting
+type hourWindow struct {
+	hour      int64
+	total     int
+	blindSpot int
+	alerted   bool
+}
+
+// Monitor tracks the distribution of classified categories and complexities
+// per tenant by the hour, and alerts when the share of prompts the classifier
+// could not place crosses a threshold
+type Monitor struct {
+	db     *sql.DB
+	alerts *alerts.Manager
+	cfg    Config
+
+	blindSpots map[string]bool
+
+	mu      sync.Mutex
+	pending map[bucketKey]int64
+	windows map[string]*hourWindow
+}
+
+func NewMonitor(db *sql.DB, cfg Config) *Monitor {
+	blindSpots := make(map[string]bool, len(cfg.BlindSpotCategories))
+	for _, c := range cfg.BlindSpotCategories {
+		blindSpots[c] = true
+	}
+	return &Monitor{
+		db:         db,
+		cfg:        cfg,
+		blindSpots: blindSpots,
+		pending:    make(map[bucketKey]int64),
+		windows:    make(map[string]*hourWindow),
+	}
+}
+
+// SetAlerts posts blind-spot surges to the operational alert webhooks
+func (m *Monitor) SetAlerts(manager *alerts.Manager) {
+	m.alerts = manager
+}
+
+// ObserveClassification counts one classifier result for a tenant
+func (m *Monitor) ObserveClassification(tenant string, result classification.ClassificationResult) {
+	now := time.Now()
+	hour := now.Unix() / 3600
+	blindSpot := result.CategoryFallback || m.blindSpots[result.Category]
+
+	m.mu.Lock()
+	m.pending[bucketKey{
+		tenant:     tenant,
+		hour:       hour,
+		category:   result.Category,
+		complexity: result.Complexity,
+		fallback:   result.CategoryFallback,
+	}]++
+
+	var surges []hourWindow
+	var surged []string
+	for _, t := range []string{tenant, AllTenants} {
+		w := m.windows[t]
+		if w == nil || w.hour != hour {
+			w = &hourWindow{hour: hour}
+			m.windows[t] = w
+		}
+		w.total++
+		if blindSpot {
+			w.blindSpot++
+		}
+		if !w.alerted && w.total >= m.cfg.MinRequests && float64(w.blindSpot)/float64(w.total) >= m.cfg.BlindSpotShare {
+			w.alerted = true
+			surges = append(surges, *w)
+			surged = append(surged, t)
+		}
+	}
+	m.mu.Unlock()
+
+	for i, w := range surges {
+		m.alert(surged[i], w)
+	}
+}
+
+func (m *Monitor) alert(tenant string, w hourWindow) {
+	share := float64(w.blindSpot) / float64(w.total)
+	who := "tenant " + tenantLabel(tenant)
+	if tenant == AllTenants {
+		who = "all tenants"
+	}
+	log.Printf("[DRIFT] %.0f%% of %d classifications for %s this hour were blind spots", share*100, w.total, who)
+
+	m.alerts.Notify(alerts.Event{
+		Type:     alerts.EventClassifierDrift,
+		Severity: alerts.SeverityWarning,
+		Source:   "drift",
+		Title:    fmt.Sprintf("Classifier blind spots for %s: %.0f%% of prompts", who, share*100),
+		Message:  "The classifier could not place a large share of recent prompts; its rules may be missing a kind of traffic. See /api/v1/admin/classifier/drift.",
+		Fields: map[string]string{
+			"tenant":          tenantLabel(tenant),
+			"classifications": strconv.Itoa(w.total),
+			"blind_spots":     strconv.Itoa(w.blindSpot),
+			"threshold":       fmt.Sprintf("%.0f%%", m.cfg.BlindSpotShare*100),
+		},
+		Key: "drift|" + tenant,
+	})
+}
+
+// Start writes counted classifications to the database on the given interval
+// until ctx is cancelled
+func (m *Monitor) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Flush(ctx); err != nil {
+					log.Printf("[DRIFT] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Flush adds the counts since the last flush to classification_distribution.
+// Counts that fail to write are kept for the next flush.
+func (m *Monitor) Flush(ctx context.Context) error {
+	hour := time.Now().Unix() / 3600
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[bucketKey]int64)
+	for tenant, w := range m.windows {
+		if w.hour < hour {
+			delete(m.windows, tenant)
+		}
+	}
+	m.mu.Unlock()
+
+	if len(pending) == 0 || m.db == nil {
+		return nil
+	}
+
+	err := m.write(ctx, pending)
+	if err != nil {
+		m.mu.Lock()
+		for key, n := range pending {
+			m.pending[key] += n
+		}
+		m.mu.Unlock()
+	}
+	return err
+}
+
+func (m *Monitor) write(ctx context.Context, pending map[bucketKey]int64) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record classification distribution: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, n := range pending {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO classification_distribution (tenant_id, hour, category, complexity, fallback, requests)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (tenant_id, hour, category, complexity, fallback) DO UPDATE SET
+				requests = classification_distribution.requests + EXCLUDED.requests`,
+			key.tenant, time.Unix(key.hour*3600, 0).UTC(), key.category, key.complexity, key.fallback, n)
+		if err != nil {
+			return fmt.Errorf("failed to record classification distribution: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record classification distribution: %w", err)
+	}
+	return nil
+}
+
+// Distribution is the makeup of a tenant's classifications over a period
+type Distribution struct {
+	Requests       int64              `json:"requests"`
+	Categories     map[string]float64 `json:"categories"`   // Share per category
+	Complexities   map[string]float64 `json:"complexities"` // Share per complexity
+	BlindSpotShare float64            `json:"blind_spot_share"`
+	FallbackShare  float64            `json:"fallback_share"` // Prompts no category rule matched
+
+	categoryCounts   map[string]int64
+	complexityCounts map[string]int64
+	blindSpots       int64
+	fallbacks        int64
+}
+
+// Shift is the change in one category's share from the baseline
+type Shift struct {
+	Category string  `json:"category"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	Change   float64 `json:"change"`
+}
+
+// TenantDrift compares a tenant's recent classifications with its baseline
+type TenantDrift struct {
+	TenantID        string       `json:"tenant_id"` // "*" for all tenants, "anonymous" for unauthenticated traffic
+	Current         Distribution `json:"current"`
+	Baseline        Distribution `json:"baseline"`
+	CategoryDrift   float64      `json:"category_drift"`   // Total variation distance, 0 to 1
+	ComplexityDrift float64      `json:"complexity_drift"` // Total variation distance, 0 to 1
+	BlindSpotChange float64      `json:"blind_spot_change"`
+	Drifting        bool         `json:"drifting"`
+	Reasons         []string     `json:"reasons,omitempty"`
+	Shifts          []Shift      `json:"shifts"` // Largest category changes first
+}
+
+// Report is the drift of every tenant, most drifted first
+type Report struct {
+	GeneratedAt    time.Time     `json:"generated_at"`
+	Window         string        `json:"window"`
+	Baseline       string        `json:"baseline"`
+	DriftThreshold float64       `json:"drift_threshold"`
+	BlindSpotShare float64       `json:"blind_spot_threshold"`
+	Overall        TenantDrift   `json:"overall"`
+	Tenants        []TenantDrift `json:"tenants"`
+}
+
+// Report compares the last window of classifications with the baseline period
+// before it, for one tenant or, when tenant is empty, all of them
+func (m *Monitor) Report(ctx context.Context, tenant string, window, baseline time.Duration, limit int) (*Report, error) {
+	if err := m.Flush(ctx); err != nil {
+		log.Printf("[DRIFT] %v", err)
+	}
+
+	now := time.Now().UTC()
+	windowStart := now.Add(-window).Truncate(time.Hour)
+	baselineStart := windowStart.Add(-baseline)
+
+	query := `
+		SELECT tenant_id, hour >= $2, category, complexity, fallback, SUM(requests)
+		FROM classification_distribution
+		WHERE hour >= $1`
+	args := []interface{}{baselineStart, windowStart}
+	if tenant != "" {
+		query += ` AND tenant_id = $3`
+		args = append(args, tenantID(tenant))
+	}
+	query += ` GROUP BY 1, 2, 3, 4, 5`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load classification distribution: %w", err)
+	}
+	defer rows.Close()
+
+	byTenant := make(map[string]*TenantDrift)
+	overall := &TenantDrift{TenantID: AllTenants}
+	for rows.Next() {
+		var id, category, complexity string
+		var recent, fallback bool
+		var n int64
+		if err := rows.Scan(&id, &recent, &category, &complexity, &fallback, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan classification distribution: %w", err)
+		}
+		td := byTenant[id]
+		if td == nil {
+			td = &TenantDrift{TenantID: tenantLabel(id)}
+			byTenant[id] = td
+		}
+		for _, d := range []*TenantDrift{td, overall} {
+			dist := &d.Baseline
+			if recent {
+				dist = &d.Current
+			}
+			dist.add(category, complexity, fallback, m.blindSpots[category] || fallback, n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load classification distribution: %w", err)
+	}
+
+	report := &Report{
+		GeneratedAt:    now,
+		Window:         window.String(),
+		Baseline:       baseline.String(),
+		DriftThreshold: m.cfg.DriftThreshold,
+		BlindSpotShare: m.cfg.BlindSpotShare,
+		Tenants:        make([]TenantDrift, 0, len(byTenant)),
+	}
+	m.compare(overall)
+	report.Overall = *overall
+	for _, td := range byTenant {
+		m.compare(td)
+		report.Tenants = append(report.Tenants, *td)
+	}
+	sort.Slice(report.Tenants, func(i, j int) bool {
+		a, b := report.Tenants[i], report.Tenants[j]
+		if a.Drifting != b.Drifting {
+			return a.Drifting
+		}
+		if a.CategoryDrift != b.CategoryDrift {
+			return a.CategoryDrift > b.CategoryDrift
+		}
+		return a.TenantID < b.TenantID
+	})
+	if limit > 0 && len(report.Tenants) > limit {
+		report.Tenants = report.Tenants[:limit]
+	}
+	return report, nil
+}
+
+func (d *Distribution) add(category, complexity string, fallback, blindSpot bool, n int64) {
+	if d.categoryCounts == nil {
+		d.categoryCounts = make(map[string]int64)
+		d.complexityCounts = make(map[string]int64)
+	}
+	d.Requests += n
+	d.categoryCounts[category] += n
+	d.complexityCounts[complexity] += n
+	if blindSpot {
+		d.blindSpots += n
+	}
+	if fallback {
+		d.fallbacks += n
+	}
+}
+
+// finish turns counts into shares
+func (d *Distribution) finish() {
+	d.Categories = shares(d.categoryCounts, d.Requests)
+	d.Complexities = shares(d.complexityCounts, d.Requests)
+	if d.Requests > 0 {
+		d.BlindSpotShare = float64(d.blindSpots) / float64(d.Requests)
+		d.FallbackShare = float64(d.fallbacks) / float64(d.Requests)
+	}
+}
+
+// compare scores a tenant's current distribution against its baseline. Drift
+// is only judged once both periods have enough classifications.
+func (m *Monitor) compare(td *TenantDrift) {
+	td.Current.finish()
+	td.Baseline.finish()
+	td.CategoryDrift = variationDistance(td.Current.Categories, td.Baseline.Categories)
+	td.ComplexityDrift = variationDistance(td.Current.Complexities, td.Baseline.Complexities)
+	td.BlindSpotChange = td.Current.BlindSpotShare - td.Baseline.BlindSpotShare
+
+	for category := range union(td.Current.Categories, td.Baseline.Categories) {
+		td.Shifts = append(td.Shifts, Shift{
+			Category: category,
+			Baseline: td.Baseline.Categories[category],
+			Current:  td.Current.Categories[category],
+			Change:   td.Current.Categories[category] - td.Baseline.Categories[category],
+		})
+	}
+	sort.Slice(td.Shifts, func(i, j int) bool {
+		if math.Abs(td.Shifts[i].Change) != math.Abs(td.Shifts[j].Change) {
+			return math.Abs(td.Shifts[i].Change) > math.Abs(td.Shifts[j].Change)
+		}
+		return td.Shifts[i].Category < td.Shifts[j].Category
+	})
+	if len(td.Shifts) > 5 {
+		td.Shifts = td.Shifts[:5]
+	}
+
+	if td.Current.Requests >= int64(m.cfg.MinRequests) {
+		if td.Current.BlindSpotShare >= m.cfg.BlindSpotShare {
+			td.Reasons = append(td.Reasons, fmt.Sprintf("%.0f%% of prompts were blind spots", td.Current.BlindSpotShare*100))
+		}
+		if td.Baseline.Requests >= int64(m.cfg.MinRequests) {
+			if td.CategoryDrift >= m.cfg.DriftThreshold {
+				td.Reasons = append(td.Reasons, fmt.Sprintf("category mix moved %.2f from the baseline", td.CategoryDrift))
+			}
+			if td.ComplexityDrift >= m.cfg.DriftThreshold {
+				td.Reasons = append(td.Reasons, fmt.Sprintf("complexity mix moved %.2f from the baseline", td.ComplexityDrift))
+			}
+		}
+	}
+	td.Drifting = len(td.Reasons) > 0
+}
+
+func shares(counts map[string]int64, total int64) map[string]float64 {
+	out := make(map[string]float64, len(counts))
+	for k, n := range counts {
+		if total > 0 {
+			out[k] = float64(n) / float64(total)
+		}
+	}
+	return out
+}
+
+func union(a, b map[string]float64) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+// variationDistance is half the L1 distance between two distributions. An
+// empty side has nothing to compare against, so it scores 0.
+func variationDistance(p, q map[string]float64) float64 {
+	if len(p) == 0 || len(q) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for k := range union(p, q) {
+		sum += math.Abs(p[k] - q[k])
+	}
+	return sum / 2
+}
+
+// tenantLabel names a tenant in reports, where unauthenticated traffic is
+// stored under the empty tenant ID
+func tenantLabel(id string) string {
+	if id == anonymousTenant {
+		return "anonymous"
+	}
+	return id
+}
+
+func tenantID(label string) string {
+	if strings.EqualFold(label, "anonymous") {
+		return anonymousTenant
+	}
+	return label
+}
@@ -0,0 +1,110 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ciphertextPrefix marks stored values produced by Encrypt; values without it
+// are plaintext written before encryption was enabled
+const ciphertextPrefix = "enc:v1:"
+
+// Encryptor seals stored fields with AES-256-GCM under the owning tenant's
+// data key. Ciphertext names its key version, and the tenant and field are
+// bound as associated data so a value cannot be replayed into another row's
+// tenant or column.
+type Encryptor struct {
+	keys *KeyStore
+}
+
+func NewEncryptor(keys *KeyStore) *Encryptor {
+	return &Encryptor{keys: keys}
+}
+
+// Encrypt seals plaintext for tenant's field under the active data key. An
+// empty tenant uses the shared key.
+func (e *Encryptor) Encrypt(ctx context.Context, tenant, field, plaintext string) (string, error) {
+	tenant = tenantOrShared(tenant)
+	version, key, err := e.keys.ActiveKey(ctx, tenant)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), associatedData(tenant, field))
+	return ciphertextPrefix + strconv.Itoa(version) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values stored before encryption
+// was enabled are returned as they are.
+func (e *Encryptor) Decrypt(ctx context.Context, tenant, field, stored string) (string, error) {
+	if !IsEncrypted(stored) {
+		return stored, nil
+	}
+	tenant = tenantOrShared(tenant)
+
+	versionText, encoded, ok := strings.Cut(strings.TrimPrefix(stored, ciphertextPrefix), ":")
+	version, err := strconv.Atoi(versionText)
+	if !ok || err != nil {
+		return "", fmt.Errorf("malformed ciphertext for %s", field)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext for %s: %w", field, err)
+	}
+
+	key, err := e.keys.Key(ctx, tenant, version)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed ciphertext for %s", field)
+	}
+	nonce, body := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, body, associatedData(tenant, field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a stored value was produced by Encrypt
+func IsEncrypted(stored string) bool {
+	return strings.HasPrefix(stored, ciphertextPrefix)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func associatedData(tenant, field string) []byte {
+	return []byte(tenant + "\x00" + field)
+}
+
+func tenantOrShared(tenant string) string {
+	if tenant == "" {
+		return SharedTenant
+	}
+	return tenant
+}
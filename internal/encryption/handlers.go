@@ -0,0 +1,110 @@
+package encryption
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Reencrypter is a store of encrypted fields that can move a tenant's records
+// onto the active data key. An empty userID selects records without a tenant.
+type Reencrypter interface {
+	Name() string
+	ReencryptTenant(ctx context.Context, userID string) (int64, error)
+}
+
+// Handlers exposes data key rotation to admins
+type Handlers struct {
+	keys   *KeyStore
+	stores []Reencrypter
+}
+
+func NewHandlers(keys *KeyStore, stores ...Reencrypter) *Handlers {
+	return &Handlers{keys: keys, stores: stores}
+}
+
+// List returns the versions of a tenant's data key
+func (h *Handlers) List(c *gin.Context) {
+	tenant, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.keys.List(c.Request.Context(), tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list data keys",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+// Rotate creates a new data key version for a tenant and re-encrypts the
+// tenant's stored records under it, after which older versions are unused
+func (h *Handlers) Rotate(c *gin.Context) {
+	tenant, ok := tenantParam(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	version, err := h.keys.Rotate(ctx, tenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to rotate data key",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID := tenant
+	if tenant == SharedTenant {
+		userID = ""
+	}
+	reencrypted := make(map[string]int64, len(h.stores))
+	for _, store := range h.stores {
+		n, err := store.ReencryptTenant(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Data key rotated but re-encryption failed; records remain readable under the old key",
+				"details": err.Error(),
+				"version": version,
+				"store":   store.Name(),
+			})
+			return
+		}
+		reencrypted[store.Name()] = n
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"tenant":      tenant,
+			"version":     version,
+			"reencrypted": reencrypted,
+		},
+	})
+}
+
+// tenantParam reads a user ID or "shared" from the path
+func tenantParam(c *gin.Context) (string, bool) {
+	tenant := c.Param("tenant")
+	if tenant == SharedTenant {
+		return tenant, true
+	}
+	if _, err := uuid.Parse(tenant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tenant must be a user ID or \"" + SharedTenant + "\"",
+		})
+		return "", false
+	}
+	return tenant, true
+}
@@ -0,0 +1,189 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/vault"
+)
+
+const (
+	// dataKeySize is an AES-256 key
+	dataKeySize = 32
+	// SharedTenant owns the data key for records without a tenant
+	SharedTenant = "shared"
+)
+
+// DataKey is the metadata of one version of a tenant's data key. The key
+// itself is only ever stored wrapped by the vault.
+type DataKey struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Active    bool      `json:"active"`
+}
+
+// activeKey is the version new records are encrypted under
+type activeKey struct {
+	version   int
+	createdAt time.Time
+}
+
+// KeyStore keeps per-tenant data keys wrapped by the vault key and caches
+// unwrapped keys in memory. Rotating adds a version; old versions stay
+// readable until every record is re-encrypted.
+type KeyStore struct {
+	db     *sql.DB
+	vault  *vault.Vault
+	maxAge time.Duration
+
+	mu     sync.RWMutex
+	keys   map[string]map[int][]byte
+	active map[string]activeKey
+}
+
+func NewKeyStore(db *sql.DB, v *vault.Vault) *KeyStore {
+	return &KeyStore{
+		db:     db,
+		vault:  v,
+		keys:   make(map[string]map[int][]byte),
+		active: make(map[string]activeKey),
+	}
+}
+
+// SetMaxAge rotates a tenant's data key on first use after it is this old
+func (s *KeyStore) SetMaxAge(maxAge time.Duration) {
+	s.maxAge = maxAge
+}
+
+// ActiveKey returns the version and key new records for tenant are encrypted
+// under, creating the tenant's first key or rotating an expired one
+func (s *KeyStore) ActiveKey(ctx context.Context, tenant string) (int, []byte, error) {
+	s.mu.RLock()
+	active, ok := s.active[tenant]
+	s.mu.RUnlock()
+
+	if !ok {
+		err := s.db.QueryRowContext(ctx, `
+			SELECT version, created_at FROM data_keys
+			WHERE tenant_id = $1
+			ORDER BY version DESC
+			LIMIT 1`, tenant).Scan(&active.version, &active.createdAt)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, fmt.Errorf("failed to load data key: %w", err)
+		}
+		ok = err == nil
+	}
+	if !ok || s.expired(active) {
+		version, err := s.Rotate(ctx, tenant)
+		if err != nil {
+			return 0, nil, err
+		}
+		active.version = version
+	} else {
+		s.mu.Lock()
+		s.active[tenant] = active
+		s.mu.Unlock()
+	}
+
+	key, err := s.Key(ctx, tenant, active.version)
+	if err != nil {
+		return 0, nil, err
+	}
+	return active.version, key, nil
+}
+
+// Key returns one version of a tenant's data key
+func (s *KeyStore) Key(ctx context.Context, tenant string, version int) ([]byte, error) {
+	s.mu.RLock()
+	key, ok := s.keys[tenant][version]
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	var wrapped []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT wrapped_key FROM data_keys WHERE tenant_id = $1 AND version = $2`,
+		tenant, version).Scan(&wrapped)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("data key %s/%d not found", tenant, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data key: %w", err)
+	}
+
+	key, err = s.vault.Open(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key %s/%d: %w", tenant, version, err)
+	}
+	s.cache(tenant, version, key)
+	return key, nil
+}
+
+// Rotate creates a new data key version for tenant and makes it active
+func (s *KeyStore) Rotate(ctx context.Context, tenant string) (int, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return 0, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := s.vault.Seal(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var active activeKey
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO data_keys (tenant_id, version, wrapped_key)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2 FROM data_keys WHERE tenant_id = $1
+		RETURNING version, created_at`, tenant, wrapped).Scan(&active.version, &active.createdAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store data key: %w", err)
+	}
+
+	s.cache(tenant, active.version, key)
+	s.mu.Lock()
+	s.active[tenant] = active
+	s.mu.Unlock()
+	return active.version, nil
+}
+
+// List returns the versions of a tenant's data key, newest first
+func (s *KeyStore) List(ctx context.Context, tenant string) ([]DataKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT version, created_at FROM data_keys
+		WHERE tenant_id = $1
+		ORDER BY version DESC`, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []DataKey{}
+	for rows.Next() {
+		var k DataKey
+		if err := rows.Scan(&k.Version, &k.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan data key: %w", err)
+		}
+		k.Active = len(keys) == 0
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *KeyStore) expired(active activeKey) bool {
+	return s.maxAge > 0 && time.Since(active.createdAt) > s.maxAge
+}
+
+func (s *KeyStore) cache(tenant string, version int, key []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys[tenant] == nil {
+		s.keys[tenant] = make(map[int][]byte)
+	}
+	s.keys[tenant][version] = key
+}
@@ -0,0 +1,65 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/generate"
+)
+
+const judgeSystem = "You are a strict grader. Score the answer against the rubric from 0 (fails it entirely) to 10 (meets it fully). Reply with the number only."
+
+var judgeScore = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// grade scores one output between 0 and 1
+func (r *Runner) grade(ctx context.Context, c Case, output, judgeModel string) (float64, error) {
+	switch c.Grader.Type {
+	case GraderExact:
+		got, want := strings.TrimSpace(output), strings.TrimSpace(c.Grader.Expected)
+		if got == want || (c.Grader.CaseInsensitive && strings.EqualFold(got, want)) {
+			return 1, nil
+		}
+		return 0, nil
+
+	case GraderRegex:
+		if c.Grader.pattern.MatchString(output) {
+			return 1, nil
+		}
+		return 0, nil
+
+	case GraderLLMJudge:
+		return r.judge(ctx, c, output, judgeModel)
+	}
+	return 0, fmt.Errorf("unknown grader %q", c.Grader.Type)
+}
+
+// judge asks the judge model for a 0-10 score and normalizes it
+func (r *Runner) judge(ctx context.Context, c Case, output, judgeModel string) (float64, error) {
+	prompt := fmt.Sprintf("Rubric:\n%s\n\nQuestion:\n%s\n\nAnswer:\n%s", c.Grader.Rubric, c.Prompt, output)
+	zero := 0.0
+	resp, err := r.generator.Generate(ctx, generate.Request{
+		Model: judgeModel,
+		Messages: []generate.Message{
+			{Role: "system", Content: judgeSystem},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   8,
+		Temperature: &zero,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("judge failed: %w", err)
+	}
+
+	match := judgeScore.FindString(resp.Content)
+	if match == "" {
+		return 0, fmt.Errorf("judge returned no score: %q", resp.Content)
+	}
+	score, _ := strconv.ParseFloat(match, 64)
+	if score > 10 {
+		score = 10
+	}
+	return score / 10, nil
+}
@@ -0,0 +1,96 @@
+package eval
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes eval suites and runs to admins
+type Handlers struct {
+	runner *Runner
+}
+
+type StartRunRequest struct {
+	Models     []string `json:"models" binding:"required,min=1"`
+	JudgeModel string   `json:"judge_model"` // Required when the suite has llm_judge cases
+}
+
+type suiteSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Description string `json:"description,omitempty"`
+	Cases       int    `json:"cases"`
+	NeedsJudge  bool   `json:"needs_judge"`
+}
+
+func NewHandlers(runner *Runner) *Handlers {
+	return &Handlers{runner: runner}
+}
+
+// List returns the loaded suites and recent runs
+func (h *Handlers) List(c *gin.Context) {
+	suites := h.runner.Suites()
+	summaries := make([]suiteSummary, 0, len(suites))
+	for _, s := range suites {
+		summaries = append(summaries, suiteSummary{
+			ID:          s.ID,
+			Name:        s.Name,
+			Category:    s.Category,
+			Description: s.Description,
+			Cases:       len(s.Cases),
+			NeedsJudge:  s.needsJudge(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"suites": summaries,
+			"runs":   h.runner.List(),
+		},
+	})
+}
+
+// StartRun runs a suite against the given models in the background
+func (h *Handlers) StartRun(c *gin.Context) {
+	var req StartRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	run, err := h.runner.Start(c.Param("id"), req.Models, req.JudgeModel)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to start eval run",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// GetRun returns a run with its per-case results
+func (h *Handlers) GetRun(c *gin.Context) {
+	run, ok := h.runner.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Eval run not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
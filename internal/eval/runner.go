@@ -0,0 +1,411 @@
+package eval
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Askeban/llm-router-go/internal/generate"
+	"github.com/Askeban/llm-router-go/internal/ingest"
+)
+
+// SourceEval labels eval suite scores in the metrics store
+const SourceEval = "eval"
+
+// Run statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+const (
+	// runTimeout bounds a whole suite run across all models
+	runTimeout = 30 * time.Minute
+	// caseMaxTokens caps each graded answer
+	caseMaxTokens = 1024
+	// maxOutputChars is how much of each answer a run keeps for review
+	maxOutputChars = 500
+	// keptRuns is how many recent runs are kept in memory
+	keptRuns = 50
+)
+
+// Generator produces model outputs; *generate.Generator satisfies it
+type Generator interface {
+	Generate(ctx context.Context, req generate.Request) (generate.Response, error)
+}
+
+// BenchmarkSink receives measured per-category scores for routing;
+// *models.FusionService satisfies it
+type BenchmarkSink interface {
+	SetMeasuredBenchmarks(modelID string, scores map[string]float64)
+}
+
+// Run is one execution of a suite against a set of models
+type Run struct {
+	ID         string             `json:"id"`
+	SuiteID    string             `json:"suite_id"`
+	Category   string             `json:"category"`
+	Models     []string           `json:"models"`
+	JudgeModel string             `json:"judge_model,omitempty"`
+	Status     string             `json:"status"`
+	Scores     map[string]float64 `json:"scores"`               // Mean score per model with a complete run
+	Incomplete []string           `json:"incomplete,omitempty"` // Models with failed generations; their scores are not recorded
+	Results    []CaseResult       `json:"results,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+}
+
+// CaseResult is one model's graded answer to one case
+type CaseResult struct {
+	ModelID   string  `json:"model_id"`
+	CaseID    string  `json:"case_id"`
+	Score     float64 `json:"score"`
+	Output    string  `json:"output,omitempty"`
+	CostUSD   float64 `json:"cost_usd"`
+	LatencyMs int64   `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Runner executes eval suites against live providers, stores the scores as
+// metrics and feeds them back into routing
+type Runner struct {
+	db        *sql.DB
+	generator Generator
+	ingester  *ingest.Ingester
+	sink      BenchmarkSink
+	suites    map[string]*Suite
+
+	mu     sync.Mutex
+	runs   map[string]*Run
+	order  []string
+	latest map[string]map[string]float64 // Latest score by model and suite
+}
+
+func NewRunner(db *sql.DB, generator Generator, ingester *ingest.Ingester, suites map[string]*Suite) *Runner {
+	return &Runner{
+		db:        db,
+		generator: generator,
+		ingester:  ingester,
+		suites:    suites,
+		runs:      make(map[string]*Run),
+		latest:    make(map[string]map[string]float64),
+	}
+}
+
+// SetBenchmarkSink routes with measured scores as they are recorded
+func (r *Runner) SetBenchmarkSink(sink BenchmarkSink) {
+	r.sink = sink
+}
+
+// Suites returns the loaded suites ordered by ID
+func (r *Runner) Suites() []*Suite {
+	suites := make([]*Suite, 0, len(r.suites))
+	for _, s := range r.suites {
+		suites = append(suites, s)
+	}
+	sort.Slice(suites, func(i, j int) bool { return suites[i].ID < suites[j].ID })
+	return suites
+}
+
+// Restore reloads the latest stored scores so routing keeps them across restarts
+func (r *Runner) Restore(ctx context.Context) error {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT model_id, metric, value FROM model_metrics WHERE source = $1`, SourceEval)
+	if err != nil {
+		return fmt.Errorf("failed to load eval scores: %w", err)
+	}
+	defer rows.Close()
+
+	restored := 0
+	r.mu.Lock()
+	for rows.Next() {
+		var modelID, suiteID string
+		var score float64
+		if err := rows.Scan(&modelID, &suiteID, &score); err != nil {
+			r.mu.Unlock()
+			return fmt.Errorf("failed to scan eval score: %w", err)
+		}
+		// Scores of suites that were removed no longer count
+		if _, ok := r.suites[suiteID]; !ok {
+			continue
+		}
+		r.recordLocked(modelID, suiteID, score)
+		restored++
+	}
+	modelIDs := make([]string, 0, len(r.latest))
+	for modelID := range r.latest {
+		modelIDs = append(modelIDs, modelID)
+	}
+	r.mu.Unlock()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	r.publish(modelIDs)
+	log.Printf("[EVAL] Restored %d eval scores for %d models", restored, len(modelIDs))
+	return nil
+}
+
+// Watch reloads stored scores periodically so replicas pick up runs executed elsewhere
+func (r *Runner) Watch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Restore(ctx); err != nil {
+					log.Printf("[EVAL] %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Start validates a run and executes it in the background
+func (r *Runner) Start(suiteID string, modelIDs []string, judgeModel string) (Run, error) {
+	suite, ok := r.suites[suiteID]
+	if !ok {
+		return Run{}, fmt.Errorf("unknown eval suite %q", suiteID)
+	}
+	if len(modelIDs) == 0 {
+		return Run{}, fmt.Errorf("at least one model is required")
+	}
+	if suite.needsJudge() && judgeModel == "" {
+		return Run{}, fmt.Errorf("suite %s has llm_judge cases, so judge_model is required", suiteID)
+	}
+
+	run := &Run{
+		ID:         uuid.New().String(),
+		SuiteID:    suite.ID,
+		Category:   suite.Category,
+		Models:     modelIDs,
+		JudgeModel: judgeModel,
+		Status:     StatusRunning,
+		Scores:     map[string]float64{},
+		StartedAt:  time.Now(),
+	}
+
+	r.mu.Lock()
+	r.runs[run.ID] = run
+	r.order = append(r.order, run.ID)
+	if len(r.order) > keptRuns {
+		delete(r.runs, r.order[0])
+		r.order = r.order[1:]
+	}
+	snapshot := *run
+	r.mu.Unlock()
+
+	go r.execute(run, suite)
+	return snapshot, nil
+}
+
+// Get returns a run by ID
+func (r *Runner) Get(id string) (Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return Run{}, false
+	}
+	return *run, true
+}
+
+// List returns recent runs, newest first, without per-case results
+func (r *Runner) List() []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := make([]Run, 0, len(r.order))
+	for i := len(r.order) - 1; i >= 0; i-- {
+		run := *r.runs[r.order[i]]
+		run.Results = nil
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+func (r *Runner) execute(run *Run, suite *Suite) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	log.Printf("[EVAL] Run %s: suite %s against %d models", run.ID, suite.ID, len(run.Models))
+
+	// Models run concurrently; each model's cases run in order
+	results := make([][]CaseResult, len(run.Models))
+	var wg sync.WaitGroup
+	for i, modelID := range run.Models {
+		wg.Add(1)
+		go func(i int, modelID string) {
+			defer wg.Done()
+			results[i] = r.runModel(ctx, suite, modelID, run.JudgeModel)
+		}(i, modelID)
+	}
+	wg.Wait()
+
+	scores := make(map[string]float64)
+	var incomplete []string
+	var all []CaseResult
+	for i, modelID := range run.Models {
+		var total float64
+		failed := false
+		for _, res := range results[i] {
+			total += res.Score
+			failed = failed || res.Error != ""
+		}
+		all = append(all, results[i]...)
+		// A model that could not answer every case is not scored, so an
+		// outage or missing key never reads as poor quality
+		if failed {
+			incomplete = append(incomplete, modelID)
+			continue
+		}
+		scores[modelID] = math.Round(total/float64(len(results[i]))*1000) / 1000
+	}
+
+	status, errMsg := StatusCompleted, ""
+	if len(scores) > 0 {
+		if err := r.store(ctx, suite, scores); err != nil {
+			status, errMsg = StatusFailed, err.Error()
+		}
+	} else if len(incomplete) > 0 {
+		status, errMsg = StatusFailed, "no model completed every case"
+	}
+
+	finished := time.Now()
+	r.mu.Lock()
+	run.Status = status
+	run.Error = errMsg
+	run.Scores = scores
+	run.Incomplete = incomplete
+	run.Results = all
+	run.FinishedAt = &finished
+	r.mu.Unlock()
+
+	log.Printf("[EVAL] Run %s %s: scores=%v incomplete=%v", run.ID, status, scores, incomplete)
+}
+
+func (r *Runner) runModel(ctx context.Context, suite *Suite, modelID, judgeModel string) []CaseResult {
+	results := make([]CaseResult, 0, len(suite.Cases))
+	zero := 0.0
+
+	for _, c := range suite.Cases {
+		res := CaseResult{ModelID: modelID, CaseID: c.ID}
+
+		var messages []generate.Message
+		if c.System != "" {
+			messages = append(messages, generate.Message{Role: "system", Content: c.System})
+		}
+		messages = append(messages, generate.Message{Role: "user", Content: c.Prompt})
+
+		start := time.Now()
+		resp, err := r.generator.Generate(ctx, generate.Request{
+			Model:       modelID,
+			Messages:    messages,
+			MaxTokens:   caseMaxTokens,
+			Temperature: &zero,
+		})
+		res.LatencyMs = time.Since(start).Milliseconds()
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		res.CostUSD = resp.Usage.CostUSD
+		res.Output = truncate(resp.Content, maxOutputChars)
+
+		score, err := r.grade(ctx, c, resp.Content, judgeModel)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		res.Score = score
+		results = append(results, res)
+	}
+	return results
+}
+
+// store persists suite scores as metrics and feeds them into routing
+func (r *Runner) store(ctx context.Context, suite *Suite, scores map[string]float64) error {
+	now := time.Now()
+	batch := make([]ingest.Metric, 0, len(scores))
+	modelIDs := make([]string, 0, len(scores))
+	for modelID, score := range scores {
+		batch = append(batch, ingest.Metric{
+			ModelID:    modelID,
+			Source:     SourceEval,
+			Metric:     suite.ID,
+			Value:      score,
+			ObservedAt: now,
+		})
+		modelIDs = append(modelIDs, modelID)
+	}
+
+	result, err := r.ingester.UpsertMetrics(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("failed to store eval scores: %w", err)
+	}
+	if result.DeadLettered > 0 {
+		log.Printf("[EVAL] %d eval scores dead-lettered in batch %s", result.DeadLettered, result.BatchID)
+	}
+
+	r.mu.Lock()
+	for modelID, score := range scores {
+		r.recordLocked(modelID, suite.ID, score)
+	}
+	r.mu.Unlock()
+
+	r.publish(modelIDs)
+	return nil
+}
+
+func (r *Runner) recordLocked(modelID, suiteID string, score float64) {
+	if r.latest[modelID] == nil {
+		r.latest[modelID] = make(map[string]float64)
+	}
+	r.latest[modelID][suiteID] = score
+}
+
+// publish hands each model's per-category averages across suites to routing
+func (r *Runner) publish(modelIDs []string) {
+	if r.sink == nil {
+		return
+	}
+
+	for _, modelID := range modelIDs {
+		r.mu.Lock()
+		totals := make(map[string]float64)
+		counts := make(map[string]int)
+		for suiteID, score := range r.latest[modelID] {
+			category := r.suites[suiteID].Category
+			totals[category] += score
+			counts[category]++
+		}
+		r.mu.Unlock()
+
+		byCategory := make(map[string]float64, len(totals))
+		for category, total := range totals {
+			byCategory[category] = math.Round(total/float64(counts[category])*1000) / 1000
+		}
+		r.sink.SetMeasuredBenchmarks(modelID, byCategory)
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
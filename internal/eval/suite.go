@@ -0,0 +1,131 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Grader types
+const (
+	GraderExact    = "exact"     // Output equals the expected answer after trimming
+	GraderRegex    = "regex"     // Output matches a pattern
+	GraderLLMJudge = "llm_judge" // A judge model scores the output against a rubric
+)
+
+// Suite is a set of prompts measuring one routing category
+type Suite struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Category    string `json:"category"` // Routing category the scores feed
+	Description string `json:"description,omitempty"`
+	Cases       []Case `json:"cases"`
+}
+
+// Case is one prompt and how its output is graded
+type Case struct {
+	ID     string `json:"id"`
+	System string `json:"system,omitempty"`
+	Prompt string `json:"prompt"`
+	Grader Grader `json:"grader"`
+}
+
+// Grader describes how a case's output is scored between 0 and 1
+type Grader struct {
+	Type            string `json:"type"`
+	Expected        string `json:"expected,omitempty"`         // exact
+	CaseInsensitive bool   `json:"case_insensitive,omitempty"` // exact
+	Pattern         string `json:"pattern,omitempty"`          // regex
+	Rubric          string `json:"rubric,omitempty"`           // llm_judge
+
+	pattern *regexp.Regexp
+}
+
+// Validate checks the suite and compiles its regex graders
+func (s *Suite) Validate() error {
+	if s.ID == "" || s.Category == "" {
+		return fmt.Errorf("id and category are required")
+	}
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("suite %s has no cases", s.ID)
+	}
+
+	seen := make(map[string]bool, len(s.Cases))
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("case_%d", i+1)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("suite %s: duplicate case id %s", s.ID, c.ID)
+		}
+		seen[c.ID] = true
+		if strings.TrimSpace(c.Prompt) == "" {
+			return fmt.Errorf("suite %s case %s: prompt is required", s.ID, c.ID)
+		}
+
+		switch c.Grader.Type {
+		case GraderExact:
+			if c.Grader.Expected == "" {
+				return fmt.Errorf("suite %s case %s: exact grader needs expected", s.ID, c.ID)
+			}
+		case GraderRegex:
+			re, err := regexp.Compile(c.Grader.Pattern)
+			if err != nil || c.Grader.Pattern == "" {
+				return fmt.Errorf("suite %s case %s: invalid pattern %q", s.ID, c.ID, c.Grader.Pattern)
+			}
+			c.Grader.pattern = re
+		case GraderLLMJudge:
+			if c.Grader.Rubric == "" {
+				return fmt.Errorf("suite %s case %s: llm_judge grader needs a rubric", s.ID, c.ID)
+			}
+		default:
+			return fmt.Errorf("suite %s case %s: unknown grader %q", s.ID, c.ID, c.Grader.Type)
+		}
+	}
+	return nil
+}
+
+// needsJudge reports whether any case is graded by a judge model
+func (s *Suite) needsJudge() bool {
+	for _, c := range s.Cases {
+		if c.Grader.Type == GraderLLMJudge {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSuites reads every *.json suite in dir. A missing directory yields no suites.
+func LoadSuites(dir string) (map[string]*Suite, error) {
+	suites := make(map[string]*Suite)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list eval suites: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read eval suite %s: %w", file, err)
+		}
+		var suite Suite
+		if err := json.Unmarshal(data, &suite); err != nil {
+			return nil, fmt.Errorf("failed to parse eval suite %s: %w", file, err)
+		}
+		if err := suite.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid eval suite %s: %w", file, err)
+		}
+		if _, dup := suites[suite.ID]; dup {
+			return nil, fmt.Errorf("eval suite %s is defined twice", suite.ID)
+		}
+		suites[suite.ID] = &suite
+	}
+	return suites, nil
+}
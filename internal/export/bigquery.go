@@ -0,0 +1,224 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+const (
+	bigQueryAPI      = "https://bigquery.googleapis.com/bigquery/v2"
+	bigQueryScope    = "https://www.googleapis.com/auth/bigquery"
+	googleTokenURL   = "https://oauth2.googleapis.com/token"
+	bigQueryRowChunk = 500 // insertAll rows per request
+)
+
+// bigQuerySink streams batches into BigQuery tables with insertAll, using the
+// record ID as insertId so a retried batch is deduplicated
+type bigQuerySink struct {
+	project string
+	dataset string
+	tokens  oauth2.TokenSource
+	client  *http.Client
+}
+
+func newBigQuerySink(settings map[string]string, credentials []byte) (Sink, error) {
+	var key struct {
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		PrivateKeyID string `json:"private_key_id"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(credentials, &key); err != nil {
+		return nil, fmt.Errorf("bigquery credentials must be a service account key file: %w", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, fmt.Errorf("bigquery credentials must include client_email and private_key")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = googleTokenURL
+	}
+
+	client := transport.Client("export")
+	cfg := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{bigQueryScope},
+		TokenURL:     key.TokenURI,
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	return &bigQuerySink{
+		project: settings["project"],
+		dataset: settings["dataset"],
+		tokens:  cfg.TokenSource(ctx),
+		client:  client,
+	}, nil
+}
+
+// EnsureSchema creates the table, day-partitioned on its timestamp column, or
+// adds the columns an existing table lacks
+func (s *bigQuerySink) EnsureSchema(ctx context.Context, table string, columns []Column) error {
+	fields := make([]map[string]string, 0, len(columns))
+	for _, col := range columns {
+		fields = append(fields, map[string]string{"name": col.Name, "type": col.Type, "mode": "NULLABLE"})
+	}
+	create := map[string]interface{}{
+		"tableReference": map[string]string{"projectId": s.project, "datasetId": s.dataset, "tableId": table},
+		"schema":         map[string]interface{}{"fields": fields},
+		"timePartitioning": map[string]string{
+			"type":  "DAY",
+			"field": columns[len(columns)-1].Name,
+		},
+	}
+	tables := fmt.Sprintf("%s/projects/%s/datasets/%s/tables", bigQueryAPI, url.PathEscape(s.project), url.PathEscape(s.dataset))
+	status, body, err := s.call(ctx, http.MethodPost, tables, create)
+	if err != nil {
+		return fmt.Errorf("failed to create bigquery table %s: %w", table, err)
+	}
+	if status < 300 {
+		return nil
+	}
+	if status != http.StatusConflict {
+		return fmt.Errorf("failed to create bigquery table %s: status %d: %s", table, status, body)
+	}
+
+	// The table exists: append the columns it lacks
+	tableURL := tables + "/" + url.PathEscape(table)
+	status, body, err = s.call(ctx, http.MethodGet, tableURL, nil)
+	if err == nil && status >= 300 {
+		err = fmt.Errorf("status %d: %s", status, body)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read bigquery table %s: %w", table, err)
+	}
+	var existing struct {
+		Schema struct {
+			Fields []map[string]interface{} `json:"fields"`
+		} `json:"schema"`
+	}
+	if err := json.Unmarshal(body, &existing); err != nil {
+		return fmt.Errorf("failed to decode bigquery table %s: %w", table, err)
+	}
+	have := make(map[string]bool)
+	for _, f := range existing.Schema.Fields {
+		name, _ := f["name"].(string)
+		have[strings.ToLower(name)] = true
+	}
+	merged := existing.Schema.Fields
+	for _, f := range fields {
+		if !have[f["name"]] {
+			merged = append(merged, map[string]interface{}{"name": f["name"], "type": f["type"], "mode": f["mode"]})
+		}
+	}
+	if len(merged) == len(existing.Schema.Fields) {
+		return nil
+	}
+	patch := map[string]interface{}{"schema": map[string]interface{}{"fields": merged}}
+	status, body, err = s.call(ctx, http.MethodPatch, tableURL, patch)
+	if err == nil && status >= 300 {
+		err = fmt.Errorf("status %d: %s", status, body)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add columns to bigquery table %s: %w", table, err)
+	}
+	return nil
+}
+
+// Write streams the batch in chunks. BigQuery drops a row whose insertId it
+// has seen in the last minute or so; later duplicates are removed by
+// selecting DISTINCT id.
+func (s *bigQuerySink) Write(ctx context.Context, batch Batch) error {
+	insertAll := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll", bigQueryAPI,
+		url.PathEscape(s.project), url.PathEscape(s.dataset), url.PathEscape(batch.Table))
+
+	for start := 0; start < len(batch.Rows); start += bigQueryRowChunk {
+		end := start + bigQueryRowChunk
+		if end > len(batch.Rows) {
+			end = len(batch.Rows)
+		}
+		rows := make([]map[string]interface{}, 0, end-start)
+		for r := start; r < end; r++ {
+			record := make(map[string]interface{}, len(batch.Columns))
+			for i, col := range batch.Columns {
+				v := batch.Rows[r][i]
+				if t, ok := v.(time.Time); ok {
+					v = t.UTC().Format(time.RFC3339Nano)
+				}
+				record[col.Name] = v
+			}
+			rows = append(rows, map[string]interface{}{"insertId": batch.ID(r), "json": record})
+		}
+
+		status, body, err := s.call(ctx, http.MethodPost, insertAll, map[string]interface{}{"rows": rows})
+		if err != nil {
+			return fmt.Errorf("failed to insert into bigquery table %s: %w", batch.Table, err)
+		}
+		if status >= 300 {
+			return fmt.Errorf("failed to insert into bigquery table %s: status %d: %s", batch.Table, status, body)
+		}
+		var result struct {
+			InsertErrors []struct {
+				Index  int `json:"index"`
+				Errors []struct {
+					Reason  string `json:"reason"`
+					Message string `json:"message"`
+				} `json:"errors"`
+			} `json:"insertErrors"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("failed to decode bigquery insert response: %w", err)
+		}
+		if n := len(result.InsertErrors); n > 0 {
+			first := result.InsertErrors[0]
+			msg := "unknown error"
+			if len(first.Errors) > 0 {
+				msg = first.Errors[0].Reason + ": " + first.Errors[0].Message
+			}
+			return fmt.Errorf("bigquery rejected %d rows of %s (row %d: %s)", n, batch.Table, start+first.Index, msg)
+		}
+	}
+	return nil
+}
+
+// call sends an authorized JSON request and returns the status and body
+func (s *bigQuerySink) call(ctx context.Context, method, endpoint string, payload interface{}) (int, []byte, error) {
+	token, err := s.tokens.Token()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch bigquery access token: %w", err)
+	}
+
+	var reader io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, body, err
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Datasets a destination can receive
+const (
+	DatasetUsage = "usage" // api_usage rows
+	DatasetAudit = "audit" // audit_log entries, without prompts
+)
+
+// Sink kinds
+const (
+	SinkBigQuery  = "bigquery"
+	SinkSnowflake = "snowflake"
+	SinkS3Parquet = "s3_parquet"
+)
+
+// Column types, named as in BigQuery; each sink maps them to its own
+const (
+	TypeString    = "STRING"
+	TypeInt64     = "INT64"
+	TypeFloat64   = "FLOAT64"
+	TypeTimestamp = "TIMESTAMP"
+)
+
+// SchemaVersion increases whenever a dataset gains columns. Destinations
+// exported under an older version have their tables extended on the next run.
+const SchemaVersion = 2
+
+// Column is one field of an exported dataset. Every column is nullable.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Schemas are the exported columns of each dataset. Columns may be added, but
+// never renamed or removed, so existing warehouse tables stay loadable.
+var Schemas = map[string][]Column{
+	DatasetUsage: {
+		{Name: "id", Type: TypeString},
+		{Name: "user_id", Type: TypeString},
+		{Name: "api_key_id", Type: TypeString},
+		{Name: "endpoint", Type: TypeString},
+		{Name: "method", Type: TypeString},
+		{Name: "prompt_category", Type: TypeString},
+		{Name: "recommended_model", Type: TypeString},
+		{Name: "tokens_estimated", Type: TypeInt64},
+		{Name: "cost_usd", Type: TypeFloat64},
+		{Name: "response_time_ms", Type: TypeInt64},
+		{Name: "status_code", Type: TypeInt64},
+		{Name: "error_message", Type: TypeString},
+		{Name: "duplicate_kind", Type: TypeString},
+		{Name: "metadata", Type: TypeString}, // JSON
+		{Name: "tags", Type: TypeString},     // JSON; added in schema version 2
+		{Name: "requested_at", Type: TypeTimestamp},
+	},
+	DatasetAudit: {
+		{Name: "id", Type: TypeString},
+		{Name: "user_id", Type: TypeString},
+		{Name: "event_type", Type: TypeString},
+		{Name: "action", Type: TypeString},
+		{Name: "resource", Type: TypeString},
+		{Name: "details", Type: TypeString}, // JSON
+		{Name: "created_at", Type: TypeTimestamp},
+	},
+}
+
+// Batch is a page of one dataset's records, oldest first. Each row holds one
+// value per column: string, int64, float64, time.Time or nil. The first
+// column is the record ID and the last its timestamp.
+type Batch struct {
+	Dataset string
+	Table   string // The destination table (or object prefix) for the dataset
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+// ID returns a row's record ID
+func (b Batch) ID(row int) string {
+	id, _ := b.Rows[row][0].(string)
+	return id
+}
+
+// Time returns a row's timestamp
+func (b Batch) Time(row int) time.Time {
+	t, _ := b.Rows[row][len(b.Columns)-1].(time.Time)
+	return t
+}
+
+// Sink writes batches to a warehouse or bucket
+type Sink interface {
+	// EnsureSchema creates the table for a dataset, or adds the columns it lacks
+	EnsureSchema(ctx context.Context, table string, columns []Column) error
+	// Write appends a batch. Writing the same batch again must not duplicate rows,
+	// since a run that fails midway is retried from the last saved cursor.
+	Write(ctx context.Context, batch Batch) error
+}
+
+// identifier matches the table prefixes and names sinks accept
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// settingsFor lists each kind's settings, and which are required
+var settingsFor = map[string]map[string]bool{
+	SinkBigQuery:  {"project": true, "dataset": true, "table_prefix": false},
+	SinkSnowflake: {"account": true, "user": true, "database": true, "schema": true, "warehouse": true, "role": false, "table_prefix": false},
+	SinkS3Parquet: {"bucket": true, "prefix": false, "region": false, "endpoint": false},
+}
+
+// validateSettings checks a destination's settings for its kind
+func validateSettings(kind string, settings map[string]string) error {
+	known, ok := settingsFor[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q; use bigquery, snowflake or s3_parquet", kind)
+	}
+	for name, value := range settings {
+		if _, ok := known[name]; !ok {
+			return fmt.Errorf("unknown %s setting %q", kind, name)
+		}
+		if name == "table_prefix" && value != "" && !identifier.MatchString(value) {
+			return fmt.Errorf("table_prefix must be letters, digits and underscores")
+		}
+	}
+	for name, required := range known {
+		if required && settings[name] == "" {
+			return fmt.Errorf("%s setting %q is required", kind, name)
+		}
+	}
+	return nil
+}
+
+// tableName is where a dataset lands: table_prefix (default "router_") and the dataset
+func tableName(settings map[string]string, dataset string) string {
+	prefix, ok := settings["table_prefix"]
+	if !ok {
+		prefix = "router_"
+	}
+	return prefix + dataset
+}
+
+// newSink opens the sink for a destination with its unsealed credentials
+func newSink(kind string, settings map[string]string, credentials []byte) (Sink, error) {
+	switch kind {
+	case SinkBigQuery:
+		return newBigQuerySink(settings, credentials)
+	case SinkSnowflake:
+		return newSnowflakeSink(settings, credentials)
+	case SinkS3Parquet:
+		return newS3ParquetSink(settings, credentials)
+	}
+	return nil, fmt.Errorf("unknown kind %q", kind)
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// Exporter batches and bounds
+const (
+	batchRows     = 1000             // Records read and written per batch
+	maxRunBatches = 50               // Per dataset per run; the rest is left as backlog
+	maxClaimed    = 5                // Destinations a replica runs per tick
+	runLease      = 15 * time.Minute // Upper bound of one destination's run
+	settleDelay   = time.Minute      // Records younger than this are left for the next run
+)
+
+// source is where a dataset's records are read from. Columns are selected in
+// Schemas order; the first is the ID and the last the timestamp.
+type source struct {
+	table      string
+	selects    []string
+	timeColumn string
+}
+
+var sources = map[string]source{
+	DatasetUsage: {
+		table: "api_usage",
+		selects: []string{"id::text", "user_id::text", "api_key_id::text", "endpoint", "method",
+			"prompt_category", "recommended_model", "tokens_estimated", "cost_usd::float8",
+			"response_time_ms", "status_code", "error_message", "duplicate_kind", "metadata::text", "tags::text", "timestamp"},
+		timeColumn: "timestamp",
+	},
+	DatasetAudit: {
+		table: "audit_log",
+		selects: []string{"id::text", "user_id::text", "event_type", "action", "resource",
+			"details::text", "created_at"},
+		timeColumn: "created_at",
+	},
+}
+
+// Exporter runs due export destinations: it claims them, ensures their tables,
+// and writes each dataset's new records from the saved cursor onward
+type Exporter struct {
+	db    *sql.DB
+	store *Store
+}
+
+func NewExporter(db *sql.DB, store *Store) *Exporter {
+	return &Exporter{db: db, store: store}
+}
+
+// Start runs due destinations on the given interval until ctx is cancelled
+func (e *Exporter) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.RunDue(ctx)
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// RunDue claims the destinations that are due and runs them one by one
+func (e *Exporter) RunDue(ctx context.Context) {
+	due, err := e.store.claim(ctx, runLease, maxClaimed)
+	if err != nil {
+		log.Printf("[EXPORT] %v", err)
+		return
+	}
+	for _, d := range due {
+		e.run(ctx, d)
+	}
+}
+
+// run exports every dataset of one destination and records the outcome
+func (e *Exporter) run(ctx context.Context, d claimed) {
+	runCtx, cancel := context.WithTimeout(ctx, runLease)
+	defer cancel()
+
+	schemaVersion := d.SchemaVersion
+	backlog := false
+	runErr := func() error {
+		sink, err := newSink(d.Kind, d.Settings, d.credentials)
+		if err != nil {
+			return err
+		}
+		if schemaVersion < SchemaVersion {
+			for _, ds := range d.Datasets {
+				if err := sink.EnsureSchema(runCtx, tableName(d.Settings, ds), Schemas[ds]); err != nil {
+					return err
+				}
+			}
+			schemaVersion = SchemaVersion
+		}
+		for _, ds := range d.Datasets {
+			more, rows, err := e.exportDataset(runCtx, sink, d.Destination, ds)
+			if rows > 0 {
+				log.Printf("[EXPORT] %s (%s) of tenant %s: %d %s records", d.Name, d.Kind, d.UserID, rows, ds)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", ds, err)
+			}
+			backlog = backlog || more
+		}
+		return nil
+	}()
+	if runErr != nil {
+		log.Printf("[EXPORT] %s (%s) of tenant %s failed: %v", d.Name, d.Kind, d.UserID, runErr)
+	}
+
+	// Record the run even when ctx was cancelled mid-way, so the lease is released
+	if err := e.store.finish(context.Background(), d.ID, schemaVersion, runErr, backlog); err != nil {
+		log.Printf("[EXPORT] %v", err)
+	}
+}
+
+// exportDataset writes a dataset's records past the cursor, batch by batch,
+// saving the cursor after each. It reports whether records were left over.
+func (e *Exporter) exportDataset(ctx context.Context, sink Sink, d Destination, dataset string) (bool, int, error) {
+	cursor, err := e.store.cursor(ctx, d.ID, dataset)
+	if err != nil {
+		return false, 0, err
+	}
+	until := time.Now().Add(-settleDelay)
+	table := tableName(d.Settings, dataset)
+
+	exported := 0
+	for i := 0; i < maxRunBatches; i++ {
+		batch, err := e.read(ctx, d.UserID, dataset, cursor, until)
+		if err != nil {
+			return false, exported, err
+		}
+		if len(batch.Rows) == 0 {
+			return false, exported, nil
+		}
+		batch.Table = table
+		if err := sink.Write(ctx, batch); err != nil {
+			return false, exported, err
+		}
+
+		last := len(batch.Rows) - 1
+		cursor.LastTime, cursor.LastID = batch.Time(last), batch.ID(last)
+		if err := e.store.advance(ctx, d.ID, cursor, len(batch.Rows)); err != nil {
+			return false, exported, err
+		}
+		exported += len(batch.Rows)
+		if len(batch.Rows) < batchRows {
+			return false, exported, nil
+		}
+	}
+	return true, exported, nil
+}
+
+// read returns the tenant's next batch of a dataset after the cursor, keyset
+// paginated on (timestamp, id) so records sharing a timestamp are not skipped
+func (e *Exporter) read(ctx context.Context, userID, dataset string, cursor Cursor, until time.Time) (Batch, error) {
+	src := sources[dataset]
+	columns := Schemas[dataset]
+	query := fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE user_id = $1 AND (%s, id) > ($2, $3) AND %s < $4
+		ORDER BY %s, id
+		LIMIT %d`,
+		strings.Join(src.selects, ", "), src.table, src.timeColumn, src.timeColumn, src.timeColumn, batchRows)
+
+	rows, err := e.db.QueryContext(ctx, query, userID, cursor.LastTime, cursor.LastID, until)
+	if err != nil {
+		return Batch{}, fmt.Errorf("failed to read %s records: %w", dataset, err)
+	}
+	defer rows.Close()
+
+	batch := Batch{Dataset: dataset, Columns: columns}
+	for rows.Next() {
+		holders := make([]interface{}, len(columns))
+		for i, col := range columns {
+			switch col.Type {
+			case TypeInt64:
+				holders[i] = &sql.NullInt64{}
+			case TypeFloat64:
+				holders[i] = &sql.NullFloat64{}
+			case TypeTimestamp:
+				holders[i] = &sql.NullTime{}
+			default:
+				holders[i] = &sql.NullString{}
+			}
+		}
+		if err := rows.Scan(holders...); err != nil {
+			return Batch{}, fmt.Errorf("failed to scan %s record: %w", dataset, err)
+		}
+
+		row := make([]interface{}, len(columns))
+		for i, h := range holders {
+			switch v := h.(type) {
+			case *sql.NullInt64:
+				if v.Valid {
+					row[i] = v.Int64
+				}
+			case *sql.NullFloat64:
+				if v.Valid {
+					row[i] = v.Float64
+				}
+			case *sql.NullTime:
+				if v.Valid {
+					row[i] = v.Time
+				}
+			case *sql.NullString:
+				if v.Valid {
+					row[i] = v.String
+				}
+			}
+		}
+		batch.Rows = append(batch.Rows, row)
+	}
+	return batch, rows.Err()
+}
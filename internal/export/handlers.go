@@ -0,0 +1,159 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes export destinations to tenants on the dashboard and to admins
+type Handlers struct {
+	store *Store
+}
+
+func NewHandlers(store *Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// List returns the caller's destinations, their cursors and the exported schemas
+func (h *Handlers) List(c *gin.Context) {
+	destinations, err := h.store.List(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list export destinations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"destinations":   destinations,
+			"schemas":        Schemas,
+			"schema_version": SchemaVersion,
+			"kinds":          []string{SinkBigQuery, SinkSnowflake, SinkS3Parquet},
+		},
+	})
+}
+
+// Create adds a destination; its first run exports the caller's history
+func (h *Handlers) Create(c *gin.Context) {
+	var req DestinationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := req.Validate(true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid export destination",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	d, err := h.store.Create(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		h.respondError(c, "Failed to create export destination", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    d,
+	})
+}
+
+// Update replaces a destination's configuration, keeping its cursors
+func (h *Handlers) Update(c *gin.Context) {
+	var req DestinationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := req.Validate(false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid export destination",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	d, err := h.store.Update(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req)
+	if err != nil {
+		h.respondError(c, "Failed to update export destination", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    d,
+	})
+}
+
+// Delete removes a destination
+func (h *Handlers) Delete(c *gin.Context) {
+	if err := h.store.Delete(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		h.respondError(c, "Failed to delete export destination", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Export destination deleted",
+	})
+}
+
+// Run schedules a destination's next run for the exporter's next tick
+func (h *Handlers) Run(c *gin.Context) {
+	if err := h.store.Trigger(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		h.respondError(c, "Failed to start export", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Export scheduled",
+	})
+}
+
+// ListAll returns every tenant's destinations, for admins
+func (h *Handlers) ListAll(c *gin.Context) {
+	destinations, err := h.store.List(c.Request.Context(), "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list export destinations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    destinations,
+	})
+}
+
+// respondError maps store errors to statuses
+func (h *Handlers) respondError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrRunning):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
@@ -0,0 +1,288 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// A minimal Parquet writer: one row group, one uncompressed PLAIN data page
+// per column, optional flat columns only. That is all an export batch needs,
+// and every Parquet reader accepts it.
+
+// Parquet physical types, repetitions, encodings and converted types
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+)
+
+var parquetMagic = []byte("PAR1")
+
+// encodeParquet writes a batch as a Parquet file
+func encodeParquet(batch Batch) ([]byte, error) {
+	var file bytes.Buffer
+	file.Write(parquetMagic)
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunk, len(batch.Columns))
+	for i, col := range batch.Columns {
+		values, defs, err := encodeColumn(batch, i, col)
+		if err != nil {
+			return nil, err
+		}
+
+		var page bytes.Buffer
+		binary.Write(&page, binary.LittleEndian, uint32(len(defs)))
+		page.Write(defs)
+		page.Write(values)
+
+		header := &thriftWriter{}
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.beginStruct(5) // DataPageHeader
+		header.i32(1, int32(len(batch.Rows)))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunks[i] = chunk{offset: int64(file.Len()), size: int64(header.buf.Len() + page.Len())}
+		file.Write(header.buf.Bytes())
+		file.Write(page.Bytes())
+	}
+
+	meta := &thriftWriter{}
+	meta.i32(1, 1) // version
+	meta.beginList(2, thriftStruct, len(batch.Columns)+1)
+	meta.beginElement()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(batch.Columns)))
+	meta.endElement()
+	for _, col := range batch.Columns {
+		physical, converted := parquetTypes(col.Type)
+		meta.beginElement()
+		meta.i32(1, physical)
+		meta.i32(3, parquetOptional)
+		meta.binary(4, col.Name)
+		if converted >= 0 {
+			meta.i32(6, converted)
+		}
+		meta.endElement()
+	}
+	meta.i64(3, int64(len(batch.Rows)))
+
+	var totalSize int64
+	for _, c := range chunks {
+		totalSize += c.size
+	}
+	meta.beginList(4, thriftStruct, 1)
+	meta.beginElement() // RowGroup
+	meta.beginList(1, thriftStruct, len(batch.Columns))
+	for i, col := range batch.Columns {
+		physical, _ := parquetTypes(col.Type)
+		meta.beginElement() // ColumnChunk
+		meta.i64(2, chunks[i].offset)
+		meta.beginStruct(3) // ColumnMetaData
+		meta.i32(1, physical)
+		meta.beginList(2, thriftI32, 2)
+		meta.listI32(encodingPlain)
+		meta.listI32(encodingRLE)
+		meta.beginList(3, thriftBinary, 1)
+		meta.listBinary(col.Name)
+		meta.i32(4, 0) // UNCOMPRESSED
+		meta.i64(5, int64(len(batch.Rows)))
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.endStruct()
+		meta.endElement()
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, int64(len(batch.Rows)))
+	meta.endElement()
+	meta.binary(6, "llm-router-go export")
+	meta.stop()
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.Write(parquetMagic)
+	return file.Bytes(), nil
+}
+
+// parquetTypes maps a column type to its physical and converted types; -1 when
+// there is no converted type
+func parquetTypes(columnType string) (int32, int32) {
+	switch columnType {
+	case TypeInt64:
+		return parquetInt64, -1
+	case TypeFloat64:
+		return parquetDouble, -1
+	case TypeTimestamp:
+		return parquetInt64, convertedTimestampMillis
+	}
+	return parquetByteArray, convertedUTF8
+}
+
+// encodeColumn returns a column's PLAIN-encoded non-null values and its
+// definition levels (1 present, 0 null) in the RLE hybrid encoding
+func encodeColumn(batch Batch, i int, col Column) ([]byte, []byte, error) {
+	var values bytes.Buffer
+	defined := make([]bool, len(batch.Rows))
+	for r, row := range batch.Rows {
+		v := row[i]
+		if v == nil {
+			continue
+		}
+		defined[r] = true
+		switch col.Type {
+		case TypeInt64:
+			n, ok := v.(int64)
+			if !ok {
+				return nil, nil, fmt.Errorf("column %s: %T is not an int64", col.Name, v)
+			}
+			binary.Write(&values, binary.LittleEndian, n)
+		case TypeFloat64:
+			f, ok := v.(float64)
+			if !ok {
+				return nil, nil, fmt.Errorf("column %s: %T is not a float64", col.Name, v)
+			}
+			binary.Write(&values, binary.LittleEndian, math.Float64bits(f))
+		case TypeTimestamp:
+			t, ok := v.(time.Time)
+			if !ok {
+				return nil, nil, fmt.Errorf("column %s: %T is not a time", col.Name, v)
+			}
+			binary.Write(&values, binary.LittleEndian, t.UnixMilli())
+		default:
+			s, ok := v.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("column %s: %T is not a string", col.Name, v)
+			}
+			binary.Write(&values, binary.LittleEndian, uint32(len(s)))
+			values.WriteString(s)
+		}
+	}
+
+	// Runs of equal levels, each a varint header (length << 1) and a one-byte level
+	var defs bytes.Buffer
+	for start := 0; start < len(defined); {
+		end := start
+		for end < len(defined) && defined[end] == defined[start] {
+			end++
+		}
+		defs.Write(binary.AppendUvarint(nil, uint64(end-start)<<1))
+		if defined[start] {
+			defs.WriteByte(1)
+		} else {
+			defs.WriteByte(0)
+		}
+		start = end
+	}
+	return values.Bytes(), defs.Bytes(), nil
+}
+
+// Thrift compact protocol field types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol structs Parquet metadata
+// is made of
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16 // Last field ID of each enclosing struct
+	last    int16
+}
+
+func (w *thriftWriter) field(id int16, fieldType byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		w.buf.WriteByte(fieldType)
+		w.varint(int64(id))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) varint(v int64) {
+	w.buf.Write(binary.AppendUvarint(nil, uint64((v<<1)^(v>>63))))
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.listBinary(s)
+}
+
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.beginElement()
+}
+
+func (w *thriftWriter) endStruct() {
+	w.endElement()
+}
+
+func (w *thriftWriter) beginList(id int16, elemType byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xF0 | elemType)
+		w.buf.Write(binary.AppendUvarint(nil, uint64(size)))
+	}
+}
+
+// beginElement starts a struct inside a list, or the body of a struct field
+func (w *thriftWriter) beginElement() {
+	w.lastIDs = append(w.lastIDs, w.last)
+	w.last = 0
+}
+
+func (w *thriftWriter) endElement() {
+	w.stop()
+	w.last = w.lastIDs[len(w.lastIDs)-1]
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+func (w *thriftWriter) listI32(v int32) {
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) listBinary(s string) {
+	w.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	w.buf.WriteString(s)
+}
+
+func (w *thriftWriter) stop() {
+	w.buf.WriteByte(0)
+}
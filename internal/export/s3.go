@@ -0,0 +1,68 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/Askeban/llm-router-go/internal/archive"
+)
+
+// s3ParquetSink writes each batch as a Parquet object under
+// <prefix>/<dataset>/dt=<day>/, partitioned the way Athena, Spark and
+// warehouse external tables expect
+type s3ParquetSink struct {
+	store archive.ObjectStore
+}
+
+func newS3ParquetSink(settings map[string]string, credentials []byte) (Sink, error) {
+	var creds struct {
+		AccessKeyID     string `json:"access_key_id"`
+		SecretAccessKey string `json:"secret_access_key"`
+		SessionToken    string `json:"session_token"`
+	}
+	if err := json.Unmarshal(credentials, &creds); err != nil {
+		return nil, fmt.Errorf("s3_parquet credentials must be JSON with access_key_id and secret_access_key: %w", err)
+	}
+
+	store, err := archive.NewS3Store(archive.S3Config{
+		Bucket:          settings["bucket"],
+		Prefix:          settings["prefix"],
+		Region:          settings["region"],
+		Endpoint:        settings["endpoint"],
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		ContentType:     "application/vnd.apache.parquet",
+	}, "export")
+	if err != nil {
+		return nil, err
+	}
+	return &s3ParquetSink{store: store}, nil
+}
+
+// EnsureSchema has nothing to do: every object carries its own schema
+func (s *s3ParquetSink) EnsureSchema(ctx context.Context, table string, columns []Column) error {
+	return nil
+}
+
+// Write uploads the batch to a key derived from its first record, so a
+// retried batch overwrites its earlier upload
+func (s *s3ParquetSink) Write(ctx context.Context, batch Batch) error {
+	if len(batch.Rows) == 0 {
+		return nil
+	}
+	body, err := encodeParquet(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode parquet: %w", err)
+	}
+
+	first := batch.Time(0).UTC()
+	key := path.Join(batch.Table, "dt="+first.Format("2006-01-02"),
+		fmt.Sprintf("%s-%s.parquet", first.Format("150405"), batch.ID(0)))
+	if err := s.store.Put(ctx, key, body); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
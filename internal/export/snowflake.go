@@ -0,0 +1,261 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+const (
+	snowflakeRowChunk     = 200 // Rows per MERGE; each row binds one value per column
+	snowflakeTokenTTL     = 59 * time.Minute
+	snowflakePollInterval = time.Second
+)
+
+// snowflakeTypes maps column types to Snowflake's
+var snowflakeTypes = map[string]string{
+	TypeString:    "VARCHAR",
+	TypeInt64:     "NUMBER(38,0)",
+	TypeFloat64:   "FLOAT",
+	TypeTimestamp: "TIMESTAMP_TZ",
+}
+
+// snowflakeSink runs statements through the Snowflake SQL API, authenticated
+// with key-pair JWTs
+type snowflakeSink struct {
+	endpoint  string
+	settings  map[string]string
+	key       *rsa.PrivateKey
+	issuer    string
+	subject   string
+	client    *http.Client
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newSnowflakeSink(settings map[string]string, credentials []byte) (Sink, error) {
+	var creds struct {
+		PrivateKey string `json:"private_key"`
+	}
+	if err := json.Unmarshal(credentials, &creds); err != nil || creds.PrivateKey == "" {
+		return nil, fmt.Errorf("snowflake credentials must be JSON with the user's PEM private_key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(creds.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid snowflake private key: %w", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snowflake private key: %w", err)
+	}
+	fingerprint := sha256.Sum256(publicKey)
+
+	// The JWT names the account without its region or cloud suffix
+	account := strings.ToUpper(strings.SplitN(settings["account"], ".", 2)[0])
+	user := strings.ToUpper(settings["user"])
+	return &snowflakeSink{
+		endpoint: fmt.Sprintf("https://%s.snowflakecomputing.com/api/v2/statements", url.PathEscape(settings["account"])),
+		settings: settings,
+		key:      key,
+		issuer:   account + "." + user + ".SHA256:" + base64.StdEncoding.EncodeToString(fingerprint[:]),
+		subject:  account + "." + user,
+		client:   transport.Client("export"),
+	}, nil
+}
+
+// EnsureSchema creates the table, then adds any columns it lacks
+func (s *snowflakeSink) EnsureSchema(ctx context.Context, table string, columns []Column) error {
+	defs := make([]string, 0, len(columns))
+	for _, col := range columns {
+		defs = append(defs, col.Name+" "+snowflakeTypes[col.Type])
+	}
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, strings.Join(defs, ", "))
+	if err := s.execute(ctx, create, nil); err != nil {
+		return fmt.Errorf("failed to create snowflake table %s: %w", table, err)
+	}
+	for _, def := range defs {
+		alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", table, def)
+		if err := s.execute(ctx, alter, nil); err != nil {
+			return fmt.Errorf("failed to add column to snowflake table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// Write merges the batch on id in chunks, so a retried batch inserts nothing twice
+func (s *snowflakeSink) Write(ctx context.Context, batch Batch) error {
+	names := make([]string, len(batch.Columns))
+	selects := make([]string, len(batch.Columns))
+	inserts := make([]string, len(batch.Columns))
+	for i, col := range batch.Columns {
+		names[i] = col.Name
+		// Every value is bound as text and cast to the column's type
+		source := fmt.Sprintf("column%d", i+1)
+		if col.Type == TypeTimestamp {
+			selects[i] = fmt.Sprintf("TO_TIMESTAMP_TZ(%s) AS %s", source, col.Name)
+		} else {
+			selects[i] = fmt.Sprintf("%s::%s AS %s", source, snowflakeTypes[col.Type], col.Name)
+		}
+		inserts[i] = "s." + col.Name
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(batch.Columns)), ",") + ")"
+
+	for start := 0; start < len(batch.Rows); start += snowflakeRowChunk {
+		end := start + snowflakeRowChunk
+		if end > len(batch.Rows) {
+			end = len(batch.Rows)
+		}
+		values := make([]string, 0, end-start)
+		bindings := make(map[string]interface{}, (end-start)*len(batch.Columns))
+		for r := start; r < end; r++ {
+			values = append(values, placeholders)
+			for _, v := range batch.Rows[r] {
+				bindings[strconv.Itoa(len(bindings)+1)] = map[string]interface{}{"type": "TEXT", "value": snowflakeText(v)}
+			}
+		}
+
+		merge := fmt.Sprintf(`MERGE INTO %s t USING (SELECT %s FROM VALUES %s) s ON t.id = s.id
+			WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)`,
+			batch.Table, strings.Join(selects, ", "), strings.Join(values, ", "),
+			strings.Join(names, ", "), strings.Join(inserts, ", "))
+		if err := s.execute(ctx, merge, bindings); err != nil {
+			return fmt.Errorf("failed to merge into snowflake table %s: %w", batch.Table, err)
+		}
+	}
+	return nil
+}
+
+// snowflakeText renders a value for a TEXT binding; nil stays NULL
+func snowflakeText(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// execute runs one statement, polling until Snowflake finishes it
+func (s *snowflakeSink) execute(ctx context.Context, statement string, bindings map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"statement": statement,
+		"timeout":   60,
+		"database":  s.settings["database"],
+		"schema":    s.settings["schema"],
+		"warehouse": s.settings["warehouse"],
+	}
+	if role := s.settings["role"]; role != "" {
+		payload["role"] = role
+	}
+	if len(bindings) > 0 {
+		payload["bindings"] = bindings
+	}
+
+	status, body, err := s.call(ctx, http.MethodPost, s.endpoint, payload)
+	for err == nil && status == http.StatusAccepted {
+		var pending struct {
+			StatementHandle string `json:"statementHandle"`
+		}
+		if err := json.Unmarshal(body, &pending); err != nil || pending.StatementHandle == "" {
+			return fmt.Errorf("snowflake accepted the statement without a handle")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(snowflakePollInterval):
+		}
+		status, body, err = s.call(ctx, http.MethodGet, s.endpoint+"/"+url.PathEscape(pending.StatementHandle), nil)
+	}
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		var failure struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &failure) == nil && failure.Message != "" {
+			return fmt.Errorf("status %d: %s (%s)", status, failure.Message, failure.Code)
+		}
+		return fmt.Errorf("status %d: %s", status, body)
+	}
+	return nil
+}
+
+// call sends an authorized JSON request and returns the status and body
+func (s *snowflakeSink) call(ctx context.Context, method, endpoint string, payload interface{}) (int, []byte, error) {
+	token, err := s.jwt()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var reader io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Snowflake-Authorization-Token-Type", "KEYPAIR_JWT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, body, err
+}
+
+// jwt returns a cached key-pair token, signing a new one shortly before expiry
+func (s *snowflakeSink) jwt() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expiresAt.Add(-time.Minute)) {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer:    s.issuer,
+		Subject:   s.subject,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(snowflakeTokenTTL)),
+	}).SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign snowflake token: %w", err)
+	}
+	s.token = token
+	s.expiresAt = now.Add(snowflakeTokenTTL)
+	return s.token, nil
+}
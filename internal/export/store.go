@@ -0,0 +1,376 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/vault"
+)
+
+// Export intervals: the default, and the shortest a tenant may choose
+const (
+	DefaultInterval = time.Hour
+	MinInterval     = 5 * time.Minute
+)
+
+var (
+	// ErrNotFound is returned when a tenant has no destination with the given ID
+	ErrNotFound = errors.New("export destination not found")
+	// ErrRunning is returned when a run is requested for a destination mid-run
+	ErrRunning = errors.New("export destination is already running")
+)
+
+// Destination is a tenant's warehouse or bucket and what is exported to it.
+// Credentials are write-only and never returned.
+type Destination struct {
+	ID              string            `json:"id"`
+	UserID          string            `json:"user_id"`
+	Name            string            `json:"name"`
+	Kind            string            `json:"kind"`
+	Datasets        []string          `json:"datasets"`
+	Settings        map[string]string `json:"settings"`
+	IntervalSeconds int               `json:"interval_seconds"`
+	Enabled         bool              `json:"enabled"`
+	SchemaVersion   int               `json:"schema_version"`
+	LastRunAt       *time.Time        `json:"last_run_at,omitempty"`
+	LastError       string            `json:"last_error,omitempty"`
+	Cursors         []Cursor          `json:"cursors"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// Cursor is how far a dataset has been exported to a destination
+type Cursor struct {
+	Dataset      string    `json:"dataset"`
+	LastTime     time.Time `json:"last_time"`
+	LastID       string    `json:"last_id"`
+	RowsExported int64     `json:"rows_exported"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DestinationInput creates or updates a destination. On update, omitted
+// credentials keep the stored ones.
+type DestinationInput struct {
+	Name            string            `json:"name" binding:"required"`
+	Kind            string            `json:"kind" binding:"required"`
+	Datasets        []string          `json:"datasets"`
+	Settings        map[string]string `json:"settings"`
+	Credentials     json.RawMessage   `json:"credentials"`
+	IntervalSeconds int               `json:"interval_seconds"`
+	Enabled         *bool             `json:"enabled"`
+}
+
+// Validate checks the input, fills in defaults and checks the credentials
+// open a sink. Credentials are required unless an update keeps the stored ones.
+func (in *DestinationInput) Validate(requireCredentials bool) error {
+	if err := validateSettings(in.Kind, in.Settings); err != nil {
+		return err
+	}
+	if len(in.Datasets) == 0 {
+		in.Datasets = []string{DatasetUsage}
+	}
+	seen := make(map[string]bool)
+	for _, ds := range in.Datasets {
+		if _, ok := Schemas[ds]; !ok {
+			return fmt.Errorf("unknown dataset %q; use usage or audit", ds)
+		}
+		if seen[ds] {
+			return fmt.Errorf("dataset %q is listed twice", ds)
+		}
+		seen[ds] = true
+	}
+	if in.IntervalSeconds == 0 {
+		in.IntervalSeconds = int(DefaultInterval.Seconds())
+	}
+	if in.IntervalSeconds < int(MinInterval.Seconds()) {
+		return fmt.Errorf("interval_seconds must be at least %d", int(MinInterval.Seconds()))
+	}
+	if in.Enabled == nil {
+		enabled := true
+		in.Enabled = &enabled
+	}
+	if len(in.Credentials) == 0 {
+		if requireCredentials {
+			return fmt.Errorf("credentials are required")
+		}
+		return nil
+	}
+	_, err := newSink(in.Kind, in.Settings, in.Credentials)
+	return err
+}
+
+// claimed is a destination leased for a run, with its unsealed credentials
+type claimed struct {
+	Destination
+	credentials []byte
+}
+
+// Store persists export destinations, with credentials sealed by the vault,
+// and their per-dataset cursors
+type Store struct {
+	db    *sql.DB
+	vault *vault.Vault
+}
+
+func NewStore(db *sql.DB, v *vault.Vault) *Store {
+	return &Store{db: db, vault: v}
+}
+
+const destinationColumns = `id, user_id, name, kind, datasets, settings, interval_seconds, enabled,
+	schema_version, last_run_at, COALESCE(last_error, ''), created_at, updated_at`
+
+func scanDestination(row interface{ Scan(...interface{}) error }, d *Destination, extra ...interface{}) error {
+	var settings []byte
+	var lastRun sql.NullTime
+	dest := []interface{}{&d.ID, &d.UserID, &d.Name, &d.Kind, pq.Array(&d.Datasets), &settings,
+		&d.IntervalSeconds, &d.Enabled, &d.SchemaVersion, &lastRun, &d.LastError, &d.CreatedAt, &d.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if lastRun.Valid {
+		d.LastRunAt = &lastRun.Time
+	}
+	d.Settings = map[string]string{}
+	return json.Unmarshal(settings, &d.Settings)
+}
+
+// List returns a tenant's destinations with their cursors, or every tenant's
+// when userID is empty
+func (s *Store) List(ctx context.Context, userID string) ([]Destination, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+destinationColumns+`
+		FROM export_destinations
+		WHERE $1 = '' OR user_id::text = $1
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list export destinations: %w", err)
+	}
+	defer rows.Close()
+
+	destinations := []Destination{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var d Destination
+		if err := scanDestination(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan export destination: %w", err)
+		}
+		d.Cursors = []Cursor{}
+		index[d.ID] = len(destinations)
+		destinations = append(destinations, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(destinations) == 0 {
+		return destinations, nil
+	}
+
+	ids := make([]string, 0, len(destinations))
+	for _, d := range destinations {
+		ids = append(ids, d.ID)
+	}
+	cursors, err := s.db.QueryContext(ctx, `
+		SELECT destination_id, dataset, last_ts, last_id, rows_exported, updated_at
+		FROM export_cursors
+		WHERE destination_id::text = ANY($1)
+		ORDER BY dataset`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list export cursors: %w", err)
+	}
+	defer cursors.Close()
+	for cursors.Next() {
+		var id string
+		var c Cursor
+		if err := cursors.Scan(&id, &c.Dataset, &c.LastTime, &c.LastID, &c.RowsExported, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan export cursor: %w", err)
+		}
+		d := &destinations[index[id]]
+		d.Cursors = append(d.Cursors, c)
+	}
+	return destinations, cursors.Err()
+}
+
+// Create stores a destination; the input must have been validated
+func (s *Store) Create(ctx context.Context, userID string, in DestinationInput) (*Destination, error) {
+	sealed, err := s.vault.Seal(in.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	settings, _ := json.Marshal(in.Settings)
+
+	d := &Destination{}
+	err = scanDestination(s.db.QueryRowContext(ctx, `
+		INSERT INTO export_destinations
+			(user_id, name, kind, datasets, settings, sealed_credentials, interval_seconds, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+destinationColumns,
+		userID, in.Name, in.Kind, pq.Array(in.Datasets), string(settings), sealed, in.IntervalSeconds, *in.Enabled,
+	), d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store export destination: %w", err)
+	}
+	d.Cursors = []Cursor{}
+	return d, nil
+}
+
+// Update replaces a validated configuration of a destination. Tables are
+// re-ensured on the next run, since the new settings may point elsewhere. The
+// kind cannot change; a destination of another kind is not found.
+func (s *Store) Update(ctx context.Context, userID, id string, in DestinationInput) (*Destination, error) {
+	var sealed interface{} // NULL keeps the stored credentials
+	if len(in.Credentials) > 0 {
+		data, err := s.vault.Seal(in.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		sealed = data
+	}
+	settings, _ := json.Marshal(in.Settings)
+
+	d := &Destination{}
+	err := scanDestination(s.db.QueryRowContext(ctx, `
+		UPDATE export_destinations SET
+			name = $3, datasets = $4, settings = $5,
+			sealed_credentials = COALESCE($6, sealed_credentials),
+			interval_seconds = $7, enabled = $8,
+			schema_version = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE id::text = $1 AND user_id::text = $2 AND kind = $9
+		RETURNING `+destinationColumns,
+		id, userID, in.Name, pq.Array(in.Datasets), string(settings), sealed, in.IntervalSeconds, *in.Enabled, in.Kind,
+	), d)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update export destination: %w", err)
+	}
+	d.Cursors = []Cursor{}
+	return d, nil
+}
+
+// Delete removes a destination and its cursors. Data already exported stays.
+func (s *Store) Delete(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM export_destinations WHERE id::text = $1 AND user_id::text = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete export destination: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Trigger makes a destination due on the exporter's next tick
+func (s *Store) Trigger(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE export_destinations SET last_run_at = NULL
+		WHERE id::text = $1 AND user_id::text = $2
+		  AND (lease_until IS NULL OR lease_until < CURRENT_TIMESTAMP)`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to trigger export: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM export_destinations WHERE id::text = $1 AND user_id::text = $2)`,
+		id, userID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to trigger export: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrRunning
+}
+
+// claim leases up to limit due destinations, so that each is run by one
+// replica at a time. A lease that outlives its run's replica expires.
+func (s *Store) claim(ctx context.Context, lease time.Duration, limit int) ([]claimed, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE export_destinations SET lease_until = CURRENT_TIMESTAMP + $1 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM export_destinations
+			WHERE enabled
+			  AND (lease_until IS NULL OR lease_until < CURRENT_TIMESTAMP)
+			  AND (last_run_at IS NULL OR last_run_at + interval_seconds * INTERVAL '1 second' <= CURRENT_TIMESTAMP)
+			ORDER BY last_run_at NULLS FIRST
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED)
+		RETURNING `+destinationColumns+`, sealed_credentials`,
+		int(lease.Seconds()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim export destinations: %w", err)
+	}
+	defer rows.Close()
+
+	var due []claimed
+	for rows.Next() {
+		var c claimed
+		var sealed []byte
+		if err := scanDestination(rows, &c.Destination, &sealed); err != nil {
+			return nil, fmt.Errorf("failed to scan export destination: %w", err)
+		}
+		if c.credentials, err = s.vault.Open(sealed); err != nil {
+			return nil, fmt.Errorf("failed to unseal credentials of export destination %s: %w", c.ID, err)
+		}
+		due = append(due, c)
+	}
+	return due, rows.Err()
+}
+
+// cursor returns how far a dataset has been exported; the zero cursor starts
+// from the oldest record
+func (s *Store) cursor(ctx context.Context, destinationID, dataset string) (Cursor, error) {
+	c := Cursor{Dataset: dataset, LastTime: time.Unix(0, 0).UTC(), LastID: "00000000-0000-0000-0000-000000000000"}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT last_ts, last_id, rows_exported, updated_at FROM export_cursors
+		WHERE destination_id = $1 AND dataset = $2`, destinationID, dataset,
+	).Scan(&c.LastTime, &c.LastID, &c.RowsExported, &c.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return c, fmt.Errorf("failed to load export cursor: %w", err)
+	}
+	return c, nil
+}
+
+// advance moves a dataset's cursor past a written batch
+func (s *Store) advance(ctx context.Context, destinationID string, c Cursor, rows int) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO export_cursors (destination_id, dataset, last_ts, last_id, rows_exported)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (destination_id, dataset) DO UPDATE SET
+			last_ts = EXCLUDED.last_ts,
+			last_id = EXCLUDED.last_id,
+			rows_exported = export_cursors.rows_exported + EXCLUDED.rows_exported,
+			updated_at = CURRENT_TIMESTAMP`,
+		destinationID, c.Dataset, c.LastTime, c.LastID, rows)
+	if err != nil {
+		return fmt.Errorf("failed to save export cursor: %w", err)
+	}
+	return nil
+}
+
+// finish records a run and releases the lease. A destination with a backlog
+// left is due again on the next tick.
+func (s *Store) finish(ctx context.Context, id string, schemaVersion int, runErr error, backlog bool) error {
+	var lastError sql.NullString
+	if runErr != nil {
+		lastError = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE export_destinations SET
+			last_run_at = CASE WHEN $4 THEN NULL ELSE CURRENT_TIMESTAMP END,
+			last_error = $2, schema_version = $3, lease_until = NULL
+		WHERE id = $1`, id, lastError, schemaVersion, backlog)
+	if err != nil {
+		return fmt.Errorf("failed to record export run: %w", err)
+	}
+	return nil
+}
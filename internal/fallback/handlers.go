@@ -0,0 +1,98 @@
+package fallback
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// Handlers exposes category fallback management to operators
+type Handlers struct {
+	store   *Store
+	catalog func(id string) (models.EnhancedModel, bool)
+}
+
+func NewHandlers(store *Store, catalog func(id string) (models.EnhancedModel, bool)) *Handlers {
+	return &Handlers{store: store, catalog: catalog}
+}
+
+// List returns the fallback in effect for each category and where it came from
+func (h *Handlers) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.store.fallbacks.List(),
+	})
+}
+
+// Put sets the fallback for :category ("*" for every category without its own)
+func (h *Handlers) Put(c *gin.Context) {
+	var req struct {
+		ModelID   string  `json:"model_id" binding:"required"`
+		Threshold float64 `json:"threshold"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	fb := recommendation.CategoryFallback{Category: c.Param("category"), ModelID: req.ModelID, Threshold: req.Threshold}
+	if err := fb.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid category fallback",
+			"details": err.Error(),
+		})
+		return
+	}
+	if _, ok := h.catalog(fb.ModelID); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid category fallback",
+			"details": "model " + fb.ModelID + " is not in the catalog",
+		})
+		return
+	}
+	if err := h.store.Put(c.Request.Context(), fb); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save category fallback",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	fb.Source = recommendation.FallbackSourceAdmin
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    fb,
+	})
+}
+
+// Delete removes the admin fallback for :category
+func (h *Handlers) Delete(c *gin.Context) {
+	category := c.Param("category")
+	if category != recommendation.AnyCategory {
+		category = models.CanonicalCapability(category)
+	}
+	deleted, err := h.store.Delete(c.Request.Context(), category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete category fallback",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No admin fallback for this category",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Category fallback deleted",
+	})
+}
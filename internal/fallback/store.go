@@ -0,0 +1,99 @@
+package fallback
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// Store persists admin-managed category fallbacks and keeps every replica's
+// engine in step with them
+type Store struct {
+	db        *sql.DB
+	fallbacks *recommendation.Fallbacks
+}
+
+func NewStore(db *sql.DB, fallbacks *recommendation.Fallbacks) *Store {
+	return &Store{db: db, fallbacks: fallbacks}
+}
+
+// Start loads the overrides and reloads them every interval, so changes made
+// through another replica take effect here too
+func (s *Store) Start(ctx context.Context, interval time.Duration) {
+	if err := s.Sync(ctx); err != nil {
+		log.Printf("[FALLBACK] %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Sync(ctx); err != nil {
+					log.Printf("[FALLBACK] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Sync replaces the engine's overrides with the stored ones
+func (s *Store) Sync(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT category, model_id, threshold FROM category_fallbacks ORDER BY category`)
+	if err != nil {
+		return fmt.Errorf("failed to load category fallbacks: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []recommendation.CategoryFallback{}
+	for rows.Next() {
+		var fb recommendation.CategoryFallback
+		if err := rows.Scan(&fb.Category, &fb.ModelID, &fb.Threshold); err != nil {
+			return fmt.Errorf("failed to scan category fallback: %w", err)
+		}
+		overrides = append(overrides, fb)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load category fallbacks: %w", err)
+	}
+	s.fallbacks.SetOverrides(overrides)
+	return nil
+}
+
+// Put stores an override for the fallback's category
+func (s *Store) Put(ctx context.Context, fb recommendation.CategoryFallback) error {
+	if err := fb.Validate(); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO category_fallbacks (category, model_id, threshold)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (category) DO UPDATE SET
+			model_id = EXCLUDED.model_id,
+			threshold = EXCLUDED.threshold,
+			updated_at = CURRENT_TIMESTAMP`,
+		fb.Category, fb.ModelID, fb.Threshold)
+	if err != nil {
+		return fmt.Errorf("failed to store category fallback: %w", err)
+	}
+	return s.Sync(ctx)
+}
+
+// Delete removes the override for category; a configured fallback applies again
+func (s *Store) Delete(ctx context.Context, category string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM category_fallbacks WHERE category = $1`, category)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete category fallback: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, s.Sync(ctx)
+}
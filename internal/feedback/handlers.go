@@ -0,0 +1,63 @@
+package feedback
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes feedback submission and learned affinities
+type Handlers struct {
+	store *Store
+}
+
+func NewHandlers(store *Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// Submit records the caller's rating of a recommended model
+func (h *Handlers) Submit(c *gin.Context) {
+	var req Feedback
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.UserID = c.GetString("user_id")
+
+	id, err := h.store.Submit(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to record feedback",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"id":      id,
+	})
+}
+
+// ListAffinities shows the personalization learned from the caller's feedback
+func (h *Handlers) ListAffinities(c *gin.Context) {
+	affinities, err := h.store.Affinities(c.Request.Context(), c.GetString("user_id"), c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load affinities",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"affinities":     affinities,
+			"max_adjustment": MaxAdjustment,
+		},
+	})
+}
@@ -0,0 +1,158 @@
+package feedback
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+const (
+	// MaxAdjustment bounds how far personalization can move a model's score
+	MaxAdjustment = 0.1
+
+	// shrinkage is the number of ratings at which a model's affinity reaches
+	// half its full weight, so a single rating cannot swing routing
+	shrinkage = 5.0
+
+	// lookback limits affinities to recent feedback
+	lookback = 180 * 24 * time.Hour
+)
+
+// Feedback is a tenant's rating of a recommended model
+type Feedback struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	ModelID    string    `json:"model_id" binding:"required"`
+	Category   string    `json:"category" binding:"required"`
+	Rating     int       `json:"rating" binding:"required"` // 1-5
+	Comment    string    `json:"comment,omitempty"`
+	Confidence *float64  `json:"confidence,omitempty"` // raw_confidence of the rated recommendation, used for calibration
+	CreatedAt  time.Time `json:"created_at"`
+
+	// The rated recommendation's priority, component_scores and
+	// weights_variant, used to tune the component weights
+	Priority        string             `json:"priority,omitempty"`
+	ComponentScores map[string]float64 `json:"component_scores,omitempty"`
+	WeightsVariant  string             `json:"weights_variant,omitempty"`
+
+	// DecisionID links the rating to the recorded recommendation, for
+	// training data exports
+	DecisionID string `json:"decision_id,omitempty" binding:"omitempty,uuid"`
+}
+
+// Affinity is a learned per-tenant preference for a model within a category
+type Affinity struct {
+	ModelID    string  `json:"model_id"`
+	Category   string  `json:"category"`
+	Ratings    int     `json:"ratings"`
+	MeanRating float64 `json:"mean_rating"`
+	Adjustment float64 `json:"adjustment"`
+}
+
+// Store persists feedback and derives personalization affinities
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Submit records a rating
+func (s *Store) Submit(ctx context.Context, f Feedback) (string, error) {
+	if f.Rating < 1 || f.Rating > 5 {
+		return "", fmt.Errorf("rating must be between 1 and 5")
+	}
+	if f.Confidence != nil && (*f.Confidence < 0 || *f.Confidence > 1) {
+		return "", fmt.Errorf("confidence must be between 0 and 1")
+	}
+
+	var components []byte
+	if len(f.ComponentScores) > 0 {
+		var err error
+		if components, err = json.Marshal(f.ComponentScores); err != nil {
+			return "", fmt.Errorf("failed to encode component scores: %w", err)
+		}
+	}
+
+	var id string
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO model_feedback (user_id, model_id, category, rating, comment, predicted_confidence, priority, component_scores, weights_variant, decision_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), $8, NULLIF($9, ''), NULLIF($10, '')::uuid)
+		RETURNING id`,
+		f.UserID, f.ModelID, models.CanonicalCapability(f.Category), f.Rating, f.Comment, f.Confidence,
+		f.Priority, components, f.WeightsVariant, f.DecisionID).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to record feedback: %w", err)
+	}
+	return id, nil
+}
+
+// Affinities returns the tenant's learned model affinities, optionally for one category.
+// Each adjustment compares the model's mean rating with the tenant's overall mean,
+// shrunk toward zero for sparse feedback and bounded by MaxAdjustment.
+func (s *Store) Affinities(ctx context.Context, userID, category string) ([]Affinity, error) {
+	since := time.Now().Add(-lookback)
+
+	var userMean sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT AVG(rating) FROM model_feedback WHERE user_id = $1 AND created_at >= $2`,
+		userID, since).Scan(&userMean)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feedback baseline: %w", err)
+	}
+	if !userMean.Valid {
+		return []Affinity{}, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT model_id, category, COUNT(*), AVG(rating)
+		FROM model_feedback
+		WHERE user_id = $1 AND created_at >= $2 AND ($3 = '' OR category = $3)
+		GROUP BY model_id, category`,
+		userID, since, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feedback affinities: %w", err)
+	}
+	defer rows.Close()
+
+	affinities := []Affinity{}
+	for rows.Next() {
+		var a Affinity
+		if err := rows.Scan(&a.ModelID, &a.Category, &a.Ratings, &a.MeanRating); err != nil {
+			return nil, fmt.Errorf("failed to scan affinity: %w", err)
+		}
+		a.Adjustment = adjustment(a.MeanRating, userMean.Float64, a.Ratings)
+		affinities = append(affinities, a)
+	}
+	return affinities, rows.Err()
+}
+
+// Adjustments returns model ID → score adjustment for one category
+func (s *Store) Adjustments(ctx context.Context, userID, category string) (map[string]float64, error) {
+	affinities, err := s.Affinities(ctx, userID, category)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments := make(map[string]float64, len(affinities))
+	for _, a := range affinities {
+		if a.Adjustment != 0 {
+			adjustments[a.ModelID] = a.Adjustment
+		}
+	}
+	return adjustments, nil
+}
+
+// adjustment maps a rating difference (at most ±4 on a 1-5 scale) onto ±MaxAdjustment
+func adjustment(modelMean, userMean float64, ratings int) float64 {
+	confidence := float64(ratings) / (float64(ratings) + shrinkage)
+	delta := (modelMean - userMean) / 4.0 * MaxAdjustment * 2 * confidence
+	delta = math.Max(-MaxAdjustment, math.Min(MaxAdjustment, delta))
+	return math.Round(delta*10000) / 10000
+}
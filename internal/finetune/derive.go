@@ -0,0 +1,108 @@
+package finetune
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// ModelLookup finds a catalog model by ID
+type ModelLookup func(id string) (models.EnhancedModel, bool)
+
+// Resolve returns the tenant's enabled models as routable catalog entries.
+// Registrations whose base model has left the catalog are skipped.
+func (s *Store) Resolve(ctx context.Context, userID string, lookup ModelLookup) ([]models.EnhancedModel, error) {
+	registered, err := s.List(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]models.EnhancedModel, 0, len(registered))
+	for _, m := range registered {
+		base, ok := lookup(m.BaseModel)
+		if !ok {
+			log.Printf("[FINETUNE] Base model %s of %s is not in the catalog, skipping", m.BaseModel, m.ModelID)
+			continue
+		}
+		resolved = append(resolved, Derive(base, m))
+	}
+	return resolved, nil
+}
+
+// Derive builds a fine-tuned model's profile from its base model. Capabilities
+// are inherited with the registered deltas applied, pricing overrides replace
+// the base prices, and every change is recorded in provenance.
+func Derive(base models.EnhancedModel, m Model) models.EnhancedModel {
+	derived := base
+	derived.ID = m.ModelID
+	derived.Provider = m.Provider
+	derived.DisplayName = m.DisplayName
+	derived.BaseModel = base.ID
+	derived.Endpoint = m.Endpoint
+	derived.OpenSource = false
+	derived.Sources = []string{"tenant"}
+	derived.Tags = append(append([]string{}, base.Tags...), "fine-tuned")
+
+	// The base model's maps are shared with the catalog; copy before writing
+	provenance := make(map[string]string, len(base.DataProvenance.DerivedData)+len(m.CapabilityDeltas)+1)
+	for k, v := range base.DataProvenance.DerivedData {
+		provenance[k] = v
+	}
+	provenance["base_model"] = fmt.Sprintf("inherited from %s", base.ID)
+	derived.DataProvenance.DerivedData = provenance
+
+	if m.CostInPer1K != nil || m.CostOutPer1K != nil {
+		if m.CostInPer1K != nil {
+			derived.Pricing.Text.CostInPer1K = m.CostInPer1K
+		}
+		if m.CostOutPer1K != nil {
+			derived.Pricing.Text.CostOutPer1K = m.CostOutPer1K
+		}
+		provenance["pricing.text"] = "tenant override"
+	}
+
+	if len(m.CapabilityDeltas) > 0 {
+		deltas := canonicalDeltas(m.CapabilityDeltas)
+		caps := &derived.TaskCapabilities
+		caps.TextTasks = applyDeltas(caps.TextTasks, deltas, "text_tasks", provenance)
+		caps.ImageTasks = applyDeltas(caps.ImageTasks, deltas, "image_tasks", provenance)
+		caps.VideoTasks = applyDeltas(caps.VideoTasks, deltas, "video_tasks", provenance)
+		caps.AudioTasks = applyDeltas(caps.AudioTasks, deltas, "audio_tasks", provenance)
+	}
+
+	derived.LastUpdated = m.UpdatedAt.Format(time.RFC3339)
+	return derived
+}
+
+// applyDeltas returns a copy of tasks with each matching category's score shifted
+// and clamped to [0, 1]. Categories the base model lacks are left out: a delta
+// adjusts an inherited capability, it does not invent one.
+func applyDeltas(tasks map[string]models.TaskCapability, deltas map[string]float64, field string, provenance map[string]string) map[string]models.TaskCapability {
+	if len(tasks) == 0 {
+		return tasks
+	}
+
+	adjusted := make(map[string]models.TaskCapability, len(tasks))
+	for category, capability := range tasks {
+		if delta, ok := deltas[category]; ok && delta != 0 {
+			capability.Score = math.Max(0, math.Min(1, capability.Score+delta))
+			provenance["task_capabilities."+field+"."+category] = fmt.Sprintf("base %.3f %+.3f tenant delta", tasks[category].Score, delta)
+		}
+		adjusted[category] = capability
+	}
+	return adjusted
+}
+
+// canonicalDeltas keys deltas by canonical capability so a delta registered
+// under an alias still reaches the base model's capability
+func canonicalDeltas(deltas map[string]float64) map[string]float64 {
+	canonical := make(map[string]float64, len(deltas))
+	for category, delta := range deltas {
+		canonical[models.CanonicalCapability(category)] = delta
+	}
+	return canonical
+}
@@ -0,0 +1,110 @@
+package finetune
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes tenant model registration on the dashboard
+type Handlers struct {
+	store  *Store
+	lookup ModelLookup
+}
+
+func NewHandlers(store *Store, lookup ModelLookup) *Handlers {
+	return &Handlers{store: store, lookup: lookup}
+}
+
+// List returns the caller's registered models
+func (h *Handlers) List(c *gin.Context) {
+	registered, err := h.store.List(c.Request.Context(), c.GetString("user_id"), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list models",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"models":               registered,
+			"max_capability_delta": MaxCapabilityDelta,
+		},
+	})
+}
+
+// Create registers a fine-tuned model
+func (h *Handlers) Create(c *gin.Context) {
+	h.save(c, "")
+}
+
+// Update replaces a registered model's settings
+func (h *Handlers) Update(c *gin.Context) {
+	h.save(c, c.Param("id"))
+}
+
+func (h *Handlers) save(c *gin.Context, id string) {
+	// Models route as soon as they are registered unless the request says otherwise
+	req := Model{Enabled: true}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.ID = id
+	req.UserID = c.GetString("user_id")
+
+	if err := h.checkCatalog(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid model",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	savedID, err := h.store.Put(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to save model",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"id":      savedID,
+	})
+}
+
+// checkCatalog requires a known base model and an ID that cannot shadow a shared one
+func (h *Handlers) checkCatalog(m Model) error {
+	if _, ok := h.lookup(m.BaseModel); !ok {
+		return fmt.Errorf("base model %s is not in the catalog", m.BaseModel)
+	}
+	if _, ok := h.lookup(m.ModelID); ok {
+		return fmt.Errorf("model_id %s is already a catalog model", m.ModelID)
+	}
+	return nil
+}
+
+// Delete removes a registered model
+func (h *Handlers) Delete(c *gin.Context) {
+	if err := h.store.Delete(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Failed to delete model",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
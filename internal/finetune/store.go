@@ -0,0 +1,150 @@
+package finetune
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// MaxCapabilityDelta bounds how far a tenant can move a base model's capability score
+const MaxCapabilityDelta = 0.3
+
+var modelIDPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._:/-]{0,127}$`)
+
+// Model is a tenant's fine-tuned or customer-specific model. It routes only for
+// the tenant that registered it, using its base model's profile adjusted by
+// CapabilityDeltas and the pricing overrides.
+type Model struct {
+	ID               string             `json:"id"`
+	UserID           string             `json:"-"`
+	ModelID          string             `json:"model_id" binding:"required"` // ID used in recommendations, e.g. ft:gpt-4o-mini:acme:support
+	DisplayName      string             `json:"display_name"`
+	Provider         string             `json:"provider" binding:"required"`
+	BaseModel        string             `json:"base_model" binding:"required"` // Catalog model the capabilities are inherited from
+	Endpoint         string             `json:"endpoint,omitempty"`
+	CostInPer1K      *float64           `json:"cost_in_per_1k,omitempty"`    // Overrides the base model's input price
+	CostOutPer1K     *float64           `json:"cost_out_per_1k,omitempty"`   // Overrides the base model's output price
+	CapabilityDeltas map[string]float64 `json:"capability_deltas,omitempty"` // Category -> score adjustment
+	Enabled          bool               `json:"enabled"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// Validate rejects unusable registrations
+func (m *Model) Validate() error {
+	if !modelIDPattern.MatchString(m.ModelID) {
+		return fmt.Errorf("model_id must be 1-128 characters of letters, digits and ._:/-")
+	}
+	if m.BaseModel == m.ModelID {
+		return fmt.Errorf("model_id must differ from base_model")
+	}
+	for _, price := range []*float64{m.CostInPer1K, m.CostOutPer1K} {
+		if price != nil && *price < 0 {
+			return fmt.Errorf("pricing overrides must not be negative")
+		}
+	}
+	for category, delta := range m.CapabilityDeltas {
+		if delta < -MaxCapabilityDelta || delta > MaxCapabilityDelta {
+			return fmt.Errorf("capability delta for %s must be within ±%.1f", category, MaxCapabilityDelta)
+		}
+	}
+	return nil
+}
+
+// Store persists tenant model registrations
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// List returns the tenant's registered models
+func (s *Store) List(ctx context.Context, userID string, enabledOnly bool) ([]Model, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, model_id, display_name, provider, base_model, endpoint, cost_in_per_1k, cost_out_per_1k,
+		       capability_deltas, enabled, created_at, updated_at
+		FROM tenant_models
+		WHERE user_id = $1 AND (NOT $2 OR enabled)
+		ORDER BY model_id`, userID, enabledOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant models: %w", err)
+	}
+	defer rows.Close()
+
+	result := []Model{}
+	for rows.Next() {
+		m := Model{UserID: userID}
+		var costIn, costOut sql.NullFloat64
+		var deltas []byte
+		if err := rows.Scan(&m.ID, &m.ModelID, &m.DisplayName, &m.Provider, &m.BaseModel, &m.Endpoint,
+			&costIn, &costOut, &deltas, &m.Enabled, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant model: %w", err)
+		}
+		if costIn.Valid {
+			m.CostInPer1K = &costIn.Float64
+		}
+		if costOut.Valid {
+			m.CostOutPer1K = &costOut.Float64
+		}
+		json.Unmarshal(deltas, &m.CapabilityDeltas)
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+// Put registers a model, or updates it when m.ID is set
+func (s *Store) Put(ctx context.Context, m Model) (string, error) {
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+	if m.DisplayName == "" {
+		m.DisplayName = m.ModelID
+	}
+	deltas, _ := json.Marshal(canonicalDeltas(m.CapabilityDeltas))
+
+	var id string
+	var err error
+	if m.ID == "" {
+		err = s.db.QueryRowContext(ctx, `
+			INSERT INTO tenant_models (user_id, model_id, display_name, provider, base_model, endpoint,
+			                           cost_in_per_1k, cost_out_per_1k, capability_deltas, enabled)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id`,
+			m.UserID, m.ModelID, m.DisplayName, m.Provider, m.BaseModel, m.Endpoint,
+			m.CostInPer1K, m.CostOutPer1K, deltas, m.Enabled).Scan(&id)
+	} else {
+		err = s.db.QueryRowContext(ctx, `
+			UPDATE tenant_models
+			SET model_id = $3, display_name = $4, provider = $5, base_model = $6, endpoint = $7,
+			    cost_in_per_1k = $8, cost_out_per_1k = $9, capability_deltas = $10, enabled = $11,
+			    updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND user_id = $2
+			RETURNING id`,
+			m.ID, m.UserID, m.ModelID, m.DisplayName, m.Provider, m.BaseModel, m.Endpoint,
+			m.CostInPer1K, m.CostOutPer1K, deltas, m.Enabled).Scan(&id)
+	}
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("tenant model not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save tenant model: %w", err)
+	}
+	return id, nil
+}
+
+// Delete removes one of the tenant's models
+func (s *Store) Delete(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM tenant_models WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant model: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("tenant model not found")
+	}
+	return nil
+}
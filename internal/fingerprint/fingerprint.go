@@ -0,0 +1,76 @@
+package fingerprint
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is how many consecutive words make one simhash feature
+const shingleSize = 2
+
+// Fingerprint identifies a prompt. Exact matches only identical prompts after
+// normalization; SimHash differs in few bits between near-identical prompts.
+type Fingerprint struct {
+	Exact   uint64
+	SimHash uint64
+}
+
+// Compute fingerprints a prompt. Case, punctuation and whitespace are ignored,
+// so trivially reformatted prompts still match exactly.
+func Compute(prompt string) Fingerprint {
+	words := strings.FieldsFunc(strings.ToLower(prompt), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return Fingerprint{
+		Exact:   hash(strings.Join(words, " ")),
+		SimHash: simHash(words),
+	}
+}
+
+// Distance is the number of differing simhash bits
+func (f Fingerprint) Distance(other Fingerprint) int {
+	return bits.OnesCount64(f.SimHash ^ other.SimHash)
+}
+
+// simHash sums the word shingles' hashes bit by bit, setting the bits most
+// shingles agree on
+func simHash(words []string) uint64 {
+	if len(words) == 0 {
+		return 0
+	}
+	var weights [64]int
+	n := len(words) - shingleSize + 1
+	if n < 1 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		end := i + shingleSize
+		if end > len(words) {
+			end = len(words)
+		}
+		h := hash(strings.Join(words[i:end], " "))
+		for b := 0; b < 64; b++ {
+			if h&(1<<uint(b)) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+
+	var sum uint64
+	for b, w := range weights {
+		if w > 0 {
+			sum |= 1 << uint(b)
+		}
+	}
+	return sum
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
@@ -0,0 +1,281 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/usage"
+)
+
+// Duplicate kinds recorded with each request
+const (
+	DuplicateExact = "exact"
+	DuplicateNear  = "near"
+)
+
+const (
+	// historySize is how many recent prompts are kept per key
+	historySize = 256
+	// maxBodyBytes bounds how much of a request body is read for its prompt
+	maxBodyBytes = 1 << 20
+	// Keys idle this long are forgotten
+	idleExpiry = time.Hour
+)
+
+// Config holds the duplicate thresholds and throttling rules
+type Config struct {
+	Window            time.Duration // How far back duplicates are looked for
+	NearDistance      int           // Simhash bits two prompts may differ by and still be near duplicates
+	MaxRepeats        int           // Identical prompts allowed in the window, e.g. a runaway retry loop
+	MaxDuplicateShare float64       // Share of duplicate prompts in the window that marks benchmark gaming or abuse
+	MinRequests       int           // Requests in the window before the share is judged
+	Throttle          bool          // Refuse duplicates once a rule trips; otherwise only alert
+}
+
+// DefaultConfig returns the built-in rules; FINGERPRINT_THROTTLE=true enables
+// throttling and FINGERPRINT_MAX_REPEATS and FINGERPRINT_MAX_DUPLICATE_SHARE
+// tune it
+func DefaultConfig() Config {
+	cfg := Config{
+		Window:            10 * time.Minute,
+		NearDistance:      12,
+		MaxRepeats:        20,
+		MaxDuplicateShare: 0.9,
+		MinRequests:       50,
+		Throttle:          os.Getenv("FINGERPRINT_THROTTLE") == "true",
+	}
+	if n, err := strconv.Atoi(os.Getenv("FINGERPRINT_MAX_REPEATS")); err == nil && n > 0 {
+		cfg.MaxRepeats = n
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("FINGERPRINT_MAX_DUPLICATE_SHARE"), 64); err == nil && v > 0 && v <= 1 {
+		cfg.MaxDuplicateShare = v
+	}
+	return cfg
+}
+
+// Match is how a prompt relates to the key's recent prompts
+type Match struct {
+	Duplicate string  `json:"duplicate,omitempty"` // DuplicateExact, DuplicateNear or empty for a new prompt
+	Repeats   int     `json:"repeats"`             // Earlier identical prompts in the window
+	Requests  int     `json:"requests"`            // Requests in the window, this one included
+	Share     float64 `json:"duplicate_share"`     // Share of those that were duplicates
+	Rule      string  `json:"rule,omitempty"`      // The throttling rule tripped, if any
+}
+
+type seenPrompt struct {
+	fp        Fingerprint
+	at        time.Time
+	duplicate bool
+}
+
+// keyHistory is a ring of the key's most recent prompts
+type keyHistory struct {
+	prompts  [historySize]seenPrompt
+	next     int
+	lastSeen time.Time
+	alerted  map[string]time.Time
+}
+
+// Guard tracks prompt fingerprints per API key and applies the throttling rules
+type Guard struct {
+	cfg    Config
+	alerts *alerts.Manager
+
+	mu     sync.Mutex
+	keys   map[string]*keyHistory
+	lastGC time.Time
+}
+
+func NewGuard(cfg Config) *Guard {
+	return &Guard{cfg: cfg, keys: make(map[string]*keyHistory)}
+}
+
+// SetAlerts posts tripped rules to the operational alert webhooks
+func (g *Guard) SetAlerts(manager *alerts.Manager) {
+	g.alerts = manager
+}
+
+// Observe records a prompt from subject and compares it with the subject's
+// recent prompts
+func (g *Guard) Observe(subject string, fp Fingerprint) Match {
+	now := time.Now()
+	cutoff := now.Add(-g.cfg.Window)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.gc(now)
+	kh, ok := g.keys[subject]
+	if !ok {
+		kh = &keyHistory{alerted: make(map[string]time.Time)}
+		g.keys[subject] = kh
+	}
+	kh.lastSeen = now
+
+	var m Match
+	duplicates := 0
+	nearest := 65
+	for _, p := range kh.prompts {
+		if p.at.IsZero() || p.at.Before(cutoff) {
+			continue
+		}
+		m.Requests++
+		if p.duplicate {
+			duplicates++
+		}
+		if p.fp.Exact == fp.Exact {
+			m.Repeats++
+		} else if d := p.fp.Distance(fp); d < nearest {
+			nearest = d
+		}
+	}
+
+	switch {
+	case m.Repeats > 0:
+		m.Duplicate = DuplicateExact
+	case nearest <= g.cfg.NearDistance:
+		m.Duplicate = DuplicateNear
+	}
+	m.Requests++
+	if m.Duplicate != "" {
+		duplicates++
+	}
+	m.Share = float64(duplicates) / float64(m.Requests)
+
+	kh.prompts[kh.next] = seenPrompt{fp: fp, at: now, duplicate: m.Duplicate != ""}
+	kh.next = (kh.next + 1) % historySize
+
+	// Rules only ever hold back duplicates; a new prompt is always let through
+	switch {
+	case m.Repeats >= g.cfg.MaxRepeats:
+		m.Rule = "max_repeats"
+	case m.Duplicate != "" && m.Requests >= g.cfg.MinRequests && m.Share >= g.cfg.MaxDuplicateShare:
+		m.Rule = "max_duplicate_share"
+	}
+	if m.Rule != "" {
+		if last, ok := kh.alerted[m.Rule]; !ok || now.Sub(last) >= g.cfg.Window {
+			kh.alerted[m.Rule] = now
+			g.report(subject, m)
+		}
+	}
+	return m
+}
+
+func (g *Guard) report(subject string, m Match) {
+	log.Printf("[FINGERPRINT] %s tripped %s: %d repeats, %.0f%% duplicates over %d requests",
+		subject, m.Rule, m.Repeats, m.Share*100, m.Requests)
+
+	g.alerts.Notify(alerts.Event{
+		Type:     alerts.EventUsageAnomaly,
+		Severity: alerts.SeverityWarning,
+		Source:   "fingerprint",
+		Title:    fmt.Sprintf("Duplicate prompt traffic on %s: %s", subject, strings.ReplaceAll(m.Rule, "_", " ")),
+		Message:  "The key is sending the same prompts over and over, e.g. a retry loop, benchmark gaming or abuse.",
+		Fields: map[string]string{
+			"subject":         subject,
+			"repeats":         strconv.Itoa(m.Repeats),
+			"requests":        strconv.Itoa(m.Requests),
+			"duplicate_share": strconv.FormatFloat(m.Share, 'f', 2, 64),
+			"window":          g.cfg.Window.String(),
+			"throttled":       strconv.FormatBool(g.cfg.Throttle),
+		},
+		Key: subject + "|" + m.Rule,
+	})
+}
+
+func (g *Guard) gc(now time.Time) {
+	if now.Sub(g.lastGC) < idleExpiry {
+		return
+	}
+	g.lastGC = now
+	for subject, kh := range g.keys {
+		if now.Sub(kh.lastSeen) > idleExpiry {
+			delete(g.keys, subject)
+		}
+	}
+}
+
+// Middleware fingerprints the prompt of each authenticated POST, attributes
+// the fingerprint and duplicate kind to the usage record and, when throttling
+// is enabled, refuses duplicates from keys that tripped a rule. It must run
+// after the auth middleware and before usage tracking completes.
+func (g *Guard) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" || c.Request.Method != http.MethodPost || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		prompt, err := readPrompt(c)
+		if err != nil || prompt == "" {
+			c.Next()
+			return
+		}
+
+		subject := "user:" + userID
+		if apiKeyID := c.GetString("api_key_id"); apiKeyID != "" {
+			subject = "key:" + apiKeyID
+		}
+		fp := Compute(prompt)
+		m := g.Observe(subject, fp)
+
+		c.Set(usage.ContextFingerprint, int64(fp.Exact))
+		c.Set(usage.ContextDuplicate, m.Duplicate)
+
+		if g.cfg.Throttle && m.Rule != "" {
+			limits.TooManyRequests(c, limits.RetryHint{
+				RetryAfter: g.cfg.Window,
+				Window:     limits.WindowDuplicate,
+				Backoff:    limits.BackoffFixed,
+			}, gin.H{
+				"error":   "Duplicate prompt throttled",
+				"code":    "duplicate_prompts",
+				"details": m,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// readPrompt extracts the prompt text from a JSON body, either a prompt field
+// or chat messages, and restores the body for the handler
+func readPrompt(c *gin.Context) (string, error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodyBytes+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil || len(body) > maxBodyBytes {
+		return "", err
+	}
+
+	var req struct {
+		Prompt   string `json:"prompt"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", err
+	}
+	if req.Prompt != "" {
+		return req.Prompt, nil
+	}
+	parts := make([]string, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		parts = append(parts, m.Content)
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n")), nil
+}
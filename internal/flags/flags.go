@@ -0,0 +1,112 @@
+package flags
+
+import "strings"
+
+// Feature flags
+const (
+	RaceMode      = "race_mode"      // Generate with the top two models at once
+	Webhooks      = "webhooks"       // Register webhooks, e.g. for price changes
+	CustomModels  = "custom_models"  // Register fine-tuned models
+	BatchAPI      = "batch_api"      // Submit batch jobs
+	ShadowRouting = "shadow_routing" // Route a sample of traffic to a candidate model in the background
+)
+
+// Where a flag's value came from, most specific first
+const (
+	SourceUser    = "user"
+	SourceOrg     = "org"
+	SourcePlan    = "plan"
+	SourceDefault = "default"
+)
+
+// Override scopes
+const (
+	ScopeOrg  = "org"
+	ScopeUser = "user"
+)
+
+// Flag is a capability plans, organizations and users can be given
+type Flag struct {
+	Name         string   `json:"name"`
+	Label        string   `json:"label"`
+	Description  string   `json:"description"`
+	DefaultPlans []string `json:"default_plans"` // Plans that have it when plan_limits.features does not say
+}
+
+// Flags lists the known flags
+var Flags = []Flag{
+	{Name: RaceMode, Label: "Race mode", Description: "Call the router's top two models at once and keep the first answer; both are billed", DefaultPlans: []string{"pro", "enterprise"}},
+	{Name: Webhooks, Label: "Webhooks", Description: "Register webhooks for events such as model price changes", DefaultPlans: []string{"pro", "enterprise"}},
+	{Name: CustomModels, Label: "Custom models", Description: "Register fine-tuned models for the router to consider", DefaultPlans: []string{"pro", "enterprise"}},
+	{Name: BatchAPI, Label: "Batch API", Description: "Submit requests as batch jobs at batch-tier prices", DefaultPlans: []string{"pro", "enterprise"}},
+	{Name: ShadowRouting, Label: "Shadow routing", Description: "Send a sample of traffic to a candidate model in the background to compare it", DefaultPlans: []string{"enterprise"}},
+}
+
+// Lookup returns the named flag
+func Lookup(name string) (Flag, bool) {
+	for _, f := range Flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Names lists the known flag names
+func Names() []string {
+	names := make([]string, len(Flags))
+	for i, f := range Flags {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// PlanDefault reports whether a plan has the flag when neither plan_limits
+// nor an override says
+func PlanDefault(name, plan string) bool {
+	f, ok := Lookup(name)
+	if !ok {
+		return false
+	}
+	for _, p := range f.DefaultPlans {
+		if p == plan {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeOrganization is the key organization overrides are stored under,
+// as for two-factor policies and SSO connections
+func NormalizeOrganization(organization string) string {
+	return strings.ToLower(strings.TrimSpace(organization))
+}
+
+// Evaluation is one flag's value for a caller and where it came from
+type Evaluation struct {
+	Flag        string `json:"flag"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source"`
+}
+
+// evaluate resolves every flag: a user override wins over an organization
+// override, which wins over the plan's features, which win over the default
+func evaluate(plan string, planFeatures map[string]bool, orgOverrides, userOverrides map[string]bool) map[string]Evaluation {
+	evaluations := make(map[string]Evaluation, len(Flags))
+	for _, f := range Flags {
+		e := Evaluation{Flag: f.Name, Label: f.Label, Description: f.Description}
+		if enabled, ok := userOverrides[f.Name]; ok {
+			e.Enabled, e.Source = enabled, SourceUser
+		} else if enabled, ok := orgOverrides[f.Name]; ok {
+			e.Enabled, e.Source = enabled, SourceOrg
+		} else if enabled, ok := planFeatures[f.Name]; ok {
+			e.Enabled, e.Source = enabled, SourcePlan
+		} else {
+			e.Enabled, e.Source = PlanDefault(f.Name, plan), SourceDefault
+		}
+		evaluations[f.Name] = e
+	}
+	return evaluations
+}
@@ -0,0 +1,161 @@
+package flags
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers shows callers their flags, gates routes on them and lets admins
+// set them per plan, organization and user
+type Handlers struct {
+	service *Service
+}
+
+type SetFlagRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+func NewHandlers(service *Service) *Handlers {
+	return &Handlers{service: service}
+}
+
+// Require answers 403 unless the caller has the flag. It runs after the
+// auth middleware.
+func (h *Handlers) Require(flag string) gin.HandlerFunc {
+	f, _ := Lookup(flag)
+	return func(c *gin.Context) {
+		plan := c.GetString("user_plan")
+		if !h.service.Enabled(c.Request.Context(), c.GetString("user_id"), plan, flag) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   f.Label + " is not available on your plan",
+				"details": "plan " + plan + " does not include " + flag,
+				"feature": flag,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Features lists every flag for the caller, so the frontend can show what
+// their plan includes
+func (h *Handlers) Features(c *gin.Context) {
+	evaluations, err := h.service.Evaluate(c.Request.Context(), c.GetString("user_id"), c.GetString("user_plan"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load features",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	features := make([]Evaluation, 0, len(evaluations))
+	for _, e := range evaluations {
+		features = append(features, e)
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i].Flag < features[j].Flag })
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"plan":     c.GetString("user_plan"),
+			"features": features,
+		},
+	})
+}
+
+// List returns the known flags, each plan's values and the overrides,
+// optionally of one ?scope=
+func (h *Handlers) List(c *gin.Context) {
+	plans, err := h.service.PlanFlags(c.Request.Context())
+	if err == nil {
+		var overrides []Override
+		if overrides, err = h.service.ListOverrides(c.Request.Context(), c.Query("scope")); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": true,
+				"data": gin.H{
+					"flags":     Flags,
+					"plans":     plans,
+					"overrides": overrides,
+				},
+			})
+			return
+		}
+	}
+	h.respondError(c, err, "Failed to load feature flags")
+}
+
+// PutPlan turns a flag on or off for a plan
+func (h *Handlers) PutPlan(c *gin.Context) {
+	var req SetFlagRequest
+	if !bindSetFlag(c, &req) {
+		return
+	}
+	err := h.service.SetPlanFlag(c.Request.Context(), c.Param("plan"), c.Param("flag"), *req.Enabled, c.GetString("admin_id"))
+	h.respond(c, err, "Failed to update plan features")
+}
+
+// PutOverride turns a flag on or off for an organization or user, over
+// their plan
+func (h *Handlers) PutOverride(c *gin.Context) {
+	var req SetFlagRequest
+	if !bindSetFlag(c, &req) {
+		return
+	}
+	err := h.service.PutOverride(c.Request.Context(), c.Param("scope"), c.Param("subject"), c.Param("flag"), *req.Enabled, c.GetString("admin_id"))
+	h.respond(c, err, "Failed to store feature flag override")
+}
+
+// DeleteOverride returns an organization or user to their plan's value
+func (h *Handlers) DeleteOverride(c *gin.Context) {
+	err := h.service.DeleteOverride(c.Request.Context(), c.Param("scope"), c.Param("subject"), c.Param("flag"), c.GetString("admin_id"))
+	h.respond(c, err, "Failed to delete feature flag override")
+}
+
+func bindSetFlag(c *gin.Context, req *SetFlagRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func (h *Handlers) respond(c *gin.Context, err error, failure string) {
+	if err != nil {
+		h.respondError(c, err, failure)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+func (h *Handlers) respondError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, ErrUnknownFlag):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       "Unknown feature flag",
+			"valid_flags": Names(),
+		})
+	case errors.Is(err, ErrInvalidScope), errors.Is(err, ErrNoSubject):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid override",
+			"details": err.Error(),
+		})
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   failure,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package flags
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+)
+
+// cacheTTL bounds how long a replica serves a caller's flags before
+// re-reading them, so changes made through another replica take effect
+const cacheTTL = 30 * time.Second
+
+var (
+	ErrUnknownFlag  = errors.New("unknown feature flag")
+	ErrInvalidScope = errors.New("scope must be org or user")
+	ErrNoSubject    = errors.New("subject is required")
+	ErrNotFound     = errors.New("not found")
+)
+
+// Override turns a flag on or off for one organization or user, whatever
+// their plan says
+type Override struct {
+	Scope     string    `json:"scope"`
+	Subject   string    `json:"subject"` // Organization (users.company_name, lower-cased) or user ID
+	Flag      string    `json:"flag"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// cached is a caller's evaluated flags
+type cached struct {
+	flags   map[string]Evaluation
+	fetched time.Time
+}
+
+// Service evaluates feature flags from plan_limits.features and the
+// organization and user overrides
+type Service struct {
+	db       *sql.DB
+	auditLog *audit.Logger
+
+	mu    sync.Mutex
+	cache map[string]cached // By user ID and plan
+}
+
+func NewService(db *sql.DB, auditLog *audit.Logger) *Service {
+	return &Service{db: db, auditLog: auditLog, cache: make(map[string]cached)}
+}
+
+// Evaluate returns every flag for a caller on a plan. Results are cached
+// for cacheTTL.
+func (s *Service) Evaluate(ctx context.Context, userID, plan string) (map[string]Evaluation, error) {
+	key := userID + "|" + plan
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && time.Since(entry.fetched) < cacheTTL {
+		return entry.flags, nil
+	}
+
+	planFeatures, err := s.planFeatures(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	orgOverrides, userOverrides, err := s.callerOverrides(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	entry = cached{flags: evaluate(plan, planFeatures, orgOverrides, userOverrides), fetched: time.Now()}
+
+	s.mu.Lock()
+	s.cache[key] = entry
+	s.mu.Unlock()
+	return entry.flags, nil
+}
+
+// Enabled reports whether a caller has a flag. When the flags cannot be
+// read it falls back to the plan's default.
+func (s *Service) Enabled(ctx context.Context, userID, plan, flag string) bool {
+	evaluations, err := s.Evaluate(ctx, userID, plan)
+	if err != nil {
+		log.Printf("[FLAGS] Failed to evaluate flags for %s, using plan defaults: %v", userID, err)
+		return PlanDefault(flag, plan)
+	}
+	return evaluations[flag].Enabled
+}
+
+// planFeatures reads the boolean entries of a plan's features
+func (s *Service) planFeatures(ctx context.Context, plan string) (map[string]bool, error) {
+	var raw []byte
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(features, '{}'::jsonb) FROM plan_limits WHERE plan_type = $1`, plan).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan features: %w", err)
+	}
+	return boolFeatures(raw)
+}
+
+// callerOverrides reads the overrides for the user and the user's organization
+func (s *Service) callerOverrides(ctx context.Context, userID string) (org, user map[string]bool, err error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT scope, flag, enabled FROM feature_flag_overrides
+		WHERE (scope = 'user' AND subject = $1)
+		   OR (scope = 'org' AND subject = (SELECT LOWER(TRIM(company_name)) FROM users WHERE id::text = $1))`, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load feature flag overrides: %w", err)
+	}
+	defer rows.Close()
+
+	org, user = make(map[string]bool), make(map[string]bool)
+	for rows.Next() {
+		var scope, flag string
+		var enabled bool
+		if err := rows.Scan(&scope, &flag, &enabled); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan feature flag override: %w", err)
+		}
+		if scope == ScopeUser {
+			user[flag] = enabled
+		} else {
+			org[flag] = enabled
+		}
+	}
+	return org, user, rows.Err()
+}
+
+// PlanFlags returns each plan's value for every flag, from its features or
+// the default
+func (s *Service) PlanFlags(ctx context.Context) (map[string]map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT plan_type, COALESCE(features, '{}'::jsonb) FROM plan_limits ORDER BY plan_type`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan features: %w", err)
+	}
+	defer rows.Close()
+
+	plans := make(map[string]map[string]bool)
+	for rows.Next() {
+		var plan string
+		var raw []byte
+		if err := rows.Scan(&plan, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan plan features: %w", err)
+		}
+		features, err := boolFeatures(raw)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]bool, len(Flags))
+		for name, e := range evaluate(plan, features, nil, nil) {
+			values[name] = e.Enabled
+		}
+		plans[plan] = values
+	}
+	return plans, rows.Err()
+}
+
+// SetPlanFlag turns a flag on or off in a plan's features
+func (s *Service) SetPlanFlag(ctx context.Context, plan, flag string, enabled bool, adminID string) error {
+	if _, ok := Lookup(flag); !ok {
+		return ErrUnknownFlag
+	}
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE plan_limits SET features = jsonb_set(COALESCE(features, '{}'::jsonb), ARRAY[$2::text], to_jsonb($3::boolean))
+		WHERE plan_type = $1`, plan, flag, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to update plan features: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	s.invalidate()
+	s.record(ctx, adminID, "plan_set", map[string]interface{}{"plan": plan, "flag": flag, "enabled": enabled})
+	return nil
+}
+
+// ListOverrides returns the overrides, optionally of one scope
+func (s *Service) ListOverrides(ctx context.Context, scope string) ([]Override, error) {
+	if scope != "" && scope != ScopeOrg && scope != ScopeUser {
+		return nil, ErrInvalidScope
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT scope, subject, flag, enabled, COALESCE(updated_by, ''), updated_at FROM feature_flag_overrides
+		WHERE $1 = '' OR scope = $1
+		ORDER BY scope, subject, flag`, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flag overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []Override{}
+	for rows.Next() {
+		var o Override
+		if err := rows.Scan(&o.Scope, &o.Subject, &o.Flag, &o.Enabled, &o.UpdatedBy, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag override: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// PutOverride turns a flag on or off for an organization or user
+func (s *Service) PutOverride(ctx context.Context, scope, subject, flag string, enabled bool, adminID string) error {
+	subject, err := overrideKey(scope, subject, flag)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO feature_flag_overrides (scope, subject, flag, enabled, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, subject, flag) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP`,
+		scope, subject, flag, enabled, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to store feature flag override: %w", err)
+	}
+	s.invalidate()
+	s.record(ctx, adminID, "override_set", map[string]interface{}{"scope": scope, "subject": subject, "flag": flag, "enabled": enabled})
+	return nil
+}
+
+// DeleteOverride returns an organization or user to what its plan says
+func (s *Service) DeleteOverride(ctx context.Context, scope, subject, flag, adminID string) error {
+	subject, err := overrideKey(scope, subject, flag)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM feature_flag_overrides WHERE scope = $1 AND subject = $2 AND flag = $3`, scope, subject, flag)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag override: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	s.invalidate()
+	s.record(ctx, adminID, "override_deleted", map[string]interface{}{"scope": scope, "subject": subject, "flag": flag})
+	return nil
+}
+
+// overrideKey validates an override and returns the subject it is stored under
+func overrideKey(scope, subject, flag string) (string, error) {
+	if _, ok := Lookup(flag); !ok {
+		return "", ErrUnknownFlag
+	}
+	switch scope {
+	case ScopeOrg:
+		subject = NormalizeOrganization(subject)
+	case ScopeUser:
+	default:
+		return "", ErrInvalidScope
+	}
+	if subject == "" {
+		return "", ErrNoSubject
+	}
+	return subject, nil
+}
+
+// invalidate drops every cached evaluation; an organization override
+// reaches many users
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cache = make(map[string]cached)
+	s.mu.Unlock()
+}
+
+func (s *Service) record(ctx context.Context, adminID, action string, details map[string]interface{}) {
+	if s.auditLog == nil {
+		return
+	}
+	if _, err := s.auditLog.Record(ctx, audit.Entry{
+		UserID:    adminID,
+		EventType: "admin.feature_flags." + action,
+		Action:    action,
+		Resource:  "feature_flags",
+		Details:   details,
+	}); err != nil {
+		log.Printf("[FLAGS] Failed to record audit entry: %v", err)
+	}
+}
+
+// boolFeatures keeps the boolean entries of a plan's features; the rest,
+// such as the support tier, are not flags
+func boolFeatures(raw []byte) (map[string]bool, error) {
+	var features map[string]interface{}
+	if err := json.Unmarshal(raw, &features); err != nil {
+		return nil, fmt.Errorf("failed to parse plan features: %w", err)
+	}
+	flags := make(map[string]bool, len(features))
+	for name, value := range features {
+		if enabled, ok := value.(bool); ok {
+			flags[name] = enabled
+		}
+	}
+	return flags, nil
+}
@@ -0,0 +1,257 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	anthropicVersion = "2023-06-01"
+	// The Messages API requires max_tokens
+	anthropicDefaultMaxTokens = 4096
+)
+
+// anthropicAdapter speaks the native Messages API: the system prompt is a
+// top-level field, tool results are user content blocks, and images are
+// base64 or URL sources
+type anthropicAdapter struct{}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type      string           `json:"type"`
+	Text      string           `json:"text,omitempty"`
+	Source    *anthropicSource `json:"source,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     json.RawMessage  `json:"input,omitempty"`
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   string           `json:"content,omitempty"`
+}
+
+type anthropicSource struct {
+	Type      string `json:"type"` // base64, url
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+var anthropicStopReasons = map[string]string{
+	"end_turn":      FinishStop,
+	"stop_sequence": FinishStop,
+	"max_tokens":    FinishLength,
+	"tool_use":      FinishTools,
+	"refusal":       FinishContentFilter,
+}
+
+// usage normalizes reported usage: input_tokens leaves out prompt tokens
+// written to or read from the cache, so they are added back
+func (u anthropicUsage) usage() *tokenUsage {
+	return &tokenUsage{
+		inputTokens:       u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens,
+		outputTokens:      u.OutputTokens,
+		cachedInputTokens: u.CacheReadInputTokens,
+	}
+}
+
+func (anthropicAdapter) encode(model, apiKey string, req Request, stream bool) (wireRequest, error) {
+	var system []string
+	messages := []anthropicMessage{}
+
+	for _, m := range req.Messages {
+		role := m.Role
+		var blocks []anthropicBlock
+
+		switch m.Role {
+		case "system":
+			system = append(system, m.text())
+			continue
+		case "tool":
+			role = "user"
+			blocks = append(blocks, anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.text()})
+		case "user", "assistant":
+			if m.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+			}
+			for _, p := range m.Parts {
+				block, err := anthropicPart(p)
+				if err != nil {
+					return wireRequest{}, err
+				}
+				blocks = append(blocks, block)
+			}
+			for _, tc := range m.ToolCalls {
+				input := tc.Arguments
+				if len(input) == 0 {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: input})
+			}
+		default:
+			return wireRequest{}, fmt.Errorf("unsupported message role %q", m.Role)
+		}
+
+		// Consecutive turns from the same role must be merged; roles alternate
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+	body := map[string]interface{}{
+		"model":      model,
+		"messages":   messages,
+		"max_tokens": maxTokens,
+	}
+	if len(system) > 0 {
+		body["system"] = strings.Join(system, "\n\n")
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	tools := make([]map[string]interface{}, 0, len(req.Tools)+1)
+	for _, t := range req.Tools {
+		schema := t.Parameters
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		tools = append(tools, map[string]interface{}{
+			"name":         t.Name,
+			"description":  t.Description,
+			"input_schema": schema,
+		})
+	}
+	// Structured output is the input of a tool the model must call
+	if req.structuredMode == StructuredTool {
+		tools = append(tools, map[string]interface{}{
+			"name":         structuredToolName,
+			"description":  "Return the response in the required structure",
+			"input_schema": req.ResponseSchema,
+		})
+		body["tool_choice"] = map[string]string{"type": "tool", "name": structuredToolName}
+	}
+	if len(tools) > 0 {
+		body["tools"] = tools
+	}
+	if stream {
+		body["stream"] = true
+	}
+
+	return wireRequest{
+		path: "/messages",
+		headers: map[string]string{
+			"x-api-key":         apiKey,
+			"anthropic-version": anthropicVersion,
+		},
+		body: body,
+	}, nil
+}
+
+func anthropicPart(p ContentPart) (anthropicBlock, error) {
+	switch p.Type {
+	case "text":
+		return anthropicBlock{Type: "text", Text: p.Text}, nil
+	case "image":
+		if mediaType, data, ok := parseDataURL(p.ImageURL); ok {
+			return anthropicBlock{Type: "image", Source: &anthropicSource{Type: "base64", MediaType: mediaType, Data: data}}, nil
+		}
+		return anthropicBlock{Type: "image", Source: &anthropicSource{Type: "url", URL: p.ImageURL}}, nil
+	}
+	return anthropicBlock{}, fmt.Errorf("unsupported content part type %q", p.Type)
+}
+
+func (anthropicAdapter) decode(body []byte) (completion, error) {
+	var out struct {
+		Content    []anthropicBlock `json:"content"`
+		StopReason string           `json:"stop_reason"`
+		Usage      anthropicUsage   `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return completion{}, err
+	}
+
+	result := completion{
+		finishReason: normalizeFinish(anthropicStopReasons, out.StopReason),
+		rawFinish:    out.StopReason,
+		usage:        out.Usage.usage(),
+	}
+	result.safety = outputFiltered(result.finishReason, out.StopReason, nil)
+	var text strings.Builder
+	for _, block := range out.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			result.toolCalls = append(result.toolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: block.Input})
+		}
+	}
+	result.content = text.String()
+	return result, nil
+}
+
+func (anthropicAdapter) decodeEvent(event string, data []byte) (streamEvent, bool, error) {
+	var payload struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type       string `json:"type"`
+			Text       string `json:"text"`
+			StopReason string `json:"stop_reason"`
+		} `json:"delta"`
+		Message struct {
+			Usage anthropicUsage `json:"usage"`
+		} `json:"message"`
+		Usage *anthropicUsage `json:"usage"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return streamEvent{}, false, err
+	}
+
+	switch payload.Type {
+	case "message_start":
+		// Output is counted by message_delta; message_start's is a placeholder
+		usage := payload.Message.Usage.usage()
+		usage.outputTokens = 0
+		return streamEvent{usage: usage}, false, nil
+	case "content_block_delta":
+		if payload.Delta.Type == "text_delta" {
+			return streamEvent{delta: payload.Delta.Text}, false, nil
+		}
+	case "message_delta":
+		ev := streamEvent{
+			finishReason: normalizeFinish(anthropicStopReasons, payload.Delta.StopReason),
+			rawFinish:    payload.Delta.StopReason,
+		}
+		ev.safety = outputFiltered(ev.finishReason, payload.Delta.StopReason, nil)
+		if payload.Usage != nil {
+			ev.usage = &tokenUsage{outputTokens: payload.Usage.OutputTokens}
+		}
+		return ev, false, nil
+	case "message_stop":
+		return streamEvent{}, true, nil
+	case "error":
+		if payload.Error != nil {
+			return streamEvent{}, true, fmt.Errorf("%s", payload.Error.Message)
+		}
+		return streamEvent{}, true, fmt.Errorf("stream error")
+	}
+	return streamEvent{}, false, nil
+}
@@ -0,0 +1,235 @@
+package generate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAnthropicEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   string
+		req     Request
+		stream  bool
+		fixture string
+	}{
+		{
+			name:    "system prompt, image, tool_use and tool_result",
+			model:   "claude-3-5-sonnet-20241022",
+			req:     toolConversation(),
+			fixture: "anthropic/request_tools.json",
+		},
+		{
+			name:  "system prompts joined, same role merged, url image and default max_tokens",
+			model: "claude-3-5-haiku-20241022",
+			req: Request{Messages: []Message{
+				{Role: "system", Content: "You are a terse assistant."},
+				{Role: "system", Content: "Answer in English."},
+				{Role: "user", Content: "Describe this diagram."},
+				{Role: "user", Parts: []ContentPart{
+					{Type: "image", ImageURL: "https://upload.wikimedia.org/wikipedia/commons/3/3a/Network_router.png"},
+				}},
+			}},
+			stream:  true,
+			fixture: "anthropic/request_stream.json",
+		},
+		{
+			name:  "response schema as a forced tool",
+			model: "claude-3-5-sonnet-20241022",
+			req: Request{
+				Messages:       []Message{{Role: "user", Content: "Extract the city from: I live in Lyon."}},
+				MaxTokens:      1024,
+				ResponseSchema: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+				structuredMode: StructuredTool,
+			},
+			fixture: "anthropic/request_structured.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wire, err := anthropicAdapter{}.encode(tt.model, "sk-ant-test", tt.req, tt.stream)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if wire.path != "/messages" {
+				t.Errorf("path = %q, want /messages", wire.path)
+			}
+			wantHeaders := map[string]string{"x-api-key": "sk-ant-test", "anthropic-version": "2023-06-01"}
+			if !reflect.DeepEqual(wire.headers, wantHeaders) {
+				t.Errorf("headers = %v, want %v", wire.headers, wantHeaders)
+			}
+			assertWireBody(t, wire.body, tt.fixture)
+		})
+	}
+}
+
+func TestAnthropicEncodeUnsupportedRole(t *testing.T) {
+	req := Request{Messages: []Message{{Role: "developer", Content: "Be brief."}}}
+	if _, err := (anthropicAdapter{}).encode("claude-3-5-haiku-20241022", "sk-ant-test", req, false); err == nil {
+		t.Fatal("encode succeeded, want an error for the developer role")
+	}
+}
+
+func TestAnthropicDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    completion
+	}{
+		{
+			name:    "text with cache writes and reads",
+			fixture: "anthropic/response_text.json",
+			want: completion{
+				content:      "Packets find their way.",
+				finishReason: FinishStop,
+				rawFinish:    "end_turn",
+				usage:        &tokenUsage{inputTokens: 21 + 188 + 1024, outputTokens: 46, cachedInputTokens: 1024},
+			},
+		},
+		{
+			name:    "tool_use",
+			fixture: "anthropic/response_tool_use.json",
+			want: completion{
+				content:      "I'll check the weather in Paris.",
+				finishReason: FinishTools,
+				rawFinish:    "tool_use",
+				toolCalls:    []ToolCall{{ID: "toolu_01A09q90qw90lq917835lq9", Name: "get_weather", Arguments: json.RawMessage(`{"city": "Paris"}`)}},
+				usage:        &tokenUsage{inputTokens: 384, outputTokens: 58},
+			},
+		},
+		{
+			name:    "max_tokens",
+			fixture: "anthropic/response_max_tokens.json",
+			want: completion{
+				content:      "Routers forward pack",
+				finishReason: FinishLength,
+				rawFinish:    "max_tokens",
+				usage:        &tokenUsage{inputTokens: 12, outputTokens: 5},
+			},
+		},
+		{
+			name:    "stop_sequence",
+			fixture: "anthropic/response_stop_sequence.json",
+			want: completion{
+				content:      "1, 2, 3",
+				finishReason: FinishStop,
+				rawFinish:    "stop_sequence",
+				usage:        &tokenUsage{inputTokens: 15, outputTokens: 7},
+			},
+		},
+		{
+			name:    "refusal",
+			fixture: "anthropic/response_refusal.json",
+			want: completion{
+				finishReason: FinishContentFilter,
+				rawFinish:    "refusal",
+				usage:        &tokenUsage{inputTokens: 40},
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Reason: "refusal"},
+			},
+		},
+		{
+			name:    "unknown stop reason",
+			fixture: "anthropic/response_pause_turn.json",
+			want: completion{
+				content:      "Searching",
+				finishReason: FinishOther,
+				rawFinish:    "pause_turn",
+				usage:        &tokenUsage{inputTokens: 20, outputTokens: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := anthropicAdapter{}.decode(readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			assertCompletion(t, got, tt.want)
+		})
+	}
+}
+
+func TestAnthropicDecodeEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		data     string
+		want     streamEvent
+		wantDone bool
+		wantErr  bool
+	}{
+		{
+			name:  "message_start counts input only",
+			event: "message_start",
+			data:  `{"type":"message_start","message":{"id":"msg_1nZdL29xx5MUA1yADyHTEsnR8uuvGzszyY","type":"message","role":"assistant","content":[],"model":"claude-3-5-sonnet-20241022","stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":25,"cache_creation_input_tokens":0,"cache_read_input_tokens":2048,"output_tokens":1}}}`,
+			want:  streamEvent{usage: &tokenUsage{inputTokens: 25 + 2048, cachedInputTokens: 2048}},
+		},
+		{
+			name:  "content_block_start",
+			event: "content_block_start",
+			data:  `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		},
+		{
+			name:  "text_delta",
+			event: "content_block_delta",
+			data:  `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Packets"}}`,
+			want:  streamEvent{delta: "Packets"},
+		},
+		{
+			name:  "input_json_delta is not text",
+			event: "content_block_delta",
+			data:  `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\": \"Par"}}`,
+		},
+		{
+			name:  "ping",
+			event: "ping",
+			data:  `{"type": "ping"}`,
+		},
+		{
+			name:  "message_delta carries finish and output",
+			event: "message_delta",
+			data:  `{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null},"usage":{"output_tokens":15}}`,
+			want:  streamEvent{finishReason: FinishLength, rawFinish: "max_tokens", usage: &tokenUsage{outputTokens: 15}},
+		},
+		{
+			name:  "message_delta refusal",
+			event: "message_delta",
+			data:  `{"type":"message_delta","delta":{"stop_reason":"refusal","stop_sequence":null},"usage":{"output_tokens":3}}`,
+			want: streamEvent{
+				finishReason: FinishContentFilter,
+				rawFinish:    "refusal",
+				usage:        &tokenUsage{outputTokens: 3},
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Reason: "refusal"},
+			},
+		},
+		{
+			name:     "message_stop",
+			event:    "message_stop",
+			data:     `{"type":"message_stop"}`,
+			wantDone: true,
+		},
+		{
+			name:     "error",
+			event:    "error",
+			data:     `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			wantDone: true,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, done, err := anthropicAdapter{}.decodeEvent(tt.event, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeEvent error = %v, want error %v", err, tt.wantErr)
+			}
+			if done != tt.wantDone {
+				t.Errorf("done = %v, want %v", done, tt.wantDone)
+			}
+			assertEvent(t, got, tt.want)
+		})
+	}
+}
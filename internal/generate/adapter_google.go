@@ -0,0 +1,281 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// googleAdapter speaks the native Gemini generateContent API: the system prompt
+// is a systemInstruction, assistant turns use the "model" role, images must be
+// inline data, and tool results are functionResponse parts keyed by name
+type googleAdapter struct{}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content       geminiContent        `json:"content"`
+		FinishReason  string               `json:"finishReason"`
+		SafetyRatings []geminiSafetyRating `json:"safetyRatings"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason   string               `json:"blockReason"`
+		SafetyRatings []geminiSafetyRating `json:"safetyRatings"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount"`
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+type geminiSafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked"`
+}
+
+var geminiFinishReasons = map[string]string{
+	"STOP":               FinishStop,
+	"MAX_TOKENS":         FinishLength,
+	"SAFETY":             FinishContentFilter,
+	"RECITATION":         FinishContentFilter,
+	"BLOCKLIST":          FinishContentFilter,
+	"PROHIBITED_CONTENT": FinishContentFilter,
+	"SPII":               FinishContentFilter,
+	"IMAGE_SAFETY":       FinishContentFilter,
+}
+
+func (googleAdapter) encode(model, apiKey string, req Request, stream bool) (wireRequest, error) {
+	var system []string
+	contents := []geminiContent{}
+
+	for _, m := range req.Messages {
+		role := m.Role
+		var parts []geminiPart
+
+		switch m.Role {
+		case "system":
+			system = append(system, m.text())
+			continue
+		case "tool":
+			if m.ToolName == "" {
+				return wireRequest{}, fmt.Errorf("tool messages need tool_name for google models")
+			}
+			role = "user"
+			parts = append(parts, geminiPart{FunctionResponse: &geminiFunctionResponse{
+				Name:     m.ToolName,
+				Response: map[string]interface{}{"content": m.text()},
+			}})
+		case "user", "assistant":
+			if role == "assistant" {
+				role = "model"
+			}
+			if m.Content != "" {
+				parts = append(parts, geminiPart{Text: m.Content})
+			}
+			for _, p := range m.Parts {
+				part, err := geminiPartFor(p)
+				if err != nil {
+					return wireRequest{}, err
+				}
+				parts = append(parts, part)
+			}
+			for _, tc := range m.ToolCalls {
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{Name: tc.Name, Args: tc.Arguments}})
+			}
+		default:
+			return wireRequest{}, fmt.Errorf("unsupported message role %q", m.Role)
+		}
+
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			continue
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	body := map[string]interface{}{
+		"contents": contents,
+	}
+	if len(system) > 0 {
+		body["systemInstruction"] = geminiContent{Parts: []geminiPart{{Text: strings.Join(system, "\n\n")}}}
+	}
+	config := map[string]interface{}{}
+	if req.MaxTokens > 0 {
+		config["maxOutputTokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		config["temperature"] = *req.Temperature
+	}
+	if req.structuredMode == StructuredSchema {
+		config["responseMimeType"] = "application/json"
+		config["responseJsonSchema"] = req.ResponseSchema
+	}
+	if len(config) > 0 {
+		body["generationConfig"] = config
+	}
+	if len(req.Tools) > 0 {
+		declarations := make([]map[string]interface{}, 0, len(req.Tools))
+		for _, t := range req.Tools {
+			declaration := map[string]interface{}{"name": t.Name, "description": t.Description}
+			if len(t.Parameters) > 0 {
+				declaration["parameters"] = t.Parameters
+			}
+			declarations = append(declarations, declaration)
+		}
+		body["tools"] = []map[string]interface{}{{"functionDeclarations": declarations}}
+	}
+
+	path := "/models/" + model + ":generateContent"
+	if stream {
+		path = "/models/" + model + ":streamGenerateContent?alt=sse"
+	}
+	return wireRequest{
+		path:    path,
+		headers: map[string]string{"x-goog-api-key": apiKey},
+		body:    body,
+	}, nil
+}
+
+func geminiPartFor(p ContentPart) (geminiPart, error) {
+	switch p.Type {
+	case "text":
+		return geminiPart{Text: p.Text}, nil
+	case "image":
+		mediaType, data, ok := parseDataURL(p.ImageURL)
+		if !ok {
+			return geminiPart{}, fmt.Errorf("google models need images as base64 data: URLs")
+		}
+		return geminiPart{InlineData: &geminiInlineData{MimeType: mediaType, Data: data}}, nil
+	}
+	return geminiPart{}, fmt.Errorf("unsupported content part type %q", p.Type)
+}
+
+func (googleAdapter) decode(body []byte) (completion, error) {
+	var out geminiResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return completion{}, err
+	}
+	if blocked := geminiPromptBlocked(out); blocked != nil {
+		return completion{finishReason: FinishContentFilter, rawFinish: blocked.Reason, usage: geminiUsage(out), safety: blocked}, nil
+	}
+	if len(out.Candidates) == 0 {
+		return completion{}, fmt.Errorf("response has no candidates")
+	}
+
+	result := completion{usage: geminiUsage(out)}
+	var text strings.Builder
+	for i, part := range out.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+		if part.FunctionCall != nil {
+			// Gemini does not assign call IDs; synthesize stable ones
+			result.toolCalls = append(result.toolCalls, ToolCall{
+				ID: fmt.Sprintf("call_%d", i), Name: part.FunctionCall.Name, Arguments: part.FunctionCall.Args,
+			})
+		}
+	}
+	result.content = text.String()
+	candidate := out.Candidates[0]
+	result.finishReason = geminiFinish(candidate.FinishReason, len(result.toolCalls) > 0)
+	result.rawFinish = candidate.FinishReason
+	result.safety = outputFiltered(result.finishReason, candidate.FinishReason, geminiBlockedCategories(candidate.SafetyRatings))
+	return result, nil
+}
+
+func (googleAdapter) decodeEvent(event string, data []byte) (streamEvent, bool, error) {
+	var chunk geminiResponse
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return streamEvent{}, false, err
+	}
+
+	ev := streamEvent{usage: geminiUsage(chunk)}
+	if blocked := geminiPromptBlocked(chunk); blocked != nil {
+		ev.finishReason, ev.rawFinish, ev.safety = FinishContentFilter, blocked.Reason, blocked
+		return ev, true, nil
+	}
+	if len(chunk.Candidates) > 0 {
+		candidate := chunk.Candidates[0]
+		for _, part := range candidate.Content.Parts {
+			ev.delta += part.Text
+		}
+		ev.finishReason, ev.rawFinish = geminiFinish(candidate.FinishReason, false), candidate.FinishReason
+		ev.safety = outputFiltered(ev.finishReason, candidate.FinishReason, geminiBlockedCategories(candidate.SafetyRatings))
+	}
+	// The stream simply ends after the chunk carrying the finish reason
+	return ev, false, nil
+}
+
+// geminiUsage normalizes reported usage: thinking tokens are billed as output
+// but left out of candidatesTokenCount, so they are added
+func geminiUsage(r geminiResponse) *tokenUsage {
+	if r.UsageMetadata == nil {
+		return nil
+	}
+	u := r.UsageMetadata
+	return &tokenUsage{
+		inputTokens:       u.PromptTokenCount,
+		outputTokens:      u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		cachedInputTokens: u.CachedContentTokenCount,
+		reasoningTokens:   u.ThoughtsTokenCount,
+	}
+}
+
+func geminiFinish(reason string, toolCalls bool) string {
+	if toolCalls {
+		return FinishTools
+	}
+	return normalizeFinish(geminiFinishReasons, reason)
+}
+
+// geminiPromptBlocked reports a prompt Gemini refused to answer, or nil
+func geminiPromptBlocked(r geminiResponse) *SafetyFlags {
+	if r.PromptFeedback == nil || r.PromptFeedback.BlockReason == "" {
+		return nil
+	}
+	return &SafetyFlags{
+		Blocked:    true,
+		Stage:      SafetyStagePrompt,
+		Categories: geminiBlockedCategories(r.PromptFeedback.SafetyRatings),
+		Reason:     r.PromptFeedback.BlockReason,
+	}
+}
+
+// geminiBlockedCategories lists the categories Gemini blocked on, or rated
+// highly likely to be harmful
+func geminiBlockedCategories(ratings []geminiSafetyRating) []string {
+	var categories []string
+	for _, r := range ratings {
+		if r.Blocked || r.Probability == "HIGH" {
+			categories = append(categories, safetyCategory(r.Category))
+		}
+	}
+	return categories
+}
@@ -0,0 +1,249 @@
+package generate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGoogleEncode(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		req      Request
+		stream   bool
+		fixture  string
+		wantPath string
+	}{
+		{
+			name:     "system instruction, inline image, functionCall and functionResponse",
+			model:    "gemini-2.0-flash",
+			req:      toolConversation(),
+			fixture:  "google/request_tools.json",
+			wantPath: "/models/gemini-2.0-flash:generateContent",
+		},
+		{
+			name:  "stream with system prompts joined and same role merged",
+			model: "gemini-2.5-flash",
+			req: Request{Messages: []Message{
+				{Role: "system", Content: "You are a terse assistant."},
+				{Role: "system", Content: "Answer in English."},
+				{Role: "user", Content: "Write a haiku about routers."},
+				{Role: "user", Content: "Mention packets."},
+			}},
+			stream:   true,
+			fixture:  "google/request_stream.json",
+			wantPath: "/models/gemini-2.5-flash:streamGenerateContent?alt=sse",
+		},
+		{
+			name:  "response schema",
+			model: "gemini-2.0-flash",
+			req: Request{
+				Messages:       []Message{{Role: "user", Content: "Extract the city from: I live in Lyon."}},
+				ResponseSchema: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+				structuredMode: StructuredSchema,
+			},
+			fixture:  "google/request_schema.json",
+			wantPath: "/models/gemini-2.0-flash:generateContent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wire, err := googleAdapter{}.encode(tt.model, "AIza-test", tt.req, tt.stream)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if wire.path != tt.wantPath {
+				t.Errorf("path = %q, want %q", wire.path, tt.wantPath)
+			}
+			wantHeaders := map[string]string{"x-goog-api-key": "AIza-test"}
+			if !reflect.DeepEqual(wire.headers, wantHeaders) {
+				t.Errorf("headers = %v, want %v", wire.headers, wantHeaders)
+			}
+			assertWireBody(t, wire.body, tt.fixture)
+		})
+	}
+}
+
+func TestGoogleEncodeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+	}{
+		{
+			name: "image url",
+			messages: []Message{{Role: "user", Parts: []ContentPart{
+				{Type: "image", ImageURL: "https://upload.wikimedia.org/wikipedia/commons/3/3a/Network_router.png"},
+			}}},
+		},
+		{
+			name:     "tool result without tool_name",
+			messages: []Message{{Role: "tool", ToolCallID: "call_0", Content: "18C"}},
+		},
+		{
+			name:     "unsupported role",
+			messages: []Message{{Role: "developer", Content: "Be brief."}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (googleAdapter{}).encode("gemini-2.0-flash", "AIza-test", Request{Messages: tt.messages}, false); err == nil {
+				t.Fatal("encode succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestGoogleDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    completion
+		wantErr bool
+	}{
+		{
+			name:    "text with cached and thinking tokens",
+			fixture: "google/response_text.json",
+			want: completion{
+				content:      "Packets find their way.",
+				finishReason: FinishStop,
+				rawFinish:    "STOP",
+				usage:        &tokenUsage{inputTokens: 1117, outputTokens: 14 + 32, cachedInputTokens: 1024, reasoningTokens: 32},
+			},
+		},
+		{
+			name:    "function calls finish with tool_calls",
+			fixture: "google/response_function_call.json",
+			want: completion{
+				content:      "Checking both cities.",
+				finishReason: FinishTools,
+				rawFinish:    "STOP",
+				toolCalls: []ToolCall{
+					{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{"city": "Paris"}`)},
+					{ID: "call_2", Name: "get_weather", Arguments: json.RawMessage(`{"city": "Lyon"}`)},
+				},
+				usage: &tokenUsage{inputTokens: 96, outputTokens: 22},
+			},
+		},
+		{
+			name:    "max tokens",
+			fixture: "google/response_max_tokens.json",
+			want: completion{
+				content:      "Routers forward pack",
+				finishReason: FinishLength,
+				rawFinish:    "MAX_TOKENS",
+				usage:        &tokenUsage{inputTokens: 12, outputTokens: 5},
+			},
+		},
+		{
+			name:    "output blocked for safety",
+			fixture: "google/response_safety.json",
+			want: completion{
+				finishReason: FinishContentFilter,
+				rawFinish:    "SAFETY",
+				usage:        &tokenUsage{inputTokens: 30},
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Categories: []string{"hate_speech", "harassment"}, Reason: "SAFETY"},
+			},
+		},
+		{
+			name:    "prompt blocked",
+			fixture: "google/response_prompt_blocked.json",
+			want: completion{
+				finishReason: FinishContentFilter,
+				rawFinish:    "SAFETY",
+				usage:        &tokenUsage{inputTokens: 18},
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStagePrompt, Categories: []string{"dangerous_content"}, Reason: "SAFETY"},
+			},
+		},
+		{
+			name:    "unknown finish reason",
+			fixture: "google/response_malformed_call.json",
+			want: completion{
+				finishReason: FinishOther,
+				rawFinish:    "MALFORMED_FUNCTION_CALL",
+				usage:        &tokenUsage{inputTokens: 64},
+			},
+		},
+		{
+			name:    "no candidates",
+			fixture: "google/response_no_candidates.json",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := googleAdapter{}.decode(readFixture(t, tt.fixture))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decode succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			assertCompletion(t, got, tt.want)
+		})
+	}
+}
+
+func TestGoogleDecodeEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     streamEvent
+		wantDone bool
+	}{
+		{
+			name: "text chunk",
+			data: `{"candidates": [{"content": {"parts": [{"text": "Packets"}],"role": "model"},"index": 0}],"usageMetadata": {"promptTokenCount": 9,"totalTokenCount": 9},"modelVersion": "gemini-2.0-flash"}`,
+			want: streamEvent{delta: "Packets", usage: &tokenUsage{inputTokens: 9}},
+		},
+		{
+			name: "final chunk",
+			data: `{"candidates": [{"content": {"parts": [{"text": " find their way."}],"role": "model"},"finishReason": "STOP","index": 0}],"usageMetadata": {"promptTokenCount": 9,"candidatesTokenCount": 12,"totalTokenCount": 41,"thoughtsTokenCount": 20},"modelVersion": "gemini-2.5-flash"}`,
+			want: streamEvent{
+				delta:        " find their way.",
+				finishReason: FinishStop,
+				rawFinish:    "STOP",
+				usage:        &tokenUsage{inputTokens: 9, outputTokens: 32, reasoningTokens: 20},
+			},
+		},
+		{
+			name: "recitation",
+			data: `{"candidates": [{"content": {"parts": [{"text": ""}],"role": "model"},"finishReason": "RECITATION","index": 0}],"modelVersion": "gemini-2.0-flash"}`,
+			want: streamEvent{
+				finishReason: FinishContentFilter,
+				rawFinish:    "RECITATION",
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Reason: "RECITATION"},
+			},
+		},
+		{
+			name: "prompt blocked ends the stream",
+			data: `{"promptFeedback": {"blockReason": "PROHIBITED_CONTENT"},"usageMetadata": {"promptTokenCount": 18,"totalTokenCount": 18},"modelVersion": "gemini-2.0-flash"}`,
+			want: streamEvent{
+				finishReason: FinishContentFilter,
+				rawFinish:    "PROHIBITED_CONTENT",
+				usage:        &tokenUsage{inputTokens: 18},
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStagePrompt, Reason: "PROHIBITED_CONTENT"},
+			},
+			wantDone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, done, err := googleAdapter{}.decodeEvent("", []byte(tt.data))
+			if err != nil {
+				t.Fatalf("decodeEvent: %v", err)
+			}
+			if done != tt.wantDone {
+				t.Errorf("done = %v, want %v", done, tt.wantDone)
+			}
+			assertEvent(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// openAIAdapter speaks the OpenAI chat completions format, which most
+// providers also expose as a compatibility endpoint
+type openAIAdapter struct{}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content,omitempty"` // string, or parts for multimodal turns
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON encoded as a string
+	} `json:"function"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string           `json:"content"`
+			Refusal   string           `json:"refusal"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+			Refusal string `json:"refusal"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+var openAIFinishReasons = map[string]string{
+	"stop":           FinishStop,
+	"length":         FinishLength,
+	"tool_calls":     FinishTools,
+	"function_call":  FinishTools,
+	"content_filter": FinishContentFilter,
+}
+
+// usage normalizes reported usage; prompt and completion counts already
+// include cached and reasoning tokens
+func (u *openAIUsage) usage() *tokenUsage {
+	if u == nil {
+		return nil
+	}
+	usage := &tokenUsage{inputTokens: u.PromptTokens, outputTokens: u.CompletionTokens}
+	if u.PromptTokensDetails != nil {
+		usage.cachedInputTokens = u.PromptTokensDetails.CachedTokens
+	}
+	if u.CompletionTokensDetails != nil {
+		usage.reasoningTokens = u.CompletionTokensDetails.ReasoningTokens
+	}
+	return usage
+}
+
+func (openAIAdapter) encode(model, apiKey string, req Request, stream bool) (wireRequest, error) {
+	messages := make([]openAIMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		msg := openAIMessage{Role: m.Role, ToolCallID: m.ToolCallID}
+
+		if len(m.Parts) == 0 {
+			msg.Content = m.Content
+		} else {
+			parts := []openAIPart{}
+			if m.Content != "" {
+				parts = append(parts, openAIPart{Type: "text", Text: m.Content})
+			}
+			for _, p := range m.Parts {
+				switch p.Type {
+				case "text":
+					parts = append(parts, openAIPart{Type: "text", Text: p.Text})
+				case "image":
+					parts = append(parts, openAIPart{Type: "image_url", ImageURL: &openAIImageURL{URL: p.ImageURL}})
+				default:
+					return wireRequest{}, fmt.Errorf("unsupported content part type %q", p.Type)
+				}
+			}
+			msg.Content = parts
+		}
+
+		for _, tc := range m.ToolCalls {
+			call := openAIToolCall{ID: tc.ID, Type: "function"}
+			call.Function.Name = tc.Name
+			call.Function.Arguments = string(tc.Arguments)
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		messages = append(messages, msg)
+	}
+
+	body := map[string]interface{}{
+		"model":    model,
+		"messages": messages,
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if len(req.Tools) > 0 {
+		tools := make([]openAITool, 0, len(req.Tools))
+		for _, t := range req.Tools {
+			tools = append(tools, openAITool{Type: "function", Function: openAIFunction{
+				Name: t.Name, Description: t.Description, Parameters: t.Parameters,
+			}})
+		}
+		body["tools"] = tools
+	}
+	switch req.structuredMode {
+	case StructuredSchema:
+		// Not strict: strict mode rejects schemas with optional properties
+		body["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "response",
+				"schema": req.ResponseSchema,
+				"strict": false,
+			},
+		}
+	case StructuredJSON:
+		body["response_format"] = map[string]string{"type": "json_object"}
+	}
+	if stream {
+		body["stream"] = true
+		body["stream_options"] = map[string]bool{"include_usage": true}
+	}
+
+	return wireRequest{
+		path:    "/chat/completions",
+		headers: bearer(apiKey),
+		body:    body,
+	}, nil
+}
+
+// bearer is the auth header for a key; self-hosted backends may have none
+func bearer(apiKey string) map[string]string {
+	if apiKey == "" {
+		return map[string]string{}
+	}
+	return map[string]string{"Authorization": "Bearer " + apiKey}
+}
+
+func (openAIAdapter) decode(body []byte) (completion, error) {
+	var out openAIResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return completion{}, err
+	}
+	if len(out.Choices) == 0 {
+		return completion{}, fmt.Errorf("response has no choices")
+	}
+
+	choice := out.Choices[0]
+	result := completion{
+		content:      choice.Message.Content,
+		finishReason: normalizeFinish(openAIFinishReasons, choice.FinishReason),
+		rawFinish:    choice.FinishReason,
+		usage:        out.Usage.usage(),
+	}
+	for _, tc := range choice.Message.ToolCalls {
+		result.toolCalls = append(result.toolCalls, ToolCall{
+			ID: tc.ID, Name: tc.Function.Name, Arguments: json.RawMessage(tc.Function.Arguments),
+		})
+	}
+	// A refusal finishes with "stop" but is the model declining on safety grounds
+	if choice.Message.Refusal != "" {
+		result.finishReason = FinishContentFilter
+	}
+	result.safety = outputFiltered(result.finishReason, refusalReason(choice.Message.Refusal, choice.FinishReason), nil)
+	return result, nil
+}
+
+func (openAIAdapter) decodeEvent(event string, data []byte) (streamEvent, bool, error) {
+	if string(data) == "[DONE]" {
+		return streamEvent{}, true, nil
+	}
+
+	var chunk openAIResponse
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return streamEvent{}, false, err
+	}
+	ev := streamEvent{usage: chunk.Usage.usage()}
+	if len(chunk.Choices) > 0 {
+		choice := chunk.Choices[0]
+		ev.delta = choice.Delta.Content
+		ev.finishReason, ev.rawFinish = normalizeFinish(openAIFinishReasons, choice.FinishReason), choice.FinishReason
+		ev.safety = outputFiltered(ev.finishReason, choice.FinishReason, nil)
+		// Refusals stream in pieces before a plain "stop"
+		if choice.Delta.Refusal != "" {
+			ev.safety = outputFiltered(FinishContentFilter, "refusal", nil)
+		}
+	}
+	return ev, false, nil
+}
+
+// refusalReason is the safety reason for an OpenAI answer: a refusal, or the
+// finish reason
+func refusalReason(refusal, finishReason string) string {
+	if refusal != "" {
+		return "refusal"
+	}
+	return finishReason
+}
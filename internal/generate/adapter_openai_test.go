@@ -0,0 +1,216 @@
+package generate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAIEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   string
+		apiKey  string
+		req     Request
+		stream  bool
+		fixture string
+		headers map[string]string
+	}{
+		{
+			name:    "system prompt, image, tool call and tool result",
+			model:   "gpt-4o",
+			apiKey:  "sk-test",
+			req:     toolConversation(),
+			fixture: "openai/request_tools.json",
+			headers: map[string]string{"Authorization": "Bearer sk-test"},
+		},
+		{
+			name:    "stream asks for usage",
+			model:   "gpt-4o-mini",
+			apiKey:  "sk-test",
+			req:     Request{Messages: []Message{{Role: "user", Content: "Write a haiku about routers."}}},
+			stream:  true,
+			fixture: "openai/request_stream.json",
+			headers: map[string]string{"Authorization": "Bearer sk-test"},
+		},
+		{
+			name:  "response schema without an api key",
+			model: "gpt-4o",
+			req: Request{
+				Messages:       []Message{{Role: "user", Content: "Extract the city from: I live in Lyon."}},
+				ResponseSchema: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+				structuredMode: StructuredSchema,
+			},
+			fixture: "openai/request_schema.json",
+			headers: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wire, err := openAIAdapter{}.encode(tt.model, tt.apiKey, tt.req, tt.stream)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if wire.path != "/chat/completions" {
+				t.Errorf("path = %q, want /chat/completions", wire.path)
+			}
+			if !reflect.DeepEqual(wire.headers, tt.headers) {
+				t.Errorf("headers = %v, want %v", wire.headers, tt.headers)
+			}
+			assertWireBody(t, wire.body, tt.fixture)
+		})
+	}
+}
+
+func TestOpenAIDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    completion
+		wantErr bool
+	}{
+		{
+			name:    "text with cached and reasoning tokens",
+			fixture: "openai/response_text.json",
+			want: completion{
+				content:      "Packets find their way.",
+				finishReason: FinishStop,
+				rawFinish:    "stop",
+				usage:        &tokenUsage{inputTokens: 1117, outputTokens: 46, cachedInputTokens: 1024, reasoningTokens: 32},
+			},
+		},
+		{
+			name:    "tool calls",
+			fixture: "openai/response_tool_calls.json",
+			want: completion{
+				finishReason: FinishTools,
+				rawFinish:    "tool_calls",
+				toolCalls:    []ToolCall{{ID: "call_abc123", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)}},
+				usage:        &tokenUsage{inputTokens: 82, outputTokens: 17},
+			},
+		},
+		{
+			name:    "length",
+			fixture: "openai/response_length.json",
+			want: completion{
+				content:      "Routers forward pack",
+				finishReason: FinishLength,
+				rawFinish:    "length",
+				usage:        &tokenUsage{inputTokens: 12, outputTokens: 5},
+			},
+		},
+		{
+			name:    "refusal finishing with stop is filtered",
+			fixture: "openai/response_refusal.json",
+			want: completion{
+				finishReason: FinishContentFilter,
+				rawFinish:    "stop",
+				usage:        &tokenUsage{inputTokens: 40, outputTokens: 10},
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Reason: "refusal"},
+			},
+		},
+		{
+			name:    "content filter",
+			fixture: "openai/response_content_filter.json",
+			want: completion{
+				finishReason: FinishContentFilter,
+				rawFinish:    "content_filter",
+				usage:        &tokenUsage{inputTokens: 30},
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Reason: "content_filter"},
+			},
+		},
+		{
+			name:    "unknown finish reason",
+			fixture: "openai/response_unknown_finish.json",
+			want: completion{
+				content:      "Done.",
+				finishReason: FinishOther,
+				rawFinish:    "eos",
+				usage:        &tokenUsage{inputTokens: 9, outputTokens: 2},
+			},
+		},
+		{
+			name:    "no choices",
+			fixture: "openai/response_no_choices.json",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openAIAdapter{}.decode(readFixture(t, tt.fixture))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decode succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			assertCompletion(t, got, tt.want)
+		})
+	}
+}
+
+func TestOpenAIDecodeEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     streamEvent
+		wantDone bool
+	}{
+		{
+			name: "role chunk",
+			data: `{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1694268190,"model":"gpt-4o-mini","choices":[{"index":0,"delta":{"role":"assistant","content":""},"logprobs":null,"finish_reason":null}],"usage":null}`,
+		},
+		{
+			name: "content delta",
+			data: `{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1694268190,"model":"gpt-4o-mini","choices":[{"index":0,"delta":{"content":"Packets"},"logprobs":null,"finish_reason":null}],"usage":null}`,
+			want: streamEvent{delta: "Packets"},
+		},
+		{
+			name: "finish",
+			data: `{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1694268190,"model":"gpt-4o-mini","choices":[{"index":0,"delta":{},"logprobs":null,"finish_reason":"length"}],"usage":null}`,
+			want: streamEvent{finishReason: FinishLength, rawFinish: "length"},
+		},
+		{
+			name: "content filter",
+			data: `{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1694268190,"model":"gpt-4o-mini","choices":[{"index":0,"delta":{},"finish_reason":"content_filter"}]}`,
+			want: streamEvent{
+				finishReason: FinishContentFilter,
+				rawFinish:    "content_filter",
+				safety:       &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Reason: "content_filter"},
+			},
+		},
+		{
+			name: "refusal delta",
+			data: `{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1694268190,"model":"gpt-4o-mini","choices":[{"index":0,"delta":{"refusal":"I'm sorry"},"finish_reason":null}]}`,
+			want: streamEvent{safety: &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Reason: "refusal"}},
+		},
+		{
+			name: "usage chunk",
+			data: `{"id":"chatcmpl-123","object":"chat.completion.chunk","created":1694268190,"model":"gpt-4o-mini","choices":[],"usage":{"prompt_tokens":1117,"completion_tokens":46,"total_tokens":1163,"prompt_tokens_details":{"cached_tokens":1024},"completion_tokens_details":{"reasoning_tokens":32}}}`,
+			want: streamEvent{usage: &tokenUsage{inputTokens: 1117, outputTokens: 46, cachedInputTokens: 1024, reasoningTokens: 32}},
+		},
+		{
+			name:     "done",
+			data:     `[DONE]`,
+			wantDone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, done, err := openAIAdapter{}.decodeEvent("", []byte(tt.data))
+			if err != nil {
+				t.Fatalf("decodeEvent: %v", err)
+			}
+			if done != tt.wantDone {
+				t.Errorf("done = %v, want %v", done, tt.wantDone)
+			}
+			assertEvent(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package generate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// pngDataURL is a one-pixel PNG, the shape callers send inline images in
+const pngDataURL = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+
+// toolConversation is a turn of each role: a system prompt, a user turn
+// with an image, an assistant tool call and the tool's result
+func toolConversation() Request {
+	temperature := 0.2
+	return Request{
+		Messages: []Message{
+			{Role: "system", Content: "You are a terse assistant."},
+			{Role: "user", Content: "What is in this picture, and what is the weather in Paris?", Parts: []ContentPart{
+				{Type: "image", ImageURL: pngDataURL},
+			}},
+			{Role: "assistant", ToolCalls: []ToolCall{
+				{ID: "call_weather_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)},
+			}},
+			{Role: "tool", ToolCallID: "call_weather_1", ToolName: "get_weather", Content: `{"temp_c":18,"sky":"overcast"}`},
+		},
+		MaxTokens:   256,
+		Temperature: &temperature,
+		Tools: []Tool{{
+			Name:        "get_weather",
+			Description: "Current weather for a city",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+		}},
+	}
+}
+
+// readFixture reads a recorded wire payload from testdata
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return data
+}
+
+// assertWireBody compares an encoded request body with a recorded one,
+// ignoring key order and whitespace
+func assertWireBody(t *testing.T, body interface{}, fixture string) {
+	t.Helper()
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to marshal body: %v", err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatalf("failed to parse body: %v", err)
+	}
+	if err := json.Unmarshal(readFixture(t, fixture), &want); err != nil {
+		t.Fatalf("failed to parse fixture %s: %v", fixture, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		pretty, _ := json.MarshalIndent(got, "", "  ")
+		t.Errorf("body does not match %s; got:\n%s", fixture, pretty)
+	}
+}
+
+// assertCompletion compares decoded responses field by field, so a
+// failure names what differs
+func assertCompletion(t *testing.T, got, want completion) {
+	t.Helper()
+	if got.content != want.content {
+		t.Errorf("content = %q, want %q", got.content, want.content)
+	}
+	if got.finishReason != want.finishReason || got.rawFinish != want.rawFinish {
+		t.Errorf("finish = %q (%q), want %q (%q)", got.finishReason, got.rawFinish, want.finishReason, want.rawFinish)
+	}
+	if !reflect.DeepEqual(got.toolCalls, want.toolCalls) {
+		t.Errorf("tool calls = %s, want %s", describe(got.toolCalls), describe(want.toolCalls))
+	}
+	if !reflect.DeepEqual(got.usage, want.usage) {
+		t.Errorf("usage = %+v, want %+v", got.usage, want.usage)
+	}
+	if !reflect.DeepEqual(got.safety, want.safety) {
+		t.Errorf("safety = %s, want %s", describe(got.safety), describe(want.safety))
+	}
+}
+
+// assertEvent compares decoded stream events
+func assertEvent(t *testing.T, got, want streamEvent) {
+	t.Helper()
+	if got.delta != want.delta {
+		t.Errorf("delta = %q, want %q", got.delta, want.delta)
+	}
+	if got.finishReason != want.finishReason || got.rawFinish != want.rawFinish {
+		t.Errorf("finish = %q (%q), want %q (%q)", got.finishReason, got.rawFinish, want.finishReason, want.rawFinish)
+	}
+	if !reflect.DeepEqual(got.usage, want.usage) {
+		t.Errorf("usage = %+v, want %+v", got.usage, want.usage)
+	}
+	if !reflect.DeepEqual(got.safety, want.safety) {
+		t.Errorf("safety = %s, want %s", describe(got.safety), describe(want.safety))
+	}
+}
+
+func describe(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,99 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// CostThresholds returns the worst-case cost in USD above which a caller's
+// generation must set confirm_high_cost, by API key; 0 means no threshold
+type CostThresholds interface {
+	MaxRequestCost(ctx context.Context, userID, apiKeyID string) (float64, error)
+}
+
+// CostEstimate is the most a generation can cost: every input token and
+// max_tokens of output, for each model called and each structured attempt
+type CostEstimate struct {
+	Models          []string `json:"models"`
+	InputTokens     int      `json:"input_tokens"`
+	MaxOutputTokens int      `json:"max_output_tokens"`
+	Attempts        int      `json:"attempts"` // Structured output may be regenerated for repair
+	WorstCaseUSD    float64  `json:"worst_case_usd"`
+	CappedByMaxCost bool     `json:"capped_by_max_cost,omitempty"`
+	Unpriced        []string `json:"unpriced,omitempty"` // Models without pricing, not counted
+	ThresholdUSD    float64  `json:"threshold_usd"`
+}
+
+// HighCostError rejects a generation whose worst-case cost is over the
+// caller's threshold and was not confirmed
+type HighCostError struct {
+	Estimate CostEstimate
+}
+
+func (e *HighCostError) Error() string {
+	return fmt.Sprintf("worst-case cost $%.2f exceeds the $%.2f threshold for this key; set confirm_high_cost, lower max_tokens or set max_cost",
+		e.Estimate.WorstCaseUSD, e.Estimate.ThresholdUSD)
+}
+
+// outputCeiling is the most output a call can produce: max_tokens, the
+// provider's default when it has one, or what is left of the context window
+func (c *call) outputCeiling(req Request) int {
+	if maxTokens := c.maxTokens(req); maxTokens > 0 {
+		return maxTokens
+	}
+	if c.provider == "anthropic" {
+		return anthropicDefaultMaxTokens
+	}
+	if window := c.model.TechnicalSpecs.ContextWindow; window > c.meter.inputTokens {
+		return window - c.meter.inputTokens
+	}
+	return 0
+}
+
+// estimateCost sums the worst case of each prepared call
+func estimateCost(calls []*call, req Request) CostEstimate {
+	estimate := CostEstimate{Attempts: 1, ThresholdUSD: req.costThreshold}
+	if req.ResponseSchema != nil {
+		estimate.Attempts = structuredRetries + 1
+	}
+
+	for _, c := range calls {
+		estimate.Models = append(estimate.Models, c.model.ID)
+		if !c.meter.priced {
+			estimate.Unpriced = append(estimate.Unpriced, c.model.ID)
+			continue
+		}
+		output := c.outputCeiling(req)
+		estimate.InputTokens += c.meter.inputTokens
+		estimate.MaxOutputTokens += output
+
+		cost := float64(estimate.Attempts) * (float64(c.meter.inputTokens)/1000*c.meter.costInPer1K + float64(output)/1000*c.meter.costOutPer1K)
+		// max_cost caps each call's spend, so it also caps the worst case
+		if req.MaxCost != nil && *req.MaxCost < cost {
+			cost = *req.MaxCost
+			estimate.CappedByMaxCost = true
+		}
+		estimate.WorstCaseUSD += cost
+	}
+	estimate.WorstCaseUSD = math.Round(estimate.WorstCaseUSD*1e6) / 1e6
+	return estimate
+}
+
+// checkCost refuses prepared calls whose worst case is over the caller's
+// threshold unless the request confirms it
+func checkCost(calls []*call, req Request) error {
+	if req.costThreshold <= 0 {
+		return nil
+	}
+	estimate := estimateCost(calls, req)
+	if estimate.WorstCaseUSD <= req.costThreshold {
+		return nil
+	}
+	if !req.ConfirmHighCost {
+		return &HighCostError{Estimate: estimate}
+	}
+	log.Printf("[GENERATE] %s confirmed a request costing up to $%.2f (threshold $%.2f)", req.UserID, estimate.WorstCaseUSD, req.costThreshold)
+	return nil
+}
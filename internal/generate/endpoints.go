@@ -0,0 +1,220 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+const (
+	// endpointTripAfter is how many consecutive failures take an endpoint out of rotation
+	endpointTripAfter = 2
+	endpointCooldown  = 30 * time.Second
+	// maxEndpointCooldown caps the cooldown, which doubles with each further failure
+	maxEndpointCooldown = 5 * time.Minute
+)
+
+// endpoint is one place a model can be called
+type endpoint struct {
+	name     string
+	region   string
+	url      string
+	priority int
+}
+
+// endpointsFor lists where the call's model can be served, in priority order.
+// Models without regional endpoints have one: their tenant endpoint or the
+// provider's default API.
+func endpointsFor(c *call, baseURL string) []endpoint {
+	if len(c.model.Endpoints) == 0 {
+		return []endpoint{{name: "default", url: baseURL}}
+	}
+	endpoints := make([]endpoint, 0, len(c.model.Endpoints))
+	for _, e := range c.model.Endpoints {
+		if e.URL == "" {
+			continue
+		}
+		name := e.Name
+		if name == "" {
+			name = e.Region
+		}
+		endpoints = append(endpoints, endpoint{name: name, region: e.Region, url: e.URL, priority: e.Priority})
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return endpoints[i].priority < endpoints[j].priority
+	})
+	if len(endpoints) == 0 {
+		return []endpoint{{name: "default", url: baseURL}}
+	}
+	return endpoints
+}
+
+// endpointState is the health of one endpoint URL
+type endpointState struct {
+	provider    string
+	name        string
+	region      string
+	consecutive int // Failures since the last success
+	downUntil   time.Time
+	served      int64
+	failed      int64
+	lastError   string
+	lastFailure time.Time
+}
+
+// endpointHealth tracks failures per endpoint so calls skip a failing region
+// until its cooldown passes
+type endpointHealth struct {
+	mu    sync.Mutex
+	byURL map[string]*endpointState
+}
+
+func newEndpointHealth() *endpointHealth {
+	return &endpointHealth{byURL: make(map[string]*endpointState)}
+}
+
+func (h *endpointHealth) stateFor(provider string, e endpoint) *endpointState {
+	s, ok := h.byURL[e.url]
+	if !ok {
+		s = &endpointState{provider: provider, name: e.name, region: e.region}
+		h.byURL[e.url] = s
+	}
+	return s
+}
+
+// order puts endpoints in rotation first, keeping their priority order. Cooling
+// endpoints follow, soonest to recover first, so a call still has somewhere to
+// go when every region is failing.
+func (h *endpointHealth) order(endpoints []endpoint) []endpoint {
+	if len(endpoints) < 2 {
+		return endpoints
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	var healthy, cooling []endpoint
+	for _, e := range endpoints {
+		if s, ok := h.byURL[e.url]; ok && now.Before(s.downUntil) {
+			cooling = append(cooling, e)
+			continue
+		}
+		healthy = append(healthy, e)
+	}
+	sort.SliceStable(cooling, func(i, j int) bool {
+		return h.byURL[cooling[i].url].downUntil.Before(h.byURL[cooling[j].url].downUntil)
+	})
+	return append(healthy, cooling...)
+}
+
+func (h *endpointHealth) succeeded(provider string, e endpoint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.stateFor(provider, e)
+	s.served++
+	s.consecutive = 0
+	s.downUntil = time.Time{}
+}
+
+// failed records a failure and reports whether it took the endpoint out of rotation
+func (h *endpointHealth) failed(provider string, e endpoint, err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.stateFor(provider, e)
+	s.failed++
+	s.consecutive++
+	s.lastError = err.Error()
+	s.lastFailure = time.Now()
+	if s.consecutive < endpointTripAfter {
+		return false
+	}
+	cooldown := endpointCooldown << (s.consecutive - endpointTripAfter)
+	if cooldown > maxEndpointCooldown || cooldown <= 0 {
+		cooldown = maxEndpointCooldown
+	}
+	s.downUntil = s.lastFailure.Add(cooldown)
+	return s.consecutive == endpointTripAfter
+}
+
+// EndpointStats is a snapshot of one endpoint's health
+type EndpointStats struct {
+	Provider    string     `json:"provider"`
+	Name        string     `json:"name"`
+	Region      string     `json:"region,omitempty"`
+	URL         string     `json:"url"`
+	Healthy     bool       `json:"healthy"`
+	DownUntil   *time.Time `json:"down_until,omitempty"`
+	Served      int64      `json:"served"`
+	Failed      int64      `json:"failed"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+}
+
+// stats returns every endpoint called so far, sorted by provider and name
+func (h *endpointHealth) stats() []EndpointStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]EndpointStats, 0, len(h.byURL))
+	for url, s := range h.byURL {
+		st := EndpointStats{
+			Provider:  s.provider,
+			Name:      s.name,
+			Region:    s.region,
+			URL:       url,
+			Healthy:   !now.Before(s.downUntil),
+			Served:    s.served,
+			Failed:    s.failed,
+			LastError: s.lastError,
+		}
+		if !st.Healthy {
+			downUntil := s.downUntil
+			st.DownUntil = &downUntil
+		}
+		if !s.lastFailure.IsZero() {
+			lastFailure := s.lastFailure
+			st.LastFailure = &lastFailure
+		}
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Provider != stats[j].Provider {
+			return stats[i].Provider < stats[j].Provider
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// HealthCheck reports the endpoints called so far. Endpoints out of rotation
+// degrade the replica while generation fails over to the others.
+func (g *Generator) HealthCheck() health.Check {
+	return health.Check{
+		Name: "providers",
+		Run: func(ctx context.Context) health.Result {
+			stats := g.EndpointStats()
+			if len(stats) == 0 {
+				return health.OK("no endpoints called yet")
+			}
+			var down []string
+			for _, s := range stats {
+				if !s.Healthy {
+					down = append(down, s.Provider+"/"+s.Name)
+				}
+			}
+			result := health.OK(fmt.Sprintf("%d endpoints healthy", len(stats)))
+			if len(down) > 0 {
+				result = health.Degraded(fmt.Sprintf("%d of %d endpoints out of rotation: %v", len(down), len(stats), down))
+			}
+			result.Details = stats
+			return result
+		},
+	}
+}
@@ -0,0 +1,476 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/providers"
+	"github.com/Askeban/llm-router-go/internal/secrets"
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// Finish reasons
+const (
+	FinishStop    = "stop"
+	FinishLength  = "length"
+	FinishTools   = "tool_calls"
+	FinishMaxCost = "max_cost" // Stopped by the caller's budget; output is partial
+
+	FinishContentFilter = "content_filter" // The provider's safety filter refused or cut short the answer
+	FinishOther         = "other"          // A provider reason without a unified equivalent; see provider_finish_reason
+)
+
+// ErrRejected wraps problems with the request itself (unknown model, missing
+// key, unpayable budget), as opposed to provider failures
+var ErrRejected = errors.New("generation rejected")
+
+// Message is one chat turn in the provider-neutral request shape. Adapters
+// translate it to each provider's wire format.
+type Message struct {
+	Role       string        `json:"role" binding:"required"` // system, user, assistant, tool
+	Content    string        `json:"content,omitempty"`
+	Parts      []ContentPart `json:"parts,omitempty"`        // Multimodal content, sent after Content
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`   // Calls requested by an assistant turn
+	ToolCallID string        `json:"tool_call_id,omitempty"` // The call a tool turn answers
+	ToolName   string        `json:"tool_name,omitempty"`    // The function a tool turn answers (required by Google)
+}
+
+// ContentPart is a piece of multimodal message content
+type ContentPart struct {
+	Type     string `json:"type" binding:"required"` // text, image
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"` // https:// or data:<media type>;base64,<data>
+}
+
+// Tool is a function the model may call, described by a JSON schema
+type Tool struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall is a model's request to call a tool
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// text returns the message's text content, including text parts
+func (m Message) text() string {
+	parts := []string{}
+	if m.Content != "" {
+		parts = append(parts, m.Content)
+	}
+	for _, p := range m.Parts {
+		if p.Type == "text" && p.Text != "" {
+			parts = append(parts, p.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Request is a generation call. Model is optional; without it the router picks one.
+type Request struct {
+	Model       string    `json:"model,omitempty"`
+	Messages    []Message `json:"messages" binding:"required,min=1"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Tools       []Tool    `json:"tools,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+	MaxCost     *float64  `json:"max_cost,omitempty"` // USD cap; streams stop once it is reached
+	Race        bool      `json:"race,omitempty"`     // Call the top two models at once and keep the first answer
+	UserID      string    `json:"-"`
+
+	// UsageTags attribute the call's usage, e.g. {"team": "search"}; merged over X-Usage-Tags
+	UsageTags map[string]string `json:"usage_tags,omitempty"`
+
+	// ConfirmHighCost accepts a worst-case cost over the caller's threshold
+	ConfirmHighCost bool `json:"confirm_high_cost,omitempty"`
+	// costThreshold is the caller's threshold in USD; 0 means none
+	costThreshold float64
+	// plan is the caller's plan, whose monthly token ceiling tenant calls count against
+	plan string
+	// expectedOutput is the predicted answer length max_tokens is planned from
+	expectedOutput outputEstimate
+
+	// Requirements are features the model must support, e.g. {"prompt_caching": true}
+	Requirements map[string]interface{} `json:"requirements,omitempty"`
+
+	// ResponseSchema is a JSON schema the output must satisfy
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
+	// structuredMode is how the chosen provider is asked for ResponseSchema
+	structuredMode string
+}
+
+// Prompt joins the message contents, for routing and input token estimates
+func (r Request) Prompt() string {
+	parts := make([]string, 0, len(r.Messages))
+	for _, m := range r.Messages {
+		parts = append(parts, m.text())
+	}
+	return strings.Join(parts, "\n")
+}
+
+// MapText rewrites the text of every message, e.g. to mask secrets
+func (r *Request) MapText(fn func(string) string) {
+	for i := range r.Messages {
+		m := &r.Messages[i]
+		if m.Content != "" {
+			m.Content = fn(m.Content)
+		}
+		for j := range m.Parts {
+			if m.Parts[j].Type == "text" && m.Parts[j].Text != "" {
+				m.Parts[j].Text = fn(m.Parts[j].Text)
+			}
+		}
+	}
+}
+
+// Images counts the image parts across the messages
+func (r Request) Images() int {
+	images := 0
+	for _, m := range r.Messages {
+		for _, p := range m.Parts {
+			if p.Type == "image" {
+				images++
+			}
+		}
+	}
+	return images
+}
+
+// FeatureRequirements are the caller's requirements plus those the request
+// implies: tools need a model with tool use
+func (r Request) FeatureRequirements() map[string]interface{} {
+	requirements := make(map[string]interface{}, len(r.Requirements)+1)
+	for k, v := range r.Requirements {
+		requirements[k] = v
+	}
+	if len(r.Tools) > 0 {
+		requirements[models.FeatureToolUse] = true
+	}
+	return requirements
+}
+
+// routableProviders restricts routing when the request needs it: structured
+// output goes to providers that enforce the schema natively
+func (r Request) routableProviders() []string {
+	if r.ResponseSchema != nil {
+		return StructuredProviders()
+	}
+	return nil
+}
+
+// Response is a completed (or budget-truncated) generation
+type Response struct {
+	Model        string     `json:"model"`
+	Provider     string     `json:"provider"`
+	Content      string     `json:"content"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	FinishReason string     `json:"finish_reason"`
+	Partial      bool       `json:"partial,omitempty"`
+	Usage        Usage      `json:"usage"`
+	QueueTimeMs  float64    `json:"queue_time_ms"`      // Time spent waiting for a provider worker
+	Endpoint     string     `json:"endpoint,omitempty"` // The regional endpoint that served the call
+	Region       string     `json:"region,omitempty"`
+	Template     string     `json:"prompt_template,omitempty"` // "catalog" or "tenant" when a prompt template was applied
+
+	// OutputLimit is the max_tokens the model was called with and why
+	OutputLimit *OutputLimit `json:"output_limit,omitempty"`
+
+	// ProviderFinishReason is the provider's own stop reason, e.g. end_turn or SAFETY
+	ProviderFinishReason string `json:"provider_finish_reason,omitempty"`
+	// Safety reports the provider's safety filter refusing or cutting short the answer
+	Safety *SafetyFlags `json:"safety,omitempty"`
+
+	// Structured is the validated output when a response_schema was given
+	Structured     json.RawMessage `json:"structured,omitempty"`
+	StructuredMode string          `json:"structured_mode,omitempty"`
+	SchemaAttempts int             `json:"schema_attempts,omitempty"` // Calls made until the output validated
+
+	// Race reports each model called in race mode; Usage then covers them all
+	Race *RaceOutcome `json:"race,omitempty"`
+
+	// SecretGuard reports secrets found in the prompt and what was done about them
+	SecretGuard *secrets.Screening `json:"secret_guard,omitempty"`
+}
+
+// Chunk is one streamed piece of output with the running totals
+type Chunk struct {
+	Delta        string  `json:"delta"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// ModelResolver finds the model to call: the requested one (including the
+// caller's fine-tuned models) or the router's top pick for the prompt among
+// providers, when given. A model given images must be able to read them, and
+// must support the features in requirements.
+type ModelResolver interface {
+	ResolveModel(ctx context.Context, userID, modelID, prompt string, images int, providers []string, requirements map[string]interface{}) (models.EnhancedModel, error)
+	// ResolveModels returns up to n of the router's top picks, best first
+	ResolveModels(ctx context.Context, userID, prompt string, images int, providers []string, requirements map[string]interface{}, n int) ([]models.EnhancedModel, error)
+}
+
+// ActivityObserver is told each time a model answers a call, e.g. to track
+// which self-hosted models are loaded
+type ActivityObserver interface {
+	ModelCalled(provider, modelID string)
+}
+
+// Generator calls providers on behalf of tenants
+type Generator struct {
+	registry  *providers.Registry
+	resolver  ModelResolver
+	queues    *Queues
+	endpoints *endpointHealth
+	races     *raceStats
+	activity  ActivityObserver
+	templates PromptTemplates
+	estimator OutputEstimator
+
+	tokenQuota TokenQuota
+}
+
+func NewGenerator(registry *providers.Registry, resolver ModelResolver) *Generator {
+	return &Generator{registry: registry, resolver: resolver, endpoints: newEndpointHealth(), races: newRaceStats()}
+}
+
+// SetQueues bounds concurrent provider calls with per-provider worker pools
+func (g *Generator) SetQueues(queues *Queues) {
+	g.queues = queues
+}
+
+// SetActivityObserver reports every model that answers a call
+func (g *Generator) SetActivityObserver(observer ActivityObserver) {
+	g.activity = observer
+}
+
+// QueueStats returns the worker pool metrics, or nil without queues
+func (g *Generator) QueueStats() []QueueStats {
+	if g.queues == nil {
+		return nil
+	}
+	return g.queues.Stats()
+}
+
+// EndpointStats returns the health of every endpoint called so far
+func (g *Generator) EndpointStats() []EndpointStats {
+	return g.endpoints.stats()
+}
+
+// admit waits for a worker for the call's provider, returning the release func
+// and the time spent queued
+func (g *Generator) admit(ctx context.Context, c *call) (func(), float64, error) {
+	if g.queues == nil {
+		return func() {}, 0, nil
+	}
+	release, queued, err := g.queues.acquire(ctx, c.provider)
+	if err != nil {
+		return nil, 0, err
+	}
+	return release, float64(queued.Microseconds()) / 1000, nil
+}
+
+// call is a prepared provider request
+type call struct {
+	model    models.EnhancedModel
+	provider string
+	apiKey   string
+	meter    *Meter
+	endpoint endpoint // Set once a provider endpoint accepts the call
+	output   *OutputLimit
+
+	template       *models.PromptTemplate // Applied to the request as it is sent
+	templateSource string
+}
+
+func (g *Generator) prepare(ctx context.Context, req Request) (*call, error) {
+	model, err := g.resolver.ResolveModel(ctx, req.UserID, req.Model, req.Prompt(), req.Images(), req.routableProviders(), req.FeatureRequirements())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	req.expectedOutput = g.estimateOutput(ctx, req)
+	c, err := g.prepareModel(ctx, req, model)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCost([]*call{c}, req); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// prepareModel checks the resolved model can serve the request and finds the key to call it with
+func (g *Generator) prepareModel(ctx context.Context, req Request, model models.EnhancedModel) (*call, error) {
+	if model.ModelType != "" && model.ModelType != "text" && model.ModelType != "multimodal" {
+		return nil, fmt.Errorf("%w: model %s is not a text model", ErrRejected, model.ID)
+	}
+
+	provider := providers.NormalizeProvider(model.Provider)
+	if !providers.IsSupported(provider) {
+		return nil, fmt.Errorf("%w: provider %s is not supported for generation", ErrRejected, model.Provider)
+	}
+	key, err := g.registry.ResolveAPIKey(ctx, req.UserID, provider)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	template, source := g.resolveTemplate(ctx, req.UserID, model)
+	prompt := applyTemplate(template, req, model.ID).Prompt()
+	c := &call{model: model, provider: provider, apiKey: key.APIKey, meter: newMeter(model, prompt), template: template, templateSource: source}
+	c.output = planOutput(model, c.meter.inputTokens, req)
+	if c.output.Warning != "" {
+		trace.Logf(ctx, "[GENERATE] %s: %s", model.ID, c.output.Warning)
+	}
+	if req.MaxCost != nil {
+		if _, err := c.meter.OutputBudget(*req.MaxCost); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+	}
+	return c, nil
+}
+
+// Generate runs a non-streamed generation. max_cost is enforced by capping
+// max_tokens to what the budget can pay for.
+func (g *Generator) Generate(ctx context.Context, req Request) (Response, error) {
+	c, err := g.prepare(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+	settle, err := g.reserveTokens(ctx, []*call{c}, req)
+	if err != nil {
+		return Response{}, err
+	}
+	resp, err := g.run(ctx, c, req)
+	settle(usedTokens(resp, err))
+	return resp, err
+}
+
+// run makes a prepared non-streamed call
+func (g *Generator) run(ctx context.Context, c *call, req Request) (Response, error) {
+	if req.MaxCost != nil {
+		budget, err := c.meter.OutputBudget(*req.MaxCost)
+		if err != nil {
+			return Response{}, fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+		if req.MaxTokens == 0 || budget < req.MaxTokens {
+			req.MaxTokens = budget
+		}
+	}
+
+	release, queueTime, err := g.admit(ctx, c)
+	if err != nil {
+		return Response{}, err
+	}
+	defer release()
+
+	if req.ResponseSchema != nil {
+		return g.generateStructured(ctx, c, req, queueTime)
+	}
+
+	out, err := g.complete(ctx, c, req)
+	if err != nil {
+		return Response{}, err
+	}
+	c.meter.AddOutput(out.content)
+	if out.usage != nil {
+		c.meter.setUsage(*out.usage)
+	}
+
+	return Response{
+		Model:        c.model.ID,
+		Provider:     c.provider,
+		Content:      out.content,
+		ToolCalls:    out.toolCalls,
+		FinishReason: out.finishReason,
+		Usage:        c.meter.Usage(),
+		QueueTimeMs:  queueTime,
+		Endpoint:     c.endpoint.name,
+		Region:       c.endpoint.region,
+		Template:     c.templateSource,
+		OutputLimit:  c.output,
+
+		ProviderFinishReason: out.rawFinish,
+		Safety:               out.safety,
+	}, nil
+}
+
+// Stream runs a streamed generation, passing each piece of output to emit with
+// the running cost. Once the cost reaches max_cost the upstream stream is
+// closed and the response is marked partial with finish reason max_cost.
+// If the stream fails midway, the output so far is returned with the error.
+// The stream's worst-case tokens are reserved until it ends.
+func (g *Generator) Stream(ctx context.Context, req Request, emit func(Chunk) error) (Response, error) {
+	c, err := g.prepare(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+	settle, err := g.reserveTokens(ctx, []*call{c}, req)
+	if err != nil {
+		return Response{}, err
+	}
+	defer func() {
+		usage := c.meter.Usage()
+		settle(usage.InputTokens + usage.OutputTokens)
+	}()
+
+	release, queueTime, err := g.admit(ctx, c)
+	if err != nil {
+		return Response{}, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resp := Response{Model: c.model.ID, Provider: c.provider, FinishReason: FinishStop, QueueTimeMs: queueTime, Template: c.templateSource, OutputLimit: c.output}
+	var content strings.Builder
+
+	err = g.stream(ctx, c, req, func(ev streamEvent) (bool, error) {
+		if ev.finishReason != "" {
+			resp.FinishReason, resp.ProviderFinishReason = ev.finishReason, ev.rawFinish
+		}
+		if ev.safety != nil {
+			resp.Safety = ev.safety
+		}
+		// A safety block overrides the plain stop a provider may report with it
+		if resp.Safety != nil && resp.FinishReason == FinishStop {
+			resp.FinishReason = FinishContentFilter
+		}
+		if ev.delta != "" {
+			content.WriteString(ev.delta)
+			c.meter.AddOutput(ev.delta)
+		}
+		// Provider counts, when present, supersede the estimate
+		if ev.usage != nil {
+			c.meter.setUsage(*ev.usage)
+		}
+		if ev.delta == "" {
+			return true, nil
+		}
+
+		if err := emit(Chunk{Delta: ev.delta, OutputTokens: c.meter.outputTokens, CostUSD: c.meter.Usage().CostUSD}); err != nil {
+			return false, err
+		}
+
+		if req.MaxCost != nil && c.meter.Cost() >= *req.MaxCost {
+			trace.Logf(ctx, "[GENERATE] %s reached max_cost $%.6f after %d output tokens", c.model.ID, *req.MaxCost, c.meter.outputTokens)
+			resp.FinishReason = FinishMaxCost
+			resp.Partial = true
+			return false, nil
+		}
+		return true, nil
+	})
+
+	// Usage is returned even on error so interrupted streams are still billed accurately
+	resp.Content = content.String()
+	resp.Usage = c.meter.Usage()
+	resp.Endpoint, resp.Region = c.endpoint.name, c.endpoint.region
+	return resp, err
+}
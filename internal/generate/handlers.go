@@ -0,0 +1,431 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/flags"
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/secrets"
+	"github.com/Askeban/llm-router-go/internal/trace"
+	"github.com/Askeban/llm-router-go/internal/usage"
+)
+
+// streamWriteWindow extends the server's write deadline for each streamed chunk,
+// so long generations are not cut off by the 30s WriteTimeout
+const streamWriteWindow = 30 * time.Second
+
+// SecretGuard screens prompts for API keys, private keys and tokens before
+// they are forwarded to a provider
+type SecretGuard interface {
+	Screen(ctx context.Context, userID, prompt string) secrets.Screening
+	Mask(text string) string
+}
+
+// Categorizer classifies a prompt into a task category, for usage records
+type Categorizer interface {
+	PromptCategory(ctx context.Context, userID, prompt string) string
+}
+
+// QualitySampler judges a sample of answers asynchronously; it must not block
+type QualitySampler interface {
+	Observe(userID, category, prompt string, resp Response)
+}
+
+// FeatureGate reports whether a caller's plan, organization or user has a
+// feature flag
+type FeatureGate interface {
+	Enabled(ctx context.Context, userID, plan, flag string) bool
+}
+
+// Handlers exposes generation over HTTP
+type Handlers struct {
+	generator   *Generator
+	features    FeatureGate
+	secretGuard SecretGuard
+	categorizer Categorizer
+	quality     QualitySampler
+	thresholds  CostThresholds
+}
+
+func NewHandlers(generator *Generator) *Handlers {
+	return &Handlers{generator: generator}
+}
+
+// SetFeatures decides who may use race mode, which bills two calls per
+// request; without it only the flag's default plans may
+func (h *Handlers) SetFeatures(features FeatureGate) {
+	h.features = features
+}
+
+// featureEnabled reports whether the caller has a feature flag
+func (h *Handlers) featureEnabled(c *gin.Context, flag string) bool {
+	plan := c.GetString("user_plan")
+	if h.features == nil {
+		return flags.PlanDefault(flag, plan)
+	}
+	return h.features.Enabled(c.Request.Context(), c.GetString("user_id"), plan, flag)
+}
+
+// SetSecretGuard applies tenants' secret policies to prompts before any provider sees them
+func (h *Handlers) SetSecretGuard(guard SecretGuard) {
+	h.secretGuard = guard
+}
+
+// SetCategorizer records each generation's task category with its usage, so
+// output lengths can be learned per category
+func (h *Handlers) SetCategorizer(categorizer Categorizer) {
+	h.categorizer = categorizer
+}
+
+// SetQualitySampler scores a sample of complete answers to learn each
+// model's quality per category. Sampling needs the categorizer.
+func (h *Handlers) SetQualitySampler(sampler QualitySampler) {
+	h.quality = sampler
+}
+
+// SetCostThresholds requires confirm_high_cost on generations whose worst-case
+// cost is over the caller's threshold
+func (h *Handlers) SetCostThresholds(thresholds CostThresholds) {
+	h.thresholds = thresholds
+}
+
+// Generate runs a generation, streaming server-sent events when stream is true
+func (h *Handlers) Generate(c *gin.Context) {
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.MaxCost != nil && *req.MaxCost <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": "max_cost must be positive",
+		})
+		return
+	}
+	if req.ResponseSchema != nil {
+		err := ValidateResponseSchema(req.ResponseSchema)
+		if err == nil && req.Stream {
+			err = errors.New("response_schema cannot be combined with stream")
+		}
+		if err == nil && len(req.Tools) > 0 {
+			err = errors.New("response_schema cannot be combined with tools")
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	if err := models.ValidateFeatureRequirements(req.Requirements); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := usage.AddTags(c, req.UsageTags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid usage tags",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.Race {
+		var err error
+		switch {
+		case req.Stream:
+			err = errors.New("race cannot be combined with stream")
+		case req.Model != "":
+			err = errors.New("race calls the router's top two models, so model must be empty")
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+		if !h.featureEnabled(c, flags.RaceMode) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Race mode is not available on your plan",
+				"details": "race calls two models per request and bills both; plan " + c.GetString("user_plan") + " does not include it",
+				"feature": flags.RaceMode,
+			})
+			return
+		}
+	}
+	req.UserID = c.GetString("user_id")
+	req.plan = c.GetString("user_plan")
+	if h.thresholds != nil {
+		threshold, err := h.thresholds.MaxRequestCost(c.Request.Context(), req.UserID, c.GetString("api_key_id"))
+		if err != nil {
+			trace.Logf(c.Request.Context(), "[GENERATE] Failed to load cost threshold for %s, using default: %v", req.UserID, err)
+		}
+		req.costThreshold = threshold
+	}
+
+	var screening *secrets.Screening
+	if h.secretGuard != nil {
+		if s := h.secretGuard.Screen(c.Request.Context(), req.UserID, req.Prompt()); s.Found() {
+			if s.Blocked() {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"error":        "Prompt contains secrets",
+					"code":         "secret_detected",
+					"details":      "remove API keys, private keys and tokens from the prompt, or change your secret policy",
+					"secret_guard": s,
+				})
+				return
+			}
+			if s.Masked() {
+				req.MapText(h.secretGuard.Mask)
+			}
+			screening = &s
+		}
+	}
+
+	if req.Stream {
+		h.stream(c, req, screening)
+		return
+	}
+
+	generate := h.generator.Generate
+	if req.Race {
+		generate = h.generator.Race
+	}
+	resp, err := generate(c.Request.Context(), req)
+	if err != nil {
+		generationFailed(c, err)
+		return
+	}
+	resp.SecretGuard = screening
+	category := h.recordCategory(c, req)
+	recordUsage(c, resp)
+	h.sampleQuality(req, category, resp)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+func (h *Handlers) stream(c *gin.Context, req Request, screening *secrets.Screening) {
+	rc := http.NewResponseController(c.Writer)
+	started := false
+
+	send := func(v interface{}) error {
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			started = true
+		}
+		rc.SetWriteDeadline(time.Now().Add(streamWriteWindow))
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	resp, err := h.generator.Stream(c.Request.Context(), req, func(chunk Chunk) error {
+		return send(chunk)
+	})
+	if err != nil && !started {
+		generationFailed(c, err)
+		return
+	}
+	resp.SecretGuard = screening
+	category := h.recordCategory(c, req)
+	recordUsage(c, resp)
+	if err == nil {
+		h.sampleQuality(req, category, resp)
+	}
+
+	// The final event carries the usage record and marks budget-truncated output
+	final := gin.H{
+		"done":          true,
+		"model":         resp.Model,
+		"provider":      resp.Provider,
+		"finish_reason": resp.FinishReason,
+		"partial":       resp.Partial,
+		"usage":         resp.Usage,
+		"queue_time_ms": resp.QueueTimeMs,
+	}
+	if resp.OutputLimit != nil {
+		final["output_limit"] = resp.OutputLimit
+	}
+	if resp.ProviderFinishReason != "" {
+		final["provider_finish_reason"] = resp.ProviderFinishReason
+	}
+	if resp.Safety != nil {
+		final["safety"] = resp.Safety
+	}
+	if screening != nil {
+		final["secret_guard"] = screening
+	}
+	if err != nil {
+		trace.Logf(c.Request.Context(), "[GENERATE] Stream from %s interrupted: %v", resp.Model, err)
+		final["error"] = err.Error()
+		final["partial"] = true
+	}
+	if send(final) == nil {
+		fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+		c.Writer.Flush()
+	}
+}
+
+// Queues returns the per-provider worker pool metrics
+func (h *Handlers) Queues(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.generator.QueueStats(),
+	})
+}
+
+// Races returns per-model race mode outcomes and latencies
+func (h *Handlers) Races(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.generator.RaceStats(),
+	})
+}
+
+// Endpoints returns the health of each provider endpoint generation has called
+func (h *Handlers) Endpoints(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.generator.EndpointStats(),
+	})
+}
+
+func generationFailed(c *gin.Context, err error) {
+	// Invalid structured output was still generated, and is billed
+	var invalid *SchemaError
+	if errors.As(err, &invalid) {
+		c.Set(usage.ContextModel, invalid.Model)
+		c.Set(usage.ContextTokens, invalid.Usage.InputTokens+invalid.Usage.OutputTokens)
+		c.Set(usage.ContextCost, invalid.Usage.CostUSD)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Output did not match response_schema",
+			"details": invalid,
+		})
+		return
+	}
+
+	var highCost *HighCostError
+	if errors.As(err, &highCost) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Request may cost more than your threshold",
+			"code":     "high_cost_unconfirmed",
+			"details":  err.Error(),
+			"estimate": highCost.Estimate,
+		})
+		return
+	}
+
+	var quota *limits.TokenQuotaError
+	if errors.As(err, &quota) {
+		// Only the month's reset helps once recorded usage alone is too high;
+		// otherwise the caller's running generations will settle
+		hint := limits.RetryHint{RetryAfter: time.Until(quota.ResetAt), Window: limits.WindowTokens}
+		if !quota.Exhausted() {
+			hint.RetryAfter, hint.Backoff = 5*time.Second, limits.BackoffExponential
+		}
+		limits.TooManyRequests(c, hint, gin.H{
+			"error":       "Monthly token limit reached",
+			"code":        "token_quota_exceeded",
+			"details":     err.Error() + "; lower max_tokens or wait for the limit to reset",
+			"token_quota": quota,
+		})
+		return
+	}
+
+	var full *QueueFullError
+	if errors.As(err, &full) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(full.RetryAfter.Seconds()))))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":       "Provider is at capacity",
+			"details":     err.Error(),
+			"retry_after": int(math.Ceil(full.RetryAfter.Seconds())),
+		})
+		return
+	}
+
+	status := http.StatusBadGateway
+	if errors.Is(err, ErrRejected) {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{
+		"error":   "Generation failed",
+		"details": err.Error(),
+	})
+}
+
+// recordCategory attributes the generation's usage to the prompt's category,
+// returning the category or "" when unknown
+func (h *Handlers) recordCategory(c *gin.Context, req Request) string {
+	if h.categorizer == nil {
+		return ""
+	}
+	category := h.categorizer.PromptCategory(c.Request.Context(), req.UserID, req.Prompt())
+	if category != "" {
+		c.Set(usage.ContextCategory, category)
+	}
+	return category
+}
+
+// sampleQuality offers the answer for quality judging
+func (h *Handlers) sampleQuality(req Request, category string, resp Response) {
+	if h.quality == nil || category == "" {
+		return
+	}
+	h.quality.Observe(req.UserID, category, req.Prompt(), resp)
+}
+
+func recordUsage(c *gin.Context, resp Response) {
+	c.Set(usage.ContextModel, resp.Model)
+	c.Set(usage.ContextTokens, resp.Usage.InputTokens+resp.Usage.OutputTokens)
+	c.Set(usage.ContextCost, resp.Usage.CostUSD)
+	metadata := map[string]interface{}{}
+	// Complete answers teach the router how long generations run per category
+	if resp.FinishReason == FinishStop && !resp.Partial {
+		metadata[usage.MetadataOutputTokens] = resp.Usage.OutputTokens
+	}
+	if resp.Endpoint != "" {
+		metadata["endpoint"], metadata["region"] = resp.Endpoint, resp.Region
+	}
+	if f := resp.Usage.Footprint; f != nil {
+		metadata["energy_wh"], metadata["co2e_grams"] = f.EnergyWh, f.CO2eGrams
+	}
+	if resp.Race != nil {
+		metadata["race"] = resp.Race.Entrants
+	}
+	if resp.SecretGuard != nil {
+		metadata["secret_guard"] = resp.SecretGuard.Action
+	}
+	if len(metadata) > 0 {
+		c.Set(usage.ContextMetadata, metadata)
+	}
+}
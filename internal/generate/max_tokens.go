@@ -0,0 +1,120 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Where a call's max_tokens came from, reported as output_limit.source
+const (
+	MaxTokensCaller  = "caller"  // The caller's max_tokens, within the model's limit
+	MaxTokensClamped = "clamped" // The caller's max_tokens, lowered to the model's limit
+	MaxTokensAuto    = "auto"    // Derived from the expected answer length
+)
+
+const (
+	// autoMaxTokensHeadroom multiplies the expected answer length, so answers
+	// that run long are rarely cut off
+	autoMaxTokensHeadroom = 3
+	// minAutoMaxTokens keeps short estimates from truncating ordinary answers
+	minAutoMaxTokens = 1024
+)
+
+// OutputEstimator predicts how many tokens the answer to a prompt runs and
+// where the estimate came from (explicit, history or heuristic)
+type OutputEstimator interface {
+	EstimateOutputTokens(ctx context.Context, userID, prompt string) (int, string)
+}
+
+// SetOutputEstimator derives max_tokens from the expected answer length when
+// callers leave it unset
+func (g *Generator) SetOutputEstimator(estimator OutputEstimator) {
+	g.estimator = estimator
+}
+
+// OutputLimit reports the max_tokens a call was sent with and why
+type OutputLimit struct {
+	MaxTokens      int    `json:"max_tokens,omitempty"` // 0 leaves the provider's default
+	Source         string `json:"source,omitempty"`
+	ExpectedTokens int    `json:"expected_tokens,omitempty"`
+	EstimateSource string `json:"estimate_source,omitempty"`
+	ModelLimit     int    `json:"model_limit,omitempty"` // The most output the model can produce for this prompt
+	Warning        string `json:"warning,omitempty"`     // Set when the answer will likely be truncated
+}
+
+// outputEstimate is a request's expected answer length
+type outputEstimate struct {
+	tokens int
+	source string
+}
+
+// estimateOutput predicts the request's answer length, or nothing without an estimator
+func (g *Generator) estimateOutput(ctx context.Context, req Request) outputEstimate {
+	if g.estimator == nil {
+		return outputEstimate{}
+	}
+	tokens, source := g.estimator.EstimateOutputTokens(ctx, req.UserID, req.Prompt())
+	return outputEstimate{tokens: tokens, source: source}
+}
+
+// modelOutputLimit is the most output the model can produce after
+// inputTokens of prompt: its output cap or the rest of its context window,
+// whichever is smaller. 0 means unknown.
+func modelOutputLimit(model models.EnhancedModel, inputTokens int) int {
+	limit := model.TechnicalSpecs.MaxOutputTokens
+	if window := model.TechnicalSpecs.ContextWindow; window > inputTokens {
+		if remaining := window - inputTokens; limit <= 0 || remaining < limit {
+			limit = remaining
+		}
+	}
+	return limit
+}
+
+// planOutput decides the call's max_tokens. A caller's value is kept within
+// the model's limit; without one, max_tokens is set to a multiple of the
+// expected answer length when there is an estimate. Either way a warning is
+// raised when the expected answer does not fit.
+func planOutput(model models.EnhancedModel, inputTokens int, req Request) *OutputLimit {
+	limit := &OutputLimit{
+		ExpectedTokens: req.expectedOutput.tokens,
+		EstimateSource: req.expectedOutput.source,
+		ModelLimit:     modelOutputLimit(model, inputTokens),
+	}
+
+	switch {
+	case req.MaxTokens > 0 && limit.ModelLimit > 0 && req.MaxTokens > limit.ModelLimit:
+		limit.MaxTokens, limit.Source = limit.ModelLimit, MaxTokensClamped
+	case req.MaxTokens > 0:
+		limit.MaxTokens, limit.Source = req.MaxTokens, MaxTokensCaller
+	case limit.ExpectedTokens > 0:
+		limit.MaxTokens, limit.Source = limit.ExpectedTokens*autoMaxTokensHeadroom, MaxTokensAuto
+		if limit.MaxTokens < minAutoMaxTokens {
+			limit.MaxTokens = minAutoMaxTokens
+		}
+		if limit.ModelLimit > 0 && limit.MaxTokens > limit.ModelLimit {
+			limit.MaxTokens = limit.ModelLimit
+		}
+	default:
+		return limit
+	}
+
+	if limit.ExpectedTokens > limit.MaxTokens {
+		limit.Warning = fmt.Sprintf("the answer is expected to run about %d tokens but max_tokens is %d; it will likely be truncated",
+			limit.ExpectedTokens, limit.MaxTokens)
+	}
+	return limit
+}
+
+// maxTokens is the max_tokens to send: the planned value, or the request's
+// when that is lower (max_cost budgets lower it per call)
+func (c *call) maxTokens(req Request) int {
+	if c.output == nil || c.output.MaxTokens == 0 {
+		return req.MaxTokens
+	}
+	if req.MaxTokens > 0 && req.MaxTokens < c.output.MaxTokens {
+		return req.MaxTokens
+	}
+	return c.output.MaxTokens
+}
@@ -0,0 +1,165 @@
+package generate
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Usage sources
+const (
+	UsageEstimated = "estimated" // Counted from streamed text; the provider never reported usage
+	UsageProvider  = "provider"  // Reported by the provider
+)
+
+// Usage is the token and cost record of one generation
+type Usage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	Source       string  `json:"source"`
+
+	// Breakdowns the provider reported: prompt tokens read from its cache, and
+	// reasoning tokens counted in OutputTokens
+	CachedInputTokens int `json:"cached_input_tokens,omitempty"`
+	ReasoningTokens   int `json:"reasoning_tokens,omitempty"`
+
+	// Footprint is the estimated energy and emissions, for models with an
+	// energy profile
+	Footprint *models.Footprint `json:"footprint,omitempty"`
+}
+
+// Meter tracks tokens as they arrive and the running cost of a generation
+type Meter struct {
+	costInPer1K  float64
+	costOutPer1K float64
+	priced       bool
+	energy       *models.EnergyProfile
+
+	inputTokens  int
+	outputTokens int
+	outputRunes  int
+	source       string
+
+	cachedInputTokens int
+	reasoningTokens   int
+}
+
+func newMeter(model models.EnhancedModel, prompt string) *Meter {
+	m := &Meter{
+		inputTokens: estimateTokens(utf8.RuneCountInString(prompt)),
+		source:      UsageEstimated,
+	}
+	if p := model.Pricing.Text; p.CostInPer1K != nil || p.CostOutPer1K != nil {
+		m.priced = true
+		if p.CostInPer1K != nil {
+			m.costInPer1K = *p.CostInPer1K
+		}
+		if p.CostOutPer1K != nil {
+			m.costOutPer1K = *p.CostOutPer1K
+		}
+	}
+	if profile, ok := model.EnergyProfile(); ok {
+		m.energy = &profile
+	}
+	return m
+}
+
+// estimateTokens approximates tokens at four characters each
+func estimateTokens(runes int) int {
+	return (runes + 3) / 4
+}
+
+// AddOutput counts streamed text toward output tokens
+func (m *Meter) AddOutput(text string) {
+	m.outputRunes += utf8.RuneCountInString(text)
+	m.outputTokens = estimateTokens(m.outputRunes)
+	m.source = UsageEstimated
+}
+
+// setUsage replaces the estimates with whichever counts the provider reported
+func (m *Meter) setUsage(u tokenUsage) {
+	if u.inputTokens > 0 {
+		m.inputTokens = u.inputTokens
+	}
+	if u.outputTokens > 0 {
+		m.outputTokens = u.outputTokens
+		m.source = UsageProvider
+	}
+	if u.cachedInputTokens > 0 {
+		m.cachedInputTokens = u.cachedInputTokens
+	}
+	if u.reasoningTokens > 0 {
+		m.reasoningTokens = u.reasoningTokens
+	}
+}
+
+// Cost returns the running cost in USD
+func (m *Meter) Cost() float64 {
+	return float64(m.inputTokens)/1000*m.costInPer1K + float64(m.outputTokens)/1000*m.costOutPer1K
+}
+
+// Usage returns the current record
+func (m *Meter) Usage() Usage {
+	return Usage{
+		InputTokens:  m.inputTokens,
+		OutputTokens: m.outputTokens,
+		CostUSD:      math.Round(m.Cost()*1e6) / 1e6,
+		Source:       m.source,
+		Footprint:    m.footprint(m.outputTokens),
+
+		CachedInputTokens: m.cachedInputTokens,
+		ReasoningTokens:   m.reasoningTokens,
+	}
+}
+
+// footprint estimates the generation's energy and emissions so far
+func (m *Meter) footprint(outputTokens int) *models.Footprint {
+	if m.energy == nil {
+		return nil
+	}
+	f := m.energy.Footprint(m.inputTokens, outputTokens)
+	return &f
+}
+
+// addFootprint sums two footprints; either may be missing for models without
+// an energy profile
+func addFootprint(total, attempt *models.Footprint) *models.Footprint {
+	if attempt == nil {
+		return total
+	}
+	if total == nil {
+		f := *attempt
+		return &f
+	}
+	sum := models.Footprint{
+		EnergyWh:  math.Round((total.EnergyWh+attempt.EnergyWh)*1e6) / 1e6,
+		CO2eGrams: math.Round((total.CO2eGrams+attempt.CO2eGrams)*1e6) / 1e6,
+		Source:    total.Source,
+	}
+	if attempt.Source != total.Source {
+		sum.Source = "estimated"
+	}
+	return &sum
+}
+
+// OutputBudget returns how many output tokens fit in maxCost after the prompt
+func (m *Meter) OutputBudget(maxCost float64) (int, error) {
+	if !m.priced {
+		return 0, fmt.Errorf("model has no pricing, so max_cost cannot be enforced")
+	}
+	remaining := maxCost - float64(m.inputTokens)/1000*m.costInPer1K
+	if remaining <= 0 {
+		return 0, fmt.Errorf("max_cost $%.6f does not cover the prompt's input cost", maxCost)
+	}
+	if m.costOutPer1K <= 0 {
+		return math.MaxInt32, nil
+	}
+	budget := int(remaining / m.costOutPer1K * 1000)
+	if budget < 1 {
+		return 0, fmt.Errorf("max_cost $%.6f leaves no budget for output tokens", maxCost)
+	}
+	return budget, nil
+}
@@ -0,0 +1,45 @@
+package generate
+
+import "strings"
+
+// Where a provider's safety filter stopped a generation
+const (
+	SafetyStagePrompt = "prompt" // The prompt was refused; nothing was generated
+	SafetyStageOutput = "output" // The answer was withheld or cut short
+)
+
+// SafetyFlags reports a provider's own safety filtering in one shape for
+// every provider. Categories are lowercase, e.g. "dangerous_content".
+type SafetyFlags struct {
+	Blocked    bool     `json:"blocked"`
+	Stage      string   `json:"stage"`
+	Categories []string `json:"categories,omitempty"`
+	Reason     string   `json:"reason,omitempty"` // The provider's reason, e.g. SAFETY or refusal
+}
+
+// normalizeFinish maps a provider's stop reason onto the unified finish
+// reasons; one the table does not know becomes FinishOther
+func normalizeFinish(reasons map[string]string, raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if mapped, ok := reasons[raw]; ok {
+		return mapped
+	}
+	return FinishOther
+}
+
+// outputFiltered reports an answer a provider withheld or cut short, or nil
+// when the finish reason was not a content filter
+func outputFiltered(finishReason, raw string, categories []string) *SafetyFlags {
+	if finishReason != FinishContentFilter {
+		return nil
+	}
+	return &SafetyFlags{Blocked: true, Stage: SafetyStageOutput, Categories: categories, Reason: raw}
+}
+
+// safetyCategory turns a provider category such as HARM_CATEGORY_HATE_SPEECH
+// into hate_speech
+func safetyCategory(category string) string {
+	return strings.ToLower(strings.TrimPrefix(category, "HARM_CATEGORY_"))
+}
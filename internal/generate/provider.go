@@ -0,0 +1,248 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// wireRequest is a provider-specific HTTP call produced by an adapter
+type wireRequest struct {
+	path    string // Appended to the provider's base URL
+	headers map[string]string
+	body    interface{}
+}
+
+// tokenUsage is provider-reported usage; zero fields were not reported.
+// Adapters normalize it: input counts every prompt token, cached or not, and
+// output counts every generated token, reasoning included.
+type tokenUsage struct {
+	inputTokens       int
+	outputTokens      int
+	cachedInputTokens int
+	reasoningTokens   int
+}
+
+// completion is a decoded non-streamed response. finishReason is the unified
+// reason and rawFinish the provider's own.
+type completion struct {
+	content      string
+	finishReason string
+	rawFinish    string
+	toolCalls    []ToolCall
+	usage        *tokenUsage
+	safety       *SafetyFlags
+}
+
+type streamEvent struct {
+	delta        string
+	finishReason string
+	rawFinish    string
+	usage        *tokenUsage
+	safety       *SafetyFlags
+}
+
+// adapter translates the unified request shape into one provider family's wire
+// format and decodes its responses back, including finish reasons
+type adapter interface {
+	encode(model, apiKey string, req Request, stream bool) (wireRequest, error)
+	decode(body []byte) (completion, error)
+	// decodeEvent handles one server-sent event; done reports the end of the stream
+	decodeEvent(event string, data []byte) (ev streamEvent, done bool, err error)
+}
+
+// provider is where and how a provider is called
+type provider struct {
+	baseURL string
+	adapter adapter
+}
+
+// providerConfigs maps providers to their API. Providers without a native
+// adapter are called through their OpenAI-compatible endpoint.
+var providerConfigs = map[string]provider{
+	"openai":     {"https://api.openai.com/v1", openAIAdapter{}},
+	"anthropic":  {"https://api.anthropic.com/v1", anthropicAdapter{}},
+	"google":     {"https://generativelanguage.googleapis.com/v1beta", googleAdapter{}},
+	"mistral":    {"https://api.mistral.ai/v1", openAIAdapter{}},
+	"openrouter": {"https://openrouter.ai/api/v1", openAIAdapter{}},
+	"xai":        {"https://api.x.ai/v1", openAIAdapter{}},
+	"deepseek":   {"https://api.deepseek.com/v1", openAIAdapter{}},
+	"cohere":     {"https://api.cohere.ai/compatibility/v1", openAIAdapter{}},
+	"ollama":     {envOr("OLLAMA_BASE_URL", "http://localhost:11434/v1"), openAIAdapter{}},
+	"vllm":       {envOr("VLLM_BASE_URL", "http://localhost:8000/v1"), openAIAdapter{}},
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func providerFor(c *call) provider {
+	p, ok := providerConfigs[c.provider]
+	if !ok {
+		p = provider{adapter: openAIAdapter{}}
+	}
+	// Tenant models may live on their own deployment of the provider's API
+	if c.model.Endpoint != "" {
+		p.baseURL = c.model.Endpoint
+	}
+	return p
+}
+
+// post sends the request to the model's endpoints in turn, failing over to the
+// next on connection errors, timeouts, 408, 429 and 5xx. The endpoint that
+// answered is recorded on the call. Streams fail over only before the first
+// event; a stream that breaks midway is not replayed.
+func (g *Generator) post(ctx context.Context, c *call, req Request, stream bool) (*http.Response, adapter, error) {
+	p := providerFor(c)
+	req.MaxTokens = c.maxTokens(req)
+	req = applyTemplate(c.template, req, c.model.ID)
+	wire, err := p.adapter.encode(c.model.ID, c.apiKey, req, stream)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	payload, err := json.Marshal(wire.body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var lastErr error
+	for _, e := range g.endpoints.order(endpointsFor(c, p.baseURL)) {
+		resp, failover, err := g.send(ctx, c, e, wire, payload)
+		if err == nil {
+			g.endpoints.succeeded(c.provider, e)
+			c.endpoint = e
+			if g.activity != nil {
+				g.activity.ModelCalled(c.provider, c.model.ID)
+			}
+			return resp, p.adapter, nil
+		}
+		if !failover || ctx.Err() != nil {
+			return nil, nil, err
+		}
+		if g.endpoints.failed(c.provider, e, err) {
+			trace.Logf(ctx, "[GENERATE] %s endpoint %s taken out of rotation: %v", c.provider, e.name, err)
+		}
+		lastErr = err
+	}
+	return nil, nil, lastErr
+}
+
+// send makes one attempt against an endpoint; failover reports whether another
+// endpoint might succeed where this one failed
+func (g *Generator) send(ctx context.Context, c *call, e endpoint, wire wireRequest, payload []byte) (resp *http.Response, failover bool, err error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(e.url, "/")+wire.path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid provider endpoint %s: %w", e.name, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	trace.Inject(httpReq)
+	for k, v := range wire.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err = g.registry.HTTPClient(c.provider).Do(httpReq)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to call %s: %w", c.provider, err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		failover = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+		return nil, failover, fmt.Errorf("%s returned %d: %s", c.provider, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, false, nil
+}
+
+func (g *Generator) complete(ctx context.Context, c *call, req Request) (completion, error) {
+	resp, a, err := g.post(ctx, c, req, false)
+	if err != nil {
+		return completion{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return completion{}, fmt.Errorf("failed to read %s response: %w", c.provider, err)
+	}
+	out, err := a.decode(body)
+	if err != nil {
+		return completion{}, fmt.Errorf("failed to decode %s response: %w", c.provider, err)
+	}
+	return out, nil
+}
+
+// stream reads server-sent events until the provider finishes or handle returns false
+func (g *Generator) stream(ctx context.Context, c *call, req Request, handle func(streamEvent) (bool, error)) error {
+	resp, a, err := g.post(ctx, c, req, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	var data []byte
+	// dispatch hands one complete event to the adapter; stop ends the stream
+	dispatch := func() (stop bool, err error) {
+		ev, done, err := a.decodeEvent(event, data)
+		event, data = "", nil
+		if err != nil {
+			return true, fmt.Errorf("failed to decode %s stream: %w", c.provider, err)
+		}
+		if ev.delta != "" || ev.finishReason != "" || ev.usage != nil || ev.safety != nil {
+			more, err := handle(ev)
+			if err != nil || !more {
+				return true, err
+			}
+		}
+		return done, nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:"))...)
+		case strings.TrimSpace(line) == "" && len(data) > 0:
+			// A blank line ends the event
+			if stop, err := dispatch(); stop {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		_, err := dispatch()
+		return err
+	}
+	return nil
+}
+
+// parseDataURL splits a data:<media type>;base64,<data> URL
+func parseDataURL(url string) (mediaType, data string, ok bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return "", "", false
+	}
+	header, data, found := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !found || !strings.HasSuffix(header, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(header, ";base64"), data, true
+}
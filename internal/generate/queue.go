@@ -0,0 +1,208 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned when a provider has no worker free and no room to wait
+var ErrQueueFull = errors.New("generation queue full")
+
+const (
+	minRetryAfter = time.Second
+	maxRetryAfter = time.Minute
+	// serviceAlpha weights the latest call in the running average service time
+	serviceAlpha = 0.2
+)
+
+// QueueConfig sizes the per-provider worker pools
+type QueueConfig struct {
+	Workers   int           // Concurrent provider calls
+	QueueSize int           // Calls allowed to wait for a worker
+	MaxWait   time.Duration // Longest a call waits before it is refused
+}
+
+// DefaultQueueConfig returns the built-in pool sizes; GENERATE_WORKERS,
+// GENERATE_QUEUE_SIZE and GENERATE_QUEUE_MAX_WAIT override them
+func DefaultQueueConfig() QueueConfig {
+	cfg := QueueConfig{Workers: 8, QueueSize: 64, MaxWait: 30 * time.Second}
+	if n, err := strconv.Atoi(os.Getenv("GENERATE_WORKERS")); err == nil && n > 0 {
+		cfg.Workers = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("GENERATE_QUEUE_SIZE")); err == nil && n >= 0 {
+		cfg.QueueSize = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("GENERATE_QUEUE_MAX_WAIT")); err == nil && d > 0 {
+		cfg.MaxWait = d
+	}
+	return cfg
+}
+
+// QueueFullError reports a refused call with a suggested retry delay
+type QueueFullError struct {
+	Provider   string
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("%s queue %s, retry after %s", e.Provider, e.Reason, e.RetryAfter)
+}
+
+func (e *QueueFullError) Unwrap() error {
+	return ErrQueueFull
+}
+
+// providerQueue bounds the calls in flight to one provider
+type providerQueue struct {
+	slots   chan struct{}
+	waiting atomic.Int64
+
+	admitted  atomic.Int64
+	rejected  atomic.Int64
+	queuedNs  atomic.Int64
+	serviceNs atomic.Int64 // Running average time a call holds a worker
+}
+
+// retryAfter estimates how long until the queue drains enough to admit a call
+func (pq *providerQueue) retryAfter() time.Duration {
+	service := time.Duration(pq.serviceNs.Load())
+	workers := int64(cap(pq.slots))
+	wait := service * time.Duration((pq.waiting.Load()+workers)/workers)
+	if wait < minRetryAfter {
+		return minRetryAfter
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
+
+func (pq *providerQueue) observeService(d time.Duration) {
+	prev := pq.serviceNs.Load()
+	if prev == 0 {
+		pq.serviceNs.Store(int64(d))
+		return
+	}
+	pq.serviceNs.Store(int64(serviceAlpha*float64(d) + (1-serviceAlpha)*float64(prev)))
+}
+
+// Queues holds a bounded worker pool per provider, so load beyond a provider's
+// capacity waits briefly and is then refused instead of piling up on its rate limit
+type Queues struct {
+	cfg QueueConfig
+
+	mu         sync.Mutex
+	byProvider map[string]*providerQueue
+}
+
+func NewQueues(cfg QueueConfig) *Queues {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	return &Queues{cfg: cfg, byProvider: make(map[string]*providerQueue)}
+}
+
+func (q *Queues) queueFor(provider string) *providerQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pq, ok := q.byProvider[provider]
+	if !ok {
+		pq = &providerQueue{slots: make(chan struct{}, q.cfg.Workers)}
+		q.byProvider[provider] = pq
+	}
+	return pq
+}
+
+// acquire takes a worker for provider, waiting in its queue when all are busy.
+// It returns the release func and how long the call was queued.
+func (q *Queues) acquire(ctx context.Context, provider string) (func(), time.Duration, error) {
+	pq := q.queueFor(provider)
+	start := time.Now()
+
+	select {
+	case pq.slots <- struct{}{}:
+	default:
+		if pq.waiting.Add(1) > int64(q.cfg.QueueSize) {
+			pq.waiting.Add(-1)
+			pq.rejected.Add(1)
+			return nil, 0, &QueueFullError{Provider: provider, Reason: "is full", RetryAfter: pq.retryAfter()}
+		}
+
+		timer := time.NewTimer(q.cfg.MaxWait)
+		select {
+		case pq.slots <- struct{}{}:
+			timer.Stop()
+			pq.waiting.Add(-1)
+		case <-timer.C:
+			pq.waiting.Add(-1)
+			pq.rejected.Add(1)
+			return nil, 0, &QueueFullError{Provider: provider, Reason: "wait timed out", RetryAfter: pq.retryAfter()}
+		case <-ctx.Done():
+			timer.Stop()
+			pq.waiting.Add(-1)
+			return nil, 0, ctx.Err()
+		}
+	}
+
+	queued := time.Since(start)
+	pq.admitted.Add(1)
+	pq.queuedNs.Add(int64(queued))
+
+	admitted := time.Now()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			pq.observeService(time.Since(admitted))
+			<-pq.slots
+		})
+	}
+	return release, queued, nil
+}
+
+// QueueStats is a snapshot of one provider's worker pool
+type QueueStats struct {
+	Provider     string  `json:"provider"`
+	Workers      int     `json:"workers"`
+	Active       int     `json:"active"`
+	Queued       int64   `json:"queued"`
+	QueueSize    int     `json:"queue_size"`
+	Admitted     int64   `json:"admitted"`
+	Rejected     int64   `json:"rejected"`
+	AvgQueueMs   float64 `json:"avg_queue_ms"`
+	AvgServiceMs float64 `json:"avg_service_ms"`
+}
+
+// Stats returns per-provider queue depth and throughput, sorted by provider
+func (q *Queues) Stats() []QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := make([]QueueStats, 0, len(q.byProvider))
+	for provider, pq := range q.byProvider {
+		s := QueueStats{
+			Provider:     provider,
+			Workers:      cap(pq.slots),
+			Active:       len(pq.slots),
+			Queued:       pq.waiting.Load(),
+			QueueSize:    q.cfg.QueueSize,
+			Admitted:     pq.admitted.Load(),
+			Rejected:     pq.rejected.Load(),
+			AvgServiceMs: float64(pq.serviceNs.Load()) / 1e6,
+		}
+		if s.Admitted > 0 {
+			s.AvgQueueMs = float64(pq.queuedNs.Load()) / float64(s.Admitted) / 1e6
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
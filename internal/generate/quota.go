@@ -0,0 +1,74 @@
+package generate
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// defaultReservedOutput is reserved for a call whose output ceiling is
+// unknown: no max_tokens, provider default or context window
+const defaultReservedOutput = 4096
+
+// TokenQuota holds a generation's tokens against the caller's monthly token
+// ceiling until it settles with the tokens actually used. Over the ceiling
+// Reserve returns a *limits.TokenQuotaError.
+type TokenQuota interface {
+	Reserve(ctx context.Context, userID, plan string, tokens int) (func(used int), error)
+}
+
+// SetTokenQuota reserves the most tokens each tenant generation can use
+// before it starts, so long streams count against the plan while they run
+func (g *Generator) SetTokenQuota(quota TokenQuota) {
+	g.tokenQuota = quota
+}
+
+// reserveTokens holds the prepared calls' worst case against the caller's
+// ceiling, returning the function that settles it. Calls without a plan, such
+// as eval runs and quality judging, are not counted. If the quota cannot be
+// reached the generation goes ahead.
+func (g *Generator) reserveTokens(ctx context.Context, calls []*call, req Request) (func(used int), error) {
+	if g.tokenQuota == nil || req.plan == "" || req.UserID == "" {
+		return func(int) {}, nil
+	}
+	settle, err := g.tokenQuota.Reserve(ctx, req.UserID, req.plan, worstCaseTokens(calls, req))
+	var exceeded *limits.TokenQuotaError
+	if errors.As(err, &exceeded) {
+		return nil, err
+	}
+	if err != nil {
+		trace.Logf(ctx, "[GENERATE] Not enforcing token quota for %s: %v", req.UserID, err)
+		return func(int) {}, nil
+	}
+	return settle, nil
+}
+
+// worstCaseTokens is every input token and the output ceiling of each call,
+// for each structured attempt
+func worstCaseTokens(calls []*call, req Request) int {
+	attempts := 1
+	if req.ResponseSchema != nil {
+		attempts = structuredRetries + 1
+	}
+	tokens := 0
+	for _, c := range calls {
+		output := c.outputCeiling(req)
+		if output <= 0 {
+			output = defaultReservedOutput
+		}
+		tokens += attempts * (c.meter.inputTokens + output)
+	}
+	return tokens
+}
+
+// usedTokens is what a finished generation used, including output that
+// failed its response_schema
+func usedTokens(resp Response, err error) int {
+	var invalid *SchemaError
+	if errors.As(err, &invalid) {
+		return invalid.Usage.InputTokens + invalid.Usage.OutputTokens
+	}
+	return resp.Usage.InputTokens + resp.Usage.OutputTokens
+}
@@ -0,0 +1,278 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// raceEntrants is how many of the router's top picks a race calls at once
+const raceEntrants = 2
+
+// Race outcomes, per entrant
+const (
+	RaceWon          = "won"
+	RaceLost         = "lost"         // Answered acceptably, but after the winner
+	RaceCancelled    = "cancelled"    // Still running when another entrant won
+	RaceFailed       = "failed"       // The call errored
+	RaceUnacceptable = "unacceptable" // Answered with no content or tool calls
+)
+
+// RaceEntrant is one model's part in a race
+type RaceEntrant struct {
+	Model     string  `json:"model"`
+	Provider  string  `json:"provider"`
+	Outcome   string  `json:"outcome"`
+	LatencyMs float64 `json:"latency_ms"` // Until it answered, failed or was cancelled
+	Usage     Usage   `json:"usage"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// RaceOutcome reports how a raced generation was decided
+type RaceOutcome struct {
+	Winner   string        `json:"winner,omitempty"`
+	Entrants []RaceEntrant `json:"entrants"`
+}
+
+// raceResult is one entrant's finished call
+type raceResult struct {
+	index   int
+	resp    Response
+	err     error
+	latency time.Duration
+}
+
+// acceptable reports whether a response is good enough to end the race
+func acceptable(resp Response) bool {
+	return strings.TrimSpace(resp.Content) != "" || len(resp.ToolCalls) > 0
+}
+
+// Race sends the request to the router's top two models at once and returns
+// the first acceptable response, cancelling the other call. Both calls are
+// billed: Usage covers every entrant, including the input of a cancelled one.
+// max_cost is split between the entrants so the race as a whole stays within it.
+// When only one model is eligible, it runs alone.
+func (g *Generator) Race(ctx context.Context, req Request) (Response, error) {
+	picks, err := g.resolver.ResolveModels(ctx, req.UserID, req.Prompt(), req.Images(), req.routableProviders(), req.FeatureRequirements(), raceEntrants)
+	if err != nil {
+		return Response{}, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	req.expectedOutput = g.estimateOutput(ctx, req)
+	if req.MaxCost != nil {
+		share := *req.MaxCost / float64(len(picks))
+		req.MaxCost = &share
+	}
+
+	// An entrant the tenant cannot call (no key for its provider, budget too
+	// small) drops out; the race needs at least one
+	calls := make([]*call, 0, len(picks))
+	var prepareErr error
+	for _, model := range picks {
+		c, err := g.prepareModel(ctx, req, model)
+		if err != nil {
+			trace.Logf(ctx, "[GENERATE] %s dropped from race: %v", model.ID, err)
+			prepareErr = err
+			continue
+		}
+		calls = append(calls, c)
+	}
+	if len(calls) == 0 {
+		return Response{}, prepareErr
+	}
+	// Every entrant is billed, so the race's worst case is their sum
+	if err := checkCost(calls, req); err != nil {
+		return Response{}, err
+	}
+	settle, err := g.reserveTokens(ctx, calls, req)
+	if err != nil {
+		return Response{}, err
+	}
+
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	started := time.Now()
+	results := make(chan raceResult, len(calls))
+	for i, c := range calls {
+		go func(i int, c *call) {
+			resp, err := g.run(raceCtx, c, req)
+			results <- raceResult{index: i, resp: resp, err: err, latency: time.Since(started)}
+		}(i, c)
+	}
+
+	outcome := &RaceOutcome{Entrants: make([]RaceEntrant, len(calls))}
+	var winner, firstAnswer *Response
+	var lastErr error
+	for range calls {
+		r := <-results
+		entrant := &outcome.Entrants[r.index]
+		entrant.Model, entrant.Provider = calls[r.index].model.ID, calls[r.index].provider
+		entrant.LatencyMs = float64(r.latency.Microseconds()) / 1000
+
+		switch {
+		case r.err == nil && winner == nil && acceptable(r.resp):
+			entrant.Outcome = RaceWon
+			entrant.Usage = r.resp.Usage
+			resp := r.resp
+			winner = &resp
+			cancel()
+		case r.err == nil && winner != nil && acceptable(r.resp):
+			entrant.Outcome = RaceLost
+			entrant.Usage = r.resp.Usage
+		case r.err == nil:
+			entrant.Outcome = RaceUnacceptable
+			entrant.Usage = r.resp.Usage
+			if firstAnswer == nil {
+				resp := r.resp
+				firstAnswer = &resp
+			}
+		case winner != nil && errors.Is(r.err, context.Canceled):
+			// The provider has likely billed the prompt already
+			entrant.Outcome = RaceCancelled
+			entrant.Usage = calls[r.index].meter.inputUsage()
+		default:
+			entrant.Outcome = RaceFailed
+			entrant.Error = r.err.Error()
+			lastErr = r.err
+		}
+	}
+	// Without an acceptable answer, the first one is still better than an error
+	if winner == nil && firstAnswer != nil {
+		winner = firstAnswer
+		for i := range outcome.Entrants {
+			if outcome.Entrants[i].Model == winner.Model {
+				outcome.Entrants[i].Outcome = RaceWon
+				break
+			}
+		}
+	}
+	used := 0
+	for _, entrant := range outcome.Entrants {
+		g.races.record(entrant)
+		used += entrant.Usage.InputTokens + entrant.Usage.OutputTokens
+	}
+	settle(used)
+	if winner == nil {
+		return Response{}, lastErr
+	}
+
+	resp := *winner
+	outcome.Winner = resp.Model
+	resp.Race = outcome
+	for i, entrant := range outcome.Entrants {
+		resp.Usage = addUsage(resp.Usage, entrant.Usage, i == 0)
+	}
+	return resp, nil
+}
+
+// inputUsage is the record of the prompt alone, what a cancelled call is billed
+func (m *Meter) inputUsage() Usage {
+	return Usage{
+		InputTokens: m.inputTokens,
+		CostUSD:     math.Round(float64(m.inputTokens)/1000*m.costInPer1K*1e6) / 1e6,
+		Source:      UsageEstimated,
+		Footprint:   m.footprint(0),
+	}
+}
+
+// RaceStats summarizes one model's races
+type RaceStats struct {
+	Model        string  `json:"model"`
+	Provider     string  `json:"provider"`
+	Entered      int64   `json:"entered"`
+	Won          int64   `json:"won"`
+	Lost         int64   `json:"lost"`
+	Cancelled    int64   `json:"cancelled"`
+	Failed       int64   `json:"failed"`
+	Unacceptable int64   `json:"unacceptable"`
+	WinRate      float64 `json:"win_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`               // Over the races it answered, won or not
+	AvgWinMs     float64 `json:"avg_win_latency_ms,omitempty"` // Over the races it won
+}
+
+// raceStats keeps per-model race counts and latencies since startup
+type raceStats struct {
+	mu      sync.Mutex
+	byModel map[string]*raceTally
+}
+
+type raceTally struct {
+	provider                                        string
+	entered, won, lost, cancelled, failed, rejected int64
+	answered                                        int64
+	answeredMs, wonMs                               float64
+}
+
+func newRaceStats() *raceStats {
+	return &raceStats{byModel: make(map[string]*raceTally)}
+}
+
+func (s *raceStats) record(e RaceEntrant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.byModel[e.Model]
+	if !ok {
+		t = &raceTally{provider: e.Provider}
+		s.byModel[e.Model] = t
+	}
+	t.entered++
+	switch e.Outcome {
+	case RaceWon:
+		t.won++
+		t.wonMs += e.LatencyMs
+	case RaceLost:
+		t.lost++
+	case RaceCancelled:
+		t.cancelled++
+	case RaceFailed:
+		t.failed++
+	case RaceUnacceptable:
+		t.rejected++
+	}
+	if e.Outcome == RaceWon || e.Outcome == RaceLost || e.Outcome == RaceUnacceptable {
+		t.answered++
+		t.answeredMs += e.LatencyMs
+	}
+}
+
+func (s *raceStats) snapshot() []RaceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]RaceStats, 0, len(s.byModel))
+	for model, t := range s.byModel {
+		st := RaceStats{
+			Model:        model,
+			Provider:     t.provider,
+			Entered:      t.entered,
+			Won:          t.won,
+			Lost:         t.lost,
+			Cancelled:    t.cancelled,
+			Failed:       t.failed,
+			Unacceptable: t.rejected,
+			WinRate:      float64(t.won) / float64(t.entered),
+		}
+		if t.answered > 0 {
+			st.AvgLatencyMs = t.answeredMs / float64(t.answered)
+		}
+		if t.won > 0 {
+			st.AvgWinMs = t.wonMs / float64(t.won)
+		}
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Model < stats[j].Model })
+	return stats
+}
+
+// RaceStats returns per-model race metrics since startup
+func (g *Generator) RaceStats() []RaceStats {
+	return g.races.snapshot()
+}
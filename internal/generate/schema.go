@@ -0,0 +1,59 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Askeban/llm-router-go/internal/jsonschema"
+)
+
+// maxSchemaErrors bounds the validation errors reported back to the model
+const maxSchemaErrors = 10
+
+// ValidateResponseSchema checks that a response_schema is a JSON schema object
+// whose types this router can validate against
+func ValidateResponseSchema(raw json.RawMessage) error {
+	var schema interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("response_schema is not valid JSON: %w", err)
+	}
+	node, ok := schema.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("response_schema must be a JSON object")
+	}
+	return checkSchemaTypes(node, "$")
+}
+
+func checkSchemaTypes(node map[string]interface{}, path string) error {
+	for _, t := range jsonschema.TypeList(node) {
+		if !jsonschema.Types[t] {
+			return fmt.Errorf("response_schema %s has unknown type %q", path, t)
+		}
+	}
+	if props, ok := node["properties"].(map[string]interface{}); ok {
+		for name, sub := range props {
+			if subNode, ok := sub.(map[string]interface{}); ok {
+				if err := checkSchemaTypes(subNode, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]interface{}); ok {
+		return checkSchemaTypes(items, path+"[]")
+	}
+	return nil
+}
+
+// validateAgainstSchema checks a decoded JSON value against the schema and
+// returns the errors reported back to the model for repair
+func validateAgainstSchema(schema, value interface{}) []string {
+	var errs []string
+	for _, err := range jsonschema.Validate(schema, value) {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > maxSchemaErrors {
+		errs = errs[:maxSchemaErrors]
+	}
+	return errs
+}
@@ -0,0 +1,223 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// How a provider is asked for output matching a response_schema
+const (
+	StructuredSchema       = "json_schema"  // The provider constrains decoding to the schema
+	StructuredTool         = "tool"         // The schema is the input of a forced tool call
+	StructuredJSON         = "json_object"  // The provider guarantees JSON; the schema is given as instructions
+	StructuredInstructions = "instructions" // The schema is given as instructions only
+)
+
+// structuredModes maps providers to their best structured output mode.
+// Providers missing here only get instructions.
+var structuredModes = map[string]string{
+	"openai":     StructuredSchema,
+	"google":     StructuredSchema,
+	"anthropic":  StructuredTool,
+	"mistral":    StructuredJSON,
+	"deepseek":   StructuredJSON,
+	"xai":        StructuredJSON,
+	"openrouter": StructuredJSON,
+}
+
+const (
+	// structuredToolName is the forced tool carrying structured output
+	structuredToolName = "structured_response"
+	// structuredRetries is how many times invalid output is sent back for repair
+	structuredRetries = 2
+)
+
+// SchemaError reports output that still failed validation after every repair attempt
+type SchemaError struct {
+	Model    string   `json:"model"`
+	Errors   []string `json:"errors"`
+	Content  string   `json:"content"`
+	Attempts int      `json:"attempts"`
+	Usage    Usage    `json:"usage"`
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("output did not match response_schema after %d attempts: %s", e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// StructuredProviders lists the providers that enforce a schema natively
+func StructuredProviders() []string {
+	var list []string
+	for provider, mode := range structuredModes {
+		if mode == StructuredSchema || mode == StructuredTool {
+			list = append(list, provider)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func structuredModeFor(provider string) string {
+	if mode, ok := structuredModes[provider]; ok {
+		return mode
+	}
+	return StructuredInstructions
+}
+
+// schemaInstructions is the system turn describing the schema to models that
+// cannot enforce it themselves
+func schemaInstructions(schema json.RawMessage) Message {
+	return Message{
+		Role: "system",
+		Content: "Respond with a single JSON value that conforms to this JSON schema. " +
+			"Output only the JSON, with no prose or code fences.\n\nSchema:\n" + string(schema),
+	}
+}
+
+// generateStructured calls the model until its output validates against the
+// schema, repairing locally first and then sending validation errors back.
+// Retries stop early once max_cost is spent.
+func (g *Generator) generateStructured(ctx context.Context, c *call, req Request, queueTime float64) (Response, error) {
+	var schema interface{}
+	if err := json.Unmarshal(req.ResponseSchema, &schema); err != nil {
+		return Response{}, fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	req.structuredMode = structuredModeFor(c.provider)
+	if root, ok := schema.(map[string]interface{}); ok && req.structuredMode == StructuredTool && root["type"] != "object" {
+		// Tool inputs must be objects
+		req.structuredMode = StructuredInstructions
+	}
+	if req.structuredMode == StructuredJSON || req.structuredMode == StructuredInstructions {
+		req.Messages = append([]Message{schemaInstructions(req.ResponseSchema)}, req.Messages...)
+	}
+
+	var total Usage
+	meter := c.meter
+	for attempt := 1; ; attempt++ {
+		out, err := g.complete(ctx, c, req)
+		if err != nil {
+			return Response{}, err
+		}
+		meter.AddOutput(out.content)
+		if out.usage != nil {
+			meter.setUsage(*out.usage)
+		}
+		total = addUsage(total, meter.Usage(), attempt == 1)
+
+		content := out.content
+		if req.structuredMode == StructuredTool {
+			content = toolOutput(out)
+		}
+
+		normalized, errs := parseStructured(content, schema)
+		if len(errs) == 0 {
+			return Response{
+				Model:          c.model.ID,
+				Provider:       c.provider,
+				Content:        normalized,
+				Structured:     json.RawMessage(normalized),
+				StructuredMode: req.structuredMode,
+				SchemaAttempts: attempt,
+				FinishReason:   FinishStop,
+				Usage:          total,
+				QueueTimeMs:    queueTime,
+				Endpoint:       c.endpoint.name,
+				Region:         c.endpoint.region,
+				Template:       c.templateSource,
+				OutputLimit:    c.output,
+			}, nil
+		}
+
+		spent := req.MaxCost != nil && total.CostUSD >= *req.MaxCost
+		if attempt > structuredRetries || spent || out.finishReason == FinishLength || out.finishReason == FinishContentFilter {
+			return Response{}, &SchemaError{Model: c.model.ID, Errors: errs, Content: content, Attempts: attempt, Usage: total}
+		}
+		trace.Logf(ctx, "[GENERATE] %s output failed response_schema (attempt %d): %s", c.model.ID, attempt, strings.Join(errs, "; "))
+
+		// Show the model its output and what was wrong with it
+		req.Messages = append(req.Messages,
+			Message{Role: "assistant", Content: content},
+			Message{Role: "user", Content: "That response does not match the required JSON schema:\n- " +
+				strings.Join(errs, "\n- ") + "\nReply with only the corrected JSON."},
+		)
+		meter = newMeter(c.model, req.Prompt())
+	}
+}
+
+// toolOutput returns the arguments of the forced structured tool call
+func toolOutput(out completion) string {
+	for _, tc := range out.toolCalls {
+		if tc.Name == structuredToolName {
+			return string(tc.Arguments)
+		}
+	}
+	return out.content
+}
+
+// parseStructured extracts the JSON value from model output, tolerating code
+// fences and surrounding prose, and validates it. The value is returned
+// compacted.
+func parseStructured(content string, schema interface{}) (string, []string) {
+	text := extractJSON(content)
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return "", []string{fmt.Sprintf("output is not valid JSON: %v", err)}
+	}
+	if errs := validateAgainstSchema(schema, value); len(errs) > 0 {
+		return "", errs
+	}
+	compact, _ := json.Marshal(value)
+	return string(compact), nil
+}
+
+// extractJSON strips code fences and any prose around the outermost JSON value
+func extractJSON(content string) string {
+	text := strings.TrimSpace(content)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:] // Drop the language tag
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	if json.Valid([]byte(text)) {
+		return text
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return text
+	}
+	closer := "}"
+	if text[start] == '[' {
+		closer = "]"
+	}
+	end := strings.LastIndex(text, closer)
+	if end <= start {
+		return text
+	}
+	return text[start : end+1]
+}
+
+// addUsage adds one attempt's usage to the running total
+func addUsage(total, attempt Usage, first bool) Usage {
+	if first {
+		return attempt
+	}
+	total.InputTokens += attempt.InputTokens
+	total.OutputTokens += attempt.OutputTokens
+	total.CachedInputTokens += attempt.CachedInputTokens
+	total.ReasoningTokens += attempt.ReasoningTokens
+	total.CostUSD += attempt.CostUSD
+	if attempt.Source != UsageProvider {
+		total.Source = UsageEstimated
+	}
+	total.Footprint = addFootprint(total.Footprint, attempt.Footprint)
+	return total
+}
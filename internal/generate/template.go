@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// Where a call's prompt template came from, reported as prompt_template
+const (
+	TemplateCatalog = "catalog"
+	TemplateTenant  = "tenant"
+)
+
+// PromptTemplates supplies tenants' overrides of the catalog prompt
+// templates; nil when the tenant has none for the model
+type PromptTemplates interface {
+	TemplateFor(ctx context.Context, userID, modelID string) (*models.PromptTemplate, error)
+}
+
+// SetPromptTemplates lets tenants override the catalog's prompt templates
+func (g *Generator) SetPromptTemplates(templates PromptTemplates) {
+	g.templates = templates
+}
+
+// resolveTemplate returns the template for calls to model on the tenant's
+// behalf and where it came from. A tenant override that fails to load leaves
+// the catalog template in place.
+func (g *Generator) resolveTemplate(ctx context.Context, userID string, model models.EnhancedModel) (*models.PromptTemplate, string) {
+	template, source := model.PromptTemplate, TemplateCatalog
+	if g.templates != nil && userID != "" {
+		override, err := g.templates.TemplateFor(ctx, userID, model.ID)
+		if err != nil {
+			trace.Logf(ctx, "[GENERATE] Using catalog prompt template for %s: %v", model.ID, err)
+		} else if override != nil {
+			template, source = override, TemplateTenant
+		}
+	}
+	if template == nil || template.Disabled {
+		return nil, ""
+	}
+	return template, source
+}
+
+// applyTemplate returns req with the template applied for model. The
+// messages are copied, so one request can be templated for several models.
+func applyTemplate(template *models.PromptTemplate, req Request, modelID string) Request {
+	if template == nil {
+		return req
+	}
+
+	// A system template takes over the caller's system prompt, placing it at
+	// {{system}} or after the template's own instructions
+	messages := make([]Message, 0, len(req.Messages)+1)
+	var system []string
+	for _, m := range req.Messages {
+		if m.Role == "system" && template.System != "" {
+			system = append(system, m.text())
+			continue
+		}
+		messages = append(messages, m)
+	}
+
+	last := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			last = i
+			break
+		}
+	}
+
+	vars := map[string]string{
+		models.TemplateVarSystem:      strings.Join(system, "\n\n"),
+		models.TemplateVarConstraints: constraints(req),
+		models.TemplateVarModel:       modelID,
+		models.TemplateVarDate:        time.Now().UTC().Format("2006-01-02"),
+	}
+	if last >= 0 {
+		vars[models.TemplateVarPrompt] = messages[last].text()
+	}
+
+	if template.User != "" && last >= 0 {
+		m := messages[last]
+		// Text moves into the templated content; images stay as parts
+		var parts []ContentPart
+		for _, p := range m.Parts {
+			if p.Type != "text" {
+				parts = append(parts, p)
+			}
+		}
+		m.Parts = parts
+		m.Content = models.RenderTemplate(template.User, vars)
+		messages[last] = m
+	}
+	if template.System != "" {
+		content := strings.TrimSpace(models.RenderTemplate(template.System, vars))
+		if caller := vars[models.TemplateVarSystem]; caller != "" && !models.TemplateUses(template.System, models.TemplateVarSystem) {
+			content += "\n\n" + caller
+		}
+		messages = append([]Message{{Role: "system", Content: content}}, messages...)
+	}
+
+	req.Messages = messages
+	return req
+}
+
+// constraints describes the request's limits for {{constraints}}
+func constraints(req Request) string {
+	var parts []string
+	if req.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("Keep the answer within %d tokens.", req.MaxTokens))
+	}
+	if req.ResponseSchema != nil {
+		parts = append(parts, "Respond only with JSON matching the requested schema.")
+	}
+	if len(req.Tools) > 0 {
+		parts = append(parts, "Call a tool when it is needed to answer.")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,31 @@
+package generate
+
+import (
+	"context"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Warm sends a one-token request so a self-hosted backend loads the model
+// before traffic needs it. It waits for a worker like any call, and returns
+// how long the backend took to answer.
+func (g *Generator) Warm(ctx context.Context, model models.EnhancedModel) (time.Duration, error) {
+	req := Request{Messages: []Message{{Role: "user", Content: "ping"}}, MaxTokens: 1}
+	c, err := g.prepareModel(ctx, req, model)
+	if err != nil {
+		return 0, err
+	}
+
+	release, _, err := g.admit(ctx, c)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	start := time.Now()
+	if _, err := g.complete(ctx, c, req); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,562 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxDepth bounds how deeply a query may nest selections
+const maxDepth = 10
+
+// Access levels for a field
+const (
+	AccessPublic = ""      // Anyone
+	AccessUser   = "user"  // Authenticated tenants
+	AccessAdmin  = "admin" // Operators presenting the admin token
+)
+
+// Object is a GraphQL object type
+type Object struct {
+	Name        string
+	Description string
+	Fields      map[string]*Field
+}
+
+// Field is a field of an object type. Fields returning objects set Object;
+// scalar fields return JSON-encodable values. A field resolves each parent
+// with Resolve, or all parents at one level of the query at once with Batch,
+// which is how fields backed by the database avoid a query per parent.
+type Field struct {
+	Type        string // SDL type, e.g. "[Model!]!"
+	Description string
+	Args        map[string]string // Argument name -> SDL type; a trailing ! makes it required
+	Object      *Object
+	Access      string
+	Resolve     func(r *Request, source interface{}, args map[string]interface{}) (interface{}, error)
+	Batch       func(r *Request, sources []interface{}, args map[string]interface{}) ([]interface{}, error)
+}
+
+// Schema is the query root type
+type Schema struct {
+	Query *Object
+}
+
+// Request carries the caller and the per-request loader cache to resolvers
+type Request struct {
+	Context context.Context
+	UserID  string
+	Email   string
+	Plan    string
+	Admin   bool
+
+	mu      sync.Mutex
+	loaders map[string]interface{}
+}
+
+// allowed reports whether the caller may read a field of the given access
+func (r *Request) allowed(access string) bool {
+	switch access {
+	case AccessUser:
+		return r.UserID != "" || r.Admin
+	case AccessAdmin:
+		return r.Admin
+	}
+	return true
+}
+
+// Load returns the value cached under key for this request, fetching it on
+// first use. Resolvers that read the same rows share one fetch.
+func (r *Request) Load(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loaders == nil {
+		r.loaders = make(map[string]interface{})
+	}
+	if v, ok := r.loaders[key]; ok {
+		return v, nil
+	}
+	v, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	r.loaders[key] = v
+	return v, nil
+}
+
+// Error is a GraphQL error, located by the path of the field it concerns
+type Error struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Result is the response to a GraphQL request
+type Result struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// object is a result object that marshals its fields in query order
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]interface{})}
+}
+
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Execute parses, validates and runs a query. Parse and validation problems
+// are returned as the error; resolver failures and denied fields become
+// null with an entry in Result.Errors.
+func (s *Schema) Execute(r *Request, query, operationName string, variables map[string]interface{}) (*Result, error) {
+	doc, err := parse(query)
+	if err != nil {
+		return nil, err
+	}
+	op, err := doc.operation(operationName)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := coerceVariables(op, variables)
+	if err != nil {
+		return nil, err
+	}
+	e := &executor{doc: doc, op: op, vars: vars, req: r}
+	if err := e.validate(s.Query, op.selections, 1, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	results := e.executeObjects(s.Query, []interface{}{nil}, op.selections, [][]interface{}{{}})
+	return &Result{Data: results[0], Errors: e.errors}, nil
+}
+
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document has several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{}, len(op.variables))
+	for _, v := range op.variables {
+		value, ok := given[v.name]
+		switch {
+		case ok:
+			vars[v.name] = value
+		case v.hasDefault:
+			vars[v.name] = v.def
+		case v.nonNull:
+			return nil, fmt.Errorf("variable $%s is required", v.name)
+		}
+		if v.nonNull && vars[v.name] == nil {
+			return nil, fmt.Errorf("variable $%s must not be null", v.name)
+		}
+	}
+	return vars, nil
+}
+
+type executor struct {
+	doc  *document
+	op   *operation
+	vars map[string]interface{}
+	req  *Request
+
+	errors []Error
+}
+
+// collected is one response key with every field selected under it
+type collected struct {
+	key    string
+	fields []*field
+}
+
+// collect flattens fragments into the ordered fields selected on t
+func (e *executor) collect(t *Object, sels []selection, out []collected, seen map[string]bool) []collected {
+	for _, sel := range sels {
+		switch {
+		case sel.field != nil:
+			key := sel.field.responseKey()
+			found := false
+			for i := range out {
+				if out[i].key == key {
+					out[i].fields = append(out[i].fields, sel.field)
+					found = true
+				}
+			}
+			if !found {
+				out = append(out, collected{key: key, fields: []*field{sel.field}})
+			}
+		case sel.spread != "":
+			if seen[sel.spread] {
+				continue
+			}
+			seen[sel.spread] = true
+			if frag := e.doc.fragments[sel.spread]; frag != nil && frag.typeCondition == t.Name {
+				out = e.collect(t, frag.selections, out, seen)
+			}
+		case sel.inline != nil:
+			if sel.inline.typeCondition == "" || sel.inline.typeCondition == t.Name {
+				out = e.collect(t, sel.inline.selections, out, seen)
+			}
+		}
+	}
+	return out
+}
+
+// validate checks the selections against the schema before anything runs
+func (e *executor) validate(t *Object, sels []selection, depth int, spreading map[string]bool) error {
+	if depth > maxDepth {
+		return fmt.Errorf("query is nested deeper than %d levels", maxDepth)
+	}
+	for _, sel := range sels {
+		switch {
+		case sel.spread != "":
+			frag, ok := e.doc.fragments[sel.spread]
+			if !ok {
+				return fmt.Errorf("unknown fragment %s", sel.spread)
+			}
+			if spreading[sel.spread] {
+				return fmt.Errorf("fragment %s spreads itself", sel.spread)
+			}
+			if frag.typeCondition != t.Name {
+				return fmt.Errorf("fragment %s on %s cannot be spread on %s", frag.name, frag.typeCondition, t.Name)
+			}
+			spreading[sel.spread] = true
+			err := e.validate(t, frag.selections, depth, spreading)
+			delete(spreading, sel.spread)
+			if err != nil {
+				return err
+			}
+		case sel.inline != nil:
+			if sel.inline.typeCondition != "" && sel.inline.typeCondition != t.Name {
+				return fmt.Errorf("inline fragment on %s cannot be spread on %s", sel.inline.typeCondition, t.Name)
+			}
+			if err := e.validate(t, sel.inline.selections, depth, spreading); err != nil {
+				return err
+			}
+		default:
+			if err := e.validateField(t, sel.field, depth, spreading); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *executor) validateField(t *Object, f *field, depth int, spreading map[string]bool) error {
+	if f.name == "__typename" {
+		if len(f.args) > 0 || f.selections != nil {
+			return fmt.Errorf("line %d: __typename takes no arguments or selections", f.line)
+		}
+		return nil
+	}
+	def, ok := t.Fields[f.name]
+	if !ok {
+		return fmt.Errorf("line %d: %s has no field %q", f.line, t.Name, f.name)
+	}
+	given := make(map[string]bool, len(f.args))
+	for _, arg := range f.args {
+		if _, ok := def.Args[arg.name]; !ok {
+			return fmt.Errorf("line %d: field %s.%s has no argument %q", f.line, t.Name, f.name, arg.name)
+		}
+		if ref, isVar := arg.value.(variableRef); isVar {
+			if !e.declared(string(ref)) {
+				return fmt.Errorf("line %d: variable $%s is not declared", f.line, ref)
+			}
+		}
+		given[arg.name] = true
+	}
+	for name, typ := range def.Args {
+		if strings.HasSuffix(typ, "!") && !given[name] {
+			return fmt.Errorf("line %d: field %s.%s requires argument %q", f.line, t.Name, f.name, name)
+		}
+	}
+	switch {
+	case def.Object != nil && f.selections == nil:
+		return fmt.Errorf("line %d: field %s.%s of type %s needs a selection of subfields", f.line, t.Name, f.name, def.Type)
+	case def.Object == nil && f.selections != nil:
+		return fmt.Errorf("line %d: field %s.%s of type %s has no subfields", f.line, t.Name, f.name, def.Type)
+	case def.Object != nil:
+		return e.validate(def.Object, f.selections, depth+1, spreading)
+	}
+	return nil
+}
+
+// declared reports whether the operation declares the variable; an optional
+// variable left unset resolves as null
+func (e *executor) declared(name string) bool {
+	for _, v := range e.op.variables {
+		if v.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// args resolves a field's arguments against the variables
+func (e *executor) args(f *field) map[string]interface{} {
+	args := make(map[string]interface{}, len(f.args))
+	for _, arg := range f.args {
+		args[arg.name] = e.resolveValue(arg.value)
+	}
+	return args
+}
+
+func (e *executor) resolveValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case variableRef:
+		return e.vars[string(v)]
+	case enumValue:
+		return string(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = e.resolveValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = e.resolveValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+func (e *executor) fail(path []interface{}, err error) {
+	e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+}
+
+// executeObjects runs the selections over every source of one object type at
+// once, so batched fields are fetched once per level rather than per source
+func (e *executor) executeObjects(t *Object, sources []interface{}, sels []selection, paths [][]interface{}) []*object {
+	results := make([]*object, len(sources))
+	for i := range results {
+		results[i] = newObject()
+	}
+
+	for _, c := range e.collect(t, sels, nil, map[string]bool{}) {
+		f := c.fields[0]
+		if f.name == "__typename" {
+			for _, res := range results {
+				res.set(c.key, t.Name)
+			}
+			continue
+		}
+		def := t.Fields[f.name]
+		var subselections []selection
+		for _, sf := range c.fields {
+			subselections = append(subselections, sf.selections...)
+		}
+
+		if !e.req.allowed(def.Access) {
+			// Reported once per field rather than once per parent
+			for _, res := range results {
+				res.set(c.key, nil)
+			}
+			if len(paths) > 0 {
+				e.fail(appendPath(paths[0], c.key), fmt.Errorf("not authorized to read %s.%s: requires %s access", t.Name, f.name, def.Access))
+			}
+			continue
+		}
+
+		values := e.resolveField(def, sources, e.args(f), paths, c.key)
+		if def.Object == nil {
+			for i, res := range results {
+				res.set(c.key, values[i])
+			}
+			continue
+		}
+
+		// Gather the child objects of every parent into one batch
+		var children []interface{}
+		var childPaths [][]interface{}
+		type slot struct{ parent, index int }
+		var slots []slot
+		for i, v := range values {
+			if v == nil {
+				continue
+			}
+			if list, ok := asList(v); ok {
+				for j, item := range list {
+					children = append(children, item)
+					childPaths = append(childPaths, appendPath(paths[i], c.key, j))
+					slots = append(slots, slot{i, j})
+				}
+				continue
+			}
+			children = append(children, v)
+			childPaths = append(childPaths, appendPath(paths[i], c.key))
+			slots = append(slots, slot{i, -1})
+		}
+		executed := e.executeObjects(def.Object, children, subselections, childPaths)
+
+		lists := make(map[int][]*object)
+		for i, v := range values {
+			if v == nil {
+				results[i].set(c.key, nil)
+			} else if list, ok := asList(v); ok {
+				lists[i] = make([]*object, len(list))
+			}
+		}
+		for k, s := range slots {
+			if s.index < 0 {
+				results[s.parent].set(c.key, executed[k])
+			} else {
+				lists[s.parent][s.index] = executed[k]
+			}
+		}
+		for i, list := range lists {
+			results[i].set(c.key, list)
+		}
+	}
+	return results
+}
+
+// resolveField returns the field's value for each source, null where it failed
+func (e *executor) resolveField(def *Field, sources []interface{}, args map[string]interface{}, paths [][]interface{}, key string) []interface{} {
+	values := make([]interface{}, len(sources))
+	if def.Batch != nil {
+		if len(sources) == 0 {
+			return values
+		}
+		batch, err := def.Batch(e.req, sources, args)
+		if err == nil && len(batch) != len(sources) {
+			err = errors.New("batch resolver returned the wrong number of values")
+		}
+		if err != nil {
+			e.fail(appendPath(paths[0], key), err)
+			return values
+		}
+		return batch
+	}
+	for i, source := range sources {
+		v, err := def.Resolve(e.req, source, args)
+		if err != nil {
+			e.fail(appendPath(paths[i], key), err)
+			continue
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// asList unpacks a slice value of any element type
+func asList(v interface{}) ([]interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+func appendPath(path []interface{}, elems ...interface{}) []interface{} {
+	out := make([]interface{}, 0, len(path)+len(elems))
+	return append(append(out, path...), elems...)
+}
+
+// SDL renders the schema in GraphQL schema definition language
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	var write func(t *Object)
+	write = func(t *Object) {
+		if seen[t.Name] {
+			return
+		}
+		seen[t.Name] = true
+		if t.Description != "" {
+			fmt.Fprintf(&b, "\"%s\"\n", t.Description)
+		}
+		fmt.Fprintf(&b, "type %s {\n", t.Name)
+		names := make([]string, 0, len(t.Fields))
+		for name := range t.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f := t.Fields[name]
+			if f.Description != "" {
+				fmt.Fprintf(&b, "  \"%s\"\n", f.Description)
+			}
+			fmt.Fprintf(&b, "  %s%s: %s", name, sdlArgs(f.Args), f.Type)
+			if f.Access != AccessPublic {
+				fmt.Fprintf(&b, " # requires %s access", f.Access)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n\n")
+		for _, name := range names {
+			if obj := t.Fields[name].Object; obj != nil {
+				write(obj)
+			}
+		}
+	}
+	write(s.Query)
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func sdlArgs(args map[string]string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + args[name]
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
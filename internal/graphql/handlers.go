@@ -0,0 +1,146 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxQueryBytes bounds a query document
+const maxQueryBytes = 64 << 10
+
+// AdminChecker reports whether a request carries operator credentials
+type AdminChecker interface {
+	IsAdmin(c *gin.Context) bool
+}
+
+// Handlers serves the schema over HTTP. It expects the optional auth
+// middleware to have identified the caller, if any.
+type Handlers struct {
+	schema    *Schema
+	persisted *PersistedQueries
+	admins    AdminChecker
+}
+
+func NewHandlers(schema *Schema) *Handlers {
+	return &Handlers{schema: schema, persisted: NewPersistedQueries()}
+}
+
+// SetPersistedQueries replaces the persisted query store, e.g. with one
+// preloaded from disk
+func (h *Handlers) SetPersistedQueries(persisted *PersistedQueries) {
+	h.persisted = persisted
+}
+
+// SetAdminChecker lets admin-only fields resolve for operators
+func (h *Handlers) SetAdminChecker(admins AdminChecker) {
+	h.admins = admins
+}
+
+// request is a GraphQL-over-HTTP request body
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *struct {
+			Version    int    `json:"version"`
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// Query runs a query sent as a JSON POST body or, for cacheable persisted
+// queries, as GET parameters (query, operationName, variables, extensions)
+func (h *Handlers) Query(c *gin.Context) {
+	var req request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		for param, target := range map[string]interface{}{"variables": &req.Variables, "extensions": &req.Extensions} {
+			if raw := c.Query(param); raw != "" {
+				if err := json.Unmarshal([]byte(raw), target); err != nil {
+					badRequest(c, "invalid "+param+": "+err.Error(), "")
+					return
+				}
+			}
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxQueryBytes)
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			badRequest(c, "invalid request body: "+err.Error(), "")
+			return
+		}
+	}
+	if len(req.Query) > maxQueryBytes {
+		badRequest(c, "query is too large", "")
+		return
+	}
+
+	hash := ""
+	if pq := req.Extensions.PersistedQuery; pq != nil {
+		if pq.Version != 1 {
+			badRequest(c, "unsupported persisted query version", "")
+			return
+		}
+		hash = pq.SHA256Hash
+	}
+	if hash == "" && req.Query == "" {
+		badRequest(c, "query is required", "")
+		return
+	}
+	query, err := h.persisted.Resolve(hash, req.Query)
+	if err != nil {
+		var perr persistedError
+		if errors.As(err, &perr) {
+			// Apollo clients retry with the full query on a 200 with this code
+			c.JSON(http.StatusOK, Result{Errors: []Error{{
+				Message:    perr.message,
+				Extensions: map[string]interface{}{"code": perr.code},
+			}}})
+			return
+		}
+		badRequest(c, err.Error(), "")
+		return
+	}
+
+	r := &Request{
+		Context: c.Request.Context(),
+		UserID:  c.GetString("user_id"),
+		Email:   c.GetString("user_email"),
+		Plan:    c.GetString("user_plan"),
+		Admin:   h.admins != nil && h.admins.IsAdmin(c),
+	}
+	result, err := h.schema.Execute(r, query, req.OperationName, req.Variables)
+	if err != nil {
+		badRequest(c, err.Error(), "GRAPHQL_VALIDATION_FAILED")
+		return
+	}
+	if len(result.Errors) > 0 {
+		log.Printf("[GRAPHQL] %d field errors in %s query", len(result.Errors), operationLabel(req.OperationName))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Schema returns the schema in SDL for client code generators
+func (h *Handlers) Schema(c *gin.Context) {
+	c.String(http.StatusOK, h.schema.SDL())
+}
+
+func badRequest(c *gin.Context, message, code string) {
+	e := Error{Message: message}
+	if code != "" {
+		e.Extensions = map[string]interface{}{"code": code}
+	}
+	c.JSON(http.StatusBadRequest, Result{Errors: []Error{e}})
+}
+
+func operationLabel(name string) string {
+	if name == "" {
+		return "anonymous"
+	}
+	return name
+}
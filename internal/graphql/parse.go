@@ -0,0 +1,507 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The subset of GraphQL understood here: query operations with variables,
+// fields with aliases and arguments, named fragments and inline fragments.
+// Mutations, subscriptions and directives are rejected.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	name       string
+	variables  []variableDef
+	selections []selection
+}
+
+type variableDef struct {
+	name       string
+	nonNull    bool
+	def        interface{}
+	hasDefault bool
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection struct {
+	field  *field
+	spread string
+	inline *fragment
+}
+
+type field struct {
+	alias      string
+	name       string
+	args       []argument
+	selections []selection
+	line       int
+}
+
+// responseKey is the field's name in the result
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value interface{} // Literal, variableRef, enumValue, []interface{} or map[string]interface{}
+}
+
+type variableRef string
+type enumValue string
+
+type gqlTokenKind int
+
+const (
+	tokEOF gqlTokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type gqlToken struct {
+	kind gqlTokenKind
+	text string
+	line int
+}
+
+func lex(src string) ([]gqlToken, error) {
+	var tokens []gqlToken
+	line := 1
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r) || r == ',' || r == '\ufeff':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '.':
+			if i+2 >= len(runes) || runes[i+1] != '.' || runes[i+2] != '.' {
+				return nil, fmt.Errorf("line %d: unexpected '.'", line)
+			}
+			tokens = append(tokens, gqlToken{kind: tokPunct, text: "...", line: line})
+			i += 3
+		case strings.ContainsRune("{}()[]:!$=@|&", r):
+			tokens = append(tokens, gqlToken{kind: tokPunct, text: string(r), line: line})
+			i++
+		case r == '"':
+			if i+2 < len(runes) && runes[i+1] == '"' && runes[i+2] == '"' {
+				end := strings.Index(string(runes[i+3:]), `"""`)
+				if end < 0 {
+					return nil, fmt.Errorf("line %d: unterminated block string", line)
+				}
+				text := string(runes[i+3:])[:end]
+				tokens = append(tokens, gqlToken{kind: tokString, text: text, line: line})
+				line += strings.Count(text, "\n")
+				i += 3 + len([]rune(text)) + 3
+				continue
+			}
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				if runes[j] == '\\' {
+					j++
+				}
+				if j < len(runes) && runes[j] == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				}
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			text, err := strconv.Unquote(string(runes[i : j+1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid string %s", line, string(runes[i:j+1]))
+			}
+			tokens = append(tokens, gqlToken{kind: tokString, text: text, line: line})
+			i = j + 1
+		case r == '-' || unicode.IsDigit(r):
+			j := i + 1
+			float := false
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || strings.ContainsRune(".eE+-", runes[j])) {
+				if strings.ContainsRune(".eE", runes[j]) {
+					float = true
+				}
+				j++
+			}
+			kind := tokInt
+			if float {
+				kind = tokFloat
+			}
+			tokens = append(tokens, gqlToken{kind: kind, text: string(runes[i:j]), line: line})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, gqlToken{kind: tokName, text: string(runes[i:j]), line: line})
+			i = j
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, r)
+		}
+	}
+	return append(tokens, gqlToken{kind: tokEOF, line: line}), nil
+}
+
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// punct consumes the punctuator if it is next
+func (p *gqlParser) punct(text string) bool {
+	if t := p.peek(); t.kind == tokPunct && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *gqlParser) expect(text string) error {
+	if !p.punct(text) {
+		t := p.peek()
+		return fmt.Errorf("line %d: expected %q, found %s", t.line, text, describe(t))
+	}
+	return nil
+}
+
+func (p *gqlParser) name() (string, error) {
+	t := p.next()
+	if t.kind != tokName {
+		return "", fmt.Errorf("line %d: expected a name, found %s", t.line, describe(t))
+	}
+	return t.text, nil
+}
+
+func describe(t gqlToken) string {
+	if t.kind == tokEOF {
+		return "end of document"
+	}
+	return strconv.Quote(t.text)
+}
+
+// parse reads an executable document
+func parse(src string) (*document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{tokens: tokens}
+	doc := &document{fragments: make(map[string]*fragment)}
+
+	for p.peek().kind != tokEOF {
+		t := p.peek()
+		switch {
+		case t.kind == tokPunct && t.text == "{":
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{selections: sels})
+		case t.kind == tokName && t.text == "query":
+			p.next()
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case t.kind == tokName && (t.text == "mutation" || t.text == "subscription"):
+			return nil, fmt.Errorf("line %d: %s operations are not supported", t.line, t.text)
+		case t.kind == tokName && t.text == "fragment":
+			p.next()
+			frag, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[frag.name]; dup {
+				return nil, fmt.Errorf("line %d: fragment %s is defined twice", t.line, frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, fmt.Errorf("line %d: expected an operation or fragment, found %s", t.line, describe(t))
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) operation() (*operation, error) {
+	op := &operation{}
+	if p.peek().kind == tokName {
+		op.name = p.next().text
+	}
+	if p.punct("(") {
+		for !p.punct(")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			nonNull, err := p.typeRef()
+			if err != nil {
+				return nil, err
+			}
+			v := variableDef{name: name, nonNull: nonNull}
+			if p.punct("=") {
+				if v.def, err = p.value(true); err != nil {
+					return nil, err
+				}
+				v.hasDefault = true
+			}
+			op.variables = append(op.variables, v)
+		}
+	}
+	if err := p.noDirectives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+// typeRef skips a variable type such as [String!]! and reports whether it is non-null
+func (p *gqlParser) typeRef() (bool, error) {
+	if p.punct("[") {
+		if _, err := p.typeRef(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	return p.punct("!"), nil
+}
+
+func (p *gqlParser) fragmentDefinition() (*fragment, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if on, err := p.name(); err != nil || on != "on" {
+		return nil, fmt.Errorf("line %d: expected \"on\" after fragment %s", p.peek().line, name)
+	}
+	typeCondition, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.noDirectives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeCondition: typeCondition, selections: sels}, nil
+}
+
+func (p *gqlParser) noDirectives() error {
+	if t := p.peek(); t.kind == tokPunct && t.text == "@" {
+		return fmt.Errorf("line %d: directives are not supported", t.line)
+	}
+	return nil
+}
+
+func (p *gqlParser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.punct("}") {
+		if p.peek().kind == tokEOF {
+			return nil, fmt.Errorf("line %d: unterminated selection set", p.peek().line)
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("line %d: empty selection set", p.peek().line)
+	}
+	return sels, nil
+}
+
+func (p *gqlParser) selection() (selection, error) {
+	if p.punct("...") {
+		t := p.peek()
+		if t.kind == tokName && t.text != "on" {
+			p.next()
+			return selection{spread: t.text}, p.noDirectives()
+		}
+		frag := &fragment{}
+		if t.kind == tokName {
+			p.next()
+			typeCondition, err := p.name()
+			if err != nil {
+				return selection{}, err
+			}
+			frag.typeCondition = typeCondition
+		}
+		if err := p.noDirectives(); err != nil {
+			return selection{}, err
+		}
+		sels, err := p.selectionSet()
+		if err != nil {
+			return selection{}, err
+		}
+		frag.selections = sels
+		return selection{inline: frag}, nil
+	}
+
+	line := p.peek().line
+	name, err := p.name()
+	if err != nil {
+		return selection{}, err
+	}
+	f := &field{name: name, line: line}
+	if p.punct(":") {
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return selection{}, err
+		}
+	}
+	if p.punct("(") {
+		for !p.punct(")") {
+			argName, err := p.name()
+			if err != nil {
+				return selection{}, err
+			}
+			if err := p.expect(":"); err != nil {
+				return selection{}, err
+			}
+			v, err := p.value(false)
+			if err != nil {
+				return selection{}, err
+			}
+			f.args = append(f.args, argument{name: argName, value: v})
+		}
+	}
+	if err := p.noDirectives(); err != nil {
+		return selection{}, err
+	}
+	if t := p.peek(); t.kind == tokPunct && t.text == "{" {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return selection{}, err
+		}
+	}
+	return selection{field: f}, nil
+}
+
+// value reads an argument or default value; constants may not reference variables
+func (p *gqlParser) value(constant bool) (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString:
+		return t.text, nil
+	case tokInt:
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid integer %s", t.line, t.text)
+		}
+		return n, nil
+	case tokFloat:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid number %s", t.line, t.text)
+		}
+		return f, nil
+	case tokName:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(t.text), nil
+	case tokPunct:
+		switch t.text {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("line %d: variables are not allowed here", t.line)
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return variableRef(name), nil
+		case "[":
+			list := []interface{}{}
+			for !p.punct("]") {
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, nil
+		case "{":
+			obj := map[string]interface{}{}
+			for !p.punct("}") {
+				key, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if obj[key], err = p.value(constant); err != nil {
+					return nil, err
+				}
+			}
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("line %d: expected a value, found %s", t.line, describe(t))
+}
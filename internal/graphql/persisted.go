@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxPersistedQueries bounds how many queries clients may register
+const maxPersistedQueries = 10000
+
+// PersistedQueries maps SHA-256 hashes to query documents. Clients send the
+// hash instead of the query (Apollo's automatic persisted queries); unknown
+// hashes are registered when the client retries with the full query, unless
+// only preloaded queries are allowed.
+type PersistedQueries struct {
+	mu      sync.RWMutex
+	queries map[string]string
+	locked  bool // Only preloaded queries run; nothing is registered
+}
+
+func NewPersistedQueries() *PersistedQueries {
+	return &PersistedQueries{queries: make(map[string]string)}
+}
+
+// LoadPersistedQueries preloads every .graphql file in dir. With locked,
+// queries outside that set are refused, so production clients can only run
+// reviewed queries.
+func LoadPersistedQueries(dir string, locked bool) (*PersistedQueries, error) {
+	p := NewPersistedQueries()
+	p.locked = locked
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.graphql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persisted queries: %w", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read persisted query: %w", err)
+		}
+		query := string(data)
+		if _, err := parse(query); err != nil {
+			return nil, fmt.Errorf("persisted query %s: %w", filepath.Base(file), err)
+		}
+		p.queries[Hash(query)] = query
+	}
+	return p, nil
+}
+
+// Hash is the hex SHA-256 of a query, as clients compute it
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Resolve returns the query to run for a request carrying a hash, a query or
+// both, registering new queries where allowed
+func (p *PersistedQueries) Resolve(hash, query string) (string, error) {
+	hash = strings.ToLower(hash)
+	if hash == "" {
+		if p.locked {
+			if _, ok := p.lookup(Hash(query)); !ok {
+				return "", errNotAllowed
+			}
+		}
+		return query, nil
+	}
+
+	if query == "" {
+		stored, ok := p.lookup(hash)
+		if !ok {
+			return "", errNotFound
+		}
+		return stored, nil
+	}
+
+	if Hash(query) != hash {
+		return "", fmt.Errorf("provided sha256Hash does not match query")
+	}
+	if _, ok := p.lookup(hash); ok {
+		return query, nil
+	}
+	if p.locked {
+		return "", errNotAllowed
+	}
+
+	p.mu.Lock()
+	if len(p.queries) < maxPersistedQueries {
+		p.queries[hash] = query
+	}
+	p.mu.Unlock()
+	return query, nil
+}
+
+func (p *PersistedQueries) lookup(hash string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	query, ok := p.queries[hash]
+	return query, ok
+}
+
+// Len returns how many queries are persisted
+func (p *PersistedQueries) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.queries)
+}
+
+// persistedError carries the code Apollo clients look for
+type persistedError struct {
+	message string
+	code    string
+}
+
+func (e persistedError) Error() string {
+	return e.message
+}
+
+var (
+	errNotFound   = persistedError{"PersistedQueryNotFound", "PERSISTED_QUERY_NOT_FOUND"}
+	errNotAllowed = persistedError{"Only persisted queries are allowed", "PERSISTED_QUERY_NOT_ALLOWED"}
+)
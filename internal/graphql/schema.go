@@ -0,0 +1,485 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/catalog"
+	"github.com/Askeban/llm-router-go/internal/ingest"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/usage"
+)
+
+const (
+	// defaultRangeDays is the usage window when from and to are omitted
+	defaultRangeDays = 30
+	// maxRangeDays bounds usage queries so they stay on the date index
+	maxRangeDays = 366
+)
+
+// Catalog supplies the live model catalog
+type Catalog interface {
+	GetAllModels() []models.EnhancedModel
+	GetModelByID(id string) (models.EnhancedModel, bool)
+}
+
+// UsageSource aggregates tenants' recorded usage
+type UsageSource interface {
+	Timeseries(ctx context.Context, userID, granularity string, from, to time.Time) ([]usage.PeriodUsage, error)
+	ByModel(ctx context.Context, userID string, from, to time.Time) ([]usage.ModelUsage, error)
+}
+
+// MetricsSource loads the stored metrics of many models at once
+type MetricsSource interface {
+	MetricsFor(ctx context.Context, modelIDs []string) (map[string][]ingest.Metric, error)
+}
+
+// viewer is the authenticated caller
+type viewer struct {
+	id, email, plan string
+}
+
+// NewSchema builds the router's schema: the model catalog with benchmark and
+// stored metrics, and usage for the caller or, for admins, any user. usage
+// and metrics may be nil when the router runs without a database.
+func NewSchema(cat Catalog, usageSource UsageSource, metrics MetricsSource) *Schema {
+	count := &Object{Name: "Count", Fields: map[string]*Field{
+		"name":     prop("String!", func(s interface{}) interface{} { return s.(usage.Count).Name }),
+		"requests": prop("Int!", func(s interface{}) interface{} { return s.(usage.Count).Requests }),
+	}}
+
+	period := &Object{Name: "UsagePeriod", Description: "Usage in one day or week", Fields: map[string]*Field{
+		"period":         prop("String!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).Period }),
+		"requests":       prop("Int!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).Requests }),
+		"tokens":         prop("Int!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).Tokens }),
+		"costUsd":        prop("Float!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).CostUSD }),
+		"errors":         prop("Int!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).Errors }),
+		"duplicates":     prop("Int!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).Duplicates }),
+		"nearDuplicates": prop("Int!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).NearDuplicate }),
+		"duplicateRate":  prop("Float!", func(s interface{}) interface{} { return s.(usage.PeriodUsage).DuplicateRate }),
+		"topCategories":  objectProp("[Count!]!", count, func(s interface{}) interface{} { return s.(usage.PeriodUsage).TopCategories }),
+		"topModels":      objectProp("[Count!]!", count, func(s interface{}) interface{} { return s.(usage.PeriodUsage).TopModels }),
+	}}
+
+	model := &Object{Name: "Model", Fields: map[string]*Field{}}
+
+	modelUsage := &Object{Name: "ModelUsage", Description: "Usage of one recommended model", Fields: map[string]*Field{
+		"model":         prop("String!", func(s interface{}) interface{} { return s.(usage.ModelUsage).Model }),
+		"requests":      prop("Int!", func(s interface{}) interface{} { return s.(usage.ModelUsage).Requests }),
+		"tokens":        prop("Int!", func(s interface{}) interface{} { return s.(usage.ModelUsage).Tokens }),
+		"costUsd":       prop("Float!", func(s interface{}) interface{} { return s.(usage.ModelUsage).CostUSD }),
+		"avgResponseMs": prop("Float!", func(s interface{}) interface{} { return s.(usage.ModelUsage).AvgResponseMs }),
+		"share":         prop("Float!", func(s interface{}) interface{} { return s.(usage.ModelUsage).Share }),
+		"catalogModel": {
+			Type:        "Model",
+			Description: "The model's catalog entry, if it is still listed",
+			Object:      model,
+			Resolve: func(r *Request, s interface{}, _ map[string]interface{}) (interface{}, error) {
+				if m, ok := cat.GetModelByID(s.(usage.ModelUsage).Model); ok {
+					return m, nil
+				}
+				return nil, nil
+			},
+		},
+	}}
+
+	usageArgs := map[string]string{"from": "String", "to": "String", "period": "String"}
+	rangeArgs := map[string]string{"from": "String", "to": "String"}
+
+	timeseries := func(r *Request, userID string, args map[string]interface{}) (interface{}, error) {
+		if usageSource == nil {
+			return nil, fmt.Errorf("usage is not available")
+		}
+		from, to, err := dateRange(args)
+		if err != nil {
+			return nil, err
+		}
+		return usageSource.Timeseries(r.Context, userID, stringArg(args, "period"), from, to)
+	}
+
+	// byModel loads the user's usage per model once per request and range
+	byModel := func(r *Request, userID string, args map[string]interface{}) ([]usage.ModelUsage, error) {
+		if usageSource == nil {
+			return nil, fmt.Errorf("usage is not available")
+		}
+		from, to, err := dateRange(args)
+		if err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("usage_by_model:%s:%s:%s", userID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+		v, err := r.Load(key, func() (interface{}, error) {
+			return usageSource.ByModel(r.Context, userID, from, to)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return v.([]usage.ModelUsage), nil
+	}
+
+	capability := &Object{Name: "Capability", Fields: map[string]*Field{
+		"name":       prop("String!", func(s interface{}) interface{} { return s.(namedCapability).name }),
+		"score":      prop("Float!", func(s interface{}) interface{} { return s.(namedCapability).Score }),
+		"confidence": prop("Float!", func(s interface{}) interface{} { return s.(namedCapability).Confidence }),
+	}}
+	benchmark := &Object{Name: "Benchmark", Description: "A catalog benchmark score", Fields: map[string]*Field{
+		"name":  prop("String!", func(s interface{}) interface{} { return s.(namedScore).name }),
+		"value": prop("Float!", func(s interface{}) interface{} { return s.(namedScore).value }),
+	}}
+	metric := &Object{Name: "Metric", Description: "A stored metric from Analytics AI or eval runs", Fields: map[string]*Field{
+		"source":     prop("String!", func(s interface{}) interface{} { return s.(ingest.Metric).Source }),
+		"metric":     prop("String!", func(s interface{}) interface{} { return s.(ingest.Metric).Metric }),
+		"value":      prop("Float!", func(s interface{}) interface{} { return s.(ingest.Metric).Value }),
+		"observedAt": prop("String!", func(s interface{}) interface{} { return s.(ingest.Metric).ObservedAt.Format(time.RFC3339) }),
+	}}
+	features := &Object{Name: "Features", Fields: map[string]*Field{
+		"toolUse":              featureProp(models.FeatureToolUse),
+		"visionInput":          featureProp(models.FeatureVisionInput),
+		"promptCaching":        featureProp(models.FeaturePromptCaching),
+		"systemPromptSupport":  featureProp(models.FeatureSystemPrompt),
+		"maxParallelToolCalls": prop("Int", func(s interface{}) interface{} { return nullInt(s.(*models.ModelFeatures).MaxParallelToolCalls) }),
+	}}
+	status := &Object{Name: "ModelStatus", Description: "The provider incident affecting a model", Fields: map[string]*Field{
+		"state":    prop("String!", func(s interface{}) interface{} { return s.(*models.ModelStatus).State }),
+		"impact":   prop("String!", func(s interface{}) interface{} { return s.(*models.ModelStatus).Impact }),
+		"incident": prop("String!", func(s interface{}) interface{} { return s.(*models.ModelStatus).Incident }),
+		"url":      prop("String", func(s interface{}) interface{} { return nullString(s.(*models.ModelStatus).URL) }),
+		"since":    prop("String!", func(s interface{}) interface{} { return s.(*models.ModelStatus).Since.Format(time.RFC3339) }),
+	}}
+	endpoint := &Object{Name: "Endpoint", Description: "A regional deployment of a model's API", Fields: map[string]*Field{
+		"name":     prop("String!", func(s interface{}) interface{} { return s.(models.ModelEndpoint).Name }),
+		"region":   prop("String", func(s interface{}) interface{} { return nullString(s.(models.ModelEndpoint).Region) }),
+		"url":      prop("String!", func(s interface{}) interface{} { return s.(models.ModelEndpoint).URL }),
+		"priority": prop("Int!", func(s interface{}) interface{} { return s.(models.ModelEndpoint).Priority }),
+	}}
+
+	m := func(s interface{}) models.EnhancedModel { return s.(models.EnhancedModel) }
+	model.Fields = map[string]*Field{
+		"id":              prop("String!", func(s interface{}) interface{} { return m(s).ID }),
+		"provider":        prop("String!", func(s interface{}) interface{} { return m(s).Provider }),
+		"name":            prop("String!", func(s interface{}) interface{} { return m(s).DisplayName }),
+		"modelType":       prop("String!", func(s interface{}) interface{} { return m(s).ModelType }),
+		"releaseDate":     prop("String", func(s interface{}) interface{} { return nullString(m(s).ReleaseDate) }),
+		"openSource":      prop("Boolean!", func(s interface{}) interface{} { return m(s).OpenSource }),
+		"contextWindow":   prop("Int", func(s interface{}) interface{} { return nullInt(m(s).TechnicalSpecs.ContextWindow) }),
+		"confidence":      prop("Float!", func(s interface{}) interface{} { return m(s).ConfidenceScore }),
+		"tags":            prop("[String!]!", func(s interface{}) interface{} { return nonNil(m(s).Tags) }),
+		"inputModalities": prop("[String!]!", func(s interface{}) interface{} { return nonNil(m(s).InputModalities) }),
+		"costInPer1k": prop("Float", func(s interface{}) interface{} {
+			return firstCost(m(s).Pricing.Text.CostInPer1K, m(s).Pricing.CostInPer1K)
+		}),
+		"costOutPer1k": prop("Float", func(s interface{}) interface{} {
+			return firstCost(m(s).Pricing.Text.CostOutPer1K, m(s).Pricing.CostOutPer1K)
+		}),
+		"capabilities": objectProp("[Capability!]!", capability, func(s interface{}) interface{} {
+			caps := []namedCapability{}
+			for name, c := range m(s).TaskCapabilities.TextTasks {
+				caps = append(caps, namedCapability{name: name, TaskCapability: c})
+			}
+			sort.Slice(caps, func(i, j int) bool { return caps[i].name < caps[j].name })
+			return caps
+		}),
+		"benchmarks": objectProp("[Benchmark!]!", benchmark, func(s interface{}) interface{} {
+			scores := []namedScore{}
+			for name, v := range m(s).Benchmarks.Text {
+				scores = append(scores, namedScore{name: name, value: v})
+			}
+			sort.Slice(scores, func(i, j int) bool { return scores[i].name < scores[j].name })
+			return scores
+		}),
+		"features": objectProp("Features", features, func(s interface{}) interface{} {
+			if f := m(s).Features; f != nil {
+				return f
+			}
+			return nil
+		}),
+		"status": objectProp("ModelStatus", status, func(s interface{}) interface{} {
+			if st := m(s).Status; st != nil {
+				return st
+			}
+			return nil
+		}),
+		"inRotation": prop("Boolean!", func(s interface{}) interface{} {
+			held, _ := m(s).Unavailable(time.Now())
+			return !held
+		}),
+		"heldReason": prop("String", func(s interface{}) interface{} {
+			_, reason := m(s).Unavailable(time.Now())
+			return nullString(reason)
+		}),
+		"metrics": {
+			Type:        "[Metric!]!",
+			Description: "Stored metrics, optionally from one source",
+			Args:        map[string]string{"source": "String"},
+			Object:      metric,
+			Batch: func(r *Request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				if metrics == nil {
+					return nil, fmt.Errorf("stored metrics are not available")
+				}
+				ids := make([]string, len(sources))
+				for i, s := range sources {
+					ids[i] = m(s).ID
+				}
+				loaded, err := metrics.MetricsFor(r.Context, ids)
+				if err != nil {
+					return nil, err
+				}
+				source := stringArg(args, "source")
+				out := make([]interface{}, len(sources))
+				for i, id := range ids {
+					list := []ingest.Metric{}
+					for _, metric := range loaded[id] {
+						if source == "" || metric.Source == source {
+							list = append(list, metric)
+						}
+					}
+					out[i] = list
+				}
+				return out, nil
+			},
+		},
+		"usage": {
+			Type:        "ModelUsage",
+			Description: "The caller's usage of the model",
+			Args:        rangeArgs,
+			Object:      modelUsage,
+			Access:      AccessUser,
+			Batch: func(r *Request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				all, err := byModel(r, r.UserID, args)
+				if err != nil {
+					return nil, err
+				}
+				byID := make(map[string]usage.ModelUsage, len(all))
+				for _, u := range all {
+					byID[u.Model] = u
+				}
+				out := make([]interface{}, len(sources))
+				for i, s := range sources {
+					if u, ok := byID[m(s).ID]; ok {
+						out[i] = u
+					}
+				}
+				return out, nil
+			},
+		},
+		"endpoints": objectProp("[Endpoint!]!", endpoint, func(s interface{}) interface{} { return nonNilEndpoints(m(s).Endpoints) }),
+	}
+	model.Fields["endpoints"].Access = AccessAdmin
+
+	viewerType := &Object{Name: "Viewer", Description: "The authenticated caller", Fields: map[string]*Field{
+		"id":    prop("String!", func(s interface{}) interface{} { return s.(viewer).id }),
+		"email": prop("String", func(s interface{}) interface{} { return nullString(s.(viewer).email) }),
+		"plan":  prop("String", func(s interface{}) interface{} { return nullString(s.(viewer).plan) }),
+		"usage": {
+			Type:        "[UsagePeriod!]!",
+			Description: "Usage per day or week (period: day or week)",
+			Args:        usageArgs,
+			Object:      period,
+			Resolve: func(r *Request, s interface{}, args map[string]interface{}) (interface{}, error) {
+				return timeseries(r, s.(viewer).id, args)
+			},
+		},
+		"usageByModel": {
+			Type:   "[ModelUsage!]!",
+			Args:   rangeArgs,
+			Object: modelUsage,
+			Resolve: func(r *Request, s interface{}, args map[string]interface{}) (interface{}, error) {
+				return byModel(r, s.(viewer).id, args)
+			},
+		},
+	}}
+
+	query := &Object{Name: "Query", Fields: map[string]*Field{
+		"models": {
+			Type:        "[Model!]!",
+			Description: "Catalog models, optionally matching a catalog query such as 'open_source = true AND coding > 0.8'",
+			Args:        map[string]string{"filter": "String", "provider": "String", "modelType": "String", "first": "Int"},
+			Object:      model,
+			Resolve: func(r *Request, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				list := cat.GetAllModels()
+				if filter := stringArg(args, "filter"); filter != "" {
+					q, err := catalog.Parse(filter)
+					if err != nil {
+						return nil, fmt.Errorf("invalid filter: %w", err)
+					}
+					list = q.Filter(list)
+				}
+				provider, modelType := stringArg(args, "provider"), stringArg(args, "modelType")
+				out := make([]models.EnhancedModel, 0, len(list))
+				for _, model := range list {
+					if (provider == "" || strings.EqualFold(model.Provider, provider)) && (modelType == "" || model.ModelType == modelType) {
+						out = append(out, model)
+					}
+				}
+				sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+				if first, ok := intArg(args, "first"); ok && first >= 0 && first < len(out) {
+					out = out[:first]
+				}
+				return out, nil
+			},
+		},
+		"model": {
+			Type:   "Model",
+			Args:   map[string]string{"id": "String!"},
+			Object: model,
+			Resolve: func(r *Request, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				if m, ok := cat.GetModelByID(stringArg(args, "id")); ok {
+					return m, nil
+				}
+				return nil, nil
+			},
+		},
+		"viewer": {
+			Type:   "Viewer",
+			Object: viewerType,
+			Access: AccessUser,
+			Resolve: func(r *Request, _ interface{}, _ map[string]interface{}) (interface{}, error) {
+				if r.UserID == "" {
+					return nil, nil
+				}
+				return viewer{id: r.UserID, email: r.Email, plan: r.Plan}, nil
+			},
+		},
+		"userUsage": {
+			Type:        "[UsagePeriod!]!",
+			Description: "Any user's usage per day or week",
+			Args:        map[string]string{"userId": "String!", "from": "String", "to": "String", "period": "String"},
+			Object:      period,
+			Access:      AccessAdmin,
+			Resolve: func(r *Request, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				return timeseries(r, stringArg(args, "userId"), args)
+			},
+		},
+		"userUsageByModel": {
+			Type:   "[ModelUsage!]!",
+			Args:   map[string]string{"userId": "String!", "from": "String", "to": "String"},
+			Object: modelUsage,
+			Access: AccessAdmin,
+			Resolve: func(r *Request, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				return byModel(r, stringArg(args, "userId"), args)
+			},
+		},
+	}}
+
+	return &Schema{Query: query}
+}
+
+type namedCapability struct {
+	name string
+	models.TaskCapability
+}
+
+type namedScore struct {
+	name  string
+	value float64
+}
+
+// prop is a scalar field read straight off its source
+func prop(typ string, get func(source interface{}) interface{}) *Field {
+	return &Field{
+		Type: typ,
+		Resolve: func(_ *Request, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			return get(source), nil
+		},
+	}
+}
+
+// objectProp is an object field read straight off its source
+func objectProp(typ string, obj *Object, get func(source interface{}) interface{}) *Field {
+	f := prop(typ, get)
+	f.Object = obj
+	return f
+}
+
+func featureProp(feature string) *Field {
+	return prop("Boolean", func(s interface{}) interface{} {
+		if supported, known := s.(*models.ModelFeatures).Supports(feature); known {
+			return supported
+		}
+		return nil
+	})
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullInt(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+func nonNilEndpoints(list []models.ModelEndpoint) []models.ModelEndpoint {
+	if list == nil {
+		return []models.ModelEndpoint{}
+	}
+	return list
+}
+
+// firstCost prefers the structured text pricing over the legacy flat fields
+func firstCost(primary, legacy *float64) interface{} {
+	if primary != nil {
+		return *primary
+	}
+	if legacy != nil {
+		return *legacy
+	}
+	return nil
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+// intArg reads an integer literal or a JSON number variable
+func intArg(args map[string]interface{}, name string) (int, bool) {
+	switch n := args[name].(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// dateRange parses from and to (YYYY-MM-DD), defaulting to the last 30 days
+func dateRange(args map[string]interface{}) (time.Time, time.Time, error) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if s := stringArg(args, "to"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date: %s", s)
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(defaultRangeDays - 1))
+	if s := stringArg(args, "from"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from date: %s", s)
+		}
+		from = t
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) > maxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range exceeds %d days", maxRangeDays)
+	}
+	return from, to, nil
+}
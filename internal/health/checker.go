@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Dependency statuses, from best to worst
+const (
+	StatusOK       = "ok"
+	StatusDisabled = "disabled" // Not configured on this deployment
+	StatusDegraded = "degraded" // Working with reduced function, e.g. a fallback
+	StatusDown     = "down"
+)
+
+// defaultTimeout bounds a check that sets no timeout of its own
+const defaultTimeout = 2 * time.Second
+
+// Result is one dependency's health
+type Result struct {
+	Status    string      `json:"status"`
+	Message   string      `json:"message,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+	Critical  bool        `json:"critical"` // Down means the replica is not ready
+	LatencyMs float64     `json:"latency_ms"`
+	CheckedAt time.Time   `json:"checked_at"`
+	Cached    bool        `json:"cached,omitempty"`
+}
+
+// OK, Degraded, Down and Disabled build results for checks
+func OK(message string) Result       { return Result{Status: StatusOK, Message: message} }
+func Degraded(message string) Result { return Result{Status: StatusDegraded, Message: message} }
+func Down(message string) Result     { return Result{Status: StatusDown, Message: message} }
+func Disabled(message string) Result { return Result{Status: StatusDisabled, Message: message} }
+
+// Check is one dependency check
+type Check struct {
+	Name     string
+	Critical bool          // Down fails readiness; other checks only degrade it
+	Timeout  time.Duration // Bounds each run
+	CacheTTL time.Duration // Reuse the last result this long, for checks that call out
+	Run      func(ctx context.Context) Result
+}
+
+// Report is every dependency's health and the replica's readiness
+type Report struct {
+	Status    string            `json:"status"` // ok, degraded or down
+	Ready     bool              `json:"ready"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+// Checker runs the registered dependency checks
+type Checker struct {
+	mu     sync.Mutex
+	checks []Check
+	cache  map[string]Result
+}
+
+func NewChecker() *Checker {
+	return &Checker{cache: make(map[string]Result)}
+}
+
+// Register adds a check; a check registered twice replaces the first
+func (c *Checker) Register(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.checks {
+		if c.checks[i].Name == check.Name {
+			c.checks[i] = check
+			delete(c.cache, check.Name)
+			return
+		}
+	}
+	c.checks = append(c.checks, check)
+}
+
+// Run runs every check concurrently, each within its timeout. The replica is
+// ready unless a critical check is down.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]Check(nil), c.checks...)
+	c.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Ready: true, Checks: make(map[string]Result, len(checks)), CheckedAt: time.Now()}
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.Name] = result
+		switch {
+		case result.Status == StatusDown && check.Critical:
+			report.Status, report.Ready = StatusDown, false
+		case result.Status == StatusDown || result.Status == StatusDegraded:
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+	return report
+}
+
+// run runs one check, reusing its cached result while fresh
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	if check.CacheTTL > 0 {
+		c.mu.Lock()
+		cached, ok := c.cache[check.Name]
+		c.mu.Unlock()
+		if ok && time.Since(cached.CheckedAt) < check.CacheTTL {
+			cached.Cached = true
+			return cached
+		}
+	}
+
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- Down("check panicked")
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Down("check timed out after " + timeout.String())
+	}
+	result.Critical = check.Critical
+	result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	result.CheckedAt = time.Now()
+
+	if check.CacheTTL > 0 {
+		c.mu.Lock()
+		c.cache[check.Name] = result
+		c.mu.Unlock()
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// analyticsCacheTTL spaces out Analytics AI probes, which call a metered API
+const analyticsCacheTTL = 5 * time.Minute
+
+// Pinger is a dependency that can be pinged
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Catalog is the fused model catalog
+type Catalog interface {
+	GetStats() map[string]interface{}
+	LastFusion() time.Time
+}
+
+// Postgres checks the database; the router cannot authenticate callers
+// without it
+func Postgres(db *sql.DB) Check {
+	return Check{
+		Name:     "postgres",
+		Critical: true,
+		Run: func(ctx context.Context) Result {
+			if err := db.PingContext(ctx); err != nil {
+				return Down(err.Error())
+			}
+			stats := db.Stats()
+			result := OK("connected")
+			result.Details = map[string]int{
+				"open_connections": stats.OpenConnections,
+				"in_use":           stats.InUse,
+				"idle":             stats.Idle,
+			}
+			return result
+		},
+	}
+}
+
+// Redis checks the Redis behind the shared concurrency limits. Without Redis
+// limits are counted per replica, and when it is down acquiring a slot fails
+// open, so neither fails readiness.
+func Redis(client Pinger, configured bool) Check {
+	return Check{
+		Name: "redis",
+		Run: func(ctx context.Context) Result {
+			if !configured {
+				return Disabled("not configured, concurrency limits apply per replica")
+			}
+			if err := client.Ping(ctx); err != nil {
+				return Down(err.Error())
+			}
+			return OK("connected")
+		},
+	}
+}
+
+// AnalyticsAI checks Analytics AI, which refreshes the catalog; the router
+// keeps serving the last fused catalog while it is unreachable
+func AnalyticsAI(ping func(ctx context.Context) error) Check {
+	return Check{
+		Name:     "analytics_ai",
+		Timeout:  5 * time.Second,
+		CacheTTL: analyticsCacheTTL,
+		Run: func(ctx context.Context) Result {
+			if err := ping(ctx); err != nil {
+				return Degraded(err.Error())
+			}
+			return OK("reachable")
+		},
+	}
+}
+
+// CatalogFreshness checks that models are loaded and were fused within
+// maxAge. An empty catalog cannot route; a stale one still can.
+func CatalogFreshness(catalog Catalog, maxAge time.Duration) Check {
+	return Check{
+		Name:     "catalog",
+		Critical: true,
+		Run: func(ctx context.Context) Result {
+			stats := catalog.GetStats()
+			total, _ := stats["total_models"].(int)
+			if total == 0 {
+				return Down("no models loaded")
+			}
+
+			lastFusion := catalog.LastFusion()
+			details := map[string]interface{}{
+				"total_models": total,
+				"max_age":      maxAge.String(),
+			}
+			if lastFusion.IsZero() {
+				result := Degraded("catalog has never been fused")
+				result.Details = details
+				return result
+			}
+			age := time.Since(lastFusion)
+			details["last_fusion"] = lastFusion
+			details["age"] = age.Round(time.Second).String()
+
+			result := OK(fmt.Sprintf("%d models", total))
+			if age > maxAge {
+				result = Degraded(fmt.Sprintf("catalog last fused %s ago", age.Round(time.Minute)))
+			}
+			result.Details = details
+			return result
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// staleIntervals is how many missed intervals make a job stale
+const staleIntervals = 3
+
+// job is one background loop's liveness
+type job struct {
+	interval   time.Duration
+	registered time.Time
+	lastBeat   time.Time
+	beats      int64
+}
+
+// JobStatus is a background job's liveness as reported by /readyz
+type JobStatus struct {
+	Interval string     `json:"interval"`
+	LastBeat *time.Time `json:"last_beat,omitempty"`
+	Beats    int64      `json:"beats"`
+	Stale    bool       `json:"stale"`
+}
+
+// Jobs tracks heartbeats from the background loops
+type Jobs struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+func NewJobs() *Jobs {
+	return &Jobs{jobs: make(map[string]*job)}
+}
+
+type jobKey struct{}
+
+// jobBeat is carried on a job's context so its loop can beat without knowing
+// its name
+type jobBeat struct {
+	jobs *Jobs
+	name string
+}
+
+// Context registers a job that runs every interval and returns a context for
+// its loop; the loop calls Beat with it after each run
+func (j *Jobs) Context(ctx context.Context, name string, interval time.Duration) context.Context {
+	j.mu.Lock()
+	j.jobs[name] = &job{interval: interval, registered: time.Now()}
+	j.mu.Unlock()
+	return context.WithValue(ctx, jobKey{}, jobBeat{jobs: j, name: name})
+}
+
+// Beat records that the job whose context this is completed a run. It is a
+// no-op on contexts that don't belong to a job.
+func Beat(ctx context.Context) {
+	b, ok := ctx.Value(jobKey{}).(jobBeat)
+	if !ok {
+		return
+	}
+	b.jobs.mu.Lock()
+	defer b.jobs.mu.Unlock()
+	if jb, ok := b.jobs.jobs[b.name]; ok {
+		jb.lastBeat = time.Now()
+		jb.beats++
+	}
+}
+
+// Status returns each job's liveness
+func (j *Jobs) Status() map[string]JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	statuses := make(map[string]JobStatus, len(j.jobs))
+	for name, jb := range j.jobs {
+		since := jb.registered
+		status := JobStatus{Interval: jb.interval.String(), Beats: jb.beats}
+		if !jb.lastBeat.IsZero() {
+			last := jb.lastBeat
+			status.LastBeat = &last
+			since = last
+		}
+		status.Stale = now.Sub(since) > staleIntervals*jb.interval
+		statuses[name] = status
+	}
+	return statuses
+}
+
+// Check reports the background jobs; a stale job degrades the replica but
+// doesn't fail readiness, since requests are still served
+func (j *Jobs) Check() Check {
+	return Check{
+		Name: "background_jobs",
+		Run: func(ctx context.Context) Result {
+			statuses := j.Status()
+			if len(statuses) == 0 {
+				return Disabled("no background jobs registered")
+			}
+			var stale []string
+			for name, status := range statuses {
+				if status.Stale {
+					stale = append(stale, name)
+				}
+			}
+			result := OK(fmt.Sprintf("%d jobs running", len(statuses)))
+			if len(stale) > 0 {
+				sort.Strings(stale)
+				result = Degraded(fmt.Sprintf("stale jobs: %v", stale))
+			}
+			result.Details = statuses
+			return result
+		},
+	}
+}
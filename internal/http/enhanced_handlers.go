@@ -5,13 +5,19 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/Askeban/llm-router-go/internal/catalog"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/overlay"
 	"github.com/Askeban/llm-router-go/internal/recommendation"
 	"github.com/Askeban/llm-router-go/internal/services"
+	"github.com/Askeban/llm-router-go/internal/status"
+	"github.com/Askeban/llm-router-go/internal/usage"
 )
 
 // EnhancedHandlers provides HTTP handlers for the enhanced router service
 type EnhancedHandlers struct {
 	routerService *services.EnhancedRouterService
+	statusMonitor *status.Monitor
 }
 
 func NewEnhancedHandlers(routerService *services.EnhancedRouterService) *EnhancedHandlers {
@@ -20,6 +26,11 @@ func NewEnhancedHandlers(routerService *services.EnhancedRouterService) *Enhance
 	}
 }
 
+// SetStatusMonitor adds provider incidents to the status endpoint
+func (h *EnhancedHandlers) SetStatusMonitor(monitor *status.Monitor) {
+	h.statusMonitor = monitor
+}
+
 // SetupEnhancedRoutes sets up all the enhanced router endpoints
 func (h *EnhancedHandlers) SetupEnhancedRoutes(r *gin.Engine) {
 	// Enhanced recommendation endpoints
@@ -31,6 +42,9 @@ func (h *EnhancedHandlers) SetupEnhancedRoutes(r *gin.Engine) {
 		// Direct recommendation - with explicit parameters
 		api.POST("/recommend/direct", h.getDirectRecommendations)
 		
+		// What-if comparison of priorities for one prompt
+		api.POST("/simulate", h.simulatePriorities)
+		
 		// Classification testing
 		api.POST("/classify", h.classifyPrompt)
 		
@@ -38,6 +52,8 @@ func (h *EnhancedHandlers) SetupEnhancedRoutes(r *gin.Engine) {
 		api.GET("/models", h.getAllModels)
 		api.GET("/models/:id", h.getModelById)
 		api.GET("/models/type/:type", h.getModelsByType)
+		api.POST("/models/search", h.searchModels)
+		api.POST("/models/compare", h.compareModels)
 		
 		// Service information
 		api.GET("/stats", h.getServiceStats)
@@ -67,7 +83,121 @@ func (h *EnhancedHandlers) getSmartRecommendations(c *gin.Context) {
 		return
 	}
 
-	response := h.routerService.GetSmartRecommendations(req)
+	if !recommendation.ValidReasoningEffort(req.ReasoningEffort) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid reasoning_effort",
+			"details": "must be one of none, minimal, low, medium, high",
+		})
+		return
+	}
+
+	if err := recommendation.ValidateLimits(req.MaxResults, req.MinScore); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid result limits",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := recommendation.ValidateRAG(req.RAG); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid rag hint",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := recommendation.ValidateLatencyTolerance(req.LatencyTolerance, req.MaxLatencyMs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid latency tolerance",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := models.ValidateFeatureRequirements(req.Requirements); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid requirements",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := recommendation.ValidateTags(req.PreferredTags, req.AvoidedTags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tags",
+			"details": err.Error(),
+		})
+		return
+	}
+	outputLocale, err := recommendation.ValidateOutputLocale(req.OutputLocale)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid output locale",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.OutputLocale = outputLocale
+	if err := recommendation.ValidateAttachments(req.Attachments); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid attachments",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := h.routerService.ValidateOverrides(req.ClassificationOverrides); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid classification overrides",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := usage.AddTags(c, req.UsageTags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid usage tags",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Authenticated callers are always evaluated under their own tenant policies
+	if userID := c.GetString("user_id"); userID != "" {
+		req.UserID = userID
+	}
+	req.Plan = c.GetString("user_plan")
+
+	response := h.routerService.GetSmartRecommendations(c.Request.Context(), req)
+
+	if response.Safety != nil && response.Safety.Blocked() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "Prompt blocked by content safety policy",
+			"safety": response.Safety,
+		})
+		return
+	}
+
+	// Attribute this request for usage analytics
+	c.Set(usage.ContextCategory, response.Classification.Category)
+	c.Set(usage.ContextTokens, len(req.Prompt)/4)
+	if recs := response.Recommendations.Recommendations; len(recs) > 0 {
+		c.Set(usage.ContextModel, recs[0].Model.ID)
+		c.Set(usage.ContextCost, recs[0].CostEstimate)
+	}
+	metadata := map[string]interface{}{}
+	// Keep the classifier's answer next to the override for calibration analysis
+	if classifier := response.ClassifierOutput; classifier != nil {
+		metadata["overridden_fields"] = response.OverriddenFields
+		metadata["classifier"] = gin.H{
+				"task_type":  classifier.TaskType,
+				"category":   classifier.Category,
+				"complexity": classifier.Complexity,
+				"confidence": classifier.Confidence,
+		}
+	}
+	if response.Memo != nil {
+		metadata["memo_hit"] = true
+		metadata["memo_similarity"] = response.Memo.Similarity
+	}
+	if len(metadata) > 0 {
+		c.Set(usage.ContextMetadata, metadata)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -86,6 +216,14 @@ func (h *EnhancedHandlers) getDirectRecommendations(c *gin.Context) {
 		return
 	}
 
+	if !recommendation.ValidReasoningEffort(req.ReasoningEffort) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid reasoning_effort",
+			"details": "must be one of none, minimal, low, medium, high",
+		})
+		return
+	}
+
 	// Validate required fields
 	if req.TaskType == "" {
 		req.TaskType = "text" // default
@@ -93,14 +231,144 @@ func (h *EnhancedHandlers) getDirectRecommendations(c *gin.Context) {
 	if req.Category == "" {
 		req.Category = "writing" // default
 	}
+	req.Category, req.Subcategory = recommendation.SplitCategory(req.Category, req.Subcategory)
+	if err := recommendation.ValidateComplexityScore(req.ComplexityScore); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid complexity score",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.Complexity == "" && req.ComplexityScore > 0 {
+		req.Complexity = recommendation.ComplexityLevel(req.ComplexityScore)
+	}
 	if req.Complexity == "" {
 		req.Complexity = "medium" // default
 	}
 	if req.Priority == "" {
 		req.Priority = "balanced" // default
 	}
+	if err := recommendation.ValidateLimits(req.MaxResults, req.MinScore); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid result limits",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := recommendation.ValidateRAG(req.RAG); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid rag hint",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := recommendation.ValidateLatencyTolerance(req.LatencyTolerance, req.MaxLatencyMs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid latency tolerance",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := models.ValidateFeatureRequirements(req.Requirements); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid requirements",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := recommendation.ValidateTags(req.PreferredTags, req.AvoidedTags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tags",
+			"details": err.Error(),
+		})
+		return
+	}
+	outputLocale, err := recommendation.ValidateOutputLocale(req.OutputLocale)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid output locale",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.OutputLocale = outputLocale
+	if err := recommendation.ValidateImageInputs(req.ImageInputs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid image inputs",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := models.ValidateSubcategory(req.Category, req.Subcategory); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid subcategory",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.PlanMaxResults = recommendation.MaxResultsForPlan(c.GetString("user_plan"))
+
+	catalogOverlay, ok := h.catalogOverlay(c)
+	if !ok {
+		return
+	}
+	req.Overlay = catalogOverlay
+
+	response := h.routerService.GetDirectRecommendations(c.Request.Context(), req)
+
+	c.Set(usage.ContextCategory, req.Category)
+	if len(response.Recommendations) > 0 {
+		c.Set(usage.ContextModel, response.Recommendations[0].Model.ID)
+		c.Set(usage.ContextCost, response.Recommendations[0].CostEstimate)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
 
-	response := h.routerService.GetDirectRecommendations(req)
+// simulatePriorities compares routing cost and quality across priorities
+func (h *EnhancedHandlers) simulatePriorities(c *gin.Context) {
+	var req services.SimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Prompt == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Prompt is required",
+		})
+		return
+	}
+
+	if userID := c.GetString("user_id"); userID != "" {
+		req.UserID = userID
+	}
+
+	response, err := h.routerService.SimulatePriorities(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid simulation request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if response.Safety != nil && response.Safety.Blocked() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "Prompt blocked by content safety policy",
+			"safety": response.Safety,
+		})
+		return
+	}
+
+	c.Set(usage.ContextCategory, response.Classification.Category)
+	c.Set(usage.ContextTokens, len(req.Prompt)/4)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -147,7 +415,11 @@ func (h *EnhancedHandlers) getAllModels(c *gin.Context) {
 		}
 	}
 
-	models := h.routerService.GetAllModels()
+	catalogOverlay, ok := h.catalogOverlay(c)
+	if !ok {
+		return
+	}
+	models := catalogOverlay.Apply(h.routerService.GetAllModels())
 
 	// Apply pagination
 	total := len(models)
@@ -187,8 +459,12 @@ func (h *EnhancedHandlers) getModelById(c *gin.Context) {
 		return
 	}
 
+	catalogOverlay, ok := h.catalogOverlay(c)
+	if !ok {
+		return
+	}
 	model, found := h.routerService.GetModelByID(modelId)
-	if !found {
+	if !found || !catalogOverlay.Visible(model) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Model not found",
 			"id":    modelId,
@@ -198,7 +474,7 @@ func (h *EnhancedHandlers) getModelById(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    model,
+		"data":    catalogOverlay.Annotate(model),
 	})
 }
 
@@ -224,7 +500,11 @@ func (h *EnhancedHandlers) getModelsByType(c *gin.Context) {
 		return
 	}
 
-	models := h.routerService.GetModelsByType(modelType)
+	catalogOverlay, ok := h.catalogOverlay(c)
+	if !ok {
+		return
+	}
+	models := catalogOverlay.Apply(h.routerService.GetModelsByType(modelType))
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -236,6 +516,150 @@ func (h *EnhancedHandlers) getModelsByType(c *gin.Context) {
 	})
 }
 
+// searchModels filters the catalog with the query language
+func (h *EnhancedHandlers) searchModels(c *gin.Context) {
+	var req struct {
+		Query  string `json:"query" binding:"required"`
+		Limit  int    `json:"limit"`
+		Offset int    `json:"offset"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 50
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	models, err := h.routerService.SearchModels(req.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query",
+			"details": err.Error(),
+			"fields":  catalog.Fields(),
+		})
+		return
+	}
+	catalogOverlay, ok := h.catalogOverlay(c)
+	if !ok {
+		return
+	}
+	models = catalogOverlay.Apply(models)
+
+	// Apply pagination; results are ordered by ID
+	total := len(models)
+	if req.Offset >= total {
+		models = nil
+	} else {
+		end := req.Offset + req.Limit
+		if end > total {
+			end = total
+		}
+		models = models[req.Offset:end]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"query":  req.Query,
+			"models": models,
+			"pagination": gin.H{
+				"total":  total,
+				"limit":  req.Limit,
+				"offset": req.Offset,
+				"count":  len(models),
+			},
+		},
+	})
+}
+
+// modelComparison is one model in a comparison, with its support for the
+// requested output locale
+type modelComparison struct {
+	Model         models.EnhancedModel  `json:"model"`
+	LocaleSupport *models.LocaleSupport `json:"locale_support,omitempty"` // Unset when nothing is known
+}
+
+// compareModels returns models side by side, in the order requested, with
+// how well each writes the output locale when one is given
+func (h *EnhancedHandlers) compareModels(c *gin.Context) {
+	var req struct {
+		ModelIDs     []string `json:"model_ids" binding:"required,min=2,max=10"`
+		OutputLocale string   `json:"output_locale"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	outputLocale, err := recommendation.ValidateOutputLocale(req.OutputLocale)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid output locale",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	catalogOverlay, ok := h.catalogOverlay(c)
+	if !ok {
+		return
+	}
+	comparisons := make([]modelComparison, 0, len(req.ModelIDs))
+	for _, modelID := range req.ModelIDs {
+		model, found := h.routerService.GetModelByID(modelID)
+		if !found || !catalogOverlay.Visible(model) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Model not found",
+				"id":    modelID,
+			})
+			return
+		}
+
+		comparison := modelComparison{Model: catalogOverlay.Annotate(model)}
+		if outputLocale != "" {
+			if support, known := model.LocaleSupport(outputLocale); known {
+				comparison.LocaleSupport = &support
+			}
+		}
+		comparisons = append(comparisons, comparison)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"output_locale": outputLocale,
+			"models":        comparisons,
+		},
+	})
+}
+
+// catalogOverlay loads the caller's catalog overlay, answering the request
+// itself when the overlay cannot be loaded
+func (h *EnhancedHandlers) catalogOverlay(c *gin.Context) (*overlay.Overlay, bool) {
+	catalogOverlay, err := h.routerService.CatalogOverlay(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load catalog overlay",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+	return catalogOverlay, true
+}
+
 // getServiceStats returns service statistics and metadata
 func (h *EnhancedHandlers) getServiceStats(c *gin.Context) {
 	stats := h.routerService.GetStats()
@@ -290,10 +714,12 @@ func (h *EnhancedHandlers) getStatus(c *gin.Context) {
 		"endpoints": []string{
 			"POST /api/v2/recommend/smart",
 			"POST /api/v2/recommend/direct",
+			"POST /api/v2/simulate",
 			"POST /api/v2/classify",
 			"GET /api/v2/models",
 			"GET /api/v2/models/{id}",
 			"GET /api/v2/models/type/{type}",
+			"POST /api/v2/models/search",
 			"GET /api/v2/stats",
 			"POST /api/v2/refresh",
 			"GET /api/v2/health",
@@ -301,6 +727,10 @@ func (h *EnhancedHandlers) getStatus(c *gin.Context) {
 		},
 		"stats": stats,
 	}
+	if h.statusMonitor != nil {
+		status["incidents"] = h.statusMonitor.Incidents()
+		status["providers"] = h.statusMonitor.Providers()
+	}
 
 	c.JSON(http.StatusOK, status)
 }
\ No newline at end of file
@@ -0,0 +1,66 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/analytics"
+)
+
+// SourceAnalyticsAI labels metrics fetched from Analytics AI
+const SourceAnalyticsAI = "analytics_ai"
+
+// MetricsFromAnalytics flattens Analytics AI model data into metric rows.
+// Unreported values are skipped rather than stored as zero.
+func MetricsFromAnalytics(data []analytics.ModelData, observedAt time.Time) []Metric {
+	var metrics []Metric
+	for _, m := range data {
+		add := func(name string, value float64) {
+			metrics = append(metrics, Metric{
+				ModelID:    m.ID,
+				Source:     SourceAnalyticsAI,
+				Metric:     name,
+				Value:      value,
+				ObservedAt: observedAt,
+			})
+		}
+		addOptional := func(name string, value *float64) {
+			if value != nil {
+				add(name, *value)
+			}
+		}
+
+		e := m.Evaluations
+		addOptional("intelligence_index", e.ArtificialAnalysisIntelligenceIndex)
+		addOptional("coding_index", e.ArtificialAnalysisCodingIndex)
+		addOptional("math_index", e.ArtificialAnalysisMathIndex)
+		addOptional("mmlu_pro", e.MMLUPro)
+		addOptional("gpqa", e.GPQA)
+		addOptional("livecodebench", e.LiveCodeBench)
+		addOptional("math_500", e.Math500)
+		addOptional("aime", e.AIME)
+
+		if m.Pricing.Price1MInputTokens > 0 || m.Pricing.Price1MOutputTokens > 0 {
+			add("price_1m_input_tokens", m.Pricing.Price1MInputTokens)
+			add("price_1m_output_tokens", m.Pricing.Price1MOutputTokens)
+		}
+		if m.MedianOutputTokensPerSecond > 0 {
+			add("median_output_tokens_per_second", m.MedianOutputTokensPerSecond)
+		}
+		if m.MedianTimeToFirstTokenSeconds > 0 {
+			add("median_time_to_first_token_seconds", m.MedianTimeToFirstTokenSeconds)
+		}
+	}
+	return metrics
+}
+
+// IngestAnalytics persists a fetched Analytics AI batch; it satisfies models.MetricsSink
+func (i *Ingester) IngestAnalytics(ctx context.Context, data []analytics.ModelData) {
+	result, err := i.UpsertMetrics(ctx, MetricsFromAnalytics(data, time.Now()))
+	if err != nil {
+		log.Printf("[INGEST] Failed to ingest Analytics AI metrics: %v", err)
+		return
+	}
+	log.Printf("[INGEST] Batch %s: %d metrics upserted, %d dead-lettered", result.BatchID, result.Upserted, result.DeadLettered)
+}
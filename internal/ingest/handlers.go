@@ -0,0 +1,89 @@
+package ingest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes the ingest dead-letter queue to admins
+type Handlers struct {
+	ingester *Ingester
+	hub      *HubIngester
+}
+
+func NewHandlers(ingester *Ingester) *Handlers {
+	return &Handlers{ingester: ingester}
+}
+
+// SetHubIngester exposes the Hugging Face Hub fetches
+func (h *Handlers) SetHubIngester(hub *HubIngester) {
+	h.hub = hub
+}
+
+// ListFailures returns dead-lettered ingest rows, optionally filtered by ?status=
+func (h *Handlers) ListFailures(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	failures, err := h.ingester.ListFailures(c.Request.Context(), c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list ingest failures",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"failures": failures,
+			"count":    len(failures),
+		},
+	})
+}
+
+// Requeue schedules one failure, or every abandoned failure, for immediate retry
+func (h *Handlers) Requeue(c *gin.Context) {
+	requeued, err := h.ingester.Requeue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to requeue ingest failures",
+			"details": err.Error(),
+		})
+		return
+	}
+	if requeued == 0 && c.Param("id") != "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Ingest failure not found or already resolved",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"requeued": requeued,
+	})
+}
+
+// HubRepos returns the last Hugging Face Hub fetch of every open model that
+// names a repository
+func (h *Handlers) HubRepos(c *gin.Context) {
+	if h.hub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Hugging Face enrichment is disabled",
+		})
+		return
+	}
+
+	repos := h.hub.Repos()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"repos": repos,
+			"count": len(repos),
+		},
+	})
+}
@@ -0,0 +1,287 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// SourceHuggingFace labels metrics fetched from the Hugging Face Hub
+const SourceHuggingFace = "huggingface"
+
+// defaultHubURL is the public Hub; HF_ENDPOINT points at a mirror instead
+const defaultHubURL = "https://huggingface.co"
+
+// HubCatalog is the catalog Hub data is layered over
+type HubCatalog interface {
+	GetAllModels() []models.EnhancedModel
+	SetHubActivity(activity map[string]models.HubActivity)
+}
+
+// HubRepoStatus is the last fetch of one model's Hub repository
+type HubRepoStatus struct {
+	ModelID   string              `json:"model_id"`
+	Repo      string              `json:"repo"`
+	Activity  *models.HubActivity `json:"activity,omitempty"`
+	CheckedAt *time.Time          `json:"checked_at,omitempty"` // Last successful fetch
+	Error     string              `json:"error,omitempty"`      // Last fetch error; activity is from CheckedAt
+}
+
+// HubIngester enriches open models in the catalog with downloads, likes,
+// license, parameter count and model card metadata from the Hugging Face Hub
+type HubIngester struct {
+	catalog  HubCatalog
+	ingester *Ingester
+	client   *http.Client
+	baseURL  string
+	token    string
+
+	mu    sync.RWMutex
+	repos map[string]HubRepoStatus
+}
+
+// NewHubIngester fetches from the public Hub, or HF_ENDPOINT when set. The
+// token is only needed for gated repositories.
+func NewHubIngester(catalog HubCatalog, token string) *HubIngester {
+	baseURL := strings.TrimRight(os.Getenv("HF_ENDPOINT"), "/")
+	if baseURL == "" {
+		baseURL = defaultHubURL
+	}
+	return &HubIngester{
+		catalog: catalog,
+		client:  transport.Client("huggingface"),
+		baseURL: baseURL,
+		token:   token,
+		repos:   make(map[string]HubRepoStatus),
+	}
+}
+
+// SetIngester also persists downloads, likes and parameter counts as metric rows
+func (h *HubIngester) SetIngester(ingester *Ingester) {
+	h.ingester = ingester
+}
+
+// Start fetches now and then on the given interval until ctx is cancelled
+func (h *HubIngester) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		h.Refresh(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.Refresh(ctx)
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh fetches the repository of every open model that names one and
+// reapplies the results to the catalog. A repository that fails keeps its
+// last known data.
+func (h *HubIngester) Refresh(ctx context.Context) {
+	fetched := make(map[string]models.HubActivity)
+	for _, model := range h.catalog.GetAllModels() {
+		if !model.OpenSource || model.HuggingFaceRepo == "" {
+			continue
+		}
+
+		activity, err := h.fetch(ctx, model.HuggingFaceRepo)
+		h.mu.Lock()
+		current := h.repos[model.ID]
+		current.ModelID, current.Repo = model.ID, model.HuggingFaceRepo
+		if err != nil {
+			log.Printf("[INGEST] Hugging Face repo %s for %s: %v", model.HuggingFaceRepo, model.ID, err)
+			current.Error = err.Error()
+		} else {
+			current.Activity, current.CheckedAt, current.Error = &activity, &activity.FetchedAt, ""
+			fetched[model.ID] = activity
+		}
+		h.repos[model.ID] = current
+		h.mu.Unlock()
+	}
+
+	h.catalog.SetHubActivity(h.activity())
+	if h.ingester != nil && len(fetched) > 0 {
+		result, err := h.ingester.UpsertMetrics(ctx, metricsFromHub(fetched))
+		if err != nil {
+			log.Printf("[INGEST] Failed to ingest Hugging Face metrics: %v", err)
+			return
+		}
+		log.Printf("[INGEST] Batch %s: %d Hugging Face metrics upserted, %d dead-lettered", result.BatchID, result.Upserted, result.DeadLettered)
+	}
+}
+
+// Repos returns the last fetch of every repository, by model ID
+func (h *HubIngester) Repos() []HubRepoStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	repos := make([]HubRepoStatus, 0, len(h.repos))
+	for _, status := range h.repos {
+		repos = append(repos, status)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].ModelID < repos[j].ModelID })
+	return repos
+}
+
+// activity is the last successfully fetched data, by model
+func (h *HubIngester) activity() map[string]models.HubActivity {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	activity := make(map[string]models.HubActivity, len(h.repos))
+	for modelID, status := range h.repos {
+		if status.Activity != nil {
+			activity[modelID] = *status.Activity
+		}
+	}
+	return activity
+}
+
+// hubModel is the part of the Hub's model info the router reads
+type hubModel struct {
+	ID           string      `json:"id"`
+	Downloads    int64       `json:"downloads"`
+	Likes        int64       `json:"likes"`
+	PipelineTag  string      `json:"pipeline_tag"`
+	Tags         []string    `json:"tags"`
+	Gated        interface{} `json:"gated"` // false, "auto" or "manual"
+	LastModified *time.Time  `json:"lastModified"`
+	CardData     struct {
+		License     string          `json:"license"`
+		LicenseName string          `json:"license_name"` // Set when license is "other"
+		Language    json.RawMessage `json:"language"`     // A string or a list
+	} `json:"cardData"`
+	Safetensors *struct {
+		Total int64 `json:"total"`
+	} `json:"safetensors"`
+}
+
+func (h *HubIngester) fetch(ctx context.Context, repo string) (models.HubActivity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+"/api/models/"+escapeRepo(repo), nil)
+	if err != nil {
+		return models.HubActivity{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return models.HubActivity{}, fmt.Errorf("failed to fetch model info: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return models.HubActivity{}, fmt.Errorf("failed to fetch model info: status %d", resp.StatusCode)
+	}
+
+	var info hubModel
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return models.HubActivity{}, fmt.Errorf("failed to decode model info: %w", err)
+	}
+	return info.activity(repo, time.Now()), nil
+}
+
+// escapeRepo escapes each path segment of "org/name"
+func escapeRepo(repo string) string {
+	segments := strings.Split(strings.Trim(repo, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func (info hubModel) activity(repo string, fetchedAt time.Time) models.HubActivity {
+	activity := models.HubActivity{
+		Repo:         repo,
+		Downloads:    info.Downloads,
+		Likes:        info.Likes,
+		License:      info.license(),
+		PipelineTag:  info.PipelineTag,
+		Languages:    info.languages(),
+		LastModified: info.LastModified,
+		FetchedAt:    fetchedAt,
+	}
+	if gated, ok := info.Gated.(string); ok && gated != "" {
+		activity.Gated = true
+	} else if gated, ok := info.Gated.(bool); ok {
+		activity.Gated = gated
+	}
+	if info.Safetensors != nil {
+		activity.ParameterCount = info.Safetensors.Total
+	}
+	return activity
+}
+
+// license prefers the model card, then the Hub's "license:" tags; "other"
+// is only returned when nothing names the license
+func (info hubModel) license() string {
+	license := info.CardData.License
+	if license == "other" && info.CardData.LicenseName != "" {
+		return info.CardData.LicenseName
+	}
+	if license != "" && license != "other" {
+		return license
+	}
+	for _, tag := range info.Tags {
+		if name, ok := strings.CutPrefix(tag, "license:"); ok && name != "other" {
+			return name
+		}
+	}
+	return license
+}
+
+func (info hubModel) languages() []string {
+	if len(info.CardData.Language) == 0 {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(info.CardData.Language, &list); err == nil {
+		return list
+	}
+	var single string
+	if err := json.Unmarshal(info.CardData.Language, &single); err == nil && single != "" {
+		return []string{single}
+	}
+	return nil
+}
+
+// metricsFromHub flattens fetched Hub data into metric rows
+func metricsFromHub(fetched map[string]models.HubActivity) []Metric {
+	var metrics []Metric
+	for modelID, activity := range fetched {
+		add := func(name string, value int64) {
+			metrics = append(metrics, Metric{
+				ModelID:    modelID,
+				Source:     SourceHuggingFace,
+				Metric:     name,
+				Value:      float64(value),
+				ObservedAt: activity.FetchedAt,
+			})
+		}
+		add("downloads_30d", activity.Downloads)
+		add("likes", activity.Likes)
+		if activity.ParameterCount > 0 {
+			add("parameter_count", activity.ParameterCount)
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,412 @@
+package ingest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+const (
+	// maxAttempts is how often a dead-lettered row is retried before it is abandoned
+	maxAttempts = 5
+
+	// baseBackoff doubles after every failed retry
+	baseBackoff = time.Minute
+
+	// retryBatchSize bounds how many dead-lettered rows one worker pass retries
+	retryBatchSize = 100
+)
+
+// Failure statuses
+const (
+	StatusPending   = "pending"
+	StatusResolved  = "resolved"
+	StatusAbandoned = "abandoned"
+)
+
+// Metric is one observed value for a model from an ingest source
+type Metric struct {
+	ModelID    string    `json:"model_id"`
+	Source     string    `json:"source"`
+	Metric     string    `json:"metric"`
+	Value      float64   `json:"value"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// Result summarizes one batch ingest
+type Result struct {
+	BatchID      string `json:"batch_id"`
+	Upserted     int    `json:"upserted"`
+	DeadLettered int    `json:"dead_lettered"`
+}
+
+// Failure is a dead-lettered metric row
+type Failure struct {
+	ID            string    `json:"id"`
+	BatchID       string    `json:"batch_id"`
+	Source        string    `json:"source"`
+	ModelID       string    `json:"model_id"`
+	Payload       Metric    `json:"payload"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	Status        string    `json:"status"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Ingester upserts metric batches transactionally and dead-letters rows that fail
+type Ingester struct {
+	db     *sql.DB
+	alerts *alerts.Manager
+}
+
+func NewIngester(db *sql.DB) *Ingester {
+	return &Ingester{db: db}
+}
+
+// SetAlerts reports dead-lettered rows and failed retry passes to operators
+func (i *Ingester) SetAlerts(manager *alerts.Manager) {
+	i.alerts = manager
+}
+
+// UpsertMetrics writes a batch in one transaction. Each row runs under its own
+// savepoint so a bad row is rolled back and dead-lettered without losing the
+// rest of the batch. If the transaction itself fails, every row is dead-lettered.
+func (i *Ingester) UpsertMetrics(ctx context.Context, batch []Metric) (Result, error) {
+	result := Result{BatchID: newBatchID()}
+	if len(batch) == 0 {
+		return result, nil
+	}
+
+	failed, err := i.upsertBatch(ctx, batch)
+	if err != nil {
+		log.Printf("[INGEST] Batch %s failed, dead-lettering %d rows: %v", result.BatchID, len(batch), err)
+		failed = make(map[int]error, len(batch))
+		for idx := range batch {
+			failed[idx] = err
+		}
+	}
+
+	for idx, rowErr := range failed {
+		if dlErr := i.deadLetter(ctx, result.BatchID, batch[idx], rowErr); dlErr != nil {
+			return result, fmt.Errorf("failed to dead-letter row for %s: %w", batch[idx].ModelID, dlErr)
+		}
+	}
+
+	result.Upserted = len(batch) - len(failed)
+	result.DeadLettered = len(failed)
+	if result.DeadLettered > 0 {
+		severity := alerts.SeverityWarning
+		if result.Upserted == 0 {
+			severity = alerts.SeverityCritical
+		}
+		i.alerts.Notify(alerts.Event{
+			Type:     alerts.EventIngestAnomaly,
+			Severity: severity,
+			Source:   "ingest:" + batch[0].Source,
+			Title:    "Metric ingest rows dead-lettered",
+			Message:  fmt.Sprintf("%d of %d rows failed and were queued for retry", result.DeadLettered, len(batch)),
+			Fields:   map[string]string{"batch_id": result.BatchID},
+		})
+	}
+	return result, nil
+}
+
+// upsertBatch returns the rows that failed by index, or an error if the batch could not commit
+func (i *Ingester) upsertBatch(ctx context.Context, batch []Metric) (map[int]error, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin ingest transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	failed := make(map[int]error)
+	for idx, m := range batch {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT ingest_row`); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		if err := upsertRow(ctx, tx, m); err != nil {
+			if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT ingest_row`); rbErr != nil {
+				return nil, fmt.Errorf("failed to roll back row: %w", rbErr)
+			}
+			failed[idx] = err
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT ingest_row`); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit ingest batch: %w", err)
+	}
+	return failed, nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func upsertRow(ctx context.Context, ex execer, m Metric) error {
+	if m.ModelID == "" || m.Source == "" || m.Metric == "" {
+		return fmt.Errorf("model_id, source and metric are required")
+	}
+	if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+		return fmt.Errorf("metric %s has non-finite value", m.Metric)
+	}
+	if m.ObservedAt.IsZero() {
+		m.ObservedAt = time.Now()
+	}
+
+	// Never let a late retry overwrite a newer observation
+	_, err := ex.ExecContext(ctx, `
+		INSERT INTO model_metrics (model_id, source, metric, value, observed_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (model_id, source, metric) DO UPDATE
+		SET value = EXCLUDED.value, observed_at = EXCLUDED.observed_at, updated_at = CURRENT_TIMESTAMP
+		WHERE model_metrics.observed_at <= EXCLUDED.observed_at`,
+		m.ModelID, m.Source, m.Metric, m.Value, m.ObservedAt)
+	return err
+}
+
+func (i *Ingester) deadLetter(ctx context.Context, batchID string, m Metric, cause error) error {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.db.ExecContext(ctx, `
+		INSERT INTO ingest_failures (batch_id, source, model_id, payload, error)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)`,
+		batchID, m.Source, m.ModelID, payload, cause.Error())
+	return err
+}
+
+// Start retries due dead-lettered rows on every tick until ctx is cancelled
+func (i *Ingester) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if resolved, err := i.RetryFailures(ctx); err != nil {
+					log.Printf("[INGEST] Retry pass failed: %v", err)
+					i.alerts.Notify(alerts.Event{
+						Type:     alerts.EventJobFailed,
+						Severity: alerts.SeverityCritical,
+						Source:   "ingest_retry",
+						Title:    "Ingest retry worker failed",
+						Message:  err.Error(),
+					})
+				} else if resolved > 0 {
+					log.Printf("[INGEST] Recovered %d dead-lettered rows", resolved)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// RetryFailures re-applies pending rows whose backoff has elapsed and returns how many succeeded.
+// Rows are claimed with SKIP LOCKED so several replicas can run the worker.
+func (i *Ingester) RetryFailures(ctx context.Context) (int, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin retry transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, payload, attempts FROM ingest_failures
+		WHERE status = $1 AND next_attempt_at <= CURRENT_TIMESTAMP
+		ORDER BY next_attempt_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		StatusPending, retryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load due failures: %w", err)
+	}
+
+	type due struct {
+		id        string
+		metric    Metric
+		attempts  int
+		decodeErr error
+	}
+	var pending []due
+	for rows.Next() {
+		var d due
+		var payload []byte
+		if err := rows.Scan(&d.id, &payload, &d.attempts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan failure: %w", err)
+		}
+		d.decodeErr = json.Unmarshal(payload, &d.metric)
+		pending = append(pending, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, d := range pending {
+		rowErr := d.decodeErr
+		if rowErr == nil {
+			if _, err := tx.ExecContext(ctx, `SAVEPOINT ingest_retry`); err != nil {
+				return 0, fmt.Errorf("failed to create savepoint: %w", err)
+			}
+			rowErr = upsertRow(ctx, tx, d.metric)
+			if rowErr != nil {
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT ingest_retry`); err != nil {
+					return 0, fmt.Errorf("failed to roll back retry: %w", err)
+				}
+			} else if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT ingest_retry`); err != nil {
+				return 0, fmt.Errorf("failed to release savepoint: %w", err)
+			}
+		}
+
+		if rowErr == nil {
+			resolved++
+			_, err = tx.ExecContext(ctx, `
+				UPDATE ingest_failures
+				SET status = $2, attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+				WHERE id = $1`,
+				d.id, StatusResolved)
+		} else {
+			attempts := d.attempts + 1
+			status := StatusPending
+			if attempts >= maxAttempts {
+				status = StatusAbandoned
+				i.alerts.Notify(alerts.Event{
+					Type:     alerts.EventIngestAnomaly,
+					Severity: alerts.SeverityWarning,
+					Source:   "ingest:" + d.metric.Source,
+					Title:    "Ingest row abandoned after retries",
+					Message:  rowErr.Error(),
+					Fields:   map[string]string{"failure_id": d.id, "model_id": d.metric.ModelID, "attempts": fmt.Sprintf("%d", attempts)},
+				})
+			}
+			backoff := baseBackoff * time.Duration(1<<uint(attempts))
+			_, err = tx.ExecContext(ctx, `
+				UPDATE ingest_failures
+				SET status = $2, attempts = $3, error = $4, next_attempt_at = $5, updated_at = CURRENT_TIMESTAMP
+				WHERE id = $1`,
+				d.id, status, attempts, rowErr.Error(), time.Now().Add(backoff))
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update failure %s: %w", d.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit retry pass: %w", err)
+	}
+	return resolved, nil
+}
+
+// ListFailures returns dead-lettered rows, newest first, optionally filtered by status
+func (i *Ingester) ListFailures(ctx context.Context, status string, limit, offset int) ([]Failure, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT id, batch_id, source, COALESCE(model_id, ''), payload, error, attempts, status,
+		       next_attempt_at, created_at, updated_at
+		FROM ingest_failures
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`,
+		status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingest failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := []Failure{}
+	for rows.Next() {
+		var f Failure
+		var payload []byte
+		if err := rows.Scan(&f.ID, &f.BatchID, &f.Source, &f.ModelID, &payload, &f.Error, &f.Attempts, &f.Status,
+			&f.NextAttemptAt, &f.CreatedAt, &f.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ingest failure: %w", err)
+		}
+		json.Unmarshal(payload, &f.Payload)
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+// MetricsFor returns the latest metrics of each model in one query, by model
+func (i *Ingester) MetricsFor(ctx context.Context, modelIDs []string) (map[string][]Metric, error) {
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT model_id, source, metric, value, observed_at
+		FROM model_metrics
+		WHERE model_id = ANY($1)
+		ORDER BY model_id, source, metric`,
+		pq.Array(modelIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model metrics: %w", err)
+	}
+	defer rows.Close()
+
+	metrics := make(map[string][]Metric, len(modelIDs))
+	for rows.Next() {
+		var m Metric
+		if err := rows.Scan(&m.ModelID, &m.Source, &m.Metric, &m.Value, &m.ObservedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan model metric: %w", err)
+		}
+		metrics[m.ModelID] = append(metrics[m.ModelID], m)
+	}
+	return metrics, rows.Err()
+}
+
+// Requeue resets unresolved rows so the worker retries them on its next pass.
+// An empty id requeues every abandoned row.
+func (i *Ingester) Requeue(ctx context.Context, id string) (int64, error) {
+	var res sql.Result
+	var err error
+	if id == "" {
+		res, err = i.db.ExecContext(ctx, `
+			UPDATE ingest_failures
+			SET status = $1, attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE status = $2`,
+			StatusPending, StatusAbandoned)
+	} else {
+		res, err = i.db.ExecContext(ctx, `
+			UPDATE ingest_failures
+			SET status = $1, attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2 AND status != $3`,
+			StatusPending, id, StatusResolved)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue ingest failures: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func newBatchID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
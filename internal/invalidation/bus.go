@@ -0,0 +1,176 @@
+package invalidation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// queueSize bounds the changes waiting for delivery; when it fills, the
+// waiting changes collapse into one change to every model
+const queueSize = 256
+
+// Sources of catalog changes
+const (
+	SourceFusion       = "fusion"       // Rebuilt from model_1.json and Analytics AI
+	SourceSnapshot     = "snapshot"     // Replaced by a replicated snapshot or a catalog import
+	SourceEdit         = "edit"         // One model added, changed or removed by an admin
+	SourceFeatures     = "features"     // Declared model and provider features
+	SourceBenchmarks   = "benchmarks"   // Eval suite results
+	SourceQuality      = "quality"      // Judged live answers
+	SourceStatus       = "status"       // Provider incidents
+	SourcePricing      = "pricing"      // Recent list price changes
+	SourceDeprecation  = "deprecation"  // Provider retirements
+	SourceTrust        = "trust"        // Trust tiers set by admins or automated checks
+	SourceAvailability = "availability" // Operator disables and maintenance windows
+	SourceHub          = "huggingface"  // Hugging Face Hub data
+	SourceLocales      = "locales"      // Locale scores
+	SourceOverflow     = "overflow"     // Changes were dropped from a full queue
+)
+
+// Event is one catalog change. With neither Models nor Removed set, any
+// model may have changed.
+type Event struct {
+	Source  string    `json:"source"`
+	Models  []string  `json:"models,omitempty"`  // Changed or added models
+	Removed []string  `json:"removed,omitempty"` // Models no longer in the catalog
+	At      time.Time `json:"at"`
+}
+
+// All reports whether any model may have changed
+func (e Event) All() bool {
+	return len(e.Models) == 0 && len(e.Removed) == 0
+}
+
+// Affects reports whether the change may affect modelID
+func (e Event) Affects(modelID string) bool {
+	if e.All() {
+		return true
+	}
+	for _, id := range e.Models {
+		if id == modelID {
+			return true
+		}
+	}
+	for _, id := range e.Removed {
+		if id == modelID {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats counts the changes published on this replica
+type Stats struct {
+	Subscribers []string `json:"subscribers"`
+	Published   int64    `json:"published"`
+	Delivered   int64    `json:"delivered"`
+	Overflows   int64    `json:"overflows"`
+}
+
+type subscriber struct {
+	name string
+	fn   func(Event)
+}
+
+// Bus carries catalog changes to the caches built from the catalog, in the
+// order they were published, one change at a time. Publishing never blocks,
+// so the catalog can publish while it holds its own lock; subscribers run on
+// the bus's goroutine and may read the catalog. The package only imports the
+// standard library so the catalog can import it.
+type Bus struct {
+	queue    chan Event
+	overflow atomic.Bool
+
+	mu          sync.RWMutex
+	subscribers []subscriber
+
+	published atomic.Int64
+	delivered atomic.Int64
+	overflows atomic.Int64
+}
+
+func NewBus() *Bus {
+	return &Bus{queue: make(chan Event, queueSize)}
+}
+
+// Subscribe calls fn with every change published after it returns
+func (b *Bus) Subscribe(name string, fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber{name: name, fn: fn})
+}
+
+// Publish queues a change for delivery. A nil bus drops it.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	b.published.Add(1)
+	select {
+	case b.queue <- e:
+	default:
+		// Subscribers will be told everything changed once the queue drains
+		if !b.overflow.Swap(true) {
+			b.overflows.Add(1)
+			log.Printf("[INVALIDATION] Queue full, collapsing pending changes")
+		}
+	}
+}
+
+// Start delivers queued changes until ctx is cancelled
+func (b *Bus) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-b.queue:
+				b.deliver(e)
+				if len(b.queue) == 0 && b.overflow.Swap(false) {
+					b.deliver(Event{Source: SourceOverflow, At: time.Now()})
+				}
+			}
+		}
+	}()
+}
+
+func (b *Bus) deliver(e Event) {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	for _, s := range subscribers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("[INVALIDATION] Subscriber %s panicked on %s change: %v", s.name, e.Source, r)
+				}
+			}()
+			s.fn(e)
+		}()
+	}
+	b.delivered.Add(1)
+}
+
+// Stats returns the subscribers and delivery counters
+func (b *Bus) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	names := make([]string, len(b.subscribers))
+	for i, s := range b.subscribers {
+		names[i] = s.name
+	}
+	return Stats{
+		Subscribers: names,
+		Published:   b.published.Load(),
+		Delivered:   b.delivered.Load(),
+		Overflows:   b.overflows.Load(),
+	}
+}
@@ -0,0 +1,225 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Types are the JSON Schema types Validate checks
+var Types = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// Error is one place a value does not match its schema
+type Error struct {
+	Path    string // "$" for the value itself, then ".name" and "[index]"
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// TypeList returns the node's "type" as a list; a schema may give one or several
+func TypeList(node map[string]interface{}) []string {
+	switch t := node["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// Validate checks a value decoded by encoding/json against the subset of
+// JSON Schema providers enforce: type, enum, const, properties, required,
+// additionalProperties, items, length and range bounds, and anyOf/oneOf/allOf
+func Validate(schema, value interface{}) []Error {
+	var errs []Error
+	validateNode(schema, value, "$", &errs)
+	return errs
+}
+
+func validateNode(schema, value interface{}, path string, errs *[]Error) {
+	node, ok := schema.(map[string]interface{})
+	if !ok {
+		return // true, or an unsupported form: accept
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := TypeList(node); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if hasType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+			return
+		}
+	}
+	if enum, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %s", describeEnum(enum))
+		}
+	}
+	if constant, ok := node["const"]; ok && !reflect.DeepEqual(constant, value) {
+		fail("value does not equal const")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(node, v, path, errs)
+	case []interface{}:
+		if min, ok := number(node["minItems"]); ok && float64(len(v)) < min {
+			fail("expected at least %v items, got %d", min, len(v))
+		}
+		if max, ok := number(node["maxItems"]); ok && float64(len(v)) > max {
+			fail("expected at most %v items, got %d", max, len(v))
+		}
+		if items, ok := node["items"]; ok {
+			for i, item := range v {
+				validateNode(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := number(node["minLength"]); ok && length < min {
+			fail("expected at least %v characters", min)
+		}
+		if max, ok := number(node["maxLength"]); ok && length > max {
+			fail("expected at most %v characters", max)
+		}
+	case float64:
+		if min, ok := number(node["minimum"]); ok && v < min {
+			fail("%v is below the minimum %v", v, min)
+		}
+		if max, ok := number(node["maximum"]); ok && v > max {
+			fail("%v is above the maximum %v", v, max)
+		}
+	}
+
+	if all, ok := node["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			validateNode(sub, value, path, errs)
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		options, ok := node[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		matches := 0
+		for _, sub := range options {
+			var subErrs []Error
+			validateNode(sub, value, path, &subErrs)
+			if len(subErrs) == 0 {
+				matches++
+			}
+		}
+		if matches == 0 || (keyword == "oneOf" && matches > 1) {
+			fail("value matches %d of the %s options", matches, keyword)
+		}
+	}
+}
+
+func validateObject(node map[string]interface{}, obj map[string]interface{}, path string, errs *[]Error) {
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := obj[name]; !present {
+					*errs = append(*errs, Error{Path: path + "." + name, Message: "missing required property"})
+				}
+			}
+		}
+	}
+
+	props, _ := node["properties"].(map[string]interface{})
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Stable error order
+	for _, name := range names {
+		if sub, ok := props[name]; ok {
+			validateNode(sub, obj[name], path+"."+name, errs)
+			continue
+		}
+		switch extra := node["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				*errs = append(*errs, Error{Path: path + "." + name, Message: "unexpected property"})
+			}
+		case map[string]interface{}:
+			validateNode(extra, obj[name], path+"."+name, errs)
+		}
+	}
+}
+
+// describeEnum lists the allowed values, e.g. ["cost", "speed"]
+func describeEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, v := range enum {
+		if s, ok := v.(string); ok {
+			values[i] = strconv.Quote(s)
+		} else {
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	default:
+		return jsonType(value) == t
+	}
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
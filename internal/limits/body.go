@@ -0,0 +1,75 @@
+package limits
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit refuses request bodies over the route's limit with 413 before
+// anything parses them. Routes are matched by their registered pattern; those
+// without an override get defaultBytes. A body within the limit is buffered so
+// later middleware and the handler read it as usual.
+func BodyLimit(defaultBytes int64, routes map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := defaultBytes
+		if routeLimit, ok := routes[c.FullPath()]; ok {
+			limit = routeLimit
+		}
+
+		// A declared length over the limit is refused without reading the body
+		if c.Request.ContentLength > limit {
+			rejectBody(c, limit, c.Request.ContentLength)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				// Chunked bodies are only known to exceed the limit
+				rejectBody(c, limit, 0)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to read request body",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+
+		c.Next()
+	}
+}
+
+// rejectBody answers 413 with the limit and, when declared, the body size
+func rejectBody(c *gin.Context, limit, size int64) {
+	details := fmt.Sprintf("request body exceeds %d bytes", limit)
+	resp := gin.H{
+		"error":       "Request body too large",
+		"code":        "body_too_large",
+		"limit_bytes": limit,
+	}
+	if size > 0 {
+		details = fmt.Sprintf("request body is %d bytes; this endpoint accepts at most %d", size, limit)
+		resp["size_bytes"] = size
+	}
+	resp["details"] = details
+
+	// The rest of the body is not read, so the connection cannot be reused
+	c.Header("Connection", "close")
+	c.JSON(http.StatusRequestEntityTooLarge, resp)
+	c.Abort()
+}
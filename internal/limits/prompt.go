@@ -0,0 +1,140 @@
+package limits
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultPromptTokens are the prompt token caps per plan, used when
+// plan_limits cannot be read. Anonymous callers and unknown plans get the
+// free cap.
+var DefaultPromptTokens = map[string]int{
+	"free":       2000,
+	"beta":       4000,
+	"starter":    8000,
+	"pro":        16000,
+	"enterprise": 32000,
+}
+
+// PromptMeasure is the size of the longest prompt in a request
+type PromptMeasure struct {
+	Tokens int `json:"prompt_tokens"` // Estimated at four characters each
+	Chars  int `json:"prompt_chars"`
+}
+
+// EstimateTokens approximates a text's tokens at four characters each, the
+// same estimate generation meters with before a provider reports usage
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// PromptCap refuses prompts longer than the caller's plan allows, before they
+// reach classification or a provider
+type PromptCap struct {
+	limits map[string]int
+}
+
+func NewPromptCap(limits map[string]int) *PromptCap {
+	return &PromptCap{limits: limits}
+}
+
+// Limit returns a plan's prompt token cap; unknown plans get the free cap
+func (p *PromptCap) Limit(plan string) int {
+	if limit, ok := p.limits[plan]; ok {
+		return limit
+	}
+	if limit, ok := p.limits["free"]; ok {
+		return limit
+	}
+	return DefaultPromptTokens["free"]
+}
+
+// Middleware measures the prompt of each JSON POST and answers 413 with
+// code prompt_too_long when it is over the plan's cap. It must run after the
+// auth middleware, so the caller's plan is known, and after BodyLimit, which
+// bounds what is read here.
+func (p *PromptCap) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		m, ok := measurePrompt(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		limit := p.Limit(c.GetString("user_plan"))
+		if m.Tokens > limit {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":             "Prompt too long",
+				"code":              "prompt_too_long",
+				"details":           fmt.Sprintf("prompt is about %d tokens (%d characters); your plan allows %d", m.Tokens, m.Chars, limit),
+				"prompt_tokens":     m.Tokens,
+				"prompt_chars":      m.Chars,
+				"max_prompt_tokens": limit,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// measurePrompt reads the prompt of a JSON body, a prompt field, a list of
+// prompts or chat messages, and restores the body for the handler. Bodies that
+// are not JSON are left for the handler to reject.
+func measurePrompt(c *gin.Context) (PromptMeasure, bool) {
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil || len(body) == 0 {
+		return PromptMeasure{}, false
+	}
+
+	var req struct {
+		Prompt   string   `json:"prompt"`
+		Prompts  []string `json:"prompts"`
+		Messages []struct {
+			Content string `json:"content"`
+			Parts   []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return PromptMeasure{}, false
+	}
+
+	var longest PromptMeasure
+	measure := func(text string) {
+		if chars := utf8.RuneCountInString(text); chars > longest.Chars {
+			longest = PromptMeasure{Tokens: EstimateTokens(text), Chars: chars}
+		}
+	}
+	measure(req.Prompt)
+	for _, prompt := range req.Prompts {
+		measure(prompt)
+	}
+
+	// A conversation is sent as a whole, so its turns count together
+	var conversation PromptMeasure
+	for _, m := range req.Messages {
+		conversation.Chars += utf8.RuneCountInString(m.Content)
+		for _, part := range m.Parts {
+			conversation.Chars += utf8.RuneCountInString(part.Text)
+		}
+	}
+	conversation.Tokens = (conversation.Chars + 3) / 4
+	if conversation.Chars > longest.Chars {
+		longest = conversation
+	}
+	return longest, longest.Chars > 0
+}
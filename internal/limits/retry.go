@@ -0,0 +1,57 @@
+package limits
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Windows a limit is counted over, reported as limit_window on 429 responses
+// and GET /v1/limits
+const (
+	WindowHour        = "hour"
+	WindowDay         = "day"
+	WindowMonth       = "month"
+	WindowBurst       = "burst"       // Requests sent faster than the token bucket refills
+	WindowConcurrency = "concurrency" // Requests in flight at once
+	WindowDuplicate   = "duplicate"   // Repeats of one prompt within the duplicate window
+	WindowThrottle    = "throttle"    // A key throttled for suspicious usage
+	WindowTokens      = "tokens"      // Generation tokens in the calendar month
+)
+
+// Backoff strategies suggested to clients on 429 responses
+const (
+	// BackoffFixed waits retry_after_ms once; the limit lifts at a known time
+	BackoffFixed = "fixed"
+	// BackoffExponential starts at retry_after_ms and doubles with jitter on
+	// each further 429; capacity frees up as other requests finish
+	BackoffExponential = "exponential_jitter"
+)
+
+// RetryHint tells a throttled client when and how to retry
+type RetryHint struct {
+	RetryAfter time.Duration
+	Window     string
+	Backoff    string
+}
+
+// TooManyRequests aborts with 429, setting Retry-After and adding the hint's
+// retry_after_ms, limit_window and suggested_backoff fields to body
+func TooManyRequests(c *gin.Context, hint RetryHint, body gin.H) {
+	if hint.RetryAfter < time.Second {
+		hint.RetryAfter = time.Second
+	}
+	if hint.Backoff == "" {
+		hint.Backoff = BackoffFixed
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(hint.RetryAfter.Seconds()))))
+	body["retry_after_ms"] = hint.RetryAfter.Milliseconds()
+	body["limit_window"] = hint.Window
+	body["suggested_backoff"] = hint.Backoff
+	body["limits_url"] = "/v1/limits"
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+}
@@ -0,0 +1,281 @@
+package limits
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Priorities the load shedder ranks requests by, lowest first
+const (
+	PriorityLow       = "low"       // Classification-only and test endpoints, and anonymous callers
+	PriorityNormal    = "normal"    // Every other tenant request
+	PriorityProtected = "protected" // Paid generation, health checks and the admin API; never shed
+)
+
+// Shedding levels reported by ShedStats
+const (
+	ShedNone   = "none"
+	ShedLow    = "low"    // Low-priority requests are refused
+	ShedNormal = "normal" // Only protected requests are served
+)
+
+const (
+	// shedLowAt is the saturation from which low-priority traffic is shed, so
+	// paid traffic keeps headroom as the router approaches capacity
+	shedLowAt = 0.8
+	// shedNormalAt is the saturation from which everything but protected
+	// traffic is shed
+	shedNormalAt = 1.0
+
+	// latencySamples bounds how many recent latencies the p99 is taken over
+	latencySamples = 1024
+	// latencyWindow is how recent a latency must be to count, so the p99
+	// recovers once a spike has passed even if little traffic is admitted
+	latencyWindow = 10 * time.Second
+	// p99Refresh is how long a computed p99 is reused before it is recomputed
+	p99Refresh = 250 * time.Millisecond
+
+	// shedRetryAfter is when shed clients are told to retry
+	shedRetryAfter = 2 * time.Second
+)
+
+// lowPriorityRoutes only classify or preview routing; callers can retry them
+// cheaply and nothing is billed. The legacy /test/ endpoints are low too.
+var lowPriorityRoutes = map[string]bool{
+	"/api/v2/classify": true,
+	"/api/v2/simulate": true,
+}
+
+// protectedRoutes are never shed: load balancers and operators must reach
+// them while the router is overloaded
+var protectedRoutes = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
+	"/livez":   true,
+	"/readyz":  true,
+}
+
+// generateRoute waits on providers, so its latency says nothing about the
+// router's own saturation and is not sampled
+const generateRoute = "/api/v2/generate"
+
+// latencySample is one finished request's latency
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// ShedStats reports the shedder's view of load and what it has refused
+type ShedStats struct {
+	InFlight        int64            `json:"in_flight"`
+	MaxInFlight     int              `json:"max_in_flight"` // 0 when in-flight requests are not a signal
+	P99LatencyMs    int64            `json:"p99_latency_ms"`
+	LatencyTargetMs int64            `json:"latency_target_ms"` // 0 when latency is not a signal
+	Saturation      float64          `json:"saturation"`        // 1 is capacity
+	Shedding        string           `json:"shedding"`
+	Admitted        map[string]int64 `json:"admitted"` // By priority, since start
+	Shed            map[string]int64 `json:"shed"`     // By priority, since start
+	LastShedAt      *time.Time       `json:"last_shed_at,omitempty"`
+}
+
+// Shedder refuses low-priority traffic with 503 as the replica approaches
+// saturation, judged by requests in flight and p99 latency, so paid
+// generation keeps its capacity. Each replica judges its own load.
+type Shedder struct {
+	maxInFlight   int
+	latencyTarget time.Duration
+	inFlight      atomic.Int64
+
+	mu        sync.Mutex
+	latencies []latencySample // Ring of recent latencies
+	next      int
+	p99       time.Duration
+	p99At     time.Time
+	admitted  map[string]int64
+	shed      map[string]int64
+	lastShed  time.Time
+}
+
+// NewShedder treats maxInFlight requests in flight or a p99 latency of
+// latencyTarget as capacity; 0 disables either signal
+func NewShedder(maxInFlight int, latencyTarget time.Duration) *Shedder {
+	return &Shedder{
+		maxInFlight:   maxInFlight,
+		latencyTarget: latencyTarget,
+		latencies:     make([]latencySample, 0, latencySamples),
+		admitted:      make(map[string]int64),
+		shed:          make(map[string]int64),
+	}
+}
+
+// RequestPriority ranks a request for shedding. It must run after the auth
+// middleware, so anonymous callers and the caller's plan are known.
+func RequestPriority(c *gin.Context) string {
+	route := c.FullPath()
+	switch {
+	case protectedRoutes[route] || strings.HasPrefix(route, "/api/v1/admin"):
+		return PriorityProtected
+	case route == generateRoute && paidPlan(c.GetString("user_plan")):
+		return PriorityProtected
+	case lowPriorityRoutes[route] || strings.HasPrefix(route, "/test/") || c.GetString("user_id") == "":
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// paidPlan reports plans that pay for their traffic
+func paidPlan(plan string) bool {
+	return plan != "" && plan != "free"
+}
+
+// Middleware counts requests in flight and their latency, and answers 503
+// with code load_shed to those the current load does not leave room for
+func (s *Shedder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		priority := RequestPriority(c)
+		now := time.Now()
+		if level := shedLevel(s.saturation(now)); sheds(level, priority) {
+			s.record(priority, false, now)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(shedRetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":          "Server is overloaded",
+				"code":           "load_shed",
+				"details":        "the router is shedding " + level + " priority traffic; retry shortly",
+				"retry_after_ms": shedRetryAfter.Milliseconds(),
+			})
+			return
+		}
+		s.record(priority, true, now)
+
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		c.Next()
+		if c.FullPath() != generateRoute {
+			s.observe(time.Now(), time.Since(now))
+		}
+	}
+}
+
+// sheds reports whether a shedding level refuses a priority
+func sheds(level, priority string) bool {
+	switch level {
+	case ShedNormal:
+		return priority != PriorityProtected
+	case ShedLow:
+		return priority == PriorityLow
+	}
+	return false
+}
+
+// shedLevel is the traffic a saturation sheds
+func shedLevel(saturation float64) string {
+	switch {
+	case saturation >= shedNormalAt:
+		return ShedNormal
+	case saturation >= shedLowAt:
+		return ShedLow
+	}
+	return ShedNone
+}
+
+// saturation is the larger of in-flight requests against maxInFlight and p99
+// latency against the target; 1 is capacity
+func (s *Shedder) saturation(now time.Time) float64 {
+	saturation := 0.0
+	if s.maxInFlight > 0 {
+		saturation = float64(s.inFlight.Load()) / float64(s.maxInFlight)
+	}
+	if s.latencyTarget > 0 {
+		if ratio := float64(s.p99Latency(now)) / float64(s.latencyTarget); ratio > saturation {
+			saturation = ratio
+		}
+	}
+	return saturation
+}
+
+// observe records a finished request's latency
+func (s *Shedder) observe(now time.Time, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sample := latencySample{at: now, latency: latency}
+	if len(s.latencies) < latencySamples {
+		s.latencies = append(s.latencies, sample)
+		return
+	}
+	s.latencies[s.next] = sample
+	s.next = (s.next + 1) % latencySamples
+}
+
+// p99Latency is the 99th percentile of latencies within the window, reused
+// for p99Refresh so busy replicas do not sort on every request
+func (s *Shedder) p99Latency(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.p99At) < p99Refresh {
+		return s.p99
+	}
+
+	recent := make([]time.Duration, 0, len(s.latencies))
+	for _, sample := range s.latencies {
+		if now.Sub(sample.at) <= latencyWindow {
+			recent = append(recent, sample.latency)
+		}
+	}
+	s.p99, s.p99At = 0, now
+	if len(recent) > 0 {
+		sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+		s.p99 = recent[(len(recent)*99)/100]
+	}
+	return s.p99
+}
+
+// record counts an admitted or shed request
+func (s *Shedder) record(priority string, admitted bool, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if admitted {
+		s.admitted[priority]++
+		return
+	}
+	s.shed[priority]++
+	s.lastShed = now
+}
+
+// Stats reports current load, the shedding level and requests admitted and
+// shed by priority
+func (s *Shedder) Stats() ShedStats {
+	now := time.Now()
+	saturation := s.saturation(now)
+	stats := ShedStats{
+		InFlight:        s.inFlight.Load(),
+		MaxInFlight:     s.maxInFlight,
+		P99LatencyMs:    s.p99Latency(now).Milliseconds(),
+		LatencyTargetMs: s.latencyTarget.Milliseconds(),
+		Saturation:      math.Round(saturation*100) / 100,
+		Shedding:        shedLevel(saturation),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats.Admitted = make(map[string]int64, len(s.admitted))
+	for priority, n := range s.admitted {
+		stats.Admitted[priority] = n
+	}
+	stats.Shed = make(map[string]int64, len(s.shed))
+	for priority, n := range s.shed {
+		stats.Shed[priority] = n
+	}
+	if !s.lastShed.IsZero() {
+		lastShed := s.lastShed
+		stats.LastShedAt = &lastShed
+	}
+	return stats
+}
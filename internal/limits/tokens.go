@@ -0,0 +1,27 @@
+package limits
+
+import (
+	"fmt"
+	"time"
+)
+
+// TokenQuotaError refuses a generation whose reserved tokens would take the
+// caller over its plan's monthly token ceiling
+type TokenQuotaError struct {
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`      // Tokens recorded this month
+	Reserved  int64     `json:"reserved"`  // Held by the caller's generations still running
+	Requested int64     `json:"requested"` // The most this generation can use
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+func (e *TokenQuotaError) Error() string {
+	return fmt.Sprintf("this generation may use up to %d tokens; %d of your plan's %d tokens this month are used and %d are reserved by running generations",
+		e.Requested, e.Used, e.Limit, e.Reserved)
+}
+
+// Exhausted reports whether only the month's reset frees enough tokens, as
+// opposed to running generations settling
+func (e *TokenQuotaError) Exhausted() bool {
+	return e.Used+e.Requested > e.Limit
+}
@@ -0,0 +1,591 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/overlay"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// Config holds who counts as depending on a deprecated model and when opted-in
+// tenants' pinned references are rewritten
+type Config struct {
+	UsageWindow   time.Duration // How far back tenant usage is looked at
+	MinRequests   int           // Requests to the model in the window before a tenant counts as using it
+	MinShare      float64       // Share of the tenant's requests the model must take
+	Suggestions   int           // Replacements suggested besides the provider's own
+	MaxCategories int           // Categories of the tenant's mix scored, largest first
+	RewriteLead   time.Duration // How long before shutdown opted-in references are rewritten
+	Interval      time.Duration // How often opted-in tenants are checked
+}
+
+// DefaultConfig returns the built-in thresholds; MIGRATION_MIN_SHARE and
+// MIGRATION_REWRITE_LEAD tune them
+func DefaultConfig() Config {
+	cfg := Config{
+		UsageWindow:   30 * 24 * time.Hour,
+		MinRequests:   20,
+		MinShare:      0.05,
+		Suggestions:   3,
+		MaxCategories: 5,
+		RewriteLead:   14 * 24 * time.Hour,
+		Interval:      time.Hour,
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("MIGRATION_MIN_SHARE"), 64); err == nil && v >= 0 && v <= 1 {
+		cfg.MinShare = v
+	}
+	if d, err := time.ParseDuration(os.Getenv("MIGRATION_REWRITE_LEAD")); err == nil && d >= 0 {
+		cfg.RewriteLead = d
+	}
+	return cfg
+}
+
+// Reasons a deprecated model is listed for a tenant
+const (
+	ReasonPinned   = "pinned"    // The model is in the tenant's catalog overlay include list
+	ReasonHeavyUse = "heavy_use" // The tenant routes a large share of its traffic to the model
+)
+
+// Audit event recorded for each rewritten reference
+const EventRewrite = "migration.rewrite"
+
+var (
+	// ErrNotDeprecated is returned when rewriting a model that is not deprecated
+	ErrNotDeprecated = errors.New("model is not deprecated")
+	// ErrNotPinned is returned when the tenant's overlay does not include the model
+	ErrNotPinned = errors.New("model is not pinned in the catalog overlay")
+	// ErrInvalidReplacement is returned for a replacement outside the live catalog
+	ErrInvalidReplacement = errors.New("replacement must be a different, non-deprecated catalog model")
+	// ErrNoReplacement is returned when no model can stand in for the deprecated one
+	ErrNoReplacement = errors.New("no replacement model available")
+)
+
+// Recommender scores models for a tenant; the router service implements it
+type Recommender interface {
+	GetAllModels() []models.EnhancedModel
+	GetModelByID(id string) (models.EnhancedModel, bool)
+	GetDirectRecommendations(ctx context.Context, req recommendation.RecommendationRequest) recommendation.RecommendationResponse
+	CatalogOverlay(ctx context.Context, userID string) (*overlay.Overlay, error)
+}
+
+// Candidate is a suggested replacement, compared with the deprecated model
+// over the tenant's category mix
+type Candidate struct {
+	ModelID             string   `json:"model_id"`
+	DisplayName         string   `json:"display_name"`
+	Provider            string   `json:"provider"`
+	ProviderReplacement bool     `json:"provider_replacement,omitempty"` // The provider's recommended successor
+	Score               float64  `json:"score"`                          // Overall score weighted by the tenant's category mix
+	ScoreDelta          *float64 `json:"score_delta,omitempty"`          // Versus the deprecated model, where both are eligible; positive is better
+	CostPerRequest      float64  `json:"cost_per_request"`
+	CostDelta           *float64 `json:"cost_delta,omitempty"` // Per request, versus the deprecated model; negative is cheaper
+	CostDeltaPercent    *float64 `json:"cost_delta_percent,omitempty"`
+	Coverage            float64  `json:"coverage"` // Share of the tenant's traffic in categories the candidate is eligible for
+}
+
+// Migration is one deprecated model a tenant depends on, with replacements
+type Migration struct {
+	ModelID     string             `json:"model_id"`
+	DisplayName string             `json:"display_name"`
+	Provider    string             `json:"provider"`
+	Deprecation models.Deprecation `json:"deprecation"`
+	Reason      string             `json:"reason"`
+	Pinned      bool               `json:"pinned"`
+	Requests    int                `json:"requests"` // In the usage window
+	Share       float64            `json:"share"`    // Of the tenant's requests in the window
+	CategoryMix map[string]float64 `json:"category_mix"`
+	Suggestions []Candidate        `json:"suggestions"`
+	RewriteAt   *time.Time         `json:"rewrite_at,omitempty"` // When the pinned reference is rewritten, once opted in
+}
+
+// Rewrite is one pinned reference the assistant replaced
+type Rewrite struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Automatic bool      `json:"automatic"`
+	At        time.Time `json:"at"`
+}
+
+// Assistant finds the deprecated models each tenant depends on, suggests
+// replacements from the tenant's actual traffic and, for tenants that opted
+// in, rewrites pinned references before the model shuts down
+type Assistant struct {
+	db          *sql.DB
+	recommender Recommender
+	overlays    *overlay.Store
+	audit       *audit.Logger
+	cfg         Config
+}
+
+func NewAssistant(db *sql.DB, recommender Recommender, overlays *overlay.Store, auditLogger *audit.Logger, cfg Config) *Assistant {
+	return &Assistant{db: db, recommender: recommender, overlays: overlays, audit: auditLogger, cfg: cfg}
+}
+
+// modelUsage is a tenant's traffic to one model in the usage window
+type modelUsage struct {
+	requests   int
+	categories map[string]int
+}
+
+// Migrations lists the deprecated models the tenant pins or uses heavily,
+// soonest shutdown first
+func (a *Assistant) Migrations(ctx context.Context, userID string) ([]Migration, error) {
+	deprecated := a.deprecatedModels()
+	migrations := []Migration{}
+	if len(deprecated) == 0 {
+		return migrations, nil
+	}
+
+	tenantOverlay, err := a.recommender.CatalogOverlay(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(deprecated))
+	for id := range deprecated {
+		ids = append(ids, id)
+	}
+	usage, total, err := a.usage(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	autoRewrite, err := a.AutoRewrite(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, model := range deprecated {
+		u := usage[id]
+		m := Migration{
+			ModelID:     id,
+			DisplayName: model.DisplayName,
+			Provider:    model.Provider,
+			Deprecation: *model.Deprecation,
+			Pinned:      tenantOverlay != nil && contains(tenantOverlay.Include, id),
+			Requests:    u.requests,
+		}
+		if total > 0 {
+			m.Share = float64(u.requests) / float64(total)
+		}
+		switch {
+		case m.Pinned:
+			m.Reason = ReasonPinned
+		case u.requests >= a.cfg.MinRequests && m.Share >= a.cfg.MinShare:
+			m.Reason = ReasonHeavyUse
+		default:
+			continue
+		}
+
+		m.CategoryMix = categoryMix(model, u.categories, a.cfg.MaxCategories)
+		m.Suggestions = a.suggest(ctx, model, m.CategoryMix, tenantOverlay)
+		if m.Pinned && autoRewrite {
+			at := a.rewriteAt(*model.Deprecation)
+			m.RewriteAt = &at
+		}
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		si, sj := migrations[i].Deprecation.ShutdownDate, migrations[j].Deprecation.ShutdownDate
+		switch {
+		case si != nil && sj != nil && !si.Equal(*sj):
+			return si.Before(*sj)
+		case (si == nil) != (sj == nil):
+			return si != nil
+		}
+		return migrations[i].ModelID < migrations[j].ModelID
+	})
+	return migrations, nil
+}
+
+// deprecatedModels returns the catalog's deprecated models by ID
+func (a *Assistant) deprecatedModels() map[string]models.EnhancedModel {
+	deprecated := make(map[string]models.EnhancedModel)
+	for _, model := range a.recommender.GetAllModels() {
+		if model.Deprecation != nil {
+			deprecated[model.ID] = model
+		}
+	}
+	return deprecated
+}
+
+// usage returns the tenant's requests to each of the models by category, and
+// its total requests, over the usage window
+func (a *Assistant) usage(ctx context.Context, userID string, modelIDs []string) (map[string]modelUsage, int, error) {
+	since := time.Now().Add(-a.cfg.UsageWindow)
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT recommended_model, COALESCE(prompt_category, ''), COUNT(*)
+		FROM api_usage
+		WHERE user_id = $1 AND date_bucket >= $2::date AND recommended_model = ANY($3)
+		GROUP BY 1, 2`, userID, since, pq.Array(modelIDs))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load model usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]modelUsage)
+	for rows.Next() {
+		var model, category string
+		var count int
+		if err := rows.Scan(&model, &category, &count); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan model usage: %w", err)
+		}
+		u, ok := usage[model]
+		if !ok {
+			u.categories = make(map[string]int)
+		}
+		u.requests += count
+		if category != "" {
+			u.categories[models.CanonicalCapability(category)] += count
+		}
+		usage[model] = u
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to load model usage: %w", err)
+	}
+
+	var total int
+	if err := a.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM api_usage WHERE user_id = $1 AND date_bucket >= $2::date`,
+		userID, since).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tenant usage: %w", err)
+	}
+	return usage, total, nil
+}
+
+// categoryMix returns the share of the model's traffic in each of its largest
+// categories. Without categorized traffic, e.g. a model pinned but not yet
+// used, the model's strongest capabilities stand in equally.
+func categoryMix(model models.EnhancedModel, counts map[string]int, maxCategories int) map[string]float64 {
+	type weighted struct {
+		category string
+		weight   float64
+	}
+	var ranked []weighted
+	for category, n := range counts {
+		ranked = append(ranked, weighted{category, float64(n)})
+	}
+	if len(ranked) == 0 {
+		for category, capability := range model.TaskCapabilities.TextTasks {
+			ranked = append(ranked, weighted{models.CanonicalCapability(category), capability.Score})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].weight != ranked[j].weight {
+			return ranked[i].weight > ranked[j].weight
+		}
+		return ranked[i].category < ranked[j].category
+	})
+	if len(ranked) > maxCategories {
+		ranked = ranked[:maxCategories]
+	}
+	if len(ranked) == 0 {
+		ranked = []weighted{{models.CapabilityConversation, 1}}
+	}
+
+	mix := make(map[string]float64, len(ranked))
+	if len(counts) == 0 {
+		for _, w := range ranked {
+			mix[w.category] = 1 / float64(len(ranked))
+		}
+		return mix
+	}
+	var sum float64
+	for _, w := range ranked {
+		sum += w.weight
+	}
+	for _, w := range ranked {
+		mix[w.category] = w.weight / sum
+	}
+	return mix
+}
+
+// categoryScore is one model's result in one category
+type categoryScore struct {
+	score float64
+	cost  float64
+}
+
+// suggest scores every eligible model in each category of the mix, under the
+// tenant's exclusions and negotiated prices, and returns the provider's
+// replacement followed by the best other models
+func (a *Assistant) suggest(ctx context.Context, deprecated models.EnhancedModel, mix map[string]float64, tenantOverlay *overlay.Overlay) []Candidate {
+	// The include list narrows the catalog to what the tenant pins today;
+	// replacements are looked for in the whole catalog
+	var view *overlay.Overlay
+	if tenantOverlay != nil {
+		copied := *tenantOverlay
+		copied.Include = nil
+		view = &copied
+	}
+	taskType := deprecated.ModelType
+	if taskType == "" {
+		taskType = "text"
+	}
+
+	minScore := 0.0
+	scores := make(map[string]map[string]categoryScore) // model -> category -> result
+	for category := range mix {
+		resp := a.recommender.GetDirectRecommendations(ctx, recommendation.RecommendationRequest{
+			TaskType:       taskType,
+			Category:       category,
+			Complexity:     "medium",
+			Priority:       "balanced",
+			Overlay:        view,
+			MaxResults:     maxScored,
+			PlanMaxResults: maxScored,
+			MinScore:       &minScore,
+		})
+		for _, rec := range resp.Recommendations {
+			if scores[rec.Model.ID] == nil {
+				scores[rec.Model.ID] = make(map[string]categoryScore)
+			}
+			scores[rec.Model.ID][category] = categoryScore{score: rec.OverallScore, cost: rec.CostEstimate}
+		}
+	}
+
+	baseline := scores[deprecated.ID]
+	var candidates []Candidate
+	for modelID, byCategory := range scores {
+		model, ok := a.recommender.GetModelByID(modelID)
+		if !ok || modelID == deprecated.ID || model.Deprecation != nil {
+			continue
+		}
+		c := Candidate{
+			ModelID:             modelID,
+			DisplayName:         model.DisplayName,
+			Provider:            model.Provider,
+			ProviderReplacement: modelID == deprecated.Deprecation.Replacement,
+		}
+		var scoreDelta, costDelta, baseCost, overlap float64
+		for category, share := range mix {
+			result, eligible := byCategory[category]
+			if !eligible {
+				continue
+			}
+			c.Coverage += share
+			c.Score += share * result.score
+			c.CostPerRequest += share * result.cost
+			if base, ok := baseline[category]; ok {
+				overlap += share
+				scoreDelta += share * (result.score - base.score)
+				costDelta += share * (result.cost - base.cost)
+				baseCost += share * base.cost
+			}
+		}
+		if c.Coverage > 0 {
+			c.CostPerRequest /= c.Coverage
+		}
+		if overlap > 0 {
+			sd, cd := scoreDelta/overlap, costDelta/overlap
+			c.ScoreDelta, c.CostDelta = &sd, &cd
+			if baseCost > 0 {
+				pct := costDelta / baseCost * 100
+				c.CostDeltaPercent = &pct
+			}
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].ModelID < candidates[j].ModelID
+	})
+	suggestions := []Candidate{}
+	others := 0
+	for _, c := range candidates {
+		if c.ProviderReplacement {
+			suggestions = append([]Candidate{c}, suggestions...)
+		} else if others < a.cfg.Suggestions {
+			suggestions = append(suggestions, c)
+			others++
+		}
+	}
+	return suggestions
+}
+
+// maxScored bounds the recommendations scored per category
+const maxScored = 100
+
+// rewriteAt is when opted-in references to the model are rewritten: the
+// rewrite lead before shutdown, or right away without a shutdown date
+func (a *Assistant) rewriteAt(d models.Deprecation) time.Time {
+	if d.ShutdownDate == nil {
+		return d.AnnouncedAt
+	}
+	return d.ShutdownDate.Add(-a.cfg.RewriteLead)
+}
+
+// Apply rewrites the tenant's pinned reference to a deprecated model, to
+// replacement or else the top suggestion, and records it in the audit log
+func (a *Assistant) Apply(ctx context.Context, userID, modelID, replacement string, automatic bool) (*Rewrite, error) {
+	model, ok := a.recommender.GetModelByID(modelID)
+	if !ok || model.Deprecation == nil {
+		return nil, ErrNotDeprecated
+	}
+	if replacement == "" {
+		tenantOverlay, err := a.recommender.CatalogOverlay(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		usage, _, err := a.usage(ctx, userID, []string{modelID})
+		if err != nil {
+			return nil, err
+		}
+		suggestions := a.suggest(ctx, model, categoryMix(model, usage[modelID].categories, a.cfg.MaxCategories), tenantOverlay)
+		if len(suggestions) == 0 {
+			return nil, ErrNoReplacement
+		}
+		replacement = suggestions[0].ModelID
+	} else if target, ok := a.recommender.GetModelByID(replacement); !ok || target.Deprecation != nil || replacement == modelID {
+		return nil, ErrInvalidReplacement
+	}
+
+	replaced, err := a.overlays.ReplaceIncluded(ctx, userID, modelID, replacement)
+	if err != nil {
+		return nil, err
+	}
+	if !replaced {
+		return nil, ErrNotPinned
+	}
+
+	rewrite := &Rewrite{From: modelID, To: replacement, Automatic: automatic, At: time.Now()}
+	action := "manual"
+	if automatic {
+		action = "automatic"
+	}
+	if _, err := a.audit.Record(ctx, audit.Entry{
+		UserID:    userID,
+		EventType: EventRewrite,
+		Action:    action,
+		Resource:  modelID,
+		Details:   map[string]interface{}{"from": modelID, "to": replacement},
+	}); err != nil {
+		log.Printf("[MIGRATION] Failed to audit rewrite of %s for tenant %s: %v", modelID, userID, err)
+	}
+	log.Printf("[MIGRATION] Rewrote %s to %s for tenant %s (%s)", modelID, replacement, userID, action)
+	return rewrite, nil
+}
+
+// Rewrites lists the tenant's rewritten references, newest first
+func (a *Assistant) Rewrites(ctx context.Context, userID string, limit int) ([]Rewrite, error) {
+	entries, err := a.audit.List(ctx, audit.Filter{UserID: userID, EventPrefix: EventRewrite, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	rewrites := make([]Rewrite, 0, len(entries))
+	for _, e := range entries {
+		from, _ := e.Details["from"].(string)
+		to, _ := e.Details["to"].(string)
+		rewrites = append(rewrites, Rewrite{From: from, To: to, Automatic: e.Action == "automatic", At: e.CreatedAt})
+	}
+	return rewrites, nil
+}
+
+// AutoRewrite reports whether the tenant opted in to automatic rewrites
+func (a *Assistant) AutoRewrite(ctx context.Context, userID string) (bool, error) {
+	var enabled bool
+	err := a.db.QueryRowContext(ctx, `SELECT auto_rewrite FROM migration_settings WHERE user_id = $1`, userID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load migration settings: %w", err)
+	}
+	return enabled, nil
+}
+
+// SetAutoRewrite opts the tenant in to or out of automatic rewrites
+func (a *Assistant) SetAutoRewrite(ctx context.Context, userID string, enabled bool) error {
+	_, err := a.db.ExecContext(ctx, `
+		INSERT INTO migration_settings (user_id, auto_rewrite) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET auto_rewrite = EXCLUDED.auto_rewrite, updated_at = CURRENT_TIMESTAMP`,
+		userID, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to store migration settings: %w", err)
+	}
+	return nil
+}
+
+// Start rewrites opted-in tenants' due references on the configured interval
+// until ctx is cancelled
+func (a *Assistant) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(a.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.RewriteDue(ctx)
+			}
+		}
+	}()
+}
+
+// RewriteDue rewrites the pinned references of opted-in tenants to deprecated
+// models whose rewrite time has come
+func (a *Assistant) RewriteDue(ctx context.Context) {
+	now := time.Now()
+	var due []string
+	for id, model := range a.deprecatedModels() {
+		if !a.rewriteAt(*model.Deprecation).After(now) {
+			due = append(due, id)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT o.user_id::text, m.model_id
+		FROM migration_settings s
+		JOIN catalog_overlays o ON o.user_id = s.user_id
+		CROSS JOIN unnest($1::text[]) AS m(model_id)
+		WHERE s.auto_rewrite AND o.include ? m.model_id`, pq.Array(due))
+	if err != nil {
+		log.Printf("[MIGRATION] Failed to find references to rewrite: %v", err)
+		return
+	}
+	type reference struct{ userID, modelID string }
+	var refs []reference
+	for rows.Next() {
+		var ref reference
+		if err := rows.Scan(&ref.userID, &ref.modelID); err != nil {
+			log.Printf("[MIGRATION] Failed to scan reference: %v", err)
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	rows.Close()
+
+	for _, ref := range refs {
+		// Another replica may have rewritten it already; ErrNotPinned is expected then
+		if _, err := a.Apply(ctx, ref.userID, ref.modelID, "", true); err != nil && err != ErrNotPinned {
+			log.Printf("[MIGRATION] Failed to rewrite %s for tenant %s: %v", ref.modelID, ref.userID, err)
+		}
+	}
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// maxNotesLength bounds an operator's deprecation notes
+const maxNotesLength = 2000
+
+// DeprecationSetter shows deprecations on the served catalog
+type DeprecationSetter interface {
+	SetDeprecations(deprecations map[string]models.Deprecation)
+}
+
+// ModelDeprecation is one model's deprecation as operators record it
+type ModelDeprecation struct {
+	ModelID string `json:"model_id"`
+	models.Deprecation
+}
+
+// Validate rejects deprecations that cannot be applied
+func (d ModelDeprecation) Validate() error {
+	if strings.TrimSpace(d.ModelID) == "" {
+		return errors.New("model_id is required")
+	}
+	if d.Replacement == d.ModelID {
+		return errors.New("a model cannot replace itself")
+	}
+	if d.ShutdownDate != nil && d.ShutdownDate.Before(d.AnnouncedAt) {
+		return errors.New("shutdown_date must not be before announced_at")
+	}
+	if len(d.Notes) > maxNotesLength {
+		return fmt.Errorf("notes exceed %d characters", maxNotesLength)
+	}
+	return nil
+}
+
+// Deprecations persists operator-recorded model deprecations and keeps every
+// replica's catalog in step with them
+type Deprecations struct {
+	db      *sql.DB
+	catalog DeprecationSetter
+}
+
+func NewDeprecations(db *sql.DB, catalog DeprecationSetter) *Deprecations {
+	return &Deprecations{db: db, catalog: catalog}
+}
+
+// Start loads the deprecations and reloads them every interval, so changes
+// made through another replica take effect here too
+func (d *Deprecations) Start(ctx context.Context, interval time.Duration) {
+	if err := d.Sync(ctx); err != nil {
+		log.Printf("[MIGRATION] %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.Sync(ctx); err != nil {
+					log.Printf("[MIGRATION] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Sync replaces the catalog's deprecations with the stored ones
+func (d *Deprecations) Sync(ctx context.Context) error {
+	list, err := d.List(ctx)
+	if err != nil {
+		return err
+	}
+	deprecations := make(map[string]models.Deprecation, len(list))
+	for _, md := range list {
+		deprecations[md.ModelID] = md.Deprecation
+	}
+	d.catalog.SetDeprecations(deprecations)
+	return nil
+}
+
+// List returns every recorded deprecation, soonest shutdown first
+func (d *Deprecations) List(ctx context.Context) ([]ModelDeprecation, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT model_id, announced_at, shutdown_date, COALESCE(replacement, ''), COALESCE(notes, '')
+		FROM model_deprecations
+		ORDER BY shutdown_date NULLS LAST, model_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model deprecations: %w", err)
+	}
+	defer rows.Close()
+
+	list := []ModelDeprecation{}
+	for rows.Next() {
+		var md ModelDeprecation
+		var shutdown sql.NullTime
+		if err := rows.Scan(&md.ModelID, &md.AnnouncedAt, &shutdown, &md.Replacement, &md.Notes); err != nil {
+			return nil, fmt.Errorf("failed to scan model deprecation: %w", err)
+		}
+		if shutdown.Valid {
+			t := shutdown.Time
+			md.ShutdownDate = &t
+		}
+		list = append(list, md)
+	}
+	return list, rows.Err()
+}
+
+// Put records or replaces a model's deprecation
+func (d *Deprecations) Put(ctx context.Context, md ModelDeprecation) error {
+	if err := md.Validate(); err != nil {
+		return err
+	}
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO model_deprecations (model_id, announced_at, shutdown_date, replacement, notes)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+		ON CONFLICT (model_id) DO UPDATE SET
+			announced_at = EXCLUDED.announced_at,
+			shutdown_date = EXCLUDED.shutdown_date,
+			replacement = EXCLUDED.replacement,
+			notes = EXCLUDED.notes,
+			updated_at = CURRENT_TIMESTAMP`,
+		md.ModelID, md.AnnouncedAt, md.ShutdownDate, md.Replacement, md.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to store model deprecation: %w", err)
+	}
+	return d.Sync(ctx)
+}
+
+// Delete withdraws a model's deprecation
+func (d *Deprecations) Delete(ctx context.Context, modelID string) (bool, error) {
+	res, err := d.db.ExecContext(ctx, `DELETE FROM model_deprecations WHERE model_id = $1`, modelID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete model deprecation: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, d.Sync(ctx)
+}
@@ -0,0 +1,224 @@
+package migration
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recentRewrites bounds the rewrites listed next to a tenant's migrations
+const recentRewrites = 20
+
+// Handlers exposes the migration assistant to tenants on the dashboard and
+// deprecation records to admins
+type Handlers struct {
+	assistant    *Assistant
+	deprecations *Deprecations
+}
+
+func NewHandlers(assistant *Assistant, deprecations *Deprecations) *Handlers {
+	return &Handlers{assistant: assistant, deprecations: deprecations}
+}
+
+// Migrations lists the deprecated models the caller depends on with suggested
+// replacements, whether automatic rewrites are on and recent rewrites
+func (h *Handlers) Migrations(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetString("user_id")
+
+	migrations, err := h.assistant.Migrations(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load model migrations",
+			"details": err.Error(),
+		})
+		return
+	}
+	autoRewrite, err := h.assistant.AutoRewrite(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load migration settings",
+			"details": err.Error(),
+		})
+		return
+	}
+	rewrites, err := h.assistant.Rewrites(ctx, userID, recentRewrites)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load model rewrites",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"migrations":   migrations,
+			"auto_rewrite": autoRewrite,
+			"rewrite_lead": h.assistant.cfg.RewriteLead.String(),
+			"rewrites":     rewrites,
+		},
+	})
+}
+
+// UpdateSettings opts the caller in to or out of automatic rewrites of pinned
+// deprecated models
+func (h *Handlers) UpdateSettings(c *gin.Context) {
+	var req struct {
+		AutoRewrite *bool `json:"auto_rewrite" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.assistant.SetAutoRewrite(c.Request.Context(), c.GetString("user_id"), *req.AutoRewrite); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update migration settings",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"auto_rewrite": *req.AutoRewrite},
+	})
+}
+
+// Apply rewrites the caller's pinned reference to a deprecated model now, to
+// the given replacement or else the top suggestion
+func (h *Handlers) Apply(c *gin.Context) {
+	var req struct {
+		Replacement string `json:"replacement"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	rewrite, err := h.assistant.Apply(c.Request.Context(), c.GetString("user_id"), c.Param("model_id"), req.Replacement, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotDeprecated), errors.Is(err, ErrNotPinned):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nothing to migrate", "details": err.Error()})
+		case errors.Is(err, ErrInvalidReplacement):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid replacement", "details": err.Error()})
+		case errors.Is(err, ErrNoReplacement):
+			c.JSON(http.StatusConflict, gin.H{"error": "No replacement available", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rewrite model reference", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rewrite,
+	})
+}
+
+// ListDeprecations returns every recorded deprecation
+func (h *Handlers) ListDeprecations(c *gin.Context) {
+	list, err := h.deprecations.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list model deprecations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// PutDeprecation records or replaces a catalog model's deprecation
+func (h *Handlers) PutDeprecation(c *gin.Context) {
+	var req ModelDeprecation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.ModelID = c.Param("model_id")
+	if req.AnnouncedAt.IsZero() {
+		req.AnnouncedAt = time.Now().UTC()
+	}
+
+	if _, ok := h.assistant.recommender.GetModelByID(req.ModelID); !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Model not found",
+			"details": "model_id must be a catalog model",
+		})
+		return
+	}
+	if req.Replacement != "" {
+		if _, ok := h.assistant.recommender.GetModelByID(req.Replacement); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid model deprecation",
+				"details": "replacement must be a catalog model",
+			})
+			return
+		}
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid model deprecation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.deprecations.Put(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to store model deprecation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    req,
+	})
+}
+
+// DeleteDeprecation withdraws a model's deprecation
+func (h *Handlers) DeleteDeprecation(c *gin.Context) {
+	deleted, err := h.deprecations.Delete(c.Request.Context(), c.Param("model_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete model deprecation",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Model deprecation not found",
+			"details": "the model has no recorded deprecation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Model deprecation withdrawn",
+	})
+}
@@ -0,0 +1,48 @@
+package mirror
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes the staging comparison report to operators
+type Handlers struct {
+	mirror *Mirror
+}
+
+func NewHandlers(mirror *Mirror) *Handlers {
+	return &Handlers{mirror: mirror}
+}
+
+// Report shows where staging and production recommendations diverge;
+// ?limit bounds the divergent requests listed (default 50)
+func (h *Handlers) Report(c *gin.Context) {
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > recentSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid limit",
+				"details": "limit must be between 0 and " + strconv.Itoa(recentSize),
+			})
+			return
+		}
+		limit = n
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.mirror.Report(limit),
+	})
+}
+
+// Reset starts a new comparison window, e.g. after a staging deploy
+func (h *Handlers) Reset(c *gin.Context) {
+	h.mirror.Reset()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Mirror comparison reset",
+	})
+}
@@ -0,0 +1,286 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/trace"
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// HeaderMirrored marks requests sent by a mirror. Routers never mirror them
+// again, so a staging deployment pointed at itself cannot loop.
+const HeaderMirrored = "X-Router-Mirror"
+
+const (
+	// maxBodyBytes bounds the request and response bodies kept per mirrored request
+	maxBodyBytes = 1 << 20
+	// queueSize is how many requests may wait for staging; more are dropped
+	queueSize = 256
+	// workers send mirrored requests to staging concurrently
+	workers = 4
+)
+
+// recommendPaths are the production routes whose traffic is mirrored
+var recommendPaths = map[string]bool{
+	"/api/v2/recommend/smart":  true,
+	"/api/v2/recommend/direct": true,
+}
+
+// Config selects where traffic is mirrored and how much of it
+type Config struct {
+	StagingURL    string        // Base URL of the staging router
+	Percent       float64       // Share of recommend requests mirrored, 0-100
+	RedactPrompts bool          // Mask PII in prompts and context before they leave
+	Timeout       time.Duration // Per staging request
+}
+
+// PromptScrubber applies a tenant's prompt logging policy. Prompts of
+// tenants that do not allow verbatim logging are never mirrored.
+type PromptScrubber interface {
+	ScrubPrompt(ctx context.Context, userID, prompt string) (stored string, verbatim bool)
+}
+
+// job is a production request and the response production gave
+type job struct {
+	requestID   string // Production's request ID, sent to staging to pair the two
+	path        string
+	userID      string
+	body        []byte
+	prod        []byte
+	prodLatency time.Duration
+}
+
+// Mirror copies a sample of production recommend requests to a staging
+// router after production has answered, and compares the two rankings. The
+// caller never waits on staging.
+type Mirror struct {
+	cfg      Config
+	client   *http.Client
+	scrubber PromptScrubber
+	queue    chan job
+	report   *reportState
+}
+
+func NewMirror(cfg Config) *Mirror {
+	cfg.StagingURL = strings.TrimRight(cfg.StagingURL, "/")
+	// Each request is mirrored once; a retry would skew staging's load
+	return &Mirror{
+		cfg:    cfg,
+		client: transport.SingleAttemptClient("mirror"),
+		queue:  make(chan job, queueSize),
+		report: newReportState(),
+	}
+}
+
+// SetPromptScrubber enforces tenant prompt logging policies on mirrored prompts
+func (m *Mirror) SetPromptScrubber(scrubber PromptScrubber) {
+	m.scrubber = scrubber
+}
+
+// Start runs the workers that send queued requests to staging until ctx ends
+func (m *Mirror) Start(ctx context.Context) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-m.queue:
+					m.mirror(trace.WithID(ctx, j.requestID), j)
+				}
+			}
+		}()
+	}
+}
+
+// Middleware samples successful recommend requests and queues them, with
+// production's response, for staging. It must run after the auth
+// middleware so tenant privacy policies can be applied.
+func (m *Mirror) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || !recommendPaths[c.Request.URL.Path] ||
+			c.GetHeader(HeaderMirrored) != "" || c.Request.Body == nil ||
+			rand.Float64()*100 >= m.cfg.Percent {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodyBytes+1))
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+		if err != nil || len(body) > maxBodyBytes {
+			c.Next()
+			return
+		}
+
+		w := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		start := time.Now()
+		c.Next()
+
+		if w.Status() != http.StatusOK || w.truncated {
+			return
+		}
+		j := job{
+			requestID:   c.GetString("request_id"),
+			path:        c.Request.URL.Path,
+			userID:      c.GetString("user_id"),
+			body:        body,
+			prod:        w.body.Bytes(),
+			prodLatency: time.Since(start),
+		}
+		select {
+		case m.queue <- j:
+		default:
+			m.report.count(&m.report.dropped)
+		}
+	}
+}
+
+// captureWriter keeps a copy of the response body
+type captureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) capture(b []byte) {
+	if w.body.Len()+len(b) > maxBodyBytes {
+		w.truncated = true
+		return
+	}
+	w.body.Write(b)
+}
+
+// mirror sends one request to staging and records how its ranking compares
+func (m *Mirror) mirror(ctx context.Context, j job) {
+	body, redacted, ok := m.prepare(ctx, j)
+	if !ok {
+		m.report.count(&m.report.skipped)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	staging, err := m.send(ctx, j.path, body)
+	if err != nil {
+		trace.Logf(ctx, "[MIRROR] Staging request failed: %v", err)
+		m.report.count(&m.report.failed)
+		return
+	}
+	stagingLatency := time.Since(start)
+
+	prodRanking, err := parseRanking(j.prod)
+	if err != nil {
+		m.report.count(&m.report.failed)
+		return
+	}
+	stagingRanking, err := parseRanking(staging)
+	if err != nil {
+		trace.Logf(ctx, "[MIRROR] Unreadable staging response: %v", err)
+		m.report.count(&m.report.failed)
+		return
+	}
+
+	cmp := compare(prodRanking, stagingRanking)
+	cmp.At = time.Now()
+	cmp.Path = j.path
+	cmp.Redacted = redacted
+	cmp.ProdLatencyMs = float64(j.prodLatency.Microseconds()) / 1000
+	cmp.StagingLatencyMs = float64(stagingLatency.Microseconds()) / 1000
+	m.report.record(cmp)
+}
+
+// prepare strips the caller's identity from a request body and applies the
+// PII controls. ok is false when the request must not leave production.
+func (m *Mirror) prepare(ctx context.Context, j job) (body []byte, redacted, ok bool) {
+	var req map[string]interface{}
+	if err := json.Unmarshal(j.body, &req); err != nil {
+		return nil, false, false
+	}
+	delete(req, "user_id")
+
+	for _, field := range []string{"prompt", "context"} {
+		text, _ := req[field].(string)
+		if text == "" {
+			continue
+		}
+		if m.scrubber != nil && j.userID != "" {
+			if _, verbatim := m.scrubber.ScrubPrompt(ctx, j.userID, text); !verbatim {
+				return nil, false, false
+			}
+		}
+		if m.cfg.RedactPrompts {
+			masked, changed := Redact(text)
+			req[field] = masked
+			redacted = redacted || changed
+		}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, false, false
+	}
+	return body, redacted, true
+}
+
+func (m *Mirror) send(ctx context.Context, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.StagingURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build staging request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderMirrored, "1")
+	trace.Inject(req)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staging response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("staging returned %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+// Report summarizes the comparisons since the last reset, with up to limit
+// of the most recent divergent requests
+func (m *Mirror) Report(limit int) Report {
+	r := m.report.snapshot(limit)
+	r.StagingURL = m.cfg.StagingURL
+	r.Percent = m.cfg.Percent
+	r.RedactPrompts = m.cfg.RedactPrompts
+	return r
+}
+
+// Reset clears the comparisons, e.g. after deploying new code to staging
+func (m *Mirror) Reset() {
+	m.report.reset()
+}
@@ -0,0 +1,29 @@
+package mirror
+
+import "regexp"
+
+// piiPatterns mask personal data and credentials in prompts leaving for
+// staging. Order matters: card numbers are masked before the shorter phone
+// pattern can match part of them.
+var piiPatterns = []struct {
+	placeholder string
+	re          *regexp.Regexp
+}{
+	{"[SECRET]", regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}\b|\bAKIA[0-9A-Z]{16}\b|\bgh[pousr]_[A-Za-z0-9]{36}\b`)},
+	{"[EMAIL]", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{"[CARD]", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)},
+	{"[SSN]", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{"[PHONE]", regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?\(?\b\d{3}\)?[ .-]?\d{3}[ .-]?\d{4}\b`)},
+	{"[IP]", regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`)},
+}
+
+// Redact replaces emails, phone, card and social security numbers, IP
+// addresses and API keys with placeholders and reports whether anything was
+// replaced
+func Redact(text string) (string, bool) {
+	redacted := text
+	for _, p := range piiPatterns {
+		redacted = p.re.ReplaceAllString(redacted, p.placeholder)
+	}
+	return redacted, redacted != text
+}
@@ -0,0 +1,326 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// compareDepth is how many of each side's top models are compared
+	compareDepth = 5
+	// recentSize is how many divergent comparisons are kept for the report
+	recentSize = 200
+	// maxSwaps bounds how many distinct top-model swaps are counted
+	maxSwaps = 1000
+)
+
+// ranking is a recommend response reduced to what is compared
+type ranking struct {
+	category string
+	models   []string
+	topScore float64
+}
+
+// parseRanking reads the ranked model IDs from a smart recommendation
+// (data.recommendations.recommendations) or direct recommendation
+// (data.recommendations) response
+func parseRanking(body []byte) (ranking, error) {
+	var resp struct {
+		Data struct {
+			Classification struct {
+				Category string `json:"category"`
+			} `json:"classification"`
+			Recommendations json.RawMessage `json:"recommendations"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ranking{}, fmt.Errorf("failed to parse recommend response: %w", err)
+	}
+
+	type scored struct {
+		Model struct {
+			ID string `json:"id"`
+		} `json:"model"`
+		OverallScore float64 `json:"overall_score"`
+	}
+	var recs []scored
+	if err := json.Unmarshal(resp.Data.Recommendations, &recs); err != nil {
+		var nested struct {
+			Recommendations []scored `json:"recommendations"`
+		}
+		if err := json.Unmarshal(resp.Data.Recommendations, &nested); err != nil {
+			return ranking{}, fmt.Errorf("response has no recommendations")
+		}
+		recs = nested.Recommendations
+	}
+
+	r := ranking{category: resp.Data.Classification.Category}
+	for i, rec := range recs {
+		if i == compareDepth {
+			break
+		}
+		r.models = append(r.models, rec.Model.ID)
+	}
+	if len(recs) > 0 {
+		r.topScore = recs[0].OverallScore
+	}
+	return r, nil
+}
+
+// Comparison is how staging's answer to one mirrored request differed from production's
+type Comparison struct {
+	At               time.Time `json:"at"`
+	Path             string    `json:"path"`
+	Category         string    `json:"category,omitempty"`         // Production's classification
+	StagingCategory  string    `json:"staging_category,omitempty"` // Staging's, when it differs
+	Prod             []string  `json:"prod"`                       // Production's top models, best first
+	Staging          []string  `json:"staging"`
+	TopMatch         bool      `json:"top_match"`
+	Overlap          float64   `json:"overlap"`            // Share of production's top models staging also ranked in its top
+	ScoreDelta       float64   `json:"score_delta"`        // Staging's top score minus production's
+	Redacted         bool      `json:"redacted,omitempty"` // PII was masked before forwarding, which can shift classification
+	ProdLatencyMs    float64   `json:"prod_latency_ms"`
+	StagingLatencyMs float64   `json:"staging_latency_ms"`
+}
+
+func compare(prod, staging ranking) Comparison {
+	cmp := Comparison{
+		Category:   prod.category,
+		Prod:       prod.models,
+		Staging:    staging.models,
+		ScoreDelta: staging.topScore - prod.topScore,
+	}
+	if staging.category != prod.category {
+		cmp.StagingCategory = staging.category
+	}
+	if cmp.Prod == nil {
+		cmp.Prod = []string{}
+	}
+	if cmp.Staging == nil {
+		cmp.Staging = []string{}
+	}
+
+	switch {
+	case len(prod.models) == 0 && len(staging.models) == 0:
+		cmp.TopMatch, cmp.Overlap = true, 1
+	case len(prod.models) == 0 || len(staging.models) == 0:
+	default:
+		cmp.TopMatch = prod.models[0] == staging.models[0]
+		inStaging := make(map[string]bool, len(staging.models))
+		for _, id := range staging.models {
+			inStaging[id] = true
+		}
+		shared := 0
+		for _, id := range prod.models {
+			if inStaging[id] {
+				shared++
+			}
+		}
+		cmp.Overlap = float64(shared) / float64(len(prod.models))
+	}
+	return cmp
+}
+
+// Report is the running comparison of staging against production
+type Report struct {
+	StagingURL    string    `json:"staging_url"`
+	Percent       float64   `json:"percent"`
+	RedactPrompts bool      `json:"redact_prompts"`
+	Since         time.Time `json:"since"`
+
+	Compared int64 `json:"compared"`
+	Dropped  int64 `json:"dropped"` // Staging queue was full
+	Skipped  int64 `json:"skipped"` // Tenant policy forbids the prompt leaving production
+	Failed   int64 `json:"failed"`  // Staging errored, timed out or answered unreadably
+
+	TopMatchRate         float64 `json:"top_match_rate"`
+	MeanOverlap          float64 `json:"mean_overlap"`
+	CategoryMismatchRate float64 `json:"category_mismatch_rate"`
+	MeanScoreDelta       float64 `json:"mean_score_delta"`
+	MeanProdLatencyMs    float64 `json:"mean_prod_latency_ms"`
+	MeanStagingLatencyMs float64 `json:"mean_staging_latency_ms"`
+
+	ByCategory []CategoryDivergence `json:"by_category"`
+	TopSwaps   []Swap               `json:"top_swaps"` // Most frequent production -> staging top model changes
+	Divergent  []Comparison         `json:"divergent"` // Most recent requests whose top model changed, newest first
+}
+
+// CategoryDivergence is how often staging agrees with production within one category
+type CategoryDivergence struct {
+	Category     string  `json:"category"`
+	Compared     int64   `json:"compared"`
+	TopMatchRate float64 `json:"top_match_rate"`
+	MeanOverlap  float64 `json:"mean_overlap"`
+}
+
+// Swap counts requests where production's top model was replaced by staging's
+type Swap struct {
+	Prod    string `json:"prod"`
+	Staging string `json:"staging"`
+	Count   int64  `json:"count"`
+}
+
+type categoryTotals struct {
+	compared   int64
+	topMatches int64
+	overlap    float64
+}
+
+// reportState accumulates comparisons between resets
+type reportState struct {
+	mu    sync.Mutex
+	since time.Time
+
+	compared, dropped, skipped, failed int64
+
+	topMatches       int64
+	categoryMismatch int64
+	overlap          float64
+	scoreDelta       float64
+	prodLatency      float64
+	stagingLatency   float64
+
+	categories map[string]*categoryTotals
+	swaps      map[[2]string]int64
+	divergent  [recentSize]Comparison
+	next       int
+}
+
+func newReportState() *reportState {
+	s := &reportState{}
+	s.reset()
+	return s
+}
+
+func (s *reportState) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = time.Now()
+	s.compared, s.dropped, s.skipped, s.failed = 0, 0, 0, 0
+	s.topMatches, s.categoryMismatch = 0, 0
+	s.overlap, s.scoreDelta, s.prodLatency, s.stagingLatency = 0, 0, 0, 0
+	s.categories = make(map[string]*categoryTotals)
+	s.swaps = make(map[[2]string]int64)
+	s.divergent = [recentSize]Comparison{}
+	s.next = 0
+}
+
+func (s *reportState) count(counter *int64) {
+	s.mu.Lock()
+	*counter++
+	s.mu.Unlock()
+}
+
+func (s *reportState) record(cmp Comparison) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.compared++
+	s.overlap += cmp.Overlap
+	s.scoreDelta += cmp.ScoreDelta
+	s.prodLatency += cmp.ProdLatencyMs
+	s.stagingLatency += cmp.StagingLatencyMs
+	if cmp.StagingCategory != "" {
+		s.categoryMismatch++
+	}
+
+	category := cmp.Category
+	if category == "" {
+		category = "unclassified"
+	}
+	ct, ok := s.categories[category]
+	if !ok {
+		ct = &categoryTotals{}
+		s.categories[category] = ct
+	}
+	ct.compared++
+	ct.overlap += cmp.Overlap
+
+	if cmp.TopMatch {
+		s.topMatches++
+		ct.topMatches++
+		return
+	}
+
+	swap := [2]string{first(cmp.Prod), first(cmp.Staging)}
+	if _, ok := s.swaps[swap]; ok || len(s.swaps) < maxSwaps {
+		s.swaps[swap]++
+	}
+	s.divergent[s.next] = cmp
+	s.next = (s.next + 1) % recentSize
+}
+
+func (s *reportState) snapshot(limit int) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := Report{
+		Since:      s.since,
+		Compared:   s.compared,
+		Dropped:    s.dropped,
+		Skipped:    s.skipped,
+		Failed:     s.failed,
+		ByCategory: []CategoryDivergence{},
+		TopSwaps:   []Swap{},
+		Divergent:  []Comparison{},
+	}
+	if s.compared > 0 {
+		n := float64(s.compared)
+		r.TopMatchRate = float64(s.topMatches) / n
+		r.MeanOverlap = s.overlap / n
+		r.CategoryMismatchRate = float64(s.categoryMismatch) / n
+		r.MeanScoreDelta = s.scoreDelta / n
+		r.MeanProdLatencyMs = s.prodLatency / n
+		r.MeanStagingLatencyMs = s.stagingLatency / n
+	}
+
+	for category, ct := range s.categories {
+		r.ByCategory = append(r.ByCategory, CategoryDivergence{
+			Category:     category,
+			Compared:     ct.compared,
+			TopMatchRate: float64(ct.topMatches) / float64(ct.compared),
+			MeanOverlap:  ct.overlap / float64(ct.compared),
+		})
+	}
+	// Least agreement first, where staging's scoring changed the most
+	sort.Slice(r.ByCategory, func(i, j int) bool {
+		a, b := r.ByCategory[i], r.ByCategory[j]
+		if a.TopMatchRate != b.TopMatchRate {
+			return a.TopMatchRate < b.TopMatchRate
+		}
+		return a.Category < b.Category
+	})
+
+	for swap, count := range s.swaps {
+		r.TopSwaps = append(r.TopSwaps, Swap{Prod: swap[0], Staging: swap[1], Count: count})
+	}
+	sort.Slice(r.TopSwaps, func(i, j int) bool {
+		a, b := r.TopSwaps[i], r.TopSwaps[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Prod+a.Staging < b.Prod+b.Staging
+	})
+	if len(r.TopSwaps) > 10 {
+		r.TopSwaps = r.TopSwaps[:10]
+	}
+
+	for i := 1; i <= recentSize && len(r.Divergent) < limit; i++ {
+		cmp := s.divergent[(s.next-i+recentSize)%recentSize]
+		if cmp.At.IsZero() {
+			break
+		}
+		r.Divergent = append(r.Divergent, cmp)
+	}
+	return r
+}
+
+func first(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
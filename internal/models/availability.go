@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// Availability is an operator's hold on a model: a manual disable switch and
+// scheduled maintenance windows. Either one takes the model out of rotation.
+type Availability struct {
+	Disabled    bool                `json:"disabled"`
+	Reason      string              `json:"reason,omitempty"`
+	DisabledBy  string              `json:"disabled_by,omitempty"` // Admin user ID
+	DisabledAt  *time.Time          `json:"disabled_at,omitempty"`
+	Until       *time.Time          `json:"until,omitempty"`       // Re-enabled automatically at this time when set
+	Maintenance []MaintenanceWindow `json:"maintenance,omitempty"` // Current and upcoming windows, soonest first
+}
+
+// MaintenanceWindow is a scheduled period the model is out of rotation
+type MaintenanceWindow struct {
+	ID       string    `json:"id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// Active reports whether the window covers t
+func (w MaintenanceWindow) Active(t time.Time) bool {
+	return !t.Before(w.StartsAt) && t.Before(w.EndsAt)
+}
+
+// Unavailable reports whether an operator has taken the model out of rotation
+// at t, and why
+func (m EnhancedModel) Unavailable(t time.Time) (bool, string) {
+	if m.Availability == nil {
+		return false, ""
+	}
+	a := m.Availability
+	if a.Disabled && (a.Until == nil || t.Before(*a.Until)) {
+		if a.Reason == "" {
+			return true, "disabled by an operator"
+		}
+		return true, "disabled: " + a.Reason
+	}
+	for _, w := range a.Maintenance {
+		if !w.Active(t) {
+			continue
+		}
+		reason := fmt.Sprintf("in maintenance until %s", w.EndsAt.UTC().Format(time.RFC3339))
+		if w.Reason != "" {
+			reason += ": " + w.Reason
+		}
+		return true, reason
+	}
+	return false, ""
+}
+
+// SetAvailability replaces the operator holds shown on models. Models missing
+// from availability are back in rotation; like trust tiers, holds survive
+// later fusions and snapshot swaps.
+func (fs *FusionService) SetAvailability(availability map[string]Availability) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceAvailability, fs.catalogBefore())
+
+	fs.availability = availability
+	fs.applyAvailability(fs.fusedModels)
+}
+
+// applyAvailability sets each model's operator hold; the caller holds the
+// write lock
+func (fs *FusionService) applyAvailability(catalog map[string]EnhancedModel) {
+	for modelID, model := range catalog {
+		availability, ok := fs.availability[modelID]
+		switch {
+		case ok:
+			model.Availability = &availability
+		case model.Availability != nil:
+			model.Availability = nil
+		default:
+			continue
+		}
+		catalog[modelID] = model
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InferredCapability is a text task capability derived from benchmark results
+type InferredCapability struct {
+	Capability TaskCapability
+	Benchmarks []string // benchmarks the score was derived from
+}
+
+// CapabilityInferrer derives task capabilities from a model's raw benchmarks
+type CapabilityInferrer interface {
+	InferCapabilities(model EnhancedModel) map[string]InferredCapability
+}
+
+// SetCapabilityInferrer fills missing text task capabilities from benchmarks
+// on every fusion instead of leaving them to the engine's fallback score
+func (fs *FusionService) SetCapabilityInferrer(inferrer CapabilityInferrer) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.capabilityInferrer = inferrer
+	for id, model := range fs.fusedModels {
+		if applyInferredCapabilities(inferrer, &model) > 0 {
+			fs.fusedModels[id] = model
+		}
+	}
+}
+
+// applyInferredCapabilities adds derived scores for categories the model has
+// no capability for. Curated and Analytics AI capabilities are never replaced,
+// and every derived score is recorded in provenance. Returns how many were added.
+// Callers read the inferrer under the service's lock.
+func applyInferredCapabilities(inferrer CapabilityInferrer, model *EnhancedModel) int {
+	if inferrer == nil || model.ModelType != "text" {
+		return 0
+	}
+
+	inferred := inferrer.InferCapabilities(*model)
+	added := 0
+	for name, ic := range inferred {
+		category := CanonicalCapability(name)
+		if _, exists := model.TaskCapabilities.TextTasks[category]; exists {
+			continue
+		}
+
+		// Models are values but their maps are shared with the base catalog; copy before writing
+		if added == 0 {
+			textTasks := make(map[string]TaskCapability, len(model.TaskCapabilities.TextTasks)+len(inferred))
+			for k, v := range model.TaskCapabilities.TextTasks {
+				textTasks[k] = v
+			}
+			model.TaskCapabilities.TextTasks = textTasks
+
+			derived := make(map[string]string, len(model.DataProvenance.DerivedData)+len(inferred))
+			for k, v := range model.DataProvenance.DerivedData {
+				derived[k] = v
+			}
+			model.DataProvenance.DerivedData = derived
+		}
+
+		model.TaskCapabilities.TextTasks[category] = ic.Capability
+		model.DataProvenance.DerivedData["task_capabilities.text_tasks."+category] = fmt.Sprintf(
+			"inferred %s from %d benchmark(s): %s", time.Now().Format("2006-01-02"),
+			len(ic.Benchmarks), strings.Join(ic.Benchmarks, ", "))
+		added++
+	}
+	return added
+}
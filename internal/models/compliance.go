@@ -0,0 +1,189 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Common model licenses. Any lowercase SPDX-style identifier is accepted.
+const (
+	LicenseApache2        = "apache-2.0"
+	LicenseMIT            = "mit"
+	LicenseLlamaCommunity = "llama-community"
+	LicenseGemma          = "gemma"
+	LicenseProprietary    = "proprietary"
+)
+
+// Compliance certifications of the service hosting a model
+const (
+	CertificationSOC2          = "soc2"
+	CertificationISO27001      = "iso27001"
+	CertificationHIPAAEligible = "hipaa_eligible"
+	CertificationGDPR          = "gdpr_dpa" // A GDPR data processing agreement is available
+	CertificationFedRAMP       = "fedramp"
+	CertificationPCIDSS        = "pci_dss"
+)
+
+// Certifications lists the known certifications
+var Certifications = []string{
+	CertificationSOC2, CertificationISO27001, CertificationHIPAAEligible,
+	CertificationGDPR, CertificationFedRAMP, CertificationPCIDSS,
+}
+
+// Compliance requirements, as named in request requirements
+const (
+	RequirementLicenses           = "licenses"              // Licenses the model may have, e.g. ["apache-2.0", "mit"]
+	RequirementNoTrainingOnInputs = "no_training_on_inputs" // The provider must not train on prompts
+	RequirementCertifications     = "certifications"        // Certifications the model must all hold
+)
+
+// ComplianceRequirementNames lists the compliance requirement keys
+var ComplianceRequirementNames = []string{RequirementLicenses, RequirementNoTrainingOnInputs, RequirementCertifications}
+
+// licenseID matches the license identifiers models and requirements use
+var licenseID = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*$`)
+
+// Compliance is a model's license and how its provider handles prompts. An
+// unset field is unknown, which a compliance requirement treats as unmet.
+type Compliance struct {
+	License        string   `json:"license,omitempty"`
+	TrainsOnInputs *bool    `json:"trains_on_inputs,omitempty"` // Whether the provider trains on API prompts by default
+	Certifications []string `json:"certifications,omitempty"`
+}
+
+// Validate rejects unknown certifications and malformed licenses
+func (c *Compliance) Validate() error {
+	if c.License != "" && !licenseID.MatchString(c.License) {
+		return fmt.Errorf("license %q must be a lowercase identifier such as %s", c.License, LicenseApache2)
+	}
+	return validateCertifications(c.Certifications)
+}
+
+// ComplianceRequirements are the licenses, data handling and certifications
+// a request or tenant policy demands of models
+type ComplianceRequirements struct {
+	Licenses           []string `json:"licenses,omitempty"`
+	NoTrainingOnInputs bool     `json:"no_training_on_inputs,omitempty"`
+	Certifications     []string `json:"certifications,omitempty"`
+}
+
+// Empty reports requirements that demand nothing
+func (r ComplianceRequirements) Empty() bool {
+	return len(r.Licenses) == 0 && !r.NoTrainingOnInputs && len(r.Certifications) == 0
+}
+
+// Validate rejects unknown certifications and malformed licenses
+func (r ComplianceRequirements) Validate() error {
+	for _, license := range r.Licenses {
+		if !licenseID.MatchString(strings.ToLower(license)) {
+			return fmt.Errorf("license %q must be a lowercase identifier such as %s", license, LicenseApache2)
+		}
+	}
+	return validateCertifications(r.Certifications)
+}
+
+func validateCertifications(certifications []string) error {
+	for _, certification := range certifications {
+		if !containsFold(Certifications, certification) {
+			return fmt.Errorf("unknown certification %q (valid: %s)", certification, strings.Join(Certifications, ", "))
+		}
+	}
+	return nil
+}
+
+// ComplianceRequirementsFrom reads the compliance requirements out of a
+// request's requirements
+func ComplianceRequirementsFrom(requirements map[string]interface{}) ComplianceRequirements {
+	noTraining, _ := requirements[RequirementNoTrainingOnInputs].(bool)
+	return ComplianceRequirements{
+		Licenses:           stringList(requirements[RequirementLicenses]),
+		NoTrainingOnInputs: noTraining,
+		Certifications:     stringList(requirements[RequirementCertifications]),
+	}
+}
+
+// ValidateComplianceRequirements rejects compliance requirements of the wrong
+// type, unknown certifications and malformed licenses
+func ValidateComplianceRequirements(requirements map[string]interface{}) error {
+	for _, name := range []string{RequirementLicenses, RequirementCertifications} {
+		if v, ok := requirements[name]; ok && !isStringList(v) {
+			return fmt.Errorf("requirements.%s must be a list of strings", name)
+		}
+	}
+	if v, ok := requirements[RequirementNoTrainingOnInputs]; ok {
+		if _, isBool := v.(bool); !isBool {
+			return fmt.Errorf("requirements.%s must be a boolean", RequirementNoTrainingOnInputs)
+		}
+	}
+	return ComplianceRequirementsFrom(requirements).Validate()
+}
+
+// MissingCompliance lists the requirements the model does not meet:
+// "licenses", "no_training_on_inputs" or "certification:<name>". Compliance
+// the catalog does not declare counts as missing.
+func (m EnhancedModel) MissingCompliance(r ComplianceRequirements) []string {
+	if r.Empty() {
+		return nil
+	}
+	c := m.Compliance
+	if c == nil {
+		c = &Compliance{}
+	}
+	var missing []string
+	if len(r.Licenses) > 0 && (c.License == "" || !containsFold(r.Licenses, c.License)) {
+		missing = append(missing, RequirementLicenses)
+	}
+	if r.NoTrainingOnInputs && (c.TrainsOnInputs == nil || *c.TrainsOnInputs) {
+		missing = append(missing, RequirementNoTrainingOnInputs)
+	}
+	for _, certification := range r.Certifications {
+		if !containsFold(c.Certifications, certification) {
+			missing = append(missing, "certification:"+strings.ToLower(certification))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// stringList reads a list of strings set in Go or decoded from JSON
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		values := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func isStringList(v interface{}) bool {
+	switch list := v.(type) {
+	case []string:
+		return true
+	case []interface{}:
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
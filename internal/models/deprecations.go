@@ -0,0 +1,48 @@
+package models
+
+import (
+	"log"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// Deprecation marks a model its provider is retiring
+type Deprecation struct {
+	AnnouncedAt  time.Time  `json:"announced_at"`
+	ShutdownDate *time.Time `json:"shutdown_date,omitempty"` // When the provider stops serving the model
+	Replacement  string     `json:"replacement,omitempty"`   // The successor the provider recommends
+	Notes        string     `json:"notes,omitempty"`
+}
+
+// SetDeprecations replaces the deprecations shown on models. Models missing
+// from deprecations lose their marker; like statuses, deprecations survive
+// later fusions and snapshot swaps.
+func (fs *FusionService) SetDeprecations(deprecations map[string]Deprecation) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceDeprecation, fs.catalogBefore())
+
+	fs.deprecations = deprecations
+	fs.applyDeprecations(fs.fusedModels)
+	if len(deprecations) > 0 {
+		log.Printf("[FUSION] %d deprecated models", len(deprecations))
+	}
+}
+
+// applyDeprecations marks deprecated models and clears the rest; the caller
+// holds the write lock
+func (fs *FusionService) applyDeprecations(catalog map[string]EnhancedModel) {
+	for modelID, model := range catalog {
+		deprecation, deprecated := fs.deprecations[modelID]
+		switch {
+		case deprecated:
+			model.Deprecation = &deprecation
+		case model.Deprecation != nil:
+			model.Deprecation = nil
+		default:
+			continue
+		}
+		catalog[modelID] = model
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"sync"
+	"time"
 )
 
 // EnhancedModel represents the complete model structure from model_1.json
@@ -29,6 +30,52 @@ type EnhancedModel struct {
 	Tags                    []string               `json:"tags"`
 	OpenSource              bool                   `json:"open_source"`
 	DataProvenance          DataProvenance         `json:"data_provenance"`
+	ReasoningEfforts        []ReasoningVariant     `json:"reasoning_efforts,omitempty"`
+	InputModalities         []string               `json:"input_modalities,omitempty"` // "text", "image"; inferred from tags when unset
+	Features                *ModelFeatures         `json:"features,omitempty"`         // API features such as tool use and prompt caching
+	BaseModel               string                 `json:"base_model,omitempty"` // Set on tenant fine-tuned models
+	Endpoint                string                 `json:"endpoint,omitempty"`   // Tenant-specific inference endpoint
+	Endpoints               []ModelEndpoint        `json:"endpoints,omitempty"`  // Regional deployments to fail over between
+	Status                  *ModelStatus           `json:"status,omitempty"`     // Set while a provider incident affects the model
+	TenantAnnotation        *TenantAnnotation      `json:"tenant_annotation,omitempty"` // Set from the caller's catalog overlay
+	PriceChangedAt          *time.Time             `json:"price_changed_at,omitempty"`  // Set when the list price changed recently
+	PreviousPricing         *TextPricing           `json:"previous_pricing,omitempty"`  // List prices before that change
+	Deprecation             *Deprecation           `json:"deprecation,omitempty"`       // Set while the provider is retiring the model
+	Trust                   *Trust                 `json:"trust,omitempty"`             // Trust tier; experimental when unset
+	Availability            *Availability          `json:"availability,omitempty"`      // Set while an operator holds the model out of rotation
+	Sustainability          *Sustainability        `json:"sustainability,omitempty"`    // Measured energy and hosting region, when known
+	PromptTemplate          *PromptTemplate        `json:"prompt_template,omitempty"`   // Applied to generation requests sent to the model
+	HuggingFaceRepo         string                 `json:"huggingface_repo,omitempty"`  // Hub repository of an open model, e.g. "meta-llama/Llama-3.3-70B-Instruct"
+	Locales                 map[string]float64     `json:"locales,omitempty"`           // Output quality 0-1 by locale or language, e.g. {"ja-JP": 0.9}
+	Compliance              *Compliance            `json:"compliance,omitempty"`        // License, data usage and certifications, when declared
+}
+
+// ModelEndpoint is one regional deployment of a model's API, such as an Azure
+// OpenAI region or Anthropic through Bedrock. Lower priorities are tried first.
+type ModelEndpoint struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Region   string `json:"region,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// TenantAnnotation carries a tenant's notes on a model and marks prices
+// replaced by its negotiated pricing
+type TenantAnnotation struct {
+	Notes             string       `json:"notes,omitempty"`
+	NegotiatedPricing bool         `json:"negotiated_pricing,omitempty"`
+	ListPricing       *TextPricing `json:"list_pricing,omitempty"` // Catalog prices before negotiation
+}
+
+// ReasoningVariant describes one reasoning effort level (o-series effort,
+// extended thinking budget, Gemini thinking) with its own cost and latency
+type ReasoningVariant struct {
+	Effort               string   `json:"effort"` // "minimal", "low", "medium", "high"
+	ThinkingBudgetTokens int      `json:"thinking_budget_tokens,omitempty"`
+	AvgReasoningTokens   int      `json:"avg_reasoning_tokens,omitempty"`
+	CostInPer1K          *float64 `json:"cost_in_per_1k,omitempty"`  // overrides base text pricing
+	CostOutPer1K         *float64 `json:"cost_out_per_1k,omitempty"` // reasoning tokens bill as output
+	TimeToFirstTokenMs   *int     `json:"ttft_ms,omitempty"`
 }
 
 // CommunityIntelligence contains community-sourced data
@@ -37,6 +84,7 @@ type CommunityIntelligence struct{
 	DeveloperRating  *float64        `json:"developer_rating,omitempty"`
 	GitHubActivity   GitHubActivity  `json:"github_activity,omitempty"`
 	UsagePatterns    *UsagePatterns  `json:"usage_patterns,omitempty"`
+	HuggingFace      *HubActivity    `json:"huggingface,omitempty"` // Enriched from the Hugging Face Hub for open models
 }
 
 type GitHubActivity struct {
@@ -48,12 +96,22 @@ type UsagePatterns struct {
 	ReportedWeaknesses   []string `json:"reported_weaknesses,omitempty"`
 }
 
+// Patterns returns the usage patterns, empty for models without community data
+func (ci CommunityIntelligence) Patterns() UsagePatterns {
+	if ci.UsagePatterns == nil {
+		return UsagePatterns{}
+	}
+	return *ci.UsagePatterns
+}
+
 // TechnicalSpecs contains model technical specifications
 type TechnicalSpecs struct {
-	ContextWindow int     `json:"context_window"`
-	Parameters    string  `json:"parameters"`
-	MaxResolution *string `json:"max_resolution"`
-	MaxDuration   *string `json:"max_duration"`
+	ContextWindow   int     `json:"context_window"`
+	MaxOutputTokens int     `json:"max_output_tokens,omitempty"` // Most tokens one response may contain
+	Parameters      string  `json:"parameters"`
+	MaxResolution   *string `json:"max_resolution"`
+	MaxDuration     *string `json:"max_duration"`
+	License         string  `json:"license,omitempty"`
 }
 
 // Benchmarks contains performance benchmarks
@@ -126,6 +184,7 @@ type PricingStructure struct {
 	Audio      *AudioPricing     `json:"audio,omitempty"`
 	Generative *GenerativePricing `json:"generative,omitempty"`
 	FreeTier   bool              `json:"free_tier"`
+	Tiers      []PriceTier       `json:"tiers,omitempty"` // Batch and off-peak discounts on text pricing
 
 	// Legacy fields for backward compatibility with model_1.json
 	CostInPer1K          *float64 `json:"cost_in_per_1k,omitempty"`
@@ -209,6 +268,7 @@ type TaskCapability struct {
 	Score           float64  `json:"score"`
 	Confidence      float64  `json:"confidence"`
 	ComplexityRange []string `json:"complexity_range"`
+	Subcategories   map[string]float64 `json:"subcategories,omitempty"` // e.g. coding: "sql", "frontend", "systems"
 }
 
 type GenerativeCapability struct {
@@ -267,6 +327,11 @@ func (s *EnhancedModelService) LoadModels() error {
 			model.Pricing.Text.CostOutPer1K = model.Pricing.CostOutPer1K
 		}
 
+		// Catalog files predate the capability taxonomy; resolve aliases at ingest
+		if n := NormalizeCapabilities(&model); n > 0 {
+			log.Printf("[ENHANCED-MODEL] Renamed %d capability aliases on %s", n, model.ID)
+		}
+
 		s.models[model.ID] = model
 	}
 
@@ -274,6 +339,40 @@ func (s *EnhancedModelService) LoadModels() error {
 	return nil
 }
 
+// ReplaceModels swaps the loaded models for a curated catalog, which later
+// fusions start from instead of the model file
+func (s *EnhancedModelService) ReplaceModels(catalog []EnhancedModel) {
+	loaded := make(map[string]EnhancedModel, len(catalog))
+	for _, model := range catalog {
+		NormalizeCapabilities(&model)
+		loaded[model.ID] = model
+	}
+
+	s.mutex.Lock()
+	s.models = loaded
+	s.mutex.Unlock()
+}
+
+// UpsertModel adds a model to the loaded catalog or replaces the one with its ID
+func (s *EnhancedModelService) UpsertModel(model EnhancedModel) EnhancedModel {
+	NormalizeCapabilities(&model)
+
+	s.mutex.Lock()
+	s.models[model.ID] = model
+	s.mutex.Unlock()
+	return model
+}
+
+// RemoveModel drops a model from the loaded catalog, reporting whether it was there
+func (s *EnhancedModelService) RemoveModel(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, exists := s.models[id]
+	delete(s.models, id)
+	return exists
+}
+
 // GetAllModels returns all loaded models
 func (s *EnhancedModelService) GetAllModels() []EnhancedModel {
 	s.mutex.RLock()
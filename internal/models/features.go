@@ -0,0 +1,291 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// API features a model can support, as named in the features config, the
+// catalog and request requirements
+const (
+	FeatureToolUse              = "tool_use"
+	FeatureVisionInput          = "vision_input"
+	FeaturePromptCaching        = "prompt_caching"
+	FeatureSystemPrompt         = "system_prompt_support"
+	FeatureMaxParallelToolCalls = "max_parallel_tool_calls"
+)
+
+// Where a model's feature flag came from, most authoritative first
+const (
+	FeatureSourceConfigModel    = "config"
+	FeatureSourceCatalog        = "catalog"
+	FeatureSourceConfigProvider = "provider_default"
+	FeatureSourceAnalytics      = "analytics-ai"
+	FeatureSourceInferred       = "inferred"
+)
+
+// BoolFeatures lists the on/off features
+var BoolFeatures = []string{FeatureToolUse, FeatureVisionInput, FeaturePromptCaching, FeatureSystemPrompt}
+
+// ModelFeatures are the API features a model supports. A nil flag is unknown,
+// which a hard requirement treats as unsupported.
+type ModelFeatures struct {
+	ToolUse              *bool             `json:"tool_use,omitempty"`
+	VisionInput          *bool             `json:"vision_input,omitempty"`
+	PromptCaching        *bool             `json:"prompt_caching,omitempty"`
+	SystemPromptSupport  *bool             `json:"system_prompt_support,omitempty"`
+	MaxParallelToolCalls int               `json:"max_parallel_tool_calls,omitempty"` // Zero is unknown
+	Sources              map[string]string `json:"sources,omitempty"`                 // Feature -> FeatureSource*
+}
+
+func (f *ModelFeatures) flag(name string) **bool {
+	switch name {
+	case FeatureToolUse:
+		return &f.ToolUse
+	case FeatureVisionInput:
+		return &f.VisionInput
+	case FeaturePromptCaching:
+		return &f.PromptCaching
+	case FeatureSystemPrompt:
+		return &f.SystemPromptSupport
+	}
+	return nil
+}
+
+// Supports reports whether the feature is on and whether it is known at all
+func (f *ModelFeatures) Supports(name string) (supported, known bool) {
+	if f == nil {
+		return false, false
+	}
+	p := f.flag(name)
+	if p == nil || *p == nil {
+		return false, false
+	}
+	return **p, true
+}
+
+// fill sets every feature other knows and f does not, attributing it to source
+func (f *ModelFeatures) fill(other *ModelFeatures, source string) {
+	if other == nil {
+		return
+	}
+	for _, name := range BoolFeatures {
+		dst, src := f.flag(name), other.flag(name)
+		if *dst == nil && *src != nil {
+			v := **src
+			*dst = &v
+			f.Sources[name] = source
+		}
+	}
+	if f.MaxParallelToolCalls == 0 && other.MaxParallelToolCalls > 0 {
+		f.MaxParallelToolCalls = other.MaxParallelToolCalls
+		f.Sources[FeatureMaxParallelToolCalls] = source
+	}
+}
+
+// FeatureConfig declares features per provider and per model. Model entries
+// override the catalog, which overrides provider defaults.
+type FeatureConfig struct {
+	Providers map[string]ModelFeatures `json:"providers"`
+	Models    map[string]ModelFeatures `json:"models"`
+}
+
+// LoadFeatureConfig reads a features config file
+func LoadFeatureConfig(path string) (*FeatureConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read features config: %w", err)
+	}
+	var cfg FeatureConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse features config %s: %w", path, err)
+	}
+	// Provider names match the catalog case-insensitively
+	providers := make(map[string]ModelFeatures, len(cfg.Providers))
+	for name, f := range cfg.Providers {
+		providers[strings.ToLower(name)] = f
+	}
+	cfg.Providers = providers
+	return &cfg, nil
+}
+
+// agenticBenchmarks are Analytics AI evaluations run through function calling,
+// so a score on any of them shows the model supports tool use
+var agenticBenchmarks = []string{"tau2", "terminalbench_hard"}
+
+// toolUseTags mark tool use in catalog tags, specializations and use cases
+var toolUseTags = map[string]bool{
+	"function_calling": true,
+	"function-calling": true,
+	"tool_use":         true,
+	"tool-use":         true,
+	"tools":            true,
+}
+
+// resolveFeatures fills the model's feature flags from, in order of
+// authority, the config's model entry, the catalog, the config's provider
+// defaults, Analytics AI evaluations and what the rest of the model implies
+func resolveFeatures(model *EnhancedModel, cfg *FeatureConfig) {
+	resolved := &ModelFeatures{Sources: make(map[string]string)}
+	if cfg != nil {
+		if f, ok := cfg.Models[model.ID]; ok {
+			resolved.fill(&f, FeatureSourceConfigModel)
+		}
+	}
+	resolved.fill(catalogFeatures(model.Features), FeatureSourceCatalog)
+	// Provider defaults describe the provider's chat API
+	if cfg != nil && (model.ModelType == "text" || model.ModelType == "") {
+		if f, ok := cfg.Providers[strings.ToLower(model.Provider)]; ok {
+			resolved.fill(&f, FeatureSourceConfigProvider)
+		}
+	}
+
+	analytics := &ModelFeatures{}
+	for _, name := range agenticBenchmarks {
+		if _, ok := model.Benchmarks.Text[name]; ok {
+			analytics.ToolUse = boolPtr(true)
+		}
+	}
+	resolved.fill(analytics, FeatureSourceAnalytics)
+
+	inferred := &ModelFeatures{}
+	if model.AcceptsImageInput() {
+		inferred.VisionInput = boolPtr(true)
+	} else if len(model.InputModalities) > 0 {
+		// input_modalities is authoritative for vision
+		inferred.VisionInput = boolPtr(false)
+	}
+	for _, list := range [][]string{model.Tags, model.ComplexityRecommendations.Specializations, model.CommunityFeedback.BestUseCases} {
+		for _, tag := range list {
+			if toolUseTags[strings.ToLower(tag)] {
+				inferred.ToolUse = boolPtr(true)
+			}
+		}
+	}
+	resolved.fill(inferred, FeatureSourceInferred)
+
+	// A parallel tool call limit means nothing without tool use
+	if resolved.ToolUse != nil && !*resolved.ToolUse && resolved.MaxParallelToolCalls > 0 {
+		resolved.MaxParallelToolCalls = 0
+		delete(resolved.Sources, FeatureMaxParallelToolCalls)
+	}
+
+	if len(resolved.Sources) == 0 {
+		model.Features = nil
+		return
+	}
+	model.Features = resolved
+}
+
+// catalogFeatures keeps the flags the catalog itself declared, dropping those
+// an earlier resolution derived so they can be derived afresh
+func catalogFeatures(f *ModelFeatures) *ModelFeatures {
+	if f == nil || len(f.Sources) == 0 {
+		return f
+	}
+	declared := &ModelFeatures{}
+	for _, name := range BoolFeatures {
+		if f.Sources[name] == FeatureSourceCatalog {
+			*declared.flag(name) = *f.flag(name)
+		}
+	}
+	if f.Sources[FeatureMaxParallelToolCalls] == FeatureSourceCatalog {
+		declared.MaxParallelToolCalls = f.MaxParallelToolCalls
+	}
+	return declared
+}
+
+// SetFeatureConfig sets the features config and re-resolves every model's
+// feature flags against it
+func (fs *FusionService) SetFeatureConfig(cfg *FeatureConfig) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceFeatures, fs.catalogBefore())
+
+	fs.features = cfg
+	fs.applyFeatures(fs.fusedModels)
+	if cfg != nil {
+		log.Printf("[FUSION] Model features declared for %d providers and %d models", len(cfg.Providers), len(cfg.Models))
+	}
+}
+
+// applyFeatures resolves the feature flags of every model; the caller holds
+// the write lock
+func (fs *FusionService) applyFeatures(catalog map[string]EnhancedModel) {
+	for id, model := range catalog {
+		resolveFeatures(&model, fs.features)
+		catalog[id] = model
+	}
+}
+
+// MissingFeatures lists the features required as {"tool_use": true} or
+// {"max_parallel_tool_calls": 4} that the model does not support. With strict,
+// unknown features count as missing; otherwise only known gaps do.
+func (m EnhancedModel) MissingFeatures(requirements map[string]interface{}, strict bool) []string {
+	var missing []string
+	for _, name := range BoolFeatures {
+		required, _ := requirements[name].(bool)
+		if !required {
+			continue
+		}
+		supported, known := m.Features.Supports(name)
+		if name == FeatureVisionInput && !known {
+			// Vision is also derivable without feature data
+			supported, known = m.AcceptsImageInput(), true
+		}
+		if !supported && (known || strict) {
+			missing = append(missing, name)
+		}
+	}
+	if n := requiredCount(requirements[FeatureMaxParallelToolCalls]); n > 0 {
+		max := 0
+		if m.Features != nil {
+			max = m.Features.MaxParallelToolCalls
+		}
+		if max < n && (max > 0 || strict) {
+			missing = append(missing, FeatureMaxParallelToolCalls)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// requiredCount reads a count requirement set in Go or decoded from JSON
+func requiredCount(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// ValidateFeatureRequirements rejects feature and compliance requirements of
+// the wrong type
+func ValidateFeatureRequirements(requirements map[string]interface{}) error {
+	for _, name := range BoolFeatures {
+		if v, ok := requirements[name]; ok {
+			if _, isBool := v.(bool); !isBool {
+				return fmt.Errorf("requirements.%s must be a boolean", name)
+			}
+		}
+	}
+	if v, ok := requirements[FeatureMaxParallelToolCalls]; ok {
+		n, isNumber := v.(float64)
+		if !isNumber || n < 1 || n != float64(int(n)) {
+			return fmt.Errorf("requirements.%s must be a positive integer", FeatureMaxParallelToolCalls)
+		}
+	}
+	return ValidateComplianceRequirements(requirements)
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
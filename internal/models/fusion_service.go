@@ -2,27 +2,80 @@ package models
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Askeban/llm-router-go/internal/alerts"
 	"github.com/Askeban/llm-router-go/internal/analytics"
+	"github.com/Askeban/llm-router-go/internal/invalidation"
 )
 
+// MetricsSink persists the raw Analytics AI data behind each fusion
+type MetricsSink interface {
+	IngestAnalytics(ctx context.Context, data []analytics.ModelData)
+}
+
 // FusionService combines model_1.json data with Analytics AI real-time data
 type FusionService struct {
 	enhancedService  *EnhancedModelService
 	analyticsService *analytics.Service
+	metricsSink      MetricsSink
+	alerts           *alerts.Manager
+
+	capabilityInferrer CapabilityInferrer
+
+	// Declared model and provider features
+	features *FeatureConfig
+
+	// Scores measured by the router, by model and category
+	measured map[string]map[string]float64
+
+	// Quality of live answers scored by a judge model, by model and category
+	judged map[string]map[string]float64
+
+	// Provider incidents affecting models, by model
+	statuses map[string]ModelStatus
+
+	// Recent list price changes, by model, and who detects them
+	priceChanges    map[string]PriceChange
+	pricingObserver PricingObserver
+
+	// Models their providers are retiring, by model
+	deprecations map[string]Deprecation
+
+	// Trust tiers, by model
+	trust map[string]Trust
+
+	// Operator disables and maintenance windows, by model
+	availability map[string]Availability
+
+	// Hugging Face Hub data for open models, by model
+	hub map[string]HubActivity
+
+	// Locale scores layered over the catalog's, by model and locale
+	locales map[string]map[string]float64
+
+	// Told about every change, for caches built from the catalog
+	bus *invalidation.Bus
 	
-	// Caching and synchronization
+	// Caching and synchronization. Catalogs are built off to the side under
+	// fusing and swapped in under mutex, so readers never wait on a fetch.
 	fusedModels map[string]EnhancedModel
 	mutex       sync.RWMutex
+	fusing      sync.Mutex
 	lastFusion  time.Time
+
+	// The last Analytics AI fetch, so single-model edits fuse without refetching
+	lastAnalytics []analytics.ModelData
 	
 	// Metrics
 	analyticsSuccessCount int64
 	fusionErrorCount      int64
+	lastAnalyticsFetch    time.Time
 }
 
 func NewFusionService(modelPath string) *FusionService {
@@ -43,54 +96,135 @@ func (fs *FusionService) Initialize(ctx context.Context) error {
 	return fs.PerformFusion(ctx)
 }
 
-func (fs *FusionService) PerformFusion(ctx context.Context) error {
+// InitializeBase loads model_1.json as the catalog without contacting Analytics AI.
+// Replicas that receive the fused catalog from a leader start from this.
+func (fs *FusionService) InitializeBase() error {
+	if err := fs.enhancedService.LoadModels(); err != nil {
+		return err
+	}
+
+	fs.mutex.RLock()
+	inferrer := fs.capabilityInferrer
+	fs.mutex.RUnlock()
+	fused := fs.fuseCatalog(inferrer, fs.enhancedService.GetAllModels(), nil)
+
 	fs.mutex.Lock()
-	defer fs.mutex.Unlock()
+	fs.applyOverlays(fused)
+	fs.fusedModels = fused
+	fs.mutex.Unlock()
 
-	log.Printf("[FUSION] Starting data fusion between model_1.json and Analytics AI")
+	log.Printf("[FUSION] Loaded %d base models without Analytics AI fusion", len(fused))
+	return nil
+}
 
-	// Get base models from model_1.json
-	baseModels := fs.enhancedService.GetAllModels()
-	fs.fusedModels = make(map[string]EnhancedModel, len(baseModels))
+// PerformFusion rebuilds the catalog from model_1.json and Analytics AI. The
+// new catalog is built off to the side while readers keep the current one;
+// only layering the in-memory overlays and the swap take the write lock.
+func (fs *FusionService) PerformFusion(ctx context.Context) error {
+	fs.fusing.Lock()
+	defer fs.fusing.Unlock()
 
-	// Copy all models from model_1.json as base
-	for _, model := range baseModels {
-		fs.fusedModels[model.ID] = model
-	}
+	log.Printf("[FUSION] Starting data fusion between model_1.json and Analytics AI")
+
+	fs.mutex.RLock()
+	notifier, sink := fs.alerts, fs.metricsSink
+	inferrer := fs.capabilityInferrer
+	serving := len(fs.fusedModels)
+	fs.mutex.RUnlock()
 
 	// Fetch Analytics AI data for text models
-	analyticsData, err := fs.analyticsService.FetchModels()
+	analyticsData, err := fs.analyticsService.FetchModels(ctx)
 	if err != nil {
 		log.Printf("[FUSION] Warning: Failed to fetch Analytics AI data: %v", err)
-		fs.fusionErrorCount++
+		notifier.Notify(alerts.Event{
+			Type:     alerts.EventCatalogFetchFailed,
+			Severity: alerts.SeverityWarning,
+			Source:   "fusion",
+			Title:    "Analytics AI catalog fetch failed",
+			Message:  err.Error(),
+			Fields:   map[string]string{"serving_models": fmt.Sprintf("%d", serving)},
+		})
 		// Continue with model_1.json data only
+		analyticsData = nil
 	} else {
 		log.Printf("[FUSION] Fetched %d models from Analytics AI", len(analyticsData))
-		fs.analyticsSuccessCount++
-
-		// Fuse Analytics AI data with existing models
-		fs.fuseAnalyticsData(analyticsData)
 
-		// Add missing text models from Analytics AI
-		fs.addMissingAnalyticsModels(analyticsData)
+		// Persist outside the fusion lock; failed rows are dead-lettered by the sink
+		if sink != nil {
+			go sink.IngestAnalytics(context.Background(), analyticsData)
+		}
 	}
 
+	fused := fs.fuseCatalog(inferrer, fs.enhancedService.GetAllModels(), analyticsData)
+
+	fs.mutex.Lock()
+	if err != nil {
+		fs.fusionErrorCount++
+	} else {
+		fs.analyticsSuccessCount++
+		fs.lastAnalyticsFetch = time.Now()
+	}
+	fs.lastAnalytics = analyticsData
+	fs.applyOverlays(fused)
+	before := fs.fusedModels
+	fs.fusedModels = fused
+	fs.observePrices()
+	fs.publishChanges(invalidation.SourceFusion, before)
 	fs.lastFusion = time.Now()
-	log.Printf("[FUSION] Fusion complete. Total models: %d", len(fs.fusedModels))
+	fs.mutex.Unlock()
 
+	log.Printf("[FUSION] Fusion complete. Total models: %d", len(fused))
 	return nil
 }
 
-func (fs *FusionService) fuseAnalyticsData(analyticsModels []analytics.ModelData) {
+// fuseCatalog builds a catalog from base models and an Analytics AI fetch,
+// which may be empty, without touching the served one. The inferrer is read
+// under the lock by the caller, since the build runs without it.
+func (fs *FusionService) fuseCatalog(inferrer CapabilityInferrer, baseModels []EnhancedModel, analyticsData []analytics.ModelData) map[string]EnhancedModel {
+	fused := make(map[string]EnhancedModel, len(baseModels)+len(analyticsData))
+
+	// Copy all base models
+	for _, model := range baseModels {
+		applyInferredCapabilities(inferrer, &model)
+		fused[model.ID] = model
+	}
+	if len(analyticsData) == 0 {
+		return fused
+	}
+
+	// Fuse Analytics AI data with existing models
+	fs.fuseAnalyticsData(fused, analyticsData)
+
+	// Add missing text models from Analytics AI
+	fs.addMissingAnalyticsModels(inferrer, fused, analyticsData)
+	return fused
+}
+
+// applyOverlays layers everything tracked beside the catalog over catalog's
+// models; the caller holds the write lock
+func (fs *FusionService) applyOverlays(catalog map[string]EnhancedModel) {
+	fs.applyFeatures(catalog)
+	fs.applyMeasuredBenchmarks(catalog)
+	fs.applyJudgedQuality(catalog)
+	fs.applyHubActivity(catalog)
+	fs.applyLocaleScores(catalog)
+	fs.applyModelStatuses(catalog)
+	fs.applyPriceChanges(catalog)
+	fs.applyDeprecations(catalog)
+	fs.applyTrust(catalog)
+	fs.applyAvailability(catalog)
+}
+
+func (fs *FusionService) fuseAnalyticsData(fused map[string]EnhancedModel, analyticsModels []analytics.ModelData) {
 	fusedCount := 0
 	
 	for _, analyticsModel := range analyticsModels {
 		// Try to match with existing model_1.json models
-		matchedModel, found := fs.findMatchingModel(analyticsModel)
+		matchedModel, found := fs.findMatchingModel(fused, analyticsModel)
 		if found {
 			// Enhance the existing model with Analytics AI data
 			enhanced := fs.enhanceWithAnalyticsData(matchedModel, analyticsModel)
-			fs.fusedModels[enhanced.ID] = enhanced
+			fused[enhanced.ID] = enhanced
 			fusedCount++
 		}
 	}
@@ -98,14 +232,14 @@ func (fs *FusionService) fuseAnalyticsData(analyticsModels []analytics.ModelData
 	log.Printf("[FUSION] Enhanced %d existing models with Analytics AI data", fusedCount)
 }
 
-func (fs *FusionService) findMatchingModel(analyticsModel analytics.ModelData) (EnhancedModel, bool) {
+func (fs *FusionService) findMatchingModel(fused map[string]EnhancedModel, analyticsModel analytics.ModelData) (EnhancedModel, bool) {
 	// Try direct ID match first
-	if existing, exists := fs.fusedModels[analyticsModel.ID]; exists {
+	if existing, exists := fused[analyticsModel.ID]; exists {
 		return existing, true
 	}
 
 	// Try name-based matching
-	for _, existing := range fs.fusedModels {
+	for _, existing := range fused {
 		if fs.isModelMatch(existing, analyticsModel) {
 			return existing, true
 		}
@@ -228,7 +362,7 @@ func (fs *FusionService) enhanceWithAnalyticsData(existing EnhancedModel, analyt
 
 	// Map Analytics AI indices to our task capabilities
 	if analytics.Evaluations.ArtificialAnalysisCodingIndex != nil {
-		enhanced.TaskCapabilities.TextTasks["coding"] = TaskCapability{
+		enhanced.TaskCapabilities.TextTasks[CapabilityCoding] = TaskCapability{
 			Score:      *analytics.Evaluations.ArtificialAnalysisCodingIndex,
 			Confidence: 0.95, // High confidence for Analytics AI data
 			ComplexityRange: []string{"simple", "medium", "hard"},
@@ -236,7 +370,7 @@ func (fs *FusionService) enhanceWithAnalyticsData(existing EnhancedModel, analyt
 	}
 
 	if analytics.Evaluations.ArtificialAnalysisMathIndex != nil {
-		enhanced.TaskCapabilities.TextTasks["math"] = TaskCapability{
+		enhanced.TaskCapabilities.TextTasks[CapabilityMath] = TaskCapability{
 			Score:      *analytics.Evaluations.ArtificialAnalysisMathIndex,
 			Confidence: 0.95,
 			ComplexityRange: []string{"simple", "medium", "hard"},
@@ -244,7 +378,7 @@ func (fs *FusionService) enhanceWithAnalyticsData(existing EnhancedModel, analyt
 	}
 
 	if analytics.Evaluations.ArtificialAnalysisIntelligenceIndex != nil {
-		enhanced.TaskCapabilities.TextTasks["reasoning"] = TaskCapability{
+		enhanced.TaskCapabilities.TextTasks[CapabilityReasoning] = TaskCapability{
 			Score:      *analytics.Evaluations.ArtificialAnalysisIntelligenceIndex,
 			Confidence: 0.95,
 			ComplexityRange: []string{"simple", "medium", "hard", "expert"},
@@ -271,16 +405,16 @@ func (fs *FusionService) enhanceWithAnalyticsData(existing EnhancedModel, analyt
 	return enhanced
 }
 
-func (fs *FusionService) addMissingAnalyticsModels(analyticsModels []analytics.ModelData) {
+func (fs *FusionService) addMissingAnalyticsModels(inferrer CapabilityInferrer, fused map[string]EnhancedModel, analyticsModels []analytics.ModelData) {
 	addedCount := 0
 
 	for _, analyticsModel := range analyticsModels {
 		// Check if this model already exists
-		_, exists := fs.findMatchingModel(analyticsModel)
+		_, exists := fs.findMatchingModel(fused, analyticsModel)
 		if !exists {
 			// Create new model from Analytics AI data
-			newModel := fs.createModelFromAnalytics(analyticsModel)
-			fs.fusedModels[newModel.ID] = newModel
+			newModel := fs.createModelFromAnalytics(inferrer, analyticsModel)
+			fused[newModel.ID] = newModel
 			addedCount++
 		}
 	}
@@ -288,7 +422,7 @@ func (fs *FusionService) addMissingAnalyticsModels(analyticsModels []analytics.M
 	log.Printf("[FUSION] Added %d new models from Analytics AI", addedCount)
 }
 
-func (fs *FusionService) createModelFromAnalytics(analytics analytics.ModelData) EnhancedModel {
+func (fs *FusionService) createModelFromAnalytics(inferrer CapabilityInferrer, analytics analytics.ModelData) EnhancedModel {
 	model := EnhancedModel{
 		ID:          analytics.ID,
 		Provider:    analytics.Creator.Slug,
@@ -313,6 +447,7 @@ func (fs *FusionService) createModelFromAnalytics(analytics analytics.ModelData)
 			AnalyticsAICoding:       analytics.Evaluations.ArtificialAnalysisCodingIndex,
 			AnalyticsAIMath:         analytics.Evaluations.ArtificialAnalysisMathIndex,
 		},
+		Text: analyticsBenchmarks(analytics.Evaluations),
 	}
 
 	// Set task capabilities
@@ -321,7 +456,7 @@ func (fs *FusionService) createModelFromAnalytics(analytics analytics.ModelData)
 	}
 
 	if analytics.Evaluations.ArtificialAnalysisCodingIndex != nil {
-		model.TaskCapabilities.TextTasks["coding"] = TaskCapability{
+		model.TaskCapabilities.TextTasks[CapabilityCoding] = TaskCapability{
 			Score:      *analytics.Evaluations.ArtificialAnalysisCodingIndex,
 			Confidence: 0.95,
 			ComplexityRange: []string{"simple", "medium", "hard"},
@@ -329,7 +464,7 @@ func (fs *FusionService) createModelFromAnalytics(analytics analytics.ModelData)
 	}
 
 	if analytics.Evaluations.ArtificialAnalysisMathIndex != nil {
-		model.TaskCapabilities.TextTasks["math"] = TaskCapability{
+		model.TaskCapabilities.TextTasks[CapabilityMath] = TaskCapability{
 			Score:      *analytics.Evaluations.ArtificialAnalysisMathIndex,
 			Confidence: 0.95,
 			ComplexityRange: []string{"simple", "medium", "hard"},
@@ -337,23 +472,28 @@ func (fs *FusionService) createModelFromAnalytics(analytics analytics.ModelData)
 	}
 
 	if analytics.Evaluations.ArtificialAnalysisIntelligenceIndex != nil {
-		model.TaskCapabilities.TextTasks["reasoning"] = TaskCapability{
+		model.TaskCapabilities.TextTasks[CapabilityReasoning] = TaskCapability{
 			Score:      *analytics.Evaluations.ArtificialAnalysisIntelligenceIndex,
 			Confidence: 0.95,
 			ComplexityRange: []string{"simple", "medium", "hard", "expert"},
 		}
 	}
 
-	// Add default capabilities
-	model.TaskCapabilities.TextTasks["writing"] = TaskCapability{
-		Score:      0.80, // Default for new models
-		Confidence: 0.75,
-		ComplexityRange: []string{"simple", "medium"},
+	// Derive what the benchmarks support, then default the rest
+	applyInferredCapabilities(inferrer, &model)
+	if _, exists := model.TaskCapabilities.TextTasks[CapabilityWriting]; !exists {
+		model.TaskCapabilities.TextTasks[CapabilityWriting] = TaskCapability{
+			Score:      0.80, // Default for new models
+			Confidence: 0.75,
+			ComplexityRange: []string{"simple", "medium"},
+		}
 	}
-	model.TaskCapabilities.TextTasks["analysis"] = TaskCapability{
-		Score:      0.75,
-		Confidence: 0.75,
-		ComplexityRange: []string{"simple", "medium"},
+	if _, exists := model.TaskCapabilities.TextTasks[CapabilityAnalysis]; !exists {
+		model.TaskCapabilities.TextTasks[CapabilityAnalysis] = TaskCapability{
+			Score:      0.75,
+			Confidence: 0.75,
+			ComplexityRange: []string{"simple", "medium"},
+		}
 	}
 
 	// Set performance
@@ -388,6 +528,34 @@ func (fs *FusionService) createModelFromAnalytics(analytics analytics.ModelData)
 	return model
 }
 
+// analyticsBenchmarks keeps Analytics AI evaluations under the benchmark names
+// used by benchmark mappings, so capabilities can be inferred from them
+func analyticsBenchmarks(e analytics.Evaluations) map[string]float64 {
+	benchmarks := make(map[string]float64)
+	for name, value := range map[string]*float64{
+		"mmlu_pro":           e.MMLUPro,
+		"gpqa":               e.GPQA,
+		"hle":                e.HLE,
+		"livecodebench":      e.LiveCodeBench,
+		"scicode":            e.SciCode,
+		"math500":            e.Math500,
+		"aime":               e.AIME,
+		"aime_25":            e.AIME25,
+		"ifbench":            e.IFBench,
+		"lcr":                e.LCR,
+		"terminalbench_hard": e.TerminalBenchHard,
+		"tau2":               e.Tau2,
+	} {
+		if value != nil {
+			benchmarks[name] = *value
+		}
+	}
+	if len(benchmarks) == 0 {
+		return nil
+	}
+	return benchmarks
+}
+
 func (fs *FusionService) inferOpenSourceFromCreator(creator string) bool {
 	openSourceCreators := map[string]bool{
 		"meta":         true,
@@ -438,7 +606,7 @@ func (fs *FusionService) GetModelsByCapability(capability string, minScore float
 
 	var filtered []EnhancedModel
 	for _, model := range fs.fusedModels {
-		if taskCap, exists := model.TaskCapabilities.TextTasks[capability]; exists {
+		if taskCap, exists := model.TaskCapabilities.TextTasks[CanonicalCapability(capability)]; exists {
 			if taskCap.Score >= minScore {
 				filtered = append(filtered, model)
 			}
@@ -448,25 +616,186 @@ func (fs *FusionService) GetModelsByCapability(capability string, minScore float
 }
 
 func (fs *FusionService) GetStats() map[string]interface{} {
+	snapshot := fs.Snapshot()
+	return map[string]interface{}{
+		"total_models":            snapshot.TotalModels,
+		"models_by_type":          snapshot.ModelsByType,
+		"models_by_provider":      snapshot.ModelsByProvider,
+		"last_fusion":             snapshot.LastFusion,
+		"last_analytics_fetch":    snapshot.LastAnalyticsFetch,
+		"analytics_success_count": snapshot.AnalyticsSuccesses,
+		"fusion_error_count":      snapshot.FusionErrors,
+	}
+}
+
+// FusionSnapshot is the catalog's size, freshness and fusion counters read
+// under one lock, so they describe the same instant
+type FusionSnapshot struct {
+	TotalModels        int
+	ModelsByType       map[string]int
+	ModelsByProvider   map[string]int
+	LastFusion         time.Time
+	LastAnalyticsFetch time.Time // Zero until Analytics AI is fetched; replication followers never fetch it
+	AnalyticsSuccesses int64
+	FusionErrors       int64
+}
+
+// Snapshot returns the catalog's size, freshness and fusion counters
+func (fs *FusionService) Snapshot() FusionSnapshot {
 	fs.mutex.RLock()
 	defer fs.mutex.RUnlock()
 
-	// Count models by type
-	typeCount := make(map[string]int)
-	providerCount := make(map[string]int)
+	snapshot := FusionSnapshot{
+		TotalModels:        len(fs.fusedModels),
+		ModelsByType:       make(map[string]int),
+		ModelsByProvider:   make(map[string]int),
+		LastFusion:         fs.lastFusion,
+		LastAnalyticsFetch: fs.lastAnalyticsFetch,
+		AnalyticsSuccesses: fs.analyticsSuccessCount,
+		FusionErrors:       fs.fusionErrorCount,
+	}
 	for _, model := range fs.fusedModels {
-		typeCount[model.ModelType]++
-		providerCount[model.Provider]++
+		snapshot.ModelsByType[model.ModelType]++
+		snapshot.ModelsByProvider[model.Provider]++
 	}
+	return snapshot
+}
 
-	return map[string]interface{}{
-		"total_models":            len(fs.fusedModels),
-		"models_by_type":          typeCount,
-		"models_by_provider":      providerCount,
-		"last_fusion":             fs.lastFusion,
-		"analytics_success_count": fs.analyticsSuccessCount,
-		"fusion_error_count":      fs.fusionErrorCount,
+// SetMetricsSink persists each fetched Analytics AI batch
+func (fs *FusionService) SetMetricsSink(sink MetricsSink) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.metricsSink = sink
+}
+
+// SetAlerts reports catalog fetch failures to operators
+func (fs *FusionService) SetAlerts(manager *alerts.Manager) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.alerts = manager
+}
+
+// LastFusion returns when the current catalog was fused
+func (fs *FusionService) LastFusion() time.Time {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+	return fs.lastFusion
+}
+
+// PingAnalytics checks that Analytics AI is reachable
+func (fs *FusionService) PingAnalytics(ctx context.Context) error {
+	return fs.analyticsService.Ping(ctx)
+}
+
+// ReplaceModels hot-swaps the whole catalog, e.g. with a snapshot published by a leader
+func (fs *FusionService) ReplaceModels(models []EnhancedModel, fusedAt time.Time) {
+	fused := make(map[string]EnhancedModel, len(models))
+	for _, model := range models {
+		NormalizeCapabilities(&model) // Snapshots from older leaders may predate the taxonomy
+		fused[model.ID] = model
+	}
+
+	fs.mutex.Lock()
+	fs.applyOverlays(fused)
+	before := fs.fusedModels
+	fs.fusedModels = fused
+	fs.observePrices()
+	fs.publishChanges(invalidation.SourceSnapshot, before)
+	fs.lastFusion = fusedAt
+	fs.mutex.Unlock()
+
+	log.Printf("[FUSION] Catalog replaced with %d models fused at %s", len(fused), fusedAt.Format(time.RFC3339))
+}
+
+// ImportCatalog makes a curated catalog the base that fusions layer Analytics
+// AI data over, so a refresh does not undo the import. It is served fused
+// with the last Analytics AI fetch, if this instance has made one.
+func (fs *FusionService) ImportCatalog(catalog []EnhancedModel, fusedAt time.Time) {
+	// A fusion already under way was built from the old base; let it land first
+	fs.fusing.Lock()
+	defer fs.fusing.Unlock()
+
+	fs.enhancedService.ReplaceModels(catalog)
+
+	fs.mutex.RLock()
+	analyticsData, inferrer := fs.lastAnalytics, fs.capabilityInferrer
+	fs.mutex.RUnlock()
+	fused := fs.fuseCatalog(inferrer, fs.enhancedService.GetAllModels(), analyticsData)
+
+	fs.mutex.Lock()
+	fs.applyOverlays(fused)
+	before := fs.fusedModels
+	fs.fusedModels = fused
+	fs.observePrices()
+	fs.publishChanges(invalidation.SourceSnapshot, before)
+	fs.lastFusion = fusedAt
+	fs.mutex.Unlock()
+
+	log.Printf("[FUSION] Imported catalog of %d models, serving %d", len(catalog), len(fused))
+}
+
+// BaseModels returns the catalog fusions start from: model_1.json, or the
+// last imported catalog, with single-model edits applied
+func (fs *FusionService) BaseModels() []EnhancedModel {
+	return fs.enhancedService.GetAllModels()
+}
+
+// UpsertModel adds or replaces one base model and fuses just that model with
+// the last Analytics AI fetch and the overlays, without rebuilding the
+// catalog. It returns the model as served.
+func (fs *FusionService) UpsertModel(model EnhancedModel) EnhancedModel {
+	fs.fusing.Lock()
+	defer fs.fusing.Unlock()
+
+	model = fs.enhancedService.UpsertModel(model)
+
+	fs.mutex.RLock()
+	analyticsData, inferrer := fs.lastAnalytics, fs.capabilityInferrer
+	fs.mutex.RUnlock()
+	applyInferredCapabilities(inferrer, &model)
+	for _, analyticsModel := range analyticsData {
+		if analyticsModel.ID == model.ID || fs.isModelMatch(model, analyticsModel) {
+			model = fs.enhanceWithAnalyticsData(model, analyticsModel)
+		}
+	}
+
+	single := map[string]EnhancedModel{model.ID: model}
+	fs.mutex.Lock()
+	fs.applyOverlays(single)
+	model = single[model.ID]
+	previous, existed := fs.fusedModels[model.ID]
+	fs.fusedModels[model.ID] = model
+	fs.observePrices()
+	if !existed || !reflect.DeepEqual(previous, model) {
+		fs.publishModel(invalidation.SourceEdit, model.ID)
+	}
+	fs.mutex.Unlock()
+
+	log.Printf("[FUSION] Upserted model %s", model.ID)
+	return model
+}
+
+// RemoveModel drops one model from the base and the served catalog, reporting
+// whether either had it. A model Analytics AI also lists returns at the next
+// fusion.
+func (fs *FusionService) RemoveModel(id string) bool {
+	fs.fusing.Lock()
+	defer fs.fusing.Unlock()
+
+	inBase := fs.enhancedService.RemoveModel(id)
+
+	fs.mutex.Lock()
+	_, served := fs.fusedModels[id]
+	delete(fs.fusedModels, id)
+	if served {
+		fs.publishModel(invalidation.SourceEdit, id)
+	}
+	fs.mutex.Unlock()
+
+	if inBase || served {
+		log.Printf("[FUSION] Removed model %s", id)
 	}
+	return inBase || served
 }
 
 func (fs *FusionService) RefreshData(ctx context.Context) error {
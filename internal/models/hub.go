@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// HubActivity is what the Hugging Face Hub reports for an open model's
+// repository
+type HubActivity struct {
+	Repo           string     `json:"repo"`
+	Downloads      int64      `json:"downloads"` // Last 30 days
+	Likes          int64      `json:"likes"`
+	License        string     `json:"license,omitempty"`
+	ParameterCount int64      `json:"parameter_count,omitempty"` // From the safetensors index
+	PipelineTag    string     `json:"pipeline_tag,omitempty"`    // e.g. "text-generation"
+	Languages      []string   `json:"languages,omitempty"`       // From the model card
+	Gated          bool       `json:"gated,omitempty"`           // Weights require accepting the license
+	LastModified   *time.Time `json:"last_modified,omitempty"`
+	FetchedAt      time.Time  `json:"fetched_at"`
+}
+
+// SetHubActivity replaces the Hugging Face Hub data layered over open models.
+// Models missing from activity keep what the catalog or a replicated snapshot
+// carries; like statuses, the data survives later fusions and snapshot swaps.
+func (fs *FusionService) SetHubActivity(activity map[string]HubActivity) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceHub, fs.catalogBefore())
+
+	fs.hub = activity
+	fs.applyHubActivity(fs.fusedModels)
+	log.Printf("[FUSION] Hugging Face Hub data applied to %d models", len(activity))
+}
+
+// applyHubActivity layers Hub data over the fused catalog; the caller holds
+// the write lock
+func (fs *FusionService) applyHubActivity(catalog map[string]EnhancedModel) {
+	for modelID, activity := range fs.hub {
+		if model, ok := catalog[modelID]; ok {
+			catalog[modelID] = withHubActivity(model, activity)
+		}
+	}
+}
+
+// withHubActivity attaches the Hub data and fills the license and parameter
+// count where the catalog leaves them empty; curated values win
+func withHubActivity(model EnhancedModel, activity HubActivity) EnhancedModel {
+	model.CommunityIntelligence.HuggingFace = &activity
+	if model.TechnicalSpecs.License == "" && activity.License != "" {
+		model.TechnicalSpecs.License = activity.License
+	}
+	if unknownParameters(model.TechnicalSpecs.Parameters) && activity.ParameterCount > 0 {
+		model.TechnicalSpecs.Parameters = FormatParameters(activity.ParameterCount)
+	}
+	return model
+}
+
+func unknownParameters(parameters string) bool {
+	parameters = strings.TrimSpace(parameters)
+	return parameters == "" || strings.EqualFold(parameters, "unknown")
+}
+
+// FormatParameters renders a parameter count the way the catalog writes
+// them, e.g. "70B" or "7.2B"
+func FormatParameters(count int64) string {
+	units := []struct {
+		suffix string
+		size   float64
+	}{{"T", 1e12}, {"B", 1e9}, {"M", 1e6}}
+	for _, unit := range units {
+		if float64(count) >= unit.size {
+			value := float64(count) / unit.size
+			if value >= 100 {
+				return fmt.Sprintf("%.0f%s", value, unit.suffix)
+			}
+			return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + unit.suffix
+		}
+	}
+	return strconv.FormatInt(count, 10)
+}
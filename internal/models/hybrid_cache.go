@@ -0,0 +1,234 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	hybridCatalogKey = "hybrid:catalog"
+	hybridLockKey    = "hybrid:refresh_lock"
+
+	// hybridLocalTTL is how long a replica serves the shared catalog from
+	// memory before reading Redis again
+	hybridLocalTTL = 30 * time.Second
+	// hybridLockTTL outlasts an Analytics AI fetch, so a replica that dies
+	// mid-refresh only delays the next one
+	hybridLockTTL = 2 * time.Minute
+	// hybridLockWait is how long a replica with no catalog at all waits for
+	// another's refresh before fetching its own
+	hybridLockWait = 15 * time.Second
+	hybridPoll     = 250 * time.Millisecond
+	// hybridStaleRetention keeps an expired catalog and its ETag in Redis, for
+	// conditional fetches and for serving while a refresh is in flight
+	hybridStaleRetention = 7 * 24 * time.Hour
+)
+
+// releaseLockScript deletes the refresh lock only while the caller holds it
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// hybridSnapshot is the catalog shared by replicas with the ETag it was
+// fetched under
+type hybridSnapshot struct {
+	Models    []ModelProfile `json:"models"`
+	ETag      string         `json:"etag"`
+	FetchedAt time.Time      `json:"fetched_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// SetRedis shares the catalog between replicas through Redis: one replica
+// refreshes from Analytics AI under a lock and the others read its result,
+// each keeping it in memory for a few seconds. An empty addr keeps the cache
+// per process. When Redis is unreachable each replica refreshes on its own.
+func (h *HybridModelService) SetRedis(addr, password string, db int) {
+	if addr == "" {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.redis = redis.NewClient(&redis.Options{Addr: addr, Password: password, DB: db})
+	h.localExpiry = time.Time{}
+}
+
+// getShared serves the in-memory copy of the shared catalog, reading Redis
+// when it is older than hybridLocalTTL and refreshing once the shared one
+// expires
+func (h *HybridModelService) getShared(ctx context.Context) ([]ModelProfile, error) {
+	h.mutex.RLock()
+	if len(h.cache) > 0 && time.Now().Before(h.localExpiry) {
+		defer h.mutex.RUnlock()
+		return h.cache, nil
+	}
+	h.mutex.RUnlock()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// Another request may have read it while this one waited
+	if len(h.cache) > 0 && time.Now().Before(h.localExpiry) {
+		return h.cache, nil
+	}
+
+	snapshot, err := h.loadSnapshot(ctx)
+	if err != nil {
+		h.redisErrorCount++
+		log.Printf("[HYBRID] Redis unavailable, refreshing locally: %v", err)
+		return h.refreshUnshared(ctx), nil
+	}
+	if snapshot != nil && time.Now().Before(snapshot.ExpiresAt) {
+		h.redisHitCount++
+		h.useSnapshot(snapshot)
+		return h.cache, nil
+	}
+	return h.refreshShared(ctx, snapshot), nil
+}
+
+// refreshShared refreshes the shared catalog under the Redis lock. Replicas
+// that miss the lock serve the stale catalog until the holder publishes, or
+// wait for it when they have none. Callers hold the mutex.
+func (h *HybridModelService) refreshShared(ctx context.Context, stale *hybridSnapshot) []ModelProfile {
+	token := uuid.NewString()
+	locked, err := h.redis.SetNX(ctx, hybridLockKey, token, hybridLockTTL).Result()
+	if err != nil {
+		h.redisErrorCount++
+		log.Printf("[HYBRID] Failed to take refresh lock: %v", err)
+		return h.refreshUnshared(ctx)
+	}
+
+	if !locked {
+		if stale != nil {
+			log.Printf("[HYBRID] Another replica is refreshing, serving catalog from %s", stale.FetchedAt.Format(time.RFC3339))
+			h.useSnapshot(stale)
+			return h.cache
+		}
+		h.lockWaitCount++
+		if snapshot := h.awaitSnapshot(ctx); snapshot != nil {
+			h.useSnapshot(snapshot)
+			return h.cache
+		}
+		// Nothing to serve: fetch without the lock rather than fail
+		return h.refreshUnshared(ctx)
+	}
+	defer func() {
+		if err := releaseLockScript.Run(context.Background(), h.redis, []string{hybridLockKey}, token).Err(); err != nil {
+			log.Printf("[HYBRID] Failed to release refresh lock: %v", err)
+		}
+	}()
+
+	var etag string
+	var previous []ModelProfile
+	if stale != nil {
+		etag, previous = stale.ETag, stale.Models
+	}
+	models, etag := h.buildModels(ctx, etag, previous)
+
+	h.scheduleDailyRefresh()
+	now := time.Now()
+	snapshot := &hybridSnapshot{Models: models, ETag: etag, FetchedAt: now, ExpiresAt: now.Add(h.cacheDuration)}
+	// Every replica refreshes at the daily refresh time, not 24h after whichever fetched last
+	if h.dailyRefreshAt.Before(snapshot.ExpiresAt) {
+		snapshot.ExpiresAt = h.dailyRefreshAt
+	}
+	if err := h.saveSnapshot(ctx, snapshot); err != nil {
+		h.redisErrorCount++
+		log.Printf("[HYBRID] Failed to share refreshed catalog: %v", err)
+	}
+	h.useSnapshot(snapshot)
+	return h.cache
+}
+
+// refreshUnshared refreshes this replica's copy alone, without Redis or when
+// it cannot be used. Callers hold the mutex.
+func (h *HybridModelService) refreshUnshared(ctx context.Context) []ModelProfile {
+	models, etag := h.buildModels(ctx, h.lastETag, h.cache)
+	h.lastETag = etag
+
+	// Update cache
+	h.cache = models
+	h.cacheExpiry = time.Now().Add(h.cacheDuration)
+	h.localExpiry = time.Now().Add(hybridLocalTTL)
+
+	// Schedule next daily refresh
+	h.scheduleDailyRefresh()
+
+	return models
+}
+
+// awaitSnapshot polls for the catalog another replica is fetching until the
+// lock wait runs out, returning nil if none is published
+func (h *HybridModelService) awaitSnapshot(ctx context.Context) *hybridSnapshot {
+	ctx, cancel := context.WithTimeout(ctx, hybridLockWait)
+	defer cancel()
+
+	ticker := time.NewTicker(hybridPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		snapshot, err := h.loadSnapshot(ctx)
+		if err != nil {
+			continue
+		}
+		if snapshot != nil {
+			return snapshot
+		}
+	}
+}
+
+// useSnapshot makes snapshot this replica's copy for hybridLocalTTL, or until
+// it expires if sooner. Callers hold the mutex.
+func (h *HybridModelService) useSnapshot(snapshot *hybridSnapshot) {
+	h.cache = snapshot.Models
+	h.cacheExpiry = snapshot.ExpiresAt
+	h.lastETag = snapshot.ETag
+
+	now := time.Now()
+	h.localExpiry = now.Add(hybridLocalTTL)
+	if snapshot.ExpiresAt.After(now) && snapshot.ExpiresAt.Before(h.localExpiry) {
+		h.localExpiry = snapshot.ExpiresAt
+	}
+}
+
+// loadSnapshot reads the shared catalog, returning nil when there is none
+func (h *HybridModelService) loadSnapshot(ctx context.Context) (*hybridSnapshot, error) {
+	data, err := h.redis.Get(ctx, hybridCatalogKey).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared catalog: %w", err)
+	}
+
+	var snapshot hybridSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode shared catalog: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// saveSnapshot publishes the catalog, kept past its expiry for stale reads
+func (h *HybridModelService) saveSnapshot(ctx context.Context, snapshot *hybridSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode shared catalog: %w", err)
+	}
+	ttl := time.Until(snapshot.ExpiresAt) + hybridStaleRetention
+	if err := h.redis.Set(ctx, hybridCatalogKey, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write shared catalog: %w", err)
+	}
+	return nil
+}
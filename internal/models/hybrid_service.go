@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
 	"github.com/Askeban/llm-router-go/internal/analytics"
 )
 
@@ -29,10 +31,17 @@ type HybridModelService struct {
 	lastETag       string
 	dailyRefreshAt time.Time
 
+	// Shared catalog in Redis; nil caches per process
+	redis       *redis.Client
+	localExpiry time.Time // When this replica next reads the shared catalog
+
 	// Metrics
 	analyticsSuccessCount  int64
 	analyticsFallbackCount int64
 	staticFallbackCount    int64
+	redisHitCount          int64
+	redisErrorCount        int64
+	lockWaitCount          int64
 }
 
 func NewHybridModelService(db *sql.DB, staticPath string) *HybridModelService {
@@ -52,6 +61,10 @@ func NewHybridModelService(db *sql.DB, staticPath string) *HybridModelService {
 
 // GetModels returns models with Analytics AI data prioritized over static data
 func (h *HybridModelService) GetModels(ctx context.Context) ([]ModelProfile, error) {
+	if h.redis != nil {
+		return h.getShared(ctx)
+	}
+
 	h.mutex.RLock()
 
 	// Check if daily refresh is needed
@@ -79,23 +92,47 @@ func (h *HybridModelService) refreshModels(ctx context.Context) ([]ModelProfile,
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
+	if h.redis != nil {
+		snapshot, err := h.loadSnapshot(ctx)
+		if err == nil {
+			return h.refreshShared(ctx, snapshot), nil
+		}
+		h.redisErrorCount++
+		log.Printf("[HYBRID] Redis unavailable, refreshing locally: %v", err)
+	}
+
+	return h.refreshUnshared(ctx), nil
+}
+
+// buildModels fetches from Analytics AI, conditionally on etag, and merges
+// the result over the static models. When Analytics AI reports no change the
+// previous catalog is kept. It returns the catalog and the ETag to send next
+// time; callers hold the mutex.
+func (h *HybridModelService) buildModels(ctx context.Context, etag string, previous []ModelProfile) ([]ModelProfile, string) {
 	var finalModels []ModelProfile
 	analyticsModels := make(map[string]ModelProfile) // keyed by model slug/name for deduplication
 
+	// A 304 is only useful with the catalog it refers to
+	if len(previous) == 0 {
+		etag = ""
+	}
+
 	// Step 1: Try to fetch from Analytics AI with ETag
-	log.Printf("[HYBRID] Fetching models from Analytics AI (ETag: %s)...", h.lastETag)
-	newETag, analyticsData, err := h.analyticsService.GetResponseETag(h.lastETag)
+	log.Printf("[HYBRID] Fetching models from Analytics AI (ETag: %s)...", etag)
+	newETag, analyticsData, err := h.analyticsService.GetResponseETag(ctx, etag)
 	if err != nil {
 		log.Printf("[HYBRID] Analytics AI fetch failed: %v", err)
 		h.analyticsFallbackCount++
+		etag = "" // The catalog built below lacks Analytics AI data, so a 304 must not keep it
 	} else if analyticsData == nil {
 		// 304 Not Modified - data hasn't changed
 		log.Printf("[HYBRID] Analytics AI data not modified (304), using cached data")
 		h.analyticsSuccessCount++
+		return previous, etag
 	} else {
 		log.Printf("[HYBRID] Successfully fetched %d models from Analytics AI (ETag: %s)", len(analyticsData), newETag)
 		h.analyticsSuccessCount++
-		h.lastETag = newETag
+		etag = newETag
 
 		// Convert Analytics AI data to our internal format
 		for _, data := range analyticsData {
@@ -146,14 +183,7 @@ func (h *HybridModelService) refreshModels(ctx context.Context) ([]ModelProfile,
 	log.Printf("[HYBRID] Final model count: %d (Analytics: %d, Static: %d)",
 		len(finalModels), len(analyticsModels), len(staticModels))
 
-	// Update cache
-	h.cache = finalModels
-	h.cacheExpiry = time.Now().Add(h.cacheDuration)
-
-	// Schedule next daily refresh
-	h.scheduleDailyRefresh()
-
-	return finalModels, nil
+	return finalModels, etag
 }
 
 // loadStaticModels loads models from the static JSON file
@@ -287,7 +317,8 @@ func (h *HybridModelService) mergeModels(analyticsModel, staticModel ModelProfil
 	if merged.Capabilities == nil {
 		merged.Capabilities = make(map[string]float64)
 	}
-	for capability, score := range staticModel.Capabilities {
+	merged.Capabilities = CanonicalCapabilityScores(merged.Capabilities)
+	for capability, score := range CanonicalCapabilityScores(staticModel.Capabilities) {
 		if _, exists := merged.Capabilities[capability]; !exists && score > 0 {
 			merged.Capabilities[capability] = score
 		}
@@ -326,6 +357,11 @@ func (h *HybridModelService) GetModelByID(ctx context.Context, id string) (*Mode
 
 // RefreshCache forces a cache refresh
 func (h *HybridModelService) RefreshCache(ctx context.Context) error {
+	if h.redis != nil {
+		_, err := h.refreshModels(ctx)
+		return err
+	}
+
 	h.mutex.Lock()
 	h.cacheExpiry = time.Time{} // Expire cache
 	h.mutex.Unlock()
@@ -339,13 +375,21 @@ func (h *HybridModelService) GetMetrics() map[string]interface{} {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 
+	backend := "memory"
+	if h.redis != nil {
+		backend = "redis"
+	}
 	return map[string]interface{}{
 		"analytics_success_count":  h.analyticsSuccessCount,
 		"analytics_fallback_count": h.analyticsFallbackCount,
 		"static_fallback_count":    h.staticFallbackCount,
+		"cache_backend":            backend,
 		"cache_size":               len(h.cache),
 		"cache_expiry":             h.cacheExpiry.Format(time.RFC3339),
 		"cache_valid":              time.Now().Before(h.cacheExpiry),
+		"redis_hit_count":          h.redisHitCount,
+		"redis_error_count":        h.redisErrorCount,
+		"refresh_lock_wait_count":  h.lockWaitCount,
 	}
 }
 
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// SetInvalidationBus publishes every catalog change, so caches built from the
+// catalog can drop what it affects
+func (fs *FusionService) SetInvalidationBus(bus *invalidation.Bus) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.bus = bus
+}
+
+// catalogBefore returns the catalog to compare a change against, or nil when
+// nothing subscribes; the caller holds the write lock. Entries are copied, so
+// later in-place updates do not show through.
+func (fs *FusionService) catalogBefore() map[string]EnhancedModel {
+	if fs.bus == nil {
+		return nil
+	}
+	before := make(map[string]EnhancedModel, len(fs.fusedModels))
+	for id, model := range fs.fusedModels {
+		before[id] = model
+	}
+	return before
+}
+
+// publishChanges publishes the models that were added, changed or removed
+// since before; the caller holds the write lock. Unchanged catalogs, such as
+// a periodic sync that found nothing new, publish nothing.
+func (fs *FusionService) publishChanges(source string, before map[string]EnhancedModel) {
+	if fs.bus == nil || before == nil {
+		return
+	}
+
+	var changed, removed []string
+	for id, model := range fs.fusedModels {
+		if previous, ok := before[id]; !ok || !reflect.DeepEqual(previous, model) {
+			changed = append(changed, id)
+		}
+	}
+	for id := range before {
+		if _, ok := fs.fusedModels[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	if len(changed) == 0 && len(removed) == 0 {
+		return
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	fs.bus.Publish(invalidation.Event{Source: source, Models: changed, Removed: removed})
+}
+
+// publishModel publishes a change to one model, reporting it removed when it
+// has left the catalog; the caller holds the write lock
+func (fs *FusionService) publishModel(source, id string) {
+	if fs.bus == nil {
+		return
+	}
+	event := invalidation.Event{Source: source, Models: []string{id}}
+	if _, ok := fs.fusedModels[id]; !ok {
+		event = invalidation.Event{Source: source, Removed: []string{id}}
+	}
+	fs.bus.Publish(event)
+}
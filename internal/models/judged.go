@@ -0,0 +1,71 @@
+package models
+
+import (
+	"log"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// judgedBenchmarkPrefix namespaces the judged quality of live answers
+const judgedBenchmarkPrefix = "judge_"
+
+// JudgedBenchmark returns the benchmarks.text key holding the judged quality
+// of a model's live answers in a category
+func JudgedBenchmark(category string) string {
+	return judgedBenchmarkPrefix + category
+}
+
+// IsJudgedBenchmark reports whether a benchmarks.text key holds judged quality
+func IsJudgedBenchmark(name string) bool {
+	return strings.HasPrefix(name, judgedBenchmarkPrefix)
+}
+
+// SetJudgedQuality replaces a model's judged quality by category; nil clears
+// it. Like measured benchmarks, it is layered over the catalog immediately and
+// after every later fusion or snapshot swap.
+func (fs *FusionService) SetJudgedQuality(modelID string, scores map[string]float64) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceQuality, fs.catalogBefore())
+
+	if fs.judged == nil {
+		fs.judged = make(map[string]map[string]float64)
+	}
+	if len(scores) == 0 {
+		delete(fs.judged, modelID)
+	} else {
+		fs.judged[modelID] = scores
+	}
+
+	if model, ok := fs.fusedModels[modelID]; ok {
+		fs.fusedModels[modelID] = withJudged(model, scores)
+	}
+	log.Printf("[FUSION] Judged quality updated for %s: %v", modelID, scores)
+}
+
+// applyJudgedQuality layers judged quality over the fused catalog; the caller
+// holds the write lock
+func (fs *FusionService) applyJudgedQuality(catalog map[string]EnhancedModel) {
+	for modelID, scores := range fs.judged {
+		if model, ok := catalog[modelID]; ok {
+			catalog[modelID] = withJudged(model, scores)
+		}
+	}
+}
+
+// withJudged copies the model's text benchmarks, dropping judged scores that
+// are no longer current, before adding the new ones
+func withJudged(model EnhancedModel, scores map[string]float64) EnhancedModel {
+	text := make(map[string]float64, len(model.Benchmarks.Text)+len(scores))
+	for name, value := range model.Benchmarks.Text {
+		if !IsJudgedBenchmark(name) {
+			text[name] = value
+		}
+	}
+	for category, score := range scores {
+		text[JudgedBenchmark(category)] = score
+	}
+	model.Benchmarks.Text = text
+	return model
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// Where a model's locale score came from, most specific first
+const (
+	LocaleSourceLocale       = "locale"       // Scored for the locale itself, e.g. "ja-JP"
+	LocaleSourceLanguage     = "language"     // Scored for the locale's language, e.g. "ja"
+	LocaleSourceModelCard    = "model_card"   // Listed in the Hugging Face model card, unscored
+	LocaleSourceMultilingual = "multilingual" // Tagged multilingual, unscored
+	LocaleSourceDefault      = "default"      // English, which every text model is assumed to write well
+)
+
+// Scores assumed for locales a model claims without a measured score
+const (
+	claimedLocaleScore = 0.7
+	defaultLocaleScore = 1.0
+)
+
+// localePattern accepts a language with an optional script and region, e.g.
+// "ja", "ja-JP", "zh-Hant-TW" or "es-419"
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+
+// LocaleSupport is how well a model is known to write in a locale
+type LocaleSupport struct {
+	Locale string  `json:"locale"`
+	Score  float64 `json:"score"`  // 0-1
+	Source string  `json:"source"` // LocaleSource*
+}
+
+// CanonicalLocale normalizes a BCP 47 locale such as "ja_jp" to "ja-JP" and
+// rejects anything else
+func CanonicalLocale(locale string) (string, error) {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToUpper(part)
+		}
+	}
+	canonical := strings.Join(parts, "-")
+	if !localePattern.MatchString(canonical) {
+		return "", fmt.Errorf("%q is not a locale such as \"ja-JP\" or \"es\"", locale)
+	}
+	return canonical, nil
+}
+
+// LocaleLanguage returns the language of a canonical locale, "ja" for "ja-JP"
+func LocaleLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	return language
+}
+
+// LocaleSupport returns how well the model writes in a canonical locale, from
+// the most specific evidence it has, or false when nothing is known
+func (m EnhancedModel) LocaleSupport(locale string) (LocaleSupport, bool) {
+	language := LocaleLanguage(locale)
+	if score, ok := m.Locales[locale]; ok {
+		return LocaleSupport{Locale: locale, Score: score, Source: LocaleSourceLocale}, true
+	}
+	if score, ok := m.Locales[language]; ok {
+		return LocaleSupport{Locale: locale, Score: score, Source: LocaleSourceLanguage}, true
+	}
+	if hub := m.CommunityIntelligence.HuggingFace; hub != nil {
+		for _, listed := range hub.Languages {
+			if strings.EqualFold(listed, language) {
+				return LocaleSupport{Locale: locale, Score: claimedLocaleScore, Source: LocaleSourceModelCard}, true
+			}
+		}
+	}
+	if language == "en" && m.ModelType == "text" {
+		return LocaleSupport{Locale: locale, Score: defaultLocaleScore, Source: LocaleSourceDefault}, true
+	}
+	for _, tag := range m.Tags {
+		if strings.EqualFold(tag, "multilingual") {
+			return LocaleSupport{Locale: locale, Score: claimedLocaleScore, Source: LocaleSourceMultilingual}, true
+		}
+	}
+	return LocaleSupport{}, false
+}
+
+// LoadLocaleScores reads per-model locale scores, {"model-id": {"ja-JP": 0.92,
+// "es": 0.88}}, with scores from 0 to 1 keyed by locale or language
+func LoadLocaleScores(path string) (map[string]map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read locale scores: %w", err)
+	}
+	var raw map[string]map[string]float64
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse locale scores: %w", err)
+	}
+
+	scores := make(map[string]map[string]float64, len(raw))
+	for modelID, locales := range raw {
+		scores[modelID] = make(map[string]float64, len(locales))
+		for locale, score := range locales {
+			canonical, err := CanonicalLocale(locale)
+			if err != nil {
+				return nil, fmt.Errorf("locale scores for %s: %w", modelID, err)
+			}
+			if score < 0 || score > 1 {
+				return nil, fmt.Errorf("locale scores for %s: %s must be between 0 and 1", modelID, locale)
+			}
+			scores[modelID][canonical] = score
+		}
+	}
+	return scores, nil
+}
+
+// SetLocaleScores layers per-model locale scores over the catalog's; like
+// measured benchmarks, they survive later fusions and snapshot swaps
+func (fs *FusionService) SetLocaleScores(scores map[string]map[string]float64) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceLocales, fs.catalogBefore())
+
+	fs.locales = scores
+	fs.applyLocaleScores(fs.fusedModels)
+	log.Printf("[FUSION] Locale scores set for %d models", len(scores))
+}
+
+// applyLocaleScores layers locale scores over the fused catalog; the caller
+// holds the write lock
+func (fs *FusionService) applyLocaleScores(catalog map[string]EnhancedModel) {
+	for modelID, scores := range fs.locales {
+		model, ok := catalog[modelID]
+		if !ok {
+			continue
+		}
+		// The catalog's map is shared with the base catalog; copy before writing
+		locales := make(map[string]float64, len(model.Locales)+len(scores))
+		for locale, score := range model.Locales {
+			locales[locale] = score
+		}
+		for locale, score := range scores {
+			locales[locale] = score
+		}
+		model.Locales = locales
+		catalog[modelID] = model
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"log"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// measuredBenchmarkPrefix namespaces benchmarks the router measured itself
+const measuredBenchmarkPrefix = "eval_"
+
+// MeasuredBenchmark returns the benchmarks.text key holding a model's measured
+// quality for a category
+func MeasuredBenchmark(category string) string {
+	return measuredBenchmarkPrefix + category
+}
+
+// IsMeasuredBenchmark reports whether a benchmarks.text key was measured by the router
+func IsMeasuredBenchmark(name string) bool {
+	return strings.HasPrefix(name, measuredBenchmarkPrefix)
+}
+
+// SetMeasuredBenchmarks records scores measured by the router itself, such as
+// eval suite results, keyed by category. They are layered over the catalog
+// immediately and after every later fusion or snapshot swap.
+func (fs *FusionService) SetMeasuredBenchmarks(modelID string, scores map[string]float64) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceBenchmarks, fs.catalogBefore())
+
+	if fs.measured == nil {
+		fs.measured = make(map[string]map[string]float64)
+	}
+	merged := make(map[string]float64, len(fs.measured[modelID])+len(scores))
+	for category, score := range fs.measured[modelID] {
+		merged[category] = score
+	}
+	for category, score := range scores {
+		merged[category] = score
+	}
+	fs.measured[modelID] = merged
+
+	if model, ok := fs.fusedModels[modelID]; ok {
+		fs.fusedModels[modelID] = withMeasured(model, merged)
+	}
+	log.Printf("[FUSION] Measured benchmarks updated for %s: %v", modelID, scores)
+}
+
+// applyMeasuredBenchmarks layers measured scores over the fused catalog; the
+// caller holds the write lock
+func (fs *FusionService) applyMeasuredBenchmarks(catalog map[string]EnhancedModel) {
+	for modelID, scores := range fs.measured {
+		if model, ok := catalog[modelID]; ok {
+			catalog[modelID] = withMeasured(model, scores)
+		}
+	}
+}
+
+// withMeasured copies the model's text benchmarks, which are shared with the
+// base catalog, before adding measured scores
+func withMeasured(model EnhancedModel, scores map[string]float64) EnhancedModel {
+	text := make(map[string]float64, len(model.Benchmarks.Text)+len(scores))
+	for name, value := range model.Benchmarks.Text {
+		text[name] = value
+	}
+	for category, score := range scores {
+		text[MeasuredBenchmark(category)] = score
+	}
+	model.Benchmarks.Text = text
+	return model
+}
@@ -47,7 +47,8 @@ type DataProvenance struct {
 	ScrapedData      map[string]string `json:"scraped_data"` // Field -> timestamp
 	APIData          map[string]string `json:"api_data"`     // Field -> timestamp
 	LastConsolidated string            `json:"last_consolidated"`
-	DataQuality      float64           `json:"data_quality"` // 0.0 to 1.0
+	DerivedData      map[string]string `json:"derived_data,omitempty"` // Field -> how it was inferred
+	DataQuality      float64           `json:"data_quality"`           // 0.0 to 1.0
 }
 
 // Enhanced ModelProfile with classifier integration
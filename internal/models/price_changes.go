@@ -0,0 +1,76 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// PricingObserver is told the catalog's prices after every fusion or snapshot
+// swap, to detect provider price changes
+type PricingObserver interface {
+	ObservePrices(ctx context.Context, catalog []EnhancedModel)
+}
+
+// PriceChange is the most recent recorded change to a model's list price
+type PriceChange struct {
+	ChangedAt time.Time   `json:"changed_at"`
+	Previous  TextPricing `json:"previous"`
+}
+
+// SetPricingObserver reports the catalog's prices after each fusion
+func (fs *FusionService) SetPricingObserver(observer PricingObserver) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.pricingObserver = observer
+}
+
+// SetPriceChanges replaces the recent price changes shown on models. Models
+// missing from changes lose their marker; like statuses, changes survive
+// later fusions and snapshot swaps.
+func (fs *FusionService) SetPriceChanges(changes map[string]PriceChange) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourcePricing, fs.catalogBefore())
+
+	fs.priceChanges = changes
+	fs.applyPriceChanges(fs.fusedModels)
+	if len(changes) > 0 {
+		log.Printf("[FUSION] %d models with recent price changes", len(changes))
+	}
+}
+
+// applyPriceChanges marks models whose price changed recently; the caller
+// holds the write lock
+func (fs *FusionService) applyPriceChanges(catalog map[string]EnhancedModel) {
+	for modelID, model := range catalog {
+		change, changed := fs.priceChanges[modelID]
+		switch {
+		case changed:
+			changedAt, previous := change.ChangedAt, change.Previous
+			model.PriceChangedAt = &changedAt
+			model.PreviousPricing = &previous
+		case model.PriceChangedAt != nil:
+			model.PriceChangedAt = nil
+			model.PreviousPricing = nil
+		default:
+			continue
+		}
+		catalog[modelID] = model
+	}
+}
+
+// observePrices hands the observer a copy of the catalog outside the fusion
+// lock; the caller holds the write lock
+func (fs *FusionService) observePrices() {
+	if fs.pricingObserver == nil {
+		return
+	}
+	catalog := make([]EnhancedModel, 0, len(fs.fusedModels))
+	for _, model := range fs.fusedModels {
+		catalog = append(catalog, model)
+	}
+	go fs.pricingObserver.ObservePrices(context.Background(), catalog)
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// PriceTierBatch is asynchronous batch processing, e.g. the OpenAI Batch
+	// API or Anthropic Message Batches, completed within a turnaround window
+	PriceTierBatch = "batch"
+	// PriceTierOffPeak is realtime serving at a discount during off-peak hours
+	PriceTierOffPeak = "off_peak"
+)
+
+// PriceTier is a discounted way to run a text model. Explicit per-token prices
+// take precedence over Discount, which applies to whatever realtime price the
+// caller sees, negotiated prices included.
+type PriceTier struct {
+	Name            string         `json:"name"`               // PriceTierBatch or PriceTierOffPeak
+	Discount        float64        `json:"discount,omitempty"` // Fraction off the realtime price, e.g. 0.5
+	CostInPer1K     *float64       `json:"cost_in_per_1k,omitempty"`
+	CostOutPer1K    *float64       `json:"cost_out_per_1k,omitempty"`
+	TurnaroundHours float64        `json:"turnaround_hours,omitempty"` // Batch completion window
+	Window          *OffPeakWindow `json:"window,omitempty"`           // When an off-peak tier applies
+}
+
+// OffPeakWindow is a daily UTC window as "HH:MM" bounds; it wraps midnight
+// when End is before Start
+type OffPeakWindow struct {
+	StartUTC string `json:"start_utc"`
+	EndUTC   string `json:"end_utc"`
+}
+
+// defaultPriceTiers are published provider-wide discounts, used for text
+// models whose profile lists no tiers of its own
+var defaultPriceTiers = map[string][]PriceTier{
+	"openai":    {{Name: PriceTierBatch, Discount: 0.5, TurnaroundHours: 24}},
+	"anthropic": {{Name: PriceTierBatch, Discount: 0.5, TurnaroundHours: 24}},
+	"google":    {{Name: PriceTierBatch, Discount: 0.5, TurnaroundHours: 24}},
+	"deepseek":  {{Name: PriceTierOffPeak, Discount: 0.5, Window: &OffPeakWindow{StartUTC: "16:30", EndUTC: "00:30"}}},
+}
+
+// PriceTiers returns the model's discounted tiers, falling back to its
+// provider's published tiers for text models
+func (m EnhancedModel) PriceTiers() []PriceTier {
+	if len(m.Pricing.Tiers) > 0 {
+		return m.Pricing.Tiers
+	}
+	if m.ModelType != "text" {
+		return nil
+	}
+	return defaultPriceTiers[strings.ToLower(m.Provider)]
+}
+
+// TextPricing returns the tier's per-token prices given the realtime prices.
+// A tier never costs more than realtime.
+func (t PriceTier) TextPricing(realtime TextPricing) TextPricing {
+	return TextPricing{
+		CostInPer1K:  t.price(t.CostInPer1K, realtime.CostInPer1K),
+		CostOutPer1K: t.price(t.CostOutPer1K, realtime.CostOutPer1K),
+	}
+}
+
+func (t PriceTier) price(explicit, realtime *float64) *float64 {
+	if realtime == nil {
+		return explicit
+	}
+	price := *realtime * (1 - t.Discount)
+	if explicit != nil && *explicit < *realtime {
+		price = *explicit
+	}
+	return &price
+}
+
+// ActiveAt reports whether the tier's window contains t; tiers without a
+// window always apply
+func (t PriceTier) ActiveAt(at time.Time) bool {
+	if t.Window == nil {
+		return true
+	}
+	start, end, err := t.Window.bounds()
+	if err != nil {
+		return false
+	}
+	at = at.UTC()
+	minute := at.Hour()*60 + at.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// EndsAfter returns when the window containing t closes
+func (w OffPeakWindow) EndsAfter(at time.Time) time.Time {
+	_, end, err := w.bounds()
+	if err != nil {
+		return at
+	}
+	at = at.UTC()
+	closes := time.Date(at.Year(), at.Month(), at.Day(), end/60, end%60, 0, 0, time.UTC)
+	if !closes.After(at) {
+		closes = closes.AddDate(0, 0, 1)
+	}
+	return closes
+}
+
+// bounds returns the window as minutes after midnight UTC
+func (w OffPeakWindow) bounds() (int, int, error) {
+	start, err := time.Parse("15:04", w.StartUTC)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid off-peak start %q: %w", w.StartUTC, err)
+	}
+	end, err := time.Parse("15:04", w.EndUTC)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid off-peak end %q: %w", w.EndUTC, err)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+// Valid reports whether both bounds parse as HH:MM
+func (w OffPeakWindow) Valid() bool {
+	_, _, err := w.bounds()
+	return err == nil
+}
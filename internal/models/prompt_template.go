@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Template variables filled in when a prompt template is applied
+const (
+	TemplateVarPrompt      = "prompt"      // The caller's last user message
+	TemplateVarSystem      = "system"      // The caller's system prompt
+	TemplateVarConstraints = "constraints" // Instructions derived from the request, e.g. its token limit
+	TemplateVarModel       = "model"       // The model's ID
+	TemplateVarDate        = "date"        // Today's date, YYYY-MM-DD
+)
+
+// maxTemplateLength bounds each part of a prompt template
+const maxTemplateLength = 8000
+
+var templateVariable = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+var templateVariables = map[string]bool{
+	TemplateVarPrompt:      true,
+	TemplateVarSystem:      true,
+	TemplateVarConstraints: true,
+	TemplateVarModel:       true,
+	TemplateVarDate:        true,
+}
+
+// PromptTemplate adapts generation requests to a model that performs better
+// with a particular system prompt or formatting. Parts may use {{prompt}},
+// {{system}}, {{constraints}}, {{model}} and {{date}}.
+type PromptTemplate struct {
+	// System is sent ahead of the caller's system prompt, or in its place when
+	// it uses {{system}}
+	System string `json:"system,omitempty"`
+	// User wraps the caller's last user message and must use {{prompt}}
+	User string `json:"user,omitempty"`
+	// Disabled, in a tenant override, sends requests to the model unchanged
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Validate rejects unknown variables and a user template without {{prompt}}
+func (t PromptTemplate) Validate() error {
+	if t.Disabled {
+		return nil
+	}
+	if t.System == "" && t.User == "" {
+		return fmt.Errorf("template needs a system or user part")
+	}
+	for name, part := range map[string]string{"system": t.System, "user": t.User} {
+		if len(part) > maxTemplateLength {
+			return fmt.Errorf("%s template exceeds %d characters", name, maxTemplateLength)
+		}
+		for _, match := range templateVariable.FindAllStringSubmatch(part, -1) {
+			if !templateVariables[match[1]] {
+				return fmt.Errorf("%s template uses unknown variable {{%s}}", name, match[1])
+			}
+		}
+	}
+	if t.User != "" && !TemplateUses(t.User, TemplateVarPrompt) {
+		return fmt.Errorf("user template must include {{%s}}", TemplateVarPrompt)
+	}
+	return nil
+}
+
+// TemplateUses reports whether a template part refers to variable
+func TemplateUses(part, variable string) bool {
+	for _, match := range templateVariable.FindAllStringSubmatch(part, -1) {
+		if match[1] == variable {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderTemplate fills a template part's variables; unknown ones are left as
+// they are
+func RenderTemplate(part string, vars map[string]string) string {
+	return templateVariable.ReplaceAllStringFunc(part, func(match string) string {
+		if value, ok := vars[templateVariable.FindStringSubmatch(match)[1]]; ok {
+			return value
+		}
+		return match
+	})
+}
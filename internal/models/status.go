@@ -0,0 +1,56 @@
+package models
+
+import (
+	"log"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// Model states during provider incidents
+const (
+	StateDegraded = "degraded" // usable, but downranked
+	StateOutage   = "outage"   // excluded from recommendations
+)
+
+// ModelStatus describes the provider incident currently affecting a model
+type ModelStatus struct {
+	State      string    `json:"state"`
+	Impact     string    `json:"impact"` // "minor", "major", "critical"
+	IncidentID string    `json:"incident_id"`
+	Incident   string    `json:"incident"`
+	URL        string    `json:"url,omitempty"`
+	Since      time.Time `json:"since"`
+}
+
+// SetModelStatuses replaces the incident status of every model. Models missing
+// from statuses are marked healthy again. Like measured benchmarks, statuses
+// survive later fusions and snapshot swaps.
+func (fs *FusionService) SetModelStatuses(statuses map[string]ModelStatus) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceStatus, fs.catalogBefore())
+
+	fs.statuses = statuses
+	fs.applyModelStatuses(fs.fusedModels)
+	if len(statuses) > 0 {
+		log.Printf("[FUSION] %d models affected by provider incidents", len(statuses))
+	}
+}
+
+// applyModelStatuses marks affected models and clears the rest; the caller
+// holds the write lock
+func (fs *FusionService) applyModelStatuses(catalog map[string]EnhancedModel) {
+	for modelID, model := range catalog {
+		status, affected := fs.statuses[modelID]
+		switch {
+		case affected:
+			model.Status = &status
+		case model.Status != nil:
+			model.Status = nil
+		default:
+			continue
+		}
+		catalog[modelID] = model
+	}
+}
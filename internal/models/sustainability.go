@@ -0,0 +1,176 @@
+package models
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// joulesPerTokenPerBillion is the inference energy of one output token per
+	// billion active parameters on current datacenter GPUs
+	joulesPerTokenPerBillion = 0.004
+	// inputTokenEnergyShare is the energy of a prompt token relative to an
+	// output token; prompts are processed in parallel
+	inputTokenEnergyShare = 0.25
+	// datacenterPUE covers cooling and power delivery on top of the accelerators
+	datacenterPUE = 1.2
+	// defaultCarbonIntensity is the world average grid intensity in gCO2e/kWh,
+	// used when a model's hosting region is unknown
+	defaultCarbonIntensity = 475.0
+	// referenceGramsPer1K is the footprint per 1K output tokens that scores
+	// 0.5; roughly a 70B model on an average grid
+	referenceGramsPer1K = 0.05
+)
+
+// regionCarbonIntensity is the grid carbon intensity of common cloud regions
+// in gCO2e/kWh
+var regionCarbonIntensity = map[string]float64{
+	"us-east-1":               380,
+	"us-east-2":               440,
+	"us-west-1":               210,
+	"us-west-2":               120,
+	"ca-central-1":            30,
+	"eu-west-1":               290,
+	"eu-west-2":               200,
+	"eu-west-3":               60,
+	"eu-central-1":            340,
+	"eu-north-1":              20,
+	"ap-northeast-1":          470,
+	"ap-southeast-1":          410,
+	"ap-southeast-2":          600,
+	"ap-south-1":              680,
+	"sa-east-1":               100,
+	"eastus":                  380,
+	"eastus2":                 380,
+	"westus":                  210,
+	"westus2":                 120,
+	"northeurope":             290,
+	"westeurope":              330,
+	"swedencentral":           20,
+	"francecentral":           60,
+	"uksouth":                 200,
+	"japaneast":               470,
+	"australiaeast":           600,
+	"us-central1":             440,
+	"us-east1":                380,
+	"us-west1":                120,
+	"europe-west1":            170,
+	"europe-west4":            330,
+	"europe-north1":           90,
+	"asia-northeast1":         470,
+	"northamerica-northeast1": 30,
+}
+
+// Sustainability is a model's energy and carbon profile. Every field is
+// optional; energy is estimated from parameters and carbon from the region
+// when not given.
+type Sustainability struct {
+	TokensPerJoule  *float64 `json:"tokens_per_joule,omitempty"` // Measured output tokens per joule
+	Region          string   `json:"region,omitempty"`           // Hosting region, for grid carbon intensity
+	CarbonIntensity *float64 `json:"carbon_intensity,omitempty"` // gCO2e/kWh; overrides the region's
+}
+
+// EnergyProfile is what one token of a model costs in energy and carbon
+type EnergyProfile struct {
+	JoulesPerToken  float64 `json:"joules_per_token"` // Per output token, datacenter overhead included
+	CarbonIntensity float64 `json:"carbon_intensity"` // gCO2e/kWh of the hosting grid
+	Source          string  `json:"source"`           // "measured" or "estimated" from parameters
+}
+
+// Footprint is the estimated energy and emissions of a generation
+type Footprint struct {
+	EnergyWh  float64 `json:"energy_wh"`
+	CO2eGrams float64 `json:"co2e_grams"`
+	Source    string  `json:"source"` // "measured" or "estimated"
+}
+
+// parameterCount matches sizes such as "70B", "1.6B" or "2T"; for mixtures of
+// experts the active count, e.g. "671B total (37B active)", is preferred
+var (
+	parameterCount = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*([MBT])\b`)
+	activeCount    = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*([MBT])\s+active`)
+)
+
+// ActiveParametersB returns the model's active parameters in billions, when
+// its specs state them
+func (m EnhancedModel) ActiveParametersB() (float64, bool) {
+	spec := m.TechnicalSpecs.Parameters
+	match := activeCount.FindStringSubmatch(spec)
+	if match == nil {
+		match = parameterCount.FindStringSubmatch(spec)
+	}
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	switch strings.ToUpper(match[2]) {
+	case "M":
+		n /= 1000
+	case "T":
+		n *= 1000
+	}
+	return n, true
+}
+
+// EnergyProfile returns the model's energy per token, measured when its
+// sustainability data gives tokens per joule and otherwise estimated from its
+// parameter count. Models with neither have no profile.
+func (m EnhancedModel) EnergyProfile() (EnergyProfile, bool) {
+	profile := EnergyProfile{CarbonIntensity: defaultCarbonIntensity}
+
+	s := m.Sustainability
+	switch {
+	case s != nil && s.TokensPerJoule != nil && *s.TokensPerJoule > 0:
+		profile.JoulesPerToken = 1 / *s.TokensPerJoule
+		profile.Source = "measured"
+	default:
+		params, ok := m.ActiveParametersB()
+		if !ok {
+			return profile, false
+		}
+		profile.JoulesPerToken = params * joulesPerTokenPerBillion * datacenterPUE
+		profile.Source = "estimated"
+	}
+
+	region := ""
+	if s != nil {
+		region = s.Region
+	}
+	if region == "" && len(m.Endpoints) > 0 {
+		region = m.Endpoints[0].Region
+	}
+	if intensity, ok := regionCarbonIntensity[strings.ToLower(region)]; ok {
+		profile.CarbonIntensity = intensity
+	}
+	if s != nil && s.CarbonIntensity != nil && *s.CarbonIntensity >= 0 {
+		profile.CarbonIntensity = *s.CarbonIntensity
+	}
+	return profile, true
+}
+
+// Footprint estimates the energy and emissions of a generation
+func (p EnergyProfile) Footprint(inputTokens, outputTokens int) Footprint {
+	joules := (float64(outputTokens) + float64(inputTokens)*inputTokenEnergyShare) * p.JoulesPerToken
+	wh := joules / 3600
+	return Footprint{
+		EnergyWh:  math.Round(wh*1e6) / 1e6,
+		CO2eGrams: math.Round(wh/1000*p.CarbonIntensity*1e6) / 1e6,
+		Source:    p.Source,
+	}
+}
+
+// SustainabilityScore rates the model's emissions per output token from 0 to
+// 1, higher being greener. Models without an energy profile have no score.
+func (m EnhancedModel) SustainabilityScore() (float64, bool) {
+	profile, ok := m.EnergyProfile()
+	if !ok {
+		return 0, false
+	}
+	gramsPer1K := profile.Footprint(0, 1000).CO2eGrams
+	return 1 / (1 + gramsPer1K/referenceGramsPer1K), true
+}
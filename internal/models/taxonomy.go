@@ -0,0 +1,401 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Canonical capability names. Catalog files, Analytics AI, benchmark mappings,
+// the classifier and tenant registrations all use different spellings; every
+// capability key is resolved to one of these before it is stored or looked up.
+const (
+	CapabilityCoding          = "coding"
+	CapabilityMath            = "math"
+	CapabilityReasoning       = "reasoning"
+	CapabilityWriting         = "writing"
+	CapabilityCreative        = "creative"
+	CapabilityAnalysis        = "analysis"
+	CapabilityResearch        = "research"
+	CapabilityConversation    = "conversation"
+	CapabilityTranslation     = "translation"
+	CapabilitySummarization   = "summarization"
+	CapabilityGrounding       = "grounding"
+	CapabilityVisionInput     = "vision_input"
+	CapabilityPhotorealistic  = "photorealistic"
+	CapabilityImageGeneration = "image_generation"
+	CapabilityVideoGeneration = "video_generation"
+	CapabilityAudioGeneration = "audio_generation"
+)
+
+var canonicalCapabilities = []string{
+	CapabilityCoding, CapabilityMath, CapabilityReasoning, CapabilityWriting,
+	CapabilityCreative, CapabilityAnalysis, CapabilityResearch, CapabilityConversation,
+	CapabilityTranslation, CapabilitySummarization, CapabilityGrounding, CapabilityVisionInput, CapabilityPhotorealistic,
+	CapabilityImageGeneration, CapabilityVideoGeneration, CapabilityAudioGeneration,
+}
+
+// capabilityAliases maps known spellings to their canonical capability
+var capabilityAliases = map[string]string{
+	"code":                 CapabilityCoding,
+	"programming":          CapabilityCoding,
+	"software_engineering": CapabilityCoding,
+	"code_generation":      CapabilityCoding,
+	"mathematics":          CapabilityMath,
+	"maths":                CapabilityMath,
+	"logic":                CapabilityReasoning,
+	"logical_reasoning":    CapabilityReasoning,
+	"intelligence":         CapabilityReasoning,
+	"creative_writing":     CapabilityWriting,
+	"copywriting":          CapabilityWriting,
+	"content_generation":   CapabilityWriting,
+	"creativity":           CapabilityCreative,
+	"data_analysis":        CapabilityAnalysis,
+	"analytics":            CapabilityAnalysis,
+	"chat":                 CapabilityConversation,
+	"dialogue":             CapabilityConversation,
+	"support":              CapabilityConversation,
+	"translate":            CapabilityTranslation,
+	"summarize":            CapabilitySummarization,
+	"summary":              CapabilitySummarization,
+	"long_context":         CapabilityGrounding,
+	"rag":                  CapabilityGrounding,
+	"vision":               CapabilityVisionInput,
+	"image_input":          CapabilityVisionInput,
+	"image_understanding":  CapabilityVisionInput,
+	"image_analysis":       CapabilityVisionInput,
+	"visual_understanding": CapabilityVisionInput,
+	"vqa":                  CapabilityVisionInput,
+	"ocr":                  CapabilityVisionInput,
+	"text_to_image":        CapabilityImageGeneration,
+	"text_to_video":        CapabilityVideoGeneration,
+	"text_to_speech":       CapabilityAudioGeneration,
+}
+
+// Subcategories split a text capability by the kind of task a prompt asks
+// for. Names are unique across categories, so a bare subcategory identifies
+// its category too.
+const (
+	SubcategorySQL              = "sql"
+	SubcategoryFrontend         = "frontend"
+	SubcategoryBackend          = "backend"
+	SubcategorySystems          = "systems"
+	SubcategoryDataScience      = "data_science"
+	SubcategoryDevOps           = "devops"
+	SubcategoryMarketingCopy    = "marketing_copy"
+	SubcategoryTechnicalWriting = "technical_writing"
+	SubcategoryAcademicWriting  = "academic_writing"
+	SubcategoryBusinessEmail    = "business_email"
+	SubcategoryStatistics       = "statistics"
+	SubcategoryProofs           = "proofs"
+	SubcategoryFinancial        = "financial_analysis"
+	SubcategoryLegal            = "legal_analysis"
+	SubcategoryCustomerSupport  = "customer_support"
+)
+
+// subcategoryTaxonomy lists the subcategories of each category that has them
+var subcategoryTaxonomy = map[string][]string{
+	CapabilityCoding: {
+		SubcategorySQL, SubcategoryFrontend, SubcategoryBackend,
+		SubcategorySystems, SubcategoryDataScience, SubcategoryDevOps,
+	},
+	CapabilityWriting: {
+		SubcategoryMarketingCopy, SubcategoryTechnicalWriting,
+		SubcategoryAcademicWriting, SubcategoryBusinessEmail,
+	},
+	CapabilityMath:         {SubcategoryStatistics, SubcategoryProofs},
+	CapabilityAnalysis:     {SubcategoryFinancial, SubcategoryLegal},
+	CapabilityConversation: {SubcategoryCustomerSupport},
+}
+
+// subcategoryParents maps each subcategory to its category
+var subcategoryParents = func() map[string]string {
+	parents := make(map[string]string)
+	for category, subs := range subcategoryTaxonomy {
+		for _, s := range subs {
+			parents[s] = category
+		}
+	}
+	return parents
+}()
+
+// normalizeName lowercases a name and turns spaces and hyphens into underscores
+func normalizeName(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+}
+
+// CanonicalCapability resolves a capability name to its canonical form. Case,
+// spaces and hyphens are normalized; names outside the taxonomy are returned
+// normalized rather than dropped.
+func CanonicalCapability(name string) string {
+	key := normalizeName(name)
+	if canonical, ok := capabilityAliases[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// Subcategories returns the subcategories of a category, if any
+func Subcategories(category string) []string {
+	return append([]string(nil), subcategoryTaxonomy[category]...)
+}
+
+// CanonicalSubcategory normalizes a subcategory's case, spaces and hyphens
+func CanonicalSubcategory(name string) string {
+	return normalizeName(name)
+}
+
+// ParentCapability returns the category a subcategory belongs to, or "" for
+// names outside the taxonomy
+func ParentCapability(subcategory string) string {
+	return subcategoryParents[normalizeName(subcategory)]
+}
+
+// SplitCapability resolves a category name that may name a subcategory:
+// "writing/marketing-copy", "writing.marketing_copy" and the bare
+// "marketing_copy" all give ("writing", "marketing_copy"), while a plain
+// category such as "code" gives ("coding", ""). This keeps flat category names
+// working alongside the two-level taxonomy.
+func SplitCapability(name string) (category, subcategory string) {
+	if i := strings.IndexAny(name, "/."); i >= 0 {
+		return CanonicalCapability(name[:i]), CanonicalSubcategory(name[i+1:])
+	}
+	if parent := ParentCapability(name); parent != "" {
+		return parent, CanonicalSubcategory(name)
+	}
+	return CanonicalCapability(name), ""
+}
+
+// ValidateSubcategory rejects a subcategory the category does not have
+func ValidateSubcategory(category, subcategory string) error {
+	if subcategory == "" {
+		return nil
+	}
+	for _, s := range subcategoryTaxonomy[category] {
+		if s == subcategory {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown subcategory %q for category %q", subcategory, category)
+}
+
+// IsCanonicalCapability reports whether name is in the taxonomy as spelled
+func IsCanonicalCapability(name string) bool {
+	for _, c := range canonicalCapabilities {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CapabilityTaxonomy returns the canonical capabilities and the aliases of each
+func CapabilityTaxonomy() map[string][]string {
+	taxonomy := make(map[string][]string, len(canonicalCapabilities))
+	for _, c := range canonicalCapabilities {
+		taxonomy[c] = []string{}
+	}
+	for alias, c := range capabilityAliases {
+		taxonomy[c] = append(taxonomy[c], alias)
+	}
+	for _, aliases := range taxonomy {
+		sort.Strings(aliases)
+	}
+	return taxonomy
+}
+
+// SubcategoryTaxonomy returns the subcategories of every category that has them
+func SubcategoryTaxonomy() map[string][]string {
+	taxonomy := make(map[string][]string, len(subcategoryTaxonomy))
+	for category := range subcategoryTaxonomy {
+		taxonomy[category] = Subcategories(category)
+	}
+	return taxonomy
+}
+
+// NormalizeCapabilities rewrites the model's capability keys and
+// specializations to canonical names. When an alias and its canonical name
+// both appear, the canonical entry wins. Text tasks keyed by a subcategory are
+// folded into their category's subcategory scores. Renames are recorded in
+// provenance. Returns how many keys were renamed.
+func NormalizeCapabilities(model *EnhancedModel) int {
+	renamed := make(map[string]string)
+	caps := &model.TaskCapabilities
+	caps.TextTasks = foldSubcategories(canonicalTasks(caps.TextTasks, "text_tasks", renamed), renamed)
+	caps.ImageTasks = canonicalTasks(caps.ImageTasks, "image_tasks", renamed)
+	caps.VideoTasks = canonicalTasks(caps.VideoTasks, "video_tasks", renamed)
+	caps.AudioTasks = canonicalTasks(caps.AudioTasks, "audio_tasks", renamed)
+	caps.GenerativeTasks = canonicalGenerativeTasks(caps.GenerativeTasks, renamed)
+
+	if specs := model.ComplexityRecommendations.Specializations; len(specs) > 0 {
+		normalized := make([]string, 0, len(specs))
+		seen := make(map[string]bool, len(specs))
+		for _, s := range specs {
+			c := CanonicalCapability(s)
+			if !seen[c] {
+				seen[c] = true
+				normalized = append(normalized, c)
+			}
+		}
+		model.ComplexityRecommendations.Specializations = normalized
+	}
+
+	if len(renamed) == 0 {
+		return 0
+	}
+	// The provenance map may be shared with the base catalog; copy before writing
+	provenance := make(map[string]string, len(model.DataProvenance.DerivedData)+len(renamed))
+	for k, v := range model.DataProvenance.DerivedData {
+		provenance[k] = v
+	}
+	for key, from := range renamed {
+		provenance[key] = fmt.Sprintf("renamed from %q", from)
+	}
+	model.DataProvenance.DerivedData = provenance
+	return len(renamed)
+}
+
+// CanonicalCapabilityScores returns scores keyed by canonical capability;
+// a canonical key wins over its aliases
+func CanonicalCapabilityScores(scores map[string]float64) map[string]float64 {
+	if scores == nil {
+		return nil
+	}
+	normalized := make(map[string]float64, len(scores))
+	for name, score := range scores {
+		c := CanonicalCapability(name)
+		if _, taken := normalized[c]; taken && name != c {
+			continue
+		}
+		normalized[c] = score
+	}
+	return normalized
+}
+
+// canonicalTasks returns tasks keyed by canonical capability, or tasks itself
+// when every key is already canonical
+func canonicalTasks(tasks map[string]TaskCapability, field string, renamed map[string]string) map[string]TaskCapability {
+	canonical := true
+	for name := range tasks {
+		if CanonicalCapability(name) != name {
+			canonical = false
+			break
+		}
+	}
+	if canonical {
+		return tasks
+	}
+
+	normalized := make(map[string]TaskCapability, len(tasks))
+	for name, capability := range tasks {
+		c := CanonicalCapability(name)
+		if c != name {
+			if _, exists := tasks[c]; exists {
+				continue
+			}
+			renamed["task_capabilities."+field+"."+c] = name
+		}
+		normalized[c] = capability
+	}
+	return normalized
+}
+
+// foldSubcategories moves text tasks keyed by a subcategory, such as
+// "writing/marketing_copy" or "marketing_copy", into their category's
+// subcategory scores; a score the category already lists wins. A category the
+// model has no entry for is created from the average of its subcategories
+// and the complexities any of them covers. Returns tasks itself when no key
+// names a subcategory.
+func foldSubcategories(tasks map[string]TaskCapability, renamed map[string]string) map[string]TaskCapability {
+	nested := false
+	for name := range tasks {
+		if _, sub := SplitCapability(name); sub != "" {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return tasks
+	}
+
+	folded := make(map[string]TaskCapability, len(tasks))
+	subs := make(map[string]map[string]TaskCapability)
+	for name, capability := range tasks {
+		category, sub := SplitCapability(name)
+		if sub == "" {
+			folded[name] = capability
+			continue
+		}
+		if subs[category] == nil {
+			subs[category] = make(map[string]TaskCapability)
+		}
+		subs[category][sub] = capability
+
+		// canonicalTasks may already have recorded a respelling of this key
+		from := name
+		if original, ok := renamed["task_capabilities.text_tasks."+name]; ok {
+			from = original
+			delete(renamed, "task_capabilities.text_tasks."+name)
+		}
+		renamed["task_capabilities.text_tasks."+category+".subcategories."+sub] = from
+	}
+
+	for category, entries := range subs {
+		parent, exists := folded[category]
+		if !exists {
+			covered := make(map[string]bool)
+			for _, entry := range entries {
+				parent.Score += entry.Score / float64(len(entries))
+				parent.Confidence += entry.Confidence / float64(len(entries))
+				for _, level := range entry.ComplexityRange {
+					covered[level] = true
+				}
+			}
+			for _, level := range []string{"simple", "medium", "hard", "expert"} {
+				if covered[level] {
+					parent.ComplexityRange = append(parent.ComplexityRange, level)
+				}
+			}
+		}
+		// The subcategory map may be shared with the base catalog; copy before writing
+		scores := make(map[string]float64, len(parent.Subcategories)+len(entries))
+		for sub, score := range parent.Subcategories {
+			scores[sub] = score
+		}
+		for sub, entry := range entries {
+			if _, curated := scores[sub]; !curated {
+				scores[sub] = entry.Score
+			}
+		}
+		parent.Subcategories = scores
+		folded[category] = parent
+	}
+	return folded
+}
+
+func canonicalGenerativeTasks(tasks map[string]GenerativeCapability, renamed map[string]string) map[string]GenerativeCapability {
+	canonical := true
+	for name := range tasks {
+		if CanonicalCapability(name) != name {
+			canonical = false
+			break
+		}
+	}
+	if canonical {
+		return tasks
+	}
+
+	normalized := make(map[string]GenerativeCapability, len(tasks))
+	for name, capability := range tasks {
+		c := CanonicalCapability(name)
+		if c != name {
+			if _, exists := tasks[c]; exists {
+				continue
+			}
+			renamed["task_capabilities.generative_tasks."+c] = name
+		}
+		normalized[c] = capability
+	}
+	return normalized
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// Trust tiers, from most to least trusted
+const (
+	TrustVerified     = "verified"     // Approved by an admin or passed every automated check
+	TrustCommunity    = "community"    // Established but not verified
+	TrustExperimental = "experimental" // Newly ingested and not yet reviewed
+)
+
+var trustRank = map[string]int{
+	TrustExperimental: 0,
+	TrustCommunity:    1,
+	TrustVerified:     2,
+}
+
+// IsValidTrustTier reports whether tier is a known trust tier
+func IsValidTrustTier(tier string) bool {
+	_, ok := trustRank[tier]
+	return ok
+}
+
+// MeetsTrustTier reports whether tier is at least as trusted as min. Models
+// without a tier count as experimental.
+func MeetsTrustTier(tier, min string) bool {
+	if min == "" {
+		return true
+	}
+	return trustRank[tier] >= trustRank[min]
+}
+
+// Trust is a model's trust tier and how it got there
+type Trust struct {
+	Tier       string     `json:"tier"`
+	VerifiedBy string     `json:"verified_by,omitempty"` // Admin user ID, or "automated" when checks verified it
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// TrustTier returns the model's tier, experimental when it has none
+func (m EnhancedModel) TrustTier() string {
+	if m.Trust == nil {
+		return TrustExperimental
+	}
+	return m.Trust.Tier
+}
+
+// SetTrust replaces the trust tiers shown on models. Models missing from
+// trust lose their tier and count as experimental; like statuses, tiers
+// survive later fusions and snapshot swaps.
+func (fs *FusionService) SetTrust(trust map[string]Trust) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	defer fs.publishChanges(invalidation.SourceTrust, fs.catalogBefore())
+
+	fs.trust = trust
+	fs.applyTrust(fs.fusedModels)
+}
+
+// applyTrust sets each model's trust tier; the caller holds the write lock
+func (fs *FusionService) applyTrust(catalog map[string]EnhancedModel) {
+	for modelID, model := range catalog {
+		trust, ok := fs.trust[modelID]
+		switch {
+		case ok:
+			model.Trust = &trust
+		case model.Trust != nil:
+			model.Trust = nil
+		default:
+			continue
+		}
+		catalog[modelID] = model
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import "strings"
+
+// InputModalityImage marks models that read images sent with the prompt
+const InputModalityImage = "image"
+
+// visionTags are tags and use cases that mark a model as reading images when
+// the catalog does not list its input modalities
+var visionTags = map[string]bool{
+	"multimodal": true,
+	"vlm":        true,
+}
+
+// AcceptsImageInput reports whether the model can read images in the prompt.
+// input_modalities is authoritative when the catalog sets it. Otherwise
+// multimodal models qualify, and text models need a vision_input capability or
+// a vision tag, specialization or use case; image, video and audio generators
+// never qualify without input_modalities.
+func (m EnhancedModel) AcceptsImageInput() bool {
+	if len(m.InputModalities) > 0 {
+		for _, modality := range m.InputModalities {
+			if strings.EqualFold(modality, InputModalityImage) {
+				return true
+			}
+		}
+		return false
+	}
+	if m.ModelType == "multimodal" {
+		return true
+	}
+	if m.ModelType != "text" {
+		return false
+	}
+	if _, ok := m.TaskCapabilities.TextTasks[CapabilityVisionInput]; ok {
+		return true
+	}
+
+	for _, list := range [][]string{m.Tags, m.ComplexityRecommendations.Specializations, m.CommunityFeedback.BestUseCases} {
+		for _, tag := range list {
+			c := CanonicalCapability(tag)
+			if c == CapabilityVisionInput || visionTags[c] {
+				return true
+			}
+		}
+	}
+	return false
+}
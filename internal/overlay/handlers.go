@@ -0,0 +1,118 @@
+package overlay
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes catalog overlay management on the dashboard
+type Handlers struct {
+	store   *Store
+	catalog func() []models.EnhancedModel
+}
+
+func NewHandlers(store *Store, catalog func() []models.EnhancedModel) *Handlers {
+	return &Handlers{store: store, catalog: catalog}
+}
+
+// Get returns the caller's overlay and how many catalog models it leaves visible
+func (h *Handlers) Get(c *gin.Context) {
+	o, err := h.store.Get(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load catalog overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	catalog := h.catalog()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"overlay":        o,
+			"visible_models": len(o.Apply(catalog)),
+			"catalog_models": len(catalog),
+		},
+	})
+}
+
+// Put replaces the caller's overlay
+func (h *Handlers) Put(c *gin.Context) {
+	var req Overlay
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.UserID = c.GetString("user_id")
+
+	catalog := h.catalog()
+	if err := checkCatalog(req, catalog); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid catalog overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := h.store.Put(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to save catalog overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"visible_models": len(req.Apply(catalog)),
+	})
+}
+
+// Delete removes the caller's overlay
+func (h *Handlers) Delete(c *gin.Context) {
+	if err := h.store.Delete(c.Request.Context(), c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete catalog overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// checkCatalog requires every entry and annotation to name a catalog model or
+// provider, so a typo cannot silently hide the whole catalog
+func checkCatalog(o Overlay, catalog []models.EnhancedModel) error {
+	ids := make(map[string]bool, len(catalog))
+	providers := make(map[string]bool)
+	for _, model := range catalog {
+		ids[model.ID] = true
+		providers[strings.ToLower(model.Provider)] = true
+	}
+
+	for _, entry := range append(append([]string{}, o.Include...), o.Exclude...) {
+		if provider, ok := strings.CutPrefix(entry, ProviderPrefix); ok {
+			if !providers[strings.ToLower(provider)] {
+				return fmt.Errorf("no catalog model is served by provider %s", provider)
+			}
+		} else if !ids[entry] {
+			return fmt.Errorf("model %s is not in the catalog", entry)
+		}
+	}
+	for modelID := range o.Annotations {
+		if !ids[modelID] {
+			return fmt.Errorf("annotated model %s is not in the catalog", modelID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package overlay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// ProviderPrefix marks an include or exclude entry that matches every model of a provider
+const ProviderPrefix = "provider:"
+
+const (
+	maxEntries     = 1000
+	maxNotesLength = 2000
+)
+
+// Overlay is a tenant's view of the shared catalog. Include, when set, hides
+// every model it does not match; Exclude hides models even when included.
+// Entries are model IDs or "provider:<name>". Annotations attach notes and
+// negotiated prices to individual models. MinTrustTier, when set, hides
+// catalog models of lower trust tiers, and Compliance hides models that do
+// not meet the tenant's license, data usage or certification policy.
+type Overlay struct {
+	UserID       string                         `json:"-"`
+	Include      []string                       `json:"include"`
+	Exclude      []string                       `json:"exclude"`
+	Annotations  map[string]Annotation          `json:"annotations"`
+	MinTrustTier string                         `json:"min_trust_tier,omitempty"` // Hides models below this trust tier
+	Compliance   *models.ComplianceRequirements `json:"compliance,omitempty"`     // Hides models that do not meet it
+	UpdatedAt    time.Time                      `json:"updated_at"`
+}
+
+// Annotation is the tenant's note and negotiated pricing for one model
+type Annotation struct {
+	Notes        string   `json:"notes,omitempty"`
+	CostInPer1K  *float64 `json:"cost_in_per_1k,omitempty"`  // Replaces the list input price
+	CostOutPer1K *float64 `json:"cost_out_per_1k,omitempty"` // Replaces the list output price
+}
+
+// Validate rejects malformed overlays
+func (o *Overlay) Validate() error {
+	if len(o.Include)+len(o.Exclude) > maxEntries || len(o.Annotations) > maxEntries {
+		return fmt.Errorf("overlays are limited to %d entries and %d annotations", maxEntries, maxEntries)
+	}
+	for _, entry := range append(append([]string{}, o.Include...), o.Exclude...) {
+		if strings.TrimSpace(entry) == "" || strings.TrimSpace(entry) == ProviderPrefix {
+			return fmt.Errorf("include and exclude entries must be a model ID or %s<name>", ProviderPrefix)
+		}
+	}
+	if o.MinTrustTier != "" && !models.IsValidTrustTier(o.MinTrustTier) {
+		return fmt.Errorf("min_trust_tier must be %s, %s or %s", models.TrustVerified, models.TrustCommunity, models.TrustExperimental)
+	}
+	if o.Compliance != nil {
+		if err := o.Compliance.Validate(); err != nil {
+			return fmt.Errorf("compliance: %w", err)
+		}
+	}
+	for modelID, a := range o.Annotations {
+		if modelID == "" || strings.HasPrefix(modelID, ProviderPrefix) {
+			return fmt.Errorf("annotations must be keyed by model ID")
+		}
+		if len(a.Notes) > maxNotesLength {
+			return fmt.Errorf("notes for %s exceed %d characters", modelID, maxNotesLength)
+		}
+		for _, price := range []*float64{a.CostInPer1K, a.CostOutPer1K} {
+			if price != nil && *price < 0 {
+				return fmt.Errorf("negotiated prices for %s must not be negative", modelID)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply returns the models the tenant sees, annotated. A nil overlay leaves
+// the catalog unchanged.
+func (o *Overlay) Apply(catalog []models.EnhancedModel) []models.EnhancedModel {
+	if o == nil {
+		return catalog
+	}
+	visible := make([]models.EnhancedModel, 0, len(catalog))
+	for _, model := range catalog {
+		if o.Visible(model) {
+			visible = append(visible, o.Annotate(model))
+		}
+	}
+	return visible
+}
+
+// Visible reports whether the overlay keeps model in the tenant's catalog
+func (o *Overlay) Visible(model models.EnhancedModel) bool {
+	if o == nil {
+		return true
+	}
+	if len(o.Include) > 0 && !matchesAny(o.Include, model) {
+		return false
+	}
+	if !models.MeetsTrustTier(model.TrustTier(), o.MinTrustTier) {
+		return false
+	}
+	if o.Compliance != nil && len(model.MissingCompliance(*o.Compliance)) > 0 {
+		return false
+	}
+	return !matchesAny(o.Exclude, model)
+}
+
+// Annotate attaches the tenant's notes to model and swaps in its negotiated
+// prices. The list prices are kept on the annotation for comparison.
+func (o *Overlay) Annotate(model models.EnhancedModel) models.EnhancedModel {
+	if o == nil {
+		return model
+	}
+	a, ok := o.Annotations[model.ID]
+	if !ok {
+		return model
+	}
+
+	annotation := &models.TenantAnnotation{Notes: a.Notes}
+	if a.CostInPer1K != nil || a.CostOutPer1K != nil {
+		list := model.Pricing.Text
+		annotation.NegotiatedPricing = true
+		annotation.ListPricing = &list
+		if a.CostInPer1K != nil {
+			model.Pricing.Text.CostInPer1K = a.CostInPer1K
+		}
+		if a.CostOutPer1K != nil {
+			model.Pricing.Text.CostOutPer1K = a.CostOutPer1K
+		}
+
+		// The provenance map is shared with the catalog; copy before writing
+		provenance := make(map[string]string, len(model.DataProvenance.DerivedData)+1)
+		for k, v := range model.DataProvenance.DerivedData {
+			provenance[k] = v
+		}
+		provenance["pricing.text"] = "tenant negotiated pricing"
+		model.DataProvenance.DerivedData = provenance
+	}
+	model.TenantAnnotation = annotation
+	return model
+}
+
+func matchesAny(entries []string, model models.EnhancedModel) bool {
+	for _, entry := range entries {
+		if provider, ok := strings.CutPrefix(entry, ProviderPrefix); ok {
+			if strings.EqualFold(provider, model.Provider) {
+				return true
+			}
+		} else if entry == model.ID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package overlay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// pinCheckTimeout bounds the overlay query run for one catalog change
+const pinCheckTimeout = 10 * time.Second
+
+// PinnedBy returns the tenants whose include lists name any of modelIDs, with
+// the models each of them pins
+func (s *Store) PinnedBy(ctx context.Context, modelIDs []string) (map[string][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, include FROM catalog_overlays WHERE include ?| $1`, pq.Array(modelIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pinned models: %w", err)
+	}
+	defer rows.Close()
+
+	wanted := make(map[string]bool, len(modelIDs))
+	for _, id := range modelIDs {
+		wanted[id] = true
+	}
+	pinned := make(map[string][]string)
+	for rows.Next() {
+		var userID string
+		var raw []byte
+		if err := rows.Scan(&userID, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog overlay: %w", err)
+		}
+		var include []string
+		json.Unmarshal(raw, &include)
+		for _, entry := range include {
+			if wanted[entry] {
+				pinned[userID] = append(pinned[userID], entry)
+			}
+		}
+	}
+	return pinned, rows.Err()
+}
+
+// PinValidator warns operators when models tenants pinned in their include
+// lists leave the catalog. A tenant that only pinned removed models would
+// otherwise see an empty catalog without anyone noticing.
+type PinValidator struct {
+	store  *Store
+	alerts *alerts.Manager
+}
+
+func NewPinValidator(store *Store) *PinValidator {
+	return &PinValidator{store: store}
+}
+
+// SetAlerts posts removed pins to operators
+func (v *PinValidator) SetAlerts(manager *alerts.Manager) {
+	v.alerts = manager
+}
+
+// Validate looks for tenants pinning the models a catalog change removed; it
+// subscribes to the invalidation bus
+func (v *PinValidator) Validate(e invalidation.Event) {
+	if len(e.Removed) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pinCheckTimeout)
+	defer cancel()
+	pinned, err := v.store.PinnedBy(ctx, e.Removed)
+	if err != nil {
+		log.Printf("[OVERLAY] Failed to check pins after %s change: %v", e.Source, err)
+		return
+	}
+	if len(pinned) == 0 {
+		return
+	}
+
+	removed := make(map[string]bool)
+	for userID, modelIDs := range pinned {
+		log.Printf("[OVERLAY] Tenant %s pins %s, no longer in the catalog", userID, strings.Join(modelIDs, ", "))
+		for _, id := range modelIDs {
+			removed[id] = true
+		}
+	}
+	names := make([]string, 0, len(removed))
+	for id := range removed {
+		names = append(names, id)
+	}
+	sort.Strings(names)
+
+	v.alerts.Notify(alerts.Event{
+		Type:     alerts.EventPinnedModelRemoved,
+		Severity: alerts.SeverityWarning,
+		Source:   "overlay",
+		Key:      strings.Join(names, ","),
+		Title:    "Pinned models left the catalog",
+		Message:  fmt.Sprintf("%d tenants pin models no longer in the catalog: %s", len(pinned), strings.Join(names, ", ")),
+		Fields:   map[string]string{"change": e.Source, "tenants": fmt.Sprintf("%d", len(pinned))},
+	})
+}
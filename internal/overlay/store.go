@@ -0,0 +1,141 @@
+package overlay
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Store persists one catalog overlay per tenant
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Get returns the tenant's overlay, or nil when it has none
+func (s *Store) Get(ctx context.Context, userID string) (*Overlay, error) {
+	var include, exclude, annotations, compliance []byte
+	o := &Overlay{UserID: userID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT include, exclude, annotations, COALESCE(min_trust_tier, ''), compliance, updated_at
+		FROM catalog_overlays WHERE user_id = $1`, userID,
+	).Scan(&include, &exclude, &annotations, &o.MinTrustTier, &compliance, &o.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog overlay: %w", err)
+	}
+
+	json.Unmarshal(include, &o.Include)
+	json.Unmarshal(exclude, &o.Exclude)
+	json.Unmarshal(annotations, &o.Annotations)
+	if len(compliance) > 0 {
+		json.Unmarshal(compliance, &o.Compliance)
+	}
+	return o, nil
+}
+
+// Put replaces the tenant's overlay
+func (s *Store) Put(ctx context.Context, o Overlay) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if o.Include == nil {
+		o.Include = []string{}
+	}
+	if o.Exclude == nil {
+		o.Exclude = []string{}
+	}
+	if o.Annotations == nil {
+		o.Annotations = map[string]Annotation{}
+	}
+	include, _ := json.Marshal(o.Include)
+	exclude, _ := json.Marshal(o.Exclude)
+	annotations, _ := json.Marshal(o.Annotations)
+	var compliance interface{}
+	if o.Compliance != nil && !o.Compliance.Empty() {
+		encoded, _ := json.Marshal(o.Compliance)
+		compliance = string(encoded)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO catalog_overlays (user_id, include, exclude, annotations, min_trust_tier, compliance)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			include = EXCLUDED.include,
+			exclude = EXCLUDED.exclude,
+			annotations = EXCLUDED.annotations,
+			min_trust_tier = EXCLUDED.min_trust_tier,
+			compliance = EXCLUDED.compliance,
+			updated_at = CURRENT_TIMESTAMP`,
+		o.UserID, string(include), string(exclude), string(annotations), o.MinTrustTier, compliance)
+	if err != nil {
+		return fmt.Errorf("failed to store catalog overlay: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the tenant's overlay, restoring the full catalog
+func (s *Store) Delete(ctx context.Context, userID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM catalog_overlays WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete catalog overlay: %w", err)
+	}
+	return nil
+}
+
+// ReplaceIncluded swaps one model for another in the tenant's include list,
+// reporting whether from was included. The row is locked so replicas
+// rewriting the same overlay do not both apply it.
+func (s *Store) ReplaceIncluded(ctx context.Context, userID, from, to string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin overlay transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var raw []byte
+	err = tx.QueryRowContext(ctx, `SELECT include FROM catalog_overlays WHERE user_id = $1 FOR UPDATE`, userID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load catalog overlay: %w", err)
+	}
+	var include []string
+	json.Unmarshal(raw, &include)
+
+	// Replace from in place, keeping a single entry for to
+	replaced := false
+	seen := make(map[string]bool, len(include))
+	rewritten := make([]string, 0, len(include))
+	for _, entry := range include {
+		if entry == from {
+			replaced = true
+			entry = to
+		}
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		rewritten = append(rewritten, entry)
+	}
+	if !replaced {
+		return false, nil
+	}
+
+	encoded, _ := json.Marshal(rewritten)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE catalog_overlays SET include = $2, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1`,
+		userID, string(encoded)); err != nil {
+		return false, fmt.Errorf("failed to rewrite catalog overlay: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to rewrite catalog overlay: %w", err)
+	}
+	return true, nil
+}
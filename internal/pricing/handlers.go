@@ -0,0 +1,152 @@
+package pricing
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxListed bounds how many changes one request lists
+const maxListed = 500
+
+// Handlers exposes price change history to tenants and operators
+type Handlers struct {
+	tracker *Tracker
+}
+
+func NewHandlers(tracker *Tracker) *Handlers {
+	return &Handlers{tracker: tracker}
+}
+
+// TenantChanges lists recent price changes to models the caller pinned or used;
+// ?days (default 90) and ?limit (default 100) bound the list
+func (h *Handlers) TenantChanges(c *gin.Context) {
+	since, limit, ok := listParams(c)
+	if !ok {
+		return
+	}
+	changes, err := h.tracker.ForTenant(c.Request.Context(), c.GetString("user_id"), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list price changes",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// Changes lists every recorded price change, optionally for ?model_id
+func (h *Handlers) Changes(c *gin.Context) {
+	since, limit, ok := listParams(c)
+	if !ok {
+		return
+	}
+	changes, err := h.tracker.Recent(c.Request.Context(), c.Query("model_id"), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list price changes",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// GetWebhook returns the caller's pricing webhook
+func (h *Handlers) GetWebhook(c *gin.Context) {
+	hook, err := h.tracker.Webhooks().Get(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load pricing webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hook,
+	})
+}
+
+// PutWebhook registers the URL price changes are POSTed to
+func (h *Handlers) PutWebhook(c *gin.Context) {
+	var req struct {
+		URL string `json:"url" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := ValidateURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pricing webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := h.tracker.Webhooks().Put(c.Request.Context(), c.GetString("user_id"), req.URL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save pricing webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Pricing webhook saved",
+	})
+}
+
+// DeleteWebhook stops price change deliveries to the caller
+func (h *Handlers) DeleteWebhook(c *gin.Context) {
+	if err := h.tracker.Webhooks().Delete(c.Request.Context(), c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete pricing webhook",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Pricing webhook deleted",
+	})
+}
+
+func listParams(c *gin.Context) (time.Time, int, bool) {
+	days, limit := 90, 100
+	if raw := c.Query("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid days",
+				"details": "days must be a positive integer",
+			})
+			return time.Time{}, 0, false
+		}
+		days = n
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxListed {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid limit",
+				"details": "limit must be between 1 and " + strconv.Itoa(maxListed),
+			})
+			return time.Time{}, 0, false
+		}
+		limit = n
+	}
+	return time.Now().AddDate(0, 0, -days), limit, true
+}
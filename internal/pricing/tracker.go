@@ -0,0 +1,424 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Config holds the change threshold and who counts as affected
+type Config struct {
+	Threshold     float64       // Relative list price change recorded, e.g. 0.05 for 5%
+	RecentWindow  time.Duration // How long a change is shown on the model as price_changed_at
+	UsageWindow   time.Duration // How far back tenant usage is looked at
+	HeavyUseShare float64       // Share of a tenant's requests routed to a model that makes it a heavy user
+	MinRequests   int           // Requests to the model in the window before a tenant counts as a heavy user
+}
+
+// DefaultConfig returns the built-in thresholds; PRICING_CHANGE_THRESHOLD
+// and PRICING_HEAVY_USE_SHARE tune them
+func DefaultConfig() Config {
+	cfg := Config{
+		Threshold:     0.05,
+		RecentWindow:  30 * 24 * time.Hour,
+		UsageWindow:   30 * 24 * time.Hour,
+		HeavyUseShare: 0.2,
+		MinRequests:   100,
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("PRICING_CHANGE_THRESHOLD"), 64); err == nil && v > 0 {
+		cfg.Threshold = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("PRICING_HEAVY_USE_SHARE"), 64); err == nil && v > 0 && v <= 1 {
+		cfg.HeavyUseShare = v
+	}
+	return cfg
+}
+
+// Reasons a tenant is told about a change
+const (
+	ReasonPinned   = "pinned"    // The model is in the tenant's catalog overlay include list
+	ReasonHeavyUse = "heavy_use" // The tenant routes a large share of its traffic to the model
+	ReasonUsed     = "used"      // The tenant routed requests to the model; listed on the dashboard only
+)
+
+// Change is one recorded list price change
+type Change struct {
+	ID               string    `json:"id"`
+	ModelID          string    `json:"model_id"`
+	Provider         string    `json:"provider"`
+	PreviousInPer1K  *float64  `json:"previous_cost_in_per_1k"`
+	PreviousOutPer1K *float64  `json:"previous_cost_out_per_1k"`
+	CostInPer1K      *float64  `json:"cost_in_per_1k"`
+	CostOutPer1K     *float64  `json:"cost_out_per_1k"`
+	ChangePercent    float64   `json:"change_percent"`   // The larger of the two relative changes, signed
+	Reason           string    `json:"reason,omitempty"` // Why a tenant was told, in tenant views
+	DetectedAt       time.Time `json:"detected_at"`
+}
+
+// PriceSetter shows recent changes on the served catalog
+type PriceSetter interface {
+	SetPriceChanges(changes map[string]models.PriceChange)
+}
+
+// Tracker compares each fused catalog against the last recorded list prices,
+// keeps a history of changes beyond the threshold and tells operators and the
+// tenants who depend on the model
+type Tracker struct {
+	db      *sql.DB
+	cfg     Config
+	alerts  *alerts.Manager
+	catalog PriceSetter
+	hooks   *Webhooks
+
+	// Serializes observations; replicas are serialized by row locks
+	mu sync.Mutex
+}
+
+func NewTracker(db *sql.DB, cfg Config) *Tracker {
+	return &Tracker{db: db, cfg: cfg, hooks: NewWebhooks(db)}
+}
+
+// SetAlerts posts detected changes to the operational alert webhooks
+func (t *Tracker) SetAlerts(manager *alerts.Manager) {
+	t.alerts = manager
+}
+
+// SetCatalog marks recently changed models in the served catalog
+func (t *Tracker) SetCatalog(catalog PriceSetter) {
+	t.catalog = catalog
+}
+
+// Webhooks returns the tenant webhook registry changes are delivered to
+func (t *Tracker) Webhooks() *Webhooks {
+	return t.hooks
+}
+
+// Start checks the current catalog against the recorded prices, which
+// catches changes made while the router was down, and loads recent changes
+func (t *Tracker) Start(ctx context.Context, catalog []models.EnhancedModel) {
+	t.ObservePrices(ctx, catalog)
+}
+
+// ObservePrices records price changes in catalog and notifies about them
+func (t *Tracker) ObservePrices(ctx context.Context, catalog []models.EnhancedModel) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changes, err := t.detect(ctx, catalog)
+	if err != nil {
+		log.Printf("[PRICING] Price change detection failed: %v", err)
+		return
+	}
+	for _, change := range changes {
+		t.report(change)
+	}
+	if len(changes) > 0 {
+		t.notifyTenants(ctx, changes)
+	}
+	if err := t.refresh(ctx); err != nil {
+		log.Printf("[PRICING] Failed to load recent price changes: %v", err)
+	}
+}
+
+// detect compares list prices with the recorded baseline in one transaction.
+// The baseline moves only when a change is recorded, so small drifts add up
+// until they cross the threshold.
+func (t *Tracker) detect(ctx context.Context, catalog []models.EnhancedModel) ([]Change, error) {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin pricing transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the baseline so replicas observing the same fusion record a change once
+	rows, err := tx.QueryContext(ctx, `SELECT model_id, cost_in_per_1k, cost_out_per_1k FROM model_prices FOR UPDATE`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recorded prices: %w", err)
+	}
+	baseline := make(map[string]models.TextPricing)
+	for rows.Next() {
+		var modelID string
+		var in, out sql.NullFloat64
+		if err := rows.Scan(&modelID, &in, &out); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan recorded price: %w", err)
+		}
+		baseline[modelID] = models.TextPricing{CostInPer1K: nullable(in), CostOutPer1K: nullable(out)}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load recorded prices: %w", err)
+	}
+
+	var changes []Change
+	for _, model := range catalog {
+		price := model.Pricing.Text
+		if price.CostInPer1K == nil && price.CostOutPer1K == nil {
+			continue
+		}
+
+		recorded, ok := baseline[model.ID]
+		if !ok {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO model_prices (model_id, cost_in_per_1k, cost_out_per_1k)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (model_id) DO NOTHING`,
+				model.ID, price.CostInPer1K, price.CostOutPer1K); err != nil {
+				return nil, fmt.Errorf("failed to record price for %s: %w", model.ID, err)
+			}
+			continue
+		}
+
+		percent, changed := relativeChange(recorded, price, t.cfg.Threshold)
+		if !changed {
+			if (recorded.CostInPer1K == nil && price.CostInPer1K != nil) || (recorded.CostOutPer1K == nil && price.CostOutPer1K != nil) {
+				// A price the provider did not publish before becomes the baseline
+				if _, err := tx.ExecContext(ctx, `
+					UPDATE model_prices
+					SET cost_in_per_1k = COALESCE(cost_in_per_1k, $2), cost_out_per_1k = COALESCE(cost_out_per_1k, $3)
+					WHERE model_id = $1`,
+					model.ID, price.CostInPer1K, price.CostOutPer1K); err != nil {
+					return nil, fmt.Errorf("failed to update recorded price for %s: %w", model.ID, err)
+				}
+			}
+			continue
+		}
+		change := Change{
+			ModelID:          model.ID,
+			Provider:         model.Provider,
+			PreviousInPer1K:  recorded.CostInPer1K,
+			PreviousOutPer1K: recorded.CostOutPer1K,
+			CostInPer1K:      price.CostInPer1K,
+			CostOutPer1K:     price.CostOutPer1K,
+			ChangePercent:    percent,
+		}
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO pricing_history (model_id, provider, previous_cost_in_per_1k, previous_cost_out_per_1k,
+			                             cost_in_per_1k, cost_out_per_1k, change_percent)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, detected_at`,
+			change.ModelID, change.Provider, change.PreviousInPer1K, change.PreviousOutPer1K,
+			change.CostInPer1K, change.CostOutPer1K, change.ChangePercent,
+		).Scan(&change.ID, &change.DetectedAt); err != nil {
+			return nil, fmt.Errorf("failed to record price change for %s: %w", model.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE model_prices
+			SET cost_in_per_1k = $2, cost_out_per_1k = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE model_id = $1`,
+			model.ID, price.CostInPer1K, price.CostOutPer1K); err != nil {
+			return nil, fmt.Errorf("failed to update recorded price for %s: %w", model.ID, err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit price changes: %w", err)
+	}
+	return changes, nil
+}
+
+// relativeChange returns the larger relative change of the input and output
+// price, in percent, and whether it reaches threshold. A price appearing or
+// disappearing is missing data, not a change.
+func relativeChange(recorded, current models.TextPricing, threshold float64) (float64, bool) {
+	largest := 0.0
+	for _, pair := range [][2]*float64{
+		{recorded.CostInPer1K, current.CostInPer1K},
+		{recorded.CostOutPer1K, current.CostOutPer1K},
+	} {
+		before, after := pair[0], pair[1]
+		if before == nil || after == nil || *before == *after {
+			continue
+		}
+		rel := 1.0
+		if *before != 0 {
+			rel = (*after - *before) / *before
+		}
+		if math.Abs(rel) > math.Abs(largest) {
+			largest = rel
+		}
+	}
+	return math.Round(largest*10000) / 100, largest != 0 && math.Abs(largest) >= threshold
+}
+
+func (t *Tracker) report(change Change) {
+	log.Printf("[PRICING] %s list price changed by %+.1f%%", change.ModelID, change.ChangePercent)
+
+	t.alerts.Notify(alerts.Event{
+		Type:     alerts.EventPricingChanged,
+		Severity: alerts.SeverityInfo,
+		Source:   "pricing",
+		Title:    fmt.Sprintf("%s list price changed by %+.1f%%", change.ModelID, change.ChangePercent),
+		Message:  "The provider changed the model's price; routing costs and budgets now use the new price.",
+		Fields: map[string]string{
+			"model":          change.ModelID,
+			"provider":       change.Provider,
+			"input_per_1k":   formatTransition(change.PreviousInPer1K, change.CostInPer1K),
+			"output_per_1k":  formatTransition(change.PreviousOutPer1K, change.CostOutPer1K),
+			"change_percent": strconv.FormatFloat(change.ChangePercent, 'f', 2, 64),
+		},
+		Key: "pricing|" + change.ModelID,
+	})
+}
+
+// refresh shows changes within the recent window on the served catalog
+func (t *Tracker) refresh(ctx context.Context) error {
+	if t.catalog == nil {
+		return nil
+	}
+	recent, err := t.Recent(ctx, "", time.Now().Add(-t.cfg.RecentWindow), 0)
+	if err != nil {
+		return err
+	}
+	changes := make(map[string]models.PriceChange)
+	for _, c := range recent {
+		// Newest first, so the first change seen for a model is its latest
+		if _, ok := changes[c.ModelID]; !ok {
+			changes[c.ModelID] = models.PriceChange{
+				ChangedAt: c.DetectedAt,
+				Previous:  models.TextPricing{CostInPer1K: c.PreviousInPer1K, CostOutPer1K: c.PreviousOutPer1K},
+			}
+		}
+	}
+	t.catalog.SetPriceChanges(changes)
+	return nil
+}
+
+// Recent lists changes detected since since, newest first, optionally for one
+// model; limit 0 lists them all
+func (t *Tracker) Recent(ctx context.Context, modelID string, since time.Time, limit int) ([]Change, error) {
+	query := `
+		SELECT id, model_id, provider, previous_cost_in_per_1k, previous_cost_out_per_1k,
+		       cost_in_per_1k, cost_out_per_1k, change_percent, '', detected_at
+		FROM pricing_history
+		WHERE detected_at >= $1 AND ($2::text = '' OR model_id = $2)
+		ORDER BY detected_at DESC`
+	args := []interface{}{since, modelID}
+	if limit > 0 {
+		query += ` LIMIT $3`
+		args = append(args, limit)
+	}
+	return t.query(ctx, query, args...)
+}
+
+// ForTenant lists changes since since to models the tenant pinned or used in
+// the usage window, newest first
+func (t *Tracker) ForTenant(ctx context.Context, userID string, since time.Time, limit int) ([]Change, error) {
+	return t.query(ctx, `
+		SELECT h.id, h.model_id, h.provider, h.previous_cost_in_per_1k, h.previous_cost_out_per_1k,
+		       h.cost_in_per_1k, h.cost_out_per_1k, h.change_percent,
+		       CASE WHEN EXISTS (SELECT 1 FROM catalog_overlays o WHERE o.user_id = $1 AND o.include ? h.model_id)
+		            THEN $4::text ELSE $5::text END,
+		       h.detected_at
+		FROM pricing_history h
+		WHERE h.detected_at >= $2 AND (
+			EXISTS (SELECT 1 FROM catalog_overlays o WHERE o.user_id = $1 AND o.include ? h.model_id)
+			OR EXISTS (SELECT 1 FROM api_usage u
+			           WHERE u.user_id = $1 AND u.recommended_model = h.model_id AND u.date_bucket >= $6::date)
+		)
+		ORDER BY h.detected_at DESC
+		LIMIT $3`,
+		userID, since, limit, ReasonPinned, ReasonUsed, time.Now().Add(-t.cfg.UsageWindow))
+}
+
+func (t *Tracker) query(ctx context.Context, query string, args ...interface{}) ([]Change, error) {
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []Change{}
+	for rows.Next() {
+		var c Change
+		var prevIn, prevOut, in, out sql.NullFloat64
+		if err := rows.Scan(&c.ID, &c.ModelID, &c.Provider, &prevIn, &prevOut, &in, &out,
+			&c.ChangePercent, &c.Reason, &c.DetectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan price change: %w", err)
+		}
+		c.PreviousInPer1K, c.PreviousOutPer1K = nullable(prevIn), nullable(prevOut)
+		c.CostInPer1K, c.CostOutPer1K = nullable(in), nullable(out)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// affectedTenants returns, per tenant, why it depends on modelID: the model
+// is in its overlay include list or took a large share of its recent requests
+func (t *Tracker) affectedTenants(ctx context.Context, modelID string) (map[string]string, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT user_id::text, $2::text FROM catalog_overlays WHERE include ? $1
+		UNION ALL
+		SELECT user_id::text, $3::text FROM api_usage
+		WHERE date_bucket >= $4::date
+		GROUP BY user_id
+		HAVING COUNT(*) FILTER (WHERE recommended_model = $1) >= $5
+		   AND COUNT(*) FILTER (WHERE recommended_model = $1)::float / COUNT(*) >= $6`,
+		modelID, ReasonPinned, ReasonHeavyUse, time.Now().Add(-t.cfg.UsageWindow), t.cfg.MinRequests, t.cfg.HeavyUseShare)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tenants using %s: %w", modelID, err)
+	}
+	defer rows.Close()
+
+	affected := make(map[string]string)
+	for rows.Next() {
+		var userID, reason string
+		if err := rows.Scan(&userID, &reason); err != nil {
+			return nil, fmt.Errorf("failed to scan affected tenant: %w", err)
+		}
+		// Pinned is listed first and is the stronger reason
+		if _, ok := affected[userID]; !ok {
+			affected[userID] = reason
+		}
+	}
+	return affected, rows.Err()
+}
+
+// notifyTenants delivers each affected tenant one webhook listing the changes
+// that concern it
+func (t *Tracker) notifyTenants(ctx context.Context, changes []Change) {
+	byTenant := make(map[string][]Change)
+	for _, change := range changes {
+		affected, err := t.affectedTenants(ctx, change.ModelID)
+		if err != nil {
+			log.Printf("[PRICING] %v", err)
+			continue
+		}
+		for userID, reason := range affected {
+			c := change
+			c.Reason = reason
+			byTenant[userID] = append(byTenant[userID], c)
+		}
+	}
+	for userID, tenantChanges := range byTenant {
+		t.hooks.Deliver(ctx, userID, tenantChanges)
+	}
+}
+
+func nullable(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
+
+func formatTransition(before, after *float64) string {
+	format := func(v *float64) string {
+		if v == nil {
+			return "n/a"
+		}
+		return "$" + strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	return format(before) + " -> " + format(after)
+}
@@ -0,0 +1,157 @@
+package pricing
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// EventPriceChanged is the event type of pricing webhook deliveries
+const EventPriceChanged = "model.price_changed"
+
+// deliveryTimeout bounds one webhook delivery
+const deliveryTimeout = 10 * time.Second
+
+// Webhook is where a tenant receives price changes for models it depends on
+type Webhook struct {
+	URL             string     `json:"url"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Delivery is the body POSTed to a tenant's webhook
+type Delivery struct {
+	Event   string    `json:"event"`
+	Changes []Change  `json:"changes"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Webhooks stores one pricing webhook per tenant and delivers to it
+type Webhooks struct {
+	db     *sql.DB
+	client *http.Client
+}
+
+func NewWebhooks(db *sql.DB) *Webhooks {
+	return &Webhooks{db: db, client: transport.Client("pricing-webhooks")}
+}
+
+// ValidateURL accepts only absolute https URLs
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("url must be an absolute https URL")
+	}
+	return nil
+}
+
+// Get returns the tenant's webhook, or nil when it has none
+func (w *Webhooks) Get(ctx context.Context, userID string) (*Webhook, error) {
+	var hook Webhook
+	var delivered sql.NullTime
+	err := w.db.QueryRowContext(ctx, `
+		SELECT url, last_delivered_at, last_error, updated_at
+		FROM pricing_webhooks WHERE user_id = $1`, userID,
+	).Scan(&hook.URL, &delivered, &hook.LastError, &hook.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing webhook: %w", err)
+	}
+	if delivered.Valid {
+		hook.LastDeliveredAt = &delivered.Time
+	}
+	return &hook, nil
+}
+
+// Put registers or replaces the tenant's webhook
+func (w *Webhooks) Put(ctx context.Context, userID, rawURL string) error {
+	if err := ValidateURL(rawURL); err != nil {
+		return err
+	}
+	_, err := w.db.ExecContext(ctx, `
+		INSERT INTO pricing_webhooks (user_id, url)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			url = EXCLUDED.url,
+			last_error = '',
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to store pricing webhook: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the tenant's webhook
+func (w *Webhooks) Delete(ctx context.Context, userID string) error {
+	if _, err := w.db.ExecContext(ctx, `DELETE FROM pricing_webhooks WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete pricing webhook: %w", err)
+	}
+	return nil
+}
+
+// Deliver posts changes to the tenant's webhook, if it registered one, and
+// records the outcome
+func (w *Webhooks) Deliver(ctx context.Context, userID string, changes []Change) {
+	hook, err := w.Get(ctx, userID)
+	if err != nil {
+		log.Printf("[PRICING] %v", err)
+		return
+	}
+	if hook == nil {
+		return
+	}
+
+	sendErr := w.send(ctx, hook.URL, Delivery{Event: EventPriceChanged, Changes: changes, SentAt: time.Now()})
+	lastError := ""
+	if sendErr != nil {
+		lastError = sendErr.Error()
+		log.Printf("[PRICING] Webhook delivery to tenant %s failed: %v", userID, sendErr)
+	}
+	if _, err := w.db.ExecContext(ctx, `
+		UPDATE pricing_webhooks
+		SET last_delivered_at = CASE WHEN $2 = '' THEN CURRENT_TIMESTAMP ELSE last_delivered_at END,
+		    last_error = $2
+		WHERE user_id = $1`,
+		userID, lastError); err != nil {
+		log.Printf("[PRICING] Failed to record webhook delivery: %v", err)
+	}
+}
+
+func (w *Webhooks) send(ctx context.Context, webhookURL string, delivery Delivery) error {
+	body, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Router-Event", delivery.Event)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
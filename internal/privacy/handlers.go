@@ -0,0 +1,89 @@
+package privacy
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes tenant logging policy management and data deletion
+type Handlers struct {
+	policies  *PolicyStore
+	retention *Retention
+}
+
+type PutPolicyRequest struct {
+	Mode          string `json:"mode" binding:"required"`
+	RetentionDays int    `json:"retention_days"`
+}
+
+func NewHandlers(policies *PolicyStore, retention *Retention) *Handlers {
+	return &Handlers{policies: policies, retention: retention}
+}
+
+// GetPolicy returns the caller's effective prompt logging policy
+func (h *Handlers) GetPolicy(c *gin.Context) {
+	policy, err := h.policies.Get(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load logging policy",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"policy":  policy,
+	})
+}
+
+// PutPolicy stores the caller's prompt logging policy
+func (h *Handlers) PutPolicy(c *gin.Context) {
+	var req PutPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.RetentionDays == 0 {
+		req.RetentionDays = DefaultPolicy().RetentionDays
+	}
+
+	if err := h.policies.Put(c.Request.Context(), c.GetString("user_id"), req.Mode, req.RetentionDays); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       "Invalid logging policy",
+			"details":     err.Error(),
+			"valid_modes": []string{ModeFull, ModeHashed, ModeNone},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// PurgeData deletes all prompt data retained for the caller (GDPR erasure)
+func (h *Handlers) PurgeData(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	purged, err := h.retention.PurgeTenant(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[PRIVACY] Purge failed for %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to purge data",
+			"details": err.Error(),
+			"purged":  purged,
+		})
+		return
+	}
+
+	log.Printf("[PRIVACY] Purged prompt data for %s: %v", userID, purged)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"purged":  purged,
+	})
+}
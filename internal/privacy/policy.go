@@ -0,0 +1,152 @@
+package privacy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Prompt logging modes
+const (
+	ModeFull   = "full"   // store prompts verbatim
+	ModeHashed = "hashed" // store a keyed hash so identical prompts can still be correlated
+	ModeNone   = "none"   // never store prompt text
+)
+
+// IsValidMode reports whether mode is a known logging mode
+func IsValidMode(mode string) bool {
+	return mode == ModeFull || mode == ModeHashed || mode == ModeNone
+}
+
+// maxRetentionDays caps tenant-configured retention
+const maxRetentionDays = 3650
+
+// Policy controls how a tenant's prompts are retained by every subsystem that logs them
+type Policy struct {
+	UserID        string `json:"user_id,omitempty"`
+	Mode          string `json:"mode"`
+	RetentionDays int    `json:"retention_days"`
+	Source        string `json:"source"` // "default" or "tenant"
+}
+
+// DefaultPolicy is applied to tenants without a stored policy
+func DefaultPolicy() Policy {
+	policy := Policy{
+		Mode:          ModeFull,
+		RetentionDays: 90,
+		Source:        "default",
+	}
+
+	// PROMPT_LOGGING sets the platform default mode (full, hashed or none)
+	if mode := os.Getenv("PROMPT_LOGGING"); IsValidMode(mode) {
+		policy.Mode = mode
+	}
+	// PROMPT_RETENTION_DAYS sets the platform default retention
+	if days, err := strconv.Atoi(os.Getenv("PROMPT_RETENTION_DAYS")); err == nil && days > 0 {
+		policy.RetentionDays = days
+	}
+
+	return policy
+}
+
+// PolicyStore persists per-tenant logging policies
+type PolicyStore struct {
+	db      *sql.DB
+	hashKey []byte
+}
+
+func NewPolicyStore(db *sql.DB) *PolicyStore {
+	return &PolicyStore{
+		db:      db,
+		hashKey: []byte(os.Getenv("PROMPT_HASH_KEY")),
+	}
+}
+
+// Get returns the tenant's policy, falling back to the default
+func (s *PolicyStore) Get(ctx context.Context, userID string) (Policy, error) {
+	policy := DefaultPolicy()
+	if userID == "" {
+		return policy, nil
+	}
+
+	var mode string
+	var retention int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT mode, retention_days FROM logging_policies WHERE user_id = $1`, userID,
+	).Scan(&mode, &retention)
+	if err == sql.ErrNoRows {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to load logging policy: %w", err)
+	}
+
+	policy.UserID = userID
+	policy.Mode = mode
+	policy.RetentionDays = retention
+	policy.Source = "tenant"
+	return policy, nil
+}
+
+// Put stores the tenant's logging mode and retention
+func (s *PolicyStore) Put(ctx context.Context, userID, mode string, retentionDays int) error {
+	if !IsValidMode(mode) {
+		return fmt.Errorf("invalid logging mode: %s", mode)
+	}
+	if retentionDays <= 0 || retentionDays > maxRetentionDays {
+		return fmt.Errorf("retention_days must be between 1 and %d", maxRetentionDays)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO logging_policies (user_id, mode, retention_days)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			mode = EXCLUDED.mode,
+			retention_days = EXCLUDED.retention_days,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, mode, retentionDays)
+	if err != nil {
+		return fmt.Errorf("failed to store logging policy: %w", err)
+	}
+	return nil
+}
+
+// ScrubPrompt returns what may be stored for a tenant's prompt under its policy
+// and whether that is the prompt verbatim. If the policy cannot be loaded the
+// prompt is dropped rather than stored in full.
+func (s *PolicyStore) ScrubPrompt(ctx context.Context, userID, prompt string) (string, bool) {
+	if prompt == "" {
+		return "", true
+	}
+
+	policy, err := s.Get(ctx, userID)
+	if err != nil {
+		log.Printf("[PRIVACY] %v; prompt not retained", err)
+		return "", false
+	}
+
+	switch policy.Mode {
+	case ModeFull:
+		return prompt, true
+	case ModeHashed:
+		return s.hash(prompt), false
+	}
+	return "", false
+}
+
+// hash is keyed with PROMPT_HASH_KEY when set so short prompts cannot be brute-forced
+func (s *PolicyStore) hash(prompt string) string {
+	if len(s.hashKey) > 0 {
+		mac := hmac.New(sha256.New, s.hashKey)
+		mac.Write([]byte(prompt))
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256([]byte(prompt))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,96 @@
+package privacy
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// PromptStore is implemented by every subsystem that retains tenant prompts
+// (audit log, request history, shadow routing) so that retention and GDPR
+// deletion are enforced in one place.
+type PromptStore interface {
+	// Name identifies the subsystem in purge reports
+	Name() string
+	// ExpirePrompts removes prompt text older than each tenant's retention,
+	// using defaultDays for tenants without a policy
+	ExpirePrompts(ctx context.Context, defaultDays int) (int64, error)
+	// PurgeTenant removes all prompt data retained for a tenant
+	PurgeTenant(ctx context.Context, userID string) (int64, error)
+}
+
+// Retention enforces retention TTLs and deletion requests across prompt stores
+type Retention struct {
+	stores []PromptStore
+	alerts *alerts.Manager
+}
+
+func NewRetention(stores ...PromptStore) *Retention {
+	return &Retention{stores: stores}
+}
+
+// SetAlerts reports failed retention passes to operators
+func (r *Retention) SetAlerts(manager *alerts.Manager) {
+	r.alerts = manager
+}
+
+// Register adds a subsystem that retains prompts
+func (r *Retention) Register(store PromptStore) {
+	r.stores = append(r.stores, store)
+}
+
+// Start expires prompts past their TTL on the given interval until ctx is cancelled
+func (r *Retention) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			r.Expire(ctx)
+			health.Beat(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Expire runs one retention pass over every store
+func (r *Retention) Expire(ctx context.Context) {
+	defaultDays := DefaultPolicy().RetentionDays
+	for _, store := range r.stores {
+		n, err := store.ExpirePrompts(ctx, defaultDays)
+		if err != nil {
+			log.Printf("[PRIVACY] Retention pass failed for %s: %v", store.Name(), err)
+			r.alerts.Notify(alerts.Event{
+				Type:     alerts.EventJobFailed,
+				Severity: alerts.SeverityCritical,
+				Source:   "retention:" + store.Name(),
+				Title:    "Prompt retention pass failed",
+				Message:  err.Error(),
+			})
+			continue
+		}
+		if n > 0 {
+			log.Printf("[PRIVACY] Expired %d prompts from %s", n, store.Name())
+		}
+	}
+}
+
+// PurgeTenant deletes a tenant's prompt data from every store and reports counts
+func (r *Retention) PurgeTenant(ctx context.Context, userID string) (map[string]int64, error) {
+	purged := make(map[string]int64, len(r.stores))
+	for _, store := range r.stores {
+		n, err := store.PurgeTenant(ctx, userID)
+		if err != nil {
+			return purged, err
+		}
+		purged[store.Name()] = n
+	}
+	return purged, nil
+}
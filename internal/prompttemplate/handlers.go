@@ -0,0 +1,133 @@
+package prompttemplate
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// ModelLookup finds a catalog model by ID
+type ModelLookup func(id string) (models.EnhancedModel, bool)
+
+// Handlers exposes prompt template overrides on the dashboard
+type Handlers struct {
+	store  *Store
+	lookup ModelLookup
+}
+
+func NewHandlers(store *Store, lookup ModelLookup) *Handlers {
+	return &Handlers{store: store, lookup: lookup}
+}
+
+// List returns the caller's overrides
+func (h *Handlers) List(c *gin.Context) {
+	overrides, err := h.store.List(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load prompt templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    overrides,
+	})
+}
+
+// Get returns a model's catalog template, the caller's override and which of
+// them generation applies
+func (h *Handlers) Get(c *gin.Context) {
+	model, ok := h.lookup(c.Param("model_id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Model not found",
+		})
+		return
+	}
+	override, err := h.store.TemplateFor(c.Request.Context(), c.GetString("user_id"), model.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load prompt template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	effective := model.PromptTemplate
+	if override != nil {
+		effective = override
+	}
+	if effective != nil && effective.Disabled {
+		effective = nil
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"model_id":  model.ID,
+			"catalog":   model.PromptTemplate,
+			"override":  override,
+			"effective": effective,
+		},
+	})
+}
+
+// Put replaces the caller's override for a model. {"disabled": true} sends
+// requests to the model without the catalog template.
+func (h *Handlers) Put(c *gin.Context) {
+	model, ok := h.lookup(c.Param("model_id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Model not found",
+		})
+		return
+	}
+
+	var template models.PromptTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := template.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid prompt template",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := h.store.Put(c.Request.Context(), c.GetString("user_id"), model.ID, template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save prompt template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Prompt template saved",
+	})
+}
+
+// Delete removes the caller's override, restoring the catalog template
+func (h *Handlers) Delete(c *gin.Context) {
+	if err := h.store.Delete(c.Request.Context(), c.GetString("user_id"), c.Param("model_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete prompt template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Prompt template removed",
+	})
+}
@@ -0,0 +1,129 @@
+package prompttemplate
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// cacheTTL bounds how long a replica serves a tenant's override before
+// re-reading it, so edits made through another replica take effect
+const cacheTTL = 30 * time.Second
+
+// Override is a tenant's prompt template for one model, used in place of the
+// catalog's
+type Override struct {
+	ModelID   string                `json:"model_id"`
+	Template  models.PromptTemplate `json:"template"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// cached is a tenant's overrides by model
+type cached struct {
+	templates map[string]*models.PromptTemplate
+	fetched   time.Time
+}
+
+// Store persists tenants' prompt template overrides
+type Store struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, cache: make(map[string]cached)}
+}
+
+// List returns the tenant's overrides
+func (s *Store) List(ctx context.Context, userID string) ([]Override, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT model_id, template, updated_at FROM prompt_templates
+		WHERE user_id = $1 ORDER BY model_id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []Override{}
+	for rows.Next() {
+		var o Override
+		var template []byte
+		if err := rows.Scan(&o.ModelID, &template, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		if err := json.Unmarshal(template, &o.Template); err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template for %s: %w", o.ModelID, err)
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// Put replaces the tenant's override for a model
+func (s *Store) Put(ctx context.Context, userID, modelID string, template models.PromptTemplate) error {
+	if err := template.Validate(); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(template)
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO prompt_templates (user_id, model_id, template)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, model_id) DO UPDATE SET
+			template = EXCLUDED.template,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, modelID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store prompt template: %w", err)
+	}
+	s.invalidate(userID)
+	return nil
+}
+
+// Delete removes the tenant's override, restoring the catalog template
+func (s *Store) Delete(ctx context.Context, userID, modelID string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM prompt_templates WHERE user_id = $1 AND model_id = $2`, userID, modelID); err != nil {
+		return fmt.Errorf("failed to delete prompt template: %w", err)
+	}
+	s.invalidate(userID)
+	return nil
+}
+
+// TemplateFor returns the tenant's override for the model, or nil when it has
+// none. A tenant's overrides are cached together for cacheTTL.
+func (s *Store) TemplateFor(ctx context.Context, userID, modelID string) (*models.PromptTemplate, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && time.Since(entry.fetched) < cacheTTL {
+		return entry.templates[modelID], nil
+	}
+
+	overrides, err := s.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	entry = cached{templates: make(map[string]*models.PromptTemplate, len(overrides)), fetched: time.Now()}
+	for i := range overrides {
+		entry.templates[overrides[i].ModelID] = &overrides[i].Template
+	}
+
+	s.mu.Lock()
+	s.cache[userID] = entry
+	s.mu.Unlock()
+	return entry.templates[modelID], nil
+}
+
+func (s *Store) invalidate(userID string) {
+	s.mu.Lock()
+	delete(s.cache, userID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes tenant provider key management for the dashboard
+type Handlers struct {
+	keyStore *KeyStore
+}
+
+type PutKeyRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	APIKey   string `json:"api_key" binding:"required"`
+	Label    string `json:"label"`
+}
+
+type UpdateKeyRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+func NewHandlers(keyStore *KeyStore) *Handlers {
+	return &Handlers{keyStore: keyStore}
+}
+
+// ListKeys returns the caller's stored provider keys (metadata only)
+func (h *Handlers) ListKeys(c *gin.Context) {
+	keys, err := h.keyStore.List(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list provider keys",
+		})
+		return
+	}
+
+	supported := SupportedProviders()
+	sort.Strings(supported)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":             true,
+		"provider_keys":       keys,
+		"supported_providers": supported,
+	})
+}
+
+// PutKey stores or replaces the caller's key for a provider
+func (h *Handlers) PutKey(c *gin.Context) {
+	var req PutKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !IsSupported(req.Provider) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":               "Unsupported provider",
+			"provided":            req.Provider,
+			"supported_providers": SupportedProviders(),
+		})
+		return
+	}
+
+	key, err := h.keyStore.Put(c.Request.Context(), c.GetString("user_id"), req.Provider, req.Label, req.APIKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store provider key",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":      true,
+		"provider_key": key,
+	})
+}
+
+// UpdateKey enables or disables a stored key
+func (h *Handlers) UpdateKey(c *gin.Context) {
+	var req UpdateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := h.keyStore.SetActive(c.Request.Context(), c.GetString("user_id"), c.Param("id"), *req.IsActive)
+	if err == ErrKeyNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Provider key not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update provider key",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// DeleteKey removes a stored key
+func (h *Handlers) DeleteKey(c *gin.Context) {
+	err := h.keyStore.Delete(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err == ErrKeyNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Provider key not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete provider key",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,162 @@
+package providers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Askeban/llm-router-go/internal/vault"
+)
+
+// ErrKeyNotFound is returned when a tenant has no stored key for a provider
+var ErrKeyNotFound = errors.New("provider key not found")
+
+// ProviderKey is the metadata of a tenant-supplied provider API key.
+// The secret itself is never returned once stored.
+type ProviderKey struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Label     string    `json:"label"`
+	KeyHint   string    `json:"key_hint"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KeyStore persists per-tenant provider API keys sealed by the vault
+type KeyStore struct {
+	db    *sql.DB
+	vault *vault.Vault
+}
+
+func NewKeyStore(db *sql.DB, v *vault.Vault) *KeyStore {
+	return &KeyStore{db: db, vault: v}
+}
+
+// Put stores (or replaces) the tenant's key for a provider
+func (s *KeyStore) Put(ctx context.Context, userID, provider, label, apiKey string) (*ProviderKey, error) {
+	provider = NormalizeProvider(provider)
+	if !IsSupported(provider) {
+		return nil, fmt.Errorf("unsupported provider: %s", provider)
+	}
+
+	sealed, err := s.vault.Seal([]byte(apiKey))
+	if err != nil {
+		return nil, err
+	}
+
+	key := &ProviderKey{
+		ID:       uuid.New().String(),
+		UserID:   userID,
+		Provider: provider,
+		Label:    label,
+		KeyHint:  keyHint(apiKey),
+		IsActive: true,
+	}
+
+	query := `
+		INSERT INTO provider_keys (id, user_id, provider, label, sealed_key, key_hint, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+		ON CONFLICT (user_id, provider) DO UPDATE SET
+			label = EXCLUDED.label,
+			sealed_key = EXCLUDED.sealed_key,
+			key_hint = EXCLUDED.key_hint,
+			is_active = TRUE,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at`
+
+	err = s.db.QueryRowContext(ctx, query,
+		key.ID, key.UserID, key.Provider, key.Label, sealed, key.KeyHint,
+	).Scan(&key.ID, &key.CreatedAt, &key.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store provider key: %w", err)
+	}
+
+	return key, nil
+}
+
+// List returns the metadata of all keys stored by a tenant
+func (s *KeyStore) List(ctx context.Context, userID string) ([]ProviderKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, provider, label, key_hint, is_active, created_at, updated_at
+		FROM provider_keys
+		WHERE user_id = $1
+		ORDER BY provider`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []ProviderKey{}
+	for rows.Next() {
+		var k ProviderKey
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Provider, &k.Label, &k.KeyHint,
+			&k.IsActive, &k.CreatedAt, &k.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan provider key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// SetActive enables or disables a stored key without deleting it
+func (s *KeyStore) SetActive(ctx context.Context, userID, id string, active bool) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE provider_keys SET is_active = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND user_id = $3`, active, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update provider key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// Delete removes a stored key
+func (s *KeyStore) Delete(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM provider_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete provider key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// Reveal returns the decrypted active key of a tenant for a provider
+func (s *KeyStore) Reveal(ctx context.Context, userID, provider string) (string, error) {
+	var sealed []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT sealed_key FROM provider_keys
+		WHERE user_id = $1 AND provider = $2 AND is_active = TRUE`,
+		userID, NormalizeProvider(provider)).Scan(&sealed)
+	if err == sql.ErrNoRows {
+		return "", ErrKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load provider key: %w", err)
+	}
+
+	plaintext, err := s.vault.Open(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// keyHint keeps the last four characters so users can tell keys apart
+func keyHint(apiKey string) string {
+	apiKey = strings.TrimSpace(apiKey)
+	if len(apiKey) <= 4 {
+		return "****"
+	}
+	return "..." + apiKey[len(apiKey)-4:]
+}
@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// Key sources reported alongside a resolved key
+const (
+	KeySourceTenant   = "tenant"
+	KeySourcePlatform = "platform"
+	KeySourceNone     = "none" // A self-hosted backend called without a key
+)
+
+// platformKeyEnv maps each supported provider to the env var holding the platform key
+var platformKeyEnv = map[string]string{
+	"openai":     "OPENAI_API_KEY",
+	"anthropic":  "ANTHROPIC_API_KEY",
+	"google":     "GOOGLE_API_KEY",
+	"mistral":    "MISTRAL_API_KEY",
+	"openrouter": "OPENROUTER_API_KEY",
+	"xai":        "XAI_API_KEY",
+	"deepseek":   "DEEPSEEK_API_KEY",
+	"cohere":     "COHERE_API_KEY",
+	"ollama":     "OLLAMA_API_KEY",
+	"vllm":       "VLLM_API_KEY",
+}
+
+// selfHosted are backends run by the operator or tenant rather than a vendor.
+// They usually need no key, and load models into memory on demand.
+var selfHosted = map[string]bool{
+	"ollama": true,
+	"vllm":   true,
+}
+
+// ResolvedKey is the credential chosen for a provider call
+type ResolvedKey struct {
+	Provider string `json:"provider"`
+	APIKey   string `json:"-"`
+	Source   string `json:"source"`
+}
+
+// Registry selects which API key to use for each provider call.
+// Tenant (BYOK) keys always take precedence over platform keys.
+type Registry struct {
+	keyStore     *KeyStore
+	platformKeys map[string]string
+}
+
+// NewRegistry builds a registry with platform keys read from the environment.
+// keyStore may be nil, in which case only platform keys are used.
+func NewRegistry(keyStore *KeyStore) *Registry {
+	platformKeys := make(map[string]string)
+	for provider, env := range platformKeyEnv {
+		if key := os.Getenv(env); key != "" {
+			platformKeys[provider] = key
+		}
+	}
+
+	return &Registry{
+		keyStore:     keyStore,
+		platformKeys: platformKeys,
+	}
+}
+
+// HTTPClient returns the pooled, retrying client to use for calls to provider
+func (r *Registry) HTTPClient(provider string) *http.Client {
+	return transport.Client(NormalizeProvider(provider))
+}
+
+// ResolveAPIKey returns the key to use when calling provider on behalf of userID
+func (r *Registry) ResolveAPIKey(ctx context.Context, userID, provider string) (ResolvedKey, error) {
+	provider = NormalizeProvider(provider)
+
+	if r.keyStore != nil && userID != "" {
+		key, err := r.keyStore.Reveal(ctx, userID, provider)
+		if err == nil {
+			return ResolvedKey{Provider: provider, APIKey: key, Source: KeySourceTenant}, nil
+		}
+		if err != ErrKeyNotFound {
+			// Never silently bill the platform when a tenant key exists but can't be read
+			return ResolvedKey{}, fmt.Errorf("resolve tenant key for %s: %w", provider, err)
+		}
+	}
+
+	if key, ok := r.platformKeys[provider]; ok {
+		return ResolvedKey{Provider: provider, APIKey: key, Source: KeySourcePlatform}, nil
+	}
+	if selfHosted[provider] {
+		return ResolvedKey{Provider: provider, Source: KeySourceNone}, nil
+	}
+
+	return ResolvedKey{}, fmt.Errorf("no API key configured for provider %s", provider)
+}
+
+// HasTenantKeys reports whether tenant key storage is available
+func (r *Registry) HasTenantKeys() bool {
+	return r.keyStore != nil
+}
+
+// PlatformProviders lists providers with a platform key configured
+func (r *Registry) PlatformProviders() []string {
+	providers := make([]string, 0, len(r.platformKeys))
+	for provider := range r.platformKeys {
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// NormalizeProvider lowercases a provider name and maps known aliases
+func NormalizeProvider(provider string) string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	switch provider {
+	case "mistral-ai", "mistralai":
+		return "mistral"
+	case "gemini", "google-ai":
+		return "google"
+	}
+	return provider
+}
+
+// IsSupported reports whether provider can be used for generation
+func IsSupported(provider string) bool {
+	_, ok := platformKeyEnv[NormalizeProvider(provider)]
+	return ok
+}
+
+// IsSelfHosted reports whether provider is a self-hosted backend such as Ollama or vLLM
+func IsSelfHosted(provider string) bool {
+	return selfHosted[NormalizeProvider(provider)]
+}
+
+// SupportedProviders lists all providers that accept BYOK keys
+func SupportedProviders() []string {
+	providers := make([]string, 0, len(platformKeyEnv))
+	for provider := range platformKeyEnv {
+		providers = append(providers, provider)
+	}
+	return providers
+}
@@ -0,0 +1,43 @@
+package quality
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes judged quality to operators
+type Handlers struct {
+	judge *Judge
+}
+
+func NewHandlers(judge *Judge) *Handlers {
+	return &Handlers{judge: judge}
+}
+
+// Scores returns judged quality by model and category with this replica's
+// sampling counters
+func (h *Handlers) Scores(c *gin.Context) {
+	cfg := h.judge.Config()
+	modelID := c.Query("model_id")
+
+	scores := []Score{}
+	for _, s := range h.judge.Scores() {
+		if modelID == "" || s.ModelID == modelID {
+			scores = append(scores, s)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"enabled":     cfg.Enabled(),
+			"judge_model": cfg.JudgeModel,
+			"sample_rate": cfg.SampleRate,
+			"min_samples": cfg.MinSamples,
+			"window":      cfg.Window.String(),
+			"scores":      scores,
+			"stats":       h.judge.Stats(),
+		},
+	})
+}
@@ -0,0 +1,385 @@
+package quality
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/generate"
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/ingest"
+	"github.com/Askeban/llm-router-go/internal/privacy"
+)
+
+// SourceJudge labels judged quality in the metrics store
+const SourceJudge = "judge"
+
+const (
+	// judgeTimeout bounds one judge call
+	judgeTimeout = time.Minute
+	// judgeSystem asks for a bare score so parsing stays trivial
+	judgeSystem = "You are a strict grader of AI assistant answers. Score the answer against the rubric from 0 (fails it entirely) to 10 (meets it fully). Reply with the number only."
+)
+
+var judgeScore = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// Config controls sampling and when judged quality starts to count
+type Config struct {
+	SampleRate     float64           // Share of complete generate answers judged; 0 disables judging
+	JudgeModel     string            // Catalog model that scores answers; never judges its own
+	MinSamples     int               // Judged answers in the window before a category score is used
+	Window         time.Duration     // How far back judged answers count; older ones are deleted
+	Workers        int               // Concurrent judge calls
+	QueueSize      int               // Sampled answers waiting for a worker; more are dropped
+	MaxPromptChars int               // Prompts and answers are truncated to this before judging
+	Rubrics        map[string]string // By category; categories without one are not sampled
+}
+
+// DefaultConfig returns the built-in settings. Judging is off until
+// QUALITY_SAMPLE_RATE and QUALITY_JUDGE_MODEL are set; QUALITY_MIN_SAMPLES
+// tunes how many judged answers a score needs.
+func DefaultConfig() Config {
+	cfg := Config{
+		MinSamples:     20,
+		Window:         30 * 24 * time.Hour,
+		Workers:        2,
+		QueueSize:      100,
+		MaxPromptChars: 8000,
+		Rubrics:        DefaultRubrics(),
+		JudgeModel:     os.Getenv("QUALITY_JUDGE_MODEL"),
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("QUALITY_SAMPLE_RATE"), 64); err == nil && v > 0 && v <= 1 {
+		cfg.SampleRate = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("QUALITY_MIN_SAMPLES")); err == nil && v > 0 {
+		cfg.MinSamples = v
+	}
+	return cfg
+}
+
+// Enabled reports whether answers are sampled
+func (c Config) Enabled() bool {
+	return c.SampleRate > 0 && c.JudgeModel != ""
+}
+
+// Generator calls the judge model; *generate.Generator satisfies it
+type Generator interface {
+	Generate(ctx context.Context, req generate.Request) (generate.Response, error)
+}
+
+// Policies are tenants' prompt logging policies; *privacy.PolicyStore
+// satisfies it
+type Policies interface {
+	Get(ctx context.Context, userID string) (privacy.Policy, error)
+}
+
+// QualitySink receives judged quality for routing; *models.FusionService
+// satisfies it
+type QualitySink interface {
+	SetJudgedQuality(modelID string, scores map[string]float64)
+}
+
+// sample is one answer waiting to be judged
+type sample struct {
+	userID   string
+	modelID  string
+	category string
+	prompt   string
+	answer   string
+}
+
+// Score is a model's judged quality in one category over the window
+type Score struct {
+	ModelID  string  `json:"model_id"`
+	Category string  `json:"category"`
+	Score    float64 `json:"score"`   // Mean judged score from 0 to 1
+	Samples  int     `json:"samples"` // Judged answers in the window
+	Applied  bool    `json:"applied"` // Enough samples to count in routing
+}
+
+// Stats counts what happened to generate answers since the replica started
+type Stats struct {
+	Sampled      int64   `json:"sampled"`
+	Dropped      int64   `json:"dropped"` // The queue was full
+	Skipped      int64   `json:"skipped"` // The tenant's logging policy keeps prompts private
+	Judged       int64   `json:"judged"`
+	Failed       int64   `json:"failed"` // The judge call failed or returned no score
+	JudgeCostUSD float64 `json:"judge_cost_usd"`
+}
+
+// Judge samples generate answers, scores them asynchronously with a judge
+// model against per-category rubrics, stores the scores and feeds each
+// model's mean score per category into routing
+type Judge struct {
+	db        *sql.DB
+	cfg       Config
+	generator Generator
+	ingester  *ingest.Ingester
+	policies  Policies
+	sink      QualitySink
+	queue     chan sample
+
+	mu        sync.Mutex
+	stats     Stats
+	scores    []Score
+	published map[string]bool // Models last given judged quality
+}
+
+func NewJudge(db *sql.DB, generator Generator, ingester *ingest.Ingester, cfg Config) *Judge {
+	return &Judge{
+		db:        db,
+		cfg:       cfg,
+		generator: generator,
+		ingester:  ingester,
+		queue:     make(chan sample, cfg.QueueSize),
+		published: make(map[string]bool),
+	}
+}
+
+// SetPolicies skips tenants whose logging policy keeps prompts private, so
+// their prompts never reach the judge model
+func (j *Judge) SetPolicies(policies Policies) {
+	j.policies = policies
+}
+
+// SetQualitySink routes with judged quality as it is refreshed
+func (j *Judge) SetQualitySink(sink QualitySink) {
+	j.sink = sink
+}
+
+// Config returns the active settings
+func (j *Judge) Config() Config {
+	return j.cfg
+}
+
+// Observe samples a complete answer for judging. It never blocks: when the
+// queue is full the answer is dropped.
+func (j *Judge) Observe(userID, category, prompt string, resp generate.Response) {
+	if !j.cfg.Enabled() || resp.Partial || resp.FinishReason != generate.FinishStop {
+		return
+	}
+	if strings.TrimSpace(resp.Content) == "" || resp.Model == j.cfg.JudgeModel || j.cfg.Rubrics[category] == "" {
+		return
+	}
+	if rand.Float64() >= j.cfg.SampleRate {
+		return
+	}
+
+	s := sample{userID: userID, modelID: resp.Model, category: category, prompt: prompt, answer: resp.Content}
+	select {
+	case j.queue <- s:
+		j.count(func(st *Stats) { st.Sampled++ })
+	default:
+		j.count(func(st *Stats) { st.Dropped++ })
+	}
+}
+
+// Start loads the current scores, runs the judge workers and refreshes the
+// scores every interval
+func (j *Judge) Start(ctx context.Context, interval time.Duration) {
+	if err := j.Refresh(ctx); err != nil {
+		log.Printf("[QUALITY] %v", err)
+	}
+	for i := 0; i < j.cfg.Workers; i++ {
+		go j.work(ctx)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := j.Refresh(ctx); err != nil {
+					log.Printf("[QUALITY] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+func (j *Judge) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case s := <-j.queue:
+			if err := j.evaluate(ctx, s); err != nil {
+				log.Printf("[QUALITY] Failed to judge %s answer: %v", s.modelID, err)
+				j.count(func(st *Stats) { st.Failed++ })
+			}
+		}
+	}
+}
+
+// evaluate judges one answer and stores its score
+func (j *Judge) evaluate(ctx context.Context, s sample) error {
+	if j.policies != nil && s.userID != "" {
+		policy, err := j.policies.Get(ctx, s.userID)
+		if err != nil {
+			return err
+		}
+		if policy.Mode == privacy.ModeNone {
+			j.count(func(st *Stats) { st.Skipped++ })
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, judgeTimeout)
+	defer cancel()
+
+	prompt := fmt.Sprintf("Rubric:\n%s\n\nQuestion:\n%s\n\nAnswer:\n%s",
+		j.cfg.Rubrics[s.category], truncate(s.prompt, j.cfg.MaxPromptChars), truncate(s.answer, j.cfg.MaxPromptChars))
+	zero := 0.0
+	resp, err := j.generator.Generate(ctx, generate.Request{
+		Model: j.cfg.JudgeModel,
+		Messages: []generate.Message{
+			{Role: "system", Content: judgeSystem},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   8,
+		Temperature: &zero,
+	})
+	if err != nil {
+		return fmt.Errorf("judge failed: %w", err)
+	}
+	j.count(func(st *Stats) { st.JudgeCostUSD += resp.Usage.CostUSD })
+
+	match := judgeScore.FindString(resp.Content)
+	if match == "" {
+		return fmt.Errorf("judge returned no score: %q", resp.Content)
+	}
+	score, _ := strconv.ParseFloat(match, 64)
+	score = math.Min(score, 10) / 10
+
+	if _, err := j.db.ExecContext(ctx, `
+		INSERT INTO quality_scores (model_id, category, score, judge_model, judge_cost_usd)
+		VALUES ($1, $2, $3, $4, $5)`,
+		s.modelID, s.category, score, j.cfg.JudgeModel, resp.Usage.CostUSD); err != nil {
+		return fmt.Errorf("failed to store quality score: %w", err)
+	}
+	j.count(func(st *Stats) { st.Judged++ })
+	return nil
+}
+
+// Refresh averages judged scores over the window, stores the averages as
+// metrics and routes with those that have enough samples. Scores judged
+// elsewhere are included, so every replica routes alike.
+func (j *Judge) Refresh(ctx context.Context) error {
+	since := time.Now().Add(-j.cfg.Window)
+	if _, err := j.db.ExecContext(ctx, `DELETE FROM quality_scores WHERE judged_at < $1`, since); err != nil {
+		return fmt.Errorf("failed to expire quality scores: %w", err)
+	}
+
+	rows, err := j.db.QueryContext(ctx, `
+		SELECT model_id, category, AVG(score), COUNT(*) FROM quality_scores
+		WHERE judged_at >= $1
+		GROUP BY model_id, category
+		ORDER BY model_id, category`, since)
+	if err != nil {
+		return fmt.Errorf("failed to load quality scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := []Score{}
+	applied := make(map[string]map[string]float64)
+	var batch []ingest.Metric
+	now := time.Now()
+	for rows.Next() {
+		var s Score
+		if err := rows.Scan(&s.ModelID, &s.Category, &s.Score, &s.Samples); err != nil {
+			return fmt.Errorf("failed to scan quality score: %w", err)
+		}
+		s.Score = math.Round(s.Score*1000) / 1000
+		s.Applied = s.Samples >= j.cfg.MinSamples
+		scores = append(scores, s)
+		if !s.Applied {
+			continue
+		}
+		if applied[s.ModelID] == nil {
+			applied[s.ModelID] = make(map[string]float64)
+		}
+		applied[s.ModelID][s.Category] = s.Score
+		batch = append(batch, ingest.Metric{
+			ModelID:    s.ModelID,
+			Source:     SourceJudge,
+			Metric:     s.Category,
+			Value:      s.Score,
+			ObservedAt: now,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if j.ingester != nil && len(batch) > 0 {
+		result, err := j.ingester.UpsertMetrics(ctx, batch)
+		if err != nil {
+			log.Printf("[QUALITY] Failed to store judged quality metrics: %v", err)
+		} else if result.DeadLettered > 0 {
+			log.Printf("[QUALITY] %d judged quality metrics dead-lettered in batch %s", result.DeadLettered, result.BatchID)
+		}
+	}
+
+	j.mu.Lock()
+	j.scores = scores
+	previous := j.published
+	j.published = make(map[string]bool, len(applied))
+	for modelID := range applied {
+		j.published[modelID] = true
+	}
+	j.mu.Unlock()
+
+	if j.sink != nil {
+		for modelID, byCategory := range applied {
+			j.sink.SetJudgedQuality(modelID, byCategory)
+		}
+		// Models whose samples all aged out stop counting
+		for modelID := range previous {
+			if applied[modelID] == nil {
+				j.sink.SetJudgedQuality(modelID, nil)
+			}
+		}
+	}
+	return nil
+}
+
+// Scores returns judged quality by model and category, including scores
+// without enough samples to count yet
+func (j *Judge) Scores() []Score {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Score(nil), j.scores...)
+}
+
+// Stats returns this replica's sampling counters
+func (j *Judge) Stats() Stats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+func (j *Judge) count(update func(*Stats)) {
+	j.mu.Lock()
+	update(&j.stats)
+	j.mu.Unlock()
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
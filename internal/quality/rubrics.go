@@ -0,0 +1,55 @@
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// DefaultRubrics are what the judge scores answers against in each text
+// category. Categories without a rubric are not sampled.
+func DefaultRubrics() map[string]string {
+	return map[string]string{
+		models.CapabilityCoding:        "The code is correct, runs as written, handles edge cases the question implies, and follows the language's conventions. Explanations are accurate and brief.",
+		models.CapabilityMath:          "The final answer is correct and the working is valid, with no skipped or wrong steps. Notation is clear.",
+		models.CapabilityReasoning:     "The conclusion follows from the premises, each step is sound, and assumptions are stated. No contradictions.",
+		models.CapabilityWriting:       "The text does what was asked, in the requested tone and length, is well organized, and is free of errors.",
+		models.CapabilityCreative:      "The piece follows the brief, is original and engaging, and keeps a consistent voice.",
+		models.CapabilityAnalysis:      "The analysis is accurate, uses the information given, weighs alternatives, and reaches supported conclusions.",
+		models.CapabilityResearch:      "The answer is factually accurate, covers the important points, distinguishes established facts from uncertainty, and does not invent sources.",
+		models.CapabilityConversation:  "The reply is helpful, relevant, appropriately concise, and natural in tone.",
+		models.CapabilityTranslation:   "The translation preserves the meaning, tone and formatting of the source and reads naturally in the target language.",
+		models.CapabilitySummarization: "The summary is faithful to the source, keeps its key points, adds nothing that is not in it, and respects any requested length.",
+		models.CapabilityGrounding:     "Every claim is supported by the material provided, and the answer says so when the material does not contain the answer.",
+	}
+}
+
+// LoadRubrics reads a JSON object of rubrics by category over the defaults;
+// an empty rubric stops a category from being sampled
+func LoadRubrics(path string) (map[string]string, error) {
+	rubrics := DefaultRubrics()
+	if path == "" {
+		return rubrics, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quality rubrics: %w", err)
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse quality rubrics: %w", err)
+	}
+	for category, rubric := range overrides {
+		category = models.CanonicalCapability(category)
+		if strings.TrimSpace(rubric) == "" {
+			delete(rubrics, category)
+			continue
+		}
+		rubrics[category] = rubric
+	}
+	return rubrics, nil
+}
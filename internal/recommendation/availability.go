@@ -0,0 +1,14 @@
+package recommendation
+
+import (
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// isInRotation excludes models an operator disabled or scheduled for
+// maintenance now
+func (ere *EnhancedRecommendationEngine) isInRotation(model models.EnhancedModel) bool {
+	held, _ := model.Unavailable(time.Now())
+	return !held
+}
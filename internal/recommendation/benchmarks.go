@@ -0,0 +1,383 @@
+package recommendation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// defaultBenchmarkScore is used when a model has none of a category's benchmarks
+const defaultBenchmarkScore = 0.7
+
+// measuredBenchmarkWeight is the weight of the router's own eval results for a
+// category, which count for more than any single published benchmark
+const measuredBenchmarkWeight = 2.0
+
+// judgedBenchmarkWeight is the weight of the judged quality of live answers,
+// which covers real prompts but is noisier than a curated eval suite
+const judgedBenchmarkWeight = 1.0
+
+// BenchmarkMapping wires one benchmark into a category's benchmark score
+type BenchmarkMapping struct {
+	Benchmark   string  `json:"benchmark"` // key in benchmarks.text or raw_benchmarks
+	Category    string  `json:"category"`
+	Subcategory string  `json:"subcategory,omitempty"` // only scores prompts of this subcategory
+	Weight      float64 `json:"weight"`
+	Min         float64 `json:"min"` // raw value mapped to 0
+	Max         float64 `json:"max"` // raw value mapped to 1
+}
+
+// normalize maps a raw benchmark value onto 0..1 using the configured range
+func (m BenchmarkMapping) normalize(value float64) float64 {
+	if m.Max <= m.Min {
+		return value
+	}
+	n := (value - m.Min) / (m.Max - m.Min)
+	if n < 0 {
+		return 0
+	}
+	if n > 1 {
+		return 1
+	}
+	return n
+}
+
+type benchmarkConfig struct {
+	Mappings []BenchmarkMapping `json:"mappings"`
+}
+
+// BenchmarkMappings holds the effective benchmark-to-category configuration
+// and reloads it when the backing file changes
+type BenchmarkMappings struct {
+	path string
+
+	mu         sync.RWMutex
+	byCategory map[string][]BenchmarkMapping
+	source     string
+	loadedAt   time.Time
+	modTime    time.Time
+}
+
+// DefaultBenchmarkMappings mirrors the benchmarks the engine has always used
+func DefaultBenchmarkMappings() []BenchmarkMapping {
+	return []BenchmarkMapping{
+		{Benchmark: "humaneval", Category: "coding", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "livecodebench", Category: "coding", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "swebench", Category: "coding", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "swe_bench", Category: "coding", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "gsm8k", Category: "math", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "math500", Category: "math", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "aime", Category: "math", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "mmlu", Category: "reasoning", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "mmlu_pro", Category: "reasoning", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "arc", Category: "reasoning", Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "longbench", Category: CapabilityGrounding, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "ruler", Category: CapabilityGrounding, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "facts_grounding", Category: CapabilityGrounding, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "spider", Category: "coding", Subcategory: models.SubcategorySQL, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "bird_sql", Category: "coding", Subcategory: models.SubcategorySQL, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "ds1000", Category: "coding", Subcategory: models.SubcategoryDataScience, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "multipl_e_go", Category: "coding", Subcategory: models.SubcategorySystems, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "multipl_e_rust", Category: "coding", Subcategory: models.SubcategorySystems, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "multipl_e_cpp", Category: "coding", Subcategory: models.SubcategorySystems, Weight: 1.0, Min: 0, Max: 1},
+		{Benchmark: "multipl_e_ts", Category: "coding", Subcategory: models.SubcategoryFrontend, Weight: 1.0, Min: 0, Max: 1},
+	}
+}
+
+// NewBenchmarkMappings loads mappings from path, falling back to the defaults
+// when the file does not exist
+func NewBenchmarkMappings(path string) (*BenchmarkMappings, error) {
+	bm := &BenchmarkMappings{path: path}
+	bm.set(DefaultBenchmarkMappings(), "default", time.Time{})
+
+	if path == "" {
+		return bm, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		log.Printf("[BENCHMARKS] %s not found, using default mappings", path)
+		return bm, nil
+	}
+	if err := bm.Reload(); err != nil {
+		return nil, err
+	}
+	return bm, nil
+}
+
+// Reload re-reads the mapping file. On error the current mappings are kept.
+func (bm *BenchmarkMappings) Reload() error {
+	info, err := os.Stat(bm.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat benchmark mappings: %w", err)
+	}
+	data, err := os.ReadFile(bm.path)
+	if err != nil {
+		return fmt.Errorf("failed to read benchmark mappings: %w", err)
+	}
+
+	var cfg benchmarkConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse benchmark mappings: %w", err)
+	}
+	for i, m := range cfg.Mappings {
+		if m.Benchmark == "" || m.Category == "" {
+			return fmt.Errorf("mapping %d: benchmark and category are required", i)
+		}
+		if m.Weight < 0 {
+			return fmt.Errorf("mapping %d (%s): weight must not be negative", i, m.Benchmark)
+		}
+		if m.Max < m.Min {
+			return fmt.Errorf("mapping %d (%s): max must not be below min", i, m.Benchmark)
+		}
+		m.Category = models.CanonicalCapability(m.Category)
+		cfg.Mappings[i].Category = m.Category
+		if !models.IsCanonicalCapability(m.Category) {
+			log.Printf("[BENCHMARKS] Mapping %d (%s): category %q is not in the capability taxonomy", i, m.Benchmark, m.Category)
+		}
+		if err := models.ValidateSubcategory(m.Category, m.Subcategory); err != nil {
+			return fmt.Errorf("mapping %d (%s): %w", i, m.Benchmark, err)
+		}
+		if m.Weight == 0 {
+			cfg.Mappings[i].Weight = 1.0
+		}
+	}
+
+	bm.set(cfg.Mappings, bm.path, info.ModTime())
+	log.Printf("[BENCHMARKS] Loaded %d benchmark mappings from %s", len(cfg.Mappings), bm.path)
+	return nil
+}
+
+// Watch reloads the mapping file whenever its modification time changes
+func (bm *BenchmarkMappings) Watch(ctx context.Context, interval time.Duration) {
+	if bm.path == "" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(bm.path)
+				if err != nil {
+					continue
+				}
+				bm.mu.RLock()
+				changed := !info.ModTime().Equal(bm.modTime)
+				bm.mu.RUnlock()
+				if changed {
+					if err := bm.Reload(); err != nil {
+						log.Printf("[BENCHMARKS] Keeping previous mappings: %v", err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (bm *BenchmarkMappings) set(mappings []BenchmarkMapping, source string, modTime time.Time) {
+	byCategory := make(map[string][]BenchmarkMapping)
+	for _, m := range mappings {
+		m.Benchmark = strings.ToLower(m.Benchmark)
+		byCategory[m.Category] = append(byCategory[m.Category], m)
+	}
+
+	bm.mu.Lock()
+	bm.byCategory = byCategory
+	bm.source = source
+	bm.loadedAt = time.Now()
+	bm.modTime = modTime
+	bm.mu.Unlock()
+}
+
+// Effective returns the active mappings grouped by category with their source
+func (bm *BenchmarkMappings) Effective() map[string]interface{} {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	mappings := make(map[string][]BenchmarkMapping, len(bm.byCategory))
+	for category, m := range bm.byCategory {
+		mappings[category] = append([]BenchmarkMapping(nil), m...)
+	}
+
+	return map[string]interface{}{
+		"source":    bm.source,
+		"loaded_at": bm.loadedAt,
+		"mappings":  mappings,
+	}
+}
+
+// Score returns the weighted, normalized benchmark score for a category
+func (bm *BenchmarkMappings) Score(model models.EnhancedModel, category string) float64 {
+	return bm.SubcategoryScore(model, category, "")
+}
+
+// SubcategoryScore scores a category from the subcategory's own benchmarks when
+// the model has any of them, and from the category's general benchmarks otherwise,
+// so a Go prompt is not ranked by Python results when Go results exist
+func (bm *BenchmarkMappings) SubcategoryScore(model models.EnhancedModel, category, subcategory string) float64 {
+	bm.mu.RLock()
+	mappings := bm.byCategory[category]
+	bm.mu.RUnlock()
+
+	mappings = forSubcategory(model, mappings, subcategory)
+
+	var weighted, totalWeight float64
+	for _, m := range mappings {
+		value, ok := benchmarkValue(model, m.Benchmark)
+		if !ok {
+			continue
+		}
+		weighted += m.normalize(value) * m.Weight
+		totalWeight += m.Weight
+	}
+	if value, ok := benchmarkValue(model, models.MeasuredBenchmark(category)); ok {
+		weighted += value * measuredBenchmarkWeight
+		totalWeight += measuredBenchmarkWeight
+	}
+	if value, ok := benchmarkValue(model, models.JudgedBenchmark(category)); ok {
+		weighted += value * judgedBenchmarkWeight
+		totalWeight += judgedBenchmarkWeight
+	}
+
+	if totalWeight == 0 {
+		return defaultBenchmarkScore
+	}
+	return weighted / totalWeight
+}
+
+// Evidence counts the mapped, measured and judged benchmarks a model has for a category
+func (bm *BenchmarkMappings) Evidence(model models.EnhancedModel, category string) int {
+	bm.mu.RLock()
+	mappings := bm.byCategory[category]
+	bm.mu.RUnlock()
+
+	count := 0
+	for _, m := range forSubcategory(model, mappings, "") {
+		if _, ok := benchmarkValue(model, m.Benchmark); ok {
+			count++
+		}
+	}
+	if _, ok := benchmarkValue(model, models.MeasuredBenchmark(category)); ok {
+		count++
+	}
+	if _, ok := benchmarkValue(model, models.JudgedBenchmark(category)); ok {
+		count++
+	}
+	return count
+}
+
+// InferCapabilities derives a text task capability for every category with at
+// least one mapped benchmark. Confidence grows with the number of benchmarks
+// but stays below curated and Analytics AI scores.
+func (bm *BenchmarkMappings) InferCapabilities(model models.EnhancedModel) map[string]models.InferredCapability {
+	bm.mu.RLock()
+	byCategory := bm.byCategory
+	bm.mu.RUnlock()
+
+	inferred := make(map[string]models.InferredCapability)
+	for category, mappings := range byCategory {
+		var weighted, totalWeight float64
+		var used []string
+		for _, m := range mappings {
+			if m.Subcategory != "" {
+				continue
+			}
+			value, ok := benchmarkValue(model, m.Benchmark)
+			if !ok || m.Weight == 0 {
+				continue
+			}
+			weighted += m.normalize(value) * m.Weight
+			totalWeight += m.Weight
+			used = append(used, m.Benchmark)
+		}
+		if totalWeight == 0 {
+			continue
+		}
+
+		score := weighted / totalWeight
+		inferred[category] = models.InferredCapability{
+			Capability: models.TaskCapability{
+				Score:           math.Round(score*1000) / 1000,
+				Confidence:      math.Min(0.5+0.1*float64(len(used)), 0.85),
+				ComplexityRange: inferredComplexityRange(score),
+			},
+			Benchmarks: used,
+		}
+	}
+	return inferred
+}
+
+// forSubcategory picks the subcategory's mappings the model has results for,
+// falling back to the general mappings
+func forSubcategory(model models.EnhancedModel, mappings []BenchmarkMapping, subcategory string) []BenchmarkMapping {
+	var general, specific []BenchmarkMapping
+	for _, m := range mappings {
+		switch m.Subcategory {
+		case "":
+			general = append(general, m)
+		case subcategory:
+			if _, ok := benchmarkValue(model, m.Benchmark); ok {
+				specific = append(specific, m)
+			}
+		}
+	}
+	if len(specific) > 0 {
+		return specific
+	}
+	return general
+}
+
+func inferredComplexityRange(score float64) []string {
+	switch {
+	case score >= 0.85:
+		return []string{"simple", "medium", "hard", "expert"}
+	case score >= 0.7:
+		return []string{"simple", "medium", "hard"}
+	default:
+		return []string{"simple", "medium"}
+	}
+}
+
+// rawBenchmarkFields resolves raw_benchmarks entries by their JSON name
+var rawBenchmarkFields = map[string]func(rb *models.RawBenchmarks) *float64{
+	"humaneval":     func(rb *models.RawBenchmarks) *float64 { return rb.HumanEval },
+	"livecodebench": func(rb *models.RawBenchmarks) *float64 { return rb.LiveCodeBench },
+	"swebench":      func(rb *models.RawBenchmarks) *float64 { return rb.SWEBench },
+	"gsm8k":         func(rb *models.RawBenchmarks) *float64 { return rb.GSM8K },
+	"math500":       func(rb *models.RawBenchmarks) *float64 { return rb.Math500 },
+	"aime":          func(rb *models.RawBenchmarks) *float64 { return rb.AIME },
+	"mmlu":          func(rb *models.RawBenchmarks) *float64 { return rb.MMLU },
+	"mmlu_pro":      func(rb *models.RawBenchmarks) *float64 { return rb.MMLUPro },
+	"arc":           func(rb *models.RawBenchmarks) *float64 { return rb.ARC },
+}
+
+// benchmarkValue looks a benchmark up in benchmarks.text, then raw_benchmarks
+func benchmarkValue(model models.EnhancedModel, name string) (float64, bool) {
+	for key, value := range model.Benchmarks.Text {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	if rb := model.Benchmarks.RawBenchmarks; rb != nil {
+		if field, ok := rawBenchmarkFields[name]; ok {
+			if v := field(rb); v != nil {
+				return *v, true
+			}
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,236 @@
+package recommendation
+
+import (
+	"context"
+	"math"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Prior sources, from most to least specific
+const (
+	PriorBaseModel = "base model"
+	PriorProvider  = "provider"
+	PriorCatalog   = "catalog"
+)
+
+const (
+	// peerEvidence is the evidence a model needs on a component to inform priors
+	peerEvidence = 0.5
+	// benchmarksForFullEvidence is how many benchmarks fully back a benchmark score
+	benchmarksForFullEvidence = 2
+	// coldStartMaxConfidence caps the confidence of a model scored from priors
+	coldStartMaxConfidence = 0.4
+)
+
+// shrunkComponents fall back to fixed defaults when a model has no data
+var shrunkComponents = []string{"capability", "performance", "community", "benchmark"}
+
+// componentPriors are mean component scores of well-measured candidates
+type componentPriors struct {
+	byProvider map[string]map[string]float64
+	catalog    map[string]float64
+}
+
+// componentEvidence rates from 0 to 1 how much of a model's own data backs each
+// shrunk component; 0 means the score is a fixed default
+func (ere *EnhancedRecommendationEngine) componentEvidence(model models.EnhancedModel, req RecommendationRequest) map[string]float64 {
+	evidence := make(map[string]float64, len(shrunkComponents))
+
+	if req.TaskType == "text" {
+		if taskCap, ok := model.TaskCapabilities.TextTasks[req.Category]; ok {
+			evidence["capability"] = taskCap.Confidence
+			if taskCap.Confidence == 0 {
+				evidence["capability"] = 0.7
+			}
+		} else if compositeIndex(model, req.Category) != nil {
+			evidence["capability"] = 0.5
+		}
+		count := ere.benchmarkMappings.Evidence(model, req.Category)
+		evidence["benchmark"] = math.Min(float64(count)/benchmarksForFullEvidence, 1)
+	} else {
+		if _, ok := model.TaskCapabilities.GenerativeTasks[req.TaskType+"_generation"]; ok {
+			evidence["capability"] = 1
+		}
+		if hasGenerativeBenchmark(model, req.TaskType) {
+			evidence["benchmark"] = 1
+		}
+	}
+
+	perf := model.Performance
+	evidence["performance"] = countPresent(perf.Latency.AvgLatencyMs != nil,
+		perf.Latency.ThroughputTokensSec != nil, perf.Availability.UptimePercentage != nil) / 3
+
+	// Judged quality of live answers and Hub popularity count as more community signals
+	community := model.CommunityIntelligence
+	_, judged := benchmarkValue(model, models.JudgedBenchmark(req.Category))
+	evidence["community"] = math.Min(countPresent(community.RedditSentiment != nil,
+		community.DeveloperRating != nil, community.GitHubActivity.Stars != nil, judged,
+		community.HuggingFace != nil)/3, 1)
+
+	return evidence
+}
+
+func countPresent(present ...bool) float64 {
+	n := 0.0
+	for _, p := range present {
+		if p {
+			n++
+		}
+	}
+	return n
+}
+
+// hasGenerativeBenchmark mirrors the fields getGenerativeBenchmarkScore reads
+func hasGenerativeBenchmark(model models.EnhancedModel, taskType string) bool {
+	gb := model.Benchmarks.GenerativeBenchmarks
+	if gb == nil {
+		return false
+	}
+	switch taskType {
+	case "image":
+		return gb.Image != nil && (gb.Image.CLIPScore != nil || gb.Image.UserPreference != nil)
+	case "video":
+		return gb.Video != nil && (gb.Video.TemporalConsistency != nil || gb.Video.UserStudies != nil)
+	case "audio":
+		return gb.Audio != nil && (gb.Audio.NaturalnessMOS != nil || gb.Audio.SimilarityScore != nil)
+	}
+	return false
+}
+
+func compositeIndex(model models.EnhancedModel, category string) *float64 {
+	ci := model.Benchmarks.CompositeIndices
+	switch category {
+	case "coding":
+		return ci.AnalyticsAICoding
+	case "math":
+		return ci.AnalyticsAIMath
+	case "reasoning":
+		return ci.AnalyticsAIIntelligence
+	}
+	return nil
+}
+
+// buildPriors averages each component over candidates with enough evidence,
+// per provider and across the whole candidate set
+func (ere *EnhancedRecommendationEngine) buildPriors(ctx context.Context, candidates []models.EnhancedModel, req RecommendationRequest) *componentPriors {
+	type sum struct {
+		total float64
+		n     int
+	}
+	byProvider := make(map[string]map[string]*sum)
+	catalog := make(map[string]*sum)
+
+	add := func(sums map[string]*sum, component string, value float64) {
+		if sums[component] == nil {
+			sums[component] = &sum{}
+		}
+		sums[component].total += value
+		sums[component].n++
+	}
+
+	// On a large catalog the priors come from the candidates seen within their share of the deadline
+	ctx, cancel := withPriorsDeadline(ctx)
+	defer cancel()
+	for _, model := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		components := ere.rawComponents(model, req)
+		evidence := ere.componentEvidence(model, req)
+		for _, component := range shrunkComponents {
+			if evidence[component] < peerEvidence {
+				continue
+			}
+			if byProvider[model.Provider] == nil {
+				byProvider[model.Provider] = make(map[string]*sum)
+			}
+			add(byProvider[model.Provider], component, components[component])
+			add(catalog, component, components[component])
+		}
+	}
+
+	mean := func(sums map[string]*sum) map[string]float64 {
+		means := make(map[string]float64, len(sums))
+		for component, s := range sums {
+			means[component] = s.total / float64(s.n)
+		}
+		return means
+	}
+
+	priors := &componentPriors{
+		byProvider: make(map[string]map[string]float64, len(byProvider)),
+		catalog:    mean(catalog),
+	}
+	for provider, sums := range byProvider {
+		priors.byProvider[provider] = mean(sums)
+	}
+	return priors
+}
+
+// prior returns the most specific prior for a component: the model's base
+// model, then its provider's peers, then every candidate
+func (ere *EnhancedRecommendationEngine) prior(model models.EnhancedModel, req RecommendationRequest, component string, priors *componentPriors) (float64, string, bool) {
+	if model.BaseModel != "" && ere.fusionService != nil {
+		if base, ok := ere.fusionService.GetModelByID(model.BaseModel); ok {
+			if ere.componentEvidence(base, req)[component] >= peerEvidence {
+				return ere.rawComponents(base, req)[component], PriorBaseModel, true
+			}
+		}
+	}
+	if value, ok := priors.byProvider[model.Provider][component]; ok {
+		return value, PriorProvider, true
+	}
+	if value, ok := priors.catalog[component]; ok {
+		return value, PriorCatalog, true
+	}
+	return 0, "", false
+}
+
+// applyColdStart blends each shrunk component with its prior in proportion to
+// the model's evidence, so a component with no data takes the prior and a fully
+// backed one keeps its own score. A model is cold-start when it has neither
+// benchmark nor community data; the returned source names the prior its
+// benchmark score was estimated from.
+func (ere *EnhancedRecommendationEngine) applyColdStart(model models.EnhancedModel, req RecommendationRequest, components map[string]float64, priors *componentPriors) (bool, string) {
+	if priors == nil {
+		return false, ""
+	}
+	evidence := ere.componentEvidence(model, req)
+
+	benchmarkSource := "default"
+	for _, component := range shrunkComponents {
+		e := math.Min(evidence[component], 1)
+		if e >= 1 {
+			continue
+		}
+		value, source, ok := ere.prior(model, req, component, priors)
+		if !ok {
+			continue
+		}
+		components[component] = e*components[component] + (1-e)*value
+		if component == "benchmark" {
+			benchmarkSource = source
+		}
+	}
+
+	coldStart := evidence["benchmark"] == 0 && evidence["community"] == 0
+	return coldStart, benchmarkSource
+}
+
+// promoteProven moves the best model with real data to the top when the
+// leader is cold-start, reporting whether it did
+func promoteProven(ranked []ScoredRecommendation) bool {
+	if len(ranked) < 2 || !ranked[0].ColdStart {
+		return false
+	}
+	for i := 1; i < len(ranked); i++ {
+		if !ranked[i].ColdStart {
+			proven := ranked[i]
+			copy(ranked[1:i+1], ranked[:i])
+			ranked[0] = proven
+			return true
+		}
+	}
+	return false
+}
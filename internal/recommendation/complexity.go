@@ -0,0 +1,123 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// complexityLevels orders the complexity buckets. Each covers an equal band
+// of the 0–1 complexity score: simple below 0.25, expert from 0.75.
+var complexityLevels = []string{"simple", "medium", "hard", "expert"}
+
+// complexityBandWidth is the share of the scale one level covers
+const complexityBandWidth = 0.25
+
+// ComplexityBand returns the range of scores a level covers; unknown levels
+// get medium's
+func ComplexityBand(level string) (float64, float64) {
+	for i, l := range complexityLevels {
+		if l == level {
+			return float64(i) * complexityBandWidth, float64(i+1) * complexityBandWidth
+		}
+	}
+	return ComplexityBand("medium")
+}
+
+// ComplexityMidpoint is the score of a typical prompt of the level
+func ComplexityMidpoint(level string) float64 {
+	lo, hi := ComplexityBand(level)
+	return (lo + hi) / 2
+}
+
+// ComplexityLevel returns the level whose band holds a score
+func ComplexityLevel(score float64) string {
+	i := int(score / complexityBandWidth)
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(complexityLevels) {
+		i = len(complexityLevels) - 1
+	}
+	return complexityLevels[i]
+}
+
+// ValidateComplexityScore rejects scores off the 0–1 scale
+func ValidateComplexityScore(score float64) error {
+	if score < 0 || score > 1 {
+		return fmt.Errorf("complexity_score must be between 0 and 1, got %v", score)
+	}
+	return nil
+}
+
+// requestComplexity is the request's complexity score, or the midpoint of its
+// level when it has none
+func requestComplexity(req RecommendationRequest) float64 {
+	if req.ComplexityScore > 0 {
+		return req.ComplexityScore
+	}
+	return ComplexityMidpoint(req.Complexity)
+}
+
+// complexitySpan is the bottom of the lowest level a model supports and the
+// top of the highest; ok is false when it lists no known level
+func complexitySpan(supported []string) (floor, ceiling float64, ok bool) {
+	floor = 1
+	for _, level := range supported {
+		for _, l := range complexityLevels {
+			if l != level {
+				continue
+			}
+			lo, hi := ComplexityBand(level)
+			floor, ceiling = math.Min(floor, lo), math.Max(ceiling, hi)
+			ok = true
+		}
+	}
+	return floor, ceiling, ok
+}
+
+// complexityFit scores how well a model's complexity range fits a prompt's
+// complexity score. Inside the range it scores 1. Above it the score falls
+// linearly to 0.3 a full level past the range, so a barely-hard prompt costs
+// a medium-only model little and a very hard one a lot. Below it, the model
+// is overqualified and falls to 0.8.
+func complexityFit(supported []string, complexity float64) float64 {
+	floor, ceiling, ok := complexitySpan(supported)
+	switch {
+	case !ok:
+		return 1.0
+	case complexity > ceiling:
+		return 1.0 - 0.7*math.Min((complexity-ceiling)/complexityBandWidth, 1)
+	case complexity < floor:
+		return 1.0 - 0.2*math.Min((floor-complexity)/complexityBandWidth, 1)
+	}
+	return 1.0
+}
+
+// generativeComplexityFit scores a generative model whose capability tops out
+// at maxComplexity: 0.9 up to it, falling to 0.4 a full level beyond
+func generativeComplexityFit(maxComplexity string, complexity float64) float64 {
+	_, ceiling, ok := complexitySpan([]string{maxComplexity})
+	if !ok || complexity <= ceiling {
+		return 0.9
+	}
+	return 0.9 - 0.5*math.Min((complexity-ceiling)/complexityBandWidth, 1)
+}
+
+// getComplexityScore interpolates the model's fit at the request's complexity
+// score rather than comparing levels
+func (ere *EnhancedRecommendationEngine) getComplexityScore(model models.EnhancedModel, req RecommendationRequest) float64 {
+	complexity := requestComplexity(req)
+	if req.TaskType == "text" {
+		if taskCap, exists := model.TaskCapabilities.TextTasks[req.Category]; exists {
+			return complexityFit(taskCap.ComplexityRange, complexity)
+		}
+	} else {
+		if genCap, exists := model.TaskCapabilities.GenerativeTasks[req.TaskType+"_generation"]; exists {
+			return generativeComplexityFit(genCap.MaxComplexity, complexity)
+		}
+	}
+
+	return 0.5 // Default neutral score
+}
@@ -0,0 +1,36 @@
+package recommendation
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// rankingReserve is kept back from the request deadline once scoring
+	// stops, for ranking, fallbacks and writing the response
+	rankingReserve = 25 * time.Millisecond
+	// priorsShare is the part of the remaining time the priors pass may use
+	// before scoring starts
+	priorsShare = 0.25
+)
+
+// withScoringDeadline ends scoring rankingReserve before the request
+// deadline, so a partial ranking still reaches the caller in time
+func withScoringDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-rankingReserve))
+}
+
+// withPriorsDeadline bounds the priors pass to its share of the time left,
+// leaving the rest for scoring
+func withPriorsDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	remaining := time.Until(deadline)
+	return context.WithTimeout(ctx, time.Duration(float64(remaining)*priorsShare))
+}
@@ -1,78 +1,213 @@
 package recommendation
 
 import (
+	"context"
+	"fmt"
 	"math"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/overlay"
 )
 
 // RecommendationRequest represents a user's model recommendation request
 type RecommendationRequest struct {
-	TaskType     string                 `json:"task_type"`     // "text", "image", "video", "audio", "multimodal"
-	Category     string                 `json:"category"`      // "coding", "math", "creative", etc.
-	Complexity   string                 `json:"complexity"`    // "simple", "medium", "hard", "expert"
-	Priority     string                 `json:"priority"`      // "quality", "speed", "cost", "balanced"
-	Requirements map[string]interface{} `json:"requirements"`  // Special requirements
-	Context      string                 `json:"context,omitempty"` // Optional context for better matching
+	TaskType        string                 `json:"task_type"`                  // "text", "image", "video", "audio", "multimodal"
+	Category        string                 `json:"category"`                   // "coding", "math", "creative", etc.
+	Subcategory     string                 `json:"subcategory,omitempty"`      // e.g. coding: "sql", "devops"; writing: "marketing_copy"
+	Complexity      string                 `json:"complexity"`                 // "simple", "medium", "hard", "expert"
+	ComplexityScore float64                `json:"complexity_score,omitempty"` // 0–1 within the level's band; 0 takes the level's midpoint
+	Priority        string                 `json:"priority"`                   // "quality", "speed", "cost", "green", "balanced"
+	Requirements    map[string]interface{} `json:"requirements"`               // Special requirements
+	Context         string                 `json:"context,omitempty"`          // Optional context for better matching
+	MaxLatencyMs    int                    `json:"max_latency_ms,omitempty"`   // Hard end-to-end latency SLO
+	ReasoningEffort string                 `json:"reasoning_effort,omitempty"` // "none", "minimal", "low", "medium", "high"
+
+	// Personalization maps model ID to a bounded score adjustment learned from the caller's feedback
+	Personalization map[string]float64 `json:"-"`
+
+	// Subject is the caller a weight experiment assigns to its arms; without
+	// one the control weights are used
+	Subject string `json:"-"`
+
+	// TenantModels are the caller's fine-tuned models, scored alongside the shared catalog
+	TenantModels []models.EnhancedModel `json:"-"`
+
+	// Overlay is the caller's view of the shared catalog: hidden models and negotiated prices
+	Overlay *overlay.Overlay `json:"-"`
+
+	MaxResults int      `json:"max_results,omitempty"` // Recommendations returned; defaults to DefaultMaxResults
+	MinScore   *float64 `json:"min_score,omitempty"`   // Lowest overall score returned; defaults to DefaultMinScore
+
+	// PlanMaxResults bounds MaxResults by the caller's plan
+	PlanMaxResults int `json:"-"`
+
+	// AllowColdStart lets a model without benchmark or community data rank first
+	AllowColdStart bool `json:"allow_cold_start,omitempty"`
+
+	// RAG marks prompts sent with retrieved chunks, which need context room and grounding
+	RAG *RAGHint `json:"rag,omitempty"`
+
+	// ImageInputs is the number of images sent with the prompt; only models that read images are eligible
+	ImageInputs int `json:"image_inputs,omitempty"`
+
+	// LatencyTolerance is "realtime" or "batch"; batch lets models be priced on their batch tier
+	LatencyTolerance string `json:"latency_tolerance,omitempty"`
+
+	// ExpectedOutputTokens is the answer length costs and latency are estimated
+	// for; when unset it is estimated from the category and complexity
+	ExpectedOutputTokens int    `json:"expected_output_tokens,omitempty"`
+	OutputSource         string `json:"output_source,omitempty"` // How ExpectedOutputTokens was estimated
+
+	// Hedge routes defensively when the classifier was unsure of the category
+	Hedge *Hedge `json:"hedge,omitempty"`
+
+	// PreferredTags nudge models carrying them up and AvoidedTags nudge them
+	// down, e.g. "agentic", "enterprise", "cheap"; neither excludes a model
+	PreferredTags []string `json:"preferred_tags,omitempty"`
+	AvoidedTags   []string `json:"avoided_tags,omitempty"`
+
+	// OutputLocale is the locale the answer must be written in, e.g. "ja-JP";
+	// models weak in it are excluded or downranked
+	OutputLocale string `json:"output_locale,omitempty"`
 }
 
 // ScoredRecommendation represents a model with its recommendation score
 type ScoredRecommendation struct {
-	Model           models.EnhancedModel   `json:"model"`
-	OverallScore    float64                `json:"overall_score"`
-	ComponentScores map[string]float64     `json:"component_scores"`
-	Reasoning       string                 `json:"reasoning"`
-	Confidence      float64                `json:"confidence"`
-	CostEstimate    float64                `json:"cost_estimate"`
-	Warnings        []string               `json:"warnings,omitempty"`
+	Model                models.EnhancedModel `json:"model"`
+	OverallScore         float64              `json:"overall_score"`
+	ComponentScores      map[string]float64   `json:"component_scores"`
+	Reasoning            string               `json:"reasoning"`
+	Confidence           float64              `json:"confidence"`
+	CostEstimate         float64              `json:"cost_estimate"`
+	Warnings             []string             `json:"warnings,omitempty"`
+	LatencyEstimate      *LatencyEstimate     `json:"latency_estimate,omitempty"`
+	PersonalizationDelta float64              `json:"personalization_delta,omitempty"`
+	ReasoningEffort      *ReasoningSuggestion `json:"reasoning_effort,omitempty"`
+	ColdStart            bool                 `json:"cold_start,omitempty"`  // No benchmark or community data; scored from priors
+	RawConfidence        float64              `json:"raw_confidence"`        // Heuristic confidence before calibration; send it back with feedback
+	Fallback             bool                 `json:"fallback,omitempty"`    // Served as the category's configured fallback
+	Calibration          string               `json:"calibration,omitempty"` // Method that mapped RawConfidence to Confidence
+	PriceTier            *PriceTierSuggestion `json:"price_tier,omitempty"`  // Discounted tier CostEstimate is priced on
+	Footprint            *models.Footprint    `json:"footprint,omitempty"`   // Estimated energy and emissions of the expected answer
 }
 
 // RecommendationResponse contains the full recommendation result
 type RecommendationResponse struct {
-	Request        RecommendationRequest  `json:"request"`
-	Recommendations []ScoredRecommendation `json:"recommendations"`
-	TotalModels    int                    `json:"total_models"`
-	FilteredModels int                    `json:"filtered_models"`
-	ProcessingTime float64                `json:"processing_time_ms"`
-	Metadata       RecommendationMetadata `json:"metadata"`
+	Request              RecommendationRequest  `json:"request"`
+	Recommendations      []ScoredRecommendation `json:"recommendations"`
+	TotalModels          int                    `json:"total_models"`
+	FilteredModels       int                    `json:"filtered_models"`
+	ProcessingTime       float64                `json:"processing_time_ms"`
+	Metadata             RecommendationMetadata `json:"metadata"`
+	Partial              bool                   `json:"partial,omitempty"`                // Deadline hit before every eligible model was scored
+	EvaluatedModels      int                    `json:"evaluated_models"`                 // Eligible models scored; below FilteredModels when partial
+	LowConfidenceRouting bool                   `json:"low_confidence_routing,omitempty"` // Filters were widened because the classifier was unsure
+	RunnerUpCategory     string                 `json:"runner_up_category,omitempty"`     // Category considered alongside the classified one
+	Limits               ResultLimits           `json:"limits"`
 }
 
 type RecommendationMetadata struct {
-	AlgorithmVersion string                 `json:"algorithm_version"`
-	DataSources      []string               `json:"data_sources"`
-	Weights          map[string]float64     `json:"weights"`
-	AppliedFilters   []string               `json:"applied_filters"`
+	AlgorithmVersion string             `json:"algorithm_version"`
+	DataSources      []string           `json:"data_sources"`
+	Weights          map[string]float64 `json:"weights"`
+	AppliedFilters   []string           `json:"applied_filters"`
+	Fallback         *FallbackDecision  `json:"fallback,omitempty"` // Set when the category's fallback model was served
+	WeightsVariant   string             `json:"weights_variant"`    // Weight set that scored the request; send it back with feedback
 }
 
 // EnhancedRecommendationEngine provides intelligent model recommendations
 type EnhancedRecommendationEngine struct {
-	fusionService *models.FusionService
+	fusionService     *models.FusionService
+	benchmarkMappings *BenchmarkMappings
+	calibrator        Calibrator
+	scorers           *ScorerRegistry
+	fallbacks         *Fallbacks
+	coldStarts        ColdStartEstimator
+	outputHistory     OutputHistory
+	tagWeights        TagWeights
+	weightSource      WeightSource
+}
+
+// Calibrator maps heuristic confidence to the success probability observed in
+// feedback, returning the method used or "" when it left confidence unchanged
+type Calibrator interface {
+	Calibrate(category string, confidence float64) (float64, string)
 }
 
 func NewEnhancedRecommendationEngine(fusionService *models.FusionService) *EnhancedRecommendationEngine {
+	benchmarkMappings, _ := NewBenchmarkMappings("")
 	return &EnhancedRecommendationEngine{
-		fusionService: fusionService,
+		fusionService:     fusionService,
+		benchmarkMappings: benchmarkMappings,
+		scorers:           NewScorerRegistry(),
+		fallbacks:         NewFallbacks(),
+		tagWeights:        DefaultTagWeights(),
 	}
 }
 
-func (ere *EnhancedRecommendationEngine) GetRecommendations(req RecommendationRequest) RecommendationResponse {
+// SetWeightSource replaces the built-in component weights, e.g. with weights
+// tuned on feedback
+func (ere *EnhancedRecommendationEngine) SetWeightSource(source WeightSource) {
+	ere.weightSource = source
+}
+
+// Scorers returns the registry of deployment-specific score components
+func (ere *EnhancedRecommendationEngine) Scorers() *ScorerRegistry {
+	return ere.scorers
+}
+
+// SetBenchmarkMappings replaces the benchmark-to-category configuration
+func (ere *EnhancedRecommendationEngine) SetBenchmarkMappings(mappings *BenchmarkMappings) {
+	ere.benchmarkMappings = mappings
+}
+
+// SetCalibrator calibrates returned confidence against recommendation outcomes
+func (ere *EnhancedRecommendationEngine) SetCalibrator(calibrator Calibrator) {
+	ere.calibrator = calibrator
+}
+
+// BenchmarkMappings returns the active benchmark-to-category configuration
+func (ere *EnhancedRecommendationEngine) BenchmarkMappings() *BenchmarkMappings {
+	return ere.benchmarkMappings
+}
+
+// GetRecommendations scores eligible models until ctx is done. If the deadline
+// hits mid-scoring, the models scored so far are ranked and marked partial,
+// with EvaluatedModels saying how many were scored.
+func (ere *EnhancedRecommendationEngine) GetRecommendations(ctx context.Context, req RecommendationRequest) RecommendationResponse {
 	startTime := getCurrentTimeMs()
+	req.Category, req.Subcategory = SplitCategory(req.Category, req.Subcategory)
 
-	// Get all available models
-	allModels := ere.fusionService.GetAllModels()
+	// Get the models the caller's overlay leaves visible, plus any they registered privately
+	allModels := append(req.Overlay.Apply(ere.fusionService.GetAllModels()), req.TenantModels...)
 
 	// Filter models by task type and basic requirements
 	filteredModels := ere.filterModels(allModels, req)
 
-	// Score each filtered model
+	// Score each filtered model, stopping in time to rank what was scored
+	ctx, cancel := withScoringDeadline(ctx)
+	defer cancel()
+	limits := resultLimits(req)
+	weights, variant := ere.weightsFor(req)
+	priors := ere.buildPriors(ctx, filteredModels, req)
 	scoredModels := make([]ScoredRecommendation, 0, len(filteredModels))
+	partial := false
+	evaluated := 0
 	for _, model := range filteredModels {
-		scored := ere.scoreModel(model, req)
-		if scored.OverallScore > 0.1 { // Only include models with reasonable scores
-			scoredModels = append(scoredModels, scored)
+		if ctx.Err() != nil {
+			partial = true
+			break
+		}
+		scored := ere.scoreModel(model, req, priors, weights)
+		evaluated++
+		if scored.OverallScore < limits.MinScore { // Only include models with reasonable scores
+			limits.BelowMinScore++
+			continue
 		}
+		scoredModels = append(scoredModels, scored)
 	}
 
 	// Sort by overall score (descending)
@@ -80,10 +215,19 @@ func (ere *EnhancedRecommendationEngine) GetRecommendations(req RecommendationRe
 		return scoredModels[i].OverallScore > scoredModels[j].OverallScore
 	})
 
-	// Limit to top 10 recommendations
-	maxResults := 10
-	if len(scoredModels) > maxResults {
-		scoredModels = scoredModels[:maxResults]
+	// Unproven models only lead when the caller opts in
+	appliedFilters := ere.getAppliedFilters(req)
+	if !req.AllowColdStart && promoteProven(scoredModels) {
+		appliedFilters = append(appliedFilters, "cold_start_not_top")
+	}
+
+	// Serve the category's fallback when nothing scored well enough
+	scoredModels, fallback := ere.applyFallback(scoredModels, allModels, req, priors, weights, limits.MinScore)
+
+	// Limit to the top recommendations
+	if len(scoredModels) > limits.MaxResults {
+		limits.BeyondMaxResults = len(scoredModels) - limits.MaxResults
+		scoredModels = scoredModels[:limits.MaxResults]
 	}
 
 	endTime := getCurrentTimeMs()
@@ -98,9 +242,16 @@ func (ere *EnhancedRecommendationEngine) GetRecommendations(req RecommendationRe
 		Metadata: RecommendationMetadata{
 			AlgorithmVersion: "2.0",
 			DataSources:      []string{"model_1.json", "analytics-ai"},
-			Weights:          ere.getWeights(req.Priority),
-			AppliedFilters:   ere.getAppliedFilters(req),
+			Weights:          ere.scorers.weights(weights),
+			AppliedFilters:   appliedFilters,
+			Fallback:         fallback,
+			WeightsVariant:   variant,
 		},
+		Partial:              partial,
+		EvaluatedModels:      evaluated,
+		LowConfidenceRouting: hedging(req),
+		RunnerUpCategory:     hedgeCategory(req),
+		Limits:               limits,
 	}
 }
 
@@ -108,18 +259,25 @@ func (ere *EnhancedRecommendationEngine) filterModels(allModels []models.Enhance
 	var filtered []models.EnhancedModel
 
 	for _, model := range allModels {
-		// Filter by model type
-		if !ere.isModelTypeMatch(model, req.TaskType) {
+		// Filter by model type; multimodal models also answer text prompts carrying images
+		readsImages := readsImagesForText(model, req)
+		if !ere.isModelTypeMatch(model, req.TaskType) && !readsImages {
 			continue
 		}
 
-		// Filter by capability availability
-		if !ere.hasRequiredCapability(model, req.Category, req.TaskType) {
+		// Filter by capability availability; while hedging the runner-up category also qualifies
+		hedged := ere.passesHedgedCapability(model, req)
+		if !ere.hasRequiredCapability(model, req.Category, req.TaskType) && !readsImages && !hedged {
+			continue
+		}
+
+		// Exclude models that cannot read the images sent with the prompt
+		if requiresImageInput(req) && !model.AcceptsImageInput() {
 			continue
 		}
 
 		// Filter by complexity requirements
-		if !ere.meetsComplexityRequirement(model, req.Category, req.Complexity, req.TaskType) {
+		if !ere.meetsComplexityRequirement(model, req.Category, req.Complexity, req.TaskType) && !hedged {
 			continue
 		}
 
@@ -128,6 +286,31 @@ func (ere *EnhancedRecommendationEngine) filterModels(allModels []models.Enhance
 			continue
 		}
 
+		// Exclude models that cannot meet the latency SLO
+		if !ere.meetsLatencySLO(model, req) {
+			continue
+		}
+
+		// Exclude models too small for the retrieved context
+		if !ere.fitsRAGContext(model, req) {
+			continue
+		}
+
+		// Exclude models their provider reports as down
+		if !ere.isAvailable(model) {
+			continue
+		}
+
+		// Exclude models an operator took out of rotation
+		if !ere.isInRotation(model) {
+			continue
+		}
+
+		// Exclude models known to write the output locale poorly
+		if !ere.meetsLocale(model, req) {
+			continue
+		}
+
 		filtered = append(filtered, model)
 	}
 
@@ -250,42 +433,72 @@ func (ere *EnhancedRecommendationEngine) meetsSpecialRequirements(model models.E
 		}
 	}
 
+	// Check explicit model allow-list (e.g. safety policy rerouting)
+	if allowedModels, exists := requirements["allowed_models"]; exists {
+		allowed := toStringSlice(allowedModels)
+		if len(allowed) > 0 && !containsString(allowed, model.ID) {
+			return false
+		}
+	}
+
+	// Check provider allow-list (e.g. providers with native structured output)
+	if allowedProviders, exists := requirements["allowed_providers"]; exists {
+		allowed := toStringSlice(allowedProviders)
+		if len(allowed) > 0 && !containsString(allowed, strings.ToLower(model.Provider)) {
+			return false
+		}
+	}
+
+	// Check API features (tool_use, vision_input, ...); unknown support fails
+	if len(model.MissingFeatures(requirements, true)) > 0 {
+		return false
+	}
+
+	// Check license, data usage and certifications; undeclared compliance fails
+	if len(model.MissingCompliance(models.ComplianceRequirementsFrom(requirements))) > 0 {
+		return false
+	}
+
 	return true
 }
 
-func (ere *EnhancedRecommendationEngine) scoreModel(model models.EnhancedModel, req RecommendationRequest) ScoredRecommendation {
-	weights := ere.getWeights(req.Priority)
-	components := make(map[string]float64)
+func (ere *EnhancedRecommendationEngine) scoreModel(model models.EnhancedModel, req RecommendationRequest, priors *componentPriors, weights map[string]float64) ScoredRecommendation {
+	// Price on the cheapest batch or off-peak tier the request can use
+	listed := model
+	now := time.Now()
+	model, priceTier := ere.applyPriceTier(model, req, now)
 
-	// 1. Task Capability Alignment (40% default weight)
-	capabilityScore := ere.getCapabilityScore(model, req.TaskType, req.Category)
-	components["capability"] = capabilityScore
+	components := ere.rawComponents(model, req)
+	if weights["sustainability"] > 0 {
+		components["sustainability"] = sustainabilityComponent(model)
+	}
 
-	// 2. Complexity Match (25% default weight)
-	complexityScore := ere.getComplexityScore(model, req.Complexity, req.Category, req.TaskType)
-	components["complexity"] = complexityScore
+	// Shrink components backed by little data toward the model's peers
+	coldStart, priorSource := ere.applyColdStart(model, req, components, priors)
 
-	// 3. Performance Metrics (20% default weight)
-	performanceScore := ere.getPerformanceScore(model, req.Priority)
-	components["performance"] = performanceScore
+	// Retrieval-augmented prompts favor grounded models with room for the chunks
+	ragFactor, ragWarnings := ere.applyRAG(model, req, components)
 
-	// 4. Community Intelligence (10% default weight)
-	communityScore := ere.getCommunityScore(model, req.Category)
-	components["community"] = communityScore
+	// Models hit by an ongoing provider incident are downranked
+	outageFactor, outageWarnings := ere.applyOutage(model)
 
-	// 5. Benchmark Alignment (5% default weight)
-	benchmarkScore := ere.getBenchmarkScore(model, req.Category, req.TaskType)
-	components["benchmark"] = benchmarkScore
+	// Models weak or unproven in the output locale are downranked
+	localeFactor, localeWarnings := ere.applyLocale(model, req, components)
 
 	// Calculate weighted overall score
-	overallScore := (capabilityScore * weights["capability"]) +
-		(complexityScore * weights["complexity"]) +
-		(performanceScore * weights["performance"]) +
-		(communityScore * weights["community"]) +
-		(benchmarkScore * weights["benchmark"])
+	overallScore := (components["capability"] * weights["capability"]) +
+		(components["complexity"] * weights["complexity"]) +
+		(components["performance"] * weights["performance"]) +
+		(components["community"] * weights["community"]) +
+		(components["benchmark"] * weights["benchmark"]) +
+		(components["sustainability"] * weights["sustainability"])
+
+	// Blend in registered plugin scorers
+	overallScore = ere.scorers.apply(model, req, components, overallScore)
 
 	// Apply priority-based adjustments
 	overallScore = ere.applyPriorityModifiers(overallScore, req.Priority, model)
+	overallScore *= ragFactor * outageFactor * localeFactor
 
 	// Calculate confidence
 	confidence := ere.calculateConfidence(model, components)
@@ -293,23 +506,120 @@ func (ere *EnhancedRecommendationEngine) scoreModel(model models.EnhancedModel,
 	// Generate reasoning
 	reasoning := ere.generateReasoning(req, model, components, overallScore)
 
+	// Apply the caller's learned affinity for this model (already bounded)
+	personalization := req.Personalization[model.ID]
+	if personalization != 0 {
+		overallScore = math.Max(overallScore+personalization, 0)
+		components["personalization"] = personalization
+		reasoning += fmt.Sprintf(". Personalized %+.3f from your feedback history", personalization)
+	}
+
+	// Nudge models carrying the caller's preferred tags up and avoided tags down
+	if tagDelta, matched := ere.tagAdjustment(model, req); matched != nil {
+		overallScore = math.Max(overallScore+tagDelta, 0)
+		components["tags"] = tagDelta
+		for tag, delta := range matched {
+			components[tagComponentPrefix+tag] = delta
+		}
+		reasoning += ". Tags: " + describeTags(matched)
+	}
+
 	// Calculate cost estimate
 	costEstimate := ere.estimateCost(req, model)
+	tierSuggestion := suggestPriceTier(priceTier, costEstimate, ere.estimateCost(req, listed), now)
+	if tierSuggestion != nil {
+		reasoning += fmt.Sprintf(". The %s tier saves %.0f%%", tierSuggestion.Name, tierSuggestion.Savings*100)
+	}
 
 	// Generate warnings
-	warnings := ere.generateWarnings(req, model)
+	warnings := append(ere.generateWarnings(req, model), ragWarnings...)
+	warnings = append(warnings, outageWarnings...)
+	warnings = append(warnings, localeWarnings...)
+	if coldStart {
+		confidence = math.Min(confidence, coldStartMaxConfidence)
+		warnings = append(warnings, fmt.Sprintf("Insufficient data: no benchmark or community data for %s, so scores are estimated from %s priors", req.Category, priorSource))
+	}
+	rawConfidence := confidence
+	var calibration string
+	if ere.calibrator != nil {
+		confidence, calibration = ere.calibrator.Calibrate(req.Category, confidence)
+	}
+
+	// Suggest a reasoning effort level; deep reasoning on a model without
+	// effort controls is possible but less predictable
+	outputTokens := ere.expectedOutputTokens(req)
+	reasoningSuggestion := ere.suggestReasoning(model, req.ReasoningEffort, outputTokens)
+	if reasoningSuggestion == nil && effortOrder[req.ReasoningEffort] >= effortOrder["medium"] {
+		warnings = append(warnings, "Model does not expose reasoning effort controls for this task's reasoning depth")
+		if req.ReasoningEffort == "high" {
+			overallScore *= 0.95
+		}
+	}
+
+	// Estimate end-to-end latency
+	var latencyEstimate *LatencyEstimate
+	if estimate, ok := ere.estimateLatency(model, outputTokens); ok {
+		latencyEstimate = &estimate
+	}
+
+	// Estimate the answer's energy and emissions for models with an energy profile
+	var footprint *models.Footprint
+	if profile, ok := model.EnergyProfile(); ok && req.TaskType == "text" {
+		f := profile.Footprint(0, outputTokens)
+		footprint = &f
+	}
 
 	return ScoredRecommendation{
-		Model:           model,
-		OverallScore:    math.Min(overallScore, 1.0), // Cap at 1.0
-		ComponentScores: components,
-		Reasoning:       reasoning,
-		Confidence:      confidence,
-		CostEstimate:    costEstimate,
-		Warnings:        warnings,
+		Model:                listed,
+		OverallScore:         math.Min(overallScore, 1.0), // Cap at 1.0
+		ComponentScores:      components,
+		Reasoning:            reasoning,
+		Confidence:           confidence,
+		CostEstimate:         costEstimate,
+		Warnings:             warnings,
+		LatencyEstimate:      latencyEstimate,
+		PersonalizationDelta: personalization,
+		ReasoningEffort:      reasoningSuggestion,
+		ColdStart:            coldStart,
+		RawConfidence:        rawConfidence,
+		Calibration:          calibration,
+		PriceTier:            tierSuggestion,
+		Footprint:            footprint,
 	}
 }
 
+// rawComponents scores a model on each component from its own data, using
+// fixed defaults where it has none
+func (ere *EnhancedRecommendationEngine) rawComponents(model models.EnhancedModel, req RecommendationRequest) map[string]float64 {
+	capability := ere.getSubcategoryCapabilityScore(model, req)
+	if hedging(req) {
+		capability = ere.hedgedCapability(model, req, capability)
+	}
+	return map[string]float64{
+		// 1. Task Capability Alignment (40% default weight)
+		"capability": capability,
+		// 2. Complexity Match (25% default weight)
+		"complexity": ere.getComplexityScore(model, req),
+		// 3. Performance Metrics (20% default weight)
+		"performance": ere.getPerformanceScore(model, req.Priority),
+		// 4. Community Intelligence (10% default weight)
+		"community": ere.getCommunityScore(model, req.Category),
+		// 5. Benchmark Alignment (5% default weight)
+		"benchmark": ere.getBenchmarkScore(model, req.Category, req.Subcategory, req.TaskType),
+	}
+}
+
+// getSubcategoryCapabilityScore prefers a model's curated subcategory score over
+// its score for the whole category
+func (ere *EnhancedRecommendationEngine) getSubcategoryCapabilityScore(model models.EnhancedModel, req RecommendationRequest) float64 {
+	if req.TaskType == "text" {
+		if score, ok := subcategoryCapability(model, req.Category, req.Subcategory); ok {
+			return score
+		}
+	}
+	return ere.getCapabilityScore(model, req.TaskType, req.Category)
+}
+
 func (ere *EnhancedRecommendationEngine) getCapabilityScore(model models.EnhancedModel, taskType, category string) float64 {
 	if taskType == "text" {
 		if taskCap, exists := model.TaskCapabilities.TextTasks[category]; exists {
@@ -357,42 +667,6 @@ func (ere *EnhancedRecommendationEngine) getCapabilityScore(model models.Enhance
 	return 0.0
 }
 
-func (ere *EnhancedRecommendationEngine) getComplexityScore(model models.EnhancedModel, complexity, category, taskType string) float64 {
-	if taskType == "text" {
-		if taskCap, exists := model.TaskCapabilities.TextTasks[category]; exists {
-			if ere.supportsComplexity(taskCap.ComplexityRange, complexity) {
-				// Perfect match gets full score
-				if ere.isOptimalComplexity(taskCap.ComplexityRange, complexity) {
-					return 1.0
-				}
-				return 0.8 // Supports but not optimal
-			}
-			return 0.3 // Doesn't support required complexity
-		}
-	} else {
-		// For generative tasks
-		taskKey := taskType + "_generation"
-		if genCap, exists := model.TaskCapabilities.GenerativeTasks[taskKey]; exists {
-			if ere.complexityLevelMet(genCap.MaxComplexity, complexity) {
-				return 0.9
-			}
-			return 0.4
-		}
-	}
-
-	return 0.5 // Default neutral score
-}
-
-func (ere *EnhancedRecommendationEngine) isOptimalComplexity(supportedRanges []string, requiredComplexity string) bool {
-	// Check if the required complexity is in the "sweet spot" of supported ranges
-	for _, supported := range supportedRanges {
-		if supported == requiredComplexity {
-			return true
-		}
-	}
-	return false
-}
-
 func (ere *EnhancedRecommendationEngine) getPerformanceScore(model models.EnhancedModel, priority string) float64 {
 	score := 0.0
 	components := 0
@@ -406,7 +680,7 @@ func (ere *EnhancedRecommendationEngine) getPerformanceScore(model models.Enhanc
 		components++
 	}
 
-	// Throughput scoring  
+	// Throughput scoring
 	if model.Performance.Latency.ThroughputTokensSec != nil {
 		throughput := *model.Performance.Latency.ThroughputTokensSec
 		// Normalize throughput: higher is better, scale 0-1
@@ -462,9 +736,23 @@ func (ere *EnhancedRecommendationEngine) getCommunityScore(model models.Enhanced
 		components++
 	}
 
+	// Hugging Face Hub popularity of open models, log scale like GitHub stars
+	if hub := model.CommunityIntelligence.HuggingFace; hub != nil {
+		likes := math.Min(math.Log10(float64(hub.Likes)+1)/4.0, 1.0)         // 10k likes = 1.0
+		downloads := math.Min(math.Log10(float64(hub.Downloads)+1)/7.0, 1.0) // 10M monthly downloads = 1.0
+		score += (likes + downloads) / 2
+		components++
+	}
+
+	// Judged quality of the model's live answers in this category
+	if judged, ok := benchmarkValue(model, models.JudgedBenchmark(category)); ok {
+		score += judged
+		components++
+	}
+
 	// Category-specific usage patterns
 	categoryBonus := 0.0
-	for _, useCase := range model.CommunityIntelligence.UsagePatterns.TopUseCases {
+	for _, useCase := range model.CommunityIntelligence.Patterns().TopUseCases {
 		if useCase == category {
 			categoryBonus = 0.2
 			break
@@ -480,48 +768,14 @@ func (ere *EnhancedRecommendationEngine) getCommunityScore(model models.Enhanced
 	return math.Min(score+categoryBonus, 1.0)
 }
 
-func (ere *EnhancedRecommendationEngine) getBenchmarkScore(model models.EnhancedModel, category, taskType string) float64 {
+func (ere *EnhancedRecommendationEngine) getBenchmarkScore(model models.EnhancedModel, category, subcategory, taskType string) float64 {
 	if taskType != "text" {
 		// For generative tasks, use generative benchmarks
 		return ere.getGenerativeBenchmarkScore(model, taskType)
 	}
 
-	// For text tasks, use raw benchmarks
-	benchmarks := model.Benchmarks.RawBenchmarks
-	switch category {
-	case "coding":
-		if benchmarks.HumanEval != nil {
-			return *benchmarks.HumanEval
-		}
-		if benchmarks.LiveCodeBench != nil {
-			return *benchmarks.LiveCodeBench
-		}
-		if benchmarks.SWEBench != nil {
-			return *benchmarks.SWEBench
-		}
-	case "math":
-		if benchmarks.GSM8K != nil {
-			return *benchmarks.GSM8K
-		}
-		if benchmarks.Math500 != nil {
-			return *benchmarks.Math500
-		}
-		if benchmarks.AIME != nil {
-			return *benchmarks.AIME
-		}
-	case "reasoning":
-		if benchmarks.MMLU != nil {
-			return *benchmarks.MMLU
-		}
-		if benchmarks.MMLUPro != nil {
-			return *benchmarks.MMLUPro
-		}
-		if benchmarks.ARC != nil {
-			return *benchmarks.ARC
-		}
-	}
-
-	return 0.7 // Default benchmark score
+	// For text tasks, use the configured benchmark mappings
+	return ere.benchmarkMappings.SubcategoryScore(model, category, subcategory)
 }
 
 func (ere *EnhancedRecommendationEngine) getGenerativeBenchmarkScore(model models.EnhancedModel, taskType string) float64 {
@@ -553,6 +807,18 @@ func (ere *EnhancedRecommendationEngine) getGenerativeBenchmarkScore(model model
 	return 0.7 // Default score
 }
 
+// neutralSustainability scores models without an energy profile in green
+// mode, so missing data neither helps nor sinks them
+const neutralSustainability = 0.5
+
+// sustainabilityComponent is the model's sustainability score for green mode
+func sustainabilityComponent(model models.EnhancedModel) float64 {
+	if score, ok := model.SustainabilityScore(); ok {
+		return score
+	}
+	return neutralSustainability
+}
+
 func (ere *EnhancedRecommendationEngine) applyPriorityModifiers(score float64, priority string, model models.EnhancedModel) float64 {
 	switch priority {
 	case "cost":
@@ -652,7 +918,7 @@ func (ere *EnhancedRecommendationEngine) generateReasoning(req RecommendationReq
 	}
 
 	// Usage pattern reasoning
-	for _, useCase := range model.CommunityIntelligence.UsagePatterns.TopUseCases {
+	for _, useCase := range model.CommunityIntelligence.Patterns().TopUseCases {
 		if useCase == req.Category {
 			reasons = append(reasons, "Popular choice for "+req.Category+" tasks")
 			break
@@ -670,8 +936,9 @@ func (ere *EnhancedRecommendationEngine) estimateCost(req RecommendationRequest,
 	if req.TaskType == "text" {
 		// Estimate cost for text tasks
 		if model.Pricing.Text.CostOutPer1K != nil {
-			// Assume 1000 output tokens for estimation
-			return *model.Pricing.Text.CostOutPer1K
+			// The expected answer length, plus any retrieved context
+			outputTokens := ere.expectedOutputTokens(req)
+			return float64(outputTokens)/1000.0**model.Pricing.Text.CostOutPer1K + ragInputCost(model, req)
 		}
 	} else if req.TaskType == "image" {
 		if model.Pricing.Generative.CostPerImage != nil {
@@ -719,7 +986,7 @@ func (ere *EnhancedRecommendationEngine) generateWarnings(req RecommendationRequ
 	}
 
 	// Community warnings
-	for _, weakness := range model.CommunityIntelligence.UsagePatterns.ReportedWeaknesses {
+	for _, weakness := range model.CommunityIntelligence.Patterns().ReportedWeaknesses {
 		if strings.Contains(strings.ToLower(weakness), strings.ToLower(req.Category)) {
 			warnings = append(warnings, "Community reports issues with "+req.Category+": "+weakness)
 		}
@@ -729,43 +996,6 @@ func (ere *EnhancedRecommendationEngine) generateWarnings(req RecommendationRequ
 }
 
 // Helper functions
-func (ere *EnhancedRecommendationEngine) getWeights(priority string) map[string]float64 {
-	switch priority {
-	case "quality":
-		return map[string]float64{
-			"capability":  0.50,
-			"complexity":  0.25,
-			"performance": 0.10,
-			"community":   0.10,
-			"benchmark":   0.05,
-		}
-	case "speed":
-		return map[string]float64{
-			"capability":  0.30,
-			"complexity":  0.15,
-			"performance": 0.40,
-			"community":   0.10,
-			"benchmark":   0.05,
-		}
-	case "cost":
-		return map[string]float64{
-			"capability":  0.30,
-			"complexity":  0.20,
-			"performance": 0.10,
-			"community":   0.25,
-			"benchmark":   0.15,
-		}
-	default: // balanced
-		return map[string]float64{
-			"capability":  0.40,
-			"complexity":  0.25,
-			"performance": 0.20,
-			"community":   0.10,
-			"benchmark":   0.05,
-		}
-	}
-}
-
 func (ere *EnhancedRecommendationEngine) getAppliedFilters(req RecommendationRequest) []string {
 	filters := []string{}
 	filters = append(filters, "model_type:"+req.TaskType)
@@ -782,6 +1012,42 @@ func (ere *EnhancedRecommendationEngine) getAppliedFilters(req RecommendationReq
 		if _, exists := req.Requirements["max_cost"]; exists {
 			filters = append(filters, "cost_limit")
 		}
+		if _, exists := req.Requirements["allowed_models"]; exists {
+			filters = append(filters, "allowed_models")
+		}
+		if _, exists := req.Requirements["allowed_providers"]; exists {
+			filters = append(filters, "allowed_providers")
+		}
+		for _, feature := range append([]string{models.FeatureMaxParallelToolCalls}, models.BoolFeatures...) {
+			if _, exists := req.Requirements[feature]; exists {
+				filters = append(filters, "feature:"+feature)
+			}
+		}
+		for _, name := range models.ComplianceRequirementNames {
+			if _, exists := req.Requirements[name]; exists {
+				filters = append(filters, "compliance:"+name)
+			}
+		}
+	}
+	if requiresImageInput(req) {
+		filters = append(filters, "image_input")
+	}
+	if req.Overlay != nil {
+		filters = append(filters, "catalog_overlay")
+	}
+	if req.MaxLatencyMs > 0 {
+		filters = append(filters, "max_latency_ms")
+	}
+	if req.RAG != nil {
+		filters = append(filters, fmt.Sprintf("rag_context:%d", req.RAG.ContextTokens()))
+	}
+	if req.OutputLocale != "" {
+		filters = append(filters, "output_locale:"+req.OutputLocale)
+	}
+	if category := hedgeCategory(req); category != "" {
+		filters = append(filters, "hedged_category:"+category)
+	} else if hedging(req) {
+		filters = append(filters, "hedged_generalist")
 	}
 
 	return filters
@@ -798,6 +1064,32 @@ func (ere *EnhancedRecommendationEngine) average(numbers []float64) float64 {
 	return sum / float64(len(numbers))
 }
 
+// toStringSlice accepts both []string and JSON-decoded []interface{} values
+func toStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
 func getCurrentTimeMs() float64 {
 	return float64(0) // Placeholder - implement with actual time measurement
-}
\ No newline at end of file
+}
@@ -0,0 +1,230 @@
+package recommendation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// AnyCategory keys the fallback for categories without their own
+const AnyCategory = "*"
+
+// Why a fallback model was served
+const (
+	FallbackNoResults      = "no_results"      // No eligible model scored at least min_score
+	FallbackBelowThreshold = "below_threshold" // The top model scored below the fallback's threshold
+)
+
+// Fallback sources
+const (
+	FallbackSourceConfig = "config"
+	FallbackSourceAdmin  = "admin"
+)
+
+// CategoryFallback is the model served for a category when no model scores
+// well enough
+type CategoryFallback struct {
+	Category  string  `json:"category"`
+	ModelID   string  `json:"model_id"`
+	Threshold float64 `json:"threshold,omitempty"` // Top score below which the fallback is served; min_score when zero
+	Source    string  `json:"source,omitempty"`    // FallbackSourceConfig or FallbackSourceAdmin
+}
+
+// Validate rejects incomplete fallbacks and normalizes the category
+func (f *CategoryFallback) Validate() error {
+	if f.Category != AnyCategory {
+		f.Category = models.CanonicalCapability(f.Category)
+	}
+	if f.Category == "" {
+		return fmt.Errorf("category is required")
+	}
+	if f.ModelID == "" {
+		return fmt.Errorf("model_id is required")
+	}
+	if f.Threshold < 0 || f.Threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// FallbackDecision records a fallback served in place of the ranking's top model
+type FallbackDecision struct {
+	Category  string   `json:"category"`
+	ModelID   string   `json:"model_id"`
+	Reason    string   `json:"reason"`              // FallbackNoResults or FallbackBelowThreshold
+	TopScore  *float64 `json:"top_score,omitempty"` // Best score among eligible models, when any scored
+	Threshold float64  `json:"threshold"`
+}
+
+// Fallbacks holds the per-category fallbacks from the config file, with
+// admin overrides layered on top
+type Fallbacks struct {
+	mu        sync.RWMutex
+	config    map[string]CategoryFallback
+	overrides map[string]CategoryFallback
+}
+
+func NewFallbacks() *Fallbacks {
+	return &Fallbacks{
+		config:    make(map[string]CategoryFallback),
+		overrides: make(map[string]CategoryFallback),
+	}
+}
+
+// LoadFallbacks reads a JSON object of category to fallback, e.g.
+// {"coding": {"model_id": "claude-sonnet-4", "threshold": 0.6}, "*": {"model_id": "gpt-4o"}}
+func LoadFallbacks(path string) (*Fallbacks, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fallbacks config: %w", err)
+	}
+	var entries map[string]CategoryFallback
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse fallbacks config %s: %w", path, err)
+	}
+
+	f := NewFallbacks()
+	for category, fb := range entries {
+		fb.Category = category
+		fb.Source = FallbackSourceConfig
+		if err := fb.Validate(); err != nil {
+			return nil, fmt.Errorf("fallback for %s: %w", category, err)
+		}
+		f.config[fb.Category] = fb
+	}
+	return f, nil
+}
+
+// SetOverrides replaces the admin-managed fallbacks
+func (f *Fallbacks) SetOverrides(overrides []CategoryFallback) {
+	byCategory := make(map[string]CategoryFallback, len(overrides))
+	for _, fb := range overrides {
+		fb.Source = FallbackSourceAdmin
+		byCategory[fb.Category] = fb
+	}
+
+	f.mu.Lock()
+	f.overrides = byCategory
+	f.mu.Unlock()
+}
+
+// Lookup returns the fallback for category: its admin override, its
+// configured fallback, then the same for AnyCategory
+func (f *Fallbacks) Lookup(category string) (CategoryFallback, bool) {
+	if f == nil {
+		return CategoryFallback{}, false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, key := range []string{category, AnyCategory} {
+		if fb, ok := f.overrides[key]; ok {
+			return fb, true
+		}
+		if fb, ok := f.config[key]; ok {
+			return fb, true
+		}
+	}
+	return CategoryFallback{}, false
+}
+
+// List returns the fallback in effect for each category, sorted by category
+func (f *Fallbacks) List() []CategoryFallback {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	effective := make(map[string]CategoryFallback, len(f.config)+len(f.overrides))
+	for category, fb := range f.config {
+		effective[category] = fb
+	}
+	for category, fb := range f.overrides {
+		effective[category] = fb
+	}
+	list := make([]CategoryFallback, 0, len(effective))
+	for _, fb := range effective {
+		list = append(list, fb)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Category < list[j].Category })
+	return list
+}
+
+// SetFallbacks replaces the per-category fallbacks
+func (ere *EnhancedRecommendationEngine) SetFallbacks(fallbacks *Fallbacks) {
+	ere.fallbacks = fallbacks
+}
+
+// Fallbacks returns the per-category fallbacks in use
+func (ere *EnhancedRecommendationEngine) Fallbacks() *Fallbacks {
+	return ere.fallbacks
+}
+
+// applyFallback serves the category's fallback model first when nothing
+// scored or the top score is below the fallback's threshold. The fallback
+// must be visible to the caller and meet the request's hard constraints;
+// otherwise the ranking is left alone.
+func (ere *EnhancedRecommendationEngine) applyFallback(scored []ScoredRecommendation, candidates []models.EnhancedModel, req RecommendationRequest, priors *componentPriors, weights map[string]float64, minScore float64) ([]ScoredRecommendation, *FallbackDecision) {
+	fb, ok := ere.fallbacks.Lookup(req.Category)
+	if !ok {
+		return scored, nil
+	}
+
+	decision := &FallbackDecision{Category: req.Category, ModelID: fb.ModelID, Threshold: fb.Threshold}
+	if decision.Threshold == 0 {
+		decision.Threshold = minScore
+	}
+	switch {
+	case len(scored) == 0:
+		decision.Reason = FallbackNoResults
+	case scored[0].OverallScore < decision.Threshold:
+		top := scored[0].OverallScore
+		decision.TopScore = &top
+		decision.Reason = FallbackBelowThreshold
+	default:
+		return scored, nil
+	}
+	if len(scored) > 0 && scored[0].Model.ID == fb.ModelID {
+		return scored, nil
+	}
+
+	var model *models.EnhancedModel
+	for i := range candidates {
+		if candidates[i].ID == fb.ModelID {
+			model = &candidates[i]
+			break
+		}
+	}
+	if model == nil || !ere.meetsHardConstraints(*model, req) {
+		return scored, nil
+	}
+
+	rec := ere.scoreModel(*model, req, priors, weights)
+	rec.Fallback = true
+	rec.Warnings = append(rec.Warnings, fmt.Sprintf("Served as the %s fallback: %s", req.Category, decision.Reason))
+
+	result := make([]ScoredRecommendation, 0, len(scored)+1)
+	result = append(result, rec)
+	for _, s := range scored {
+		if s.Model.ID != fb.ModelID {
+			result = append(result, s)
+		}
+	}
+	return result, decision
+}
+
+// meetsHardConstraints checks the filters a fallback cannot waive: images it
+// must read, required features, the latency SLO, provider outages and
+// operator holds.
+// Capability and complexity matching are what the fallback stands in for.
+func (ere *EnhancedRecommendationEngine) meetsHardConstraints(model models.EnhancedModel, req RecommendationRequest) bool {
+	if requiresImageInput(req) && !model.AcceptsImageInput() {
+		return false
+	}
+	return ere.meetsSpecialRequirements(model, req.Requirements) &&
+		ere.meetsLatencySLO(model, req) &&
+		ere.isAvailable(model) &&
+		ere.isInRotation(model)
+}
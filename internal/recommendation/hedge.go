@@ -0,0 +1,76 @@
+package recommendation
+
+import (
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Capability blend used while hedging: the classified category still leads,
+// but models that also handle the runner-up, and generalists, rank higher
+const (
+	hedgePrimaryShare  = 0.6
+	hedgeRunnerUpShare = 0.2
+	hedgeBreadthShare  = 0.2
+)
+
+// generalistCategories are the text categories a generalist handles well
+var generalistCategories = []string{
+	models.CapabilityCoding,
+	models.CapabilityMath,
+	models.CapabilityReasoning,
+	models.CapabilityWriting,
+	models.CapabilityAnalysis,
+	models.CapabilityConversation,
+}
+
+// Hedge asks the engine to route defensively because the classifier was
+// unsure of the category: models capable in the runner-up category pass the
+// filters too, and generalists are preferred
+type Hedge struct {
+	Confidence       float64 `json:"confidence"`                   // The classifier's confidence
+	RunnerUpCategory string  `json:"runner_up_category,omitempty"` // Also considered; empty when nothing else matched
+}
+
+// hedging reports whether the request routes defensively; only text
+// categories are hedged
+func hedging(req RecommendationRequest) bool {
+	return req.Hedge != nil && req.TaskType == "text"
+}
+
+// hedgeCategory returns the runner-up category considered alongside the
+// classified one, or "" when not hedging
+func hedgeCategory(req RecommendationRequest) string {
+	if !hedging(req) || req.Hedge.RunnerUpCategory == req.Category {
+		return ""
+	}
+	return req.Hedge.RunnerUpCategory
+}
+
+// passesHedgedCapability lets models built for the runner-up category through
+// the capability and complexity filters
+func (ere *EnhancedRecommendationEngine) passesHedgedCapability(model models.EnhancedModel, req RecommendationRequest) bool {
+	runnerUp := hedgeCategory(req)
+	if runnerUp == "" {
+		return false
+	}
+	return ere.hasRequiredCapability(model, runnerUp, req.TaskType) &&
+		ere.meetsComplexityRequirement(model, runnerUp, req.Complexity, req.TaskType)
+}
+
+// hedgedCapability blends the capability score for the classified category
+// with the runner-up's and the model's breadth across generalist categories
+func (ere *EnhancedRecommendationEngine) hedgedCapability(model models.EnhancedModel, req RecommendationRequest, primary float64) float64 {
+	runnerUp := primary
+	if category := hedgeCategory(req); category != "" {
+		runnerUp = ere.getCapabilityScore(model, req.TaskType, category)
+	}
+	return primary*hedgePrimaryShare + runnerUp*hedgeRunnerUpShare + ere.breadth(model)*hedgeBreadthShare
+}
+
+// breadth is the model's mean capability across the generalist categories
+func (ere *EnhancedRecommendationEngine) breadth(model models.EnhancedModel) float64 {
+	scores := make([]float64, 0, len(generalistCategories))
+	for _, category := range generalistCategories {
+		scores = append(scores, ere.getCapabilityScore(model, "text", category))
+	}
+	return ere.average(scores)
+}
@@ -0,0 +1,100 @@
+package recommendation
+
+import (
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// defaultExpectedOutputTokens is the answer length assumed for categories
+// without a typical length of their own
+const defaultExpectedOutputTokens = 1000
+
+// LatencyEstimate is the end-to-end latency predicted for a model
+type LatencyEstimate struct {
+	TimeToFirstTokenMs float64 `json:"ttft_ms"`
+	GenerationMs       float64 `json:"generation_ms"`
+	TotalMs            float64 `json:"total_ms"`
+	OutputTokens       int     `json:"output_tokens"`
+
+	// Self-hosted models may have to be loaded first; the expected load time
+	// (probability times load time) is included in TimeToFirstTokenMs
+	ColdStartProbability float64 `json:"cold_start_probability,omitempty"`
+	ColdStartMs          float64 `json:"cold_start_ms,omitempty"`
+}
+
+// ColdStartEstimator predicts whether a self-hosted model has been unloaded
+// since it last served, and how long loading it takes
+type ColdStartEstimator interface {
+	ColdStart(model models.EnhancedModel) (probability float64, loadMs float64)
+}
+
+// SetColdStarts adds expected model load time to latency estimates
+func (ere *EnhancedRecommendationEngine) SetColdStarts(estimator ColdStartEstimator) {
+	ere.coldStarts = estimator
+}
+
+// estimateLatency predicts TTFT + output tokens / throughput for a model.
+// The second return value is false when the model lacks the data to estimate.
+func (ere *EnhancedRecommendationEngine) estimateLatency(model models.EnhancedModel, outputTokens int) (LatencyEstimate, bool) {
+	if outputTokens <= 0 {
+		outputTokens = defaultExpectedOutputTokens
+	}
+
+	// Time to first token, falling back to the average request latency
+	var ttft float64
+	switch {
+	case model.Performance.Latency.TimeToFirstTokenMs != nil:
+		ttft = float64(*model.Performance.Latency.TimeToFirstTokenMs)
+	case model.Performance.Latency.AvgLatencyMs != nil:
+		ttft = float64(*model.Performance.Latency.AvgLatencyMs)
+	case model.Performance.AvgLatencyMs > 0:
+		ttft = float64(model.Performance.AvgLatencyMs)
+	default:
+		return LatencyEstimate{}, false
+	}
+
+	var throughput float64
+	switch {
+	case model.Performance.Latency.ThroughputTokensSec != nil && *model.Performance.Latency.ThroughputTokensSec > 0:
+		throughput = *model.Performance.Latency.ThroughputTokensSec
+	case model.Performance.Throughput > 0:
+		throughput = model.Performance.Throughput
+	default:
+		return LatencyEstimate{}, false
+	}
+
+	generation := float64(outputTokens) / throughput * 1000.0
+
+	estimate := LatencyEstimate{
+		TimeToFirstTokenMs: ttft,
+		GenerationMs:       generation,
+		OutputTokens:       outputTokens,
+	}
+	if ere.coldStarts != nil {
+		if p, loadMs := ere.coldStarts.ColdStart(model); p > 0 && loadMs > 0 {
+			estimate.ColdStartProbability = p
+			estimate.ColdStartMs = loadMs
+			estimate.TimeToFirstTokenMs += p * loadMs
+		}
+	}
+	estimate.TotalMs = estimate.TimeToFirstTokenMs + generation
+	return estimate, true
+}
+
+// meetsLatencySLO excludes models whose estimated latency exceeds the hard SLO.
+// Models without enough data to estimate cannot guarantee the SLO and are excluded too.
+// Thinking tokens of the suggested reasoning effort count toward the budget.
+func (ere *EnhancedRecommendationEngine) meetsLatencySLO(model models.EnhancedModel, req RecommendationRequest) bool {
+	if req.MaxLatencyMs <= 0 {
+		return true
+	}
+
+	outputTokens := ere.expectedOutputTokens(req)
+	estimate, ok := ere.estimateLatency(model, outputTokens)
+	if variant, hasVariant := selectReasoningVariant(model, req.ReasoningEffort); hasVariant {
+		estimate, ok = ere.reasoningLatency(model, variant, outputTokens)
+	}
+	if !ok {
+		return false
+	}
+	return estimate.TotalMs <= float64(req.MaxLatencyMs)
+}
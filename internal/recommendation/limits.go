@@ -0,0 +1,68 @@
+package recommendation
+
+import "fmt"
+
+// Result limits applied when the request leaves them unset
+const (
+	DefaultMaxResults = 10
+	DefaultMinScore   = 0.1
+)
+
+// planMaxResults is the largest max_results each plan may request. Anonymous
+// callers and unknown plans get DefaultMaxResults.
+var planMaxResults = map[string]int{
+	"free":       DefaultMaxResults,
+	"beta":       25,
+	"starter":    25,
+	"pro":        50,
+	"enterprise": 100,
+}
+
+// ResultLimits reports the thresholds a response was cut with and how many
+// scored candidates each one removed
+type ResultLimits struct {
+	MaxResults       int     `json:"max_results"`
+	MinScore         float64 `json:"min_score"`
+	BelowMinScore    int     `json:"below_min_score"`    // Scored under min_score
+	BeyondMaxResults int     `json:"beyond_max_results"` // Passed min_score but ranked past max_results
+	Capped           bool    `json:"capped,omitempty"`   // max_results was lowered to the plan's bound
+}
+
+// MaxResultsForPlan returns the upper bound on max_results for a plan
+func MaxResultsForPlan(plan string) int {
+	if bound, ok := planMaxResults[plan]; ok {
+		return bound
+	}
+	return DefaultMaxResults
+}
+
+// ValidateLimits rejects result limits that cannot be applied
+func ValidateLimits(maxResults int, minScore *float64) error {
+	if maxResults < 0 {
+		return fmt.Errorf("max_results must not be negative")
+	}
+	if minScore != nil && (*minScore < 0 || *minScore > 1) {
+		return fmt.Errorf("min_score must be between 0 and 1")
+	}
+	return nil
+}
+
+// resultLimits resolves the request's limits against defaults and its plan bound
+func resultLimits(req RecommendationRequest) ResultLimits {
+	limits := ResultLimits{MaxResults: req.MaxResults, MinScore: DefaultMinScore}
+	if limits.MaxResults <= 0 {
+		limits.MaxResults = DefaultMaxResults
+	}
+	bound := req.PlanMaxResults
+	if bound <= 0 {
+		bound = DefaultMaxResults
+	}
+	if limits.MaxResults > bound {
+		limits.MaxResults = bound
+		limits.Capped = true
+	}
+	if req.MinScore != nil {
+		limits.MinScore = *req.MinScore
+	}
+	return limits
+}
@@ -0,0 +1,54 @@
+package recommendation
+
+import (
+	"fmt"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+const (
+	// minLocaleScore excludes models known to write the output locale poorly
+	minLocaleScore = 0.5
+	// localeWeight is the share of the overall score a weak locale can cost
+	localeWeight = 0.3
+	// unknownLocaleFactor downranks models with no evidence for the locale
+	unknownLocaleFactor = 0.9
+)
+
+// ValidateOutputLocale normalizes the output locale, e.g. "ja_jp" to "ja-JP"
+func ValidateOutputLocale(locale string) (string, error) {
+	if locale == "" {
+		return "", nil
+	}
+	return models.CanonicalLocale(locale)
+}
+
+// meetsLocale excludes text models whose known score for the output locale is
+// below minLocaleScore; models with no evidence stay eligible
+func (ere *EnhancedRecommendationEngine) meetsLocale(model models.EnhancedModel, req RecommendationRequest) bool {
+	if req.OutputLocale == "" || req.TaskType != "text" {
+		return true
+	}
+	support, known := model.LocaleSupport(req.OutputLocale)
+	return !known || support.Score >= minLocaleScore
+}
+
+// applyLocale records the model's score for the output locale and returns the
+// factor to scale the overall score by, with any warnings
+func (ere *EnhancedRecommendationEngine) applyLocale(model models.EnhancedModel, req RecommendationRequest, components map[string]float64) (float64, []string) {
+	if req.OutputLocale == "" || req.TaskType != "text" {
+		return 1, nil
+	}
+
+	support, known := model.LocaleSupport(req.OutputLocale)
+	if !known {
+		return unknownLocaleFactor, []string{fmt.Sprintf("No locale data; output quality in %s is unverified", req.OutputLocale)}
+	}
+	components["locale"] = support.Score
+
+	var warnings []string
+	if support.Source == models.LocaleSourceModelCard || support.Source == models.LocaleSourceMultilingual {
+		warnings = append(warnings, fmt.Sprintf("Claims %s support (%s) without a measured score", req.OutputLocale, support.Source))
+	}
+	return 1 - localeWeight*(1-support.Score), warnings
+}
@@ -0,0 +1,33 @@
+package recommendation
+
+import (
+	"fmt"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// degradedFactor downranks models whose provider reports an incident, by impact
+var degradedFactor = map[string]float64{
+	"minor": 0.9,
+	"major": 0.7,
+}
+
+// isAvailable excludes models whose provider reports an outage for them
+func (ere *EnhancedRecommendationEngine) isAvailable(model models.EnhancedModel) bool {
+	return model.Status == nil || model.Status.State != models.StateOutage
+}
+
+// applyOutage returns the score factor and warning for a model affected by an
+// ongoing provider incident
+func (ere *EnhancedRecommendationEngine) applyOutage(model models.EnhancedModel) (float64, []string) {
+	if model.Status == nil {
+		return 1.0, nil
+	}
+
+	factor, ok := degradedFactor[model.Status.Impact]
+	if !ok {
+		factor = 0.95
+	}
+	warning := fmt.Sprintf("Provider incident in progress (%s impact): %s", model.Status.Impact, model.Status.Incident)
+	return factor, []string{warning}
+}
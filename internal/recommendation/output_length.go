@@ -0,0 +1,211 @@
+package recommendation
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// Where an output length estimate came from
+const (
+	OutputSourceExplicit  = "explicit"  // The prompt asks for a length, e.g. "in 500 words"
+	OutputSourceHistory   = "history"   // Observed generation lengths in the category
+	OutputSourceHeuristic = "heuristic" // Task type, category and complexity
+)
+
+const (
+	// minExpectedOutputTokens and maxExpectedOutputTokens bound estimates to
+	// what a single answer plausibly runs
+	minExpectedOutputTokens = 16
+	maxExpectedOutputTokens = 32000
+	// tokensPerWord is the usual English ratio of tokens to words
+	tokensPerWord = 4.0 / 3.0
+)
+
+// categoryOutputTokens is the typical answer length per category at medium
+// complexity, before any history is available
+var categoryOutputTokens = map[string]int{
+	models.CapabilityCoding:        900,
+	models.CapabilityMath:          500,
+	models.CapabilityReasoning:     600,
+	models.CapabilityWriting:       800,
+	models.CapabilityCreative:      900,
+	models.CapabilityAnalysis:      800,
+	models.CapabilityResearch:      1000,
+	models.CapabilityConversation:  250,
+	models.CapabilityTranslation:   400,
+	models.CapabilitySummarization: 300,
+	models.CapabilityGrounding:     400,
+	models.CapabilityVisionInput:   300,
+}
+
+// complexityOutputScale stretches answers for harder tasks
+var complexityOutputScale = map[string]float64{
+	"simple":  0.5,
+	"medium":  1.0,
+	"complex": 1.5,
+	"hard":    1.5,
+	"expert":  2.0,
+}
+
+// OutputHistory reports the typical generation length observed per category
+type OutputHistory interface {
+	ExpectedOutputTokens(category string) (int, bool)
+}
+
+// SetOutputHistory bases output length estimates on observed generations
+func (ere *EnhancedRecommendationEngine) SetOutputHistory(history OutputHistory) {
+	ere.outputHistory = history
+}
+
+// OutputEstimate is the expected answer length for a request
+type OutputEstimate struct {
+	Tokens int    `json:"tokens"`
+	Source string `json:"source"`
+}
+
+// lengthUnits converts a requested count of each unit to tokens
+var lengthUnits = []struct {
+	re     *regexp.Regexp
+	tokens float64
+}{
+	{regexp.MustCompile(`(?i)\b(\d[\d,]*|` + numberWords + `)(?:\s*(?:-|to)\s*(\d[\d,]*))?[\s-]*tokens?\b`), 1},
+	{regexp.MustCompile(`(?i)\b(\d[\d,]*|` + numberWords + `)(?:\s*(?:-|to)\s*(\d[\d,]*))?[\s-]*words?\b`), tokensPerWord},
+	{regexp.MustCompile(`(?i)\b(\d[\d,]*|` + numberWords + `)(?:\s*(?:-|to)\s*(\d[\d,]*))?[\s-]*(?:characters?|chars)\b`), 0.25},
+	{regexp.MustCompile(`(?i)\b(\d[\d,]*|` + numberWords + `)(?:\s*(?:-|to)\s*(\d[\d,]*))?[\s-]*(?:sentences?|lines?|tweets?)\b`), 25},
+	{regexp.MustCompile(`(?i)\b(\d[\d,]*|` + numberWords + `)(?:\s*(?:-|to)\s*(\d[\d,]*))?[\s-]*(?:bullet points?|bullets?|examples?|ideas?|tips?)\b`), 40},
+	{regexp.MustCompile(`(?i)\b(\d[\d,]*|` + numberWords + `)(?:\s*(?:-|to)\s*(\d[\d,]*))?[\s-]*paragraphs?\b`), 120},
+	{regexp.MustCompile(`(?i)\b(\d[\d,]*|` + numberWords + `)(?:\s*(?:-|to)\s*(\d[\d,]*))?[\s-]*pages?\b`), 650},
+}
+
+// numberWords are the spelled-out counts recognized; "a" and "an" are left
+// out, since "an example" says nothing about length
+const numberWords = `one|two|three|four|five|six|seven|eight|nine|ten|twelve|fifteen|twenty|fifty|hundred`
+
+var numberWordValues = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7,
+	"eight": 8, "nine": 9, "ten": 10, "twelve": 12, "fifteen": 15, "twenty": 20, "fifty": 50, "hundred": 100,
+}
+
+// Phrases asking for a short or a long answer without a number
+var (
+	briefAnswer    = regexp.MustCompile(`(?i)\b(?:briefly|brief|concise(?:ly)?|in short|tl;?dr|one[- ]liner|short answer|yes or no|just the (?:answer|number|code))\b`)
+	detailedAnswer = regexp.MustCompile(`(?i)\b(?:in (?:great )?detail|detailed|comprehensive(?:ly)?|thorough(?:ly)?|in depth|in-depth|step[- ]by[- ]step|exhaustive)\b`)
+)
+
+// EstimateOutputTokens predicts how many tokens the answer to a prompt runs.
+// An explicit length in the prompt wins; otherwise the category's observed
+// generation length, or its typical length, is scaled by complexity and by
+// any request for a brief or detailed answer. Translations and summaries
+// follow the input's length. prompt may be empty for direct requests.
+func EstimateOutputTokens(prompt string, req RecommendationRequest, history OutputHistory) OutputEstimate {
+	if tokens, ok := explicitLength(prompt); ok {
+		return OutputEstimate{Tokens: clampOutputTokens(tokens), Source: OutputSourceExplicit}
+	}
+
+	category := models.CanonicalCapability(req.Category)
+	estimate := OutputEstimate{Tokens: defaultExpectedOutputTokens, Source: OutputSourceHeuristic}
+	if base, ok := categoryOutputTokens[category]; ok {
+		estimate.Tokens = base
+	}
+	promptTokens := int(math.Ceil(float64(len([]rune(prompt))) / 4))
+	switch category {
+	case models.CapabilityTranslation:
+		if promptTokens > 0 {
+			estimate.Tokens = promptTokens
+		}
+	case models.CapabilitySummarization:
+		if promptTokens > 0 {
+			estimate.Tokens = promptTokens / 5
+			if estimate.Tokens < 100 {
+				estimate.Tokens = 100
+			}
+		}
+	}
+	if history != nil {
+		observed, ok := history.ExpectedOutputTokens(req.Category)
+		if !ok {
+			observed, ok = history.ExpectedOutputTokens(category)
+		}
+		if ok && observed > 0 {
+			estimate = OutputEstimate{Tokens: observed, Source: OutputSourceHistory}
+		}
+	}
+
+	scale := 1.0
+	if s, ok := complexityOutputScale[req.Complexity]; ok {
+		scale = s
+	}
+	switch {
+	case briefAnswer.MatchString(prompt):
+		scale *= 0.3
+	case detailedAnswer.MatchString(prompt):
+		scale *= 1.5
+	}
+	estimate.Tokens = clampOutputTokens(int(math.Round(float64(estimate.Tokens) * scale)))
+	return estimate
+}
+
+// explicitLength finds a requested answer length in the prompt, taking the
+// upper end of ranges such as "300-500 words"
+func explicitLength(prompt string) (int, bool) {
+	if prompt == "" {
+		return 0, false
+	}
+	for _, unit := range lengthUnits {
+		m := unit.re.FindStringSubmatch(prompt)
+		if m == nil {
+			continue
+		}
+		count := parseCount(m[1])
+		if upper := parseCount(m[2]); upper > count {
+			count = upper
+		}
+		if count <= 0 {
+			continue
+		}
+		return int(math.Ceil(float64(count) * unit.tokens)), true
+	}
+	return 0, false
+}
+
+func parseCount(s string) int {
+	if s == "" {
+		return 0
+	}
+	if n, ok := numberWordValues[strings.ToLower(s)]; ok {
+		return n
+	}
+	n, err := strconv.Atoi(strings.ReplaceAll(s, ",", ""))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func clampOutputTokens(tokens int) int {
+	if tokens < minExpectedOutputTokens {
+		return minExpectedOutputTokens
+	}
+	if tokens > maxExpectedOutputTokens {
+		return maxExpectedOutputTokens
+	}
+	return tokens
+}
+
+// EstimateOutput estimates the answer length for a prompt with the engine's
+// output history
+func (ere *EnhancedRecommendationEngine) EstimateOutput(prompt string, req RecommendationRequest) OutputEstimate {
+	return EstimateOutputTokens(prompt, req, ere.outputHistory)
+}
+
+// expectedOutputTokens is the answer length costs and latency are estimated for
+func (ere *EnhancedRecommendationEngine) expectedOutputTokens(req RecommendationRequest) int {
+	if req.ExpectedOutputTokens > 0 {
+		return req.ExpectedOutputTokens
+	}
+	return EstimateOutputTokens("", req, ere.outputHistory).Tokens
+}
@@ -0,0 +1,90 @@
+package recommendation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+const (
+	// LatencyRealtime needs an answer within the request; the default
+	LatencyRealtime = "realtime"
+	// LatencyBatch accepts results within a batch tier's turnaround window
+	LatencyBatch = "batch"
+)
+
+// PriceTierSuggestion is a discounted tier the caller can run the model on
+type PriceTierSuggestion struct {
+	Name                 string     `json:"name"`
+	CostEstimate         float64    `json:"cost_estimate"`
+	RealtimeCostEstimate float64    `json:"realtime_cost_estimate"`
+	Savings              float64    `json:"savings"` // Fraction of the realtime cost saved
+	TurnaroundHours      float64    `json:"turnaround_hours,omitempty"`
+	AvailableUntil       *time.Time `json:"available_until,omitempty"` // End of the current off-peak window
+}
+
+// ValidateLatencyTolerance rejects unknown tolerances and batch requests that
+// also set a realtime latency SLO
+func ValidateLatencyTolerance(tolerance string, maxLatencyMs int) error {
+	switch tolerance {
+	case "", LatencyRealtime:
+		return nil
+	case LatencyBatch:
+		if maxLatencyMs > 0 {
+			return fmt.Errorf("latency_tolerance %q cannot be combined with max_latency_ms", tolerance)
+		}
+		return nil
+	}
+	return fmt.Errorf("latency_tolerance must be %q or %q", LatencyRealtime, LatencyBatch)
+}
+
+// applyPriceTier picks the cheapest text tier the request can use at the
+// given time and returns the model priced on it, or the model unchanged
+func (ere *EnhancedRecommendationEngine) applyPriceTier(model models.EnhancedModel, req RecommendationRequest, at time.Time) (models.EnhancedModel, *models.PriceTier) {
+	if req.TaskType != "text" || model.Pricing.Text.CostOutPer1K == nil {
+		return model, nil
+	}
+
+	var best *models.PriceTier
+	bestCost := *model.Pricing.Text.CostOutPer1K
+	tiers := model.PriceTiers()
+	for i := range tiers {
+		tier := &tiers[i]
+		if tier.Name == models.PriceTierBatch && req.LatencyTolerance != LatencyBatch {
+			continue
+		}
+		if !tier.ActiveAt(at) {
+			continue
+		}
+		if cost := tier.TextPricing(model.Pricing.Text).CostOutPer1K; cost != nil && *cost < bestCost {
+			best, bestCost = tier, *cost
+		}
+	}
+	if best == nil {
+		return model, nil
+	}
+	model.Pricing.Text = best.TextPricing(model.Pricing.Text)
+	return model, best
+}
+
+// suggestPriceTier describes the tier a model was priced on
+func suggestPriceTier(tier *models.PriceTier, cost, realtimeCost float64, at time.Time) *PriceTierSuggestion {
+	if tier == nil {
+		return nil
+	}
+	suggestion := &PriceTierSuggestion{
+		Name:                 tier.Name,
+		CostEstimate:         cost,
+		RealtimeCostEstimate: realtimeCost,
+		TurnaroundHours:      tier.TurnaroundHours,
+	}
+	if realtimeCost > 0 {
+		suggestion.Savings = 1 - cost/realtimeCost
+	}
+	if tier.Window != nil {
+		until := tier.Window.EndsAfter(at)
+		suggestion.AvailableUntil = &until
+	}
+	return suggestion
+}
@@ -0,0 +1,66 @@
+package recommendation
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// defaultProcurementWeight is the procurement component's weight when
+// PROCUREMENT_WEIGHT is unset
+const defaultProcurementWeight = 0.1
+
+// ProcurementScorer favors providers the organization has contracts with.
+// Providers without a preference score neutral.
+type ProcurementScorer struct {
+	preferences map[string]float64
+	weight      float64
+}
+
+func NewProcurementScorer(preferences map[string]float64, weight float64) *ProcurementScorer {
+	return &ProcurementScorer{preferences: preferences, weight: weight}
+}
+
+func (p *ProcurementScorer) Name() string { return "procurement" }
+
+func (p *ProcurementScorer) Weight() float64 { return p.weight }
+
+func (p *ProcurementScorer) Score(model models.EnhancedModel, req RecommendationRequest) float64 {
+	if preference, ok := p.preferences[strings.ToLower(model.Provider)]; ok {
+		return preference
+	}
+	return 0.5
+}
+
+// ProcurementScorerFromEnv reads PROCUREMENT_PREFERENCES as comma-separated
+// provider=preference pairs in [0, 1] and PROCUREMENT_WEIGHT. It returns nil
+// when no preferences are configured.
+func ProcurementScorerFromEnv() (*ProcurementScorer, error) {
+	raw := strings.TrimSpace(os.Getenv("PROCUREMENT_PREFERENCES"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	preferences := make(map[string]float64)
+	for _, pair := range strings.Split(raw, ",") {
+		provider, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		preference, err := strconv.ParseFloat(value, 64)
+		if !ok || provider == "" || err != nil || preference < 0 || preference > 1 {
+			return nil, fmt.Errorf("invalid PROCUREMENT_PREFERENCES entry %q, expected provider=preference in [0, 1]", pair)
+		}
+		preferences[strings.ToLower(provider)] = preference
+	}
+
+	weight := defaultProcurementWeight
+	if v := os.Getenv("PROCUREMENT_WEIGHT"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PROCUREMENT_WEIGHT %q: %w", v, err)
+		}
+		weight = w
+	}
+	return NewProcurementScorer(preferences, weight), nil
+}
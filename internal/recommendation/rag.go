@@ -0,0 +1,110 @@
+package recommendation
+
+import (
+	"fmt"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// CapabilityGrounding is the text task capability for answering from supplied
+// context: staying faithful to retrieved chunks and finding facts in long inputs
+const CapabilityGrounding = models.CapabilityGrounding
+
+const (
+	// defaultGroundingScore is used for models without a grounding capability
+	defaultGroundingScore = 0.6
+	// ragGroundingWeight is the share of the capability score taken by grounding on RAG requests
+	ragGroundingWeight = 0.4
+	// ragCrowdedContext is the context window share above which recall degrades
+	ragCrowdedContext = 0.75
+	// ragCrowdedPenalty scales the score of models whose window is crowded by retrieval
+	ragCrowdedPenalty = 0.95
+	// maxRAGContextTokens bounds hints to a plausible size
+	maxRAGContextTokens = 10_000_000
+)
+
+// RAGHint tells the router the prompt will be sent with retrieved chunks
+type RAGHint struct {
+	TopK         int `json:"top_k"`                   // Chunks retrieved per request
+	ChunkTokens  int `json:"chunk_tokens"`            // Expected tokens per chunk
+	PromptTokens int `json:"prompt_tokens,omitempty"` // Tokens besides the chunks; estimated from the prompt when omitted
+}
+
+// RetrievedTokens is the size of the retrieved context
+func (h RAGHint) RetrievedTokens() int {
+	return h.TopK * h.ChunkTokens
+}
+
+// ContextTokens is the context window a model needs: prompt, chunks and output
+func (h RAGHint) ContextTokens() int {
+	return h.PromptTokens + h.RetrievedTokens() + defaultExpectedOutputTokens
+}
+
+// ValidateRAG rejects hints that cannot describe a retrieval setup
+func ValidateRAG(h *RAGHint) error {
+	if h == nil {
+		return nil
+	}
+	if h.TopK <= 0 || h.ChunkTokens <= 0 {
+		return fmt.Errorf("rag.top_k and rag.chunk_tokens must be positive")
+	}
+	if h.PromptTokens < 0 {
+		return fmt.Errorf("rag.prompt_tokens must not be negative")
+	}
+	if h.TopK > maxRAGContextTokens/h.ChunkTokens || h.ContextTokens() > maxRAGContextTokens {
+		return fmt.Errorf("rag context must not exceed %d tokens", maxRAGContextTokens)
+	}
+	return nil
+}
+
+// fitsRAGContext excludes models whose known context window cannot hold the
+// prompt, the retrieved chunks and the answer
+func (ere *EnhancedRecommendationEngine) fitsRAGContext(model models.EnhancedModel, req RecommendationRequest) bool {
+	if req.RAG == nil || model.TechnicalSpecs.ContextWindow <= 0 {
+		return true
+	}
+	return model.TechnicalSpecs.ContextWindow >= req.RAG.ContextTokens()
+}
+
+// groundingScore returns the model's grounding capability, if its profile has one
+func groundingScore(model models.EnhancedModel) (float64, bool) {
+	taskCap, ok := model.TaskCapabilities.TextTasks[CapabilityGrounding]
+	return taskCap.Score, ok
+}
+
+// applyRAG blends grounding into the capability score of RAG requests and
+// returns the factor to scale the overall score by, with any warnings
+func (ere *EnhancedRecommendationEngine) applyRAG(model models.EnhancedModel, req RecommendationRequest, components map[string]float64) (float64, []string) {
+	if req.RAG == nil || req.TaskType != "text" {
+		return 1, nil
+	}
+
+	var warnings []string
+	grounding, ok := groundingScore(model)
+	if !ok {
+		grounding = defaultGroundingScore
+		warnings = append(warnings, "No grounding data; retrieval faithfulness is unmeasured")
+	}
+	components[CapabilityGrounding] = grounding
+	components["capability"] = (1-ragGroundingWeight)*components["capability"] + ragGroundingWeight*grounding
+
+	window := model.TechnicalSpecs.ContextWindow
+	if window <= 0 {
+		warnings = append(warnings, fmt.Sprintf("Context window unknown; retrieval needs about %d tokens", req.RAG.ContextTokens()))
+		return 1, warnings
+	}
+	used := float64(req.RAG.ContextTokens()) / float64(window)
+	if used > ragCrowdedContext {
+		warnings = append(warnings, fmt.Sprintf("Retrieved context fills %.0f%% of the context window", used*100))
+		return ragCrowdedPenalty, warnings
+	}
+	return 1, warnings
+}
+
+// ragInputCost prices the retrieved context sent with each request
+func ragInputCost(model models.EnhancedModel, req RecommendationRequest) float64 {
+	if req.RAG == nil || model.Pricing.Text.CostInPer1K == nil {
+		return 0
+	}
+	return float64(req.RAG.PromptTokens+req.RAG.RetrievedTokens()) / 1000.0 * *model.Pricing.Text.CostInPer1K
+}
@@ -0,0 +1,120 @@
+package recommendation
+
+import (
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// effortOrder ranks reasoning effort levels from none to high
+var effortOrder = map[string]int{
+	"none":    0,
+	"minimal": 1,
+	"low":     2,
+	"medium":  3,
+	"high":    4,
+}
+
+// ValidReasoningEffort reports whether effort is empty or a known effort level
+func ValidReasoningEffort(effort string) bool {
+	if effort == "" {
+		return true
+	}
+	_, ok := effortOrder[effort]
+	return ok
+}
+
+// defaultReasoningTokens is assumed when a variant does not report its usage
+var defaultReasoningTokens = map[string]int{
+	"minimal": 256,
+	"low":     1024,
+	"medium":  4096,
+	"high":    16384,
+}
+
+// ReasoningSuggestion is the effort level recommended for a model and what it costs
+type ReasoningSuggestion struct {
+	Effort               string  `json:"effort"`
+	ThinkingBudgetTokens int     `json:"thinking_budget_tokens,omitempty"`
+	ReasoningTokens      int     `json:"estimated_reasoning_tokens"`
+	CostEstimate         float64 `json:"cost_estimate"`
+	LatencyMs            float64 `json:"latency_ms,omitempty"`
+}
+
+// selectReasoningVariant picks the cheapest variant that reaches the required
+// depth, or the deepest available when none does
+func selectReasoningVariant(model models.EnhancedModel, depth string) (models.ReasoningVariant, bool) {
+	required := effortOrder[depth]
+	if required == 0 || len(model.ReasoningEfforts) == 0 {
+		return models.ReasoningVariant{}, false
+	}
+
+	var best, deepest models.ReasoningVariant
+	found := false
+	for _, v := range model.ReasoningEfforts {
+		level := effortOrder[v.Effort]
+		if level >= required && (!found || level < effortOrder[best.Effort]) {
+			best = v
+			found = true
+		}
+		if level > effortOrder[deepest.Effort] {
+			deepest = v
+		}
+	}
+	if !found {
+		best = deepest
+	}
+	return best, best.Effort != ""
+}
+
+func reasoningTokens(v models.ReasoningVariant) int {
+	if v.AvgReasoningTokens > 0 {
+		return v.AvgReasoningTokens
+	}
+	if v.ThinkingBudgetTokens > 0 {
+		return v.ThinkingBudgetTokens / 2
+	}
+	return defaultReasoningTokens[v.Effort]
+}
+
+// suggestReasoning prices the selected effort level: reasoning tokens are
+// billed as output on top of the expected answer
+func (ere *EnhancedRecommendationEngine) suggestReasoning(model models.EnhancedModel, depth string, outputTokens int) *ReasoningSuggestion {
+	variant, ok := selectReasoningVariant(model, depth)
+	if !ok {
+		return nil
+	}
+
+	tokens := reasoningTokens(variant)
+	suggestion := &ReasoningSuggestion{
+		Effort:               variant.Effort,
+		ThinkingBudgetTokens: variant.ThinkingBudgetTokens,
+		ReasoningTokens:      tokens,
+	}
+
+	costOut := variant.CostOutPer1K
+	if costOut == nil {
+		costOut = model.Pricing.Text.CostOutPer1K
+	}
+	if costOut != nil {
+		suggestion.CostEstimate = float64(outputTokens+tokens) / 1000.0 * *costOut
+	}
+
+	if estimate, ok := ere.reasoningLatency(model, variant, outputTokens); ok {
+		suggestion.LatencyMs = estimate.TotalMs
+	}
+
+	return suggestion
+}
+
+// reasoningLatency estimates latency including thinking tokens and any variant TTFT
+func (ere *EnhancedRecommendationEngine) reasoningLatency(model models.EnhancedModel, variant models.ReasoningVariant, outputTokens int) (LatencyEstimate, bool) {
+	estimate, ok := ere.estimateLatency(model, outputTokens+reasoningTokens(variant))
+	if !ok {
+		return estimate, false
+	}
+	if variant.TimeToFirstTokenMs != nil {
+		ttft := float64(*variant.TimeToFirstTokenMs) + estimate.ColdStartProbability*estimate.ColdStartMs
+		estimate.TotalMs += ttft - estimate.TimeToFirstTokenMs
+		estimate.TimeToFirstTokenMs = ttft
+	}
+	return estimate, true
+}
@@ -0,0 +1,214 @@
+package recommendation
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// builtinComponents are the engine's own score components; plugin scorers
+// may not reuse their names
+var builtinComponents = map[string]bool{
+	"capability": true, "complexity": true, "performance": true, "community": true,
+	"benchmark": true, "personalization": true, "tags": true, "locale": true,
+}
+
+// ComponentScorer is a deployment-specific score component, such as an
+// internal compliance rating or a procurement preference. Score returns a
+// value in [0, 1]; Weight is the component's share relative to the built-in
+// components, whose weights sum to 1, and the weighted sum is renormalized so
+// overall scores stay in [0, 1].
+type ComponentScorer interface {
+	Name() string
+	Score(model models.EnhancedModel, req RecommendationRequest) float64
+	Weight() float64
+}
+
+// ScorerStats reports one registered scorer and how it has behaved
+type ScorerStats struct {
+	Name          string  `json:"name"`
+	Order         int     `json:"order"`
+	Weight        float64 `json:"weight"`
+	Calls         int64   `json:"calls"`
+	Failures      int64   `json:"failures"` // Panics and scores outside [0, 1]
+	MeanScore     float64 `json:"mean_score"`
+	MeanLatencyUs float64 `json:"mean_latency_us"`
+	LastFailure   string  `json:"last_failure,omitempty"`
+}
+
+// registeredScorer is a scorer with its position and running metrics
+type registeredScorer struct {
+	scorer ComponentScorer
+	order  int
+
+	mu          sync.Mutex
+	calls       int64
+	failures    int64
+	scoreSum    float64
+	latency     time.Duration
+	lastFailure string
+}
+
+// ScorerRegistry holds the plugin scorers, run in ascending order and then
+// by name so results do not depend on registration timing
+type ScorerRegistry struct {
+	mu      sync.RWMutex
+	scorers []*registeredScorer
+}
+
+func NewScorerRegistry() *ScorerRegistry {
+	return &ScorerRegistry{}
+}
+
+// Register adds a scorer at the given order. Names must be unique and must not
+// shadow a built-in component; weights must be in (0, 1].
+func (r *ScorerRegistry) Register(scorer ComponentScorer, order int) error {
+	name := scorer.Name()
+	if name == "" {
+		return fmt.Errorf("scorer name is required")
+	}
+	if builtinComponents[name] || strings.HasPrefix(name, tagComponentPrefix) {
+		return fmt.Errorf("scorer name %q is a built-in component", name)
+	}
+	if w := scorer.Weight(); !(w > 0 && w <= 1) {
+		return fmt.Errorf("scorer %s weight must be in (0, 1], got %v", name, w)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.scorers {
+		if existing.scorer.Name() == name {
+			return fmt.Errorf("scorer %s is already registered", name)
+		}
+	}
+	scorers := append(append([]*registeredScorer{}, r.scorers...), &registeredScorer{scorer: scorer, order: order})
+	sort.SliceStable(scorers, func(i, j int) bool {
+		if scorers[i].order != scorers[j].order {
+			return scorers[i].order < scorers[j].order
+		}
+		return scorers[i].scorer.Name() < scorers[j].scorer.Name()
+	})
+	r.scorers = scorers
+	log.Printf("[SCORING] Registered scorer %s (order %d, weight %.3f)", name, order, scorer.Weight())
+	return nil
+}
+
+// Unregister removes a scorer, reporting whether it was registered
+func (r *ScorerRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.scorers {
+		if existing.scorer.Name() == name {
+			r.scorers = append(append([]*registeredScorer{}, r.scorers[:i]...), r.scorers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the registered scorers in run order with their metrics
+func (r *ScorerRegistry) Stats() []ScorerStats {
+	stats := []ScorerStats{}
+	for _, s := range r.snapshot() {
+		s.mu.Lock()
+		st := ScorerStats{
+			Name:        s.scorer.Name(),
+			Order:       s.order,
+			Weight:      s.scorer.Weight(),
+			Calls:       s.calls,
+			Failures:    s.failures,
+			LastFailure: s.lastFailure,
+		}
+		if scored := s.calls - s.failures; scored > 0 {
+			st.MeanScore = s.scoreSum / float64(scored)
+		}
+		if s.calls > 0 {
+			st.MeanLatencyUs = float64(s.latency.Microseconds()) / float64(s.calls)
+		}
+		s.mu.Unlock()
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+// snapshot returns the scorers to run for one request
+func (r *ScorerRegistry) snapshot() []*registeredScorer {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.scorers
+}
+
+// weights returns each scorer's weight, for response metadata
+func (r *ScorerRegistry) weights(into map[string]float64) map[string]float64 {
+	for _, s := range r.snapshot() {
+		into[s.scorer.Name()] = s.scorer.Weight()
+	}
+	return into
+}
+
+// apply runs the scorers on a model, records their components and folds
+// them into the built-in weighted score. Failed scorers are left out of both
+// the sum and the normalization.
+func (r *ScorerRegistry) apply(model models.EnhancedModel, req RecommendationRequest, components map[string]float64, builtinScore float64) float64 {
+	scorers := r.snapshot()
+	if len(scorers) == 0 {
+		return builtinScore
+	}
+
+	total, weight := builtinScore, 1.0
+	for _, s := range scorers {
+		score, ok := s.run(model, req)
+		if !ok {
+			continue
+		}
+		w := s.scorer.Weight()
+		components[s.scorer.Name()] = score
+		total += score * w
+		weight += w
+	}
+	return total / weight
+}
+
+// run calls the scorer, recovering from panics and rejecting invalid scores
+func (s *registeredScorer) run(model models.EnhancedModel, req RecommendationRequest) (score float64, ok bool) {
+	start := time.Now()
+	failure := ""
+	defer func() {
+		if p := recover(); p != nil {
+			failure, ok = fmt.Sprintf("panic: %v", p), false
+		}
+		s.record(score, time.Since(start), failure)
+	}()
+
+	score = s.scorer.Score(model, req)
+	if math.IsNaN(score) || score < 0 || score > 1 {
+		failure = fmt.Sprintf("score %v for %s is outside [0, 1]", score, model.ID)
+		return score, false
+	}
+	return score, true
+}
+
+func (s *registeredScorer) record(score float64, latency time.Duration, failure string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	s.latency += latency
+	if failure != "" {
+		s.failures++
+		if s.lastFailure != failure {
+			log.Printf("[SCORING] Scorer %s failed: %s", s.scorer.Name(), failure)
+		}
+		s.lastFailure = failure
+		return
+	}
+	s.scoreSum += score
+}
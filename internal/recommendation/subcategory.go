@@ -0,0 +1,31 @@
+package recommendation
+
+import (
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// SplitCategory resolves a request's category, which may be given as
+// "writing/marketing_copy" or a bare subcategory, into its category and
+// subcategory. An explicit subcategory wins over one named by the category.
+func SplitCategory(category, subcategory string) (string, string) {
+	category, named := models.SplitCapability(category)
+	if subcategory != "" {
+		return category, models.CanonicalSubcategory(subcategory)
+	}
+	return category, named
+}
+
+// subcategoryCapability returns a model's curated score for a subcategory of a
+// text category, when its profile has one. Without it callers fall back to
+// the model's score for the whole category.
+func subcategoryCapability(model models.EnhancedModel, category, subcategory string) (float64, bool) {
+	if subcategory == "" {
+		return 0, false
+	}
+	taskCap, ok := model.TaskCapabilities.TextTasks[category]
+	if !ok {
+		return 0, false
+	}
+	score, ok := taskCap.Subcategories[subcategory]
+	return score, ok
+}
@@ -0,0 +1,170 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+const (
+	// defaultTagBoost is added to a model's overall score per preferred tag it carries
+	defaultTagBoost = 0.05
+	// defaultTagPenalty is subtracted per avoided tag it carries
+	defaultTagPenalty = 0.08
+	// defaultMaxTagAdjustment bounds the net adjustment so tags only reorder close calls
+	defaultMaxTagAdjustment = 0.15
+	// maxRequestTags bounds preferred_tags and avoided_tags each
+	maxRequestTags = 10
+	// tagComponentPrefix names each matched tag's entry in component scores
+	tagComponentPrefix = "tag:"
+)
+
+// tagAliases maps catalog tag spellings to the tag callers ask for
+var tagAliases = map[string]string{
+	"cost_effective": "cheap",
+	"affordable":     "cheap",
+	"budget":         "cheap",
+	"open":           "open_source",
+	"closed":         "closed_source",
+	"proprietary":    "closed_source",
+	"agent":          "agentic",
+	"agents":         "agentic",
+}
+
+// TagWeights are the soft adjustments applied for tag matches
+type TagWeights struct {
+	Boost   float64 // Per preferred tag the model carries
+	Penalty float64 // Per avoided tag the model carries
+	Max     float64 // Bound on the net adjustment either way
+}
+
+// DefaultTagWeights returns the built-in adjustments; ROUTER_TAG_BOOST,
+// ROUTER_TAG_PENALTY and ROUTER_TAG_MAX_ADJUSTMENT tune them
+func DefaultTagWeights() TagWeights {
+	weights := TagWeights{Boost: defaultTagBoost, Penalty: defaultTagPenalty, Max: defaultMaxTagAdjustment}
+	if v, err := strconv.ParseFloat(os.Getenv("ROUTER_TAG_BOOST"), 64); err == nil && v >= 0 && v <= 1 {
+		weights.Boost = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("ROUTER_TAG_PENALTY"), 64); err == nil && v >= 0 && v <= 1 {
+		weights.Penalty = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("ROUTER_TAG_MAX_ADJUSTMENT"), 64); err == nil && v >= 0 && v <= 1 {
+		weights.Max = v
+	}
+	return weights
+}
+
+// SetTagWeights replaces the adjustments applied for preferred and avoided tags
+func (ere *EnhancedRecommendationEngine) SetTagWeights(weights TagWeights) {
+	ere.tagWeights = weights
+}
+
+// CanonicalTag folds case, separators and known aliases, so "Open-Source"
+// and "open_source" match
+func CanonicalTag(tag string) string {
+	key := strings.ToLower(strings.TrimSpace(tag))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+	if canonical, ok := tagAliases[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// ValidateTags rejects empty tags, too many tags, and tags both preferred and
+// avoided
+func ValidateTags(preferred, avoided []string) error {
+	if len(preferred) > maxRequestTags || len(avoided) > maxRequestTags {
+		return fmt.Errorf("at most %d preferred_tags and %d avoided_tags are allowed", maxRequestTags, maxRequestTags)
+	}
+	seen := make(map[string]bool, len(preferred))
+	for _, tag := range preferred {
+		if CanonicalTag(tag) == "" {
+			return fmt.Errorf("preferred_tags cannot contain empty tags")
+		}
+		seen[CanonicalTag(tag)] = true
+	}
+	for _, tag := range avoided {
+		canonical := CanonicalTag(tag)
+		if canonical == "" {
+			return fmt.Errorf("avoided_tags cannot contain empty tags")
+		}
+		if seen[canonical] {
+			return fmt.Errorf("tag %q cannot be both preferred and avoided", tag)
+		}
+	}
+	return nil
+}
+
+// modelTags returns the model's catalog tags in canonical form, plus tags
+// implied by its metadata
+func modelTags(model models.EnhancedModel) map[string]bool {
+	tags := make(map[string]bool, len(model.Tags)+2)
+	for _, tag := range model.Tags {
+		tags[CanonicalTag(tag)] = true
+	}
+	if model.OpenSource {
+		tags["open_source"] = true
+	} else {
+		tags["closed_source"] = true
+	}
+	if model.Pricing.FreeTier {
+		tags["free_tier"] = true
+	}
+	return tags
+}
+
+// tagAdjustment returns the net score adjustment for the request's tags and
+// each matched tag's share of it, or 0 and nil when no tag matched
+func (ere *EnhancedRecommendationEngine) tagAdjustment(model models.EnhancedModel, req RecommendationRequest) (float64, map[string]float64) {
+	if len(req.PreferredTags) == 0 && len(req.AvoidedTags) == 0 {
+		return 0, nil
+	}
+
+	tags := modelTags(model)
+	matched := make(map[string]float64)
+	var total float64
+	for _, tag := range req.PreferredTags {
+		if canonical := CanonicalTag(tag); tags[canonical] {
+			if _, done := matched[canonical]; !done {
+				matched[canonical] = ere.tagWeights.Boost
+				total += ere.tagWeights.Boost
+			}
+		}
+	}
+	for _, tag := range req.AvoidedTags {
+		if canonical := CanonicalTag(tag); tags[canonical] {
+			if _, done := matched[canonical]; !done {
+				matched[canonical] = -ere.tagWeights.Penalty
+				total -= ere.tagWeights.Penalty
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return 0, nil
+	}
+	return math.Max(-ere.tagWeights.Max, math.Min(total, ere.tagWeights.Max)), matched
+}
+
+// describeTags lists matched tags for reasoning, preferred ones first
+func describeTags(matched map[string]float64) string {
+	names := make([]string, 0, len(matched))
+	for tag := range matched {
+		names = append(names, tag)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (matched[names[i]] > 0) != (matched[names[j]] > 0) {
+			return matched[names[i]] > 0
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, tag := range names {
+		parts[i] = fmt.Sprintf("%s %+.2f", tag, matched[tag])
+	}
+	return strings.Join(parts, ", ")
+}
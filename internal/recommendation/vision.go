@@ -0,0 +1,108 @@
+package recommendation
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// RequirementImageInput is set by the classifier when the prompt refers to an
+// image it carries ("what's in this screenshot")
+const RequirementImageInput = "image_input"
+
+const (
+	// maxImageInputs bounds the images one request may carry
+	maxImageInputs = 20
+	// maxAttachmentBytes bounds a decoded inline image
+	maxAttachmentBytes = 20 << 20
+)
+
+// Attachment is an image sent with the prompt, as an https:// or data: URL or
+// as base64 data. The router only routes on it; it is never fetched or stored.
+type Attachment struct {
+	Type      string `json:"type"`                 // "image"
+	URL       string `json:"url,omitempty"`        // https:// or data:<media type>;base64,<data>
+	Data      string `json:"data,omitempty"`       // Base64 image bytes, as an alternative to URL
+	MediaType string `json:"media_type,omitempty"` // e.g. "image/png"; required with Data
+}
+
+// ValidateAttachments rejects attachments the router cannot route on
+func ValidateAttachments(attachments []Attachment) error {
+	if err := ValidateImageInputs(len(attachments)); err != nil {
+		return err
+	}
+	for i, a := range attachments {
+		if a.Type != "image" {
+			return fmt.Errorf("attachments[%d].type must be \"image\"", i)
+		}
+		if (a.URL == "") == (a.Data == "") {
+			return fmt.Errorf("attachments[%d] needs exactly one of url or data", i)
+		}
+		if a.Data != "" {
+			if !strings.HasPrefix(a.MediaType, "image/") {
+				return fmt.Errorf("attachments[%d].media_type must be an image type", i)
+			}
+			if err := validateImageData(a.Data); err != nil {
+				return fmt.Errorf("attachments[%d].data: %v", i, err)
+			}
+			continue
+		}
+		if err := validateImageURL(a.URL); err != nil {
+			return fmt.Errorf("attachments[%d].url: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// ValidateImageInputs bounds the image count given to direct recommendations
+func ValidateImageInputs(count int) error {
+	if count < 0 {
+		return fmt.Errorf("image_inputs must not be negative")
+	}
+	if count > maxImageInputs {
+		return fmt.Errorf("at most %d images may be attached", maxImageInputs)
+	}
+	return nil
+}
+
+func validateImageURL(url string) error {
+	if strings.HasPrefix(url, "https://") {
+		return nil
+	}
+	if !strings.HasPrefix(url, "data:image/") {
+		return fmt.Errorf("must be an https:// or data:image/ URL")
+	}
+	header, data, ok := strings.Cut(url, ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return fmt.Errorf("data URLs must be base64 encoded")
+	}
+	return validateImageData(data)
+}
+
+func validateImageData(data string) error {
+	if base64.StdEncoding.DecodedLen(len(data)) > maxAttachmentBytes {
+		return fmt.Errorf("image must not exceed %d bytes", maxAttachmentBytes)
+	}
+	if _, err := base64.StdEncoding.DecodeString(data); err != nil {
+		return fmt.Errorf("invalid base64")
+	}
+	return nil
+}
+
+// requiresImageInput reports whether the prompt carries images, counted from
+// attachments or detected by the classifier
+func requiresImageInput(req RecommendationRequest) bool {
+	if req.ImageInputs > 0 {
+		return true
+	}
+	required, _ := req.Requirements[RequirementImageInput].(bool)
+	return required
+}
+
+// readsImagesForText admits multimodal models to text requests that carry
+// images; they answer in text but often have no per-category text scores
+func readsImagesForText(model models.EnhancedModel, req RecommendationRequest) bool {
+	return req.TaskType == "text" && model.ModelType == "multimodal" && requiresImageInput(req)
+}
@@ -0,0 +1,76 @@
+package recommendation
+
+// Weight set variants reported as weights_variant
+const (
+	WeightsDefault   = "default"   // Built-in weights
+	WeightsPromoted  = "promoted"  // Tuned weights an admin promoted
+	WeightsCandidate = "candidate" // Tuned weights under an A/B experiment
+)
+
+// Priorities are the priority modes, each with its own component weights
+var Priorities = []string{"balanced", "quality", "speed", "cost", "green"}
+
+// WeightSource overrides the built-in component weights of a priority. It
+// returns nil to keep the built-in weights, and otherwise names the variant
+// it served subject.
+type WeightSource interface {
+	Weights(priority, subject string) (map[string]float64, string)
+}
+
+// weightsFor returns the component weights that score req and their variant
+func (ere *EnhancedRecommendationEngine) weightsFor(req RecommendationRequest) (map[string]float64, string) {
+	if ere.weightSource != nil {
+		if weights, variant := ere.weightSource.Weights(req.Priority, req.Subject); weights != nil {
+			return weights, variant
+		}
+	}
+	return DefaultWeights(req.Priority), WeightsDefault
+}
+
+// DefaultWeights returns the built-in component weights of a priority;
+// unknown priorities get the balanced weights
+func DefaultWeights(priority string) map[string]float64 {
+	switch priority {
+	case "quality":
+		return map[string]float64{
+			"capability":  0.50,
+			"complexity":  0.25,
+			"performance": 0.10,
+			"community":   0.10,
+			"benchmark":   0.05,
+		}
+	case "speed":
+		return map[string]float64{
+			"capability":  0.30,
+			"complexity":  0.15,
+			"performance": 0.40,
+			"community":   0.10,
+			"benchmark":   0.05,
+		}
+	case "cost":
+		return map[string]float64{
+			"capability":  0.30,
+			"complexity":  0.20,
+			"performance": 0.10,
+			"community":   0.25,
+			"benchmark":   0.15,
+		}
+	case "green":
+		return map[string]float64{
+			"capability":     0.35,
+			"complexity":     0.20,
+			"performance":    0.10,
+			"community":      0.05,
+			"benchmark":      0.05,
+			"sustainability": 0.25,
+		}
+	default: // balanced
+		return map[string]float64{
+			"capability":  0.40,
+			"complexity":  0.25,
+			"performance": 0.20,
+			"community":   0.10,
+			"benchmark":   0.05,
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package replication
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+const (
+	// catalogChannel is the Postgres NOTIFY channel carrying new snapshot IDs
+	catalogChannel = "catalog_updates"
+
+	// leaderLockKey is the advisory lock held by the instance that fetches and fuses
+	leaderLockKey int64 = 0x6c6c6d726f757465 // "llmroute"
+
+	// snapshotsRetained is how many published snapshots are kept for late followers
+	snapshotsRetained = 10
+)
+
+// Status describes this instance's part in catalog replication
+type Status struct {
+	InstanceID     string    `json:"instance_id"`
+	Role           string    `json:"role"` // leader or follower
+	SnapshotID     int64     `json:"snapshot_id"`
+	SnapshotHash   string    `json:"snapshot_hash,omitempty"`
+	LastPublished  time.Time `json:"last_published,omitempty"`
+	LastApplied    time.Time `json:"last_applied,omitempty"`
+	RefreshEvery   string    `json:"refresh_interval"`
+	ListenerActive bool      `json:"listener_active"`
+}
+
+// CatalogReplicator elects one instance to fetch Analytics AI and fuse the catalog,
+// publishes each fused catalog to Postgres, and hot-swaps it into followers.
+// Leadership is a session-level advisory lock, so a standby takes over as soon
+// as the leader's connection drops.
+type CatalogReplicator struct {
+	db       *sql.DB
+	dsn      string
+	fusion   *models.FusionService
+	interval time.Duration
+	alerts   *alerts.Manager
+
+	mu         sync.RWMutex
+	status     Status
+	leaderConn *sql.Conn
+	listener   *pq.Listener
+}
+
+// NewCatalogReplicator creates a replicator. dsn is used for the LISTEN connection.
+func NewCatalogReplicator(db *sql.DB, dsn string, fusion *models.FusionService, interval time.Duration) *CatalogReplicator {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	hostname, _ := os.Hostname()
+	return &CatalogReplicator{
+		db:       db,
+		dsn:      dsn,
+		fusion:   fusion,
+		interval: interval,
+		status: Status{
+			InstanceID:   fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+			Role:         "follower",
+			RefreshEvery: interval.String(),
+		},
+	}
+}
+
+// SetAlerts reports failed leader publishes to operators
+func (r *CatalogReplicator) SetAlerts(manager *alerts.Manager) {
+	r.alerts = manager
+}
+
+// Start runs the replication loop until ctx is cancelled
+func (r *CatalogReplicator) Start(ctx context.Context) {
+	r.startListener()
+	r.tick(ctx)
+
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		var notify <-chan *pq.Notification
+		if r.listener != nil {
+			notify = r.listener.Notify
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				r.stop()
+				return
+			case <-ticker.C:
+				r.tick(ctx)
+				health.Beat(ctx)
+			case n := <-notify:
+				// nil means the listener reconnected and may have missed notifications
+				if n == nil {
+					r.syncLatest(ctx)
+					continue
+				}
+				id, err := strconv.ParseInt(n.Extra, 10, 64)
+				if err != nil {
+					log.Printf("[REPLICATION] Ignoring malformed notification %q", n.Extra)
+					continue
+				}
+				// The leader applies catalogs imported on other replicas too;
+				// its own snapshots are already current and skipped
+				if err := r.apply(ctx, id); err != nil {
+					log.Printf("[REPLICATION] Failed to apply snapshot %d: %v", id, err)
+				}
+			}
+		}
+	}()
+}
+
+// Refresh publishes a new catalog when leading, otherwise pulls the latest snapshot
+func (r *CatalogReplicator) Refresh(ctx context.Context) error {
+	if r.isLeader() {
+		return r.fuseAndPublish(ctx)
+	}
+	return r.syncLatest(ctx)
+}
+
+// Status returns a copy of the replication state
+func (r *CatalogReplicator) Status() interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status
+}
+
+func (r *CatalogReplicator) tick(ctx context.Context) {
+	if !r.isLeader() {
+		r.tryAcquireLeadership(ctx)
+	} else if err := r.leaderConn.PingContext(ctx); err != nil {
+		log.Printf("[REPLICATION] Lost leader connection: %v", err)
+		r.releaseLeadership()
+	}
+
+	if r.isLeader() {
+		if err := r.fuseAndPublish(ctx); err != nil {
+			log.Printf("[REPLICATION] %v", err)
+			r.alerts.Notify(alerts.Event{
+				Type:     alerts.EventJobFailed,
+				Severity: alerts.SeverityCritical,
+				Source:   "catalog_replication",
+				Title:    "Catalog leader failed to publish a snapshot",
+				Message:  err.Error(),
+				Fields:   map[string]string{"instance": r.status.InstanceID},
+			})
+		}
+		return
+	}
+
+	// Followers poll as a safety net for missed notifications
+	if err := r.syncLatest(ctx); err != nil {
+		log.Printf("[REPLICATION] %v", err)
+	}
+}
+
+func (r *CatalogReplicator) isLeader() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.leaderConn != nil
+}
+
+func (r *CatalogReplicator) tryAcquireLeadership(ctx context.Context) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		log.Printf("[REPLICATION] Failed to get connection for leader election: %v", err)
+		return
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, leaderLockKey).Scan(&acquired); err != nil || !acquired {
+		if err != nil {
+			log.Printf("[REPLICATION] Leader election failed: %v", err)
+		}
+		conn.Close()
+		return
+	}
+
+	r.mu.Lock()
+	r.leaderConn = conn
+	r.status.Role = "leader"
+	r.mu.Unlock()
+
+	log.Printf("[REPLICATION] Instance %s is now catalog leader", r.status.InstanceID)
+}
+
+func (r *CatalogReplicator) releaseLeadership() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leaderConn != nil {
+		r.leaderConn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, leaderLockKey)
+		r.leaderConn.Close()
+		r.leaderConn = nil
+	}
+	r.status.Role = "follower"
+}
+
+func (r *CatalogReplicator) fuseAndPublish(ctx context.Context) error {
+	if err := r.fusion.PerformFusion(ctx); err != nil {
+		return fmt.Errorf("failed to fuse catalog: %w", err)
+	}
+
+	fusedAt := r.fusion.LastFusion()
+	payload, err := json.Marshal(r.fusion.GetAllModels())
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	hash := hex.EncodeToString(sum[:])
+
+	// Skip publishing an identical catalog so followers are not churned
+	r.mu.RLock()
+	unchanged := hash == r.status.SnapshotHash
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	var id int64
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO catalog_snapshots (content_hash, payload, model_count, fused_at, published_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		hash, payload, len(r.fusion.GetAllModels()), fusedAt, r.status.InstanceID).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to publish catalog snapshot: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, catalogChannel, strconv.FormatInt(id, 10)); err != nil {
+		log.Printf("[REPLICATION] Failed to notify followers of snapshot %d: %v", id, err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM catalog_snapshots
+		WHERE id < (SELECT MIN(id) FROM (SELECT id FROM catalog_snapshots ORDER BY id DESC LIMIT $1) recent)`,
+		snapshotsRetained); err != nil {
+		log.Printf("[REPLICATION] Failed to prune old snapshots: %v", err)
+	}
+
+	r.mu.Lock()
+	r.status.SnapshotID = id
+	r.status.SnapshotHash = hash
+	r.status.LastPublished = time.Now()
+	r.mu.Unlock()
+
+	log.Printf("[REPLICATION] Published catalog snapshot %d", id)
+	return nil
+}
+
+// PublishImport publishes a catalog an admin imported on this instance, which
+// has already applied it, so every replica adopts it as its base catalog
+func (r *CatalogReplicator) PublishImport(ctx context.Context, catalog []models.EnhancedModel, importedAt time.Time) error {
+	payload, err := json.Marshal(catalog)
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	hash := hex.EncodeToString(sum[:])
+
+	var id int64
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO catalog_snapshots (content_hash, payload, model_count, fused_at, published_by, imported)
+		VALUES ($1, $2, $3, $4, $5, TRUE)
+		RETURNING id`,
+		hash, payload, len(catalog), importedAt, r.status.InstanceID).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to publish imported catalog: %w", err)
+	}
+
+	r.mu.Lock()
+	r.status.SnapshotID = id
+	r.status.SnapshotHash = hash
+	r.status.LastPublished = time.Now()
+	r.mu.Unlock()
+
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, catalogChannel, strconv.FormatInt(id, 10)); err != nil {
+		log.Printf("[REPLICATION] Failed to notify replicas of imported snapshot %d: %v", id, err)
+	}
+	log.Printf("[REPLICATION] Published imported catalog snapshot %d", id)
+	return nil
+}
+
+// syncLatest applies the newest snapshot if it is ahead of the local catalog
+func (r *CatalogReplicator) syncLatest(ctx context.Context) error {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM catalog_snapshots ORDER BY id DESC LIMIT 1`).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up latest snapshot: %w", err)
+	}
+	return r.apply(ctx, id)
+}
+
+func (r *CatalogReplicator) apply(ctx context.Context, id int64) error {
+	r.mu.RLock()
+	current := r.status.SnapshotID
+	r.mu.RUnlock()
+	if id <= current {
+		return nil
+	}
+
+	var hash string
+	var payload []byte
+	var fusedAt time.Time
+	var imported bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT content_hash, payload, fused_at, imported FROM catalog_snapshots WHERE id = $1`, id).Scan(&hash, &payload, &fusedAt, &imported)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot %d: %w", id, err)
+	}
+
+	var catalog []models.EnhancedModel
+	if err := json.Unmarshal(payload, &catalog); err != nil {
+		return fmt.Errorf("failed to decode snapshot %d: %w", id, err)
+	}
+
+	// An imported catalog also becomes the base, so whichever replica leads
+	// next fuses over it instead of the model file
+	if imported {
+		r.fusion.ImportCatalog(catalog, fusedAt)
+	} else {
+		r.fusion.ReplaceModels(catalog, fusedAt)
+	}
+
+	r.mu.Lock()
+	r.status.SnapshotID = id
+	r.status.SnapshotHash = hash
+	r.status.LastApplied = time.Now()
+	r.mu.Unlock()
+
+	log.Printf("[REPLICATION] Applied catalog snapshot %d (%d models)", id, len(catalog))
+	return nil
+}
+
+func (r *CatalogReplicator) startListener() {
+	if r.dsn == "" {
+		log.Println("[REPLICATION] No DSN for LISTEN; followers will poll")
+		return
+	}
+
+	listener := pq.NewListener(r.dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("[REPLICATION] Listener event %d: %v", ev, err)
+		}
+	})
+	if err := listener.Listen(catalogChannel); err != nil {
+		log.Printf("[REPLICATION] Failed to LISTEN on %s, followers will poll: %v", catalogChannel, err)
+		listener.Close()
+		return
+	}
+
+	r.listener = listener
+	r.mu.Lock()
+	r.status.ListenerActive = true
+	r.mu.Unlock()
+}
+
+func (r *CatalogReplicator) stop() {
+	if r.listener != nil {
+		r.listener.Close()
+	}
+	r.releaseLeadership()
+}
@@ -0,0 +1,199 @@
+package ruleoverlay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+)
+
+// maxTestPrompts bounds the prompts one overlay test classifies
+const maxTestPrompts = 20
+
+// Handlers exposes classifier rule overlays on the dashboard
+type Handlers struct {
+	store *Store
+}
+
+func NewHandlers(store *Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// Get returns the caller's overlay and the base rules it is merged over
+func (h *Handlers) Get(c *gin.Context) {
+	o, err := h.store.Get(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load classifier rule overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"overlay":    o,
+			"base_rules": h.store.classifier.Rules(),
+		},
+	})
+}
+
+// Put replaces the caller's overlay. A label it lists replaces that label's
+// base patterns; labels it omits keep them.
+func (h *Handlers) Put(c *gin.Context) {
+	var rules classification.RuleConfig
+	if err := c.ShouldBindJSON(&rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if _, err := h.store.classifier.CompileOverlay(rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid classifier rule overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := h.store.Put(c.Request.Context(), c.GetString("user_id"), rules); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save classifier rule overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Classifier rule overlay saved",
+	})
+}
+
+// Delete removes the caller's overlay
+func (h *Handlers) Delete(c *gin.Context) {
+	if err := h.store.Delete(c.Request.Context(), c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete classifier rule overlay",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Classifier rule overlay deleted",
+	})
+}
+
+// Labels are the parts of a classification an overlay can change
+type Labels struct {
+	TaskType    string `json:"task_type"`
+	Category    string `json:"category"`
+	Subcategory string `json:"subcategory,omitempty"`
+	Complexity  string `json:"complexity"`
+}
+
+// TestResult compares a prompt's classification with and without an overlay
+type TestResult struct {
+	Prompt  string   `json:"prompt"`
+	Base    Labels   `json:"base"`
+	Overlay Labels   `json:"overlay"`
+	Changed []string `json:"changed"`
+}
+
+// Test classifies prompts with the base rules and with an overlay: the one in
+// the request, or the caller's saved overlay, so edits can be tried before
+// they are saved
+func (h *Handlers) Test(c *gin.Context) {
+	var req struct {
+		Prompts []string                   `json:"prompts" binding:"required,min=1"`
+		Rules   *classification.RuleConfig `json:"rules,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(req.Prompts) > maxTestPrompts {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": fmt.Sprintf("at most %d prompts can be tested at once", maxTestPrompts),
+		})
+		return
+	}
+
+	classifier := h.store.classifier
+	var tenant *classification.TenantRules
+	var err error
+	if req.Rules != nil {
+		tenant, err = classifier.CompileOverlay(*req.Rules)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid classifier rule overlay",
+				"details": err.Error(),
+			})
+			return
+		}
+	} else {
+		tenant, err = h.store.RulesFor(c.Request.Context(), c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to load classifier rule overlay",
+				"details": err.Error(),
+			})
+			return
+		}
+		if tenant == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "No classifier rule overlay to test",
+				"details": "send rules, or save an overlay first",
+			})
+			return
+		}
+	}
+
+	results := make([]TestResult, 0, len(req.Prompts))
+	for _, prompt := range req.Prompts {
+		base, _ := classifier.ClassifyPromptWith(context.Background(), prompt, nil)
+		overlaid, _ := classifier.ClassifyPromptWith(context.Background(), prompt, tenant)
+		results = append(results, compare(prompt, base, overlaid))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+func labelsOf(result classification.ClassificationResult) Labels {
+	return Labels{
+		TaskType:    result.TaskType,
+		Category:    result.Category,
+		Subcategory: result.Subcategory,
+		Complexity:  result.Complexity,
+	}
+}
+
+func compare(prompt string, base, overlaid classification.ClassificationResult) TestResult {
+	result := TestResult{Prompt: prompt, Base: labelsOf(base), Overlay: labelsOf(overlaid), Changed: []string{}}
+	if result.Base.TaskType != result.Overlay.TaskType {
+		result.Changed = append(result.Changed, "task_type")
+	}
+	if result.Base.Category != result.Overlay.Category {
+		result.Changed = append(result.Changed, "category")
+	}
+	if result.Base.Subcategory != result.Overlay.Subcategory {
+		result.Changed = append(result.Changed, "subcategory")
+	}
+	if result.Base.Complexity != result.Overlay.Complexity {
+		result.Changed = append(result.Changed, "complexity")
+	}
+	return result
+}
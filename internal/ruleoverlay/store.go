@@ -0,0 +1,131 @@
+package ruleoverlay
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+)
+
+// cacheTTL bounds how long a replica serves a tenant's compiled overlay
+// before re-reading it, so edits made through another replica take effect
+const cacheTTL = 30 * time.Second
+
+// Overlay is a tenant's classifier rules, merged over the base rules at
+// classify time
+type Overlay struct {
+	Rules     classification.RuleConfig `json:"rules"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+}
+
+// cached is a tenant's compiled overlay; rules is nil for tenants without one
+type cached struct {
+	rules   *classification.TenantRules
+	fetched time.Time
+}
+
+// Store persists one classifier rule overlay per tenant and caches each
+// compiled over the active base rules
+type Store struct {
+	db         *sql.DB
+	classifier *classification.TaskClassifier
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+func NewStore(db *sql.DB, classifier *classification.TaskClassifier) *Store {
+	return &Store{db: db, classifier: classifier, cache: make(map[string]cached)}
+}
+
+// Get returns the tenant's overlay, or nil when it has none
+func (s *Store) Get(ctx context.Context, userID string) (*Overlay, error) {
+	var rules []byte
+	o := &Overlay{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT rules, updated_at FROM classifier_rule_overlays WHERE user_id = $1`, userID,
+	).Scan(&rules, &o.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load classifier rule overlay: %w", err)
+	}
+	if err := json.Unmarshal(rules, &o.Rules); err != nil {
+		return nil, fmt.Errorf("failed to parse classifier rule overlay: %w", err)
+	}
+	return o, nil
+}
+
+// Put replaces the tenant's overlay once it compiles over the active rules
+func (s *Store) Put(ctx context.Context, userID string, rules classification.RuleConfig) error {
+	if _, err := s.classifier.CompileOverlay(rules); err != nil {
+		return err
+	}
+	data, _ := json.Marshal(rules)
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO classifier_rule_overlays (user_id, rules)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			rules = EXCLUDED.rules,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store classifier rule overlay: %w", err)
+	}
+	s.invalidate(userID)
+	return nil
+}
+
+// Delete removes the tenant's overlay, restoring the base rules
+func (s *Store) Delete(ctx context.Context, userID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM classifier_rule_overlays WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete classifier rule overlay: %w", err)
+	}
+	s.invalidate(userID)
+	return nil
+}
+
+// RulesFor returns the tenant's overlay compiled over the active rules, or nil
+// when it has none. Compiled overlays are cached until cacheTTL passes or the
+// base rules reload. An overlay that no longer compiles over reloaded base
+// rules is skipped, so the tenant is classified with the base rules.
+func (s *Store) RulesFor(ctx context.Context, userID string) (*classification.TenantRules, error) {
+	generation := s.classifier.Generation()
+
+	s.mu.Lock()
+	entry, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && time.Since(entry.fetched) < cacheTTL && (entry.rules == nil || entry.rules.BaseGeneration == generation) {
+		return entry.rules, nil
+	}
+
+	o, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	entry = cached{fetched: time.Now()}
+	if o != nil {
+		entry.rules, err = s.classifier.CompileOverlay(o.Rules)
+		if err != nil {
+			log.Printf("[CLASSIFIER] Skipping rule overlay of tenant %s: %v", userID, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[userID] = entry
+	s.mu.Unlock()
+	return entry.rules, nil
+}
+
+func (s *Store) invalidate(userID string) {
+	s.mu.Lock()
+	delete(s.cache, userID)
+	s.mu.Unlock()
+}
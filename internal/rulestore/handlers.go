@@ -0,0 +1,260 @@
+package rulestore
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes classifier rule editing to admins
+type Handlers struct {
+	store *Store
+}
+
+type PutRuleRequest struct {
+	Patterns  []string `json:"patterns"`
+	Terms     []string `json:"terms"`
+	Weight    *float64 `json:"weight"`
+	Threshold *float64 `json:"threshold"`
+}
+
+type ActivateRequest struct {
+	Note string `json:"note"`
+}
+
+func NewHandlers(store *Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// ListDraft returns the draft rules, optionally of one ?group=
+func (h *Handlers) ListDraft(c *gin.Context) {
+	rules, err := h.store.List(c.Request.Context(), c.Query("group"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load classifier rules",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// GetRule returns one label's draft rules
+func (h *Handlers) GetRule(c *gin.Context) {
+	rule, err := h.store.Get(c.Request.Context(), c.Param("group"), c.Param("label"))
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Classifier rule not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load classifier rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// PutRule creates or replaces one label's draft rules
+func (h *Handlers) PutRule(c *gin.Context) {
+	var req PutRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule := Rule{
+		Group:     c.Param("group"),
+		Label:     c.Param("label"),
+		Patterns:  req.Patterns,
+		Terms:     req.Terms,
+		Weight:    req.Weight,
+		Threshold: req.Threshold,
+	}
+	if err := h.store.Put(c.Request.Context(), rule, c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid classifier rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Rule saved to draft; activate the draft to apply it",
+	})
+}
+
+// DeleteRule removes one label's draft rules
+func (h *Handlers) DeleteRule(c *gin.Context) {
+	err := h.store.Delete(c.Request.Context(), c.Param("group"), c.Param("label"))
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Classifier rule not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete classifier rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Rule removed from draft; activate the draft to apply it",
+	})
+}
+
+// ValidateDraft compiles the draft without activating it
+func (h *Handlers) ValidateDraft(c *gin.Context) {
+	if _, err := h.store.Validate(c.Request.Context()); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    gin.H{"valid": false, "error": err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"valid": true},
+	})
+}
+
+// Activate snapshots the draft as a new version and applies it
+func (h *Handlers) Activate(c *gin.Context) {
+	var req ActivateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	version, err := h.store.Activate(c.Request.Context(), c.GetString("user_id"), req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to activate classifier rules",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"version": version},
+	})
+}
+
+// ListVersions returns the rule versions, newest first
+func (h *Handlers) ListVersions(c *gin.Context) {
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 500 {
+			limit = n
+		}
+	}
+
+	versions, err := h.store.Versions(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load classifier rule versions",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+	})
+}
+
+// GetVersion returns one version with its rules
+func (h *Handlers) GetVersion(c *gin.Context) {
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	v, err := h.store.Version(c.Request.Context(), version)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Classifier rule version not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load classifier rule version",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    v,
+	})
+}
+
+// ActivateVersion makes an earlier version active again
+func (h *Handlers) ActivateVersion(c *gin.Context) {
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.store.ActivateVersion(c.Request.Context(), version, c.GetString("user_id"))
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Classifier rule version not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to activate classifier rules",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Classifier rules version " + strconv.FormatInt(version, 10) + " activated",
+	})
+}
+
+func versionParam(c *gin.Context) (int64, bool) {
+	version, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid version",
+		})
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,637 @@
+package rulestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// SchemaVersion is the layout of the rule sets this build stores and reads.
+// Bump it when RuleConfig changes incompatibly; replicas refuse to activate
+// rule sets stored under a newer schema than they understand.
+const SchemaVersion = 1
+
+// GroupComplexity holds complexity indicators, edited as terms per level
+const GroupComplexity = "complexity"
+
+// Bounds on one edited rule
+const (
+	maxPatterns      = 200
+	maxPatternLength = 500
+	maxNoteLength    = 2000
+)
+
+// isGroup reports whether a rule group can be edited: the classifier's
+// pattern groups and complexity
+func isGroup(group string) bool {
+	return group == GroupComplexity || classification.IsRuleGroup(group)
+}
+
+// errSeeded stops an import another replica has already made
+var errSeeded = errors.New("classifier rules already imported")
+
+var labelFormat = regexp.MustCompile(`^[a-z0-9_-]{1,100}$`)
+
+var (
+	// ErrNotFound is returned for rules and versions that do not exist
+	ErrNotFound = errors.New("not found")
+	// ErrSchema is returned for rule sets stored under a newer schema
+	ErrSchema = errors.New("rule set uses a newer schema")
+)
+
+// Rule is one label's editable rules
+type Rule struct {
+	Group     string    `json:"group"`
+	Label     string    `json:"label"`
+	Patterns  []string  `json:"patterns"`            // Regular expressions
+	Terms     []string  `json:"terms"`               // Plain phrases, matched as whole words
+	Weight    *float64  `json:"weight,omitempty"`    // Multiplies the label's match score
+	Threshold *float64  `json:"threshold,omitempty"` // Score the label needs to be chosen
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the rule on its own; Store.Put also checks it against the
+// rest of the draft
+func (r Rule) Validate() error {
+	if !isGroup(r.Group) {
+		return fmt.Errorf("unknown rule group %q", r.Group)
+	}
+	if !labelFormat.MatchString(r.Label) {
+		return fmt.Errorf("labels are 1-100 lowercase letters, digits, dashes or underscores")
+	}
+	if len(r.Patterns)+len(r.Terms) == 0 {
+		return fmt.Errorf("%s.%s needs at least one pattern or term", r.Group, r.Label)
+	}
+	if len(r.Patterns)+len(r.Terms) > maxPatterns {
+		return fmt.Errorf("%s.%s has more than %d patterns and terms", r.Group, r.Label, maxPatterns)
+	}
+	if r.Group == GroupComplexity && (len(r.Patterns) > 0 || r.Weight != nil || r.Threshold != nil) {
+		return fmt.Errorf("complexity levels take terms only")
+	}
+	for i, expr := range r.Patterns {
+		if len(expr) > maxPatternLength {
+			return fmt.Errorf("patterns[%d] is longer than %d characters", i, maxPatternLength)
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("patterns[%d]: %w", i, err)
+		}
+	}
+	for i, term := range r.Terms {
+		if strings.TrimSpace(term) == "" || len(term) > maxPatternLength {
+			return fmt.Errorf("terms[%d] must be 1-%d characters", i, maxPatternLength)
+		}
+	}
+	return nil
+}
+
+// Version is an activated rule set
+type Version struct {
+	Version       int64                      `json:"version"`
+	SchemaVersion int                        `json:"schema_version"`
+	Active        bool                       `json:"active"`
+	Note          string                     `json:"note,omitempty"`
+	CreatedBy     string                     `json:"created_by,omitempty"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	ActivatedAt   *time.Time                 `json:"activated_at,omitempty"`
+	Rules         *classification.RuleConfig `json:"rules,omitempty"`
+}
+
+// Store keeps the classifier rules in the database: a draft edited one label
+// at a time, and immutable versions of which exactly one is active. Every
+// replica applies the active version.
+type Store struct {
+	db         *sql.DB
+	classifier *classification.TaskClassifier
+	auditLog   *audit.Logger
+
+	mu     sync.Mutex
+	loaded int64 // Active version applied to the classifier
+}
+
+func NewStore(db *sql.DB, classifier *classification.TaskClassifier, auditLog *audit.Logger) *Store {
+	return &Store{db: db, classifier: classifier, auditLog: auditLog}
+}
+
+// Start imports the running rules when the database has none, applies the
+// active version and checks for newly activated ones every interval. The
+// rules file, if any, is no longer watched.
+func (s *Store) Start(ctx context.Context, interval time.Duration) {
+	s.classifier.StopWatching()
+	if err := s.seed(ctx); err != nil {
+		log.Printf("[CLASSIFIER] %v", err)
+	}
+	if err := s.Sync(ctx); err != nil {
+		log.Printf("[CLASSIFIER] %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Sync(ctx); err != nil {
+					log.Printf("[CLASSIFIER] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// seed moves the running rules, from the rules file or built in, into the
+// database as the draft and version 1
+func (s *Store) seed(ctx context.Context) error {
+	var versions int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM classifier_rule_versions`).Scan(&versions); err != nil {
+		return fmt.Errorf("failed to count classifier rule versions: %w", err)
+	}
+	if versions > 0 {
+		return nil
+	}
+
+	status := s.classifier.Rules()
+	note := "Imported from " + status.Source
+	cfg := s.classifier.RuleConfig()
+	_, err := s.activate(ctx, "", note, func(tx *sql.Tx) (classification.RuleConfig, error) {
+		// Replicas starting together import once
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE classifier_rule_versions IN EXCLUSIVE MODE`); err != nil {
+			return cfg, fmt.Errorf("failed to lock classifier rule versions: %w", err)
+		}
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM classifier_rule_versions`).Scan(&versions); err != nil {
+			return cfg, fmt.Errorf("failed to count classifier rule versions: %w", err)
+		}
+		if versions > 0 {
+			return cfg, errSeeded
+		}
+		return cfg, replaceDraft(ctx, tx, cfg, "")
+	})
+	if err == errSeeded {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import classifier rules: %w", err)
+	}
+	log.Printf("[CLASSIFIER] Imported rules from %s into the database", status.Source)
+	return nil
+}
+
+// Sync applies the active version when it differs from the one loaded
+func (s *Store) Sync(ctx context.Context) error {
+	var version int64
+	var schema int
+	var raw []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT version, schema_version, rules FROM classifier_rule_versions WHERE active`,
+	).Scan(&version, &schema, &raw)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load active classifier rules: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version == s.loaded {
+		return nil
+	}
+	if schema > SchemaVersion {
+		return fmt.Errorf("keeping current rules: version %d: %w (%d, this build reads %d)", version, ErrSchema, schema, SchemaVersion)
+	}
+	var cfg classification.RuleConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("failed to parse classifier rules version %d: %w", version, err)
+	}
+	if _, err := s.classifier.ReplaceRules(classification.WithDefaults(cfg), fmt.Sprintf("database v%d", version)); err != nil {
+		return err
+	}
+	s.loaded = version
+	return nil
+}
+
+// List returns the draft rules, optionally of one group
+func (s *Store) List(ctx context.Context, group string) ([]Rule, error) {
+	return listRules(ctx, s.db, group)
+}
+
+// querier is a *sql.DB or a *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func listRules(ctx context.Context, q querier, group string) ([]Rule, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT group_name, label, patterns, terms, weight, threshold, COALESCE(updated_by, ''), updated_at
+		FROM classifier_rules
+		WHERE $1 = '' OR group_name = $1
+		ORDER BY group_name, label`, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load classifier rules: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Rule{}
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRule(row scanner) (Rule, error) {
+	var r Rule
+	var patterns, terms []byte
+	var weight, threshold sql.NullFloat64
+	if err := row.Scan(&r.Group, &r.Label, &patterns, &terms, &weight, &threshold, &r.UpdatedBy, &r.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return r, ErrNotFound
+		}
+		return r, fmt.Errorf("failed to scan classifier rule: %w", err)
+	}
+	json.Unmarshal(patterns, &r.Patterns)
+	json.Unmarshal(terms, &r.Terms)
+	if weight.Valid {
+		r.Weight = &weight.Float64
+	}
+	if threshold.Valid {
+		r.Threshold = &threshold.Float64
+	}
+	return r, nil
+}
+
+// Get returns one label's draft rules
+func (s *Store) Get(ctx context.Context, group, label string) (Rule, error) {
+	return scanRule(s.db.QueryRowContext(ctx, `
+		SELECT group_name, label, patterns, terms, weight, threshold, COALESCE(updated_by, ''), updated_at
+		FROM classifier_rules WHERE group_name = $1 AND label = $2`, group, label))
+}
+
+// Put creates or replaces one label's draft rules once they compile with the
+// rest of the draft. The change takes effect on the next activation.
+func (s *Store) Put(ctx context.Context, r Rule, userID string) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	draft, err := s.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range draft {
+		if draft[i].Group == r.Group && draft[i].Label == r.Label {
+			draft[i], replaced = r, true
+		}
+	}
+	if !replaced {
+		draft = append(draft, r)
+	}
+	if err := classification.WithDefaults(toConfig(draft)).Validate(); err != nil {
+		return err
+	}
+
+	if r.Patterns == nil {
+		r.Patterns = []string{}
+	}
+	if r.Terms == nil {
+		r.Terms = []string{}
+	}
+	patterns, _ := json.Marshal(r.Patterns)
+	terms, _ := json.Marshal(r.Terms)
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO classifier_rules (group_name, label, patterns, terms, weight, threshold, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		ON CONFLICT (group_name, label) DO UPDATE SET
+			patterns = EXCLUDED.patterns,
+			terms = EXCLUDED.terms,
+			weight = EXCLUDED.weight,
+			threshold = EXCLUDED.threshold,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP`,
+		r.Group, r.Label, string(patterns), string(terms), r.Weight, r.Threshold, userID)
+	if err != nil {
+		return fmt.Errorf("failed to store classifier rule: %w", err)
+	}
+	return nil
+}
+
+// Delete removes one label's draft rules; once activated, the label falls
+// back to its built-in rules, or disappears if it has none
+func (s *Store) Delete(ctx context.Context, group, label string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM classifier_rules WHERE group_name = $1 AND label = $2`, group, label)
+	if err != nil {
+		return fmt.Errorf("failed to delete classifier rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Validate compiles the draft as it would be activated
+func (s *Store) Validate(ctx context.Context) (classification.RuleConfig, error) {
+	draft, err := s.List(ctx, "")
+	if err != nil {
+		return classification.RuleConfig{}, err
+	}
+	cfg := toConfig(draft)
+	return cfg, classification.WithDefaults(cfg).Validate()
+}
+
+// Activate snapshots the draft as a new version and makes it the active one
+// in a single transaction, then applies it on this replica; the others follow
+// on their next sync
+func (s *Store) Activate(ctx context.Context, userID, note string) (int64, error) {
+	if len(note) > maxNoteLength {
+		return 0, fmt.Errorf("note exceeds %d characters", maxNoteLength)
+	}
+	version, err := s.activate(ctx, userID, note, func(tx *sql.Tx) (classification.RuleConfig, error) {
+		// Edits made while activating wait, so the snapshot is the draft as validated
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE classifier_rules IN SHARE MODE`); err != nil {
+			return classification.RuleConfig{}, fmt.Errorf("failed to lock classifier rules: %w", err)
+		}
+		draft, err := listRules(ctx, tx, "")
+		if err != nil {
+			return classification.RuleConfig{}, err
+		}
+		cfg := toConfig(draft)
+		return cfg, classification.WithDefaults(cfg).Validate()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version, s.Sync(ctx)
+}
+
+// activate stores the rule set built inside the transaction as a new active
+// version
+func (s *Store) activate(ctx context.Context, userID, note string, build func(*sql.Tx) (classification.RuleConfig, error)) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin activation: %w", err)
+	}
+	defer tx.Rollback()
+
+	cfg, err := build(tx)
+	if err != nil {
+		return 0, err
+	}
+	data, _ := json.Marshal(cfg)
+	if _, err := tx.ExecContext(ctx, `UPDATE classifier_rule_versions SET active = FALSE WHERE active`); err != nil {
+		return 0, fmt.Errorf("failed to deactivate classifier rules: %w", err)
+	}
+	var version int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO classifier_rule_versions (schema_version, rules, note, created_by, active, activated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), TRUE, CURRENT_TIMESTAMP)
+		RETURNING version`,
+		SchemaVersion, string(data), note, userID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store classifier rules version: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to activate classifier rules: %w", err)
+	}
+
+	s.record(ctx, userID, version, note)
+	return version, nil
+}
+
+// ActivateVersion makes an earlier version active again, e.g. to roll back,
+// and resets the draft to it so later edits start from what is running
+func (s *Store) ActivateVersion(ctx context.Context, version int64, userID string) error {
+	v, err := s.Version(ctx, version)
+	if err != nil {
+		return err
+	}
+	if v.SchemaVersion > SchemaVersion {
+		return ErrSchema
+	}
+	if err := classification.WithDefaults(*v.Rules).Validate(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin activation: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE classifier_rule_versions SET active = FALSE WHERE active`); err != nil {
+		return fmt.Errorf("failed to deactivate classifier rules: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE classifier_rule_versions SET active = TRUE, activated_at = CURRENT_TIMESTAMP WHERE version = $1`,
+		version); err != nil {
+		return fmt.Errorf("failed to activate classifier rules: %w", err)
+	}
+	if err := replaceDraft(ctx, tx, *v.Rules, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to activate classifier rules: %w", err)
+	}
+
+	s.record(ctx, userID, version, fmt.Sprintf("Reactivated version %d", version))
+	return s.Sync(ctx)
+}
+
+// replaceDraft overwrites the draft with cfg
+func replaceDraft(ctx context.Context, tx *sql.Tx, cfg classification.RuleConfig, userID string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM classifier_rules`); err != nil {
+		return fmt.Errorf("failed to reset classifier rules draft: %w", err)
+	}
+	for _, r := range toRules(cfg) {
+		patterns, _ := json.Marshal(r.Patterns)
+		terms, _ := json.Marshal(r.Terms)
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO classifier_rules (group_name, label, patterns, terms, weight, threshold, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))`,
+			r.Group, r.Label, string(patterns), string(terms), r.Weight, r.Threshold, userID); err != nil {
+			return fmt.Errorf("failed to reset classifier rules draft: %w", err)
+		}
+	}
+	return nil
+}
+
+// Versions lists the versions newest first, without their rules
+func (s *Store) Versions(ctx context.Context, limit int) ([]Version, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT version, schema_version, active, COALESCE(note, ''), COALESCE(created_by, ''), created_at, activated_at
+		FROM classifier_rule_versions
+		ORDER BY version DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load classifier rule versions: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Version{}
+	for rows.Next() {
+		var v Version
+		var activated sql.NullTime
+		if err := rows.Scan(&v.Version, &v.SchemaVersion, &v.Active, &v.Note, &v.CreatedBy, &v.CreatedAt, &activated); err != nil {
+			return nil, fmt.Errorf("failed to scan classifier rule version: %w", err)
+		}
+		if activated.Valid {
+			t := activated.Time
+			v.ActivatedAt = &t
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// Version returns one version with its rules
+func (s *Store) Version(ctx context.Context, version int64) (Version, error) {
+	v := Version{Version: version}
+	var raw []byte
+	var activated sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT schema_version, active, COALESCE(note, ''), COALESCE(created_by, ''), created_at, activated_at, rules
+		FROM classifier_rule_versions WHERE version = $1`, version,
+	).Scan(&v.SchemaVersion, &v.Active, &v.Note, &v.CreatedBy, &v.CreatedAt, &activated, &raw)
+	if err == sql.ErrNoRows {
+		return v, ErrNotFound
+	}
+	if err != nil {
+		return v, fmt.Errorf("failed to load classifier rule version: %w", err)
+	}
+	if activated.Valid {
+		t := activated.Time
+		v.ActivatedAt = &t
+	}
+	var cfg classification.RuleConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return v, fmt.Errorf("failed to parse classifier rule version: %w", err)
+	}
+	v.Rules = &cfg
+	return v, nil
+}
+
+func (s *Store) record(ctx context.Context, userID string, version int64, note string) {
+	log.Printf("[CLASSIFIER] Activated rules version %d", version)
+	if s.auditLog == nil {
+		return
+	}
+	if _, err := s.auditLog.Record(ctx, audit.Entry{
+		UserID:    userID,
+		EventType: "classifier.rules_activated",
+		Action:    "activate",
+		Resource:  fmt.Sprintf("classifier_rules:v%d", version),
+		Details:   map[string]interface{}{"version": version, "note": note},
+	}); err != nil {
+		log.Printf("[CLASSIFIER] Failed to record audit entry: %v", err)
+	}
+}
+
+// toConfig assembles draft rules into a rule config
+func toConfig(rules []Rule) classification.RuleConfig {
+	cfg := classification.RuleConfig{
+		Patterns:             map[string]map[string][]string{},
+		Terms:                map[string]map[string][]string{},
+		Weights:              map[string]map[string]float64{},
+		Thresholds:           map[string]map[string]float64{},
+		ComplexityIndicators: map[string][]string{},
+	}
+	set := func(m map[string]map[string][]string, group, label string, values []string) {
+		if m[group] == nil {
+			m[group] = map[string][]string{}
+		}
+		m[group][label] = append([]string{}, values...)
+	}
+	for _, r := range rules {
+		if r.Group == GroupComplexity {
+			cfg.ComplexityIndicators[r.Label] = append([]string{}, r.Terms...)
+			continue
+		}
+		// Listing the label replaces its built-in patterns even when only terms are given
+		set(cfg.Patterns, r.Group, r.Label, r.Patterns)
+		if len(r.Terms) > 0 {
+			set(cfg.Terms, r.Group, r.Label, r.Terms)
+		}
+		for _, v := range []struct {
+			m     map[string]map[string]float64
+			value *float64
+		}{{cfg.Weights, r.Weight}, {cfg.Thresholds, r.Threshold}} {
+			if v.value == nil {
+				continue
+			}
+			if v.m[r.Group] == nil {
+				v.m[r.Group] = map[string]float64{}
+			}
+			v.m[r.Group][r.Label] = *v.value
+		}
+	}
+	return cfg
+}
+
+// toRules splits a rule config into one rule per label
+func toRules(cfg classification.RuleConfig) []Rule {
+	byKey := map[[2]string]*Rule{}
+	get := func(group, label string) *Rule {
+		key := [2]string{group, label}
+		if byKey[key] == nil {
+			byKey[key] = &Rule{Group: group, Label: label, Patterns: []string{}, Terms: []string{}}
+		}
+		return byKey[key]
+	}
+	for group, labels := range cfg.Patterns {
+		for label, patterns := range labels {
+			get(group, label).Patterns = append([]string{}, patterns...)
+		}
+	}
+	for group, labels := range cfg.Terms {
+		for label, terms := range labels {
+			get(group, label).Terms = append([]string{}, terms...)
+		}
+	}
+	for group, labels := range cfg.Weights {
+		for label, v := range labels {
+			v := v
+			get(group, label).Weight = &v
+		}
+	}
+	for group, labels := range cfg.Thresholds {
+		for label, v := range labels {
+			v := v
+			get(group, label).Threshold = &v
+		}
+	}
+	for level, indicators := range cfg.ComplexityIndicators {
+		get(GroupComplexity, level).Terms = append([]string{}, indicators...)
+	}
+
+	rules := make([]Rule, 0, len(byKey))
+	for _, r := range byKey {
+		rules = append(rules, *r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Group != rules[j].Group {
+			return rules[i].Group < rules[j].Group
+		}
+		return rules[i].Label < rules[j].Label
+	})
+	return rules
+}
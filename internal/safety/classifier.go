@@ -0,0 +1,101 @@
+package safety
+
+import (
+	"math"
+	"regexp"
+	"sort"
+)
+
+// Safety categories detected on incoming prompts
+const (
+	CategorySelfHarm = "self_harm"
+	CategoryViolence = "violence"
+	CategorySexual   = "sexual"
+	CategoryMalware  = "malware"
+)
+
+// Categories lists every category the classifier can emit
+var Categories = []string{CategorySelfHarm, CategoryViolence, CategorySexual, CategoryMalware}
+
+// Detection is a single safety category hit on a prompt
+type Detection struct {
+	Category string   `json:"category"`
+	Score    float64  `json:"score"`
+	Matches  []string `json:"matches"`
+}
+
+// Classifier is a rule-based safety classifier for prompts
+type Classifier struct {
+	patterns map[string][]*regexp.Regexp
+
+	// Minimum score for a category to count as detected
+	threshold float64
+}
+
+func NewClassifier() *Classifier {
+	c := &Classifier{
+		patterns:  make(map[string][]*regexp.Regexp),
+		threshold: 0.4,
+	}
+	c.initializePatterns()
+	return c
+}
+
+func (c *Classifier) initializePatterns() {
+	c.patterns[CategorySelfHarm] = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(kill|hurt|harm|cut)\s+(myself|yourself)\b`),
+		regexp.MustCompile(`(?i)\b(suicide|suicidal|self[- ]harm|end\s+my\s+life)\b`),
+		regexp.MustCompile(`(?i)\b(overdose|lethal\s+dose)\b`),
+	}
+
+	c.patterns[CategoryViolence] = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(how\s+to\s+)?(build|make|assemble)\s+(a\s+)?(bomb|explosive|pipe\s+bomb|weapon)\b`),
+		regexp.MustCompile(`(?i)\b(kill|murder|assassinate|torture)\s+(him|her|them|someone|people|my)\b`),
+		regexp.MustCompile(`(?i)\b(mass\s+shooting|terror(ist)?\s+attack)\b`),
+	}
+
+	c.patterns[CategorySexual] = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(explicit|graphic)\s+(sex|sexual)\b`),
+		regexp.MustCompile(`(?i)\b(porn|pornographic|nsfw|erotic)\b`),
+		regexp.MustCompile(`(?i)\b(nude|naked)\s+(image|photo|picture)s?\b`),
+	}
+
+	c.patterns[CategoryMalware] = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(ransomware|keylogger|rootkit|botnet|trojan|spyware)\b`),
+		regexp.MustCompile(`(?i)\b(write|create|build|develop)\s+.*\b(malware|virus|worm|exploit)\b`),
+		regexp.MustCompile(`(?i)\b(bypass|evade)\s+(antivirus|edr|detection)\b`),
+		regexp.MustCompile(`(?i)\b(steal|exfiltrate|dump)\s+(credentials|passwords|cookies)\b`),
+	}
+}
+
+// Classify returns the safety categories detected in a prompt, most severe first
+func (c *Classifier) Classify(prompt string) []Detection {
+	detections := []Detection{}
+
+	for category, patterns := range c.patterns {
+		score := 0.0
+		matches := []string{}
+		for _, pattern := range patterns {
+			found := pattern.FindAllString(prompt, -1)
+			if len(found) > 0 {
+				score += 0.5 + float64(len(found)-1)*0.1
+				matches = append(matches, found...)
+			}
+		}
+
+		score = math.Min(score, 1.0)
+		if score >= c.threshold {
+			detections = append(detections, Detection{
+				Category: category,
+				Score:    score,
+				Matches:  matches,
+			})
+		}
+	}
+
+	sort.Slice(detections, func(i, j int) bool {
+		return detections[i].Score > detections[j].Score
+	})
+
+	return detections
+}
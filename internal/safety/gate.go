@@ -0,0 +1,200 @@
+package safety
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// Decision is the outcome of running a prompt through the safety gate
+type Decision struct {
+	Action       string      `json:"action"`
+	Categories   []string    `json:"categories"`
+	Detections   []Detection `json:"detections,omitempty"`
+	SafeModels   []string    `json:"safe_models,omitempty"`
+	PolicySource string      `json:"policy_source"`
+	AuditID      string      `json:"audit_id,omitempty"`
+	Reason       string      `json:"reason,omitempty"`
+}
+
+// Blocked reports whether the prompt must not be routed at all
+func (d Decision) Blocked() bool {
+	return d.Action == ActionBlock
+}
+
+// Gate classifies prompts, applies tenant policy and records decisions
+type Gate struct {
+	classifier *Classifier
+	policies   *PolicyStore
+	auditLog   *audit.Logger
+	db         *sql.DB
+}
+
+// NewGate creates a safety gate. db may be nil, in which case the default
+// policy is used and decisions are only logged.
+func NewGate(db *sql.DB, auditLog *audit.Logger) *Gate {
+	g := &Gate{
+		classifier: NewClassifier(),
+		auditLog:   auditLog,
+		db:         db,
+	}
+	if db != nil {
+		g.policies = NewPolicyStore(db)
+	}
+	return g
+}
+
+// Policies exposes the tenant policy store (nil without a database)
+func (g *Gate) Policies() *PolicyStore {
+	return g.policies
+}
+
+// Evaluate runs the safety stage for a tenant's prompt
+func (g *Gate) Evaluate(ctx context.Context, userID, prompt string) Decision {
+	decision := Decision{
+		Action:     ActionAllow,
+		Categories: []string{},
+	}
+
+	detections := g.classifier.Classify(prompt)
+	if len(detections) == 0 {
+		decision.PolicySource = "none"
+		return decision
+	}
+
+	policy := DefaultPolicy()
+	if g.policies != nil {
+		p, err := g.policies.Get(ctx, userID)
+		if err != nil {
+			trace.Logf(ctx, "[SAFETY] Failed to load policy for %s, using default: %v", userID, err)
+		} else {
+			policy = p
+		}
+	}
+
+	decision.Detections = detections
+	decision.PolicySource = policy.Source
+
+	// The most restrictive action across detected categories wins
+	for _, d := range detections {
+		decision.Categories = append(decision.Categories, d.Category)
+		action := policy.ActionFor(d.Category)
+		if actionSeverity[action] > actionSeverity[decision.Action] {
+			decision.Action = action
+		}
+	}
+
+	if decision.Action == ActionRouteSafe {
+		if len(policy.SafeModels) == 0 {
+			decision.Action = ActionFlag
+			decision.Reason = "no safe models configured; request flagged instead of rerouted"
+		} else {
+			decision.SafeModels = policy.SafeModels
+		}
+	}
+
+	trace.Logf(ctx, "[SAFETY] Decision=%s categories=%v policy=%s", decision.Action, decision.Categories, decision.PolicySource)
+
+	if g.auditLog != nil && decision.Action != ActionAllow {
+		id, err := g.auditLog.Record(ctx, audit.Entry{
+			UserID:    userID,
+			EventType: "safety." + decision.Action,
+			Action:    decision.Action,
+			Resource:  "prompt",
+			Prompt:    prompt,
+			Details: map[string]interface{}{
+				"categories":    decision.Categories,
+				"policy_source": decision.PolicySource,
+				"safe_models":   decision.SafeModels,
+			},
+			PromptDetails: map[string]interface{}{
+				"detections": decision.Detections,
+			},
+		})
+		if err != nil {
+			trace.Logf(ctx, "[SAFETY] Failed to record audit entry: %v", err)
+		} else {
+			decision.AuditID = id
+		}
+	}
+
+	return decision
+}
+
+// FlaggedRequest is a flagged safety decision awaiting or after admin review
+type FlaggedRequest struct {
+	audit.Entry
+	ReviewStatus string     `json:"review_status"`
+	ReviewedBy   string     `json:"reviewed_by,omitempty"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ListFlagged returns flagged, rerouted and blocked decisions for admin review
+func (g *Gate) ListFlagged(ctx context.Context, status string, limit, offset int) ([]FlaggedRequest, error) {
+	if g.db == nil {
+		return nil, fmt.Errorf("safety review requires a database")
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	rows, err := g.db.QueryContext(ctx, `
+		SELECT a.id, COALESCE(a.user_id::text, ''), a.event_type, a.action, COALESCE(a.prompt, ''),
+		       a.created_at, COALESCE(r.status, 'pending'), COALESCE(r.reviewed_by, ''),
+		       COALESCE(r.note, ''), r.reviewed_at
+		FROM audit_log a
+		LEFT JOIN safety_reviews r ON r.audit_id = a.id
+		WHERE a.event_type LIKE 'safety.%'
+		  AND ($1 = '' OR COALESCE(r.status, 'pending') = $1)
+		ORDER BY a.created_at DESC
+		LIMIT $2 OFFSET $3`, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flagged requests: %w", err)
+	}
+	defer rows.Close()
+
+	flagged := []FlaggedRequest{}
+	for rows.Next() {
+		var f FlaggedRequest
+		if err := rows.Scan(&f.ID, &f.UserID, &f.EventType, &f.Action, &f.Prompt, &f.CreatedAt,
+			&f.ReviewStatus, &f.ReviewedBy, &f.ReviewNote, &f.ReviewedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan flagged request: %w", err)
+		}
+		if g.auditLog != nil {
+			if f.Prompt, err = g.auditLog.OpenPrompt(ctx, f.UserID, f.Prompt); err != nil {
+				return nil, err
+			}
+		}
+		flagged = append(flagged, f)
+	}
+	return flagged, rows.Err()
+}
+
+// Review records an admin verdict on a flagged decision
+func (g *Gate) Review(ctx context.Context, auditID, status, reviewer, note string) error {
+	if g.db == nil {
+		return fmt.Errorf("safety review requires a database")
+	}
+	if status != "approved" && status != "rejected" && status != "escalated" {
+		return fmt.Errorf("invalid review status: %s", status)
+	}
+
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO safety_reviews (audit_id, status, reviewed_by, note, reviewed_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (audit_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			reviewed_by = EXCLUDED.reviewed_by,
+			note = EXCLUDED.note,
+			reviewed_at = EXCLUDED.reviewed_at`,
+		auditID, status, reviewer, note)
+	if err != nil {
+		return fmt.Errorf("failed to record review: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package safety
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes safety policy management and flagged request review
+type Handlers struct {
+	gate *Gate
+}
+
+type PutPolicyRequest struct {
+	Actions    map[string]string `json:"actions" binding:"required"`
+	SafeModels []string          `json:"safe_models"`
+}
+
+type ReviewRequest struct {
+	Status string `json:"status" binding:"required"` // approved, rejected, escalated
+	Note   string `json:"note"`
+}
+
+func NewHandlers(gate *Gate) *Handlers {
+	return &Handlers{gate: gate}
+}
+
+// GetPolicy returns the caller's effective safety policy
+func (h *Handlers) GetPolicy(c *gin.Context) {
+	policy, err := h.gate.Policies().Get(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load safety policy",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"policy":     policy,
+		"categories": Categories,
+	})
+}
+
+// PutPolicy stores the caller's safety policy overrides
+func (h *Handlers) PutPolicy(c *gin.Context) {
+	var req PutPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.gate.Policies().Put(c.Request.Context(), c.GetString("user_id"), req.Actions, req.SafeModels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid safety policy",
+			"details":       err.Error(),
+			"valid_actions": []string{ActionAllow, ActionFlag, ActionRouteSafe, ActionBlock},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// ListFlagged returns safety decisions for admin review
+func (h *Handlers) ListFlagged(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	flagged, err := h.gate.ListFlagged(c.Request.Context(), c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list flagged requests",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"flagged": flagged,
+			"count":   len(flagged),
+		},
+	})
+}
+
+// ReviewFlagged records an admin verdict on a flagged request
+func (h *Handlers) ReviewFlagged(c *gin.Context) {
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.gate.Review(c.Request.Context(), c.Param("id"), req.Status, c.GetString("admin_id"), req.Note); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to record review",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,144 @@
+package safety
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Policy actions, ordered from least to most restrictive
+const (
+	ActionAllow     = "allow"
+	ActionFlag      = "flag"
+	ActionRouteSafe = "route_to_safe_model"
+	ActionBlock     = "block"
+)
+
+var actionSeverity = map[string]int{
+	ActionAllow:     0,
+	ActionFlag:      1,
+	ActionRouteSafe: 2,
+	ActionBlock:     3,
+}
+
+// IsValidAction reports whether action is a known policy action
+func IsValidAction(action string) bool {
+	_, ok := actionSeverity[action]
+	return ok
+}
+
+// Policy maps safety categories to the action taken when they are detected
+type Policy struct {
+	UserID     string            `json:"user_id,omitempty"`
+	Actions    map[string]string `json:"actions"`
+	SafeModels []string          `json:"safe_models"`
+	Source     string            `json:"source"` // "default" or "tenant"
+}
+
+// ActionFor returns the configured action for a category
+func (p Policy) ActionFor(category string) string {
+	if action, ok := p.Actions[category]; ok {
+		return action
+	}
+	return ActionFlag
+}
+
+// DefaultPolicy is applied to tenants without a stored policy
+func DefaultPolicy() Policy {
+	policy := Policy{
+		Actions: map[string]string{
+			CategorySelfHarm: ActionRouteSafe,
+			CategoryViolence: ActionBlock,
+			CategorySexual:   ActionFlag,
+			CategoryMalware:  ActionBlock,
+		},
+		SafeModels: []string{},
+		Source:     "default",
+	}
+
+	// SAFE_MODEL_IDS is a comma-separated list of models used for route_to_safe_model
+	if safeModels := os.Getenv("SAFE_MODEL_IDS"); safeModels != "" {
+		for _, id := range strings.Split(safeModels, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				policy.SafeModels = append(policy.SafeModels, id)
+			}
+		}
+	}
+
+	return policy
+}
+
+// PolicyStore persists per-tenant safety policies
+type PolicyStore struct {
+	db *sql.DB
+}
+
+func NewPolicyStore(db *sql.DB) *PolicyStore {
+	return &PolicyStore{db: db}
+}
+
+// Get returns the tenant's policy merged over the default policy
+func (s *PolicyStore) Get(ctx context.Context, userID string) (Policy, error) {
+	policy := DefaultPolicy()
+	if userID == "" {
+		return policy, nil
+	}
+
+	var actionsJSON, safeModelsJSON []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT actions, safe_models FROM safety_policies WHERE user_id = $1`, userID,
+	).Scan(&actionsJSON, &safeModelsJSON)
+	if err == sql.ErrNoRows {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to load safety policy: %w", err)
+	}
+
+	var actions map[string]string
+	if err := json.Unmarshal(actionsJSON, &actions); err == nil {
+		for category, action := range actions {
+			policy.Actions[category] = action
+		}
+	}
+
+	var safeModels []string
+	if err := json.Unmarshal(safeModelsJSON, &safeModels); err == nil && len(safeModels) > 0 {
+		policy.SafeModels = safeModels
+	}
+
+	policy.UserID = userID
+	policy.Source = "tenant"
+	return policy, nil
+}
+
+// Put stores the tenant's action overrides and safe model list
+func (s *PolicyStore) Put(ctx context.Context, userID string, actions map[string]string, safeModels []string) error {
+	for category, action := range actions {
+		if !IsValidAction(action) {
+			return fmt.Errorf("invalid action %q for category %q", action, category)
+		}
+	}
+	if safeModels == nil {
+		safeModels = []string{}
+	}
+
+	actionsJSON, _ := json.Marshal(actions)
+	safeModelsJSON, _ := json.Marshal(safeModels)
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO safety_policies (user_id, actions, safe_models)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			actions = EXCLUDED.actions,
+			safe_models = EXCLUDED.safe_models,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, string(actionsJSON), string(safeModelsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to store safety policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package secrets
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of detected secrets
+const (
+	KindPrivateKey   = "private_key"
+	KindAWSAccessKey = "aws_access_key"
+	KindAnthropicKey = "anthropic_key"
+	KindStripeKey    = "stripe_key"
+	KindAPIKey       = "api_key" // sk-, pk- and rk- keys, e.g. OpenAI's
+	KindGitHubToken  = "github_token"
+	KindSlackToken   = "slack_token"
+	KindGoogleAPIKey = "google_api_key"
+	KindJWT          = "jwt"
+	KindBearerToken  = "bearer_token"
+	KindCredential   = "credential" // A high-entropy value assigned to a key, secret, token or password
+	KindHighEntropy  = "high_entropy"
+)
+
+// minCredentialChars is the shortest assigned value checked as a credential
+const minCredentialChars = 8
+
+// secretPattern matches one kind of secret. When the expression has a group,
+// only the group is the secret, e.g. the value of "api_key=...".
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+	// minEntropy, when set, also requires the secret to mix letters and
+	// digits and look random, so prose such as "author: JaneDoe" and
+	// placeholders such as "password: aaaa1111" pass
+	minEntropy float64
+}
+
+// patterns are tried in order; a span already claimed by an earlier, more
+// specific pattern is not reported again
+var patterns = []secretPattern{
+	{kind: KindPrivateKey, re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY[A-Z ]*-----(?:[\s\S]*?-----END [A-Z ]*PRIVATE KEY[A-Z ]*-----|[A-Za-z0-9+/=\s:,-]*)`)},
+	{kind: KindAWSAccessKey, re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: KindAnthropicKey, re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{kind: KindStripeKey, re: regexp.MustCompile(`\b(?:sk|rk|pk)_(?:live|test)_[0-9A-Za-z]{16,}\b`)},
+	{kind: KindAPIKey, re: regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{20,}`)},
+	{kind: KindGitHubToken, re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}\b`)},
+	{kind: KindSlackToken, re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{kind: KindGoogleAPIKey, re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{kind: KindJWT, re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{kind: KindBearerToken, re: regexp.MustCompile(`(?i)\b(?:bearer|token)\s+([A-Za-z0-9._~+/-]{20,}=*)`), minEntropy: 3.5},
+	{kind: KindCredential, re: regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|pwd|access[_-]?key|private[_-]?key|client[_-]?secret|auth)[A-Za-z0-9_-]*["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{8,})`), minEntropy: 3.0},
+}
+
+// highEntropyToken finds runs long enough to be a random key or token
+var highEntropyToken = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+
+// Finding is one detected secret. The secret itself is never reported.
+type Finding struct {
+	Kind  string `json:"kind"`
+	Start int    `json:"start"` // Byte offsets into the screened text
+	End   int    `json:"end"`
+}
+
+// DetectorConfig tunes the entropy check for secrets without a known shape
+type DetectorConfig struct {
+	MinEntropy float64 // Shannon entropy, in bits per character, from which a long token counts as a secret
+	MinLength  int     // Shortest token checked for entropy
+}
+
+// DefaultDetectorConfig returns the built-in thresholds; SECRET_GUARD_ENTROPY
+// tunes the entropy a token needs. Hex digests top out at 4 bits per
+// character, so the default leaves commit and content hashes alone.
+func DefaultDetectorConfig() DetectorConfig {
+	cfg := DetectorConfig{MinEntropy: 4.3, MinLength: 32}
+	if v, err := strconv.ParseFloat(os.Getenv("SECRET_GUARD_ENTROPY"), 64); err == nil && v > 0 {
+		cfg.MinEntropy = v
+	}
+	return cfg
+}
+
+// Detector finds API keys, private keys and tokens in text, by their known
+// shapes and by entropy
+type Detector struct {
+	cfg DetectorConfig
+}
+
+func NewDetector(cfg DetectorConfig) *Detector {
+	return &Detector{cfg: cfg}
+}
+
+// Detect returns the secrets in text, in order
+func (d *Detector) Detect(text string) []Finding {
+	var findings []Finding
+	claimed := func(start, end int) bool {
+		for _, f := range findings {
+			if start < f.End && end > f.Start {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range patterns {
+		for _, m := range p.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if p.minEntropy > 0 && !credentialLike(text[start:end], p.minEntropy) {
+				continue
+			}
+			if !claimed(start, end) {
+				findings = append(findings, Finding{Kind: p.kind, Start: start, End: end})
+			}
+		}
+	}
+
+	for _, m := range highEntropyToken.FindAllStringIndex(text, -1) {
+		token := text[m[0]:m[1]]
+		if len(token) < d.cfg.MinLength || !mixedClasses(token) || entropy(token) < d.cfg.MinEntropy {
+			continue
+		}
+		if !claimed(m[0], m[1]) {
+			findings = append(findings, Finding{Kind: KindHighEntropy, Start: m[0], End: m[1]})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	return findings
+}
+
+// Mask replaces each secret in text with a placeholder naming its kind
+func (d *Detector) Mask(text string) string {
+	findings := d.Detect(text)
+	if len(findings) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.Start])
+		b.WriteString("[SECRET:" + f.Kind + "]")
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Count tallies findings by kind
+func Count(findings []Finding) map[string]int {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Kind]++
+	}
+	return counts
+}
+
+// entropy is the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		freq[r]++
+		n++
+	}
+	var h float64
+	for _, c := range freq {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// credentialLike reports whether a value assigned to a credential name looks
+// like a real secret rather than prose or a placeholder
+func credentialLike(value string, minEntropy float64) bool {
+	return len(value) >= minCredentialChars && mixedClasses(value) && !placeholder(value) && entropy(value) >= minEntropy
+}
+
+// mixedClasses reports whether a token mixes letters and digits, as generated
+// keys do and long words, paths and identifiers mostly do not
+func mixedClasses(s string) bool {
+	var letters, digits bool
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letters = true
+		}
+	}
+	return letters && digits
+}
+
+// placeholder reports values that stand in for a secret rather than being one
+func placeholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, marker := range []string{"${", "{{", "<", "process.env", "os.environ", "getenv", "xxxx", "****", "your_", "your-", "example", "redacted", "[secret"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
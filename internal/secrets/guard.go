@@ -0,0 +1,243 @@
+package secrets
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// Policy actions taken when a prompt carries secrets
+const (
+	ActionWarn  = "warn"  // Forward the prompt and tell the caller
+	ActionMask  = "mask"  // Forward the prompt with secrets replaced by placeholders
+	ActionBlock = "block" // Refuse the request
+)
+
+// IsValidAction reports whether action is a known policy action
+func IsValidAction(action string) bool {
+	return action == ActionWarn || action == ActionMask || action == ActionBlock
+}
+
+// Policy is what a tenant wants done with prompts carrying secrets
+type Policy struct {
+	UserID string `json:"user_id,omitempty"`
+	Action string `json:"action"`
+	Source string `json:"source"` // "default" or "tenant"
+}
+
+// DefaultPolicy is applied to tenants without a stored policy
+func DefaultPolicy() Policy {
+	policy := Policy{Action: ActionMask, Source: "default"}
+
+	// SECRET_GUARD_ACTION sets the platform default (warn, mask or block)
+	if action := os.Getenv("SECRET_GUARD_ACTION"); IsValidAction(action) {
+		policy.Action = action
+	}
+	return policy
+}
+
+// PolicyStore persists per-tenant secret policies
+type PolicyStore struct {
+	db *sql.DB
+}
+
+func NewPolicyStore(db *sql.DB) *PolicyStore {
+	return &PolicyStore{db: db}
+}
+
+// Get returns the tenant's policy, falling back to the default
+func (s *PolicyStore) Get(ctx context.Context, userID string) (Policy, error) {
+	policy := DefaultPolicy()
+	if userID == "" {
+		return policy, nil
+	}
+
+	var action string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT action FROM secret_policies WHERE user_id = $1`, userID,
+	).Scan(&action)
+	if err == sql.ErrNoRows {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to load secret policy: %w", err)
+	}
+
+	policy.UserID = userID
+	policy.Action = action
+	policy.Source = "tenant"
+	return policy, nil
+}
+
+// Put stores the tenant's action
+func (s *PolicyStore) Put(ctx context.Context, userID, action string) error {
+	if !IsValidAction(action) {
+		return fmt.Errorf("invalid secret policy action: %s", action)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO secret_policies (user_id, action)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			action = EXCLUDED.action,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, action)
+	if err != nil {
+		return fmt.Errorf("failed to store secret policy: %w", err)
+	}
+	return nil
+}
+
+// Screening is the outcome of checking a prompt for secrets
+type Screening struct {
+	Action       string         `json:"action"`
+	Detections   map[string]int `json:"detections"` // Secrets found, by kind
+	PolicySource string         `json:"policy_source"`
+	AuditID      string         `json:"audit_id,omitempty"`
+}
+
+// Found reports whether the prompt carried any secret
+func (s Screening) Found() bool {
+	return len(s.Detections) > 0
+}
+
+// Blocked reports whether the prompt must not be forwarded
+func (s Screening) Blocked() bool {
+	return s.Action == ActionBlock
+}
+
+// Masked reports whether secrets must be replaced before forwarding
+func (s Screening) Masked() bool {
+	return s.Action == ActionMask
+}
+
+// Guard screens prompts for secrets before they leave for third-party
+// providers, applies the tenant's policy and records detections
+type Guard struct {
+	detector *Detector
+	policies *PolicyStore
+	auditLog *audit.Logger
+	db       *sql.DB
+}
+
+func NewGuard(db *sql.DB, auditLog *audit.Logger, cfg DetectorConfig) *Guard {
+	return &Guard{
+		detector: NewDetector(cfg),
+		policies: NewPolicyStore(db),
+		auditLog: auditLog,
+		db:       db,
+	}
+}
+
+// Policies exposes the tenant policy store
+func (g *Guard) Policies() *PolicyStore {
+	return g.policies
+}
+
+// Screen checks a tenant's prompt. A screening without detections needs no
+// action; otherwise the tenant's policy decides and the detection is audited.
+// The prompt itself is never recorded, since it holds the secret.
+func (g *Guard) Screen(ctx context.Context, userID, prompt string) Screening {
+	findings := g.detector.Detect(prompt)
+	if len(findings) == 0 {
+		return Screening{Detections: map[string]int{}, PolicySource: "none"}
+	}
+
+	policy, err := g.policies.Get(ctx, userID)
+	if err != nil {
+		trace.Logf(ctx, "[SECRETS] Failed to load policy for %s, using default: %v", userID, err)
+	}
+	screening := Screening{
+		Action:       policy.Action,
+		Detections:   Count(findings),
+		PolicySource: policy.Source,
+	}
+
+	trace.Logf(ctx, "[SECRETS] Action=%s detections=%v policy=%s", screening.Action, screening.Detections, screening.PolicySource)
+
+	if g.auditLog != nil {
+		id, err := g.auditLog.Record(ctx, audit.Entry{
+			UserID:    userID,
+			EventType: "secrets." + screening.Action,
+			Action:    screening.Action,
+			Resource:  "prompt",
+			Details: map[string]interface{}{
+				"detections":    screening.Detections,
+				"total":         len(findings),
+				"policy_source": screening.PolicySource,
+			},
+		})
+		if err != nil {
+			trace.Logf(ctx, "[SECRETS] Failed to record audit entry: %v", err)
+		} else {
+			screening.AuditID = id
+		}
+	}
+	return screening
+}
+
+// Mask replaces the secrets in text with placeholders
+func (g *Guard) Mask(text string) string {
+	return g.detector.Mask(text)
+}
+
+// Summary counts the secrets caught in a tenant's prompts over a window
+type Summary struct {
+	Since    time.Time      `json:"since"`
+	Prompts  int            `json:"prompts"` // Prompts that carried at least one secret
+	Secrets  int            `json:"secrets"`
+	ByKind   map[string]int `json:"by_kind"`
+	ByAction map[string]int `json:"by_action"` // Prompts, by the action taken
+}
+
+// Summarize counts the tenant's detections since the given time from the audit log
+func (g *Guard) Summarize(ctx context.Context, userID string, since time.Time) (Summary, error) {
+	summary := Summary{Since: since, ByKind: map[string]int{}, ByAction: map[string]int{}}
+
+	rows, err := g.db.QueryContext(ctx, `
+		SELECT action, COUNT(*), COALESCE(SUM((details->>'total')::int), 0)
+		FROM audit_log
+		WHERE user_id = $1 AND event_type LIKE 'secrets.%' AND created_at >= $2
+		GROUP BY action`, userID, since)
+	if err != nil {
+		return summary, fmt.Errorf("failed to count secret detections: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var action string
+		var prompts, secrets int
+		if err := rows.Scan(&action, &prompts, &secrets); err != nil {
+			return summary, fmt.Errorf("failed to scan secret detections: %w", err)
+		}
+		summary.ByAction[action] = prompts
+		summary.Prompts += prompts
+		summary.Secrets += secrets
+	}
+	if err := rows.Err(); err != nil {
+		return summary, fmt.Errorf("failed to count secret detections: %w", err)
+	}
+
+	kinds, err := g.db.QueryContext(ctx, `
+		SELECT d.key, SUM(d.value::int)
+		FROM audit_log a, jsonb_each_text(a.details->'detections') d
+		WHERE a.user_id = $1 AND a.event_type LIKE 'secrets.%' AND a.created_at >= $2
+		GROUP BY d.key`, userID, since)
+	if err != nil {
+		return summary, fmt.Errorf("failed to count secret detections: %w", err)
+	}
+	defer kinds.Close()
+	for kinds.Next() {
+		var kind string
+		var n int
+		if err := kinds.Scan(&kind, &n); err != nil {
+			return summary, fmt.Errorf("failed to scan secret detections: %w", err)
+		}
+		summary.ByKind[kind] = n
+	}
+	return summary, kinds.Err()
+}
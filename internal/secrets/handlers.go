@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes tenant secret policy management
+type Handlers struct {
+	guard *Guard
+}
+
+type PutPolicyRequest struct {
+	Action string `json:"action" binding:"required"`
+}
+
+func NewHandlers(guard *Guard) *Handlers {
+	return &Handlers{guard: guard}
+}
+
+// GetPolicy returns the caller's effective secret policy
+func (h *Handlers) GetPolicy(c *gin.Context) {
+	policy, err := h.guard.Policies().Get(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load secret policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"policy":  policy,
+	})
+}
+
+// PutPolicy stores what is done with the caller's prompts that carry secrets
+func (h *Handlers) PutPolicy(c *gin.Context) {
+	var req PutPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.guard.Policies().Put(c.Request.Context(), c.GetString("user_id"), req.Action); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Invalid secret policy",
+			"details":       err.Error(),
+			"valid_actions": []string{ActionWarn, ActionMask, ActionBlock},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
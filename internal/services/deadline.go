@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// Request budgets. The default stays under the HTTP server's 30s write timeout
+// so a slow upstream yields a degraded response rather than a dropped one.
+const (
+	defaultRequestTimeout = 25 * time.Second
+	classificationBudget  = time.Second
+	classifierRulesBudget = 500 * time.Millisecond
+	personalizationBudget = 500 * time.Millisecond
+	tenantModelsBudget    = 500 * time.Millisecond
+	catalogOverlayBudget  = 500 * time.Millisecond
+)
+
+// Pipeline stages reported in DegradedStages when they ran out of time
+const (
+	StageClassification  = "classification"
+	StageClassifierRules = "classifier_rules"
+	StagePersonalization = "personalization"
+	StageTenantModels    = "tenant_models"
+	StageCatalogOverlay  = "catalog_overlay"
+	StageScoring         = "scoring"
+)
+
+// requestTimeout returns the end-to-end budget, overridable with ROUTER_REQUEST_TIMEOUT (e.g. "10s")
+func requestTimeout() time.Duration {
+	if v := os.Getenv("ROUTER_REQUEST_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultRequestTimeout
+}
+
+// withRequestDeadline applies the default budget when the caller set no deadline
+func withRequestDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, requestTimeout())
+}
+
+// withStageBudget bounds one stage; the request deadline still wins if it is sooner
+func withStageBudget(ctx context.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, budget)
+}
+
+// fillClassificationDefaults completes a classification cut short by its deadline
+// with the same defaults the classifier uses for prompts it cannot place
+func fillClassificationDefaults(result classification.ClassificationResult) classification.ClassificationResult {
+	if result.TaskType == "" {
+		result.TaskType = "text"
+	}
+	if result.Category == "" {
+		result.Category = "writing"
+	}
+	if result.Complexity == "" {
+		result.Complexity = "medium"
+	}
+	if result.ComplexityScore == 0 {
+		result.ComplexityScore = recommendation.ComplexityMidpoint(result.Complexity)
+	}
+	if result.Priority == "" {
+		result.Priority = "balanced"
+	}
+	if result.ReasoningDepth == "" {
+		result.ReasoningDepth = "none"
+	}
+	result.ReasoningSteps = append(result.ReasoningSteps, "Classification deadline exceeded; remaining fields defaulted")
+	return result
+}
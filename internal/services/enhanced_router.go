@@ -3,10 +3,20 @@ package services
 import (
 	"context"
 	"log"
+	"sort"
+	"time"
 
+	"github.com/Askeban/llm-router-go/internal/catalog"
 	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/feedback"
+	"github.com/Askeban/llm-router-go/internal/finetune"
+	"github.com/Askeban/llm-router-go/internal/invalidation"
 	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/overlay"
 	"github.com/Askeban/llm-router-go/internal/recommendation"
+	"github.com/Askeban/llm-router-go/internal/safety"
+	"github.com/Askeban/llm-router-go/internal/trace"
+	"github.com/Askeban/llm-router-go/internal/transport"
 )
 
 // EnhancedRouterService provides the complete AI model routing functionality
@@ -14,6 +24,48 @@ type EnhancedRouterService struct {
 	fusionService       *models.FusionService
 	recommendationEngine *recommendation.EnhancedRecommendationEngine
 	taskClassifier      *classification.TaskClassifier
+	safetyGate          *safety.Gate
+	feedbackStore       *feedback.Store
+	tenantModels        *finetune.Store
+	overlays            *overlay.Store
+	replicator          CatalogReplicator
+	observer            ClassificationObserver
+	classifierOverlays  ClassifierOverlays
+	classifierRules     ClassifierRuleStore
+	decisions           DecisionRecorder
+	memo                *recommendationMemo
+	invalidations       *invalidation.Bus
+}
+
+// ClassificationObserver receives the classifier's output for every smart
+// recommendation, before caller overrides
+type ClassificationObserver interface {
+	ObserveClassification(tenant string, result classification.ClassificationResult)
+}
+
+// DecisionRecorder keeps what each smart recommendation saw and ranked,
+// without the prompt itself, for training rankers. It returns the decision's
+// ID, or "" when the decision is not kept.
+type DecisionRecorder interface {
+	RecordDecision(userID, prompt string, images int, result classification.ClassificationResult, recs recommendation.RecommendationResponse) string
+}
+
+// ClassifierOverlays supplies tenants' classifier rule overlays, compiled
+// over the active rules; nil when the tenant has none
+type ClassifierOverlays interface {
+	RulesFor(ctx context.Context, userID string) (*classification.TenantRules, error)
+}
+
+// ClassifierRuleStore manages the classifier rules in place of the rules
+// file, applying its active version on Sync
+type ClassifierRuleStore interface {
+	Sync(ctx context.Context) error
+}
+
+// CatalogReplicator keeps the catalog in sync across router replicas
+type CatalogReplicator interface {
+	Refresh(ctx context.Context) error
+	Status() interface{}
 }
 
 // SmartRecommendationRequest represents a high-level request with just a prompt
@@ -21,13 +73,45 @@ type SmartRecommendationRequest struct {
 	Prompt   string `json:"prompt"`
 	Context  string `json:"context,omitempty"`
 	UserID   string `json:"user_id,omitempty"`
+	MaxLatencyMs int `json:"max_latency_ms,omitempty"` // Hard end-to-end latency SLO
+	DisablePersonalization bool `json:"disable_personalization,omitempty"` // Opt out of feedback-based adjustments
+	ReasoningEffort string `json:"reasoning_effort,omitempty"` // Overrides the classifier's reasoning depth
+	MaxResults int `json:"max_results,omitempty"` // Recommendations returned, bounded by the caller's plan
+	MinScore *float64 `json:"min_score,omitempty"` // Lowest overall score returned
+	Plan string `json:"-"` // Caller's plan, which bounds MaxResults
+	AllowColdStart bool `json:"allow_cold_start,omitempty"` // Let models without benchmark or community data rank first
+	RAG *recommendation.RAGHint `json:"rag,omitempty"` // The prompt will be sent with retrieved chunks
+	AllowedProviders []string `json:"-"` // Restricts routing, e.g. to providers with native structured output
+	ClassificationOverrides *ClassificationOverrides `json:"classification_overrides,omitempty"` // Replace parts of the classifier's output
+	Attachments []recommendation.Attachment `json:"attachments,omitempty"` // Images sent with the prompt
+	ImageInputs int `json:"-"` // Images carried by a generation's messages, counted with Attachments
+	LatencyTolerance string `json:"latency_tolerance,omitempty"` // "batch" allows batch-tier pricing
+	Requirements map[string]interface{} `json:"requirements,omitempty"` // Hard requirements such as {"tool_use": true}, added to the classifier's
+	PreferredTags []string `json:"preferred_tags,omitempty"` // Soft boost for models carrying these tags, e.g. "agentic"
+	AvoidedTags []string `json:"avoided_tags,omitempty"` // Soft penalty for models carrying these tags
+	OutputLocale string `json:"output_locale,omitempty"` // Locale the answer must be written in, e.g. "ja-JP"
+	UsageTags map[string]string `json:"usage_tags,omitempty"` // Usage attribution such as {"team": "search"}, merged over X-Usage-Tags
+}
+
+// images is the number of images sent with the prompt
+func (r SmartRecommendationRequest) images() int {
+	return len(r.Attachments) + r.ImageInputs
 }
 
 // SmartRecommendationResponse includes both classification and recommendations
 type SmartRecommendationResponse struct {
 	Classification    classification.ClassificationResult      `json:"classification"`
+	ClassifierOutput  *classification.ClassificationResult     `json:"classifier_output,omitempty"` // What the classifier said, when overridden
+	OverriddenFields  []string                                 `json:"overridden_fields,omitempty"`
 	Recommendations   recommendation.RecommendationResponse    `json:"recommendations"`
 	ProcessingTime    float64                                  `json:"total_processing_time_ms"`
+	Safety            *safety.Decision                         `json:"safety,omitempty"`
+	Partial           bool                                     `json:"partial,omitempty"`         // Scoring stopped at the deadline
+	LowConfidenceRouting bool                                  `json:"low_confidence_routing,omitempty"` // Routing hedged because the classifier was unsure
+	RunnerUpCategory  string                                   `json:"runner_up_category,omitempty"`     // Category considered alongside the classified one
+	DegradedStages    []string                                 `json:"degraded_stages,omitempty"` // Stages that fell back after running out of time
+	Memo              *MemoHit                                 `json:"memo,omitempty"`            // The ranking was reused from a similar recent prompt
+	DecisionID        string                                   `json:"decision_id,omitempty"`     // Send it back with feedback to label the decision
 }
 
 func NewEnhancedRouterService(modelPath string) (*EnhancedRouterService, error) {
@@ -37,53 +121,455 @@ func NewEnhancedRouterService(modelPath string) (*EnhancedRouterService, error)
 		return nil, err
 	}
 
+	return newEnhancedRouterService(fusionService), nil
+}
+
+// NewReplicatedRouterService starts from model_1.json only; the fused catalog
+// is supplied afterwards by a CatalogReplicator, so replicas do not each call Analytics AI
+func NewReplicatedRouterService(modelPath string) (*EnhancedRouterService, error) {
+	fusionService := models.NewFusionService(modelPath)
+	if err := fusionService.InitializeBase(); err != nil {
+		return nil, err
+	}
+
+	return newEnhancedRouterService(fusionService), nil
+}
+
+func newEnhancedRouterService(fusionService *models.FusionService) *EnhancedRouterService {
 	// Initialize recommendation engine
 	recommendationEngine := recommendation.NewEnhancedRecommendationEngine(fusionService)
 
 	// Initialize task classifier
 	taskClassifier := classification.NewTaskClassifier()
 
-	return &EnhancedRouterService{
+	ers := &EnhancedRouterService{
 		fusionService:       fusionService,
 		recommendationEngine: recommendationEngine,
 		taskClassifier:      taskClassifier,
-	}, nil
+		memo:                newRecommendationMemo(DefaultMemoConfig()),
+		invalidations:       invalidation.NewBus(),
+	}
+
+	// Catalog changes reach every cache built from the catalog
+	ers.invalidations.Subscribe("recommendation_memo", func(e invalidation.Event) { ers.memo.invalidate(e) })
+	fusionService.SetInvalidationBus(ers.invalidations)
+	ers.invalidations.Start(context.Background())
+	return ers
+}
+
+// InvalidationBus carries catalog changes, for caches outside the router
+// service to subscribe to
+func (ers *EnhancedRouterService) InvalidationBus() *invalidation.Bus {
+	return ers.invalidations
+}
+
+// ConfigureBenchmarkMappings loads the benchmark-to-category mapping file and
+// reloads it whenever it changes
+func (ers *EnhancedRouterService) ConfigureBenchmarkMappings(path string) error {
+	mappings, err := recommendation.NewBenchmarkMappings(path)
+	if err != nil {
+		return err
+	}
+	mappings.Watch(context.Background(), 30*time.Second)
+	ers.recommendationEngine.SetBenchmarkMappings(mappings)
+
+	// The same mappings derive capabilities for models that only ship benchmarks;
+	// mapping changes apply to derived capabilities on the next fusion
+	ers.fusionService.SetCapabilityInferrer(mappings)
+	return nil
+}
+
+// ConfigureClassifierRules layers a rules file over the classifier's built-in
+// rules and reloads it whenever it changes
+func (ers *EnhancedRouterService) ConfigureClassifierRules(path string) error {
+	return ers.taskClassifier.LoadRules(context.Background(), path, 30*time.Second)
+}
+
+// ConfigureFallbacks loads the per-category fallback models from a JSON file
+func (ers *EnhancedRouterService) ConfigureFallbacks(path string) error {
+	fallbacks, err := recommendation.LoadFallbacks(path)
+	if err != nil {
+		return err
+	}
+	ers.recommendationEngine.SetFallbacks(fallbacks)
+	return nil
+}
+
+// Fallbacks returns the per-category fallback models, including admin overrides
+func (ers *EnhancedRouterService) Fallbacks() *recommendation.Fallbacks {
+	return ers.recommendationEngine.Fallbacks()
+}
+
+// ClassifierRules identifies the classifier rules currently serving requests
+func (ers *EnhancedRouterService) ClassifierRules() classification.RulesStatus {
+	return ers.taskClassifier.Rules()
+}
+
+// ReloadClassifierRules re-reads the classifier rules now, from the rule
+// store when one is set and otherwise from the rules file
+func (ers *EnhancedRouterService) ReloadClassifierRules() (classification.RulesStatus, error) {
+	var err error
+	if ers.classifierRules != nil {
+		err = ers.classifierRules.Sync(context.Background())
+	} else {
+		err = ers.taskClassifier.Reload()
+	}
+	ers.memo.reset()
+	return ers.taskClassifier.Rules(), err
+}
+
+// Classifier returns the task classifier, for tools that compile or test rules
+func (ers *EnhancedRouterService) Classifier() *classification.TaskClassifier {
+	return ers.taskClassifier
+}
+
+// SetMemoConfig replaces the recommendation memo, forgetting its rankings;
+// call it before serving
+func (ers *EnhancedRouterService) SetMemoConfig(cfg MemoConfig) {
+	ers.memo = newRecommendationMemo(cfg)
+}
+
+// SetClassifierOverlays merges tenants' rule overlays over the classifier rules
+func (ers *EnhancedRouterService) SetClassifierOverlays(overlays ClassifierOverlays) {
+	ers.classifierOverlays = overlays
+}
+
+// SetClassifierRuleStore moves the classifier rules into a rule store
+func (ers *EnhancedRouterService) SetClassifierRuleStore(store ClassifierRuleStore) {
+	ers.classifierRules = store
+}
+
+// BenchmarkMappings returns the effective benchmark-to-category mappings
+func (ers *EnhancedRouterService) BenchmarkMappings() map[string]interface{} {
+	return ers.recommendationEngine.BenchmarkMappings().Effective()
+}
+
+// FusionService exposes the underlying catalog for replication
+func (ers *EnhancedRouterService) FusionService() *models.FusionService {
+	return ers.fusionService
+}
+
+// SetCatalogReplicator routes refreshes through the replicator instead of fusing locally
+func (ers *EnhancedRouterService) SetCatalogReplicator(replicator CatalogReplicator) {
+	ers.replicator = replicator
+}
+
+// SetClassificationObserver reports classifier output, e.g. for drift monitoring
+func (ers *EnhancedRouterService) SetClassificationObserver(observer ClassificationObserver) {
+	ers.observer = observer
+}
+
+// SetSafetyGate enables the content safety stage for smart recommendations
+func (ers *EnhancedRouterService) SetSafetyGate(gate *safety.Gate) {
+	ers.safetyGate = gate
+}
+
+// SetFeedbackStore enables personalization from tenant feedback
+func (ers *EnhancedRouterService) SetFeedbackStore(store *feedback.Store) {
+	ers.feedbackStore = store
 }
 
-// GetSmartRecommendations analyzes a prompt and provides intelligent recommendations
-func (ers *EnhancedRouterService) GetSmartRecommendations(req SmartRecommendationRequest) SmartRecommendationResponse {
+// SetTenantModels lets tenants route to their registered fine-tuned models
+func (ers *EnhancedRouterService) SetTenantModels(store *finetune.Store) {
+	ers.tenantModels = store
+}
+
+// SetCalibrator calibrates recommendation confidence against feedback outcomes
+func (ers *EnhancedRouterService) SetCalibrator(calibrator recommendation.Calibrator) {
+	ers.recommendationEngine.SetCalibrator(calibrator)
+}
+
+// SetWeightSource scores recommendations with tuned component weights
+func (ers *EnhancedRouterService) SetWeightSource(source recommendation.WeightSource) {
+	ers.recommendationEngine.SetWeightSource(source)
+}
+
+// SetDecisionRecorder keeps smart recommendation decisions for training exports
+func (ers *EnhancedRouterService) SetDecisionRecorder(recorder DecisionRecorder) {
+	ers.decisions = recorder
+}
+
+// SetColdStarts adds the expected load time of self-hosted models to latency estimates
+func (ers *EnhancedRouterService) SetColdStarts(estimator recommendation.ColdStartEstimator) {
+	ers.recommendationEngine.SetColdStarts(estimator)
+}
+
+// SetOutputHistory bases expected answer lengths on observed generations per category
+func (ers *EnhancedRouterService) SetOutputHistory(history recommendation.OutputHistory) {
+	ers.recommendationEngine.SetOutputHistory(history)
+}
+
+// RegisterScorer adds a deployment-specific score component to ranking.
+// Scorers run in ascending order, then by name.
+func (ers *EnhancedRouterService) RegisterScorer(scorer recommendation.ComponentScorer, order int) error {
+	return ers.recommendationEngine.Scorers().Register(scorer, order)
+}
+
+// ScorerStats reports the registered scorers and their per-scorer metrics
+func (ers *EnhancedRouterService) ScorerStats() []recommendation.ScorerStats {
+	return ers.recommendationEngine.Scorers().Stats()
+}
+
+// SetCatalogOverlays applies tenants' catalog overlays to routing and model listings
+func (ers *EnhancedRouterService) SetCatalogOverlays(store *overlay.Store) {
+	ers.overlays = store
+}
+
+// CatalogOverlay returns the tenant's catalog overlay, or nil when it has none
+func (ers *EnhancedRouterService) CatalogOverlay(ctx context.Context, userID string) (*overlay.Overlay, error) {
+	if ers.overlays == nil || userID == "" {
+		return nil, nil
+	}
+	return ers.overlays.Get(ctx, userID)
+}
+
+// GetSmartRecommendations analyzes a prompt and provides intelligent recommendations.
+// Each stage runs within its own budget under the request deadline; a stage that
+// runs out of time falls back and is listed in DegradedStages.
+func (ers *EnhancedRouterService) GetSmartRecommendations(ctx context.Context, req SmartRecommendationRequest) SmartRecommendationResponse {
 	startTime := getCurrentTimeMs()
+	ctx, cancel := withRequestDeadline(ctx)
+	defer cancel()
+
+	// Step 0: Content safety gate (falls back to the default policy on its own errors)
+	var safetyDecision *safety.Decision
+	if ers.safetyGate != nil {
+		decision := ers.safetyGate.Evaluate(ctx, req.UserID, req.Prompt)
+		safetyDecision = &decision
+		if decision.Blocked() {
+			trace.Logf(ctx, "[ROUTER] Prompt blocked by safety policy: %v", decision.Categories)
+			return SmartRecommendationResponse{
+				Recommendations: recommendation.RecommendationResponse{
+					Recommendations: []recommendation.ScoredRecommendation{},
+				},
+				Safety: safetyDecision,
+			}
+		}
+	}
+
+	// Reuse the ranking of a near-identical recent prompt. Safety restrictions
+	// are per prompt, so prompts routed to safe models are always scored.
+	memoable := safetyDecision == nil || safetyDecision.Action != safety.ActionRouteSafe
+	if memoable {
+		if cached, hit, ok := ers.memo.lookup(req); ok {
+			if ers.observer != nil {
+				classifierOutput := cached.Classification
+				if cached.ClassifierOutput != nil {
+					classifierOutput = *cached.ClassifierOutput
+				}
+				ers.observer.ObserveClassification(req.UserID, classifierOutput)
+			}
+			cached.Safety = safetyDecision
+			cached.Memo = hit
+			cached.ProcessingTime = getCurrentTimeMs() - startTime
+			cached.DecisionID = ers.recordDecision(req, cached)
+			return cached
+		}
+	}
 
 	// Step 1: Classify the prompt
-	log.Printf("[ROUTER] Classifying prompt: %s", truncateString(req.Prompt, 100))
-	classification := ers.taskClassifier.ClassifyPrompt(req.Prompt)
+	trace.Logf(ctx, "[ROUTER] Classifying prompt: %s", truncateString(req.Prompt, 100))
+	classifierOutput, degraded := ers.classify(ctx, req.UserID, req.Prompt)
+	if req.images() > 0 {
+		classifierOutput = classification.WithImageInput(classifierOutput, req.Prompt)
+	}
+	if ers.observer != nil && len(degraded) == 0 {
+		ers.observer.ObserveClassification(req.UserID, classifierOutput)
+	}
+	classification, overridden := applyOverrides(classifierOutput, req.ClassificationOverrides)
 
 	// Step 2: Convert to recommendation request
-	recRequest := ers.taskClassifier.ConvertToRecommendationRequest(classification, req.Context)
+	recRequest, buildDegraded := ers.buildRecommendationRequest(ctx, req, classification, safetyDecision)
+	degraded = append(degraded, buildDegraded...)
+	recRequest.Hedge = lowConfidenceHedge(classification, overridden)
 
 	// Step 3: Get recommendations
-	log.Printf("[ROUTER] Getting recommendations for task_type=%s, category=%s, complexity=%s", 
+	trace.Logf(ctx, "[ROUTER] Getting recommendations for task_type=%s, category=%s, complexity=%s", 
 		recRequest.TaskType, recRequest.Category, recRequest.Complexity)
-	recommendations := ers.recommendationEngine.GetRecommendations(recRequest)
+	recommendations := ers.recommendationEngine.GetRecommendations(ctx, recRequest)
+	if recommendations.LowConfidenceRouting {
+		trace.Logf(ctx, "[ROUTER] Low classifier confidence %.2f, hedging with runner-up category %q",
+			classification.Confidence, recommendations.RunnerUpCategory)
+	}
+	if recommendations.Partial {
+		trace.Logf(ctx, "[ROUTER] Scoring deadline exceeded, returning %d partial recommendations",
+			len(recommendations.Recommendations))
+		degraded = append(degraded, StageScoring)
+	}
 
 	endTime := getCurrentTimeMs()
 	totalTime := endTime - startTime
 
-	log.Printf("[ROUTER] Smart recommendation complete in %.2fms - %d recommendations", 
+	trace.Logf(ctx, "[ROUTER] Smart recommendation complete in %.2fms - %d recommendations", 
 		totalTime, len(recommendations.Recommendations))
 
-	return SmartRecommendationResponse{
+	response := SmartRecommendationResponse{
 		Classification:  classification,
 		Recommendations: recommendations,
 		ProcessingTime:  totalTime,
+		Safety:          safetyDecision,
+		Partial:         recommendations.Partial,
+		DegradedStages:  degraded,
+		LowConfidenceRouting: recommendations.LowConfidenceRouting,
+		RunnerUpCategory: recommendations.RunnerUpCategory,
 	}
+	if len(overridden) > 0 {
+		response.ClassifierOutput = &classifierOutput
+		response.OverriddenFields = overridden
+	}
+	// Only complete rankings are worth reusing
+	if memoable && len(degraded) == 0 {
+		ers.memo.store(req, response)
+	}
+	response.DecisionID = ers.recordDecision(req, response)
+	return response
+}
+
+// recordDecision keeps the decision for training data when a recorder is set
+func (ers *EnhancedRouterService) recordDecision(req SmartRecommendationRequest, response SmartRecommendationResponse) string {
+	if ers.decisions == nil || len(response.Recommendations.Recommendations) == 0 {
+		return ""
+	}
+	return ers.decisions.RecordDecision(req.UserID, req.Prompt, req.images(), response.Classification, response.Recommendations)
+}
+
+// classify runs the classifier within its stage budget, with the tenant's rule
+// overlay when it has one, defaulting whatever it could not determine in time.
+// If the overlay cannot be loaded the base rules are used.
+func (ers *EnhancedRouterService) classify(ctx context.Context, userID, prompt string) (classification.ClassificationResult, []string) {
+	var degraded []string
+	var tenantRules *classification.TenantRules
+	if ers.classifierOverlays != nil && userID != "" {
+		stageCtx, cancel := withStageBudget(ctx, classifierRulesBudget)
+		rules, err := ers.classifierOverlays.RulesFor(stageCtx, userID)
+		cancel()
+		if err != nil {
+			if stageCtx.Err() != nil {
+				degraded = append(degraded, StageClassifierRules)
+			}
+			trace.Logf(ctx, "[ROUTER] Classifier rule overlay unavailable: %v", err)
+		} else {
+			tenantRules = rules
+		}
+	}
+
+	stageCtx, cancel := withStageBudget(ctx, classificationBudget)
+	defer cancel()
+
+	result, err := ers.taskClassifier.ClassifyPromptWith(stageCtx, prompt, tenantRules)
+	if err != nil {
+		trace.Logf(ctx, "[ROUTER] Classification deadline exceeded, using defaults: %v", err)
+		return fillClassificationDefaults(result), append(degraded, StageClassification)
+	}
+	return result, degraded
+}
+
+// buildRecommendationRequest turns a classified prompt into an engine request with
+// the caller's overrides, personalization and safety restrictions applied.
+// Personalization is skipped, and reported as degraded, if it misses its budget.
+func (ers *EnhancedRouterService) buildRecommendationRequest(ctx context.Context, req SmartRecommendationRequest, classification classification.ClassificationResult, safetyDecision *safety.Decision) (recommendation.RecommendationRequest, []string) {
+	var degraded []string
+	recRequest := ers.taskClassifier.ConvertToRecommendationRequest(classification, req.Context)
+	recRequest.MaxLatencyMs = req.MaxLatencyMs
+	recRequest.MaxResults = req.MaxResults
+	recRequest.MinScore = req.MinScore
+	recRequest.PlanMaxResults = recommendation.MaxResultsForPlan(req.Plan)
+	recRequest.AllowColdStart = req.AllowColdStart
+	recRequest.ImageInputs = req.images()
+	recRequest.LatencyTolerance = req.LatencyTolerance
+	recRequest.PreferredTags = req.PreferredTags
+	recRequest.AvoidedTags = req.AvoidedTags
+	recRequest.OutputLocale = req.OutputLocale
+	recRequest.Subject = req.UserID
+	outputEstimate := ers.recommendationEngine.EstimateOutput(req.Prompt, recRequest)
+	recRequest.ExpectedOutputTokens, recRequest.OutputSource = outputEstimate.Tokens, outputEstimate.Source
+	if req.RAG != nil {
+		rag := *req.RAG
+		if rag.PromptTokens == 0 {
+			rag.PromptTokens = len(req.Prompt) / 4
+		}
+		recRequest.RAG = &rag
+	}
+	if req.ReasoningEffort != "" {
+		recRequest.ReasoningEffort = req.ReasoningEffort
+	}
+
+	// Personalize with the tenant's feedback history unless opted out
+	if ers.feedbackStore != nil && req.UserID != "" && !req.DisablePersonalization {
+		stageCtx, cancel := withStageBudget(ctx, personalizationBudget)
+		adjustments, err := ers.feedbackStore.Adjustments(stageCtx, req.UserID, recRequest.Category)
+		cancel()
+		if err != nil {
+			if stageCtx.Err() != nil {
+				degraded = append(degraded, StagePersonalization)
+			}
+			trace.Logf(ctx, "[ROUTER] Personalization unavailable: %v", err)
+		} else {
+			recRequest.Personalization = adjustments
+		}
+	}
+	// The tenant's overlay hides models and swaps in negotiated prices
+	if ers.overlays != nil && req.UserID != "" {
+		stageCtx, cancel := withStageBudget(ctx, catalogOverlayBudget)
+		catalogOverlay, err := ers.overlays.Get(stageCtx, req.UserID)
+		cancel()
+		if err != nil {
+			if stageCtx.Err() != nil {
+				degraded = append(degraded, StageCatalogOverlay)
+			}
+			trace.Logf(ctx, "[ROUTER] Catalog overlay unavailable: %v", err)
+		} else {
+			recRequest.Overlay = catalogOverlay
+		}
+	}
+	// The tenant's fine-tuned models compete with the shared catalog
+	if ers.tenantModels != nil && req.UserID != "" {
+		stageCtx, cancel := withStageBudget(ctx, tenantModelsBudget)
+		tenantModels, err := ers.tenantModels.Resolve(stageCtx, req.UserID, ers.fusionService.GetModelByID)
+		cancel()
+		if err != nil {
+			if stageCtx.Err() != nil {
+				degraded = append(degraded, StageTenantModels)
+			}
+			trace.Logf(ctx, "[ROUTER] Tenant models unavailable: %v", err)
+		} else {
+			recRequest.TenantModels = tenantModels
+		}
+	}
+	if len(req.Requirements) > 0 {
+		// Copy so the caller's requirements don't leak into the classification
+		requirements := make(map[string]interface{}, len(recRequest.Requirements)+len(req.Requirements))
+		for k, v := range recRequest.Requirements {
+			requirements[k] = v
+		}
+		for k, v := range req.Requirements {
+			requirements[k] = v
+		}
+		recRequest.Requirements = requirements
+	}
+	if len(req.AllowedProviders) > 0 {
+		if recRequest.Requirements == nil {
+			recRequest.Requirements = make(map[string]interface{})
+		}
+		recRequest.Requirements["allowed_providers"] = req.AllowedProviders
+	}
+	if safetyDecision != nil && safetyDecision.Action == safety.ActionRouteSafe {
+		if recRequest.Requirements == nil {
+			recRequest.Requirements = make(map[string]interface{})
+		}
+		recRequest.Requirements["allowed_models"] = safetyDecision.SafeModels
+	}
+
+	return recRequest, degraded
 }
 
 // GetDirectRecommendations provides recommendations with explicit parameters
-func (ers *EnhancedRouterService) GetDirectRecommendations(req recommendation.RecommendationRequest) recommendation.RecommendationResponse {
-	log.Printf("[ROUTER] Getting direct recommendations for task_type=%s, category=%s", 
+func (ers *EnhancedRouterService) GetDirectRecommendations(ctx context.Context, req recommendation.RecommendationRequest) recommendation.RecommendationResponse {
+	trace.Logf(ctx, "[ROUTER] Getting direct recommendations for task_type=%s, category=%s", 
 		req.TaskType, req.Category)
-	return ers.recommendationEngine.GetRecommendations(req)
+	ctx, cancel := withRequestDeadline(ctx)
+	defer cancel()
+	return ers.recommendationEngine.GetRecommendations(ctx, req)
 }
 
 // GetAllModels returns all available models with their metadata
@@ -101,9 +587,22 @@ func (ers *EnhancedRouterService) GetModelByID(id string) (models.EnhancedModel,
 	return ers.fusionService.GetModelByID(id)
 }
 
+// SearchModels evaluates a catalog query against the fused catalog, ordered
+// by model ID so pages of the results are stable
+func (ers *EnhancedRouterService) SearchModels(query string) ([]models.EnhancedModel, error) {
+	q, err := catalog.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	matches := q.Filter(ers.fusionService.GetAllModels())
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	return matches, nil
+}
+
 // GetStats returns service statistics
 func (ers *EnhancedRouterService) GetStats() map[string]interface{} {
 	stats := ers.fusionService.GetStats()
+	stats["captured_at"] = time.Now()
 	
 	// Add router-specific stats
 	stats["service_type"] = "enhanced_router"
@@ -114,6 +613,12 @@ func (ers *EnhancedRouterService) GetStats() map[string]interface{} {
 		"community_intelligence",
 		"complexity_scoring",
 	}
+	if ers.replicator != nil {
+		stats["catalog_replication"] = ers.replicator.Status()
+	}
+	stats["http_clients"] = transport.Stats()
+	stats["recommendation_memo"] = ers.memo.stats()
+	stats["catalog_invalidation"] = ers.invalidations.Stats()
 	
 	return stats
 }
@@ -121,6 +626,10 @@ func (ers *EnhancedRouterService) GetStats() map[string]interface{} {
 // RefreshData triggers a refresh of underlying data sources
 func (ers *EnhancedRouterService) RefreshData(ctx context.Context) error {
 	log.Printf("[ROUTER] Refreshing data sources...")
+	ers.memo.reset()
+	if ers.replicator != nil {
+		return ers.replicator.Refresh(ctx)
+	}
 	return ers.fusionService.RefreshData(ctx)
 }
 
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// ResolveModel picks the model a generation calls: modelID from the caller's
+// view of the shared catalog or their fine-tuned models, or the top smart
+// recommendation for the prompt when modelID is empty. A non-empty providers list restricts
+// that pick to those providers. When the messages carry images, the model must read them.
+// The pick must be known to support the required features; a named model is
+// only refused for features it is known to lack.
+func (ers *EnhancedRouterService) ResolveModel(ctx context.Context, userID, modelID, prompt string, images int, providers []string, requirements map[string]interface{}) (models.EnhancedModel, error) {
+	if modelID == "" {
+		picks, err := ers.ResolveModels(ctx, userID, prompt, images, providers, requirements, 1)
+		if err != nil {
+			return models.EnhancedModel{}, err
+		}
+		return picks[0], nil
+	}
+
+	if model, ok := ers.fusionService.GetModelByID(modelID); ok {
+		catalogOverlay, err := ers.CatalogOverlay(ctx, userID)
+		if err != nil {
+			return models.EnhancedModel{}, err
+		}
+		if !catalogOverlay.Visible(model) {
+			return models.EnhancedModel{}, fmt.Errorf("model %s is excluded by your catalog overlay", modelID)
+		}
+		if held, reason := model.Unavailable(time.Now()); held {
+			return models.EnhancedModel{}, fmt.Errorf("model %s is out of rotation: %s", modelID, reason)
+		}
+		if images > 0 && !model.AcceptsImageInput() {
+			return models.EnhancedModel{}, fmt.Errorf("model %s does not accept image input", modelID)
+		}
+		if missing := model.MissingFeatures(requirements, false); len(missing) > 0 {
+			return models.EnhancedModel{}, fmt.Errorf("model %s does not support %s", modelID, strings.Join(missing, ", "))
+		}
+		if missing := model.MissingCompliance(models.ComplianceRequirementsFrom(requirements)); len(missing) > 0 {
+			return models.EnhancedModel{}, fmt.Errorf("model %s does not meet %s", modelID, strings.Join(missing, ", "))
+		}
+		return catalogOverlay.Annotate(model), nil
+	}
+	if ers.tenantModels != nil && userID != "" {
+		tenantModels, err := ers.tenantModels.Resolve(ctx, userID, ers.fusionService.GetModelByID)
+		if err != nil {
+			return models.EnhancedModel{}, err
+		}
+		for _, model := range tenantModels {
+			if model.ID == modelID {
+				if images > 0 && !model.AcceptsImageInput() {
+					return models.EnhancedModel{}, fmt.Errorf("model %s does not accept image input", modelID)
+				}
+				if missing := model.MissingFeatures(requirements, false); len(missing) > 0 {
+					return models.EnhancedModel{}, fmt.Errorf("model %s does not support %s", modelID, strings.Join(missing, ", "))
+				}
+				if missing := model.MissingCompliance(models.ComplianceRequirementsFrom(requirements)); len(missing) > 0 {
+					return models.EnhancedModel{}, fmt.Errorf("model %s does not meet %s", modelID, strings.Join(missing, ", "))
+				}
+				return model, nil
+			}
+		}
+	}
+	return models.EnhancedModel{}, fmt.Errorf("model %s not found", modelID)
+}
+
+// PromptCategory classifies a generation's prompt with the tenant's classifier
+// rules, for usage records
+func (ers *EnhancedRouterService) PromptCategory(ctx context.Context, userID, prompt string) string {
+	result, _ := ers.classify(ctx, userID, prompt)
+	return result.Category
+}
+
+// EstimateOutputTokens predicts the length of a generation's answer from its
+// classified prompt, for planning max_tokens
+func (ers *EnhancedRouterService) EstimateOutputTokens(ctx context.Context, userID, prompt string) (int, string) {
+	result, _ := ers.classify(ctx, userID, prompt)
+	estimate := ers.recommendationEngine.EstimateOutput(prompt, ers.taskClassifier.ConvertToRecommendationRequest(result, ""))
+	return estimate.Tokens, estimate.Source
+}
+
+// ResolveModels returns up to n of the top smart recommendations for the
+// prompt, best first, under the constraints ResolveModel applies when no
+// model is named
+func (ers *EnhancedRouterService) ResolveModels(ctx context.Context, userID, prompt string, images int, providers []string, requirements map[string]interface{}, n int) ([]models.EnhancedModel, error) {
+	response := ers.GetSmartRecommendations(ctx, SmartRecommendationRequest{Prompt: prompt, UserID: userID, AllowedProviders: providers, ImageInputs: images, Requirements: requirements})
+	if response.Safety != nil && response.Safety.Blocked() {
+		return nil, fmt.Errorf("prompt blocked by safety policy")
+	}
+	recs := response.Recommendations.Recommendations
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("no eligible model for this prompt")
+	}
+	if len(recs) > n {
+		recs = recs[:n]
+	}
+	picks := make([]models.EnhancedModel, len(recs))
+	for i, rec := range recs {
+		picks[i] = rec.Model
+	}
+	return picks, nil
+}
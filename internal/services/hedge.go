@@ -0,0 +1,40 @@
+package services
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// defaultLowConfidence is the classifier confidence below which routing hedges
+const defaultLowConfidence = 0.4
+
+// lowConfidenceThreshold returns the confidence below which routing hedges,
+// overridable with ROUTER_LOW_CONFIDENCE (0 disables hedging)
+func lowConfidenceThreshold() float64 {
+	if v := os.Getenv("ROUTER_LOW_CONFIDENCE"); v != "" {
+		if t, err := strconv.ParseFloat(v, 64); err == nil && t >= 0 && t <= 1 {
+			return t
+		}
+	}
+	return defaultLowConfidence
+}
+
+// lowConfidenceHedge returns a hedge when the classifier was unsure of the
+// category: its confidence is below the threshold or no category rule
+// matched. A category the caller chose is never hedged.
+func lowConfidenceHedge(result classification.ClassificationResult, overridden []string) *recommendation.Hedge {
+	threshold := lowConfidenceThreshold()
+	if threshold == 0 || containsValue(overridden, "category") {
+		return nil
+	}
+	if result.Confidence >= threshold && !result.CategoryFallback {
+		return nil
+	}
+	return &recommendation.Hedge{
+		Confidence:       result.Confidence,
+		RunnerUpCategory: result.RunnerUpCategory,
+	}
+}
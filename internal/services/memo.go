@@ -0,0 +1,251 @@
+package services
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/fingerprint"
+	"github.com/Askeban/llm-router-go/internal/invalidation"
+)
+
+// MemoConfig tunes the recommendation memo
+type MemoConfig struct {
+	Enabled    bool
+	Similarity float64       // Lowest simhash similarity, 1 - differing bits / 64, at which a ranking is reused
+	TTL        time.Duration // How long a ranking may be reused
+	MaxEntries int           // Rankings kept per replica; the oldest are evicted first
+}
+
+// minMemoSimilarity bounds how loosely the memo may match, keeping the band
+// count, and so the lookups per request, small
+const minMemoSimilarity = 0.75
+
+// DefaultMemoConfig returns the built-in memo settings; RECOMMENDATION_MEMO=false
+// disables it and RECOMMENDATION_MEMO_SIMILARITY and RECOMMENDATION_MEMO_TTL
+// tune it
+func DefaultMemoConfig() MemoConfig {
+	cfg := MemoConfig{
+		Enabled:    os.Getenv("RECOMMENDATION_MEMO") != "false",
+		Similarity: 0.8, // 12 differing bits, what prompt fingerprinting calls a near duplicate
+		TTL:        5 * time.Minute,
+		MaxEntries: 10000,
+	}
+	if s, err := strconv.ParseFloat(os.Getenv("RECOMMENDATION_MEMO_SIMILARITY"), 64); err == nil && s >= minMemoSimilarity && s <= 1 {
+		cfg.Similarity = s
+	}
+	if d, err := time.ParseDuration(os.Getenv("RECOMMENDATION_MEMO_TTL")); err == nil && d > 0 {
+		cfg.TTL = d
+	}
+	return cfg
+}
+
+// MemoHit describes a response whose ranking was reused from a similar prompt
+type MemoHit struct {
+	Similarity float64 `json:"similarity"`
+	AgeMs      int64   `json:"age_ms"` // Since the reused ranking was scored
+}
+
+// MemoStats counts memo lookups on this replica
+type MemoStats struct {
+	Enabled    bool    `json:"enabled"`
+	Similarity float64 `json:"similarity"`
+	TTL        string  `json:"ttl"`
+	Entries    int     `json:"entries"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+}
+
+type memoEntry struct {
+	scope    uint64 // Tenant and constraints
+	simHash  uint64
+	response SmartRecommendationResponse
+	at       time.Time
+	buckets  []uint64
+}
+
+// recommendationMemo reuses the ranking of a recently routed prompt for a
+// semantically near one from the same tenant under the same constraints.
+// Prompts are compared by simhash, split into bands one more than the bits
+// two prompts may differ by, so any match shares at least one band exactly
+// and is found in that band's bucket.
+type recommendationMemo struct {
+	cfg         MemoConfig
+	maxDistance int
+
+	mu      sync.Mutex
+	buckets map[uint64][]*memoEntry
+	order   []*memoEntry // Oldest first
+	hits    int64
+	misses  int64
+}
+
+func newRecommendationMemo(cfg MemoConfig) *recommendationMemo {
+	maxDistance := int(math.Floor(64 * (1 - cfg.Similarity)))
+	return &recommendationMemo{
+		cfg:         cfg,
+		maxDistance: maxDistance,
+		buckets:     make(map[uint64][]*memoEntry),
+	}
+}
+
+// memoScope keys everything besides the prompt that shapes a ranking. Requests
+// that differ in any of it, the tenant included, never share a ranking.
+func memoScope(req SmartRecommendationRequest) uint64 {
+	scope := struct {
+		Request          SmartRecommendationRequest
+		Plan             string
+		AllowedProviders []string
+		ImageInputs      int
+	}{req, req.Plan, req.AllowedProviders, req.ImageInputs}
+	scope.Request.Prompt = ""
+
+	encoded, _ := json.Marshal(scope)
+	h := fnv.New64a()
+	h.Write(encoded)
+	return h.Sum64()
+}
+
+// bucketKeys returns the bucket of each simhash band within a scope
+func (m *recommendationMemo) bucketKeys(scope, simHash uint64) []uint64 {
+	bands := m.maxDistance + 1
+	keys := make([]uint64, 0, bands)
+	for i := 0; i < bands; i++ {
+		start, end := i*64/bands, (i+1)*64/bands
+		band := simHash >> uint(start)
+		if end-start < 64 {
+			band &= 1<<uint(end-start) - 1
+		}
+
+		var buf [17]byte
+		for b := 0; b < 8; b++ {
+			buf[b] = byte(scope >> (8 * b))
+			buf[8+b] = byte(band >> (8 * b))
+		}
+		buf[16] = byte(i)
+		h := fnv.New64a()
+		h.Write(buf[:])
+		keys = append(keys, h.Sum64())
+	}
+	return keys
+}
+
+// lookup returns the freshest ranking stored for a prompt near this one
+func (m *recommendationMemo) lookup(req SmartRecommendationRequest) (SmartRecommendationResponse, *MemoHit, bool) {
+	if !m.cfg.Enabled || req.Prompt == "" {
+		return SmartRecommendationResponse{}, nil, false
+	}
+	scope := memoScope(req)
+	simHash := fingerprint.Compute(req.Prompt).SimHash
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var best *memoEntry
+	bestDistance := m.maxDistance + 1
+	for _, key := range m.bucketKeys(scope, simHash) {
+		for _, e := range m.buckets[key] {
+			if e.scope != scope || now.Sub(e.at) > m.cfg.TTL {
+				continue
+			}
+			d := fingerprint.Fingerprint{SimHash: simHash}.Distance(fingerprint.Fingerprint{SimHash: e.simHash})
+			if d < bestDistance || (d == bestDistance && best != nil && e.at.After(best.at)) {
+				best, bestDistance = e, d
+			}
+		}
+	}
+	if best == nil {
+		m.misses++
+		return SmartRecommendationResponse{}, nil, false
+	}
+	m.hits++
+	return best.response, &MemoHit{
+		Similarity: 1 - float64(bestDistance)/64,
+		AgeMs:      now.Sub(best.at).Milliseconds(),
+	}, true
+}
+
+// store remembers a complete ranking for the prompt
+func (m *recommendationMemo) store(req SmartRecommendationRequest, response SmartRecommendationResponse) {
+	if !m.cfg.Enabled || req.Prompt == "" {
+		return
+	}
+	scope := memoScope(req)
+	simHash := fingerprint.Compute(req.Prompt).SimHash
+	e := &memoEntry{scope: scope, simHash: simHash, response: response, at: time.Now()}
+	e.buckets = m.bucketKeys(scope, simHash)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.order) >= m.cfg.MaxEntries && len(m.order) > 0 {
+		m.evict(m.order[0])
+		m.order = m.order[1:]
+	}
+	m.order = append(m.order, e)
+	for _, key := range e.buckets {
+		m.buckets[key] = append(m.buckets[key], e)
+	}
+}
+
+// evict removes an entry from its buckets
+func (m *recommendationMemo) evict(e *memoEntry) {
+	for _, key := range e.buckets {
+		bucket := m.buckets[key]
+		for i, other := range bucket {
+			if other == e {
+				bucket = append(bucket[:i], bucket[i+1:]...)
+				break
+			}
+		}
+		if len(bucket) == 0 {
+			delete(m.buckets, key)
+		} else {
+			m.buckets[key] = bucket
+		}
+	}
+}
+
+// reset forgets every ranking, e.g. after the catalog or rules change
+func (m *recommendationMemo) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets = make(map[uint64][]*memoEntry)
+	m.order = nil
+}
+
+// invalidate forgets every ranking on a catalog change. Dropping only the
+// rankings that name a changed model is not enough, since a model that got
+// cheaper or recovered from an outage can now outrank the ones stored.
+func (m *recommendationMemo) invalidate(e invalidation.Event) {
+	m.mu.Lock()
+	entries := len(m.order)
+	m.mu.Unlock()
+	if entries == 0 {
+		return
+	}
+	m.reset()
+	if e.All() {
+		log.Printf("[ROUTER] Recommendation memo cleared after %s change", e.Source)
+		return
+	}
+	log.Printf("[ROUTER] Recommendation memo cleared after %s change to %d models", e.Source, len(e.Models)+len(e.Removed))
+}
+
+func (m *recommendationMemo) stats() MemoStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return MemoStats{
+		Enabled:    m.cfg.Enabled,
+		Similarity: m.cfg.Similarity,
+		TTL:        m.cfg.TTL.String(),
+		Entries:    len(m.order),
+		Hits:       m.hits,
+		Misses:     m.misses,
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// ClassificationOverrides replace the parts of the classifier's output the
+// caller knows better. Empty fields keep the classifier's answer.
+type ClassificationOverrides struct {
+	TaskType   string `json:"task_type,omitempty"`
+	Category   string `json:"category,omitempty"` // A category or "category/subcategory"
+	Complexity string `json:"complexity,omitempty"`
+}
+
+var (
+	overrideTaskTypes    = []string{"text", "image", "video", "audio", "multimodal"}
+	overrideComplexities = []string{"simple", "medium", "hard", "expert"}
+)
+
+// ValidateOverrides rejects override values the engine cannot route on
+func (ers *EnhancedRouterService) ValidateOverrides(o *ClassificationOverrides) error {
+	if o == nil {
+		return nil
+	}
+	if o.TaskType != "" && !containsValue(overrideTaskTypes, o.TaskType) {
+		return fmt.Errorf("unknown task_type %q (valid: %v)", o.TaskType, overrideTaskTypes)
+	}
+	if o.Category != "" {
+		category, subcategory := models.SplitCapability(o.Category)
+		if categories := ers.taskClassifier.Categories(); !containsValue(categories, category) {
+			return fmt.Errorf("unknown category %q (valid: %v)", o.Category, categories)
+		}
+		if err := models.ValidateSubcategory(category, subcategory); err != nil {
+			return err
+		}
+	}
+	if o.Complexity != "" && !containsValue(overrideComplexities, o.Complexity) {
+		return fmt.Errorf("unknown complexity %q (valid: %v)", o.Complexity, overrideComplexities)
+	}
+	return nil
+}
+
+// applyOverrides returns the classification routed on and the fields the
+// caller changed. The classifier's own answer is left untouched in result so
+// it can be reported for calibration.
+func applyOverrides(result classification.ClassificationResult, o *ClassificationOverrides) (classification.ClassificationResult, []string) {
+	if o == nil {
+		return result, nil
+	}
+
+	routed := result
+	routed.ReasoningSteps = append([]string(nil), result.ReasoningSteps...)
+	var changed []string
+	override := func(field, value string, target *string) {
+		if value == "" || value == *target {
+			return
+		}
+		routed.ReasoningSteps = append(routed.ReasoningSteps,
+			fmt.Sprintf("Caller overrode %s: %s (classifier said %s)", field, value, *target))
+		*target = value
+		changed = append(changed, field)
+	}
+	override("task_type", o.TaskType, &routed.TaskType)
+	var subcategory string
+	if o.Category != "" {
+		var category string
+		category, subcategory = models.SplitCapability(o.Category)
+		override("category", category, &routed.Category)
+	}
+	override("complexity", o.Complexity, &routed.Complexity)
+	if routed.Complexity != result.Complexity {
+		// The classifier's score placed the prompt within its own level
+		routed.ComplexityScore = recommendation.ComplexityMidpoint(routed.Complexity)
+	}
+
+	// A subcategory and runner-up only apply to the category they were detected for
+	if routed.Category != result.Category {
+		routed.Subcategory = ""
+		routed.RunnerUpCategory = ""
+	}
+	override("subcategory", subcategory, &routed.Subcategory)
+	return routed, changed
+}
+
+func containsValue(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
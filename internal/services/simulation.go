@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+	"github.com/Askeban/llm-router-go/internal/safety"
+)
+
+// SimulationPriorities are the what-if scenarios evaluated when none are requested
+var SimulationPriorities = []string{"balanced", "quality", "cost", "speed"}
+
+const defaultSimulatedRequestsPerMonth = 1000
+
+// SimulationRequest asks how routing would change under different priorities
+type SimulationRequest struct {
+	SmartRecommendationRequest
+	Priorities       []string `json:"priorities,omitempty"`         // Defaults to all of SimulationPriorities
+	RequestsPerMonth int      `json:"requests_per_month,omitempty"` // Volume used for monthly projections
+	TopN             int      `json:"top_n,omitempty"`              // Recommendations kept per scenario
+}
+
+// PriorityScenario is the routing outcome under one priority
+type PriorityScenario struct {
+	Priority        string                                `json:"priority"`
+	TopModel        string                                `json:"top_model,omitempty"`
+	QualityScore    float64                               `json:"quality_score"` // Task capability of the top model, comparable across priorities
+	CostPerRequest  float64                               `json:"cost_per_request"`
+	MonthlyCost     float64                               `json:"monthly_cost"`
+	MonthlySavings  float64                               `json:"monthly_savings"` // Versus the baseline priority; negative means more expensive
+	QualityDelta    float64                               `json:"quality_delta"`
+	LatencyMs       float64                               `json:"latency_ms,omitempty"`
+	Summary         string                                `json:"summary"`
+	Recommendations []recommendation.ScoredRecommendation `json:"recommendations"`
+}
+
+// SimulationResponse compares priorities for one classified prompt
+type SimulationResponse struct {
+	Classification   classification.ClassificationResult `json:"classification"`
+	BaselinePriority string                              `json:"baseline_priority"`
+	RequestsPerMonth int                                 `json:"requests_per_month"`
+	Scenarios        []PriorityScenario                  `json:"scenarios"`
+	ProcessingTime   float64                             `json:"total_processing_time_ms"`
+	Safety           *safety.Decision                    `json:"safety,omitempty"`
+	DegradedStages   []string                            `json:"degraded_stages,omitempty"`
+}
+
+// SimulatePriorities classifies the prompt once and runs the full scoring
+// pipeline under each priority. The baseline is the priority the caller would
+// get today, i.e. the one inferred from the prompt.
+func (ers *EnhancedRouterService) SimulatePriorities(ctx context.Context, req SimulationRequest) (SimulationResponse, error) {
+	startTime := getCurrentTimeMs()
+	ctx, cancel := withRequestDeadline(ctx)
+	defer cancel()
+
+	priorities := req.Priorities
+	if len(priorities) == 0 {
+		priorities = SimulationPriorities
+	}
+	for _, p := range priorities {
+		if _, ok := validPriorities[p]; !ok {
+			return SimulationResponse{}, fmt.Errorf("unknown priority %q (valid: %v)", p, SimulationPriorities)
+		}
+	}
+	if err := ers.ValidateOverrides(req.ClassificationOverrides); err != nil {
+		return SimulationResponse{}, err
+	}
+
+	if req.RequestsPerMonth <= 0 {
+		req.RequestsPerMonth = defaultSimulatedRequestsPerMonth
+	}
+	if req.TopN <= 0 {
+		req.TopN = 3
+	}
+
+	response := SimulationResponse{
+		RequestsPerMonth: req.RequestsPerMonth,
+		Scenarios:        []PriorityScenario{},
+	}
+
+	if ers.safetyGate != nil {
+		decision := ers.safetyGate.Evaluate(ctx, req.UserID, req.Prompt)
+		response.Safety = &decision
+		if decision.Blocked() {
+			return response, nil
+		}
+	}
+
+	classifierOutput, degraded := ers.classify(ctx, req.UserID, req.Prompt)
+	response.Classification, _ = applyOverrides(classifierOutput, req.ClassificationOverrides)
+	base, buildDegraded := ers.buildRecommendationRequest(ctx, req.SmartRecommendationRequest, response.Classification, response.Safety)
+	response.DegradedStages = append(degraded, buildDegraded...)
+	response.BaselinePriority = base.Priority
+
+	// Always evaluate the baseline so savings have a reference point
+	evaluate := priorities
+	if !containsPriority(evaluate, base.Priority) {
+		evaluate = append([]string{base.Priority}, evaluate...)
+	}
+
+	scenarios := make(map[string]PriorityScenario, len(evaluate))
+	anyPartial := false
+	for _, priority := range evaluate {
+		recRequest := base
+		recRequest.Priority = priority
+		scenario, partial := ers.simulateScenario(ctx, recRequest, req.RequestsPerMonth, req.TopN)
+		scenarios[priority] = scenario
+		anyPartial = anyPartial || partial
+	}
+	if anyPartial {
+		response.DegradedStages = append(response.DegradedStages, StageScoring)
+	}
+
+	baseline := scenarios[base.Priority]
+	for _, priority := range priorities {
+		scenario := scenarios[priority]
+		scenario.MonthlySavings = roundCents(baseline.MonthlyCost - scenario.MonthlyCost)
+		scenario.QualityDelta = math.Round((scenario.QualityScore-baseline.QualityScore)*1000) / 1000
+		scenario.Summary = summarizeScenario(scenario, baseline)
+		response.Scenarios = append(response.Scenarios, scenario)
+	}
+
+	response.ProcessingTime = getCurrentTimeMs() - startTime
+	log.Printf("[ROUTER] Simulated %d priorities for category=%s in %.2fms",
+		len(response.Scenarios), base.Category, response.ProcessingTime)
+
+	return response, nil
+}
+
+func (ers *EnhancedRouterService) simulateScenario(ctx context.Context, req recommendation.RecommendationRequest, requestsPerMonth, topN int) (PriorityScenario, bool) {
+	result := ers.recommendationEngine.GetRecommendations(ctx, req)
+
+	recs := result.Recommendations
+	if len(recs) > topN {
+		recs = recs[:topN]
+	}
+	scenario := PriorityScenario{
+		Priority:        req.Priority,
+		Recommendations: recs,
+	}
+	if len(recs) == 0 {
+		return scenario, result.Partial
+	}
+
+	top := recs[0]
+	scenario.TopModel = top.Model.ID
+	scenario.QualityScore = top.ComponentScores["capability"]
+	scenario.CostPerRequest = top.CostEstimate
+	scenario.MonthlyCost = roundCents(top.CostEstimate * float64(requestsPerMonth))
+	if top.LatencyEstimate != nil {
+		scenario.LatencyMs = top.LatencyEstimate.TotalMs
+	}
+	return scenario, result.Partial
+}
+
+func summarizeScenario(scenario, baseline PriorityScenario) string {
+	switch {
+	case scenario.TopModel == "":
+		return "No eligible models under this priority"
+	case scenario.Priority == baseline.Priority:
+		return fmt.Sprintf("Current routing: %s at $%.2f/month", scenario.TopModel, scenario.MonthlyCost)
+	case scenario.TopModel == baseline.TopModel:
+		return fmt.Sprintf("Same model as current routing (%s)", scenario.TopModel)
+	case scenario.MonthlySavings > 0:
+		return fmt.Sprintf("Switching to %s routes to %s and saves $%.2f/month (quality %+.3f)",
+			scenario.Priority, scenario.TopModel, scenario.MonthlySavings, scenario.QualityDelta)
+	default:
+		return fmt.Sprintf("Switching to %s routes to %s and costs $%.2f/month more (quality %+.3f)",
+			scenario.Priority, scenario.TopModel, -scenario.MonthlySavings, scenario.QualityDelta)
+	}
+}
+
+var validPriorities = map[string]struct{}{
+	"balanced": {},
+	"quality":  {},
+	"cost":     {},
+	"speed":    {},
+	"green":    {},
+}
+
+func containsPriority(priorities []string, priority string) bool {
+	for _, p := range priorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
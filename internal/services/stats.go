@@ -0,0 +1,40 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/statshistory"
+)
+
+// StatsSnapshot reads the catalog size, source freshness, fusion counters and
+// memo cache metrics for the stats history
+func (ers *EnhancedRouterService) StatsSnapshot() statshistory.Snapshot {
+	now := time.Now()
+	fusion := ers.fusionService.Snapshot()
+	memo := ers.memo.stats()
+
+	snapshot := statshistory.Snapshot{
+		CapturedAt:       now,
+		TotalModels:      fusion.TotalModels,
+		ModelsByType:     fusion.ModelsByType,
+		SourceAgeSeconds: make(map[string]int64),
+		FusionErrors:     fusion.FusionErrors,
+		AnalyticsFetches: fusion.AnalyticsSuccesses,
+		MemoEntries:      memo.Entries,
+		MemoHits:         memo.Hits,
+		MemoMisses:       memo.Misses,
+	}
+	sources := map[string]time.Time{
+		"catalog":      fusion.LastFusion,
+		"analytics_ai": fusion.LastAnalyticsFetch,
+	}
+	for source, refreshed := range sources {
+		if !refreshed.IsZero() {
+			snapshot.SourceAgeSeconds[source] = int64(now.Sub(refreshed).Seconds())
+		}
+	}
+	if lookups := memo.Hits + memo.Misses; lookups > 0 {
+		snapshot.MemoHitRate = float64(memo.Hits) / float64(lookups)
+	}
+	return snapshot
+}
@@ -0,0 +1,137 @@
+package signing
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes signing secret management on the dashboard
+type Handlers struct {
+	store    *Store
+	verifier *Verifier
+}
+
+type CreateSecretRequest struct {
+	APIKeyID string `json:"api_key_id"` // Optional; required signing is enforced per key
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+}
+
+type UpdateSecretRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+func NewHandlers(store *Store, verifier *Verifier) *Handlers {
+	return &Handlers{store: store, verifier: verifier}
+}
+
+// List returns the caller's active signing secrets (metadata only)
+func (h *Handlers) List(c *gin.Context) {
+	secrets, err := h.store.List(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list signing secrets",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"signing_secrets":       secrets,
+			"replay_window_seconds": int(h.verifier.window.Seconds()),
+		},
+	})
+}
+
+// Create generates a signing secret. The plaintext is only returned here.
+func (h *Handlers) Create(c *gin.Context) {
+	var req CreateSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.Required && req.APIKeyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "required signing needs an api_key_id to enforce it on",
+		})
+		return
+	}
+
+	secret, plaintext, err := h.store.Create(c.Request.Context(), c.GetString("user_id"), req.APIKeyID, req.Label, req.Required)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to create signing secret",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"signing_secret": secret,
+			"secret":         plaintext,
+		},
+		"message": "Store this secret now; it cannot be shown again",
+	})
+}
+
+// Update turns required signing on or off
+func (h *Handlers) Update(c *gin.Context) {
+	var req UpdateSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := h.store.SetRequired(c.Request.Context(), c.GetString("user_id"), c.Param("id"), *req.Required)
+	if errors.Is(err, ErrSecretNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Signing secret not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update signing secret",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Revoke disables a signing secret
+func (h *Handlers) Revoke(c *gin.Context) {
+	err := h.store.Revoke(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, ErrSecretNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Signing secret not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to revoke signing secret",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
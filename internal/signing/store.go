@@ -0,0 +1,206 @@
+package signing
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Askeban/llm-router-go/internal/vault"
+)
+
+// secretPrefix marks signing secrets so they are not mistaken for API keys
+const secretPrefix = "ss_"
+
+// ErrSecretNotFound is returned for unknown, revoked or foreign secrets
+var ErrSecretNotFound = errors.New("signing secret not found")
+
+// Secret is the metadata of an HMAC signing secret. The secret itself is only
+// returned once, when it is created.
+type Secret struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	APIKeyID   string     `json:"api_key_id,omitempty"` // Key whose requests this secret signs; empty for any of the tenant's calls
+	Label      string     `json:"label"`
+	SecretHint string     `json:"secret_hint"`
+	Required   bool       `json:"required"` // Unsigned requests from APIKeyID are rejected
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	ownerPlan string // The owner's plan, loaded with the secret for verification
+}
+
+// Store persists signing secrets sealed by the vault, since verification
+// needs the plaintext secret rather than a hash
+type Store struct {
+	db    *sql.DB
+	vault *vault.Vault
+}
+
+func NewStore(db *sql.DB, v *vault.Vault) *Store {
+	return &Store{db: db, vault: v}
+}
+
+// Create generates a secret for the tenant and returns it with its plaintext
+func (s *Store) Create(ctx context.Context, userID, apiKeyID, label string, required bool) (*Secret, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate signing secret: %w", err)
+	}
+	plaintext := secretPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	sealed, err := s.vault.Seal([]byte(plaintext))
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := &Secret{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		APIKeyID:   apiKeyID,
+		Label:      label,
+		SecretHint: "..." + plaintext[len(plaintext)-4:],
+		Required:   required,
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO signing_secrets (id, user_id, api_key_id, label, sealed_secret, secret_hint, required)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7)
+		RETURNING created_at`,
+		secret.ID, userID, apiKeyID, label, sealed, secret.SecretHint, required,
+	).Scan(&secret.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to store signing secret: %w", err)
+	}
+	return secret, plaintext, nil
+}
+
+// List returns the metadata of a tenant's active secrets
+func (s *Store) List(ctx context.Context, userID string) ([]Secret, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, COALESCE(api_key_id::text, ''), COALESCE(label, ''), COALESCE(secret_hint, ''),
+			required, last_used_at, created_at
+		FROM signing_secrets
+		WHERE user_id = $1 AND is_active = TRUE
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing secrets: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []Secret{}
+	for rows.Next() {
+		var sec Secret
+		if err := rows.Scan(&sec.ID, &sec.UserID, &sec.APIKeyID, &sec.Label, &sec.SecretHint,
+			&sec.Required, &sec.LastUsedAt, &sec.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan signing secret: %w", err)
+		}
+		secrets = append(secrets, sec)
+	}
+	return secrets, rows.Err()
+}
+
+// SetRequired turns enforcement of signatures on or off for a secret's key
+func (s *Store) SetRequired(ctx context.Context, userID, id string, required bool) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE signing_secrets SET required = $1
+		WHERE id = $2 AND user_id = $3 AND is_active = TRUE`, required, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update signing secret: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSecretNotFound
+	}
+	return nil
+}
+
+// Revoke disables a secret; requests signed with it are rejected from then on
+func (s *Store) Revoke(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE signing_secrets SET is_active = FALSE, required = FALSE
+		WHERE id = $1 AND user_id = $2 AND is_active = TRUE`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke signing secret: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSecretNotFound
+	}
+	return nil
+}
+
+// reveal loads an active secret of an active user, the owner's plan and the
+// plaintext for verification
+func (s *Store) reveal(ctx context.Context, id string) (*Secret, []byte, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil, ErrSecretNotFound
+	}
+
+	var sec Secret
+	var sealed []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT s.id, s.user_id, COALESCE(s.api_key_id::text, ''), COALESCE(u.plan_type, ''), s.sealed_secret
+		FROM signing_secrets s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1 AND s.is_active = TRUE AND u.is_active = TRUE AND u.status = 'active'`, id).Scan(&sec.ID, &sec.UserID, &sec.APIKeyID, &sec.ownerPlan, &sealed)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load signing secret: %w", err)
+	}
+
+	plaintext, err := s.vault.Open(sealed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &sec, plaintext, nil
+}
+
+// requiredFor reports whether unsigned requests from an API key are rejected
+func (s *Store) requiredFor(ctx context.Context, apiKeyID string) (bool, error) {
+	var required bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM signing_secrets
+			WHERE api_key_id = $1 AND is_active = TRUE AND required = TRUE
+		)`, apiKeyID).Scan(&required)
+	if err != nil {
+		return false, fmt.Errorf("failed to check signing requirement: %w", err)
+	}
+	return required, nil
+}
+
+// claimSignature records a verified signature until it expires, reporting
+// false if any replica already recorded it. An expired row is reclaimed.
+func (s *Store) claimSignature(ctx context.Context, hash string, expires time.Time) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO signature_nonces (signature_hash, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (signature_hash) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE signature_nonces.expires_at < CURRENT_TIMESTAMP`, hash, expires)
+	if err != nil {
+		return false, fmt.Errorf("failed to record signature: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record signature: %w", err)
+	}
+	return claimed == 1, nil
+}
+
+// purgeSignatures drops signatures that fell out of the replay window
+func (s *Store) purgeSignatures(ctx context.Context) {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM signature_nonces WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+		log.Printf("[SIGNING] Failed to purge expired signatures: %v", err)
+	}
+}
+
+// touch records when a secret last verified a request
+func (s *Store) touch(ctx context.Context, id string) {
+	s.db.ExecContext(ctx, `UPDATE signing_secrets SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+}
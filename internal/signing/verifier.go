@@ -0,0 +1,225 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Request headers of a signed call
+const (
+	HeaderKeyID     = "X-Signature-Key-Id"    // ID of the signing secret
+	HeaderTimestamp = "X-Signature-Timestamp" // Unix seconds when the request was signed
+	HeaderSignature = "X-Signature"           // v1=<hex HMAC-SHA256 of the canonical request>
+)
+
+const (
+	signatureVersion    = "v1="
+	defaultReplayWindow = 5 * time.Minute
+	// Signed bodies are buffered in full to be hashed
+	maxSignedBodyBytes = 10 << 20
+)
+
+// ContextSigned is set on the gin context once a request's signature verifies
+const ContextSigned = "request_signed"
+
+var (
+	errStale    = errors.New("timestamp is outside the replay window")
+	errMismatch = errors.New("signature does not match")
+	errReplayed = errors.New("signature was already used")
+)
+
+// Sign returns the X-Signature value for a request: an HMAC-SHA256, keyed by
+// the secret, over the timestamp, method, request URI and body hash, one per line
+func Sign(secret, timestamp, method, requestURI string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", timestamp, method, requestURI, hex.EncodeToString(bodyHash[:]))
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier checks signed requests and rejects replays inside the window.
+// Verified signatures are recorded in the database so a request replayed to
+// another replica is also refused.
+type Verifier struct {
+	store  *Store
+	window time.Duration
+
+	mu     sync.Mutex
+	seen   map[string]time.Time // Fallback when the database cannot record a signature
+	lastGC time.Time
+}
+
+// NewVerifier creates a verifier; SIGNING_REPLAY_WINDOW overrides the
+// five-minute window in which a timestamp is accepted
+func NewVerifier(store *Store) *Verifier {
+	window := defaultReplayWindow
+	if d, err := time.ParseDuration(os.Getenv("SIGNING_REPLAY_WINDOW")); err == nil && d > 0 {
+		window = d
+	}
+	return &Verifier{
+		store:  store,
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// Middleware verifies signed requests and authenticates them as the secret's
+// owner, on the owner's plan. Unsigned requests pass through unless the
+// caller's API key requires signing. It must run after the auth middleware
+// and before usage tracking.
+func (v *Verifier) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(HeaderSignature) == "" {
+			v.requireUnsigned(c)
+			return
+		}
+
+		secret, err := v.verify(c)
+		if err != nil {
+			log.Printf("[SIGNING] Rejected %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Invalid request signature",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		// A signature cannot vouch for a different tenant than the token
+		if userID := c.GetString("user_id"); userID != "" && userID != secret.UserID {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Signing secret does not belong to the authenticated user",
+			})
+			c.Abort()
+			return
+		}
+		c.Set("user_id", secret.UserID)
+		// Quotas and plan limits downstream are keyed by the owner's plan
+		if secret.ownerPlan != "" {
+			c.Set("user_plan", secret.ownerPlan)
+		}
+		if secret.APIKeyID != "" {
+			c.Set("api_key_id", secret.APIKeyID)
+		}
+		c.Set(ContextSigned, true)
+		go v.store.touch(context.Background(), secret.ID)
+
+		c.Next()
+	}
+}
+
+// requireUnsigned rejects unsigned requests from keys that must sign
+func (v *Verifier) requireUnsigned(c *gin.Context) {
+	apiKeyID := c.GetString("api_key_id")
+	if apiKeyID == "" {
+		c.Next()
+		return
+	}
+
+	required, err := v.store.requiredFor(c.Request.Context(), apiKeyID)
+	if err != nil {
+		// Fail open: signing is an additional control on top of the key
+		log.Printf("[SIGNING] %v", err)
+	}
+	if required {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Request signature required",
+			"details": fmt.Sprintf("This API key requires %s, %s and %s headers", HeaderKeyID, HeaderTimestamp, HeaderSignature),
+		})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func (v *Verifier) verify(c *gin.Context) (*Secret, error) {
+	keyID := c.GetHeader(HeaderKeyID)
+	timestamp := c.GetHeader(HeaderTimestamp)
+	signature := c.GetHeader(HeaderSignature)
+	if keyID == "" || timestamp == "" {
+		return nil, fmt.Errorf("%s and %s headers are required with %s", HeaderKeyID, HeaderTimestamp, HeaderSignature)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be unix seconds", HeaderTimestamp)
+	}
+	signedAt := time.Unix(unix, 0)
+	if age := time.Since(signedAt); age > v.window || age < -v.window {
+		return nil, errStale
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(body) > maxSignedBodyBytes {
+		return nil, fmt.Errorf("signed bodies are limited to %d bytes", maxSignedBodyBytes)
+	}
+	// Handlers still need the body
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	secret, plaintext, err := v.store.reveal(c.Request.Context(), keyID)
+	if err != nil {
+		if errors.Is(err, ErrSecretNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to load signing secret")
+	}
+
+	expected := Sign(string(plaintext), timestamp, c.Request.Method, c.Request.URL.RequestURI(), body)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature))) {
+		return nil, errMismatch
+	}
+	if !v.remember(c.Request.Context(), keyID+":"+expected, signedAt.Add(v.window)) {
+		return nil, errReplayed
+	}
+	return secret, nil
+}
+
+// remember records a verified signature until it expires, reporting false if
+// it was already seen by any replica. If the database is unavailable only
+// this replica's signatures are checked.
+func (v *Verifier) remember(ctx context.Context, signature string, expires time.Time) bool {
+	hash := sha256.Sum256([]byte(signature))
+	claimed, err := v.store.claimSignature(ctx, hex.EncodeToString(hash[:]), expires)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(v.lastGC) > v.window {
+		for sig, until := range v.seen {
+			if now.After(until) {
+				delete(v.seen, sig)
+			}
+		}
+		v.lastGC = now
+		go v.store.purgeSignatures(context.Background())
+	}
+
+	if err != nil {
+		log.Printf("[SIGNING] %v; checking replays locally", err)
+		if until, ok := v.seen[signature]; ok && now.Before(until) {
+			return false
+		}
+		v.seen[signature] = expires
+		return true
+	}
+	return claimed
+}
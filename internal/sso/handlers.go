@@ -0,0 +1,335 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+
+	"github.com/Askeban/llm-router-go/internal/auth"
+)
+
+// Users provisions and links accounts signing in through SSO
+type Users interface {
+	CreateOrGetUserBySSO(identity auth.SSOIdentity) (*auth.User, error)
+}
+
+// Handlers runs the OIDC login flow for dashboard users and exposes
+// connections to operators
+type Handlers struct {
+	store       *Store
+	users       Users
+	jwtManager  *auth.JWTManager
+	providers   *providers
+	redirectURL string
+}
+
+type StartRequest struct {
+	Email        string `json:"email"`        // Finds the connection by email domain
+	Organization string `json:"organization"` // Or names it directly
+}
+
+type CallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// NewHandlers issues tokens with jwtManager; identity providers send users
+// back to redirectURL, the dashboard page that posts the callback
+func NewHandlers(store *Store, users Users, jwtManager *auth.JWTManager, redirectURL string) *Handlers {
+	return &Handlers{
+		store:       store,
+		users:       users,
+		jwtManager:  jwtManager,
+		providers:   newProviders(),
+		redirectURL: redirectURL,
+	}
+}
+
+// Start begins an SSO login and returns the identity provider URL to send
+// the user to
+func (h *Handlers) Start(c *gin.Context) {
+	var req StartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var conn Connection
+	var err error
+	switch {
+	case req.Organization != "":
+		conn, err = h.store.Get(ctx, req.Organization)
+	case req.Email != "":
+		conn, err = h.store.ForEmail(ctx, req.Email)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "email or organization is required",
+		})
+		return
+	}
+	if errors.Is(err, ErrNoConnection) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Single sign-on is not configured for this organization",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start single sign-on",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	p, err := h.providers.get(ctx, conn.Issuer)
+	if err != nil {
+		log.Printf("[SSO] %s: %v", conn.Organization, err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Identity provider unavailable",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start single sign-on",
+		})
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+	state, err := h.store.createState(ctx, loginState{Organization: conn.Organization, Nonce: nonce, CodeVerifier: verifier})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start single sign-on",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	opts := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(verifier), oauth2.SetAuthURLParam("nonce", nonce)}
+	if req.Email != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("login_hint", req.Email))
+	}
+	// The client secret is only needed for the code exchange
+	loginURL := p.oauthConfig(conn.ClientID, "", h.redirectURL).AuthCodeURL(state, opts...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":           true,
+		"authorization_url": loginURL,
+		"organization":      conn.Organization,
+	})
+}
+
+// Callback completes an SSO login: it exchanges the code, verifies the ID
+// token, provisions or links the account and issues dashboard tokens
+func (h *Handlers) Callback(c *gin.Context) {
+	var req CallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	ls, err := h.store.takeState(ctx, req.State)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Single sign-on failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	conn, err := h.store.Get(ctx, ls.Organization)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Single sign-on failed",
+			"details": err.Error(),
+		})
+		return
+	}
+	p, err := h.providers.get(ctx, conn.Issuer)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Identity provider unavailable",
+			"details": err.Error(),
+		})
+		return
+	}
+	secret, err := h.store.clientSecret(conn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to complete single sign-on",
+		})
+		return
+	}
+
+	// The exchange goes through the same client as discovery and key fetches
+	exchangeCtx := context.WithValue(ctx, oauth2.HTTPClient, h.providers.client)
+	token, err := p.oauthConfig(conn.ClientID, secret, h.redirectURL).Exchange(exchangeCtx, req.Code, oauth2.VerifierOption(ls.CodeVerifier))
+	if err != nil {
+		log.Printf("[SSO] %s: code exchange failed: %v", conn.Organization, err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Failed to exchange the authorization code",
+		})
+		return
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Identity provider returned no ID token",
+		})
+		return
+	}
+	identity, err := h.providers.verify(ctx, p, conn.ClientID, rawIDToken, ls.Nonce)
+	if err != nil {
+		log.Printf("[SSO] %s: %v", conn.Organization, err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Single sign-on failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// The provider may only sign in its organization's verified addresses
+	email := strings.ToLower(identity.Email)
+	if !identity.EmailVerified || !conn.allowsEmail(email) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "This identity provider may not sign in " + email,
+		})
+		return
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = email
+	}
+	user, err := h.users.CreateOrGetUserBySSO(auth.SSOIdentity{
+		Issuer:       conn.Issuer,
+		Subject:      identity.Subject,
+		Email:        email,
+		FullName:     name,
+		Organization: conn.Organization,
+		Role:         conn.Role(identity),
+	})
+	if errors.Is(err, auth.ErrOtherOrganization) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An account with this email belongs to another organization",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create or retrieve user",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !user.IsActive || user.Status != "active" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Account is suspended or inactive",
+		})
+		return
+	}
+
+	jwtToken, err := h.jwtManager.Generate(user.ID, user.Email, user.PlanType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+	refreshToken, err := h.jwtManager.GenerateRefreshToken(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate refresh token",
+		})
+		return
+	}
+
+	h.store.record(ctx, user.ID, "login", conn.Organization, map[string]interface{}{
+		"issuer": conn.Issuer,
+		"role":   user.OrgRole,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"token":         jwtToken,
+		"refresh_token": refreshToken,
+		"user":          user,
+	})
+}
+
+// List returns every organization's connection, without client secrets
+func (h *Handlers) List(c *gin.Context) {
+	list, err := h.store.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load SSO connections",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// Put creates or replaces an organization's connection
+func (h *Handlers) Put(c *gin.Context) {
+	var req PutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.store.Put(c.Request.Context(), c.Param("organization"), req, c.GetString("admin_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to store SSO connection",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Delete removes an organization's connection
+func (h *Handlers) Delete(c *gin.Context) {
+	deleted, err := h.store.Delete(c.Request.Context(), c.Param("organization"), c.GetString("admin_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete SSO connection",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No SSO connection for this organization",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
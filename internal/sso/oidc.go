@@ -0,0 +1,241 @@
+package sso
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// discoveryTTL is how long a provider's configuration and signing keys are
+// reused before they are fetched again
+const discoveryTTL = time.Hour
+
+// provider is an issuer's OpenID configuration with its signing keys
+type provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// Identity is what a verified ID token asserts about the user
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Claims        jwt.MapClaims
+}
+
+// providers discovers and caches identity providers by issuer
+type providers struct {
+	client *http.Client
+	mu     sync.Mutex
+	cache  map[string]*provider
+}
+
+func newProviders() *providers {
+	return &providers{client: transport.Client("oidc"), cache: make(map[string]*provider)}
+}
+
+// get returns the issuer's configuration, discovering it when missing or stale
+func (ps *providers) get(ctx context.Context, issuer string) (*provider, error) {
+	ps.mu.Lock()
+	p, ok := ps.cache[issuer]
+	ps.mu.Unlock()
+	if ok && time.Since(p.fetchedAt) < discoveryTTL {
+		return p, nil
+	}
+
+	p = &provider{}
+	if err := ps.fetchJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", p); err != nil {
+		return nil, fmt.Errorf("failed to discover %s: %w", issuer, err)
+	}
+	if p.Issuer != issuer {
+		return nil, fmt.Errorf("provider reports issuer %q, expected %q", p.Issuer, issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, fmt.Errorf("provider configuration of %s is incomplete", issuer)
+	}
+	keys, err := ps.loadKeys(ctx, p.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.fetchedAt = time.Now()
+
+	ps.mu.Lock()
+	ps.cache[issuer] = p
+	ps.mu.Unlock()
+	return p, nil
+}
+
+// loadKeys fetches a provider's RSA signing keys by key ID
+func (ps *providers) loadKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := ps.fetchJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("provider publishes no RSA signing keys")
+	}
+	return keys, nil
+}
+
+// key returns the provider's signing key, refetching the key set once when
+// the provider rotated in a key since discovery
+func (ps *providers) key(ctx context.Context, p *provider, kid string) (*rsa.PublicKey, error) {
+	ps.mu.Lock()
+	key, ok := p.keys[kid]
+	ps.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	keys, err := ps.loadKeys(ctx, p.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	ps.mu.Lock()
+	p.keys = keys
+	ps.mu.Unlock()
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (ps *providers) fetchJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ps.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// oauthConfig is the code flow configuration for a connection
+func (p *provider) oauthConfig(clientID, clientSecret, redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  p.AuthorizationEndpoint,
+			TokenURL: p.TokenEndpoint,
+		},
+	}
+}
+
+// verify checks the ID token's signature, issuer, audience, expiry and nonce
+func (ps *providers) verify(ctx context.Context, p *provider, clientID, rawToken, nonce string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return ps.key(ctx, p, kid)
+	}
+	_, err := jwt.ParseWithClaims(rawToken, claims, keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return Identity{}, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return Identity{}, errors.New("invalid ID token: nonce mismatch")
+	}
+
+	identity := Identity{Claims: claims}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	// Azure AD omits email_verified; its emails are managed by the tenant
+	identity.EmailVerified = true
+	if v, ok := claims["email_verified"].(bool); ok {
+		identity.EmailVerified = v
+	}
+	if identity.Email == "" {
+		// Azure AD puts the sign-in address in preferred_username
+		identity.Email, _ = claims["preferred_username"].(string)
+	}
+	if identity.Subject == "" || identity.Email == "" {
+		return Identity{}, errors.New("ID token lacks a subject or email")
+	}
+	return identity, nil
+}
+
+// Role maps the identity's role claim to an organization role. With several
+// matches, the most privileged wins.
+func (conn Connection) Role(identity Identity) string {
+	var values []string
+	switch v := identity.Claims[conn.RoleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	rank := map[string]int{RoleViewer: 1, RoleMember: 2, RoleAdmin: 3}
+	role := ""
+	for _, value := range values {
+		if mapped, ok := conn.RoleMapping[value]; ok && rank[mapped] > rank[role] {
+			role = mapped
+		}
+	}
+	if role == "" {
+		return conn.DefaultRole
+	}
+	return role
+}
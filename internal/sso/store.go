@@ -0,0 +1,394 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/vault"
+)
+
+// Organization roles SSO users are provisioned with
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+// stateTTL is how long a login may take at the identity provider
+const stateTTL = 10 * time.Minute
+
+var (
+	// ErrNoConnection is returned when an organization has no enabled SSO connection
+	ErrNoConnection = errors.New("no SSO connection for this organization")
+	// ErrInvalidState is returned for unknown, used or expired login states
+	ErrInvalidState = errors.New("invalid or expired SSO login")
+)
+
+var validRoles = map[string]bool{RoleAdmin: true, RoleMember: true, RoleViewer: true}
+
+// Connection is an organization's OIDC identity provider, such as Okta or
+// Azure AD
+type Connection struct {
+	Organization    string            `json:"organization"`
+	Issuer          string            `json:"issuer"`
+	ClientID        string            `json:"client_id"`
+	ClientSecretSet bool              `json:"client_secret_set"`
+	Domains         []string          `json:"domains"`      // Email domains the provider may sign in
+	RoleClaim       string            `json:"role_claim"`   // ID token claim holding groups or roles
+	RoleMapping     map[string]string `json:"role_mapping"` // Claim value to organization role
+	DefaultRole     string            `json:"default_role"` // Role of users no mapping matches
+	PasswordLogin   bool              `json:"password_login"`
+	Enabled         bool              `json:"enabled"`
+	UpdatedBy       string            `json:"updated_by,omitempty"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+
+	clientSecret []byte // Sealed by the key vault
+}
+
+// PutRequest creates or replaces a connection. An empty client secret keeps
+// the stored one.
+type PutRequest struct {
+	Issuer        string            `json:"issuer" binding:"required,url"`
+	ClientID      string            `json:"client_id" binding:"required"`
+	ClientSecret  string            `json:"client_secret"`
+	Domains       []string          `json:"domains" binding:"required,min=1"`
+	RoleClaim     string            `json:"role_claim"`
+	RoleMapping   map[string]string `json:"role_mapping"`
+	DefaultRole   string            `json:"default_role"`
+	PasswordLogin *bool             `json:"password_login"` // Defaults to allowed
+	Enabled       *bool             `json:"enabled"`        // Defaults to enabled
+}
+
+// Validate rejects connections that cannot be used
+func (r PutRequest) Validate() error {
+	u, err := url.Parse(r.Issuer)
+	if err != nil || u.Host == "" {
+		return errors.New("issuer must be an absolute URL")
+	}
+	if u.Scheme != "https" && u.Hostname() != "localhost" {
+		return errors.New("issuer must use https")
+	}
+	for _, domain := range r.Domains {
+		if d := normalizeDomain(domain); d == "" || strings.ContainsAny(d, "@/ ") {
+			return fmt.Errorf("invalid email domain %q", domain)
+		}
+	}
+	if r.DefaultRole != "" && !validRoles[r.DefaultRole] {
+		return fmt.Errorf("invalid default_role %q; use admin, member or viewer", r.DefaultRole)
+	}
+	for value, role := range r.RoleMapping {
+		if !validRoles[role] {
+			return fmt.Errorf("invalid role %q for %q; use admin, member or viewer", role, value)
+		}
+	}
+	return nil
+}
+
+// allowsEmail reports whether the email is in one of the connection's domains
+func (conn Connection) allowsEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := normalizeDomain(email[at+1:])
+	for _, d := range conn.Domains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// loginState is a pending login between the redirect and the callback
+type loginState struct {
+	Organization string
+	Nonce        string
+	CodeVerifier string
+}
+
+// Store persists per-organization SSO connections, with client secrets
+// sealed by the key vault, and pending logins. Organizations are the users'
+// company_name, like two-factor policies.
+type Store struct {
+	db       *sql.DB
+	vault    *vault.Vault
+	auditLog *audit.Logger
+}
+
+func NewStore(db *sql.DB, v *vault.Vault) *Store {
+	return &Store{db: db, vault: v}
+}
+
+// SetAuditLog records connection changes and SSO logins
+func (s *Store) SetAuditLog(auditLog *audit.Logger) {
+	s.auditLog = auditLog
+}
+
+// NormalizeOrganization is the key connections are stored under
+func NormalizeOrganization(organization string) string {
+	return strings.ToLower(strings.TrimSpace(organization))
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
+}
+
+const connectionColumns = `organization, issuer, client_id, client_secret, domains, role_claim, role_mapping,
+	default_role, password_login, enabled, COALESCE(updated_by, ''), updated_at`
+
+func scanConnection(row interface{ Scan(...interface{}) error }) (Connection, error) {
+	var conn Connection
+	var mapping []byte
+	err := row.Scan(&conn.Organization, &conn.Issuer, &conn.ClientID, &conn.clientSecret,
+		pq.Array(&conn.Domains), &conn.RoleClaim, &mapping, &conn.DefaultRole,
+		&conn.PasswordLogin, &conn.Enabled, &conn.UpdatedBy, &conn.UpdatedAt)
+	if err != nil {
+		return conn, err
+	}
+	json.Unmarshal(mapping, &conn.RoleMapping)
+	conn.ClientSecretSet = len(conn.clientSecret) > 0
+	return conn, nil
+}
+
+// List returns every organization's connection, without client secrets
+func (s *Store) List(ctx context.Context) ([]Connection, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+connectionColumns+` FROM sso_connections ORDER BY organization`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSO connections: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Connection{}
+	for rows.Next() {
+		conn, err := scanConnection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan SSO connection: %w", err)
+		}
+		list = append(list, conn)
+	}
+	return list, rows.Err()
+}
+
+// Get returns an organization's enabled connection
+func (s *Store) Get(ctx context.Context, organization string) (Connection, error) {
+	conn, err := scanConnection(s.db.QueryRowContext(ctx, `
+		SELECT `+connectionColumns+` FROM sso_connections WHERE organization = $1 AND enabled`,
+		NormalizeOrganization(organization)))
+	if err == sql.ErrNoRows {
+		return Connection{}, ErrNoConnection
+	}
+	if err != nil {
+		return Connection{}, fmt.Errorf("failed to load SSO connection: %w", err)
+	}
+	return conn, nil
+}
+
+// ForEmail returns the enabled connection whose domains include the email's
+func (s *Store) ForEmail(ctx context.Context, email string) (Connection, error) {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return Connection{}, ErrNoConnection
+	}
+	conn, err := scanConnection(s.db.QueryRowContext(ctx, `
+		SELECT `+connectionColumns+` FROM sso_connections
+		WHERE enabled AND $1 = ANY(domains)
+		ORDER BY organization LIMIT 1`,
+		normalizeDomain(email[at+1:])))
+	if err == sql.ErrNoRows {
+		return Connection{}, ErrNoConnection
+	}
+	if err != nil {
+		return Connection{}, fmt.Errorf("failed to load SSO connection: %w", err)
+	}
+	return conn, nil
+}
+
+// Put creates or replaces an organization's connection
+func (s *Store) Put(ctx context.Context, organization string, req PutRequest, adminID string) error {
+	organization = NormalizeOrganization(organization)
+	if organization == "" {
+		return fmt.Errorf("organization is required")
+	}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	var sealed []byte
+	if req.ClientSecret != "" {
+		var err error
+		if sealed, err = s.vault.Seal([]byte(req.ClientSecret)); err != nil {
+			return fmt.Errorf("failed to seal client secret: %w", err)
+		}
+	} else if err := s.db.QueryRowContext(ctx, `
+		SELECT client_secret FROM sso_connections WHERE organization = $1`, organization).Scan(&sealed); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("client_secret is required")
+		}
+		return fmt.Errorf("failed to load SSO connection: %w", err)
+	}
+
+	domains := make([]string, 0, len(req.Domains))
+	for _, d := range req.Domains {
+		domains = append(domains, normalizeDomain(d))
+	}
+	roleClaim := req.RoleClaim
+	if roleClaim == "" {
+		roleClaim = "groups"
+	}
+	defaultRole := req.DefaultRole
+	if defaultRole == "" {
+		defaultRole = RoleMember
+	}
+	mapping, _ := json.Marshal(req.RoleMapping)
+	passwordLogin := req.PasswordLogin == nil || *req.PasswordLogin
+	enabled := req.Enabled == nil || *req.Enabled
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sso_connections (organization, issuer, client_id, client_secret, domains, role_claim,
+		                             role_mapping, default_role, password_login, enabled, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (organization) DO UPDATE SET
+			issuer = EXCLUDED.issuer,
+			client_id = EXCLUDED.client_id,
+			client_secret = EXCLUDED.client_secret,
+			domains = EXCLUDED.domains,
+			role_claim = EXCLUDED.role_claim,
+			role_mapping = EXCLUDED.role_mapping,
+			default_role = EXCLUDED.default_role,
+			password_login = EXCLUDED.password_login,
+			enabled = EXCLUDED.enabled,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP`,
+		organization, strings.TrimSpace(req.Issuer), req.ClientID, sealed, pq.Array(domains), roleClaim,
+		string(mapping), defaultRole, passwordLogin, enabled, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to store SSO connection: %w", err)
+	}
+
+	log.Printf("[SSO] %s configured SSO for %s (password login %t, enabled %t)", adminID, organization, passwordLogin, enabled)
+	s.record(ctx, adminID, "connection_updated", organization, map[string]interface{}{
+		"issuer":         req.Issuer,
+		"client_id":      req.ClientID,
+		"domains":        domains,
+		"password_login": passwordLogin,
+		"enabled":        enabled,
+		"secret_rotated": req.ClientSecret != "",
+	})
+	return nil
+}
+
+// Delete removes an organization's connection, allowing password login again
+func (s *Store) Delete(ctx context.Context, organization, adminID string) (bool, error) {
+	organization = NormalizeOrganization(organization)
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sso_connections WHERE organization = $1`, organization)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete SSO connection: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n > 0 {
+		log.Printf("[SSO] %s removed SSO for %s", adminID, organization)
+		s.record(ctx, adminID, "connection_deleted", organization, nil)
+	}
+	return n > 0, nil
+}
+
+// PasswordLoginDisabled reports whether the organization signs in through
+// SSO only
+func (s *Store) PasswordLoginDisabled(ctx context.Context, organization string) (bool, error) {
+	organization = NormalizeOrganization(organization)
+	if organization == "" {
+		return false, nil
+	}
+	var disabled bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT enabled AND NOT password_login FROM sso_connections WHERE organization = $1`,
+		organization).Scan(&disabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check SSO connection: %w", err)
+	}
+	return disabled, nil
+}
+
+// clientSecret opens the connection's sealed client secret
+func (s *Store) clientSecret(conn Connection) (string, error) {
+	secret, err := s.vault.Open(conn.clientSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to open client secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// createState records a pending login and returns its state parameter
+func (s *Store) createState(ctx context.Context, ls loginState) (string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	// Abandoned logins are cleared as new ones start
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM sso_login_states WHERE created_at < $1`, time.Now().Add(-stateTTL)); err != nil {
+		log.Printf("[SSO] Failed to clear expired login states: %v", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO sso_login_states (state, organization, nonce, code_verifier) VALUES ($1, $2, $3, $4)`,
+		state, ls.Organization, ls.Nonce, ls.CodeVerifier)
+	if err != nil {
+		return "", fmt.Errorf("failed to store SSO login: %w", err)
+	}
+	return state, nil
+}
+
+// takeState consumes a pending login, so each state is used once
+func (s *Store) takeState(ctx context.Context, state string) (loginState, error) {
+	var ls loginState
+	err := s.db.QueryRowContext(ctx, `
+		DELETE FROM sso_login_states WHERE state = $1 AND created_at >= $2
+		RETURNING organization, nonce, code_verifier`,
+		state, time.Now().Add(-stateTTL)).Scan(&ls.Organization, &ls.Nonce, &ls.CodeVerifier)
+	if err == sql.ErrNoRows {
+		return ls, ErrInvalidState
+	}
+	if err != nil {
+		return ls, fmt.Errorf("failed to load SSO login: %w", err)
+	}
+	return ls, nil
+}
+
+func (s *Store) record(ctx context.Context, userID, action, organization string, details map[string]interface{}) {
+	if s.auditLog == nil {
+		return
+	}
+	if _, err := s.auditLog.Record(ctx, audit.Entry{
+		UserID:    userID,
+		EventType: "auth.sso." + action,
+		Action:    action,
+		Resource:  organization,
+		Details:   details,
+	}); err != nil {
+		log.Printf("[SSO] Failed to record audit entry: %v", err)
+	}
+}
+
+// randomToken returns 32 random bytes, hex encoded
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
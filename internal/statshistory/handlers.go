@@ -0,0 +1,84 @@
+package statshistory
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxWindow bounds how far back history reaches
+	maxWindow = 90 * 24 * time.Hour
+	// maxPoints is how many steps a window is split into by default
+	maxPoints = 300
+	// minStep keeps default steps no finer than snapshots are taken
+	minStep = 5 * time.Minute
+)
+
+// Handlers serves the stats history for trend dashboards
+type Handlers struct {
+	recorder *Recorder
+}
+
+func NewHandlers(recorder *Recorder) *Handlers {
+	return &Handlers{recorder: recorder}
+}
+
+// History returns snapshots over ?window (default 24h, up to 90d, e.g. 7d),
+// one per instance per ?step (default window/300, at least 5m), optionally for
+// one ?instance
+func (h *Handlers) History(c *gin.Context) {
+	window, err := parseDuration(c.DefaultQuery("window", "24h"))
+	if err != nil || window < time.Hour || window > maxWindow {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid window",
+			"details": "window must be a duration between 1h and 90d, e.g. 24h or 7d",
+		})
+		return
+	}
+
+	step := window / maxPoints
+	if step < minStep {
+		step = minStep
+	}
+	if raw := c.Query("step"); raw != "" {
+		step, err = parseDuration(raw)
+		if err != nil || step < time.Minute || step > window {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid step",
+				"details": "step must be a duration of at least 1m and at most the window",
+			})
+			return
+		}
+	}
+
+	history, err := h.recorder.History(c.Request.Context(), window, step, c.Query("instance"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load stats history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// parseDuration reads a Go duration or a whole number of days such as "7d"
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,139 @@
+package statshistory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// Snapshot is a replica's catalog size, source freshness, fusion counters and
+// cache metrics at one instant. Counters are cumulative since the replica
+// started, so trends compare snapshots of the same instance.
+type Snapshot struct {
+	CapturedAt       time.Time        `json:"captured_at"`
+	InstanceID       string           `json:"instance_id"`
+	TotalModels      int              `json:"total_models"`
+	ModelsByType     map[string]int   `json:"models_by_type"`
+	SourceAgeSeconds map[string]int64 `json:"source_age_seconds"` // Since each source last refreshed; absent until it has
+	FusionErrors     int64            `json:"fusion_errors"`
+	AnalyticsFetches int64            `json:"analytics_fetches"`
+	MemoEntries      int              `json:"memo_entries"`
+	MemoHits         int64            `json:"memo_hits"`
+	MemoMisses       int64            `json:"memo_misses"`
+	MemoHitRate      float64          `json:"memo_hit_rate"`
+}
+
+// Source takes a snapshot of the running router's stats
+type Source interface {
+	StatsSnapshot() Snapshot
+}
+
+// History is the snapshots within a window, at most one per instance per step
+type History struct {
+	From   time.Time  `json:"from"`
+	To     time.Time  `json:"to"`
+	Step   string     `json:"step"`
+	Points []Snapshot `json:"points"` // Oldest first
+}
+
+// Recorder persists periodic snapshots to stats_snapshots for trend
+// dashboards; every replica records its own
+type Recorder struct {
+	db         *sql.DB
+	source     Source
+	instanceID string
+}
+
+func NewRecorder(db *sql.DB, source Source) *Recorder {
+	hostname, _ := os.Hostname()
+	return &Recorder{
+		db:         db,
+		source:     source,
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Start records a snapshot now and then every interval until ctx is cancelled
+func (r *Recorder) Start(ctx context.Context, interval time.Duration) {
+	if err := r.Record(ctx); err != nil {
+		log.Printf("[STATS] %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Record(ctx); err != nil {
+					log.Printf("[STATS] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Record stores a snapshot of the router's stats as they are now
+func (r *Recorder) Record(ctx context.Context) error {
+	snapshot := r.source.StatsSnapshot()
+	snapshot.InstanceID = r.instanceID
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode stats snapshot: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO stats_snapshots (instance_id, captured_at, snapshot)
+		VALUES ($1, $2, $3)`, r.instanceID, snapshot.CapturedAt, data); err != nil {
+		return fmt.Errorf("failed to store stats snapshot: %w", err)
+	}
+	return nil
+}
+
+// History returns the snapshots of the last window, keeping the latest of
+// each instance in every step; instance narrows it to one replica
+func (r *Recorder) History(ctx context.Context, window, step time.Duration, instance string) (History, error) {
+	to := time.Now()
+	history := History{From: to.Add(-window), To: to, Step: step.String(), Points: []Snapshot{}}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (instance_id, bucket) snapshot
+		FROM (
+			SELECT instance_id, captured_at, snapshot,
+				floor(extract(epoch FROM captured_at) / $2) AS bucket
+			FROM stats_snapshots
+			WHERE captured_at >= $1 AND ($3 = '' OR instance_id = $3)
+		) s
+		ORDER BY instance_id, bucket, captured_at DESC`,
+		history.From, step.Seconds(), instance)
+	if err != nil {
+		return history, fmt.Errorf("failed to query stats history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return history, fmt.Errorf("failed to scan stats snapshot: %w", err)
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return history, fmt.Errorf("failed to decode stats snapshot: %w", err)
+		}
+		history.Points = append(history.Points, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return history, err
+	}
+	sort.Slice(history.Points, func(i, j int) bool {
+		return history.Points[i].CapturedAt.Before(history.Points[j].CapturedAt)
+	})
+	return history, nil
+}
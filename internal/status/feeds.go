@@ -0,0 +1,224 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Feed formats
+const (
+	FormatStatuspage  = "statuspage"   // statuspage.io /api/v2/summary.json
+	FormatGoogleCloud = "google_cloud" // status.cloud.google.com/incidents.json
+)
+
+// Incident impacts, in increasing order
+const (
+	ImpactMinor    = "minor"
+	ImpactMajor    = "major"
+	ImpactCritical = "critical"
+)
+
+var impactRank = map[string]int{
+	ImpactMinor:    1,
+	ImpactMajor:    2,
+	ImpactCritical: 3,
+}
+
+// Feed is a provider status page polled for incidents
+type Feed struct {
+	Provider string `json:"provider"` // Matches EnhancedModel.Provider
+	URL      string `json:"url"`
+	Format   string `json:"format"`
+}
+
+// DefaultFeeds are the status pages of the providers most traffic routes to
+var DefaultFeeds = []Feed{
+	{Provider: "openai", URL: "https://status.openai.com/api/v2/summary.json", Format: FormatStatuspage},
+	{Provider: "anthropic", URL: "https://status.anthropic.com/api/v2/summary.json", Format: FormatStatuspage},
+	{Provider: "google", URL: "https://status.cloud.google.com/incidents.json", Format: FormatGoogleCloud},
+}
+
+// FeedsFromEnv reads STATUS_FEEDS as comma-separated provider=url pairs, with
+// the format inferred from the URL. Unset uses DefaultFeeds; "none" disables polling.
+func FeedsFromEnv() ([]Feed, error) {
+	raw := strings.TrimSpace(os.Getenv("STATUS_FEEDS"))
+	switch raw {
+	case "":
+		return DefaultFeeds, nil
+	case "none":
+		return nil, nil
+	}
+
+	var feeds []Feed
+	for _, pair := range strings.Split(raw, ",") {
+		provider, url, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || provider == "" || url == "" {
+			return nil, fmt.Errorf("invalid STATUS_FEEDS entry %q, expected provider=url", pair)
+		}
+		format := FormatStatuspage
+		if strings.HasSuffix(url, "/incidents.json") {
+			format = FormatGoogleCloud
+		}
+		feeds = append(feeds, Feed{Provider: strings.ToLower(provider), URL: url, Format: format})
+	}
+	return feeds, nil
+}
+
+// Incident is an unresolved incident reported by a provider
+type Incident struct {
+	ID             string    `json:"id"`
+	Provider       string    `json:"provider"`
+	Name           string    `json:"name"`
+	Status         string    `json:"status"` // As reported, e.g. "investigating", "monitoring"
+	Impact         string    `json:"impact"`
+	Components     []string  `json:"components,omitempty"`
+	URL            string    `json:"url,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	AffectedModels []string  `json:"affected_models"`
+}
+
+// parseFeed decodes a feed body into its unresolved incidents that can
+// affect API traffic, along with the page's overall description
+func parseFeed(feed Feed, body io.Reader) ([]Incident, string, error) {
+	switch feed.Format {
+	case FormatStatuspage:
+		return parseStatuspage(feed, body)
+	case FormatGoogleCloud:
+		return parseGoogleCloud(feed, body)
+	}
+	return nil, "", fmt.Errorf("unsupported feed format %q", feed.Format)
+}
+
+func parseStatuspage(feed Feed, body io.Reader) ([]Incident, string, error) {
+	var summary struct {
+		Status struct {
+			Description string `json:"description"`
+		} `json:"status"`
+		Incidents []struct {
+			ID         string    `json:"id"`
+			Name       string    `json:"name"`
+			Status     string    `json:"status"`
+			Impact     string    `json:"impact"`
+			Shortlink  string    `json:"shortlink"`
+			StartedAt  time.Time `json:"started_at"`
+			UpdatedAt  time.Time `json:"updated_at"`
+			Components []struct {
+				Name string `json:"name"`
+			} `json:"components"`
+		} `json:"incidents"`
+	}
+	if err := json.NewDecoder(body).Decode(&summary); err != nil {
+		return nil, "", fmt.Errorf("failed to decode statuspage summary: %w", err)
+	}
+
+	incidents := []Incident{}
+	for _, inc := range summary.Incidents {
+		if _, ok := impactRank[inc.Impact]; !ok {
+			continue // "none": informational only
+		}
+		var components []string
+		for _, c := range inc.Components {
+			components = append(components, c.Name)
+		}
+		// Incidents scoped to consoles or chat apps leave the API up
+		if len(components) > 0 && !anyContains(components, "api") {
+			continue
+		}
+		incidents = append(incidents, Incident{
+			ID:         inc.ID,
+			Provider:   feed.Provider,
+			Name:       inc.Name,
+			Status:     inc.Status,
+			Impact:     inc.Impact,
+			Components: components,
+			URL:        inc.Shortlink,
+			StartedAt:  inc.StartedAt,
+			UpdatedAt:  inc.UpdatedAt,
+		})
+	}
+	return incidents, summary.Status.Description, nil
+}
+
+// googleImpacts maps Google Cloud status impacts onto incident impacts
+var googleImpacts = map[string]string{
+	"SERVICE_INFORMATION": ImpactMinor,
+	"SERVICE_DISRUPTION":  ImpactMajor,
+	"SERVICE_OUTAGE":      ImpactCritical,
+}
+
+// googleAIProducts are the Google Cloud products that serve model traffic
+var googleAIProducts = []string{"vertex", "gemini", "ai studio", "generative ai"}
+
+func parseGoogleCloud(feed Feed, body io.Reader) ([]Incident, string, error) {
+	var entries []struct {
+		ID               string    `json:"id"`
+		Begin            time.Time `json:"begin"`
+		End              string    `json:"end"`
+		Modified         time.Time `json:"modified"`
+		ExternalDesc     string    `json:"external_desc"`
+		StatusImpact     string    `json:"status_impact"`
+		URI              string    `json:"uri"`
+		AffectedProducts []struct {
+			Title string `json:"title"`
+		} `json:"affected_products"`
+		MostRecentUpdate struct {
+			Status string `json:"status"`
+		} `json:"most_recent_update"`
+	}
+	if err := json.NewDecoder(body).Decode(&entries); err != nil {
+		return nil, "", fmt.Errorf("failed to decode google cloud incidents: %w", err)
+	}
+
+	incidents := []Incident{}
+	for _, e := range entries {
+		if e.End != "" {
+			continue
+		}
+		impact, ok := googleImpacts[e.StatusImpact]
+		if !ok {
+			continue
+		}
+		var products []string
+		for _, p := range e.AffectedProducts {
+			products = append(products, p.Title)
+		}
+		if !anyContains(products, googleAIProducts...) {
+			continue
+		}
+		incidents = append(incidents, Incident{
+			ID:         e.ID,
+			Provider:   feed.Provider,
+			Name:       e.ExternalDesc,
+			Status:     strings.ToLower(e.MostRecentUpdate.Status),
+			Impact:     impact,
+			Components: products,
+			URL:        "https://status.cloud.google.com/" + strings.TrimPrefix(e.URI, "/"),
+			StartedAt:  e.Begin,
+			UpdatedAt:  e.Modified,
+		})
+	}
+
+	description := "All Systems Operational"
+	if len(incidents) > 0 {
+		description = fmt.Sprintf("%d active AI incidents", len(incidents))
+	}
+	return incidents, description, nil
+}
+
+// anyContains reports whether any value contains any of the substrings, ignoring case
+func anyContains(values []string, substrings ...string) bool {
+	for _, v := range values {
+		v = strings.ToLower(v)
+		for _, s := range substrings {
+			if strings.Contains(v, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/alerts"
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/transport"
+)
+
+// Catalog is the model catalog incidents are applied to
+type Catalog interface {
+	GetAllModels() []models.EnhancedModel
+	SetModelStatuses(statuses map[string]models.ModelStatus)
+}
+
+// ProviderStatus is the last known state of one provider's status page
+type ProviderStatus struct {
+	Provider    string     `json:"provider"`
+	Description string     `json:"description"`
+	Incidents   []Incident `json:"incidents"`
+	CheckedAt   *time.Time `json:"checked_at,omitempty"` // Last successful poll
+	Error       string     `json:"error,omitempty"`      // Last poll error; incidents are from CheckedAt
+}
+
+// Monitor polls provider status pages and marks affected models in the catalog
+type Monitor struct {
+	feeds   []Feed
+	catalog Catalog
+	client  *http.Client
+	alerts  *alerts.Manager
+
+	mu        sync.RWMutex
+	providers map[string]ProviderStatus
+}
+
+func NewMonitor(feeds []Feed, catalog Catalog) *Monitor {
+	providers := make(map[string]ProviderStatus, len(feeds))
+	for _, feed := range feeds {
+		providers[feed.Provider] = ProviderStatus{Provider: feed.Provider, Incidents: []Incident{}}
+	}
+	return &Monitor{
+		feeds:     feeds,
+		catalog:   catalog,
+		client:    transport.Client("status"),
+		providers: providers,
+	}
+}
+
+// SetAlerts notifies operators when an incident starts affecting models
+func (m *Monitor) SetAlerts(manager *alerts.Manager) {
+	m.alerts = manager
+}
+
+// Start polls every feed now and then on the given interval until ctx is cancelled
+func (m *Monitor) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		m.Poll(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Poll(ctx)
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Poll fetches every feed and reapplies incidents to the catalog. A feed that
+// fails keeps its last known incidents.
+func (m *Monitor) Poll(ctx context.Context) {
+	catalog := m.catalog.GetAllModels()
+
+	for _, feed := range m.feeds {
+		incidents, description, err := m.fetch(ctx, feed)
+
+		m.mu.Lock()
+		current := m.providers[feed.Provider]
+		if err != nil {
+			log.Printf("[STATUS] %s status page: %v", feed.Provider, err)
+			current.Error = err.Error()
+			m.providers[feed.Provider] = current
+			m.mu.Unlock()
+			continue
+		}
+		now := time.Now()
+		previous := make(map[string]bool, len(current.Incidents))
+		for _, inc := range current.Incidents {
+			previous[inc.ID] = true
+		}
+		for i := range incidents {
+			incidents[i].AffectedModels = affectedModels(incidents[i], catalog)
+		}
+		m.providers[feed.Provider] = ProviderStatus{
+			Provider:    feed.Provider,
+			Description: description,
+			Incidents:   incidents,
+			CheckedAt:   &now,
+		}
+		m.mu.Unlock()
+
+		for _, inc := range incidents {
+			if !previous[inc.ID] && len(inc.AffectedModels) > 0 {
+				m.notify(inc)
+			}
+		}
+	}
+
+	m.catalog.SetModelStatuses(m.modelStatuses())
+}
+
+func (m *Monitor) fetch(ctx context.Context, feed Feed) ([]Incident, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch status page: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch status page: status %d", resp.StatusCode)
+	}
+	return parseFeed(feed, resp.Body)
+}
+
+// affectedModels scopes an incident to the provider's models it names, or to
+// every model of the provider when it names none
+func affectedModels(inc Incident, catalog []models.EnhancedModel) []string {
+	text := strings.ToLower(inc.Name + " " + strings.Join(inc.Components, " "))
+
+	var all, named []string
+	for _, model := range catalog {
+		if !strings.EqualFold(model.Provider, inc.Provider) {
+			continue
+		}
+		all = append(all, model.ID)
+		if strings.Contains(text, strings.ToLower(model.ID)) ||
+			(model.DisplayName != "" && strings.Contains(text, strings.ToLower(model.DisplayName))) {
+			named = append(named, model.ID)
+		}
+	}
+	if len(named) > 0 {
+		return named
+	}
+	if all == nil {
+		return []string{}
+	}
+	return all
+}
+
+// modelStatuses marks each affected model with its most severe incident;
+// critical incidents take models out of rotation
+func (m *Monitor) modelStatuses() map[string]models.ModelStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make(map[string]models.ModelStatus)
+	for _, provider := range m.providers {
+		for _, inc := range provider.Incidents {
+			state := models.StateDegraded
+			if inc.Impact == ImpactCritical {
+				state = models.StateOutage
+			}
+			for _, modelID := range inc.AffectedModels {
+				if existing, ok := statuses[modelID]; ok && impactRank[existing.Impact] >= impactRank[inc.Impact] {
+					continue
+				}
+				statuses[modelID] = models.ModelStatus{
+					State:      state,
+					Impact:     inc.Impact,
+					IncidentID: inc.ID,
+					Incident:   inc.Name,
+					URL:        inc.URL,
+					Since:      inc.StartedAt,
+				}
+			}
+		}
+	}
+	return statuses
+}
+
+func (m *Monitor) notify(inc Incident) {
+	severity := alerts.SeverityWarning
+	if inc.Impact == ImpactCritical {
+		severity = alerts.SeverityCritical
+	}
+	m.alerts.Notify(alerts.Event{
+		Type:     alerts.EventProviderIncident,
+		Severity: severity,
+		Source:   "status",
+		Title:    fmt.Sprintf("%s incident: %s", inc.Provider, inc.Name),
+		Message:  fmt.Sprintf("%d models affected (%s impact)", len(inc.AffectedModels), inc.Impact),
+		Fields: map[string]string{
+			"provider": inc.Provider,
+			"status":   inc.Status,
+			"url":      inc.URL,
+		},
+		Key: "status:" + inc.ID,
+	})
+}
+
+// Providers returns the last known state of every polled provider, sorted by name
+func (m *Monitor) Providers() []ProviderStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]ProviderStatus, 0, len(m.providers))
+	for _, p := range m.providers {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Provider < list[j].Provider })
+	return list
+}
+
+// Incidents returns the active incidents across every polled provider
+func (m *Monitor) Incidents() []Incident {
+	incidents := []Incident{}
+	for _, p := range m.Providers() {
+		incidents = append(incidents, p.Incidents...)
+	}
+	return incidents
+}
@@ -0,0 +1,114 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID on responses, on calls to providers and
+// other services, and from callers who bring their own
+const Header = "X-Request-ID"
+
+// maxIDLength bounds caller-supplied request IDs
+const maxIDLength = 128
+
+type contextKey struct{}
+
+// NewID returns a new request ID
+func NewID() string {
+	return uuid.NewString()
+}
+
+// WithID returns a context carrying the request ID
+func WithID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the request ID carried by ctx, or "" outside a request
+func ID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Detach returns a background context carrying ctx's request ID, for work
+// that outlives the request such as writes after the response
+func Detach(ctx context.Context) context.Context {
+	return WithID(context.Background(), ID(ctx))
+}
+
+// Middleware assigns every request an ID at ingress, keeping a well-formed
+// one supplied by the caller, and returns it on the response. The ID is
+// carried by the request context and set as "request_id" on the gin context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !validID(id) {
+			id = NewID()
+		}
+		c.Set("request_id", id)
+		c.Header(Header, id)
+		c.Request = c.Request.WithContext(WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validID accepts printable ASCII IDs without spaces, so they are safe to log
+// and forward
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Inject sets the request ID of req's context on the outgoing call
+func Inject(req *http.Request) {
+	if id := ID(req.Context()); id != "" {
+		req.Header.Set(Header, id)
+	}
+}
+
+// Logf logs like log.Printf, ending the line with the request ID when ctx
+// carries one
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if id := ID(ctx); id != "" {
+		log.Printf(format+" request_id=%s", append(args, id)...)
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// LogFormatter is gin's access log line with the request ID appended
+func LogFormatter(param gin.LogFormatterParams) string {
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	id, _ := param.Keys["request_id"].(string)
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | request_id=%s\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		param.Path,
+		id,
+		param.ErrorMessage,
+	)
+}
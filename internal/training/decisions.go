@@ -0,0 +1,255 @@
+package training
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/Askeban/llm-router-go/internal/classification"
+	"github.com/Askeban/llm-router-go/internal/limits"
+	"github.com/Askeban/llm-router-go/internal/mirror"
+	"github.com/Askeban/llm-router-go/internal/privacy"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+// saveTimeout bounds the write of one decision, which happens after the
+// response is sent
+const saveTimeout = 5 * time.Second
+
+var (
+	codePattern = regexp.MustCompile("```|\\b(func|def|class|import|return|SELECT)\\b|[;{}]\\s*$")
+	urlPattern  = regexp.MustCompile(`https?://`)
+)
+
+// Features describe a prompt without keeping any of its text
+type Features struct {
+	PromptChars  int  `json:"prompt_chars"`
+	PromptTokens int  `json:"prompt_tokens"` // Estimated at four characters each
+	Lines        int  `json:"lines"`
+	Images       int  `json:"images"`
+	HasCode      bool `json:"has_code"`
+	HasURL       bool `json:"has_url"`
+	Question     bool `json:"question"`
+	ContainsPII  bool `json:"contains_pii"` // Emails, phone numbers and the like were detected
+}
+
+// Classification is the classifier's output without the matched keywords,
+// which can quote the prompt
+type Classification struct {
+	TaskType         string                 `json:"task_type"`
+	Category         string                 `json:"category"`
+	Subcategory      string                 `json:"subcategory,omitempty"`
+	Complexity       string                 `json:"complexity"`
+	ComplexityScore  float64                `json:"complexity_score"`
+	Priority         string                 `json:"priority"`
+	ReasoningDepth   string                 `json:"reasoning_depth"`
+	Confidence       float64                `json:"confidence"`
+	KeywordCount     int                    `json:"keyword_count"`
+	CategoryFallback bool                   `json:"category_fallback,omitempty"`
+	RulesGeneration  int64                  `json:"rules_generation"`
+	Requirements     map[string]interface{} `json:"requirements,omitempty"` // Flags and numbers only
+}
+
+// Candidate is one ranked model of a decision
+type Candidate struct {
+	ModelID         string             `json:"model_id"`
+	Rank            int                `json:"rank"`
+	OverallScore    float64            `json:"overall_score"`
+	ComponentScores map[string]float64 `json:"component_scores"`
+	Confidence      float64            `json:"confidence"`
+	CostEstimate    float64            `json:"cost_estimate"`
+	ColdStart       bool               `json:"cold_start,omitempty"`
+	Fallback        bool               `json:"fallback,omitempty"`
+}
+
+// Policies supplies tenants' prompt logging policies
+type Policies interface {
+	Get(ctx context.Context, userID string) (privacy.Policy, error)
+}
+
+// Recorder keeps each smart recommendation's prompt features, classification
+// and ranked candidates. Decisions of tenants whose logging mode is none are
+// not kept, and kept ones follow the tenant's prompt retention.
+type Recorder struct {
+	db       *sql.DB
+	policies Policies
+}
+
+type decision struct {
+	id             string
+	userID         string
+	features       Features
+	classification Classification
+	priority       string
+	weightsVariant string
+	candidates     []Candidate
+}
+
+func NewRecorder(db *sql.DB, policies Policies) *Recorder {
+	return &Recorder{db: db, policies: policies}
+}
+
+// RecordDecision keeps the decision in the background and returns its ID
+func (r *Recorder) RecordDecision(userID, prompt string, images int, result classification.ClassificationResult, recs recommendation.RecommendationResponse) string {
+	d := decision{
+		id:             uuid.NewString(),
+		userID:         userID,
+		features:       extractFeatures(prompt, images),
+		classification: summarize(result),
+		priority:       recs.Request.Priority,
+		weightsVariant: recs.Metadata.WeightsVariant,
+	}
+	for i, rec := range recs.Recommendations {
+		d.candidates = append(d.candidates, Candidate{
+			ModelID:         rec.Model.ID,
+			Rank:            i + 1,
+			OverallScore:    rec.OverallScore,
+			ComponentScores: rec.ComponentScores,
+			Confidence:      rec.Confidence,
+			CostEstimate:    rec.CostEstimate,
+			ColdStart:       rec.ColdStart,
+			Fallback:        rec.Fallback,
+		})
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+		defer cancel()
+		if err := r.save(ctx, d); err != nil {
+			log.Printf("[TRAINING] %v", err)
+		}
+	}()
+	return d.id
+}
+
+func (r *Recorder) save(ctx context.Context, d decision) error {
+	if r.policies != nil {
+		policy, err := r.policies.Get(ctx, d.userID)
+		if err != nil {
+			return fmt.Errorf("decision not kept: %w", err)
+		}
+		if policy.Mode == privacy.ModeNone {
+			return nil
+		}
+	}
+
+	features, err := json.Marshal(d.features)
+	if err != nil {
+		return fmt.Errorf("failed to encode features: %w", err)
+	}
+	result, err := json.Marshal(d.classification)
+	if err != nil {
+		return fmt.Errorf("failed to encode classification: %w", err)
+	}
+	candidates, err := json.Marshal(d.candidates)
+	if err != nil {
+		return fmt.Errorf("failed to encode candidates: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO recommendation_decisions (id, user_id, features, classification, priority, weights_variant, candidates, chosen_model)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8)`,
+		d.id, d.userID, features, result, d.priority, d.weightsVariant, candidates, d.candidates[0].ModelID)
+	if err != nil {
+		return fmt.Errorf("failed to record decision: %w", err)
+	}
+	return nil
+}
+
+// Name identifies the decision log in retention and purge reports
+func (r *Recorder) Name() string {
+	return "recommendation_decisions"
+}
+
+// ExpirePrompts deletes decisions older than each tenant's retention, and the
+// contents of training exports past exportRetention
+func (r *Recorder) ExpirePrompts(ctx context.Context, defaultDays int) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM recommendation_decisions d
+		WHERE d.created_at < CURRENT_TIMESTAMP - make_interval(days => COALESCE(
+		      (SELECT p.retention_days FROM logging_policies p WHERE p.user_id = d.user_id), $1))`,
+		defaultDays)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire decisions: %w", err)
+	}
+	expired, _ := res.RowsAffected()
+
+	res, err = r.db.ExecContext(ctx, `
+		UPDATE training_exports SET data = NULL, status = $2
+		WHERE data IS NOT NULL AND completed_at < $1`,
+		time.Now().Add(-exportRetention), StatusExpired)
+	if err != nil {
+		return expired, fmt.Errorf("failed to expire training exports: %w", err)
+	}
+	files, _ := res.RowsAffected()
+	return expired + files, nil
+}
+
+// PurgeTenant deletes a tenant's decisions. Stored exports may hold them
+// under a pseudonym, so their contents are dropped too.
+func (r *Recorder) PurgeTenant(ctx context.Context, userID string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM recommendation_decisions WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge decisions: %w", err)
+	}
+	purged, _ := res.RowsAffected()
+
+	res, err = r.db.ExecContext(ctx, `
+		UPDATE training_exports SET data = NULL, status = $1 WHERE data IS NOT NULL`, StatusExpired)
+	if err != nil {
+		return purged, fmt.Errorf("failed to purge training exports: %w", err)
+	}
+	files, _ := res.RowsAffected()
+	return purged + files, nil
+}
+
+// extractFeatures measures a prompt; nothing of its text is kept
+func extractFeatures(prompt string, images int) Features {
+	_, pii := mirror.Redact(prompt)
+	return Features{
+		PromptChars:  utf8.RuneCountInString(prompt),
+		PromptTokens: limits.EstimateTokens(prompt),
+		Lines:        strings.Count(prompt, "\n") + 1,
+		Images:       images,
+		HasCode:      codePattern.MatchString(prompt),
+		HasURL:       urlPattern.MatchString(prompt),
+		Question:     strings.HasSuffix(strings.TrimSpace(prompt), "?"),
+		ContainsPII:  pii,
+	}
+}
+
+// summarize keeps the classifier's labels and drops anything that can echo
+// the prompt: matched keywords, reasoning steps and string requirements
+func summarize(result classification.ClassificationResult) Classification {
+	c := Classification{
+		TaskType:         result.TaskType,
+		Category:         result.Category,
+		Subcategory:      result.Subcategory,
+		Complexity:       result.Complexity,
+		ComplexityScore:  result.ComplexityScore,
+		Priority:         result.Priority,
+		ReasoningDepth:   result.ReasoningDepth,
+		Confidence:       result.Confidence,
+		KeywordCount:     len(result.DetectedKeywords),
+		CategoryFallback: result.CategoryFallback,
+		RulesGeneration:  result.RulesGeneration,
+	}
+	for name, value := range result.Requirements {
+		switch value.(type) {
+		case bool, int, int64, float64:
+			if c.Requirements == nil {
+				c.Requirements = make(map[string]interface{})
+			}
+			c.Requirements[name] = value
+		}
+	}
+	return c
+}
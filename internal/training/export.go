@@ -0,0 +1,377 @@
+package training
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/mirror"
+)
+
+// SchemaVersion increases whenever exported records change shape. Fields may
+// be added under the same version, but never renamed or removed.
+const SchemaVersion = 1
+
+// Export statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusExpired   = "expired" // Contents dropped by retention or a tenant purge
+)
+
+const (
+	// MaxRecords caps the decisions one export holds
+	MaxRecords = 200000
+	// defaultWindow is exported when a request gives no since
+	defaultWindow = 30 * 24 * time.Hour
+	// runTimeout bounds building one export
+	runTimeout = 30 * time.Minute
+	// exportRetention is how long a built export can be downloaded
+	exportRetention = 7 * 24 * time.Hour
+)
+
+var (
+	ErrNotFound = errors.New("training export not found")
+	ErrNotReady = errors.New("training export has not completed")
+)
+
+// Request selects the decisions an export holds
+type Request struct {
+	Since           *time.Time `json:"since,omitempty"`  // Default: 30 days ago
+	Until           *time.Time `json:"until,omitempty"`  // Default: now
+	RatedOnly       bool       `json:"rated_only"`       // Only decisions with feedback
+	IncludeComments bool       `json:"include_comments"` // Feedback comments, with personal data masked
+	MaxRecords      int        `json:"max_records,omitempty"`
+}
+
+// Export is one built (or building) training dataset
+type Export struct {
+	ID              string     `json:"id"`
+	Status          string     `json:"status"`
+	SchemaVersion   int        `json:"schema_version"`
+	Since           time.Time  `json:"since"`
+	Until           time.Time  `json:"until"`
+	RatedOnly       bool       `json:"rated_only"`
+	IncludeComments bool       `json:"include_comments"`
+	MaxRecords      int        `json:"max_records"`
+	Records         int        `json:"records"`
+	SizeBytes       int64      `json:"size_bytes"`
+	Error           string     `json:"error,omitempty"`
+	RequestedBy     string     `json:"requested_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// Record is one line of an export: a decision, its candidates labelled with
+// the ratings they received, and its feedback
+type Record struct {
+	SchemaVersion  int                `json:"schema_version"`
+	DecisionID     string             `json:"decision_id"`
+	Tenant         string             `json:"tenant,omitempty"` // Pseudonym, stable within one export only
+	Hour           time.Time          `json:"hour"`             // Decision time, truncated to the hour
+	Features       Features           `json:"features"`
+	Classification Classification     `json:"classification"`
+	Priority       string             `json:"priority,omitempty"`
+	WeightsVariant string             `json:"weights_variant,omitempty"`
+	ChosenModel    string             `json:"chosen_model"`
+	Candidates     []LabeledCandidate `json:"candidates"`
+	Feedback       []FeedbackRecord   `json:"feedback"`
+}
+
+// LabeledCandidate is a candidate with its latest rating, null when unrated
+type LabeledCandidate struct {
+	Candidate
+	Label *int `json:"label"`
+}
+
+// FeedbackRecord is a rating given to one of a decision's models
+type FeedbackRecord struct {
+	ModelID string    `json:"model_id"`
+	Rating  int       `json:"rating"`
+	Comment string    `json:"comment,omitempty"`
+	Hour    time.Time `json:"hour"`
+}
+
+// Exporter builds training datasets from the decision log
+type Exporter struct {
+	db *sql.DB
+}
+
+func NewExporter(db *sql.DB) *Exporter {
+	return &Exporter{db: db}
+}
+
+// Trigger records an export and builds it in the background
+func (e *Exporter) Trigger(ctx context.Context, req Request, requestedBy string) (*Export, error) {
+	now := time.Now()
+	until := now
+	if req.Until != nil {
+		until = *req.Until
+	}
+	since := until.Add(-defaultWindow)
+	if req.Since != nil {
+		since = *req.Since
+	}
+	if !since.Before(until) {
+		return nil, fmt.Errorf("since must be before until")
+	}
+	if req.MaxRecords <= 0 || req.MaxRecords > MaxRecords {
+		req.MaxRecords = MaxRecords
+	}
+
+	x := &Export{
+		Status:          StatusPending,
+		SchemaVersion:   SchemaVersion,
+		Since:           since,
+		Until:           until,
+		RatedOnly:       req.RatedOnly,
+		IncludeComments: req.IncludeComments,
+		MaxRecords:      req.MaxRecords,
+		RequestedBy:     requestedBy,
+	}
+	err := e.db.QueryRowContext(ctx, `
+		INSERT INTO training_exports (status, schema_version, since, until, rated_only, include_comments, max_records, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+		RETURNING id, created_at`,
+		x.Status, x.SchemaVersion, x.Since, x.Until, x.RatedOnly, x.IncludeComments, x.MaxRecords, x.RequestedBy,
+	).Scan(&x.ID, &x.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create training export: %w", err)
+	}
+
+	go e.run(*x)
+	return x, nil
+}
+
+// run builds an export and stores it gzipped, or records why it failed
+func (e *Exporter) run(x Export) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	if _, err := e.db.ExecContext(ctx, `UPDATE training_exports SET status = $2 WHERE id = $1`, x.ID, StatusRunning); err != nil {
+		log.Printf("[TRAINING] Export %s: %v", x.ID, err)
+	}
+
+	data, records, err := e.build(ctx, x)
+	if err != nil {
+		log.Printf("[TRAINING] Export %s failed: %v", x.ID, err)
+		if _, err := e.db.ExecContext(context.Background(), `
+			UPDATE training_exports SET status = $2, error = $3, completed_at = CURRENT_TIMESTAMP
+			WHERE id = $1`, x.ID, StatusFailed, err.Error()); err != nil {
+			log.Printf("[TRAINING] Export %s: %v", x.ID, err)
+		}
+		return
+	}
+
+	_, err = e.db.ExecContext(ctx, `
+		UPDATE training_exports SET status = $2, records = $3, size_bytes = $4, data = $5, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, x.ID, StatusCompleted, records, len(data), data)
+	if err != nil {
+		log.Printf("[TRAINING] Export %s: failed to store: %v", x.ID, err)
+		return
+	}
+	log.Printf("[TRAINING] Export %s: %d decisions, %d bytes", x.ID, records, len(data))
+}
+
+// build writes the selected decisions as gzipped JSON lines. Tenants are
+// replaced by a pseudonym keyed per export, times are cut to the hour, and
+// tenants whose logging mode is now none are left out.
+func (e *Exporter) build(ctx context.Context, x Export) ([]byte, int, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, 0, fmt.Errorf("failed to generate pseudonym key: %w", err)
+	}
+
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT d.id, COALESCE(d.user_id::text, ''), d.features, d.classification,
+		       COALESCE(d.priority, ''), COALESCE(d.weights_variant, ''), d.candidates, d.chosen_model, d.created_at,
+		       COALESCE((SELECT json_agg(json_build_object('model_id', f.model_id, 'rating', f.rating,
+		                         'comment', COALESCE(f.comment, ''), 'hour', date_trunc('hour', f.created_at))
+		                         ORDER BY f.created_at)
+		                 FROM model_feedback f WHERE f.decision_id = d.id), '[]')
+		FROM recommendation_decisions d
+		WHERE d.created_at >= $1 AND d.created_at < $2
+		  AND ($3 = FALSE OR EXISTS (SELECT 1 FROM model_feedback f WHERE f.decision_id = d.id))
+		  AND NOT EXISTS (SELECT 1 FROM logging_policies p WHERE p.user_id = d.user_id AND p.mode = 'none')
+		ORDER BY d.created_at
+		LIMIT $4`,
+		x.Since, x.Until, x.RatedOnly, x.MaxRecords)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read decisions: %w", err)
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	records := 0
+	for rows.Next() {
+		var r Record
+		var userID string
+		var features, result, candidates, feedback []byte
+		if err := rows.Scan(&r.DecisionID, &userID, &features, &result, &r.Priority, &r.WeightsVariant,
+			&candidates, &r.ChosenModel, &r.Hour, &feedback); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan decision: %w", err)
+		}
+		if err := decodeRecord(&r, features, result, candidates, feedback); err != nil {
+			return nil, 0, fmt.Errorf("decision %s: %w", r.DecisionID, err)
+		}
+
+		r.SchemaVersion = SchemaVersion
+		r.Hour = r.Hour.UTC().Truncate(time.Hour)
+		if userID != "" {
+			r.Tenant = pseudonym(salt, userID)
+		}
+		for i := range r.Feedback {
+			if x.IncludeComments {
+				r.Feedback[i].Comment, _ = mirror.Redact(r.Feedback[i].Comment)
+			} else {
+				r.Feedback[i].Comment = ""
+			}
+		}
+		label(&r)
+
+		if err := enc.Encode(r); err != nil {
+			return nil, 0, fmt.Errorf("failed to encode record: %w", err)
+		}
+		records++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, 0, fmt.Errorf("failed to compress export: %w", err)
+	}
+	return buf.Bytes(), records, nil
+}
+
+func decodeRecord(r *Record, features, result, candidates, feedback []byte) error {
+	if err := json.Unmarshal(features, &r.Features); err != nil {
+		return fmt.Errorf("invalid features: %w", err)
+	}
+	if err := json.Unmarshal(result, &r.Classification); err != nil {
+		return fmt.Errorf("invalid classification: %w", err)
+	}
+	if err := json.Unmarshal(candidates, &r.Candidates); err != nil {
+		return fmt.Errorf("invalid candidates: %w", err)
+	}
+	if err := json.Unmarshal(feedback, &r.Feedback); err != nil {
+		return fmt.Errorf("invalid feedback: %w", err)
+	}
+	return nil
+}
+
+// label gives each candidate the latest rating of its model
+func label(r *Record) {
+	for _, f := range r.Feedback {
+		for i := range r.Candidates {
+			if r.Candidates[i].ModelID == f.ModelID {
+				rating := f.Rating
+				r.Candidates[i].Label = &rating
+			}
+		}
+	}
+}
+
+// pseudonym replaces a tenant ID with a keyed hash, so records of one tenant
+// can be grouped but not traced back or joined across exports
+func pseudonym(salt []byte, userID string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// List returns the exports, newest first
+func (e *Exporter) List(ctx context.Context) ([]Export, error) {
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT `+exportColumns+` FROM training_exports ORDER BY created_at DESC LIMIT 100`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list training exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []Export{}
+	for rows.Next() {
+		x, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, x)
+	}
+	return exports, rows.Err()
+}
+
+// Get returns one export
+func (e *Exporter) Get(ctx context.Context, id string) (Export, error) {
+	x, err := scanExport(e.db.QueryRowContext(ctx, `
+		SELECT `+exportColumns+` FROM training_exports WHERE id::text = $1`, id))
+	if err == sql.ErrNoRows {
+		return x, ErrNotFound
+	}
+	return x, err
+}
+
+// Open returns a completed export's gzipped JSON lines
+func (e *Exporter) Open(ctx context.Context, id string) (Export, []byte, error) {
+	x, err := e.Get(ctx, id)
+	if err != nil {
+		return x, nil, err
+	}
+	if x.Status != StatusCompleted {
+		return x, nil, ErrNotReady
+	}
+
+	var data []byte
+	if err := e.db.QueryRowContext(ctx, `SELECT data FROM training_exports WHERE id = $1`, x.ID).Scan(&data); err != nil {
+		return x, nil, fmt.Errorf("failed to read training export: %w", err)
+	}
+	return x, data, nil
+}
+
+// Delete removes an export
+func (e *Exporter) Delete(ctx context.Context, id string) error {
+	res, err := e.db.ExecContext(ctx, `DELETE FROM training_exports WHERE id::text = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete training export: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const exportColumns = `id, status, schema_version, since, until, rated_only, include_comments, max_records,
+	records, COALESCE(size_bytes, 0), COALESCE(error, ''), COALESCE(requested_by, ''), created_at, completed_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExport(row scanner) (Export, error) {
+	var x Export
+	var completedAt sql.NullTime
+	err := row.Scan(&x.ID, &x.Status, &x.SchemaVersion, &x.Since, &x.Until, &x.RatedOnly, &x.IncludeComments,
+		&x.MaxRecords, &x.Records, &x.SizeBytes, &x.Error, &x.RequestedBy, &x.CreatedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		return x, err
+	}
+	if err != nil {
+		return x, fmt.Errorf("failed to scan training export: %w", err)
+	}
+	if completedAt.Valid {
+		x.CompletedAt = &completedAt.Time
+	}
+	return x, nil
+}
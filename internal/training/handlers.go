@@ -0,0 +1,123 @@
+package training
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers lets admins build and download training exports
+type Handlers struct {
+	exporter *Exporter
+}
+
+func NewHandlers(exporter *Exporter) *Handlers {
+	return &Handlers{exporter: exporter}
+}
+
+// Create starts building an export; the body is optional
+func (h *Handlers) Create(c *gin.Context) {
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	x, err := h.exporter.Trigger(c.Request.Context(), req, c.GetString("admin_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to start training export",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    x,
+	})
+}
+
+// List returns recent exports and the record schema version
+func (h *Handlers) List(c *gin.Context) {
+	exports, err := h.exporter.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list training exports",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"exports":        exports,
+			"schema_version": SchemaVersion,
+			"max_records":    MaxRecords,
+		},
+	})
+}
+
+// Get returns an export's status
+func (h *Handlers) Get(c *gin.Context) {
+	x, err := h.exporter.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, "Failed to get training export", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    x,
+	})
+}
+
+// Download sends a completed export as gzipped JSON lines
+func (h *Handlers) Download(c *gin.Context) {
+	x, data, err := h.exporter.Open(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, "Failed to download training export", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="training-%s.jsonl.gz"`, x.ID))
+	c.Header("X-Training-Schema-Version", strconv.Itoa(x.SchemaVersion))
+	c.Header("X-Training-Records", strconv.Itoa(x.Records))
+	c.Data(http.StatusOK, "application/gzip", data)
+}
+
+// Delete removes an export
+func (h *Handlers) Delete(c *gin.Context) {
+	if err := h.exporter.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, "Failed to delete training export", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Training export deleted",
+	})
+}
+
+// respondError maps exporter errors to statuses
+func (h *Handlers) respondError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNotReady):
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
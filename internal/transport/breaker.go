@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while a client's breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+const (
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// BreakerHook is called when a client's breaker trips from closed to open
+type BreakerHook func(name string, failures int, cooldown time.Duration)
+
+var (
+	breakerHookMu sync.RWMutex
+	breakerHook   BreakerHook
+)
+
+// SetBreakerHook registers the callback for breaker trips, replacing any previous one
+func SetBreakerHook(hook BreakerHook) {
+	breakerHookMu.Lock()
+	defer breakerHookMu.Unlock()
+	breakerHook = hook
+}
+
+func notifyBreakerOpen(name string, failures int) {
+	breakerHookMu.RLock()
+	hook := breakerHook
+	breakerHookMu.RUnlock()
+	if hook != nil {
+		hook(name, failures, breakerCooldown)
+	}
+}
+
+// breakerTransport fails fast after consecutive failures of a named client.
+// It wraps the retry transport, so one failure is a request that exhausted
+// its retries. After the cooldown a single probe is let through; success
+// closes the breaker and failure reopens it.
+type breakerTransport struct {
+	base http.RoundTripper
+	name string
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.allow() {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, t.name)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if req.Context().Err() != nil {
+		// Caller cancellations say nothing about the upstream's health
+		t.release()
+		return resp, err
+	}
+	t.record(err != nil || resp.StatusCode >= 500)
+	return resp, err
+}
+
+func (t *breakerTransport) allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.openUntil.IsZero() {
+		return true
+	}
+	if time.Now().Before(t.openUntil) || t.probing {
+		return false
+	}
+	t.probing = true
+	return true
+}
+
+func (t *breakerTransport) release() {
+	t.mu.Lock()
+	t.probing = false
+	t.mu.Unlock()
+}
+
+func (t *breakerTransport) record(failed bool) {
+	t.mu.Lock()
+	if !failed {
+		t.failures = 0
+		t.openUntil = time.Time{}
+		t.probing = false
+		t.mu.Unlock()
+		return
+	}
+
+	t.failures++
+	wasClosed := t.openUntil.IsZero()
+	if t.probing || t.failures >= breakerThreshold {
+		t.openUntil = time.Now().Add(breakerCooldown)
+	}
+	t.probing = false
+	tripped := wasClosed && !t.openUntil.IsZero()
+	failures := t.failures
+	t.mu.Unlock()
+
+	if tripped {
+		notifyBreakerOpen(t.name, failures)
+	}
+}
@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clientMetrics counts requests and connection reuse for one named client
+type clientMetrics struct {
+	requests    atomic.Int64
+	errors      atomic.Int64
+	retries     atomic.Int64
+	newConns    atomic.Int64
+	reusedConns atomic.Int64
+	http2       atomic.Int64
+	inFlight    atomic.Int64
+	latencyNs   atomic.Int64
+}
+
+var (
+	metricsMu sync.Mutex
+	metrics   = map[string]*clientMetrics{}
+)
+
+func metricsFor(name string) *clientMetrics {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	m, ok := metrics[name]
+	if !ok {
+		m = &clientMetrics{}
+		metrics[name] = m
+	}
+	return m
+}
+
+// instrumentedTransport records each attempt, including retries
+type instrumentedTransport struct {
+	base    http.RoundTripper
+	metrics *clientMetrics
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.metrics.reusedConns.Add(1)
+			} else {
+				t.metrics.newConns.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	t.metrics.requests.Add(1)
+	t.metrics.inFlight.Add(1)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.metrics.inFlight.Add(-1)
+	t.metrics.latencyNs.Add(int64(time.Since(start)))
+
+	if err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		t.metrics.errors.Add(1)
+	}
+	if resp != nil && resp.ProtoMajor == 2 {
+		t.metrics.http2.Add(1)
+	}
+	return resp, err
+}
+
+// ClientStats is a snapshot of one named client's counters
+type ClientStats struct {
+	Name         string  `json:"name"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	Retries      int64   `json:"retries"`
+	NewConns     int64   `json:"new_connections"`
+	ReusedConns  int64   `json:"reused_connections"`
+	HTTP2        int64   `json:"http2_requests"`
+	InFlight     int64   `json:"in_flight"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	TimeoutMs    int64   `json:"timeout_ms"`
+}
+
+// Stats returns per-client connection metrics, sorted by name
+func Stats() []ClientStats {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	stats := make([]ClientStats, 0, len(metrics))
+	for name, m := range metrics {
+		s := ClientStats{
+			Name:        name,
+			Requests:    m.requests.Load(),
+			Errors:      m.errors.Load(),
+			Retries:     m.retries.Load(),
+			NewConns:    m.newConns.Load(),
+			ReusedConns: m.reusedConns.Load(),
+			HTTP2:       m.http2.Load(),
+			InFlight:    m.inFlight.Load(),
+			TimeoutMs:   timeoutFor(name).Milliseconds(),
+		}
+		if s.Requests > 0 {
+			s.AvgLatencyMs = float64(m.latencyNs.Load()) / float64(s.Requests) / 1e6
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
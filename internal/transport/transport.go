@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeouts are per-client request timeouts, covering every retry.
+// Override with HTTP_TIMEOUT_<NAME>, e.g. HTTP_TIMEOUT_ANALYTICS=45s.
+var defaultTimeouts = map[string]time.Duration{
+	"analytics": 30 * time.Second,
+	"openai":    120 * time.Second,
+	"anthropic": 120 * time.Second,
+	"google":    120 * time.Second,
+	"status":    10 * time.Second,
+	"export":    60 * time.Second,
+	"ollama":    300 * time.Second, // Self-hosted backends may load the model first
+	"vllm":      300 * time.Second,
+}
+
+const fallbackTimeout = 30 * time.Second
+
+var (
+	sharedOnce      sync.Once
+	sharedTransport *http.Transport
+
+	clientsMu            sync.Mutex
+	clients              = map[string]*http.Client{}
+	singleAttemptClients = map[string]*http.Client{}
+)
+
+// Shared returns the process-wide pooled transport. HTTP/2 is negotiated via
+// ALPN, so connections to a provider are multiplexed instead of re-dialed.
+func Shared() *http.Transport {
+	sharedOnce.Do(func() {
+		dialer := &net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		sharedTransport = &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          256,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			ResponseHeaderTimeout: 60 * time.Second,
+		}
+	})
+	return sharedTransport
+}
+
+// Client returns the shared client for a named caller (a provider, "analytics", ...).
+// All clients share one connection pool; each has its own timeout, retry policy, circuit breaker and metrics.
+func Client(name string) *http.Client {
+	name = strings.ToLower(name)
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if client, ok := clients[name]; ok {
+		return client
+	}
+
+	client := &http.Client{
+		Timeout: timeoutFor(name),
+		Transport: &breakerTransport{
+			name: name,
+			base: &retryTransport{
+				base:    &instrumentedTransport{base: Shared(), metrics: metricsFor(name)},
+				policy:  DefaultRetryPolicy(),
+				metrics: metricsFor(name),
+			},
+		},
+	}
+	clients[name] = client
+	return client
+}
+
+// SingleAttemptClient is like Client without the retry layer, for callers
+// whose requests must be sent at most once, such as traffic mirroring
+func SingleAttemptClient(name string) *http.Client {
+	name = strings.ToLower(name)
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if client, ok := singleAttemptClients[name]; ok {
+		return client
+	}
+
+	client := &http.Client{
+		Timeout:   timeoutFor(name),
+		Transport: &instrumentedTransport{base: Shared(), metrics: metricsFor(name)},
+	}
+	singleAttemptClients[name] = client
+	return client
+}
+
+func timeoutFor(name string) time.Duration {
+	key := "HTTP_TIMEOUT_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	if d, ok := defaultTimeouts[name]; ok {
+		return d
+	}
+	return fallbackTimeout
+}
@@ -0,0 +1,108 @@
+package trust
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes the trust verification workflow to admins
+type Handlers struct {
+	store *Store
+}
+
+type VerifyRequest struct {
+	Note string `json:"note"`
+}
+
+type SetTierRequest struct {
+	Tier string `json:"tier" binding:"required"`
+	Note string `json:"note"`
+}
+
+func NewHandlers(store *Store) *Handlers {
+	return &Handlers{store: store}
+}
+
+// List returns the trust records with their automated checks, optionally
+// filtered by ?tier=, so admins can review experimental models
+func (h *Handlers) List(c *gin.Context) {
+	records, err := h.store.List(c.Request.Context(), c.Query("tier"))
+	if errors.Is(err, ErrInvalidTier) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       "Invalid trust tier",
+			"valid_tiers": []string{models.TrustVerified, models.TrustCommunity, models.TrustExperimental},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load model trust",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    records,
+	})
+}
+
+// Verify approves a model, moving it to the verified tier
+func (h *Handlers) Verify(c *gin.Context) {
+	var req VerifyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	err := h.store.Verify(c.Request.Context(), c.Param("model_id"), c.GetString("user_id"), req.Note)
+	h.respond(c, err, "Model verified")
+}
+
+// SetTier moves a model to any tier, e.g. demoting a verified model
+func (h *Handlers) SetTier(c *gin.Context) {
+	var req SetTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := h.store.SetTier(c.Request.Context(), c.Param("model_id"), req.Tier, c.GetString("user_id"), req.Note)
+	h.respond(c, err, "Trust tier updated")
+}
+
+func (h *Handlers) respond(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrUnknownModel):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Model not found in trust records",
+		})
+	case errors.Is(err, ErrInvalidTier):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       "Invalid trust tier",
+			"valid_tiers": []string{models.TrustVerified, models.TrustCommunity, models.TrustExperimental},
+		})
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to update model trust",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": message,
+		})
+	}
+}
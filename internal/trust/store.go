@@ -0,0 +1,319 @@
+package trust
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/models"
+)
+
+// VerifiedByChecks is recorded as the verifier of models promoted by passing
+// every automated check
+const VerifiedByChecks = "automated"
+
+// maxNoteLength bounds an admin's note on a tier change
+const maxNoteLength = 2000
+
+var (
+	// ErrUnknownModel is returned when a model has no trust record
+	ErrUnknownModel = errors.New("model has no trust record")
+	// ErrInvalidTier is returned for tiers other than verified, community and experimental
+	ErrInvalidTier = errors.New("invalid trust tier")
+)
+
+// Catalog is the served model catalog trust tiers are shown on
+type Catalog interface {
+	GetAllModels() []models.EnhancedModel
+	SetTrust(trust map[string]models.Trust)
+}
+
+// Config controls automated verification
+type Config struct {
+	AutoVerify bool          // Promote experimental models once every check passes
+	MinAge     time.Duration // How long a model must have been in the catalog to pass the age check
+}
+
+// DefaultConfig returns the default trust configuration
+func DefaultConfig() Config {
+	cfg := Config{AutoVerify: true, MinAge: 7 * 24 * time.Hour}
+
+	// TRUST_AUTO_VERIFY=false leaves every promotion to an admin
+	if v := os.Getenv("TRUST_AUTO_VERIFY"); v != "" {
+		cfg.AutoVerify = v != "false" && v != "0"
+	}
+	// TRUST_MIN_AGE is how long new models stay experimental at least, e.g. "72h"
+	if v := os.Getenv("TRUST_MIN_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.MinAge = d
+		}
+	}
+	return cfg
+}
+
+// Record is one model's trust tier with the automated checks behind it
+type Record struct {
+	ModelID string `json:"model_id"`
+	models.Trust
+	Manual      bool            `json:"manual"` // Set by an admin; automated checks leave it alone
+	Checks      map[string]bool `json:"checks"`
+	Note        string          `json:"note,omitempty"`
+	FirstSeenAt time.Time       `json:"first_seen_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Store persists model trust tiers, places newly ingested models in the
+// experimental tier, promotes them once automated checks pass and keeps every
+// replica's catalog in step
+type Store struct {
+	db       *sql.DB
+	catalog  Catalog
+	auditLog *audit.Logger
+	cfg      Config
+}
+
+func NewStore(db *sql.DB, catalog Catalog, auditLog *audit.Logger, cfg Config) *Store {
+	return &Store{db: db, catalog: catalog, auditLog: auditLog, cfg: cfg}
+}
+
+// Start syncs trust tiers and resyncs them every interval, so new ingests
+// are picked up and changes made through another replica take effect here
+func (s *Store) Start(ctx context.Context, interval time.Duration) {
+	if err := s.Sync(ctx); err != nil {
+		log.Printf("[TRUST] %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Sync(ctx); err != nil {
+					log.Printf("[TRUST] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Sync records catalog models not seen before, runs the automated checks on
+// experimental models and shows the stored tiers on the catalog. The first
+// sync places the existing catalog in the community tier, so only models
+// ingested afterwards start as experimental.
+func (s *Store) Sync(ctx context.Context) error {
+	catalog := s.catalog.GetAllModels()
+
+	var known int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM model_trust`).Scan(&known); err != nil {
+		return fmt.Errorf("failed to count model trust records: %w", err)
+	}
+	tier := models.TrustExperimental
+	if known == 0 {
+		tier = models.TrustCommunity
+	}
+	for _, model := range catalog {
+		if _, err := s.db.ExecContext(ctx, `
+			INSERT INTO model_trust (model_id, tier) VALUES ($1, $2)
+			ON CONFLICT (model_id) DO NOTHING`, model.ID, tier); err != nil {
+			return fmt.Errorf("failed to record model trust: %w", err)
+		}
+	}
+
+	records, err := s.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]models.EnhancedModel, len(catalog))
+	for _, model := range catalog {
+		byID[model.ID] = model
+	}
+	for i, r := range records {
+		model, ok := byID[r.ModelID]
+		if !ok || r.Manual || r.Tier != models.TrustExperimental {
+			continue
+		}
+		checks := s.check(model, r.FirstSeenAt)
+		if err := s.updateChecks(ctx, &records[i], checks); err != nil {
+			return err
+		}
+	}
+
+	trust := make(map[string]models.Trust, len(records))
+	for _, r := range records {
+		trust[r.ModelID] = r.Trust
+	}
+	s.catalog.SetTrust(trust)
+	return nil
+}
+
+// check runs the automated checks a model must pass to be verified without
+// an admin
+func (s *Store) check(model models.EnhancedModel, firstSeen time.Time) map[string]bool {
+	p := model.Pricing
+	priced := (p.Text.CostInPer1K != nil && p.Text.CostOutPer1K != nil) ||
+		(p.CostInPer1K != nil && p.CostOutPer1K != nil) ||
+		p.Generative != nil || p.Image != nil || p.Video != nil || p.Audio != nil
+	b := model.Benchmarks
+	benchmarked := len(b.Text) > 0 || len(b.Image) > 0 || len(b.Video) > 0 || len(b.Audio) > 0 ||
+		b.RawBenchmarks != nil || b.GenerativeBenchmarks != nil
+
+	return map[string]bool{
+		"pricing":    priced,
+		"benchmarks": benchmarked,
+		"sources":    len(model.Sources) >= 2, // Corroborated by more than one source
+		"age":        time.Since(firstSeen) >= s.cfg.MinAge,
+	}
+}
+
+// updateChecks stores changed check results and promotes the model when all
+// of them pass and automated verification is on
+func (s *Store) updateChecks(ctx context.Context, r *Record, checks map[string]bool) error {
+	passed := true
+	changed := len(checks) != len(r.Checks)
+	for name, ok := range checks {
+		passed = passed && ok
+		if r.Checks[name] != ok {
+			changed = true
+		}
+	}
+	promote := passed && s.cfg.AutoVerify
+	if !changed && !promote {
+		return nil
+	}
+
+	encoded, _ := json.Marshal(checks)
+	r.Checks = checks
+	if !promote {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE model_trust SET checks = $2, updated_at = CURRENT_TIMESTAMP WHERE model_id = $1`,
+			r.ModelID, string(encoded)); err != nil {
+			return fmt.Errorf("failed to store trust checks: %w", err)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE model_trust SET tier = $2, checks = $3, verified_by = $4, verified_at = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE model_id = $1 AND tier = $6 AND NOT manual`,
+		r.ModelID, models.TrustVerified, string(encoded), VerifiedByChecks, now, models.TrustExperimental)
+	if err != nil {
+		return fmt.Errorf("failed to verify model: %w", err)
+	}
+	// Another replica promoted it first
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	r.Tier = models.TrustVerified
+	r.VerifiedBy = VerifiedByChecks
+	r.VerifiedAt = &now
+
+	log.Printf("[TRUST] %s verified by automated checks", r.ModelID)
+	s.record(ctx, "", "trust.verified", r.ModelID, map[string]interface{}{
+		"tier":        models.TrustVerified,
+		"verified_by": VerifiedByChecks,
+		"checks":      checks,
+	})
+	return nil
+}
+
+// List returns trust records, newest first, optionally limited to one tier
+func (s *Store) List(ctx context.Context, tier string) ([]Record, error) {
+	if tier != "" && !models.IsValidTrustTier(tier) {
+		return nil, ErrInvalidTier
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT model_id, tier, manual, checks, COALESCE(verified_by, ''), verified_at,
+		       COALESCE(note, ''), first_seen_at, updated_at
+		FROM model_trust
+		WHERE $1 = '' OR tier = $1
+		ORDER BY first_seen_at DESC, model_id`, tier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model trust: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Record{}
+	for rows.Next() {
+		var r Record
+		var checks []byte
+		var verifiedAt sql.NullTime
+		if err := rows.Scan(&r.ModelID, &r.Tier, &r.Manual, &checks, &r.VerifiedBy, &verifiedAt,
+			&r.Note, &r.FirstSeenAt, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan model trust: %w", err)
+		}
+		json.Unmarshal(checks, &r.Checks)
+		if verifiedAt.Valid {
+			t := verifiedAt.Time
+			r.VerifiedAt = &t
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// Verify approves a model on an admin's behalf, moving it to the verified tier
+func (s *Store) Verify(ctx context.Context, modelID, adminID, note string) error {
+	return s.SetTier(ctx, modelID, models.TrustVerified, adminID, note)
+}
+
+// SetTier moves a model to a tier on an admin's behalf. Automated checks no
+// longer change the model's tier afterwards.
+func (s *Store) SetTier(ctx context.Context, modelID, tier, adminID, note string) error {
+	if !models.IsValidTrustTier(tier) {
+		return ErrInvalidTier
+	}
+	if len(note) > maxNoteLength {
+		return fmt.Errorf("note exceeds %d characters", maxNoteLength)
+	}
+
+	var verifiedBy interface{}
+	var verifiedAt interface{}
+	if tier == models.TrustVerified {
+		verifiedBy, verifiedAt = adminID, time.Now()
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE model_trust SET tier = $2, manual = TRUE, verified_by = $3, verified_at = $4,
+		       note = NULLIF($5, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE model_id = $1`,
+		modelID, tier, verifiedBy, verifiedAt, strings.TrimSpace(note))
+	if err != nil {
+		return fmt.Errorf("failed to store model trust: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUnknownModel
+	}
+
+	log.Printf("[TRUST] %s moved to %s by %s", modelID, tier, adminID)
+	s.record(ctx, adminID, "trust."+tier, modelID, map[string]interface{}{
+		"tier": tier,
+		"note": note,
+	})
+	return s.Sync(ctx)
+}
+
+func (s *Store) record(ctx context.Context, userID, eventType, modelID string, details map[string]interface{}) {
+	if s.auditLog == nil {
+		return
+	}
+	if _, err := s.auditLog.Record(ctx, audit.Entry{
+		UserID:    userID,
+		EventType: eventType,
+		Action:    strings.TrimPrefix(eventType, "trust."),
+		Resource:  modelID,
+		Details:   details,
+	}); err != nil {
+		log.Printf("[TRUST] Failed to record audit entry: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package tuning
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// minWeight keeps every component in play, so a sparse fit cannot switch
+	// one off entirely
+	minWeight = 0.02
+	// ridge pulls the fit toward the weights in use, so noisy feedback moves
+	// them gradually
+	ridge = 0.05
+	// fitIterations and learningRate drive the projected gradient descent
+	fitIterations = 500
+	learningRate  = 0.1
+)
+
+// fitWeights finds the component weights whose weighted score best predicts
+// the ratings up to an affine map. Weights stay non-negative, at least
+// minWeight, and sum to the baseline's total, so scores keep their scale.
+// It returns the baseline itself when the fit does not reduce the error.
+func fitWeights(components []string, baseline map[string]float64, samples []Sample) (map[string]float64, float64, float64) {
+	k := len(components)
+	x := make([][]float64, len(samples))
+	y := make([]float64, len(samples))
+	for i, s := range samples {
+		x[i] = make([]float64, k)
+		for j, name := range components {
+			x[i][j] = s.Components[name]
+		}
+		y[i] = float64(s.Rating-1) / 4
+	}
+
+	w0 := make([]float64, k)
+	total := 0.0
+	for j, name := range components {
+		w0[j] = baseline[name]
+		total += w0[j]
+	}
+
+	w := append([]float64(nil), w0...)
+	grad := make([]float64, k)
+	for iter := 0; iter < fitIterations; iter++ {
+		a, b := affine(x, y, w)
+		if b < 0 {
+			b = 0 // A score that predicts ratings backwards says nothing about the weights
+		}
+		for j := range grad {
+			grad[j] = 2 * ridge * (w[j] - w0[j])
+		}
+		for i := range x {
+			residual := a + b*dot(w, x[i]) - y[i]
+			for j := range grad {
+				grad[j] += 2 / float64(len(x)) * residual * b * x[i][j]
+			}
+		}
+		for j := range w {
+			w[j] -= learningRate * grad[j]
+		}
+		w = project(w, total)
+	}
+
+	before, after := affineMSE(x, y, w0), affineMSE(x, y, w)
+	if after >= before {
+		return baseline, before, before
+	}
+
+	fitted := make(map[string]float64, k)
+	for j, name := range components {
+		fitted[name] = math.Round(w[j]*1000) / 1000
+	}
+	return fitted, before, after
+}
+
+// affine regresses the ratings on the weighted scores, returning intercept
+// and slope
+func affine(x [][]float64, y []float64, w []float64) (float64, float64) {
+	n := float64(len(x))
+	var sumS, sumY, sumSS, sumSY float64
+	for i := range x {
+		s := dot(w, x[i])
+		sumS += s
+		sumY += y[i]
+		sumSS += s * s
+		sumSY += s * y[i]
+	}
+	variance := sumSS - sumS*sumS/n
+	if variance <= 1e-12 {
+		return sumY / n, 0
+	}
+	b := (sumSY - sumS*sumY/n) / variance
+	return (sumY - b*sumS) / n, b
+}
+
+// affineMSE is the mean squared error of the best affine map from weighted
+// scores to ratings
+func affineMSE(x [][]float64, y []float64, w []float64) float64 {
+	a, b := affine(x, y, w)
+	sum := 0.0
+	for i := range x {
+		residual := a + b*dot(w, x[i]) - y[i]
+		sum += residual * residual
+	}
+	return math.Round(sum/float64(len(x))*1e6) / 1e6
+}
+
+// project returns the closest weights that are at least minWeight and sum to
+// total
+func project(w []float64, total float64) []float64 {
+	floor := math.Min(minWeight, total/float64(len(w)))
+	budget := total - floor*float64(len(w))
+
+	// Euclidean projection of w-floor onto the simplex of size budget
+	shifted := make([]float64, len(w))
+	for i := range w {
+		shifted[i] = w[i] - floor
+	}
+	sorted := append([]float64(nil), shifted...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+	cumulative, theta := 0.0, 0.0
+	for i, v := range sorted {
+		cumulative += v
+		t := (cumulative - budget) / float64(i+1)
+		if v-t > 0 {
+			theta = t
+		}
+	}
+
+	projected := make([]float64, len(w))
+	for i := range shifted {
+		projected[i] = math.Max(shifted[i]-theta, 0) + floor
+	}
+	return projected
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,139 @@
+package tuning
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes tuned weights, their experiments and promotion to admins
+type Handlers struct {
+	tuner *Tuner
+}
+
+type ExperimentRequest struct {
+	Percent int `json:"percent" binding:"required,min=1,max=100"` // Share of callers served the candidate
+}
+
+func NewHandlers(tuner *Tuner) *Handlers {
+	return &Handlers{tuner: tuner}
+}
+
+// List returns each priority's built-in, promoted and candidate weights and
+// the outcomes of running experiments
+func (h *Handlers) List(c *gin.Context) {
+	sets, err := h.tuner.Sets(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load scoring weights",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"weights":        sets,
+			"min_samples":    minSamples,
+			"success_rating": successRating,
+		},
+	})
+}
+
+// Refit fits candidate weights now
+func (h *Handlers) Refit(c *gin.Context) {
+	if err := h.tuner.Refresh(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fit candidate weights",
+			"details": err.Error(),
+		})
+		return
+	}
+	h.List(c)
+}
+
+// StartExperiment serves the priority's candidate weights to a share of
+// callers, or changes the share of the running experiment
+func (h *Handlers) StartExperiment(c *gin.Context) {
+	var req ExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := h.tuner.StartExperiment(c.Request.Context(), c.Param("priority"), req.Percent, c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// StopExperiment returns every caller to the control weights
+func (h *Handlers) StopExperiment(c *gin.Context) {
+	err := h.tuner.StopExperiment(c.Request.Context(), c.Param("priority"), c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Promote serves the experiment's weights to every caller
+func (h *Handlers) Promote(c *gin.Context) {
+	err := h.tuner.Promote(c.Request.Context(), c.Param("priority"), c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Revert returns the priority to its built-in weights
+func (h *Handlers) Revert(c *gin.Context) {
+	reverted, err := h.tuner.Revert(c.Request.Context(), c.Param("priority"), c.GetString("admin_id"))
+	if !h.respond(c, err) {
+		return
+	}
+	if !reverted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No promoted weights to revert",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// respond writes the error response for err, reporting whether there was none
+func (h *Handlers) respond(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrUnknownPriority):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Unknown priority",
+			"details": "priority must be balanced, quality, speed, cost or green",
+		})
+	case errors.Is(err, ErrNoCandidate), errors.Is(err, ErrNoExperiment):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Cannot change scoring weights",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update scoring weights",
+			"details": err.Error(),
+		})
+	}
+	return false
+}
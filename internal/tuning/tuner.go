@@ -0,0 +1,512 @@
+package tuning
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+	"github.com/Askeban/llm-router-go/internal/recommendation"
+)
+
+const (
+	// minSamples is the rated recommendations a priority needs before it is fitted
+	minSamples = 100
+	// maxSamples bounds the rows fitted per priority
+	maxSamples = 20000
+	// lookback limits fitting to recent feedback
+	lookback = 90 * 24 * time.Hour
+	// successRating is the lowest rating counted as a success in outcomes
+	successRating = 4
+	// syncInterval is how often replicas pick up promotions and experiments
+	syncInterval = 30 * time.Second
+)
+
+var (
+	ErrUnknownPriority = errors.New("unknown priority")
+	ErrNoCandidate     = errors.New("no candidate weights; the priority lacks enough feedback with component scores")
+	ErrNoExperiment    = errors.New("no experiment is running")
+)
+
+// Sample is one rated recommendation's component scores
+type Sample struct {
+	Components map[string]float64
+	Rating     int
+}
+
+// Fit is a candidate weight set fitted on feedback
+type Fit struct {
+	Priority  string             `json:"priority"`
+	Baseline  map[string]float64 `json:"baseline"` // Weights served to the control arm when fitted
+	Weights   map[string]float64 `json:"weights"`
+	Samples   int                `json:"samples"`
+	MSEBefore float64            `json:"mse_before"` // Rating prediction error of the baseline
+	MSEAfter  float64            `json:"mse_after"`
+	FittedAt  time.Time          `json:"fitted_at"`
+}
+
+// Experiment serves frozen candidate weights to a share of callers
+type Experiment struct {
+	Weights   map[string]float64 `json:"weights"`
+	Percent   int                `json:"percent"`
+	StartedAt time.Time          `json:"started_at"`
+}
+
+// Outcome is the feedback one variant received during an experiment
+type Outcome struct {
+	Variant     string  `json:"variant"`
+	Ratings     int     `json:"ratings"`
+	MeanRating  float64 `json:"mean_rating"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// WeightSet is everything known about one priority's weights
+type WeightSet struct {
+	Priority   string             `json:"priority"`
+	Default    map[string]float64 `json:"default"`
+	Promoted   map[string]float64 `json:"promoted,omitempty"`
+	PromotedAt *time.Time         `json:"promoted_at,omitempty"`
+	Experiment *Experiment        `json:"experiment,omitempty"`
+	Outcomes   []Outcome          `json:"outcomes,omitempty"` // By variant since the experiment started
+	Candidate  *Fit               `json:"candidate,omitempty"`
+}
+
+// stored is a priority's promoted weights and experiment from the database
+type stored struct {
+	promoted   map[string]float64
+	promotedAt *time.Time
+	experiment *Experiment
+}
+
+// Tuner fits component weights per priority against feedback ratings and
+// serves promoted weights, or candidate weights to an experiment's share of
+// callers. Candidates are only served once an admin starts an experiment, and
+// only replace the control weights once promoted.
+type Tuner struct {
+	db *sql.DB
+
+	mu     sync.RWMutex
+	fits   map[string]Fit
+	stored map[string]stored
+}
+
+func NewTuner(db *sql.DB) *Tuner {
+	return &Tuner{db: db, fits: map[string]Fit{}, stored: map[string]stored{}}
+}
+
+// Weights implements recommendation.WeightSource. Subjects are assigned to an
+// experiment's arms by hash, so a caller keeps its arm for the experiment.
+func (t *Tuner) Weights(priority, subject string) (map[string]float64, string) {
+	priority = normalizePriority(priority)
+
+	t.mu.RLock()
+	set, ok := t.stored[priority]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, ""
+	}
+
+	// Copies, because the engine adds plugin scorers to the weights it reports
+	if exp := set.experiment; exp != nil && subject != "" && bucket(priority, subject) < exp.Percent {
+		return copyWeights(exp.Weights), recommendation.WeightsCandidate
+	}
+	if set.promoted != nil {
+		return copyWeights(set.promoted), recommendation.WeightsPromoted
+	}
+	return nil, ""
+}
+
+// Start syncs promotions and experiments every syncInterval and refits on
+// the given interval until ctx is cancelled
+func (t *Tuner) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		if err := t.Sync(ctx); err != nil {
+			log.Printf("[TUNING] Sync failed: %v", err)
+		}
+		if err := t.Refresh(ctx); err != nil {
+			log.Printf("[TUNING] Refit failed: %v", err)
+		}
+
+		syncTicker := time.NewTicker(syncInterval)
+		defer syncTicker.Stop()
+		refit := time.NewTicker(interval)
+		defer refit.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-syncTicker.C:
+				if err := t.Sync(ctx); err != nil {
+					log.Printf("[TUNING] Sync failed: %v", err)
+				}
+			case <-refit.C:
+				if err := t.Refresh(ctx); err != nil {
+					log.Printf("[TUNING] Refit failed: %v", err)
+				}
+			}
+			health.Beat(ctx)
+		}
+	}()
+}
+
+// Sync loads the promoted weights and experiments every replica serves
+func (t *Tuner) Sync(ctx context.Context) error {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT priority, promoted, promoted_at, candidate, candidate_percent, experiment_started_at
+		FROM scoring_weights`)
+	if err != nil {
+		return fmt.Errorf("failed to load scoring weights: %w", err)
+	}
+	defer rows.Close()
+
+	sets := make(map[string]stored)
+	for rows.Next() {
+		var priority string
+		var promoted, candidate []byte
+		var promotedAt, startedAt sql.NullTime
+		var percent int
+		if err := rows.Scan(&priority, &promoted, &promotedAt, &candidate, &percent, &startedAt); err != nil {
+			return fmt.Errorf("failed to scan scoring weights: %w", err)
+		}
+
+		var set stored
+		if promoted != nil {
+			if err := json.Unmarshal(promoted, &set.promoted); err != nil {
+				return fmt.Errorf("invalid promoted weights for %s: %w", priority, err)
+			}
+			set.promotedAt = &promotedAt.Time
+		}
+		if candidate != nil {
+			exp := &Experiment{Percent: percent, StartedAt: startedAt.Time}
+			if err := json.Unmarshal(candidate, &exp.Weights); err != nil {
+				return fmt.Errorf("invalid candidate weights for %s: %w", priority, err)
+			}
+			set.experiment = exp
+		}
+		sets[priority] = set
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.stored = sets
+	t.mu.Unlock()
+	return nil
+}
+
+// Refresh refits every priority with enough feedback against the weights
+// its control arm is served
+func (t *Tuner) Refresh(ctx context.Context) error {
+	byPriority, err := t.samples(ctx)
+	if err != nil {
+		return err
+	}
+
+	fits := make(map[string]Fit)
+	for _, priority := range recommendation.Priorities {
+		baseline := t.control(priority)
+		components := sortedKeys(baseline)
+
+		var usable []Sample
+		for _, s := range byPriority[priority] {
+			if hasAll(s.Components, components) {
+				usable = append(usable, s)
+			}
+		}
+		if len(usable) < minSamples {
+			continue
+		}
+
+		weights, before, after := fitWeights(components, baseline, usable)
+		fits[priority] = Fit{
+			Priority:  priority,
+			Baseline:  baseline,
+			Weights:   weights,
+			Samples:   len(usable),
+			MSEBefore: before,
+			MSEAfter:  after,
+			FittedAt:  time.Now(),
+		}
+	}
+
+	t.mu.Lock()
+	t.fits = fits
+	t.mu.Unlock()
+
+	log.Printf("[TUNING] Fitted candidate weights for %d priorities", len(fits))
+	return nil
+}
+
+// Sets returns every priority's weights, with the outcomes of running
+// experiments
+func (t *Tuner) Sets(ctx context.Context) ([]WeightSet, error) {
+	sets := make([]WeightSet, 0, len(recommendation.Priorities))
+	t.mu.RLock()
+	for _, priority := range recommendation.Priorities {
+		set := WeightSet{Priority: priority, Default: recommendation.DefaultWeights(priority)}
+		if s, ok := t.stored[priority]; ok {
+			set.Promoted, set.PromotedAt, set.Experiment = s.promoted, s.promotedAt, s.experiment
+		}
+		if fit, ok := t.fits[priority]; ok {
+			set.Candidate = &fit
+		}
+		sets = append(sets, set)
+	}
+	t.mu.RUnlock()
+
+	for i := range sets {
+		if set := &sets[i]; set.Experiment != nil {
+			outcomes, err := t.outcomes(ctx, set.Priority, set.Experiment.StartedAt)
+			if err != nil {
+				return nil, err
+			}
+			set.Outcomes = outcomes
+		}
+	}
+	return sets, nil
+}
+
+// StartExperiment serves the current candidate to percent of callers, or
+// changes the share of a running experiment without touching its weights
+func (t *Tuner) StartExperiment(ctx context.Context, priority string, percent int, userID string) error {
+	if !knownPriority(priority) {
+		return ErrUnknownPriority
+	}
+	if percent < 1 || percent > 100 {
+		return fmt.Errorf("percent must be between 1 and 100")
+	}
+
+	result, err := t.db.ExecContext(ctx, `
+		UPDATE scoring_weights
+		SET candidate_percent = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE priority = $1 AND candidate IS NOT NULL`,
+		priority, percent, nullable(userID))
+	if err != nil {
+		return fmt.Errorf("failed to update experiment: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		log.Printf("[TUNING] %s experiment now serves %d%% of callers", priority, percent)
+		return t.Sync(ctx)
+	}
+
+	t.mu.RLock()
+	fit, ok := t.fits[priority]
+	t.mu.RUnlock()
+	if !ok {
+		return ErrNoCandidate
+	}
+	weights, err := json.Marshal(fit.Weights)
+	if err != nil {
+		return fmt.Errorf("failed to encode candidate weights: %w", err)
+	}
+
+	_, err = t.db.ExecContext(ctx, `
+		INSERT INTO scoring_weights (priority, candidate, candidate_percent, experiment_started_at, updated_by)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $4)
+		ON CONFLICT (priority) DO UPDATE SET
+			candidate = EXCLUDED.candidate,
+			candidate_percent = EXCLUDED.candidate_percent,
+			experiment_started_at = EXCLUDED.experiment_started_at,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP`,
+		priority, weights, percent, nullable(userID))
+	if err != nil {
+		return fmt.Errorf("failed to start experiment: %w", err)
+	}
+	log.Printf("[TUNING] Started %s experiment on %d%% of callers: %v", priority, percent, fit.Weights)
+	return t.Sync(ctx)
+}
+
+// StopExperiment returns every caller to the control weights
+func (t *Tuner) StopExperiment(ctx context.Context, priority, userID string) error {
+	if !knownPriority(priority) {
+		return ErrUnknownPriority
+	}
+	result, err := t.db.ExecContext(ctx, `
+		UPDATE scoring_weights
+		SET candidate = NULL, candidate_percent = 0, experiment_started_at = NULL,
+			updated_by = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE priority = $1 AND candidate IS NOT NULL`,
+		priority, nullable(userID))
+	if err != nil {
+		return fmt.Errorf("failed to stop experiment: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNoExperiment
+	}
+	log.Printf("[TUNING] Stopped %s experiment", priority)
+	return t.Sync(ctx)
+}
+
+// Promote makes the experiment's weights the ones every caller is served
+func (t *Tuner) Promote(ctx context.Context, priority, userID string) error {
+	if !knownPriority(priority) {
+		return ErrUnknownPriority
+	}
+	result, err := t.db.ExecContext(ctx, `
+		UPDATE scoring_weights
+		SET promoted = candidate, promoted_at = CURRENT_TIMESTAMP,
+			candidate = NULL, candidate_percent = 0, experiment_started_at = NULL,
+			updated_by = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE priority = $1 AND candidate IS NOT NULL`,
+		priority, nullable(userID))
+	if err != nil {
+		return fmt.Errorf("failed to promote weights: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNoExperiment
+	}
+	log.Printf("[TUNING] Promoted %s candidate weights", priority)
+	return t.Sync(ctx)
+}
+
+// Revert drops the promoted weights, returning callers to the built-in ones.
+// It reports whether there were promoted weights.
+func (t *Tuner) Revert(ctx context.Context, priority, userID string) (bool, error) {
+	if !knownPriority(priority) {
+		return false, ErrUnknownPriority
+	}
+	result, err := t.db.ExecContext(ctx, `
+		UPDATE scoring_weights
+		SET promoted = NULL, promoted_at = NULL, updated_by = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE priority = $1 AND promoted IS NOT NULL`,
+		priority, nullable(userID))
+	if err != nil {
+		return false, fmt.Errorf("failed to revert weights: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	log.Printf("[TUNING] Reverted %s to the built-in weights", priority)
+	return true, t.Sync(ctx)
+}
+
+// control returns the weights a priority's control arm is served
+func (t *Tuner) control(priority string) map[string]float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if set, ok := t.stored[priority]; ok && set.promoted != nil {
+		return copyWeights(set.promoted)
+	}
+	return recommendation.DefaultWeights(priority)
+}
+
+func (t *Tuner) samples(ctx context.Context) (map[string][]Sample, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT priority, component_scores, rating FROM (
+			SELECT priority, component_scores, rating,
+				ROW_NUMBER() OVER (PARTITION BY priority ORDER BY created_at DESC) AS n
+			FROM model_feedback
+			WHERE priority IS NOT NULL AND component_scores IS NOT NULL AND created_at >= $1
+		) recent
+		WHERE n <= $2`, time.Now().Add(-lookback), maxSamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tuning samples: %w", err)
+	}
+	defer rows.Close()
+
+	byPriority := make(map[string][]Sample)
+	for rows.Next() {
+		var priority string
+		var components []byte
+		var s Sample
+		if err := rows.Scan(&priority, &components, &s.Rating); err != nil {
+			return nil, fmt.Errorf("failed to scan tuning sample: %w", err)
+		}
+		if err := json.Unmarshal(components, &s.Components); err != nil {
+			continue // Written by a client; skip what does not parse
+		}
+		byPriority[priority] = append(byPriority[priority], s)
+	}
+	return byPriority, rows.Err()
+}
+
+// outcomes compares the ratings each variant received since the experiment started
+func (t *Tuner) outcomes(ctx context.Context, priority string, since time.Time) ([]Outcome, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT weights_variant, COUNT(*), AVG(rating),
+			AVG(CASE WHEN rating >= $3 THEN 1.0 ELSE 0.0 END)
+		FROM model_feedback
+		WHERE priority = $1 AND created_at >= $2 AND weights_variant IS NOT NULL
+		GROUP BY weights_variant
+		ORDER BY weights_variant`, priority, since, successRating)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load experiment outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	outcomes := []Outcome{}
+	for rows.Next() {
+		var o Outcome
+		if err := rows.Scan(&o.Variant, &o.Ratings, &o.MeanRating, &o.SuccessRate); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment outcome: %w", err)
+		}
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
+
+// bucket places a subject in one of 100 buckets, independently per priority
+func bucket(priority, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(priority + ":" + subject))
+	return int(h.Sum32() % 100)
+}
+
+// normalizePriority maps priorities the engine scores as balanced to it
+func normalizePriority(priority string) string {
+	if knownPriority(priority) {
+		return priority
+	}
+	return "balanced"
+}
+
+func knownPriority(priority string) bool {
+	for _, p := range recommendation.Priorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAll(components map[string]float64, names []string) bool {
+	for _, name := range names {
+		if _, ok := components[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(weights map[string]float64) []string {
+	keys := make([]string, 0, len(weights))
+	for k := range weights {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func copyWeights(weights map[string]float64) map[string]float64 {
+	copied := make(map[string]float64, len(weights))
+	for k, v := range weights {
+		copied[k] = v
+	}
+	return copied
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
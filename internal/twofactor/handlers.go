@@ -0,0 +1,369 @@
+package twofactor
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/auth"
+)
+
+// Handlers exposes enrollment and challenges to dashboard users, policies
+// to operators, and the middleware that enforces both
+type Handlers struct {
+	store      *Store
+	jwtManager *auth.JWTManager
+	maxAge     time.Duration
+}
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type PutPolicyRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// NewHandlers issues tokens with jwtManager; sensitive operations require a
+// challenge passed within maxAge
+func NewHandlers(store *Store, jwtManager *auth.JWTManager, maxAge time.Duration) *Handlers {
+	return &Handlers{store: store, jwtManager: jwtManager, maxAge: maxAge}
+}
+
+// Status returns the caller's second factor and their organization's policy
+func (h *Handlers) Status(c *gin.Context) {
+	status, err := h.store.Status(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load two-factor status",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// Enroll starts enrollment and returns the secret for the authenticator app
+func (h *Handlers) Enroll(c *gin.Context) {
+	enrollment, err := h.store.Enroll(c.Request.Context(), c.GetString("user_id"), c.GetString("user_email"))
+	if errors.Is(err, ErrAlreadyEnrolled) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start two-factor enrollment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    enrollment,
+		"message": "Add the secret to an authenticator app, then confirm with a code",
+	})
+}
+
+// Confirm completes enrollment and returns the backup codes, with a token
+// that has passed the challenge
+func (h *Handlers) Confirm(c *gin.Context) {
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.store.Confirm(c.Request.Context(), c.GetString("user_id"), req.Code)
+	if err != nil {
+		h.codeError(c, err)
+		return
+	}
+	token, err := h.token(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"backup_codes": codes,
+			"token":        token,
+		},
+		"message": "Store the backup codes somewhere safe; they are not shown again",
+	})
+}
+
+// Challenge verifies a code and returns a token that has passed the
+// challenge, for sensitive operations
+func (h *Handlers) Challenge(c *gin.Context) {
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.store.Verify(c.Request.Context(), c.GetString("user_id"), req.Code); err != nil {
+		h.codeError(c, err)
+		return
+	}
+	token, err := h.token(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"token":     token,
+			"valid_for": h.maxAge.String(),
+		},
+	})
+}
+
+// RegenerateBackupCodes replaces the caller's backup codes
+func (h *Handlers) RegenerateBackupCodes(c *gin.Context) {
+	codes, err := h.store.RegenerateBackupCodes(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.codeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"backup_codes": codes,
+		},
+		"message": "Previous backup codes no longer work",
+	})
+}
+
+// Disable removes the caller's second factor, unless their organization
+// requires one
+func (h *Handlers) Disable(c *gin.Context) {
+	userID := c.GetString("user_id")
+	status, err := h.store.Status(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load two-factor status",
+			"details": err.Error(),
+		})
+		return
+	}
+	if status.Required {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":        "Your organization requires two-factor authentication",
+			"organization": status.Organization,
+		})
+		return
+	}
+
+	if err := h.store.Disable(c.Request.Context(), userID); err != nil {
+		h.codeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// ListPolicies returns every organization's policy
+func (h *Handlers) ListPolicies(c *gin.Context) {
+	policies, err := h.store.Policies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list two-factor policies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policies,
+	})
+}
+
+// PutPolicy sets whether an organization requires a second factor
+func (h *Handlers) PutPolicy(c *gin.Context) {
+	var req PutPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := h.store.PutPolicy(c.Request.Context(), c.Param("organization"), *req.Required, c.GetString("admin_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid two-factor policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// DeletePolicy makes enrollment optional again for an organization
+func (h *Handlers) DeletePolicy(c *gin.Context) {
+	deleted, err := h.store.DeletePolicy(c.Request.Context(), c.Param("organization"), c.GetString("admin_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete two-factor policy",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No two-factor policy for this organization",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// Enforce guards the dashboard: users whose organization requires a second
+// factor must enroll, and users with one must have passed it in this
+// session. A nil Handlers lets every request through.
+func (h *Handlers) Enforce() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h == nil {
+			c.Next()
+			return
+		}
+
+		status, err := h.store.Status(c.Request.Context(), c.GetString("user_id"))
+		if err != nil {
+			log.Printf("[2FA] Failed to check two-factor status: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check two-factor authentication",
+			})
+			c.Abort()
+			return
+		}
+
+		switch {
+		case status.Required && !status.Enabled:
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":               "Two-factor enrollment required",
+				"details":             "Your organization requires two-factor authentication; enroll at /api/v1/auth/2fa/enroll",
+				"two_factor_required": true,
+			})
+			c.Abort()
+			return
+		case status.Enabled && c.GetTime("two_factor_at").IsZero():
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":               "Two-factor challenge required",
+				"details":             "This session has not passed two-factor authentication; POST a code to /api/v1/auth/2fa/challenge",
+				"two_factor_required": true,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRecent guards sensitive operations: users with a second factor, or
+// whose organization requires one, must have passed a challenge within the
+// max age. A nil Handlers lets every request through.
+func (h *Handlers) RequireRecent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h == nil {
+			c.Next()
+			return
+		}
+
+		if passed := c.GetTime("two_factor_at"); !passed.IsZero() && time.Since(passed) <= h.maxAge {
+			c.Next()
+			return
+		}
+
+		status, err := h.store.Status(c.Request.Context(), c.GetString("user_id"))
+		if err != nil {
+			log.Printf("[2FA] Failed to check two-factor status: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check two-factor authentication",
+			})
+			c.Abort()
+			return
+		}
+		if !status.Enabled && !status.Required {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":               "Recent two-factor challenge required",
+			"details":             "POST a code to /api/v1/auth/2fa/challenge and retry with the returned token",
+			"max_age":             h.maxAge.String(),
+			"two_factor_required": true,
+		})
+		c.Abort()
+	}
+}
+
+// token reissues the caller's token as having just passed a challenge
+func (h *Handlers) token(c *gin.Context) (string, error) {
+	return h.jwtManager.GenerateTwoFactor(c.GetString("user_id"), c.GetString("user_email"), c.GetString("user_plan"), time.Now())
+}
+
+// codeError answers a failed enrollment or verification
+func (h *Handlers) codeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid two-factor code",
+		})
+	case errors.Is(err, ErrLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Too many invalid two-factor codes",
+			"details": err.Error(),
+		})
+	case errors.Is(err, ErrNotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is not enabled",
+		})
+	case errors.Is(err, ErrAlreadyEnrolled):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Two-factor verification failed",
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/audit"
+	"github.com/Askeban/llm-router-go/internal/vault"
+)
+
+const (
+	// backupCodeCount is how many backup codes confirming or regenerating issues
+	backupCodeCount = 10
+	// maxFailedAttempts locks verification after this many wrong codes in a row
+	maxFailedAttempts = 5
+	// lockout is how long verification stays locked
+	lockout = 15 * time.Minute
+)
+
+var (
+	ErrNotEnrolled     = errors.New("two-factor authentication is not enabled")
+	ErrAlreadyEnrolled = errors.New("two-factor authentication is already enabled")
+	ErrInvalidCode     = errors.New("invalid two-factor code")
+	ErrLocked          = errors.New("too many invalid two-factor codes, try again later")
+)
+
+// Status is an account's second factor and its organization's policy
+type Status struct {
+	Enabled         bool       `json:"enabled"`
+	Pending         bool       `json:"pending"` // Enrolled but not yet confirmed with a code
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	BackupCodesLeft int        `json:"backup_codes_left"`
+	Organization    string     `json:"organization,omitempty"`
+	Required        bool       `json:"required"` // The organization requires a second factor
+}
+
+// Enrollment is the secret an authenticator app is set up with
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"` // Shown as a QR code
+}
+
+// Policy is whether an organization's dashboard users must enroll
+type Policy struct {
+	Organization string    `json:"organization"`
+	Required     bool      `json:"required"`
+	UpdatedBy    string    `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Store persists TOTP secrets, sealed by the key vault, with backup codes
+// and per-organization enforcement. Organizations are the users'
+// company_name.
+type Store struct {
+	db       *sql.DB
+	vault    *vault.Vault
+	auditLog *audit.Logger
+}
+
+func NewStore(db *sql.DB, v *vault.Vault) *Store {
+	return &Store{db: db, vault: v}
+}
+
+// SetAuditLog records enrollments, backup code use and policy changes
+func (s *Store) SetAuditLog(auditLog *audit.Logger) {
+	s.auditLog = auditLog
+}
+
+// NormalizeOrganization is the key policies are stored under
+func NormalizeOrganization(organization string) string {
+	return strings.ToLower(strings.TrimSpace(organization))
+}
+
+// Status returns the account's second factor and whether its organization
+// requires one
+func (s *Store) Status(ctx context.Context, userID string) (Status, error) {
+	var status Status
+	var organization sql.NullString
+	var confirmedAt sql.NullTime
+	var pending sql.NullBool
+	var backupCodes []byte
+	err := s.db.QueryRowContext(ctx, `
+		SELECT u.company_name, tf.confirmed_at, tf.user_id IS NOT NULL AND tf.confirmed_at IS NULL,
+		       COALESCE(tf.backup_codes, '[]'::jsonb), COALESCE(p.required, FALSE)
+		FROM users u
+		LEFT JOIN two_factor tf ON tf.user_id = u.id
+		LEFT JOIN two_factor_policies p ON p.organization = LOWER(TRIM(u.company_name))
+		WHERE u.id = $1`, userID,
+	).Scan(&organization, &confirmedAt, &pending, &backupCodes, &status.Required)
+	if err != nil {
+		return status, fmt.Errorf("failed to load two-factor status: %w", err)
+	}
+
+	status.Organization = organization.String
+	status.Pending = pending.Bool
+	if confirmedAt.Valid {
+		status.Enabled = true
+		status.ConfirmedAt = &confirmedAt.Time
+	}
+	var hashes []string
+	json.Unmarshal(backupCodes, &hashes)
+	status.BackupCodesLeft = len(hashes)
+	return status, nil
+}
+
+// Enrolled reports whether the account has confirmed a second factor
+func (s *Store) Enrolled(ctx context.Context, userID string) (bool, error) {
+	status, err := s.Status(ctx, userID)
+	return status.Enabled, err
+}
+
+// Enroll starts enrollment with a new secret, replacing a pending one. The
+// second factor is not enforced until Confirm.
+func (s *Store) Enroll(ctx context.Context, userID, email string) (Enrollment, error) {
+	status, err := s.Status(ctx, userID)
+	if err != nil {
+		return Enrollment{}, err
+	}
+	if status.Enabled {
+		return Enrollment{}, ErrAlreadyEnrolled
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return Enrollment{}, err
+	}
+	sealed, err := s.vault.Seal([]byte(secret))
+	if err != nil {
+		return Enrollment{}, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO two_factor (user_id, sealed_secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			sealed_secret = EXCLUDED.sealed_secret,
+			backup_codes = '[]'::jsonb,
+			last_step = 0,
+			failed_attempts = 0,
+			locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE two_factor.confirmed_at IS NULL`,
+		userID, sealed)
+	if err != nil {
+		return Enrollment{}, fmt.Errorf("failed to store two-factor secret: %w", err)
+	}
+
+	return Enrollment{Secret: secret, URI: provisioningURI(secret, email)}, nil
+}
+
+// Confirm completes enrollment with a code from the authenticator app and
+// returns the backup codes, which are only ever shown here
+func (s *Store) Confirm(ctx context.Context, userID, input string) ([]string, error) {
+	var codes []string
+	err := s.withFactor(ctx, userID, func(f *factor) error {
+		if f.confirmedAt.Valid {
+			return ErrAlreadyEnrolled
+		}
+		counter, ok := matchStep(f.secret, normalizeCode(input), time.Now(), f.lastStep)
+		if !ok {
+			return ErrInvalidCode
+		}
+
+		var hashes []string
+		var err error
+		codes, hashes, err = generateBackupCodes()
+		if err != nil {
+			return err
+		}
+		f.lastStep = counter
+		f.backupCodes = hashes
+		f.confirmedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[2FA] Two-factor authentication enabled for %s", userID)
+	s.record(ctx, userID, "enable", nil)
+	return codes, nil
+}
+
+// Verify checks an authenticator code or consumes a backup code. Repeated
+// wrong codes lock verification for a while.
+func (s *Store) Verify(ctx context.Context, userID, input string) error {
+	usedBackup := false
+	remaining := 0
+	err := s.withFactor(ctx, userID, func(f *factor) error {
+		if !f.confirmedAt.Valid {
+			return ErrNotEnrolled
+		}
+
+		input = normalizeCode(input)
+		if isTOTPCode(input) {
+			if counter, ok := matchStep(f.secret, input, time.Now(), f.lastStep); ok {
+				f.lastStep = counter
+				return nil
+			}
+			return ErrInvalidCode
+		}
+
+		hash := hashBackupCode(input)
+		for i, stored := range f.backupCodes {
+			if stored == hash {
+				f.backupCodes = append(f.backupCodes[:i], f.backupCodes[i+1:]...)
+				usedBackup, remaining = true, len(f.backupCodes)
+				return nil
+			}
+		}
+		return ErrInvalidCode
+	})
+	if err != nil {
+		return err
+	}
+
+	if usedBackup {
+		log.Printf("[2FA] Backup code used by %s, %d left", userID, remaining)
+		s.record(ctx, userID, "backup_code", map[string]interface{}{"remaining": remaining})
+	}
+	return nil
+}
+
+// RegenerateBackupCodes replaces every backup code
+func (s *Store) RegenerateBackupCodes(ctx context.Context, userID string) ([]string, error) {
+	var codes []string
+	err := s.withFactor(ctx, userID, func(f *factor) error {
+		if !f.confirmedAt.Valid {
+			return ErrNotEnrolled
+		}
+		var hashes []string
+		var err error
+		codes, hashes, err = generateBackupCodes()
+		f.backupCodes = hashes
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, userID, "backup_codes", nil)
+	return codes, nil
+}
+
+// Disable removes the account's second factor
+func (s *Store) Disable(ctx context.Context, userID string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM two_factor WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove two-factor secret: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotEnrolled
+	}
+
+	log.Printf("[2FA] Two-factor authentication disabled for %s", userID)
+	s.record(ctx, userID, "disable", nil)
+	return nil
+}
+
+// Policies lists every organization with a stored policy
+func (s *Store) Policies(ctx context.Context) ([]Policy, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT organization, required, COALESCE(updated_by, ''), updated_at
+		FROM two_factor_policies ORDER BY organization`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list two-factor policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []Policy{}
+	for rows.Next() {
+		var p Policy
+		if err := rows.Scan(&p.Organization, &p.Required, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan two-factor policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// PutPolicy sets whether an organization requires a second factor
+func (s *Store) PutPolicy(ctx context.Context, organization string, required bool, adminID string) error {
+	organization = NormalizeOrganization(organization)
+	if organization == "" {
+		return fmt.Errorf("organization is required")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO two_factor_policies (organization, required, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization) DO UPDATE SET
+			required = EXCLUDED.required,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP`,
+		organization, required, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to store two-factor policy: %w", err)
+	}
+
+	log.Printf("[2FA] %s set two-factor required=%t for %s", adminID, required, organization)
+	s.record(ctx, "", "policy", map[string]interface{}{
+		"organization": organization,
+		"required":     required,
+		"admin_id":     adminID,
+	})
+	return nil
+}
+
+// DeletePolicy drops an organization's policy, so enrollment is optional again
+func (s *Store) DeletePolicy(ctx context.Context, organization, adminID string) (bool, error) {
+	organization = NormalizeOrganization(organization)
+	res, err := s.db.ExecContext(ctx, `DELETE FROM two_factor_policies WHERE organization = $1`, organization)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete two-factor policy: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n > 0 {
+		log.Printf("[2FA] %s removed the two-factor policy of %s", adminID, organization)
+		s.record(ctx, "", "policy", map[string]interface{}{
+			"organization": organization,
+			"removed":      true,
+			"admin_id":     adminID,
+		})
+	}
+	return n > 0, nil
+}
+
+// factor is one account's row, opened for update
+type factor struct {
+	secret         string
+	backupCodes    []string
+	lastStep       int64
+	failedAttempts int
+	lockedUntil    sql.NullTime
+	confirmedAt    sql.NullTime
+}
+
+// withFactor locks the account's row, runs fn and writes back its changes.
+// ErrInvalidCode counts towards the lockout; success resets it.
+func (s *Store) withFactor(ctx context.Context, userID string, fn func(f *factor) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var f factor
+	var sealed, backupCodes []byte
+	err = tx.QueryRowContext(ctx, `
+		SELECT sealed_secret, backup_codes, last_step, failed_attempts, locked_until, confirmed_at
+		FROM two_factor WHERE user_id = $1 FOR UPDATE`, userID,
+	).Scan(&sealed, &backupCodes, &f.lastStep, &f.failedAttempts, &f.lockedUntil, &f.confirmedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotEnrolled
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load two-factor secret: %w", err)
+	}
+	if f.lockedUntil.Valid && time.Now().Before(f.lockedUntil.Time) {
+		return ErrLocked
+	}
+	secret, err := s.vault.Open(sealed)
+	if err != nil {
+		return fmt.Errorf("failed to open two-factor secret: %w", err)
+	}
+	f.secret = string(secret)
+	json.Unmarshal(backupCodes, &f.backupCodes)
+
+	result := fn(&f)
+	switch {
+	case result == nil:
+		f.failedAttempts, f.lockedUntil = 0, sql.NullTime{}
+	case errors.Is(result, ErrInvalidCode):
+		f.failedAttempts++
+		if f.failedAttempts >= maxFailedAttempts {
+			log.Printf("[2FA] Verification locked for %s after %d invalid codes", userID, f.failedAttempts)
+			f.failedAttempts, f.lockedUntil = 0, sql.NullTime{Time: time.Now().Add(lockout), Valid: true}
+		}
+	default:
+		return result
+	}
+
+	if f.backupCodes == nil {
+		f.backupCodes = []string{}
+	}
+	encoded, _ := json.Marshal(f.backupCodes)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE two_factor SET
+			backup_codes = $2, last_step = $3, failed_attempts = $4, locked_until = $5,
+			confirmed_at = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1`,
+		userID, encoded, f.lastStep, f.failedAttempts, f.lockedUntil, f.confirmedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update two-factor state: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit two-factor state: %w", err)
+	}
+	return result
+}
+
+func (s *Store) record(ctx context.Context, userID, action string, details map[string]interface{}) {
+	if s.auditLog == nil {
+		return
+	}
+	if _, err := s.auditLog.Record(ctx, audit.Entry{
+		UserID:    userID,
+		EventType: "auth.two_factor." + action,
+		Action:    action,
+		Resource:  "two_factor",
+		Details:   details,
+	}); err != nil {
+		log.Printf("[2FA] Failed to record audit entry: %v", err)
+	}
+}
+
+// normalizeCode drops the spaces and dashes users copy along with codes
+func normalizeCode(input string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(input)))
+}
+
+// generateBackupCodes returns new backup codes, formatted "abcd-efgh", and
+// the hashes stored in their place
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate backup codes: %w", err)
+		}
+		encoded := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+		codes[i] = encoded[:4] + "-" + encoded[4:]
+		hashes[i] = hashBackupCode(encoded)
+	}
+	return codes, hashes, nil
+}
+
+func hashBackupCode(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,182 @@
+package usage
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRangeDays bounds dashboard queries so they stay on the date index
+const maxRangeDays = 366
+
+// Handlers exposes usage aggregates for the web dashboard
+type Handlers struct {
+	tracker *Tracker
+}
+
+func NewHandlers(tracker *Tracker) *Handlers {
+	return &Handlers{tracker: tracker}
+}
+
+// Daily returns requests, tokens, cost and top categories/models per day or week
+func (h *Handlers) Daily(c *gin.Context) {
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid date range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	period := c.DefaultQuery("period", "day")
+	series, err := h.tracker.Timeseries(c.Request.Context(), c.GetString("user_id"), period, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to get usage timeseries",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"period": period,
+			"from":   from.Format("2006-01-02"),
+			"to":     to.Format("2006-01-02"),
+			"series": series,
+		},
+	})
+}
+
+// ByModel returns usage per recommended model
+func (h *Handlers) ByModel(c *gin.Context) {
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid date range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	usage, err := h.tracker.ByModel(c.Request.Context(), c.GetString("user_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get usage by model",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"from":   from.Format("2006-01-02"),
+			"to":     to.Format("2006-01-02"),
+			"models": usage,
+		},
+	})
+}
+
+// ByTag returns usage and cost per value of the tag given by key
+func (h *Handlers) ByTag(c *gin.Context) {
+	key := c.Query("key")
+	if err := ValidateTagKey(key); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tag key",
+			"details": err.Error(),
+		})
+		return
+	}
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid date range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	values, err := h.tracker.ByTag(c.Request.Context(), c.GetString("user_id"), key, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get usage by tag",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"key":    key,
+			"from":   from.Format("2006-01-02"),
+			"to":     to.Format("2006-01-02"),
+			"values": values,
+		},
+	})
+}
+
+// Duplicates returns each key's duplicate and near-duplicate prompt rates
+func (h *Handlers) Duplicates(c *gin.Context) {
+	from, to, err := parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid date range",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	keys, err := h.tracker.DuplicatesByKey(c.Request.Context(), c.GetString("user_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get duplicate prompt rates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"from": from.Format("2006-01-02"),
+			"to":   to.Format("2006-01-02"),
+			"keys": keys,
+		},
+	})
+}
+
+// parseRange reads from/to (YYYY-MM-DD), defaulting to the last 30 days
+func parseRange(c *gin.Context) (time.Time, time.Time, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -29)
+	to := today
+
+	if s := c.Query("from"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from date: %s", s)
+		}
+		from = t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to date: %s", s)
+		}
+		to = t
+	}
+
+	if to.Before(from) {
+		return from, to, fmt.Errorf("to must not be before from")
+	}
+	if to.Sub(from) > maxRangeDays*24*time.Hour {
+		return from, to, fmt.Errorf("range exceeds %d days", maxRangeDays)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,98 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/health"
+)
+
+// MetadataOutputTokens is the api_usage.metadata key generations record their
+// output length under
+const MetadataOutputTokens = "output_tokens"
+
+// OutputLengths learns how long generations run in each category from the
+// recorded usage of every replica
+type OutputLengths struct {
+	db         *sql.DB
+	window     time.Duration
+	minSamples int
+
+	mu      sync.RWMutex
+	medians map[string]int
+}
+
+// NewOutputLengths reports the median output of the last window's successful
+// generations per category, once a category has minSamples of them
+func NewOutputLengths(db *sql.DB, window time.Duration, minSamples int) *OutputLengths {
+	return &OutputLengths{db: db, window: window, minSamples: minSamples, medians: map[string]int{}}
+}
+
+// Start loads the medians and reloads them every interval
+func (o *OutputLengths) Start(ctx context.Context, interval time.Duration) {
+	if err := o.Refresh(ctx); err != nil {
+		log.Printf("[USAGE] %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := o.Refresh(ctx); err != nil {
+					log.Printf("[USAGE] %v", err)
+				}
+				health.Beat(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh recomputes the per-category medians
+func (o *OutputLengths) Refresh(ctx context.Context) error {
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT prompt_category,
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY (metadata->>'output_tokens')::int)
+		FROM api_usage
+		WHERE endpoint = '/api/v2/generate' AND status_code = 200
+		  AND COALESCE(prompt_category, '') <> '' AND metadata ? 'output_tokens'
+		  AND timestamp >= $1
+		GROUP BY prompt_category
+		HAVING COUNT(*) >= $2`, time.Now().Add(-o.window), o.minSamples)
+	if err != nil {
+		return fmt.Errorf("failed to load generation lengths: %w", err)
+	}
+	defer rows.Close()
+
+	medians := make(map[string]int)
+	for rows.Next() {
+		var category string
+		var median float64
+		if err := rows.Scan(&category, &median); err != nil {
+			return fmt.Errorf("failed to scan generation lengths: %w", err)
+		}
+		medians[category] = int(median + 0.5)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load generation lengths: %w", err)
+	}
+
+	o.mu.Lock()
+	o.medians = medians
+	o.mu.Unlock()
+	return nil
+}
+
+// ExpectedOutputTokens returns the median generation length in the category
+func (o *OutputLengths) ExpectedOutputTokens(category string) (int, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	tokens, ok := o.medians[category]
+	return tokens, ok
+}
@@ -0,0 +1,299 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// topN is how many categories and models are reported per period
+const topN = 5
+
+// Count is a labelled request count
+type Count struct {
+	Name     string `json:"name"`
+	Requests int    `json:"requests"`
+}
+
+// PeriodUsage aggregates usage for one day or week
+type PeriodUsage struct {
+	Period        string  `json:"period"`
+	Requests      int     `json:"requests"`
+	Tokens        int     `json:"tokens"`
+	CostUSD       float64 `json:"cost_usd"`
+	Errors        int     `json:"errors"`
+	Duplicates    int     `json:"duplicates"`      // Prompts the key had sent within the fingerprint window
+	NearDuplicate int     `json:"near_duplicates"` // Prompts nearly identical to a recent one
+	DuplicateRate float64 `json:"duplicate_rate"`  // Exact and near duplicates over requests
+	TopCategories []Count `json:"top_categories"`
+	TopModels     []Count `json:"top_models"`
+}
+
+// ModelUsage aggregates usage for one recommended model
+type ModelUsage struct {
+	Model         string  `json:"model"`
+	Requests      int     `json:"requests"`
+	Tokens        int     `json:"tokens"`
+	CostUSD       float64 `json:"cost_usd"`
+	AvgResponseMs float64 `json:"avg_response_ms"`
+	Share         float64 `json:"share"`
+}
+
+// periodExpr maps a granularity to the bucket expression over date_bucket
+func periodExpr(granularity string) (string, error) {
+	switch granularity {
+	case "", "day":
+		return "date_bucket", nil
+	case "week":
+		return "date_trunc('week', date_bucket)::date", nil
+	}
+	return "", fmt.Errorf("invalid period: %s (expected day or week)", granularity)
+}
+
+// Timeseries returns per-day or per-week usage between from and to (inclusive)
+func (t *Tracker) Timeseries(ctx context.Context, userID, granularity string, from, to time.Time) ([]PeriodUsage, error) {
+	bucket, err := periodExpr(granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Totals per period; served by idx_usage_user_date
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT `+bucket+` AS period, COUNT(*), COALESCE(SUM(tokens_estimated), 0),
+		       COALESCE(SUM(cost_usd), 0), COUNT(*) FILTER (WHERE status_code >= 400),
+		       COUNT(*) FILTER (WHERE duplicate_kind = 'exact'), COUNT(*) FILTER (WHERE duplicate_kind = 'near')
+		FROM api_usage
+		WHERE user_id = $1 AND date_bucket BETWEEN $2 AND $3
+		GROUP BY period
+		ORDER BY period`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []PeriodUsage{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var p PeriodUsage
+		var period time.Time
+		if err := rows.Scan(&period, &p.Requests, &p.Tokens, &p.CostUSD, &p.Errors, &p.Duplicates, &p.NearDuplicate); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		if p.Requests > 0 {
+			p.DuplicateRate = float64(p.Duplicates+p.NearDuplicate) / float64(p.Requests)
+		}
+		p.Period = period.Format("2006-01-02")
+		p.TopCategories = []Count{}
+		p.TopModels = []Count{}
+		index[p.Period] = len(periods)
+		periods = append(periods, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Top categories and models per period
+	breakdowns := []struct {
+		column string
+		assign func(p *PeriodUsage, counts []Count)
+	}{
+		{"prompt_category", func(p *PeriodUsage, counts []Count) { p.TopCategories = counts }},
+		{"recommended_model", func(p *PeriodUsage, counts []Count) { p.TopModels = counts }},
+	}
+	for _, b := range breakdowns {
+		counts, err := t.breakdown(ctx, bucket, b.column, userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for period, c := range counts {
+			if i, ok := index[period]; ok {
+				b.assign(&periods[i], c)
+			}
+		}
+	}
+
+	return periods, nil
+}
+
+func (t *Tracker) breakdown(ctx context.Context, bucket, column, userID string, from, to time.Time) (map[string][]Count, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT `+bucket+` AS period, `+column+`, COUNT(*)
+		FROM api_usage
+		WHERE user_id = $1 AND date_bucket BETWEEN $2 AND $3 AND `+column+` IS NOT NULL
+		GROUP BY period, `+column, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string][]Count)
+	for rows.Next() {
+		var period time.Time
+		var c Count
+		if err := rows.Scan(&period, &c.Name, &c.Requests); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", column, err)
+		}
+		key := period.Format("2006-01-02")
+		counts[key] = append(counts[key], c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for key, c := range counts {
+		sort.Slice(c, func(i, j int) bool {
+			if c[i].Requests == c[j].Requests {
+				return c[i].Name < c[j].Name
+			}
+			return c[i].Requests > c[j].Requests
+		})
+		if len(c) > topN {
+			counts[key] = c[:topN]
+		}
+	}
+	return counts, nil
+}
+
+// ByModel returns usage per recommended model between from and to (inclusive)
+func (t *Tracker) ByModel(ctx context.Context, userID string, from, to time.Time) ([]ModelUsage, error) {
+	// Served by idx_usage_user_model
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT recommended_model, COUNT(*), COALESCE(SUM(tokens_estimated), 0),
+		       COALESCE(SUM(cost_usd), 0), COALESCE(AVG(response_time_ms), 0)
+		FROM api_usage
+		WHERE user_id = $1 AND date_bucket BETWEEN $2 AND $3 AND recommended_model IS NOT NULL
+		GROUP BY recommended_model
+		ORDER BY COUNT(*) DESC`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage by model: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []ModelUsage{}
+	total := 0
+	for rows.Next() {
+		var m ModelUsage
+		if err := rows.Scan(&m.Model, &m.Requests, &m.Tokens, &m.CostUSD, &m.AvgResponseMs); err != nil {
+			return nil, fmt.Errorf("failed to scan model usage: %w", err)
+		}
+		total += m.Requests
+		usage = append(usage, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range usage {
+		if total > 0 {
+			usage[i].Share = float64(usage[i].Requests) / float64(total)
+		}
+	}
+	return usage, nil
+}
+
+// KeyDuplicates is how much of one key's traffic repeated recent prompts
+type KeyDuplicates struct {
+	APIKeyID      string  `json:"api_key_id,omitempty"` // Empty for JWT requests without a key
+	Name          string  `json:"name,omitempty"`
+	Requests      int     `json:"requests"`
+	Duplicates    int     `json:"duplicates"`
+	NearDuplicate int     `json:"near_duplicates"`
+	DuplicateRate float64 `json:"duplicate_rate"`
+	// TopRepeats is how often the key's most repeated prompt was sent
+	TopRepeats int `json:"top_repeats"`
+}
+
+// DuplicatesByKey returns duplicate and near-duplicate prompt rates per API key
+// between from and to (inclusive), highest rate first
+func (t *Tracker) DuplicatesByKey(ctx context.Context, userID string, from, to time.Time) ([]KeyDuplicates, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		WITH per_key AS (
+			SELECT api_key_id, COUNT(*) AS requests,
+			       COUNT(*) FILTER (WHERE duplicate_kind = 'exact') AS exact,
+			       COUNT(*) FILTER (WHERE duplicate_kind = 'near') AS near
+			FROM api_usage
+			WHERE user_id = $1 AND date_bucket BETWEEN $2 AND $3 AND prompt_fingerprint IS NOT NULL
+			GROUP BY api_key_id
+		), repeats AS (
+			SELECT api_key_id, MAX(n) AS top_repeats
+			FROM (
+				SELECT api_key_id, COUNT(*) AS n
+				FROM api_usage
+				WHERE user_id = $1 AND date_bucket BETWEEN $2 AND $3 AND prompt_fingerprint IS NOT NULL
+				GROUP BY api_key_id, prompt_fingerprint
+			) f
+			GROUP BY api_key_id
+		)
+		SELECT COALESCE(p.api_key_id::text, ''), COALESCE(k.name, ''), p.requests, p.exact, p.near, COALESCE(r.top_repeats, 0)
+		FROM per_key p
+		LEFT JOIN repeats r ON r.api_key_id IS NOT DISTINCT FROM p.api_key_id
+		LEFT JOIN api_keys k ON k.id = p.api_key_id
+		ORDER BY (p.exact + p.near)::float / p.requests DESC, p.requests DESC`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate duplicate prompts: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []KeyDuplicates{}
+	for rows.Next() {
+		var k KeyDuplicates
+		if err := rows.Scan(&k.APIKeyID, &k.Name, &k.Requests, &k.Duplicates, &k.NearDuplicate, &k.TopRepeats); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate prompts: %w", err)
+		}
+		k.DuplicateRate = float64(k.Duplicates+k.NearDuplicate) / float64(k.Requests)
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// TagUsage aggregates usage for one value of a tag key
+type TagUsage struct {
+	Value         string  `json:"value"` // Empty for requests without the tag
+	Requests      int     `json:"requests"`
+	Tokens        int     `json:"tokens"`
+	CostUSD       float64 `json:"cost_usd"`
+	Errors        int     `json:"errors"`
+	AvgResponseMs float64 `json:"avg_response_ms"`
+	CostShare     float64 `json:"cost_share"`
+}
+
+// ByTag returns usage per value of the tag key between from and to
+// (inclusive), most expensive first, for chargeback across teams or features
+// sharing an API key
+func (t *Tracker) ByTag(ctx context.Context, userID, key string, from, to time.Time) ([]TagUsage, error) {
+	// Served by idx_usage_user_date
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT COALESCE(tags->>$4, '') AS value, COUNT(*), COALESCE(SUM(tokens_estimated), 0),
+		       COALESCE(SUM(cost_usd), 0), COUNT(*) FILTER (WHERE status_code >= 400),
+		       COALESCE(AVG(response_time_ms), 0)
+		FROM api_usage
+		WHERE user_id = $1 AND date_bucket BETWEEN $2 AND $3
+		GROUP BY value
+		ORDER BY SUM(cost_usd) DESC NULLS LAST, COUNT(*) DESC`, userID, from, to, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage by tag: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []TagUsage{}
+	total := 0.0
+	for rows.Next() {
+		var u TagUsage
+		if err := rows.Scan(&u.Value, &u.Requests, &u.Tokens, &u.CostUSD, &u.Errors, &u.AvgResponseMs); err != nil {
+			return nil, fmt.Errorf("failed to scan tag usage: %w", err)
+		}
+		total += u.CostUSD
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range usage {
+		if total > 0 {
+			usage[i].CostShare = usage[i].CostUSD / total
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,115 @@
+package usage
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagsHeader carries a request's usage tags as comma-separated key=value
+// pairs, e.g. "team=search, feature=autocomplete, env=prod"
+const TagsHeader = "X-Usage-Tags"
+
+// ContextTags holds the request's usage tags, a map[string]string
+const ContextTags = "usage_tags"
+
+// Bounds on usage tags, so they stay cheap to store and group by
+const (
+	maxTags        = 10
+	maxTagKeyLen   = 64
+	maxTagValueLen = 128
+)
+
+// tagKey matches the keys usage tags may use
+var tagKey = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// ValidateTagKey rejects keys that are not lowercase letters, digits, '_',
+// '.' and '-', or are too long
+func ValidateTagKey(key string) error {
+	if len(key) > maxTagKeyLen || !tagKey.MatchString(key) {
+		return fmt.Errorf("invalid tag key %q: use up to %d lowercase letters, digits, '_', '.' or '-'", key, maxTagKeyLen)
+	}
+	return nil
+}
+
+// ValidateTags checks a request's usage tags
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > maxTags {
+		return fmt.Errorf("at most %d usage tags are allowed, got %d", maxTags, len(tags))
+	}
+	for key, value := range tags {
+		if err := ValidateTagKey(key); err != nil {
+			return err
+		}
+		if value == "" || len(value) > maxTagValueLen {
+			return fmt.Errorf("tag %q must have a value of 1 to %d characters", key, maxTagValueLen)
+		}
+	}
+	return nil
+}
+
+// ParseTags reads the X-Usage-Tags header format
+func ParseTags(header string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("tag %q must be key=value", pair)
+		}
+		tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return tags, ValidateTags(tags)
+}
+
+// AddTags attributes the request to tags sent in its body. They are merged
+// over the header's, so a body tag wins over a header tag of the same key.
+func AddTags(c *gin.Context, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(tags))
+	for key, value := range requestTags(c) {
+		merged[key] = value
+	}
+	for key, value := range tags {
+		merged[key] = value
+	}
+	if err := ValidateTags(merged); err != nil {
+		return err
+	}
+	c.Set(ContextTags, merged)
+	return nil
+}
+
+// requestTags returns the tags the request is attributed to
+func requestTags(c *gin.Context) map[string]string {
+	value, _ := c.Get(ContextTags)
+	tags, _ := value.(map[string]string)
+	return tags
+}
+
+// readHeaderTags stores the header's tags on the request, answering 400 when
+// they are malformed; it reports whether the request may continue
+func readHeaderTags(c *gin.Context) bool {
+	header := c.GetHeader(TagsHeader)
+	if header == "" {
+		return true
+	}
+	tags, err := ParseTags(header)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid usage tags",
+			"details": err.Error(),
+		})
+		return false
+	}
+	c.Set(ContextTags, tags)
+	return true
+}
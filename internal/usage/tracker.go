@@ -0,0 +1,168 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Askeban/llm-router-go/internal/trace"
+)
+
+// Context keys handlers set so the middleware can attribute a request
+const (
+	ContextCategory = "usage_category"
+	ContextModel    = "usage_model"
+	ContextTokens   = "usage_tokens"
+	ContextCost     = "usage_cost"
+	ContextMetadata = "usage_metadata" // map[string]interface{} stored in api_usage.metadata
+
+	ContextFingerprint = "usage_fingerprint" // int64 prompt fingerprint
+	ContextDuplicate   = "usage_duplicate"   // "exact" or "near" when the key sent the prompt recently
+)
+
+// Record is a single API call to be stored in api_usage
+type Record struct {
+	UserID         string
+	APIKeyID       string
+	Endpoint       string
+	Method         string
+	Category       string
+	Model          string
+	Tokens         int
+	CostUSD        float64
+	ResponseTimeMs int
+	StatusCode     int
+	ErrorMessage   string
+	Metadata       map[string]interface{}
+	Tags           map[string]string // Caller-chosen attribution, e.g. {"team": "search"}
+	Fingerprint    int64             // Zero when the request carried no prompt
+	Duplicate      string            // Empty for a prompt the key had not sent recently
+}
+
+// Tracker writes per-request usage and serves dashboard aggregates
+type Tracker struct {
+	db *sql.DB
+}
+
+func NewTracker(db *sql.DB) *Tracker {
+	return &Tracker{db: db}
+}
+
+// Record stores a usage row and bumps the monthly summary
+func (t *Tracker) Record(ctx context.Context, r Record) error {
+	var apiKeyID interface{}
+	if r.APIKeyID != "" {
+		apiKeyID = r.APIKeyID
+	}
+
+	metadata := []byte("{}")
+	if len(r.Metadata) > 0 {
+		encoded, err := json.Marshal(r.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode usage metadata: %w", err)
+		}
+		metadata = encoded
+	}
+	tags := []byte("{}")
+	if len(r.Tags) > 0 {
+		encoded, err := json.Marshal(r.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to encode usage tags: %w", err)
+		}
+		tags = encoded
+	}
+
+	_, err := t.db.ExecContext(ctx, `
+		INSERT INTO api_usage (user_id, api_key_id, endpoint, method, prompt_category, recommended_model,
+		                       tokens_estimated, cost_usd, response_time_ms, status_code, error_message, metadata,
+		                       prompt_fingerprint, duplicate_kind, tags)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, NULLIF($11, ''), $12,
+		        NULLIF($13, 0), NULLIF($14, ''), $15)`,
+		r.UserID, apiKeyID, r.Endpoint, r.Method, r.Category, r.Model,
+		r.Tokens, r.CostUSD, r.ResponseTimeMs, r.StatusCode, r.ErrorMessage, metadata,
+		r.Fingerprint, r.Duplicate, tags)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+
+	_, err = t.db.ExecContext(ctx, `
+		INSERT INTO monthly_usage_summary (user_id, year_month, total_requests, total_tokens, last_updated)
+		VALUES ($1, $2, 1, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, year_month) DO UPDATE SET
+			total_requests = monthly_usage_summary.total_requests + 1,
+			total_tokens = monthly_usage_summary.total_tokens + EXCLUDED.total_tokens,
+			last_updated = CURRENT_TIMESTAMP`,
+		r.UserID, time.Now().Format("2006-01"), r.Tokens)
+	if err != nil {
+		return fmt.Errorf("failed to update monthly usage: %w", err)
+	}
+
+	return nil
+}
+
+// Middleware records every authenticated request after it completes.
+// Handlers attribute category, model, tokens and cost via the Context* keys.
+// Requests are tagged from the X-Usage-Tags header, and handlers add tags
+// sent in the body with AddTags.
+func (t *Tracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		if !readHeaderTags(c) {
+			return
+		}
+		c.Next()
+
+		userID := c.GetString("user_id")
+		if userID == "" || c.Request.Method == "OPTIONS" {
+			return
+		}
+
+		record := Record{
+			UserID:         userID,
+			APIKeyID:       c.GetString("api_key_id"),
+			Endpoint:       c.FullPath(),
+			Method:         c.Request.Method,
+			Category:       c.GetString(ContextCategory),
+			Model:          c.GetString(ContextModel),
+			Tokens:         c.GetInt(ContextTokens),
+			CostUSD:        c.GetFloat64(ContextCost),
+			ResponseTimeMs: int(time.Since(start).Milliseconds()),
+			StatusCode:     c.Writer.Status(),
+			Duplicate:      c.GetString(ContextDuplicate),
+			Tags:           requestTags(c),
+		}
+		if fp, ok := c.Get(ContextFingerprint); ok {
+			record.Fingerprint, _ = fp.(int64)
+		}
+		if record.Endpoint == "" {
+			record.Endpoint = c.Request.URL.Path
+		}
+		if len(c.Errors) > 0 {
+			record.ErrorMessage = c.Errors.String()
+		}
+		if metadata, ok := c.Get(ContextMetadata); ok {
+			record.Metadata, _ = metadata.(map[string]interface{})
+		}
+		// The request ID ties a usage row to the logs and audit entries of its request
+		if id := c.GetString("request_id"); id != "" {
+			if record.Metadata == nil {
+				record.Metadata = map[string]interface{}{}
+			}
+			record.Metadata["request_id"] = id
+		}
+
+		// Usage is recorded off the request path so slow writes never add latency
+		detached := trace.Detach(c.Request.Context())
+		go func() {
+			ctx, cancel := context.WithTimeout(detached, 5*time.Second)
+			defer cancel()
+			if err := t.Record(ctx, record); err != nil {
+				trace.Logf(ctx, "[USAGE] %v", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,77 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Vault seals secrets at rest using a libsodium-compatible sealed box
+// (X25519 + XSalsa20-Poly1305). Only the holder of the private key can open them.
+type Vault struct {
+	publicKey  [32]byte
+	privateKey [32]byte
+}
+
+// NewVault creates a vault from a base64-encoded 32-byte X25519 private key
+func NewVault(privateKeyB64 string) (*Vault, error) {
+	raw, err := base64.StdEncoding.DecodeString(privateKeyB64)
+	if err != nil {
+		return nil, fmt.Errorf("decode vault private key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("vault private key must be 32 bytes, got %d", len(raw))
+	}
+
+	v := &Vault{}
+	copy(v.privateKey[:], raw)
+
+	pub, err := curve25519.X25519(v.privateKey[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("derive vault public key: %w", err)
+	}
+	copy(v.publicKey[:], pub)
+
+	return v, nil
+}
+
+// NewVaultFromEnv creates a vault from the VAULT_PRIVATE_KEY environment variable
+func NewVaultFromEnv() (*Vault, error) {
+	key := os.Getenv("VAULT_PRIVATE_KEY")
+	if key == "" {
+		return nil, errors.New("VAULT_PRIVATE_KEY not set")
+	}
+	return NewVault(key)
+}
+
+// GenerateKey returns a new base64-encoded private key suitable for VAULT_PRIVATE_KEY
+func GenerateKey() (string, error) {
+	_, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv[:]), nil
+}
+
+// Seal encrypts plaintext so that only this vault can open it
+func (v *Vault) Seal(plaintext []byte) ([]byte, error) {
+	sealed, err := box.SealAnonymous(nil, plaintext, &v.publicKey, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("seal secret: %w", err)
+	}
+	return sealed, nil
+}
+
+// Open decrypts a value previously produced by Seal
+func (v *Vault) Open(sealed []byte) ([]byte, error) {
+	plaintext, ok := box.OpenAnonymous(nil, sealed, &v.publicKey, &v.privateKey)
+	if !ok {
+		return nil, errors.New("failed to open sealed secret")
+	}
+	return plaintext, nil
+}
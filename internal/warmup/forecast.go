@@ -0,0 +1,119 @@
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Askeban/llm-router-go/internal/providers"
+)
+
+// forecastTTL is how long an hourly forecast is used before it is rebuilt
+const forecastTTL = time.Hour
+
+// hoursPerWeek indexes a forecast by weekday and hour, in UTC
+const hoursPerWeek = 7 * 24
+
+// forecast is the average number of requests each model received in each
+// hour of the week over the usage history
+type forecast struct {
+	since   time.Time
+	builtAt time.Time
+	perHour map[string]*[hoursPerWeek]float64
+}
+
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// expected returns the requests the model is forecast to receive in the hour
+// containing t; zero without a forecast
+func (f *forecast) expected(modelID string, t time.Time) float64 {
+	if f == nil {
+		return 0
+	}
+	hours, ok := f.perHour[modelID]
+	if !ok {
+		return 0
+	}
+	return hours[hourOfWeek(t)]
+}
+
+// refreshForecast rebuilds the forecast of self-hosted models once it is older
+// than forecastTTL. Without a database no traffic is forecast, so models are
+// warmed only when Always is set.
+func (s *Scheduler) refreshForecast(ctx context.Context) {
+	s.mu.Lock()
+	fresh := s.forecast != nil && time.Since(s.forecast.builtAt) < forecastTTL
+	s.mu.Unlock()
+	if fresh || s.db == nil {
+		return
+	}
+
+	var ids []string
+	for _, model := range s.catalog() {
+		if providers.IsSelfHosted(model.Provider) {
+			ids = append(ids, model.ID)
+		}
+	}
+	f, err := s.loadForecast(ctx, ids)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		// Keep the last forecast and retry after forecastTTL rather than every tick
+		log.Printf("[WARMUP] %v", err)
+		if s.forecast == nil {
+			s.forecast = &forecast{perHour: map[string]*[hoursPerWeek]float64{}}
+		}
+		s.forecast.builtAt = time.Now()
+		return
+	}
+	s.forecast = f
+}
+
+func (s *Scheduler) loadForecast(ctx context.Context, modelIDs []string) (*forecast, error) {
+	now := time.Now()
+	f := &forecast{
+		since:   now.AddDate(0, 0, -s.cfg.HistoryDays),
+		builtAt: now,
+		perHour: make(map[string]*[hoursPerWeek]float64),
+	}
+	if len(modelIDs) == 0 {
+		return f, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT recommended_model,
+		       EXTRACT(DOW FROM timestamp AT TIME ZONE 'UTC')::int,
+		       EXTRACT(HOUR FROM timestamp AT TIME ZONE 'UTC')::int,
+		       COUNT(*)
+		FROM api_usage
+		WHERE timestamp >= $1 AND recommended_model = ANY($2)
+		GROUP BY 1, 2, 3`, f.since, pq.Array(modelIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load warm-up forecast: %w", err)
+	}
+	defer rows.Close()
+
+	weeks := float64(s.cfg.HistoryDays) / 7
+	for rows.Next() {
+		var model string
+		var weekday, hour int
+		var count int64
+		if err := rows.Scan(&model, &weekday, &hour, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan warm-up forecast: %w", err)
+		}
+		hours, ok := f.perHour[model]
+		if !ok {
+			hours = &[hoursPerWeek]float64{}
+			f.perHour[model] = hours
+		}
+		hours[weekday*24+hour] = float64(count) / weeks
+	}
+	return f, rows.Err()
+}
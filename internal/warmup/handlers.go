@@ -0,0 +1,46 @@
+package warmup
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers exposes the warm-up scheduler to admins
+type Handlers struct {
+	scheduler *Scheduler
+}
+
+func NewHandlers(scheduler *Scheduler) *Handlers {
+	return &Handlers{scheduler: scheduler}
+}
+
+// Report returns each self-hosted model's warm-up state and cold-start estimate
+func (h *Handlers) Report(c *gin.Context) {
+	cfg := h.scheduler.Config()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"report": h.scheduler.Report(),
+			"config": gin.H{
+				"interval_seconds":       cfg.Interval.Seconds(),
+				"keep_alive_seconds":     cfg.KeepAlive.Seconds(),
+				"lead_seconds":           cfg.Lead.Seconds(),
+				"min_predicted_requests": cfg.MinPredictedRequests,
+				"history_days":           cfg.HistoryDays,
+				"always":                 cfg.Always,
+			},
+		},
+	})
+}
+
+// Run warms every self-hosted catalog model now, e.g. after a backend restart
+func (h *Handlers) Run(c *gin.Context) {
+	started := h.scheduler.Run(context.Background(), true)
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Warm-up started",
+		"data":    gin.H{"models": started},
+	})
+}
@@ -0,0 +1,316 @@
+package warmup
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Askeban/llm-router-go/internal/models"
+	"github.com/Askeban/llm-router-go/internal/providers"
+)
+
+// Config holds the warm-up cadence and forecast thresholds
+type Config struct {
+	Interval             time.Duration // How often models are checked
+	KeepAlive            time.Duration // How long a backend keeps an idle model loaded
+	Lead                 time.Duration // How far ahead predicted traffic warms a model
+	MinPredictedRequests float64       // Requests expected in an hour that make a model worth keeping loaded
+	HistoryDays          int           // Usage history the hourly forecast is built from
+	Always               bool          // Keep every self-hosted model loaded, whatever the forecast
+	ColdStart            time.Duration // Assumed load time until warm-ups have measured one
+	PingTimeout          time.Duration // Upper bound of one warm-up request
+}
+
+// DefaultConfig returns the built-in cadence; WARMUP_KEEP_ALIVE, WARMUP_LEAD,
+// WARMUP_MIN_PREDICTED, WARMUP_ALWAYS and WARMUP_COLD_START override it
+func DefaultConfig() Config {
+	cfg := Config{
+		Interval:             30 * time.Second,
+		KeepAlive:            5 * time.Minute, // Ollama's default keep_alive
+		Lead:                 10 * time.Minute,
+		MinPredictedRequests: 1,
+		HistoryDays:          28,
+		ColdStart:            20 * time.Second,
+		PingTimeout:          5 * time.Minute,
+	}
+	if d, err := time.ParseDuration(os.Getenv("WARMUP_KEEP_ALIVE")); err == nil && d > time.Minute {
+		cfg.KeepAlive = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("WARMUP_LEAD")); err == nil && d >= 0 {
+		cfg.Lead = d
+	}
+	if n, err := strconv.ParseFloat(os.Getenv("WARMUP_MIN_PREDICTED"), 64); err == nil && n >= 0 {
+		cfg.MinPredictedRequests = n
+	}
+	if always, err := strconv.ParseBool(os.Getenv("WARMUP_ALWAYS")); err == nil {
+		cfg.Always = always
+	}
+	if d, err := time.ParseDuration(os.Getenv("WARMUP_COLD_START")); err == nil && d > 0 {
+		cfg.ColdStart = d
+	}
+	return cfg
+}
+
+// Pinger sends a minimal request that makes a backend load a model
+type Pinger interface {
+	Warm(ctx context.Context, model models.EnhancedModel) (time.Duration, error)
+}
+
+// ewmaAlpha weights the latest warm-up duration in the running averages
+const ewmaAlpha = 0.3
+
+// modelState is what this replica knows about one self-hosted model
+type modelState struct {
+	provider     string
+	lastActive   time.Time // Last call or warm-up the model answered
+	lastWarmup   time.Time
+	lastWarmupMs float64
+	lastError    string
+	warmups      int64
+	coldWarmups  int64
+	warming      bool
+}
+
+// Scheduler keeps self-hosted models loaded: it pings them before the backend
+// unloads them when traffic is expected, and estimates how likely a request
+// is to wait for a model load
+type Scheduler struct {
+	db      *sql.DB
+	pinger  Pinger
+	catalog func() []models.EnhancedModel
+	cfg     Config
+
+	mu       sync.Mutex
+	states   map[string]*modelState
+	forecast *forecast
+	coldMs   float64 // Average warm-up duration after the keep-alive expired
+	warmMs   float64 // Average warm-up duration of a loaded model
+}
+
+func NewScheduler(db *sql.DB, pinger Pinger, catalog func() []models.EnhancedModel, cfg Config) *Scheduler {
+	return &Scheduler{db: db, pinger: pinger, catalog: catalog, cfg: cfg, states: make(map[string]*modelState)}
+}
+
+// Config returns the scheduler's cadence and thresholds
+func (s *Scheduler) Config() Config {
+	return s.cfg
+}
+
+// Start checks models on the configured interval until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Run(ctx, false)
+			}
+		}
+	}()
+}
+
+// ModelCalled records that a model answered, so it is known to be loaded
+func (s *Scheduler) ModelCalled(provider, modelID string) {
+	if !providers.IsSelfHosted(provider) {
+		return
+	}
+	s.mu.Lock()
+	s.stateFor(provider, modelID).lastActive = time.Now()
+	s.mu.Unlock()
+}
+
+// ColdStart estimates the chance a request to the model waits for it to load,
+// and the load time. A model that answered within the keep-alive is loaded;
+// otherwise it is loaded only if another replica served it since, which the
+// forecast request rate makes likely or not.
+func (s *Scheduler) ColdStart(model models.EnhancedModel) (float64, float64) {
+	if !providers.IsSelfHosted(model.Provider) {
+		return 0, 0
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.states[model.ID]; ok && now.Sub(st.lastActive) < s.cfg.KeepAlive {
+		return 0, 0
+	}
+	perHour := s.forecast.expected(model.ID, now)
+	probability := math.Exp(-perHour * s.cfg.KeepAlive.Hours())
+	return probability, s.loadMs()
+}
+
+// loadMs is the measured extra time of a cold warm-up over a warm one, or the
+// configured assumption before both have been measured
+func (s *Scheduler) loadMs() float64 {
+	if s.coldMs > 0 && s.warmMs > 0 && s.coldMs > s.warmMs {
+		return s.coldMs - s.warmMs
+	}
+	return float64(s.cfg.ColdStart.Milliseconds())
+}
+
+func (s *Scheduler) stateFor(provider, modelID string) *modelState {
+	st, ok := s.states[modelID]
+	if !ok {
+		st = &modelState{provider: providers.NormalizeProvider(provider)}
+		s.states[modelID] = st
+	}
+	return st
+}
+
+// Run warms the self-hosted catalog models that are due, or all of them when
+// force is set, and returns how many warm-ups it started
+func (s *Scheduler) Run(ctx context.Context, force bool) int {
+	s.refreshForecast(ctx)
+
+	now := time.Now()
+	started := 0
+	for _, model := range s.catalog() {
+		if !providers.IsSelfHosted(model.Provider) {
+			continue
+		}
+
+		s.mu.Lock()
+		st := s.stateFor(model.Provider, model.ID)
+		due := !st.warming && (force || s.due(model.ID, st, now))
+		if due {
+			st.warming = true
+		}
+		s.mu.Unlock()
+
+		if due {
+			started++
+			go s.warm(ctx, model)
+		}
+	}
+	return started
+}
+
+// due reports whether a model would be unloaded before the next check while
+// traffic is expected for it
+func (s *Scheduler) due(modelID string, st *modelState, now time.Time) bool {
+	if now.Sub(st.lastActive) < s.cfg.KeepAlive-2*s.cfg.Interval {
+		return false
+	}
+	if s.cfg.Always {
+		return true
+	}
+	// Warm ahead of a busy hour, and keep the model loaded through it
+	expected := math.Max(s.forecast.expected(modelID, now), s.forecast.expected(modelID, now.Add(s.cfg.Lead)))
+	return expected > 0 && expected >= s.cfg.MinPredictedRequests
+}
+
+// warm pings one model and records how long it took to answer
+func (s *Scheduler) warm(ctx context.Context, model models.EnhancedModel) {
+	s.mu.Lock()
+	previous := s.states[model.ID].lastActive
+	s.mu.Unlock()
+
+	pingCtx, cancel := context.WithTimeout(ctx, s.cfg.PingTimeout)
+	took, err := s.pinger.Warm(pingCtx, model)
+	cancel()
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.states[model.ID]
+	st.warming = false
+	st.lastWarmup = now
+	if err != nil {
+		st.lastError = err.Error()
+		log.Printf("[WARMUP] %s (%s) failed: %v", model.ID, st.provider, err)
+		return
+	}
+	st.lastError = ""
+	st.warmups++
+	st.lastWarmupMs = float64(took.Milliseconds())
+	st.lastActive = now
+
+	// A model idle past the keep-alive was most likely unloaded
+	if previous.IsZero() || now.Add(-took).Sub(previous) > s.cfg.KeepAlive {
+		st.coldWarmups++
+		s.coldMs = ewma(s.coldMs, st.lastWarmupMs)
+	} else {
+		s.warmMs = ewma(s.warmMs, st.lastWarmupMs)
+	}
+}
+
+func ewma(avg, sample float64) float64 {
+	if avg == 0 {
+		return sample
+	}
+	return avg + ewmaAlpha*(sample-avg)
+}
+
+// ModelStats is the warm-up state of one self-hosted model on this replica
+type ModelStats struct {
+	ModelID              string     `json:"model_id"`
+	Provider             string     `json:"provider"`
+	LastActive           *time.Time `json:"last_active,omitempty"`
+	LastWarmup           *time.Time `json:"last_warmup,omitempty"`
+	LastWarmupMs         float64    `json:"last_warmup_ms,omitempty"`
+	LastError            string     `json:"last_error,omitempty"`
+	Warmups              int64      `json:"warmups"`
+	ColdWarmups          int64      `json:"cold_warmups"`
+	PredictedRequests    float64    `json:"predicted_requests_per_hour"`
+	ColdStartProbability float64    `json:"cold_start_probability"`
+}
+
+// Report is the scheduler's view of every self-hosted model it has seen
+type Report struct {
+	Models        []ModelStats `json:"models"`
+	ColdStartMs   float64      `json:"cold_start_ms"`
+	ColdWarmupMs  float64      `json:"cold_warmup_ms,omitempty"`
+	WarmWarmupMs  float64      `json:"warm_warmup_ms,omitempty"`
+	ForecastSince *time.Time   `json:"forecast_since,omitempty"`
+}
+
+// Report returns every model's warm-up state, sorted by model ID
+func (s *Scheduler) Report() Report {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := Report{Models: make([]ModelStats, 0, len(s.states)), ColdStartMs: s.loadMs(),
+		ColdWarmupMs: s.coldMs, WarmWarmupMs: s.warmMs}
+	if s.forecast != nil {
+		since := s.forecast.since
+		report.ForecastSince = &since
+	}
+	for id, st := range s.states {
+		ms := ModelStats{
+			ModelID:           id,
+			Provider:          st.provider,
+			LastWarmupMs:      st.lastWarmupMs,
+			LastError:         st.lastError,
+			Warmups:           st.warmups,
+			ColdWarmups:       st.coldWarmups,
+			PredictedRequests: s.forecast.expected(id, now),
+		}
+		if !st.lastActive.IsZero() {
+			t := st.lastActive
+			ms.LastActive = &t
+		}
+		if !st.lastWarmup.IsZero() {
+			t := st.lastWarmup
+			ms.LastWarmup = &t
+		}
+		if now.Sub(st.lastActive) >= s.cfg.KeepAlive {
+			ms.ColdStartProbability = math.Exp(-ms.PredictedRequests * s.cfg.KeepAlive.Hours())
+		}
+		report.Models = append(report.Models, ms)
+	}
+	sort.Slice(report.Models, func(i, j int) bool {
+		return report.Models[i].ModelID < report.Models[j].ModelID
+	})
+	return report
+}
@@ -72,7 +72,7 @@ fi
 
 # Build Go application
 echo "🔨 Building Go application..."
-go build -o router cmd/server/main.go
+go build -o router ./cmd/router
 if [ $? -eq 0 ]; then
     echo "✅ Go application built successfully"
 else